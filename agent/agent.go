@@ -43,9 +43,11 @@ type Agent struct {
 	// applies to EC2 hosts.
 	ec2InstanceID string
 	endTaskResp   *TriggerEndTaskResp
-	tracer        trace.Tracer
-	otelGrpcConn  *grpc.ClientConn
-	closers       []closerOp
+	// localAPI is the local API for the running task's processes.
+	localAPI     localTaskAPI
+	tracer       trace.Tracer
+	otelGrpcConn *grpc.ClientConn
+	closers      []closerOp
 }
 
 // Options contains startup options for an Agent.
@@ -83,6 +85,10 @@ const (
 
 	endTaskMessageLimit = 500
 
+	// minFreeDiskSpaceBytes is the amount of free disk space below which a
+	// command is considered to have run out of disk space.
+	minFreeDiskSpaceBytes = 100 * 1024 * 1024
+
 	taskTimeoutBlock   = "timeout"
 	preBlock           = "pre"
 	setupTaskBlock     = "setup_task"
@@ -119,6 +125,8 @@ type taskContext struct {
 	project                   *model.Project
 	taskModel                 *task.Task
 	oomTracker                jasper.OOMTracker
+	failureRecorded           bool
+	failedWithDiskFull        bool
 	traceID                   string
 	unsetFunctionVarsDisabled bool
 	sync.RWMutex
@@ -487,14 +495,19 @@ func (a *Agent) fetchProjectConfig(ctx context.Context, tc *taskContext) error {
 		return errors.Wrap(err, "getting expansions and variables")
 	}
 
-	// GetExpansionsAndVars does not include build variant expansions or project
-	// parameters, so load them from the project.
+	// GetExpansionsAndVars does not include build variant expansions, task
+	// matrix expansions or project parameters, so load them from the project.
 	for _, bv := range project.BuildVariants {
 		if bv.Name == taskModel.BuildVariant {
 			expAndVars.Expansions.Update(bv.Expansions)
 			break
 		}
 	}
+	// Tasks generated from a task matrix have their parameter values as
+	// expansions.
+	if pt := project.FindProjectTask(taskModel.DisplayName); pt != nil {
+		expAndVars.Expansions.Update(pt.Expansions)
+	}
 	expAndVars.Expansions.Update(expAndVars.Vars)
 	for _, param := range project.Parameters {
 		// If the key doesn't exist, the value will default to "" anyway; this
@@ -597,11 +610,23 @@ func (a *Agent) runTask(ctx context.Context, tc *taskContext) (shouldExit bool,
 	tc.taskConfig.WorkDir = tc.taskDirectory
 	tc.taskConfig.Expansions.Put("workdir", tc.taskConfig.WorkDir)
 
+	if err = a.setupShardTests(tskCtx, tc); err != nil {
+		err = errors.Wrap(err, "setting up shard tests")
+		grip.Error(err)
+		grip.Infof("Task complete: '%s'.", tc.task.ID)
+		tc.logger.Execution().Error(err)
+		return a.handleTaskResponse(tskCtx, tc, evergreen.TaskSystemFailed, err.Error())
+	}
+
 	grip.Info(message.Fields{
 		"message": "running_task",
 		"task_id": tc.task.ID,
 	})
 
+	tc.taskConfig.LocalAPIURL = fmt.Sprintf("http://127.0.0.1:%d%s", a.opts.StatusPort, localAPIPrefix)
+	tc.taskConfig.LocalAPIToken = a.localAPI.setTask(a.comm, tc)
+	defer a.localAPI.clearTask()
+
 	defer a.killProcs(ctx, tc, false, "task is finished")
 
 	tskCtx = utility.ContextWithAttributes(tskCtx, tc.taskConfig.TaskAttributes())
@@ -619,6 +644,10 @@ func (a *Agent) runTask(ctx context.Context, tc *taskContext) (shouldExit bool,
 		tc.logger.Execution().Error(errors.Wrap(a.uploadTraces(tskCtx, tc.taskConfig.WorkDir), "uploading traces"))
 	}()
 
+	// The resource usage collector runs until the task is finished so that
+	// it also samples the timeout and post blocks.
+	newResourceUsageCollector(a.comm, tc.logger, tc.task, tc.taskConfig.Task.Execution, tc.taskConfig.Redactor).start(tskCtx)
+
 	idleTimeoutCtx, idleTimeoutCancel := context.WithCancel(tskCtx)
 	go a.startIdleTimeoutWatch(tskCtx, tc, idleTimeoutCancel)
 
@@ -933,6 +962,9 @@ func (a *Agent) endTaskResponse(ctx context.Context, tc *taskContext, status str
 		Message:         message,
 		TraceID:         tc.traceID,
 	}
+	if detail.Status == evergreen.TaskFailed {
+		detail.DiskFull = tc.ranOutOfDiskSpace()
+	}
 	setEndTaskCommand(tc, detail, description, failureType)
 	if tc.taskConfig != nil {
 		detail.Modules.Prefixes = tc.taskConfig.ModulePaths
@@ -125,6 +125,8 @@ func (a *Agent) runCommandOrFunc(ctx context.Context, tc *taskContext, commandIn
 			continue
 		}
 
+		a.localAPI.applyExpansions(tc)
+
 		tc.logger.Task().Infof("Running command %s.", displayName)
 
 		ctx, commandSpan := a.tracer.Start(ctx, cmd.Name(), trace.WithAttributes(
@@ -177,10 +179,19 @@ func (a *Agent) runCommand(ctx context.Context, tc *taskContext, logger client.L
 		}
 	}()
 
+	command.RegisterSecretParams(cmd, tc.taskConfig)
 	tc.setCurrentCommand(cmd)
 	tc.setCurrentIdleTimeout(cmd)
 	a.comm.UpdateLastMessageTime()
 
+	var diskWatch *diskSpaceWatch
+	if options.isTaskCommands || options.failPreAndPost {
+		diskWatch = startDiskSpaceWatch(ctx, diskSpaceCheckInterval, func(ctx context.Context) bool {
+			return a.isDiskFull(ctx, tc)
+		})
+	}
+	defer diskWatch.stop(nil)
+
 	start := time.Now()
 	// This method must return soon after the context errors (e.g. due to
 	// aborting the task). Even though commands ought to respect the context and
@@ -211,6 +222,7 @@ func (a *Agent) runCommand(ctx context.Context, tc *taskContext, logger client.L
 			if options.isTaskCommands || options.failPreAndPost ||
 				(cmd.Name() == "git.get_project" && tc.taskModel.Requester == evergreen.MergeTestRequester) {
 				// any git.get_project in the commit queue should fail
+				tc.recordCommandFailure(diskWatch.stop(err))
 				return errors.Wrap(err, "command failed")
 			}
 		}
@@ -230,6 +242,7 @@ func (a *Agent) runCommand(ctx context.Context, tc *taskContext, logger client.L
 		} else {
 			tc.logger.Task().Errorf("Command %s stopped early: %s.", displayName, ctx.Err())
 		}
+		tc.recordCommandFailure(diskWatch.stop(ctx.Err()))
 		return errors.Wrap(ctx.Err(), "agent stopped early")
 	}
 	tc.logger.Task().Infof("Finished command %s in %s.", displayName, time.Since(start).String())
@@ -7,6 +7,7 @@ import (
 
 	"github.com/evergreen-ci/evergreen/agent/internal"
 	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mholt/archiver"
 	"github.com/mitchellh/mapstructure"
@@ -62,6 +63,15 @@ func (e *autoExtract) Execute(ctx context.Context,
 		return errors.Errorf("archive '%s' does not exist", e.ArchivePath)
 	}
 
+	// The archiver library doesn't support zstd, so zstd-compressed tar
+	// archives are extracted separately.
+	if isZstdTarArchive(e.ArchivePath) {
+		if err := e.extractZstdTar(ctx); err != nil {
+			return errors.Wrapf(err, "extracting archive '%s'", e.ArchivePath)
+		}
+		return nil
+	}
+
 	unzipper := archiver.MatchingFormat(e.ArchivePath)
 	if unzipper == nil {
 		return errors.Errorf("could not detect archive format for archive '%s'", e.ArchivePath)
@@ -73,3 +83,23 @@ func (e *autoExtract) Execute(ctx context.Context,
 
 	return nil
 }
+
+// isZstdTarArchive returns whether the archive is a zstd-compressed tar
+// archive based on its file extension or contents.
+func isZstdTarArchive(path string) bool {
+	if compression, ok := agentutil.TarCompressionFromFileName(path); ok {
+		return compression == agentutil.TarCompressionZstd
+	}
+	compression, err := agentutil.DetectTarCompressionForFile(path)
+	return err == nil && compression == agentutil.TarCompressionZstd
+}
+
+func (e *autoExtract) extractZstdTar(ctx context.Context) error {
+	archive, err := os.Open(e.ArchivePath)
+	if err != nil {
+		return errors.Wrapf(err, "opening file '%s'", e.ArchivePath)
+	}
+	defer archive.Close()
+
+	return agentutil.ExtractTar(ctx, archive, e.TargetDirectory, nil, agentutil.TarCompressionZstd)
+}
@@ -152,3 +152,25 @@ func (s *AutoExtractSuite) TestExtractionTarWorkingCase() {
 	s.NoError(err)
 	s.True(counter > 1)
 }
+
+func (s *AutoExtractSuite) TestExtractionZstdTarWorkingCase() {
+	s.conf.WorkDir = "/srv/evergreen"
+	s.cmd.TargetDirectory = s.targetLocation
+	s.cmd.ArchivePath = filepath.Join(testutil.GetDirectoryOfFile(),
+		"testdata", "archive", "artifacts.tar.zst")
+
+	s.NoError(s.cmd.Execute(context.Background(), s.comm, s.logger, s.conf))
+	s.FileExists(filepath.Join(s.targetLocation, "artifacts", "dir1", "dir2", "testfile.txt"))
+}
+
+func (s *AutoExtractSuite) TestExtractionZstdTarDetectedFromContents() {
+	contents, err := os.ReadFile(filepath.Join(testutil.GetDirectoryOfFile(),
+		"testdata", "archive", "artifacts.tar.zst"))
+	s.Require().NoError(err)
+	s.cmd.ArchivePath = filepath.Join(s.T().TempDir(), "artifacts")
+	s.Require().NoError(os.WriteFile(s.cmd.ArchivePath, contents, 0644))
+	s.cmd.TargetDirectory = s.targetLocation
+
+	s.NoError(s.cmd.Execute(context.Background(), s.comm, s.logger, s.conf))
+	s.FileExists(filepath.Join(s.targetLocation, "artifacts", "dir1", "dir2", "testfile.txt"))
+}
@@ -0,0 +1,144 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// tarCreate is a command to create a tar archive with a choice of compression.
+// Unlike archive.targz_pack, it compresses with multiple cores.
+type tarCreate struct {
+	// Target is the archive file that will be created.
+	Target string `mapstructure:"target" plugin:"expand"`
+
+	// SourceDir is the directory to archive.
+	SourceDir string `mapstructure:"source_dir" plugin:"expand"`
+
+	// Include is a list of filename blobs to include,
+	// e.g. "*.tgz", "file.txt", "test_*"
+	Include []string `mapstructure:"include" plugin:"expand"`
+
+	// ExcludeFiles is a list of filename blobs to exclude,
+	// e.g. "*.zip", "results.out", "ignore/**"
+	ExcludeFiles []string `mapstructure:"exclude_files" plugin:"expand"`
+
+	// Compression is the compression format, which is one of "gzip", "zstd",
+	// "xz" or "none". If it's not set, it's inferred from the target's file
+	// extension.
+	Compression string `mapstructure:"compression" plugin:"expand"`
+
+	// CompressionLevel is the compression level. If it's not set, the
+	// format's default level is used.
+	CompressionLevel int `mapstructure:"compression_level"`
+
+	// Concurrency is how many cores to compress with. It defaults to all of
+	// them.
+	Concurrency int `mapstructure:"concurrency"`
+
+	base
+}
+
+func tarCreateFactory() Command   { return &tarCreate{} }
+func (c *tarCreate) Name() string { return "archive.tar_pack" }
+
+// ParseParams reads in the given parameters for the command.
+func (c *tarCreate) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, c); err != nil {
+		return errors.Wrap(err, "decoding mapstructure params")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(c.Target == "", "target cannot be blank")
+	catcher.NewWhen(c.SourceDir == "", "source directory cannot be blank")
+	catcher.NewWhen(len(c.Include) == 0, "include cannot be empty")
+	catcher.NewWhen(c.CompressionLevel < 0, "compression level cannot be negative")
+	catcher.NewWhen(c.Concurrency < 0, "concurrency cannot be negative")
+
+	return catcher.Resolve()
+}
+
+// Execute builds the archive.
+func (c *tarCreate) Execute(ctx context.Context,
+	client client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+
+	if err := util.ExpandValues(c, conf.Expansions); err != nil {
+		return errors.Wrap(err, "applying expansions")
+	}
+
+	// if the source dir is a relative path, join it to the working dir
+	if !filepath.IsAbs(c.SourceDir) {
+		c.SourceDir = getJoinedWithWorkDir(conf, c.SourceDir)
+	}
+
+	// if the target is a relative path, join it to the working dir
+	if !filepath.IsAbs(c.Target) {
+		c.Target = getJoinedWithWorkDir(conf, c.Target)
+	}
+
+	opts, err := c.compressionOptions()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	filesArchived, err := c.makeArchive(ctx, opts, logger.Execution())
+	if err != nil {
+		return errors.Wrapf(err, "making archive '%s'", c.Target)
+	}
+	if filesArchived == 0 {
+		logger.Execution().Infof("No files matched the include patterns, removing empty archive '%s'.", c.Target)
+		logger.Execution().Info(errors.Wrap(os.Remove(c.Target), "deleting empty archive"))
+		return nil
+	}
+	logger.Task().Infof("Packed %d file(s) into archive '%s' with compression '%s'.", filesArchived, c.Target, opts.Compression)
+
+	return nil
+}
+
+// compressionOptions returns the options to compress the archive with.
+func (c *tarCreate) compressionOptions() (agentutil.TarCompressionOptions, error) {
+	compression := agentutil.TarCompression(c.Compression)
+	if compression == "" {
+		var ok bool
+		compression, ok = agentutil.TarCompressionFromFileName(c.Target)
+		if !ok {
+			return agentutil.TarCompressionOptions{}, errors.Errorf("cannot infer compression from target '%s', must specify compression", c.Target)
+		}
+	}
+
+	opts := agentutil.TarCompressionOptions{
+		Compression: compression,
+		Level:       c.CompressionLevel,
+		Concurrency: c.Concurrency,
+	}
+	return opts, errors.Wrap(opts.Validate(), "invalid compression options")
+}
+
+// makeArchive builds the archive and returns the number of files included in
+// it (0 means empty archive).
+func (c *tarCreate) makeArchive(ctx context.Context, opts agentutil.TarCompressionOptions, logger grip.Journaler) (int, error) {
+	f, compressor, tarWriter, err := agentutil.TarWriter(c.Target, opts)
+	if err != nil {
+		return -1, errors.Wrapf(err, "opening target archive file '%s'", c.Target)
+	}
+
+	out, err := agentutil.BuildArchive(ctx, tarWriter, c.SourceDir, c.Include, c.ExcludeFiles, logger)
+
+	// The writers must be closed in order, and their errors are not ignored
+	// since they flush the end of the archive.
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(err)
+	catcher.Wrap(tarWriter.Close(), "closing tar writer")
+	catcher.Wrap(compressor.Close(), "closing compressor")
+	catcher.Wrap(f.Close(), "closing archive file")
+
+	return out, catcher.Resolve()
+}
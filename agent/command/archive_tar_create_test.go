@@ -0,0 +1,145 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarPackParseParams(t *testing.T) {
+	for tName, tCase := range map[string]struct {
+		params  map[string]interface{}
+		isValid bool
+	}{
+		"MissingTarget": {
+			params: map[string]interface{}{"source_dir": "s", "include": []string{"i"}},
+		},
+		"MissingSourceDir": {
+			params: map[string]interface{}{"target": "t", "include": []string{"i"}},
+		},
+		"EmptyInclude": {
+			params: map[string]interface{}{"target": "t", "source_dir": "s"},
+		},
+		"NegativeConcurrency": {
+			params: map[string]interface{}{"target": "t", "source_dir": "s", "include": []string{"i"}, "concurrency": -1},
+		},
+		"ValidParams": {
+			params: map[string]interface{}{
+				"target":            "t",
+				"source_dir":        "s",
+				"include":           []string{"i", "j"},
+				"exclude_files":     []string{"e"},
+				"compression":       "zstd",
+				"compression_level": 3,
+				"concurrency":       4,
+			},
+			isValid: true,
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			cmd := &tarCreate{}
+			err := cmd.ParseParams(tCase.params)
+			if tCase.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTarPackExecute(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	comm := client.NewMock("http://localhost.com")
+	conf := &internal.TaskConfig{Expansions: &util.Expansions{}, Task: &task.Task{}, Project: &model.Project{}}
+	logger, err := comm.GetLoggerProducer(ctx, client.TaskData{ID: conf.Task.Id, Secret: conf.Task.Secret}, nil)
+	require.NoError(t, err)
+	testDataDir := filepath.Join(testutil.GetDirectoryOfFile(), "testdata", "archive")
+
+	checkArchive := func(t *testing.T, target string, compression agentutil.TarCompression) {
+		detected, err := agentutil.DetectTarCompressionForFile(target)
+		require.NoError(t, err)
+		assert.Equal(t, compression, detected)
+
+		archive, err := os.Open(target)
+		require.NoError(t, err)
+		defer archive.Close()
+		outputDir := t.TempDir()
+		require.NoError(t, agentutil.ExtractTar(ctx, archive, outputDir, nil, compression))
+		assert.True(t, utility.FileExists(filepath.Join(outputDir, "targz_me", "dir1", "dir2", "testfile.txt")))
+		assert.False(t, utility.FileExists(filepath.Join(outputDir, "targz_me", "dir1", "dir2", "test.pdb")))
+	}
+
+	for tName, tCase := range map[string]func(t *testing.T, cmd *tarCreate){
+		"InfersCompressionFromTarget": func(t *testing.T, cmd *tarCreate) {
+			for name, compression := range map[string]agentutil.TarCompression{
+				"archive.tgz":     agentutil.TarCompressionGzip,
+				"archive.tar.zst": agentutil.TarCompressionZstd,
+				"archive.tar.xz":  agentutil.TarCompressionXz,
+				"archive.tar":     agentutil.TarCompressionNone,
+			} {
+				cmd.Target = filepath.Join(t.TempDir(), name)
+				require.NoError(t, cmd.Execute(ctx, comm, logger, conf), name)
+				checkArchive(t, cmd.Target, compression)
+			}
+		},
+		"UsesExplicitCompression": func(t *testing.T, cmd *tarCreate) {
+			cmd.Target = filepath.Join(t.TempDir(), "archive.out")
+			cmd.Compression = "zstd"
+			cmd.CompressionLevel = 10
+			cmd.Concurrency = 2
+			require.NoError(t, cmd.Execute(ctx, comm, logger, conf))
+			checkArchive(t, cmd.Target, agentutil.TarCompressionZstd)
+		},
+		"ExpandsCompression": func(t *testing.T, cmd *tarCreate) {
+			conf.Expansions.Put("compression", "xz")
+			cmd.Target = filepath.Join(t.TempDir(), "archive.out")
+			cmd.Compression = "${compression}"
+			require.NoError(t, cmd.Execute(ctx, comm, logger, conf))
+			checkArchive(t, cmd.Target, agentutil.TarCompressionXz)
+		},
+		"FailsWithoutInferrableCompression": func(t *testing.T, cmd *tarCreate) {
+			cmd.Target = filepath.Join(t.TempDir(), "archive.out")
+			assert.Error(t, cmd.Execute(ctx, comm, logger, conf))
+			assert.False(t, utility.FileExists(cmd.Target))
+		},
+		"FailsWithInvalidCompression": func(t *testing.T, cmd *tarCreate) {
+			cmd.Target = filepath.Join(t.TempDir(), "archive.out")
+			cmd.Compression = "bzip2"
+			assert.Error(t, cmd.Execute(ctx, comm, logger, conf))
+		},
+		"FailsWithUnsupportedCompressionLevel": func(t *testing.T, cmd *tarCreate) {
+			cmd.Target = filepath.Join(t.TempDir(), "archive.tar.xz")
+			cmd.CompressionLevel = 5
+			assert.Error(t, cmd.Execute(ctx, comm, logger, conf))
+		},
+		"RemovesEmptyArchive": func(t *testing.T, cmd *tarCreate) {
+			cmd.Target = filepath.Join(t.TempDir(), "archive.tar.zst")
+			cmd.Include = []string{"nonexistent/**"}
+			require.NoError(t, cmd.Execute(ctx, comm, logger, conf))
+			assert.False(t, utility.FileExists(cmd.Target))
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			cmd := &tarCreate{
+				SourceDir:    testDataDir,
+				Include:      []string{"targz_me/dir1/**"},
+				ExcludeFiles: []string{"*.pdb"},
+			}
+			tCase(t, cmd)
+		})
+	}
+}
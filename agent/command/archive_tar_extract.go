@@ -0,0 +1,99 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// tarExtract is a command to extract a tar archive with a choice of
+// compression.
+type tarExtract struct {
+	ArchivePath string `mapstructure:"path" plugin:"expand"`
+
+	TargetDirectory string `mapstructure:"destination" plugin:"expand"`
+
+	// a list of filename blobs to exclude when extracting
+	ExcludeFiles []string `mapstructure:"exclude_files" plugin:"expand"`
+
+	// Compression is the compression format, which is one of "gzip", "zstd",
+	// "xz" or "none". If it's not set, it's detected from the archive's
+	// contents.
+	Compression string `mapstructure:"compression" plugin:"expand"`
+
+	base
+}
+
+func tarExtractFactory() Command   { return &tarExtract{} }
+func (e *tarExtract) Name() string { return "archive.tar_extract" }
+func (e *tarExtract) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, e); err != nil {
+		return errors.Wrap(err, "decoding mapstructure params")
+	}
+
+	if e.ArchivePath == "" {
+		return errors.New("archive path must be specified")
+	}
+
+	return nil
+}
+
+func (e *tarExtract) Execute(ctx context.Context,
+	client client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+
+	if err := util.ExpandValues(e, conf.Expansions); err != nil {
+		return errors.Wrap(err, "applying expansions")
+	}
+
+	if e.TargetDirectory == "" {
+		return errors.New("must specify a target directory")
+	}
+
+	// if the target is a relative path, join it to the working dir
+	if !filepath.IsAbs(e.TargetDirectory) {
+		e.TargetDirectory = getJoinedWithWorkDir(conf, e.TargetDirectory)
+	}
+
+	if !filepath.IsAbs(e.ArchivePath) {
+		e.ArchivePath = getJoinedWithWorkDir(conf, e.ArchivePath)
+	}
+
+	if _, err := os.Stat(e.ArchivePath); os.IsNotExist(err) {
+		return errors.Errorf("archive '%s' does not exist", e.ArchivePath)
+	}
+
+	compression := agentutil.TarCompression(e.Compression)
+	if compression == "" {
+		var err error
+		compression, err = agentutil.DetectTarCompressionForFile(e.ArchivePath)
+		if err != nil {
+			return errors.Wrap(err, "must specify compression")
+		}
+		logger.Execution().Infof("Detected compression '%s' for archive '%s'.", compression, e.ArchivePath)
+	}
+	if err := compression.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	archive, err := os.Open(e.ArchivePath)
+	if err != nil {
+		return errors.Wrapf(err, "reading file '%s'", e.ArchivePath)
+	}
+
+	defer func() {
+		logger.Task().Notice(errors.Wrapf(archive.Close(), "closing file '%s'", e.ArchivePath))
+	}()
+
+	if err := agentutil.ExtractTar(ctx, archive, e.TargetDirectory, e.ExcludeFiles, compression); err != nil {
+		return errors.Wrapf(err, "extracting file '%s'", e.ArchivePath)
+	}
+
+	return nil
+}
@@ -124,5 +124,8 @@ func (r *ec2AssumeRole) Execute(ctx context.Context,
 	conf.Expansions.Put(AWSSecretAccessKey, credValues.SecretAccessKey)
 	conf.Expansions.Put(AWSSessionToken, credValues.SessionToken)
 	conf.Expansions.Put(AWSRoleExpiration, expTime.String())
+	conf.Redactor.Add(AWSAccessKeyId, credValues.AccessKeyID)
+	conf.Redactor.Add(AWSSecretAccessKey, credValues.SecretAccessKey)
+	conf.Redactor.Add(AWSSessionToken, credValues.SessionToken)
 	return nil
 }
@@ -108,6 +108,10 @@ func (c *attachArtifacts) Execute(ctx context.Context,
 		return nil
 	}
 
+	if err = SetArtifactDigests(getJoinedWithWorkDir(conf, c.Prefix), files, logger.Task()); err != nil {
+		return errors.Wrap(err, "computing artifact digests")
+	}
+
 	td := client.TaskData{ID: conf.Task.Id, Secret: conf.Task.Secret}
 	if err = comm.AttachFiles(ctx, td, files); err != nil {
 		return errors.Wrap(err, "attach artifacts failed")
@@ -140,3 +144,36 @@ func readArtifactsFile(wd, fn string) ([]*artifact.File, error) {
 
 	return out, nil
 }
+
+// SetArtifactDigests sets the digest of each file that has a local path from
+// the file's contents. The local path is cleared since it's only meaningful
+// on this host. A digest given without a local path is dropped, since the
+// agent can't check it and only digests the agent computed are signed.
+func SetArtifactDigests(wd string, files []*artifact.File, logger grip.Journaler) error {
+	catcher := grip.NewBasicCatcher()
+	for _, f := range files {
+		if f.LocalPath == "" {
+			if f.SHA256 != "" {
+				logger.Warningf("Not recording digest for artifact '%s' because it has no local path to compute it from.", f.Name)
+				f.SHA256 = ""
+			}
+			continue
+		}
+		fn := f.LocalPath
+		if !filepath.IsAbs(fn) {
+			fn = filepath.Join(wd, fn)
+		}
+		digest, err := util.FileSHA256(fn)
+		if err != nil {
+			catcher.Wrapf(err, "artifact '%s'", f.Name)
+			continue
+		}
+		if f.SHA256 != "" && f.SHA256 != digest {
+			catcher.Errorf("artifact '%s' has digest '%s', but the file '%s' has digest '%s'", f.Name, f.SHA256, fn, digest)
+			continue
+		}
+		f.SHA256 = digest
+		f.LocalPath = ""
+	}
+	return catcher.Resolve()
+}
@@ -161,3 +161,34 @@ func (s *ArtifactsSuite) TestPrefixectoryWithSubDir() {
 	s.NoError(s.cmd.Execute(s.ctx, s.comm, s.logger, s.conf))
 	s.Len(s.cmd.Files, 1)
 }
+
+func (s *ArtifactsSuite) TestCommandComputesDigestsFromLocalPaths() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "binary"), []byte("foo"), 0644))
+	s.Require().NoError(utility.WriteJSONFile(filepath.Join(dir, "artifacts.json"), []*artifact.File{
+		{Name: "binary", Link: "https://example.com/binary", LocalPath: "binary"},
+		{Name: "docs", Link: "https://example.com/docs", SHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+	}))
+	s.conf.WorkDir = dir
+	s.cmd.Files = []string{"artifacts.json"}
+	s.NoError(s.cmd.Execute(s.ctx, s.comm, s.logger, s.conf))
+
+	files := s.mock.AttachedFiles[s.conf.Task.Id]
+	s.Require().Len(files, 2)
+	// The SHA-256 digest of "foo".
+	s.Equal("2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", files[0].SHA256)
+	s.Empty(files[0].LocalPath)
+	s.Empty(files[1].SHA256, "digest without a local path should not be recorded")
+}
+
+func (s *ArtifactsSuite) TestCommandFailsWithMismatchedDigest() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "binary"), []byte("foo"), 0644))
+	s.Require().NoError(utility.WriteJSONFile(filepath.Join(dir, "artifacts.json"), []*artifact.File{
+		{Name: "binary", Link: "https://example.com/binary", LocalPath: "binary", SHA256: "abc"},
+	}))
+	s.conf.WorkDir = dir
+	s.cmd.Files = []string{"artifacts.json"}
+	s.Error(s.cmd.Execute(s.ctx, s.comm, s.logger, s.conf))
+	s.Empty(s.mock.AttachedFiles[s.conf.Task.Id])
+}
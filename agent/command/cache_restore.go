@@ -0,0 +1,176 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// cacheRestore is a command to restore files that were saved to the project's
+// cache with cache.save.
+type cacheRestore struct {
+	// AwsKey, AwsSecret and AwsSessionToken are the credentials for the
+	// bucket that holds the cache.
+	AwsKey          string `mapstructure:"aws_key" plugin:"expand"`
+	AwsSecret       string `mapstructure:"aws_secret" plugin:"expand"`
+	AwsSessionToken string `mapstructure:"aws_session_token" plugin:"expand"`
+
+	// Bucket, Region and Prefix are where the cache is stored and must match
+	// the ones used to save it.
+	Bucket string `mapstructure:"bucket" plugin:"expand"`
+	Region string `mapstructure:"region" plugin:"expand"`
+	Prefix string `mapstructure:"prefix" plugin:"expand"`
+
+	// Key is the key of the cache entry to restore.
+	Key string `mapstructure:"key" plugin:"expand"`
+	// RestoreKeys are key prefixes to fall back to, in order, if there's no
+	// entry for the key. For each prefix, the most recently saved entry whose
+	// key starts with it is restored.
+	RestoreKeys []string `mapstructure:"restore_keys" plugin:"expand"`
+
+	// Path is the directory to restore the files into. It defaults to the
+	// working directory.
+	Path string `mapstructure:"path" plugin:"expand"`
+
+	// CacheHitExpansion is the name of an expansion to set to "true" if there
+	// was an entry for the exact key and "false" otherwise.
+	CacheHitExpansion string `mapstructure:"cache_hit_expansion" plugin:"expand"`
+
+	bucket pail.Bucket
+
+	base
+}
+
+func cacheRestoreFactory() Command   { return &cacheRestore{} }
+func (c *cacheRestore) Name() string { return "cache.restore" }
+
+func (c *cacheRestore) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, c); err != nil {
+		return errors.Wrap(err, "decoding mapstructure params")
+	}
+	return c.validate()
+}
+
+func (c *cacheRestore) validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(validateCacheBucketParams(c.AwsKey, c.AwsSecret, c.Bucket))
+	catcher.NewWhen(c.Key == "", "key cannot be blank")
+	for i, prefix := range c.RestoreKeys {
+		catcher.ErrorfWhen(prefix == "", "restore key at index %d cannot be blank", i)
+	}
+	return catcher.Resolve()
+}
+
+func (c *cacheRestore) Execute(ctx context.Context, comm client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+	if err := util.ExpandValues(c, conf.Expansions); err != nil {
+		return errors.Wrap(err, "applying expansions")
+	}
+	if err := c.validate(); err != nil {
+		return errors.Wrap(err, "validating expanded params")
+	}
+	if c.Path == "" {
+		c.Path = conf.WorkDir
+	} else if !filepath.IsAbs(c.Path) {
+		c.Path = getJoinedWithWorkDir(conf, c.Path)
+	}
+
+	if c.bucket == nil {
+		httpClient := utility.GetHTTPClient()
+		httpClient.Timeout = s3HTTPClientTimeout
+		defer utility.PutHTTPClient(httpClient)
+		bucket, err := newCacheBucket(httpClient, cacheBucketOptions{
+			awsKey:          c.AwsKey,
+			awsSecret:       c.AwsSecret,
+			awsSessionToken: c.AwsSessionToken,
+			region:          c.Region,
+			bucket:          c.Bucket,
+			prefix:          c.Prefix,
+			projectID:       conf.ProjectRef.Id,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		c.bucket = bucket
+	}
+	store := newCacheStore(c.bucket, conf.Task.Requester)
+
+	entry, entryStore, err := store.findEntry(ctx, c.Key, c.RestoreKeys)
+	if err != nil {
+		return errors.Wrap(err, "finding cache entry")
+	}
+	c.setCacheHitExpansion(conf, entry != nil && entry.Key == c.Key)
+	if entry == nil {
+		logger.Task().Infof("Cache miss for key '%s', not restoring any files.", c.Key)
+		return nil
+	}
+	if entry.Key == c.Key {
+		logger.Task().Infof("Cache hit for key '%s'.", c.Key)
+	} else {
+		logger.Task().Infof("Cache miss for key '%s', restoring entry with key '%s' instead.", c.Key, entry.Key)
+	}
+
+	archive, err := os.CreateTemp(conf.WorkDir, "cache-*.tar.gz")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary cache archive")
+	}
+	archivePath := archive.Name()
+	defer func() {
+		logger.Execution().Warning(errors.Wrapf(os.RemoveAll(archivePath), "removing temporary cache archive '%s'", archivePath))
+	}()
+	if err = archive.Close(); err != nil {
+		return errors.Wrapf(err, "closing temporary cache archive '%s'", archivePath)
+	}
+
+	if err = entryStore.downloadArchive(ctx, *entry, archivePath); err != nil {
+		return errors.Wrap(err, "downloading cache archive")
+	}
+	if err = c.extract(ctx, archivePath); err != nil {
+		return errors.Wrapf(err, "extracting cache archive into '%s'", c.Path)
+	}
+	logger.Task().Infof("Restored %d bytes from cache entry with key '%s' into '%s'.", entry.Size, entry.Key, c.Path)
+
+	// Restoring an entry keeps it from being evicted. Failing to update it only
+	// means it may be evicted earlier, so it doesn't fail the command. Entries
+	// that a patch restores from the mainline cache aren't updated, since
+	// patches can't modify the mainline cache.
+	if entryStore != store {
+		return nil
+	}
+	entry.LastAccessedAt = time.Now()
+	logger.Execution().Warning(errors.Wrapf(store.putEntry(ctx, *entry), "updating last access time of cache entry for key '%s'", entry.Key))
+
+	return nil
+}
+
+func (c *cacheRestore) extract(ctx context.Context, archivePath string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return errors.Wrapf(err, "opening file '%s'", archivePath)
+	}
+	defer f.Close()
+
+	if err = os.MkdirAll(c.Path, 0755); err != nil {
+		return errors.Wrapf(err, "creating directory '%s'", c.Path)
+	}
+
+	return agentutil.ExtractTarball(ctx, f, c.Path, nil)
+}
+
+func (c *cacheRestore) setCacheHitExpansion(conf *internal.TaskConfig, hit bool) {
+	if c.CacheHitExpansion == "" {
+		return
+	}
+	conf.Expansions.Put(c.CacheHitExpansion, strconv.FormatBool(hit))
+}
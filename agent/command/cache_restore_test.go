@@ -0,0 +1,174 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	comm := client.NewMock("localhost")
+
+	// saveEntry saves the working directory's src directory to the cache
+	// with the given key.
+	saveEntry := func(t *testing.T, bucket pail.Bucket, conf *internal.TaskConfig, logger client.LoggerProducer, key string) {
+		save := &cacheSave{
+			AwsKey:    "key",
+			AwsSecret: "secret",
+			Bucket:    "bucket",
+			Key:       key,
+			Path:      "src",
+			Include:   []string{"**"},
+			bucket:    bucket,
+		}
+		require.NoError(t, save.Execute(ctx, comm, logger, conf))
+	}
+
+	for tName, tCase := range map[string]func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer){
+		"ParseParamsRequiresKey": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			params := map[string]interface{}{
+				"aws_key":    "key",
+				"aws_secret": "secret",
+				"bucket":     "bucket",
+			}
+			assert.Error(t, (&cacheRestore{}).ParseParams(params))
+			params["key"] = "go-${revision}"
+			assert.NoError(t, (&cacheRestore{}).ParseParams(params))
+		},
+		"ParseParamsRejectsBlankRestoreKey": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			assert.Error(t, (&cacheRestore{}).ParseParams(map[string]interface{}{
+				"aws_key":      "key",
+				"aws_secret":   "secret",
+				"bucket":       "bucket",
+				"key":          "go-key",
+				"restore_keys": []string{"go-", ""},
+			}))
+		},
+		"RestoresFilesForExactKey": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			saveEntry(t, c.bucket, conf, logger, "go-abc123")
+
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			contents, err := os.ReadFile(filepath.Join(conf.WorkDir, "restored", "pkg", "pkg.go"))
+			require.NoError(t, err)
+			assert.Equal(t, "package pkg", string(contents))
+			assert.Equal(t, "true", conf.Expansions.Get("cache_hit"))
+		},
+		"RestoresNewestEntryForRestoreKey": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			saveEntry(t, c.bucket, conf, logger, "go-old")
+			require.NoError(t, os.WriteFile(filepath.Join(conf.WorkDir, "src", "pkg", "pkg.go"), []byte("package newer"), 0644))
+			// Ensure the entries have distinct creation times.
+			time.Sleep(10 * time.Millisecond)
+			saveEntry(t, c.bucket, conf, logger, "go-new")
+			c.RestoreKeys = []string{"node-", "go-"}
+
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			contents, err := os.ReadFile(filepath.Join(conf.WorkDir, "restored", "pkg", "pkg.go"))
+			require.NoError(t, err)
+			assert.Equal(t, "package newer", string(contents))
+			assert.Equal(t, "false", conf.Expansions.Get("cache_hit"))
+		},
+		"SucceedsOnCacheMiss": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			c.RestoreKeys = []string{"go-"}
+
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			assert.NoDirExists(t, filepath.Join(conf.WorkDir, "restored"))
+			assert.Equal(t, "false", conf.Expansions.Get("cache_hit"))
+		},
+		"UpdatesLastAccessTime": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			saveEntry(t, c.bucket, conf, logger, "go-abc123")
+			store := &cacheStore{bucket: c.bucket}
+			entry, err := store.getEntry(ctx, "go-abc123")
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			entry.LastAccessedAt = time.Now().Add(-time.Hour)
+			require.NoError(t, store.putEntry(ctx, *entry))
+
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			updated, err := store.getEntry(ctx, "go-abc123")
+			require.NoError(t, err)
+			require.NotNil(t, updated)
+			assert.True(t, updated.LastAccessedAt.After(entry.LastAccessedAt))
+			assert.True(t, updated.CreatedAt.Equal(entry.CreatedAt))
+		},
+		"PatchRestoresMainlineEntryWithoutUpdatingIt": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			saveEntry(t, c.bucket, conf, logger, "go-abc123")
+			store := &cacheStore{bucket: c.bucket}
+			entry, err := store.getEntry(ctx, "go-abc123")
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			conf.Task.Requester = evergreen.PatchVersionRequester
+
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			contents, err := os.ReadFile(filepath.Join(conf.WorkDir, "restored", "pkg", "pkg.go"))
+			require.NoError(t, err)
+			assert.Equal(t, "package pkg", string(contents))
+			unchanged, err := store.getEntry(ctx, "go-abc123")
+			require.NoError(t, err)
+			require.NotNil(t, unchanged)
+			assert.True(t, unchanged.LastAccessedAt.Equal(entry.LastAccessedAt))
+		},
+		"FailsWithCorruptedArchive": func(t *testing.T, c *cacheRestore, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			saveEntry(t, c.bucket, conf, logger, "go-abc123")
+			store := &cacheStore{bucket: c.bucket}
+			entry, err := store.getEntry(ctx, "go-abc123")
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			archivePath := filepath.Join(t.TempDir(), "corrupted.tar.gz")
+			require.NoError(t, os.WriteFile(archivePath, []byte("corrupted"), 0644))
+			require.NoError(t, c.bucket.Upload(ctx, cacheArchiveName(entry.SHA256), archivePath))
+
+			assert.Error(t, c.Execute(ctx, comm, logger, conf))
+			assert.NoFileExists(t, filepath.Join(conf.WorkDir, "restored", "pkg", "pkg.go"))
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			workDir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(workDir, "src", "pkg"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(workDir, "src", "pkg", "pkg.go"), []byte("package pkg"), 0644))
+
+			conf := &internal.TaskConfig{
+				Expansions: util.NewExpansions(map[string]string{"revision": "abc123"}),
+				Task:       &task.Task{Id: "task_id"},
+				ProjectRef: &model.ProjectRef{Id: "project_id"},
+				Project:    &model.Project{},
+				WorkDir:    workDir,
+			}
+			logger, err := comm.GetLoggerProducer(ctx, client.TaskData{ID: conf.Task.Id}, nil)
+			require.NoError(t, err)
+
+			bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+			require.NoError(t, err)
+			c := &cacheRestore{
+				AwsKey:            "key",
+				AwsSecret:         "secret",
+				Bucket:            "bucket",
+				Key:               "go-${revision}",
+				Path:              "restored",
+				CacheHitExpansion: "cache_hit",
+				bucket:            bucket,
+			}
+
+			tCase(t, c, conf, logger)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// cacheSave is a command to save files to the project's cache so that later
+// tasks can restore them with cache.restore.
+type cacheSave struct {
+	// AwsKey, AwsSecret and AwsSessionToken are the credentials for the
+	// bucket that holds the cache.
+	AwsKey          string `mapstructure:"aws_key" plugin:"expand"`
+	AwsSecret       string `mapstructure:"aws_secret" plugin:"expand"`
+	AwsSessionToken string `mapstructure:"aws_session_token" plugin:"expand"`
+
+	// Bucket is the S3 bucket that holds the cache. Region defaults to
+	// "us-east-1".
+	Bucket string `mapstructure:"bucket" plugin:"expand"`
+	Region string `mapstructure:"region" plugin:"expand"`
+	// Prefix is the path in the bucket under which the project's cache is
+	// stored.
+	Prefix string `mapstructure:"prefix" plugin:"expand"`
+
+	// Key identifies the cache entry. Entries can't be overwritten, so the key
+	// should change whenever the cached files should, e.g. by including a
+	// checksum of a lock file.
+	Key string `mapstructure:"key" plugin:"expand"`
+
+	// Path is the directory that the cached files are in. It defaults to the
+	// working directory.
+	Path string `mapstructure:"path" plugin:"expand"`
+	// Include is a list of file patterns in the path to cache, e.g.
+	// "pkg/mod/**".
+	Include []string `mapstructure:"include" plugin:"expand"`
+	// ExcludeFiles is a list of file name patterns to not cache.
+	ExcludeFiles []string `mapstructure:"exclude_files" plugin:"expand"`
+
+	// TTLSecs is how long an entry is kept after it was last restored. It
+	// defaults to 7 days.
+	TTLSecs int `mapstructure:"ttl_secs"`
+	// MaxSizeMB is the total size of the project's cache. When it's exceeded,
+	// the least recently restored entries are removed. By default, the cache
+	// size is not limited.
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+
+	bucket pail.Bucket
+
+	base
+}
+
+func cacheSaveFactory() Command   { return &cacheSave{} }
+func (c *cacheSave) Name() string { return "cache.save" }
+
+func (c *cacheSave) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, c); err != nil {
+		return errors.Wrap(err, "decoding mapstructure params")
+	}
+	return c.validate()
+}
+
+func (c *cacheSave) validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(validateCacheBucketParams(c.AwsKey, c.AwsSecret, c.Bucket))
+	catcher.NewWhen(c.Key == "", "key cannot be blank")
+	catcher.NewWhen(len(c.Include) == 0, "include cannot be empty")
+	catcher.NewWhen(c.TTLSecs < 0, "TTL cannot be negative")
+	catcher.NewWhen(c.MaxSizeMB < 0, "max size cannot be negative")
+	return catcher.Resolve()
+}
+
+func (c *cacheSave) Execute(ctx context.Context, comm client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+	if err := util.ExpandValues(c, conf.Expansions); err != nil {
+		return errors.Wrap(err, "applying expansions")
+	}
+	if err := c.validate(); err != nil {
+		return errors.Wrap(err, "validating expanded params")
+	}
+	if c.Path == "" {
+		c.Path = conf.WorkDir
+	} else if !filepath.IsAbs(c.Path) {
+		c.Path = getJoinedWithWorkDir(conf, c.Path)
+	}
+
+	if c.bucket == nil {
+		httpClient := utility.GetHTTPClient()
+		httpClient.Timeout = s3HTTPClientTimeout
+		defer utility.PutHTTPClient(httpClient)
+		bucket, err := newCacheBucket(httpClient, cacheBucketOptions{
+			awsKey:          c.AwsKey,
+			awsSecret:       c.AwsSecret,
+			awsSessionToken: c.AwsSessionToken,
+			region:          c.Region,
+			bucket:          c.Bucket,
+			prefix:          c.Prefix,
+			projectID:       conf.ProjectRef.Id,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		c.bucket = bucket
+	}
+	store := newCacheStore(c.bucket, conf.Task.Requester)
+
+	existing, err := store.getEntry(ctx, c.Key)
+	if err != nil {
+		return errors.Wrap(err, "checking for existing cache entry")
+	}
+	if existing != nil {
+		logger.Task().Infof("Cache entry for key '%s' already exists, not saving it again.", c.Key)
+		return nil
+	}
+
+	archive, err := os.CreateTemp(conf.WorkDir, "cache-*.tar.gz")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary cache archive")
+	}
+	archivePath := archive.Name()
+	defer func() {
+		logger.Execution().Warning(errors.Wrapf(os.RemoveAll(archivePath), "removing temporary cache archive '%s'", archivePath))
+	}()
+
+	numFiles, hash, err := c.makeArchive(ctx, archive, logger)
+	if err != nil {
+		return errors.Wrap(err, "making cache archive")
+	}
+	if numFiles == 0 {
+		logger.Task().Warningf("No files matched the include patterns, not saving cache entry for key '%s'.", c.Key)
+		return nil
+	}
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return errors.Wrapf(err, "getting size of cache archive '%s'", archivePath)
+	}
+
+	exists, err := store.hasArchive(ctx, hash)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if exists {
+		logger.Task().Infof("Cache archive with the same contents already exists, reusing it for key '%s'.", c.Key)
+	} else {
+		logger.Task().Infof("Uploading %d file(s) (%d bytes) to cache for key '%s'.", numFiles, info.Size(), c.Key)
+		if err = store.uploadArchive(ctx, hash, archivePath); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	now := time.Now()
+	if err = store.putEntry(ctx, cacheEntry{
+		Key:            c.Key,
+		SHA256:         hash,
+		Size:           info.Size(),
+		CreatedAt:      now,
+		LastAccessedAt: now,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	logger.Task().Infof("Saved cache entry for key '%s'.", c.Key)
+
+	c.evict(ctx, store, logger)
+
+	return nil
+}
+
+// makeArchive writes the included files to the archive and returns how many
+// files it contains and its SHA-256 hash.
+func (c *cacheSave) makeArchive(ctx context.Context, f *os.File, logger client.LoggerProducer) (int, string, error) {
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tarWriter := tar.NewWriter(gz)
+	numFiles, err := agentutil.BuildArchive(ctx, tarWriter, c.Path, c.Include, c.ExcludeFiles, logger.Execution())
+	if err != nil {
+		return 0, "", errors.Wrap(err, "building archive")
+	}
+	if err = tarWriter.Close(); err != nil {
+		return 0, "", errors.Wrap(err, "closing tar writer")
+	}
+	if err = gz.Close(); err != nil {
+		return 0, "", errors.Wrap(err, "closing gzip writer")
+	}
+
+	if err = f.Close(); err != nil {
+		return 0, "", errors.Wrap(err, "closing archive file")
+	}
+	hash, err := util.FileSHA256(f.Name())
+	if err != nil {
+		return 0, "", errors.Wrap(err, "hashing archive")
+	}
+
+	return numFiles, hash, nil
+}
+
+// evict removes old entries from the cache. Failing to evict entries doesn't
+// fail the command, since the entry was already saved.
+func (c *cacheSave) evict(ctx context.Context, store *cacheStore, logger client.LoggerProducer) {
+	ttl := defaultCacheTTL
+	if c.TTLSecs > 0 {
+		ttl = time.Duration(c.TTLSecs) * time.Second
+	}
+	evicted, err := store.evict(ctx, ttl, int64(c.MaxSizeMB)*1024*1024)
+	logger.Task().Warning(errors.Wrap(err, "evicting old cache entries"))
+	if len(evicted) > 0 {
+		logger.Task().Infof("Evicted %d old cache entries.", len(evicted))
+	}
+}
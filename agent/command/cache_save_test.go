@@ -0,0 +1,166 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSave(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	comm := client.NewMock("localhost")
+
+	for tName, tCase := range map[string]func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer){
+		"ParseParamsRequiresKeyAndInclude": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			params := map[string]interface{}{
+				"aws_key":    "key",
+				"aws_secret": "secret",
+				"bucket":     "bucket",
+			}
+			assert.Error(t, (&cacheSave{}).ParseParams(params))
+			params["key"] = "go-${revision}"
+			assert.Error(t, (&cacheSave{}).ParseParams(params))
+			params["include"] = []string{"*.go"}
+			assert.NoError(t, (&cacheSave{}).ParseParams(params))
+		},
+		"ParseParamsRequiresCredentials": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			assert.Error(t, (&cacheSave{}).ParseParams(map[string]interface{}{
+				"bucket":  "bucket",
+				"key":     "key",
+				"include": []string{"*.go"},
+			}))
+		},
+		"SavesEntryWithExpandedKey": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			c.Key = "go-${revision}"
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			store := &cacheStore{bucket: c.bucket}
+			entry, err := store.getEntry(ctx, "go-abc123")
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.NotZero(t, entry.SHA256)
+			assert.NotZero(t, entry.Size)
+			assert.False(t, entry.CreatedAt.IsZero())
+			assert.Equal(t, entry.CreatedAt.Unix(), entry.LastAccessedAt.Unix())
+
+			exists, err := store.hasArchive(ctx, entry.SHA256)
+			require.NoError(t, err)
+			assert.True(t, exists)
+		},
+		"DoesNotOverwriteExistingEntry": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			store := &cacheStore{bucket: c.bucket}
+			require.NoError(t, store.putEntry(ctx, cacheEntry{Key: c.Key, SHA256: "existing"}))
+
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			entry, err := store.getEntry(ctx, c.Key)
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, "existing", entry.SHA256)
+		},
+		"SharesArchiveBetweenEntriesWithSameContents": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+			other := &cacheSave{
+				AwsKey:    c.AwsKey,
+				AwsSecret: c.AwsSecret,
+				Bucket:    c.Bucket,
+				Key:       "other-key",
+				Path:      "src",
+				Include:   c.Include,
+				bucket:    c.bucket,
+			}
+			require.NoError(t, other.Execute(ctx, comm, logger, conf))
+
+			store := &cacheStore{bucket: c.bucket}
+			first, err := store.getEntry(ctx, c.Key)
+			require.NoError(t, err)
+			second, err := store.getEntry(ctx, other.Key)
+			require.NoError(t, err)
+			require.NotNil(t, first)
+			require.NotNil(t, second)
+			assert.Equal(t, first.SHA256, second.SHA256)
+		},
+		"SavesPatchEntriesSeparatelyFromMainline": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			conf.Task.Requester = evergreen.GithubPRRequester
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			entry, err := (&cacheStore{bucket: c.bucket}).getEntry(ctx, c.Key)
+			require.NoError(t, err)
+			assert.Nil(t, entry, "patches should not save entries to the mainline cache")
+
+			patchStore := newCacheStore(c.bucket, conf.Task.Requester)
+			entry, err = patchStore.getEntry(ctx, c.Key)
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			exists, err := patchStore.hasArchive(ctx, entry.SHA256)
+			require.NoError(t, err)
+			assert.True(t, exists)
+		},
+		"SkipsSavingWithoutMatchingFiles": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			c.Include = []string{"*.nonexistent"}
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			entry, err := (&cacheStore{bucket: c.bucket}).getEntry(ctx, c.Key)
+			require.NoError(t, err)
+			assert.Nil(t, entry)
+		},
+		"EvictsLeastRecentlyUsedEntriesOverMaxSize": func(t *testing.T, c *cacheSave, conf *internal.TaskConfig, logger client.LoggerProducer) {
+			store := &cacheStore{bucket: c.bucket}
+			require.NoError(t, store.putEntry(ctx, cacheEntry{Key: "old", SHA256: "old", Size: 2 * 1024 * 1024}))
+			c.MaxSizeMB = 1
+
+			require.NoError(t, c.Execute(ctx, comm, logger, conf))
+
+			entry, err := store.getEntry(ctx, "old")
+			require.NoError(t, err)
+			assert.Nil(t, entry)
+			entry, err = store.getEntry(ctx, c.Key)
+			require.NoError(t, err)
+			assert.NotNil(t, entry)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			workDir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(workDir, "src", "pkg"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(workDir, "src", "main.go"), []byte("package main"), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(workDir, "src", "pkg", "pkg.go"), []byte("package pkg"), 0644))
+
+			conf := &internal.TaskConfig{
+				Expansions: util.NewExpansions(map[string]string{"revision": "abc123"}),
+				Task:       &task.Task{Id: "task_id"},
+				ProjectRef: &model.ProjectRef{Id: "project_id"},
+				Project:    &model.Project{},
+				WorkDir:    workDir,
+			}
+			logger, err := comm.GetLoggerProducer(ctx, client.TaskData{ID: conf.Task.Id}, nil)
+			require.NoError(t, err)
+
+			bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+			require.NoError(t, err)
+			c := &cacheSave{
+				AwsKey:    "key",
+				AwsSecret: "secret",
+				Bucket:    "bucket",
+				Key:       "go-key",
+				Path:      "src",
+				Include:   []string{"**.go"},
+				bucket:    bucket,
+			}
+
+			tCase(t, c, conf, logger)
+		})
+	}
+}
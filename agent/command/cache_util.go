@@ -0,0 +1,459 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultCachePrefix is the path in the bucket under which each project's
+	// cache is stored.
+	defaultCachePrefix = "evergreen_cache"
+	// defaultCacheTTL is how long a cache entry is kept after it was last
+	// restored.
+	defaultCacheTTL = 7 * 24 * time.Hour
+
+	// patchCacheNamespace is the directory that patches save their cache
+	// entries in.
+	patchCacheNamespace = "patches"
+	// cacheEvictInterval is the minimum time between evictions of a cache,
+	// since eviction reads every entry.
+	cacheEvictInterval = time.Hour
+	// cacheMaxListedEntries is the maximum number of entries that are read
+	// when looking up a restore key or evicting entries.
+	cacheMaxListedEntries = 1000
+
+	cacheEntriesDir   = "keys"
+	cacheArchivesDir  = "archives"
+	cacheEvictionName = "eviction.json"
+)
+
+// cacheEntry describes a saved cache entry. Entries refer to the archive of
+// their files by its SHA-256 hash, so that entries with the same contents
+// share one archive and restored archives can be verified.
+type cacheEntry struct {
+	Key            string    `json:"key"`
+	SHA256         string    `json:"sha256"`
+	Size           int64     `json:"size"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+}
+
+// cacheStore is a project's cache in a bucket. Entries are stored by their
+// escaped key, so a key prefix is also a prefix of the stored names.
+type cacheStore struct {
+	bucket pail.Bucket
+	// namespace is the directory in the bucket that holds the store's entries
+	// and archives. Entries saved by mainline tasks are at the root.
+	namespace string
+	// fallback is a store to restore entries from when this one doesn't have
+	// a matching entry. Entries are never saved to it.
+	fallback *cacheStore
+}
+
+// cacheEviction records when a cache's entries were last evicted.
+type cacheEviction struct {
+	EvictedAt time.Time `json:"evicted_at"`
+	// UnreferencedArchives are the hashes of the archives that no entry
+	// referred to at the end of the last eviction. They're removed if they're
+	// still unreferenced at the next one.
+	UnreferencedArchives []string `json:"unreferenced_archives,omitempty"`
+}
+
+// newCacheStore returns the cache store for a task with the given requester.
+// Patches save to their own namespace so that untested changes can't put
+// entries in the cache that mainline tasks restore from, but they can restore
+// the entries that mainline tasks saved.
+func newCacheStore(bucket pail.Bucket, requester string) *cacheStore {
+	mainline := &cacheStore{bucket: bucket}
+	if !evergreen.IsPatchRequester(requester) {
+		return mainline
+	}
+	return &cacheStore{
+		bucket:    bucket,
+		namespace: patchCacheNamespace,
+		fallback:  mainline,
+	}
+}
+
+// cacheBucketOptions are the options to create the bucket that backs the
+// cache.
+type cacheBucketOptions struct {
+	awsKey          string
+	awsSecret       string
+	awsSessionToken string
+	region          string
+	bucket          string
+	prefix          string
+	projectID       string
+}
+
+func newCacheBucket(httpClient *http.Client, opts cacheBucketOptions) (pail.Bucket, error) {
+	if opts.region == "" {
+		opts.region = endpoints.UsEast1RegionID
+	}
+	if opts.prefix == "" {
+		opts.prefix = defaultCachePrefix
+	}
+	bucket, err := pail.NewS3MultiPartBucketWithHTTPClient(httpClient, pail.S3Options{
+		Credentials: pail.CreateAWSCredentials(opts.awsKey, opts.awsSecret, opts.awsSessionToken),
+		Region:      opts.region,
+		Name:        opts.bucket,
+		Prefix:      path.Join(opts.prefix, opts.projectID),
+		Permissions: pail.S3PermissionsPrivate,
+	})
+	return bucket, errors.Wrap(err, "initializing cache bucket")
+}
+
+func validateCacheBucketParams(awsKey, awsSecret, bucket string) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(awsKey == "", "AWS key cannot be blank")
+	catcher.NewWhen(awsSecret == "", "AWS secret cannot be blank")
+	catcher.Wrapf(validateS3BucketName(bucket), "validating bucket name '%s'", bucket)
+	return catcher.Resolve()
+}
+
+func cacheEntryName(key string) string {
+	return cacheEntriesDir + "/" + url.PathEscape(key) + ".json"
+}
+
+func cacheArchiveName(hash string) string {
+	return cacheArchivesDir + "/" + hash + ".tar.gz"
+}
+
+func (s *cacheStore) entryName(key string) string {
+	return path.Join(s.namespace, cacheEntryName(key))
+}
+
+func (s *cacheStore) archiveName(hash string) string {
+	return path.Join(s.namespace, cacheArchiveName(hash))
+}
+
+// getEntry returns the entry for the key, or nil if there isn't one.
+func (s *cacheStore) getEntry(ctx context.Context, key string) (*cacheEntry, error) {
+	return s.readEntry(ctx, s.entryName(key))
+}
+
+func (s *cacheStore) readEntry(ctx context.Context, name string) (*cacheEntry, error) {
+	r, err := s.bucket.Get(ctx, name)
+	if pail.IsKeyNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting cache entry '%s'", name)
+	}
+	defer r.Close()
+
+	entry := &cacheEntry{}
+	if err = json.NewDecoder(r).Decode(entry); err != nil {
+		return nil, errors.Wrapf(err, "decoding cache entry '%s'", name)
+	}
+	return entry, nil
+}
+
+func (s *cacheStore) putEntry(ctx context.Context, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshalling cache entry")
+	}
+	return errors.Wrapf(s.bucket.Put(ctx, s.entryName(entry.Key), bytes.NewReader(data)), "putting cache entry for key '%s'", entry.Key)
+}
+
+// listEntries returns all the entries in the cache.
+func (s *cacheStore) listEntries(ctx context.Context) ([]cacheEntry, error) {
+	return s.listEntriesWithPrefix(ctx, "")
+}
+
+// listEntriesWithPrefix returns the entries whose key starts with the prefix.
+// At most cacheMaxListedEntries entries are returned, in the order that the
+// bucket lists them.
+func (s *cacheStore) listEntriesWithPrefix(ctx context.Context, keyPrefix string) ([]cacheEntry, error) {
+	entries, _, err := s.scanEntries(ctx, keyPrefix)
+	return entries, err
+}
+
+// scanEntries returns the first cacheMaxListedEntries entries whose key starts
+// with the prefix and whether those are all of the matching entries.
+func (s *cacheStore) scanEntries(ctx context.Context, keyPrefix string) ([]cacheEntry, bool, error) {
+	entriesDir := path.Join(s.namespace, cacheEntriesDir) + "/"
+	iter, err := s.bucket.List(ctx, entriesDir)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "listing cache entries")
+	}
+
+	namePrefix := entriesDir + url.PathEscape(keyPrefix)
+	var entries []cacheEntry
+	complete := true
+	for iter.Next(ctx) {
+		name := iter.Item().Name()
+		if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if len(entries) >= cacheMaxListedEntries {
+			complete = false
+			break
+		}
+		entry, err := s.readEntry(ctx, name)
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		// The entry may have been evicted since it was listed.
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	if err = iter.Err(); err != nil {
+		return nil, false, errors.Wrap(err, "iterating cache entries")
+	}
+
+	return entries, complete, nil
+}
+
+// findEntry returns the entry for the key if there is one. Otherwise, it
+// returns the most recently created entry whose key starts with the first
+// restore key prefix that has any entries. For each key, the store's own
+// entries are checked before its fallback's. It also returns the store that
+// has the entry, which is the one to download its archive from.
+func (s *cacheStore) findEntry(ctx context.Context, key string, restoreKeys []string) (*cacheEntry, *cacheStore, error) {
+	stores := []*cacheStore{s}
+	if s.fallback != nil {
+		stores = append(stores, s.fallback)
+	}
+
+	for _, store := range stores {
+		entry, err := store.getEntry(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if entry != nil {
+			return entry, store, nil
+		}
+	}
+
+	for _, prefix := range restoreKeys {
+		for _, store := range stores {
+			entries, err := store.listEntriesWithPrefix(ctx, prefix)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "finding cache entries for restore key '%s'", prefix)
+			}
+			if len(entries) == 0 {
+				continue
+			}
+			sort.SliceStable(entries, func(i, j int) bool {
+				return entries[i].CreatedAt.After(entries[j].CreatedAt)
+			})
+			return &entries[0], store, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// hasArchive returns whether the archive with the hash is already stored.
+func (s *cacheStore) hasArchive(ctx context.Context, hash string) (bool, error) {
+	r, err := s.bucket.Get(ctx, s.archiveName(hash))
+	if pail.IsKeyNotFoundError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "checking for cache archive '%s'", hash)
+	}
+	return true, errors.Wrap(r.Close(), "closing cache archive")
+}
+
+// downloadArchive downloads the entry's archive to the file and checks that
+// its contents match the entry's hash.
+func (s *cacheStore) downloadArchive(ctx context.Context, entry cacheEntry, fileName string) error {
+	r, err := s.bucket.Get(ctx, s.archiveName(entry.SHA256))
+	if err != nil {
+		return errors.Wrapf(err, "getting cache archive '%s'", entry.SHA256)
+	}
+	defer r.Close()
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "creating file '%s'", fileName)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(f, hasher), r); err != nil {
+		return errors.Wrapf(err, "downloading cache archive '%s'", entry.SHA256)
+	}
+	if hash := hex.EncodeToString(hasher.Sum(nil)); hash != entry.SHA256 {
+		return errors.Errorf("cache archive has hash '%s' but entry for key '%s' expects '%s'", hash, entry.Key, entry.SHA256)
+	}
+
+	return errors.Wrapf(f.Close(), "closing file '%s'", fileName)
+}
+
+// uploadArchive uploads the archive file for the hash.
+func (s *cacheStore) uploadArchive(ctx context.Context, hash, fileName string) error {
+	return errors.Wrapf(s.bucket.Upload(ctx, s.archiveName(hash), fileName), "uploading cache archive '%s'", hash)
+}
+
+// evict removes the entries that were not restored within the TTL and then,
+// if the cache is still larger than the max size, the least recently used
+// entries until it fits. It returns the keys of the removed entries.
+//
+// Since eviction reads the cache's entries, it runs at most once per
+// cacheEvictInterval and only considers the first cacheMaxListedEntries
+// entries. Entries past those are considered once earlier ones are evicted.
+//
+// Archives are only removed when every entry was listed, because an entry past
+// the listed ones may still refer to them. Even then, a concurrent cache.save
+// may be about to write an entry for an archive it found, so an archive is
+// only removed once it has been unreferenced for two consecutive evictions.
+func (s *cacheStore) evict(ctx context.Context, ttl time.Duration, maxSize int64) ([]string, error) {
+	last, due, err := s.startEviction(ctx)
+	if err != nil || !due {
+		return nil, errors.WithStack(err)
+	}
+	startedAt := time.Now()
+
+	entries, complete, err := s.scanEntries(ctx, "")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastAccessedAt.Before(entries[j].LastAccessedAt)
+	})
+
+	// Archives that are shared by several entries only count once.
+	refs := map[string]int{}
+	var size int64
+	for _, entry := range entries {
+		if refs[entry.SHA256] == 0 {
+			size += entry.Size
+		}
+		refs[entry.SHA256]++
+	}
+
+	var evicted []string
+	catcher := grip.NewBasicCatcher()
+	cutoff := time.Now().Add(-ttl)
+	for _, entry := range entries {
+		expired := ttl > 0 && entry.LastAccessedAt.Before(cutoff)
+		overSize := maxSize > 0 && size > maxSize
+		if !expired && !overSize {
+			// Entries are in LRU order, so the remaining ones are more
+			// recent.
+			break
+		}
+
+		if err := s.bucket.Remove(ctx, s.entryName(entry.Key)); err != nil {
+			catcher.Wrapf(err, "removing cache entry for key '%s'", entry.Key)
+			continue
+		}
+		evicted = append(evicted, entry.Key)
+
+		refs[entry.SHA256]--
+		if refs[entry.SHA256] == 0 {
+			size -= entry.Size
+		}
+	}
+
+	if !complete {
+		return evicted, catcher.Resolve()
+	}
+
+	unreferenced, err := s.removeUnreferencedArchives(ctx, refs, last.UnreferencedArchives)
+	catcher.Add(err)
+	catcher.Add(s.recordEviction(ctx, cacheEviction{
+		EvictedAt:            startedAt,
+		UnreferencedArchives: unreferenced,
+	}))
+
+	return evicted, catcher.Resolve()
+}
+
+// removeUnreferencedArchives removes the archives that no entry refers to and
+// that were also unreferenced at the last eviction. It returns the hashes of
+// the archives that are unreferenced for the first time.
+func (s *cacheStore) removeUnreferencedArchives(ctx context.Context, refs map[string]int, lastUnreferenced []string) ([]string, error) {
+	wasUnreferenced := map[string]bool{}
+	for _, hash := range lastUnreferenced {
+		wasUnreferenced[hash] = true
+	}
+
+	archivesDir := path.Join(s.namespace, cacheArchivesDir) + "/"
+	iter, err := s.bucket.List(ctx, archivesDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing cache archives")
+	}
+
+	var unreferenced []string
+	catcher := grip.NewBasicCatcher()
+	for iter.Next(ctx) {
+		name := iter.Item().Name()
+		if !strings.HasPrefix(name, archivesDir) || !strings.HasSuffix(name, ".tar.gz") {
+			continue
+		}
+		hash := strings.TrimSuffix(strings.TrimPrefix(name, archivesDir), ".tar.gz")
+		if refs[hash] > 0 {
+			continue
+		}
+		if !wasUnreferenced[hash] {
+			unreferenced = append(unreferenced, hash)
+			continue
+		}
+		catcher.Wrapf(s.bucket.Remove(ctx, name), "removing cache archive '%s'", hash)
+	}
+	catcher.Wrap(iter.Err(), "iterating cache archives")
+
+	return unreferenced, catcher.Resolve()
+}
+
+// startEviction returns the last eviction and whether the cache is due to be
+// evicted. If it is, it records that eviction started so that other tasks
+// don't also evict it.
+func (s *cacheStore) startEviction(ctx context.Context) (*cacheEviction, bool, error) {
+	last := &cacheEviction{}
+	r, err := s.bucket.Get(ctx, s.evictionName())
+	if err != nil && !pail.IsKeyNotFoundError(err) {
+		return nil, false, errors.Wrap(err, "getting last cache eviction")
+	}
+	if err == nil {
+		defer r.Close()
+		if err = json.NewDecoder(r).Decode(last); err != nil {
+			return nil, false, errors.Wrap(err, "decoding last cache eviction")
+		}
+		if time.Since(last.EvictedAt) < cacheEvictInterval {
+			return last, false, nil
+		}
+	}
+
+	if err = s.recordEviction(ctx, cacheEviction{EvictedAt: time.Now()}); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return last, true, nil
+}
+
+func (s *cacheStore) evictionName() string {
+	return path.Join(s.namespace, cacheEvictionName)
+}
+
+func (s *cacheStore) recordEviction(ctx context.Context, eviction cacheEviction) error {
+	data, err := json.Marshal(eviction)
+	if err != nil {
+		return errors.Wrap(err, "marshalling cache eviction")
+	}
+	return errors.Wrap(s.bucket.Put(ctx, s.evictionName(), bytes.NewReader(data)), "recording cache eviction")
+}
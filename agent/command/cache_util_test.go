@@ -0,0 +1,238 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	putArchive := func(t *testing.T, s *cacheStore, hash string, size int) {
+		require.NoError(t, s.bucket.Put(ctx, cacheArchiveName(hash), bytes.NewReader(make([]byte, size))))
+	}
+	// allowNextEviction backdates the last eviction so that the next one isn't
+	// skipped for running within the eviction interval.
+	allowNextEviction := func(t *testing.T, s *cacheStore) {
+		last, due, err := s.startEviction(ctx)
+		require.NoError(t, err)
+		require.False(t, due)
+		last.EvictedAt = time.Now().Add(-2 * cacheEvictInterval)
+		require.NoError(t, s.recordEviction(ctx, *last))
+	}
+
+	for tName, tCase := range map[string]func(t *testing.T, s *cacheStore){
+		"GetEntryReturnsNilForMissingKey": func(t *testing.T, s *cacheStore) {
+			entry, err := s.getEntry(ctx, "missing")
+			require.NoError(t, err)
+			assert.Nil(t, entry)
+		},
+		"EntryNamesEscapeKeys": func(t *testing.T, s *cacheStore) {
+			assert.Equal(t, "keys/..%2Fgo-mod.json", cacheEntryName("../go-mod"))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "a/b c", SHA256: "hash"}))
+			entry, err := s.getEntry(ctx, "a/b c")
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, "a/b c", entry.Key)
+		},
+		"FindEntryPrefersExactKey": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "go-abc", SHA256: "1", CreatedAt: now.Add(-time.Hour)}))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "go-def", SHA256: "2", CreatedAt: now}))
+
+			entry, entryStore, err := s.findEntry(ctx, "go-abc", []string{"go-"})
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, "go-abc", entry.Key)
+			assert.Equal(t, s, entryStore)
+		},
+		"FindEntryFallsBackToNewestEntryForFirstMatchingRestoreKey": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "go-linux-abc", SHA256: "1", CreatedAt: now.Add(-time.Hour)}))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "go-linux-def", SHA256: "2", CreatedAt: now}))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "go-macos-ghi", SHA256: "3", CreatedAt: now.Add(time.Hour)}))
+
+			entry, _, err := s.findEntry(ctx, "go-linux-xyz", []string{"go-windows-", "go-linux-", "go-"})
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, "go-linux-def", entry.Key)
+		},
+		"FindEntryReturnsNilWithoutMatches": func(t *testing.T, s *cacheStore) {
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "node-abc", SHA256: "1"}))
+
+			entry, _, err := s.findEntry(ctx, "go-abc", []string{"go-"})
+			require.NoError(t, err)
+			assert.Nil(t, entry)
+		},
+		"PatchStoreSavesSeparatelyFromMainline": func(t *testing.T, s *cacheStore) {
+			patchStore := newCacheStore(s.bucket, evergreen.PatchVersionRequester)
+			require.NoError(t, patchStore.putEntry(ctx, cacheEntry{Key: "go-abc", SHA256: "patch"}))
+
+			entry, err := s.getEntry(ctx, "go-abc")
+			require.NoError(t, err)
+			assert.Nil(t, entry, "patch entries should not be visible to mainline tasks")
+			entries, err := s.listEntries(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+
+			entry, err = patchStore.getEntry(ctx, "go-abc")
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, "patch", entry.SHA256)
+		},
+		"PatchStoreFindsMainlineEntries": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			patchStore := newCacheStore(s.bucket, evergreen.GithubPRRequester)
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "go-abc", SHA256: "mainline", CreatedAt: now}))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "go-def", SHA256: "mainline", CreatedAt: now}))
+			require.NoError(t, patchStore.putEntry(ctx, cacheEntry{Key: "go-def", SHA256: "patch", CreatedAt: now.Add(-time.Hour)}))
+
+			entry, entryStore, err := patchStore.findEntry(ctx, "go-abc", nil)
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, "mainline", entry.SHA256)
+			assert.Equal(t, s.namespace, entryStore.namespace)
+
+			entry, entryStore, err = patchStore.findEntry(ctx, "go-xyz", []string{"go-"})
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, "patch", entry.SHA256, "patch's own entries should be preferred")
+			assert.Equal(t, patchStore, entryStore)
+		},
+		"EvictRunsAtMostOncePerInterval": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			putArchive(t, s, "old", 10)
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "old", SHA256: "old", LastAccessedAt: now.Add(-2 * time.Hour)}))
+			evicted, err := s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"old"}, evicted)
+
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "older", SHA256: "older", LastAccessedAt: now.Add(-3 * time.Hour)}))
+			evicted, err = s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			assert.Empty(t, evicted)
+			entry, err := s.getEntry(ctx, "older")
+			require.NoError(t, err)
+			assert.NotNil(t, entry)
+		},
+		"EvictRemovesExpiredEntries": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			putArchive(t, s, "old", 10)
+			putArchive(t, s, "new", 10)
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "old", SHA256: "old", Size: 10, LastAccessedAt: now.Add(-2 * time.Hour)}))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "new", SHA256: "new", Size: 10, LastAccessedAt: now}))
+
+			evicted, err := s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"old"}, evicted)
+
+			entry, err := s.getEntry(ctx, "new")
+			require.NoError(t, err)
+			assert.NotNil(t, entry)
+		},
+		"EvictRemovesArchivesUnreferencedForTwoEvictions": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			putArchive(t, s, "old", 10)
+			putArchive(t, s, "new", 10)
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "old", SHA256: "old", Size: 10, LastAccessedAt: now.Add(-2 * time.Hour)}))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "new", SHA256: "new", Size: 10, LastAccessedAt: now}))
+
+			_, err := s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			exists, err := s.hasArchive(ctx, "old")
+			require.NoError(t, err)
+			assert.True(t, exists, "archive should be kept in case a concurrent save is about to refer to it")
+
+			allowNextEviction(t, s)
+			_, err = s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			exists, err = s.hasArchive(ctx, "old")
+			require.NoError(t, err)
+			assert.False(t, exists)
+			exists, err = s.hasArchive(ctx, "new")
+			require.NoError(t, err)
+			assert.True(t, exists)
+		},
+		"EvictKeepsArchivesThatBecomeReferencedAgain": func(t *testing.T, s *cacheStore) {
+			putArchive(t, s, "hash", 10)
+
+			_, err := s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "key", SHA256: "hash", Size: 10, LastAccessedAt: time.Now()}))
+
+			allowNextEviction(t, s)
+			_, err = s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			exists, err := s.hasArchive(ctx, "hash")
+			require.NoError(t, err)
+			assert.True(t, exists)
+		},
+		"EvictKeepsArchivesWhenNotAllEntriesAreListed": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			putArchive(t, s, "shared", 10)
+			for i := 0; i <= cacheMaxListedEntries; i++ {
+				require.NoError(t, s.putEntry(ctx, cacheEntry{Key: fmt.Sprintf("key-%04d", i), SHA256: "shared", Size: 10, LastAccessedAt: now.Add(-2 * time.Hour)}))
+			}
+
+			evicted, err := s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			assert.Len(t, evicted, cacheMaxListedEntries)
+
+			entries, err := s.listEntries(ctx)
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+			exists, err := s.hasArchive(ctx, "shared")
+			require.NoError(t, err)
+			assert.True(t, exists, "archive should only be removed after a full listing finds it unreferenced")
+		},
+		"EvictRemovesLeastRecentlyUsedEntriesOverMaxSize": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			for i, key := range []string{"a", "b", "c"} {
+				putArchive(t, s, key, 10)
+				require.NoError(t, s.putEntry(ctx, cacheEntry{Key: key, SHA256: key, Size: 10, LastAccessedAt: now.Add(time.Duration(i) * time.Minute)}))
+			}
+
+			evicted, err := s.evict(ctx, time.Hour, 20)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a"}, evicted)
+
+			entries, err := s.listEntries(ctx)
+			require.NoError(t, err)
+			assert.Len(t, entries, 2)
+		},
+		"EvictKeepsArchivesThatAreStillReferenced": func(t *testing.T, s *cacheStore) {
+			now := time.Now()
+			putArchive(t, s, "shared", 10)
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "old", SHA256: "shared", Size: 10, LastAccessedAt: now.Add(-2 * time.Hour)}))
+			require.NoError(t, s.putEntry(ctx, cacheEntry{Key: "new", SHA256: "shared", Size: 10, LastAccessedAt: now}))
+
+			evicted, err := s.evict(ctx, time.Hour, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"old"}, evicted)
+
+			exists, err := s.hasArchive(ctx, "shared")
+			require.NoError(t, err)
+			assert.True(t, exists)
+		},
+		"DownloadArchiveFailsWithHashMismatch": func(t *testing.T, s *cacheStore) {
+			putArchive(t, s, "not-the-hash", 10)
+			err := s.downloadArchive(ctx, cacheEntry{Key: "key", SHA256: "not-the-hash"}, t.TempDir()+"/archive.tar.gz")
+			assert.Error(t, err)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+			require.NoError(t, err)
+			tCase(t, &cacheStore{bucket: bucket})
+		})
+	}
+}
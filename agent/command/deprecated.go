@@ -7,6 +7,15 @@ import (
 	"github.com/evergreen-ci/evergreen/agent/internal/client"
 )
 
+// DeprecatedCommands maps the names of deprecated commands to the names of the
+// commands that replace them. An empty replacement means that the command is
+// a no-op because its functionality has been folded into another command, so
+// it can be removed from the project configuration.
+var DeprecatedCommands = map[string]string{
+	"git.apply_patch": "",
+	"manifest.load":   "",
+}
+
 // gitApplyPatch is deprecated. Its functionality is now a part of GitGetProjectCommand.
 type gitApplyPatch struct{ base }
 
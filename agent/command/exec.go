@@ -154,6 +154,8 @@ func (c *subprocessExec) doExpansions(exp *util.Expansions) error {
 
 type modifyEnvOptions struct {
 	taskID                 string
+	localAPIURL            string
+	localAPIToken          string
 	workingDir             string
 	tmpDir                 string
 	expansions             util.Expansions
@@ -198,6 +200,10 @@ func defaultAndApplyExpansionsToEnv(env map[string]string, opts modifyEnvOptions
 
 	env[agentutil.MarkerTaskID] = opts.taskID
 	env[agentutil.MarkerAgentPID] = strconv.Itoa(os.Getpid())
+	if opts.localAPIURL != "" {
+		env[internal.LocalAPIURLEnv] = opts.localAPIURL
+		env[internal.LocalAPITokenEnv] = opts.localAPIToken
+	}
 
 	addTempDirs(env, opts.tmpDir)
 
@@ -352,6 +358,8 @@ func (c *subprocessExec) Execute(ctx context.Context, comm client.Communicator,
 
 	c.Env = defaultAndApplyExpansionsToEnv(c.Env, modifyEnvOptions{
 		taskID:                 conf.Task.Id,
+		localAPIURL:            conf.LocalAPIURL,
+		localAPIToken:          conf.LocalAPIToken,
 		workingDir:             c.WorkingDir,
 		tmpDir:                 taskTmpDir,
 		expansions:             *conf.Expansions,
@@ -540,6 +540,17 @@ func TestDefaultAndApplyExpansionsToEnv(t *testing.T) {
 			assert.Equal(t, strconv.Itoa(os.Getpid()), env[agentutil.MarkerAgentPID])
 			assert.Equal(t, opts.taskID, env[agentutil.MarkerTaskID])
 		},
+		"SetsLocalAPIEnvVarsWhenGiven": func(t *testing.T, exp util.Expansions) {
+			opts := modifyEnvOptions{
+				taskID:        "task_id",
+				localAPIURL:   "http://127.0.0.1:2285/task",
+				localAPIToken: "token",
+			}
+			env := defaultAndApplyExpansionsToEnv(map[string]string{}, opts)
+			assert.Len(t, env, 9)
+			assert.Equal(t, opts.localAPIURL, env[internal.LocalAPIURLEnv])
+			assert.Equal(t, opts.localAPIToken, env[internal.LocalAPITokenEnv])
+		},
 		"ExplicitlySetEnvVarsOverrideDefaultEnvVars": func(t *testing.T, exp util.Expansions) {
 			gocache := "/path/to/gocache"
 			ci := "definitely not Jenkins"
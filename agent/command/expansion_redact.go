@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/utility"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// secretParams are the names of command parameters whose values are always
+// redacted from task logs.
+var secretParams = []string{"token", "secret", "aws_secret", "aws_session_token"}
+
+// expansionsRedact registers the values of expansions as secrets, so that they
+// are redacted from the task's logs for the rest of the task.
+type expansionsRedact struct {
+	// Keys are the names of the expansions whose values should be redacted.
+	Keys []string `mapstructure:"keys"`
+
+	base
+}
+
+func redactExpansionsFactory() Command   { return &expansionsRedact{} }
+func (c *expansionsRedact) Name() string { return "expansions.redact" }
+
+func (c *expansionsRedact) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, c); err != nil {
+		return errors.Wrap(err, "decoding mapstructure params")
+	}
+	if len(c.Keys) == 0 {
+		return errors.New("must specify at least one expansion to redact")
+	}
+	return nil
+}
+
+func (c *expansionsRedact) Execute(ctx context.Context,
+	_ client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+	for _, key := range c.Keys {
+		if !conf.Expansions.Exists(key) {
+			logger.Task().Warningf("Expansion '%s' is not set, so there is nothing to redact.", key)
+			continue
+		}
+		conf.Redactor.Add(key, conf.Expansions.Get(key))
+	}
+	logger.Task().Infof("Redacting %d expansion(s) from the task logs.", len(c.Keys))
+	return nil
+}
+
+// RegisterRedactedExpansions registers the values of the task's private
+// variables and of sensitive expansions set by Evergreen with the task
+// config's redactor.
+func RegisterRedactedExpansions(conf *internal.TaskConfig) {
+	for name := range conf.Redacted {
+		conf.Redactor.Add(name, conf.Expansions.Get(name))
+	}
+	for _, name := range expansionsToRedact {
+		conf.Redactor.Add(name, conf.Expansions.Get(name))
+	}
+}
+
+// RegisterSecretParams registers the values of the command's secret
+// parameters, with expansions applied, with the task config's redactor. It
+// should be called before the command is executed so that secrets are redacted
+// even if the command logs them.
+func RegisterSecretParams(cmd Command, conf *internal.TaskConfig) {
+	v := reflect.Indirect(reflect.ValueOf(cmd))
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() != reflect.String || !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("mapstructure")
+		if !utility.StringSliceContains(secretParams, name) {
+			continue
+		}
+		value, err := conf.Expansions.ExpandString(v.Field(i).String())
+		if err != nil {
+			continue
+		}
+		conf.Redactor.Add(name, value)
+	}
+}
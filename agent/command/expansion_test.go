@@ -8,6 +8,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/agent/internal"
 	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/util"
@@ -110,3 +111,55 @@ func TestExpansionWriter(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal("baz: qux\nfoo: bar\npassword: hunter2\n", string(out))
 }
+
+func TestExpansionsRedact(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	comm := client.NewMock("http://localhost.com")
+
+	t.Run("ParseParamsRequiresKeys", func(t *testing.T) {
+		cmd := &expansionsRedact{}
+		assert.Error(t, cmd.ParseParams(map[string]interface{}{}))
+		assert.NoError(t, cmd.ParseParams(map[string]interface{}{"keys": []string{"password"}}))
+		assert.Equal(t, []string{"password"}, cmd.Keys)
+	})
+	t.Run("RegistersExpansionValues", func(t *testing.T) {
+		conf := &internal.TaskConfig{
+			Expansions: util.NewExpansions(map[string]string{"password": "generated-password"}),
+			Task:       &task.Task{},
+			Redactor:   redactor.New(),
+		}
+		logger, err := comm.GetLoggerProducer(ctx, client.TaskData{}, nil)
+		require.NoError(t, err)
+
+		cmd := &expansionsRedact{Keys: []string{"password", "nonexistent"}}
+		require.NoError(t, cmd.Execute(ctx, comm, logger, conf))
+		assert.Equal(t, "echo <REDACTED:password>", conf.Redactor.Redact("echo generated-password"))
+	})
+}
+
+func TestRegisterRedactedExpansions(t *testing.T) {
+	conf := &internal.TaskConfig{
+		Expansions: util.NewExpansions(map[string]string{
+			"private":                            "private-value",
+			"public":                             "public-value",
+			evergreen.GlobalGitHubTokenExpansion: "global-token",
+		}),
+		Redacted: map[string]bool{"private": true},
+		Redactor: redactor.New(),
+	}
+	RegisterRedactedExpansions(conf)
+	assert.Equal(t, "<REDACTED:private> public-value <REDACTED:global_github_oauth_token>",
+		conf.Redactor.Redact("private-value public-value global-token"))
+}
+
+func TestRegisterSecretParams(t *testing.T) {
+	conf := &internal.TaskConfig{
+		Expansions: util.NewExpansions(map[string]string{"secret_key": "expanded-secret"}),
+		Redactor:   redactor.New(),
+	}
+	RegisterSecretParams(&s3put{AwsKey: "public-key-id", AwsSecret: "${secret_key}"}, conf)
+	RegisterSecretParams(&gitFetchProject{Token: "literal-token"}, conf)
+	assert.Equal(t, "public-key-id <REDACTED:aws_secret> <REDACTED:token>",
+		conf.Redactor.Redact("public-key-id expanded-secret literal-token"))
+}
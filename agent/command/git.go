@@ -78,6 +78,7 @@ type gitFetchProject struct {
 type cloneOpts struct {
 	method                 string
 	location               string
+	gitURL                 string
 	owner                  string
 	repo                   string
 	branch                 string
@@ -104,7 +105,11 @@ func (opts cloneOpts) validate() error {
 	if opts.method != "" {
 		catcher.Wrapf(evergreen.ValidateCloneMethod(opts.method), "invalid clone method '%s'", opts.method)
 	}
-	if opts.method == evergreen.CloneMethodOAuth && opts.token == "" {
+	if opts.gitURL != "" {
+		// Task hosts can't read local repositories on the app server.
+		catcher.Add(thirdparty.ValidateGitURL(opts.gitURL, false))
+	}
+	if opts.method == evergreen.CloneMethodOAuth && opts.token == "" && opts.gitURL == "" {
 		catcher.New("cannot clone using OAuth if token is not set")
 	}
 	if opts.cloneDepth < 0 {
@@ -121,8 +126,13 @@ func (opts cloneOpts) httpLocation() string {
 	return fmt.Sprintf("https://github.com/%s/%s.git", opts.owner, opts.repo)
 }
 
-// setLocation sets the location to clone from.
+// setLocation sets the location to clone from. Projects that aren't hosted on
+// GitHub always clone from their git URL.
 func (opts *cloneOpts) setLocation() error {
+	if opts.gitURL != "" {
+		opts.location = opts.gitURL
+		return nil
+	}
 	switch opts.method {
 	case "", evergreen.CloneMethodLegacySSH:
 		opts.location = opts.sshLocation()
@@ -134,6 +144,38 @@ func (opts *cloneOpts) setLocation() error {
 	return nil
 }
 
+// redact returns s with the OAuth token and any credentials in the git URL
+// replaced.
+func (opts cloneOpts) redact(s string) string {
+	if opts.token != "" {
+		s = strings.Replace(s, opts.token, "[redacted oauth token]", -1)
+	}
+	if opts.gitURL != "" {
+		s = strings.Replace(s, opts.gitURL, thirdparty.RedactGitURL(opts.gitURL), -1)
+	}
+	return s
+}
+
+// registerGitURLCredentials registers the credentials embedded in a git URL
+// with the task's redactor so that they're redacted wherever git echoes them
+// in the task logs. A bare SSH user name such as "git" isn't a credential.
+func registerGitURLCredentials(conf *internal.TaskConfig, gitURL string) {
+	if !strings.Contains(gitURL, "://") {
+		return
+	}
+	u, err := url.Parse(gitURL)
+	if err != nil || u.User == nil {
+		return
+	}
+	if password, ok := u.User.Password(); ok {
+		conf.Redactor.Add("git_url_password", password)
+		return
+	}
+	if u.Scheme != "ssh" {
+		conf.Redactor.Add("git_url_user", u.User.Username())
+	}
+}
+
 // getProjectMethodAndToken returns the project's clone method and token. If
 // set, the project token takes precedence over global settings.
 func getProjectMethodAndToken(projectToken, globalToken, appToken, globalCloneMethod string) (string, string, error) {
@@ -182,6 +224,11 @@ func (opts cloneOpts) getCloneCommand() ([]string, error) {
 	if err := opts.validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid clone command options")
 	}
+	if opts.gitURL != "" {
+		// The git URL is expected to contain any credentials needed to
+		// clone it, so it's cloned as-is.
+		return opts.buildSSHCloneCommand()
+	}
 	switch opts.method {
 	case "", evergreen.CloneMethodLegacySSH:
 		return opts.buildSSHCloneCommand()
@@ -447,6 +494,7 @@ func (c *gitFetchProject) opts(projectMethod, projectToken string, logger client
 	shallowCloneEnabled := conf.Distro == nil || !conf.Distro.DisableShallowClone
 	opts := cloneOpts{
 		method:                 projectMethod,
+		gitURL:                 conf.ProjectRef.GitURL,
 		owner:                  conf.ProjectRef.Owner,
 		repo:                   conf.ProjectRef.Repo,
 		branch:                 conf.ProjectRef.Branch,
@@ -470,6 +518,7 @@ func (c *gitFetchProject) opts(projectMethod, projectToken string, logger client
 		}
 	}
 
+	registerGitURLCredentials(conf, opts.gitURL)
 	if err := opts.setLocation(); err != nil {
 		return opts, errors.Wrap(err, "setting location to clone from")
 	}
@@ -579,11 +628,7 @@ func (c *gitFetchProject) fetchSource(ctx context.Context,
 		SetOutputSender(level.Info, logger.Task().GetSender()).SetErrorWriter(stdErr)
 
 	logger.Execution().Info("Fetching source from git...")
-	redactedCmds := fetchScript
-	if opts.token != "" {
-		redactedCmds = strings.Replace(redactedCmds, opts.token, "[redacted oauth token]", -1)
-	}
-	logger.Execution().Debugf("Commands are: %s", redactedCmds)
+	logger.Execution().Debugf("Commands are: %s", opts.redact(fetchScript))
 
 	ctx, span := getTracer().Start(ctx, "clone_source", trace.WithAttributes(
 		attribute.String(cloneOwnerAttribute, opts.owner),
@@ -595,10 +640,7 @@ func (c *gitFetchProject) fetchSource(ctx context.Context,
 	err = fetchSourceCmd.Run(ctx)
 	out := stdErr.String()
 	if out != "" {
-		if opts.token != "" {
-			out = strings.Replace(out, opts.token, "[redacted oauth token]", -1)
-		}
-		logger.Execution().Error(out)
+		logger.Execution().Error(opts.redact(out))
 	}
 	return err
 }
@@ -819,8 +861,10 @@ func (c *gitFetchProject) fetch(ctx context.Context,
 		}
 	}
 
-	// Apply additional patches for commit queue batch execution.
-	if conf.Task.Requester == evergreen.MergeTestRequester && !conf.Task.CommitQueueMerge {
+	// Apply additional patches for commit queue batch execution. Merge tasks
+	// only get the patches batched into their own merge patch, since earlier
+	// items have already merged.
+	if conf.Task.Requester == evergreen.MergeTestRequester {
 		for _, patchId := range additionalPatches {
 			err := c.applyAdditionalPatch(ctx, comm, logger, conf, td, patchId, opts.useVerbose)
 			if err != nil {
@@ -22,12 +22,17 @@ func init() {
 		"archive.zip_pack":                      zipArchiveCreateFactory,
 		"archive.zip_extract":                   zipExtractFactory,
 		"archive.auto_extract":                  autoExtractFactory,
+		"archive.tar_pack":                      tarCreateFactory,
+		"archive.tar_extract":                   tarExtractFactory,
+		"cache.restore":                         cacheRestoreFactory,
+		"cache.save":                            cacheSaveFactory,
 		evergreen.AttachResultsCommandName:      attachResultsFactory,
 		evergreen.AttachXUnitResultsCommandName: xunitResultsFactory,
 		evergreen.AttachArtifactsCommandName:    attachArtifactsFactory,
 		evergreen.HostCreateCommandName:         createHostFactory,
 		"ec2.assume_role":                       ec2AssumeRoleFactory,
 		"host.list":                             listHostFactory,
+		"expansions.redact":                     redactExpansionsFactory,
 		"expansions.update":                     updateExpansionsFactory,
 		"expansions.write":                      writeExpansionsFactory,
 		"generate.tasks":                        generateTaskFactory,
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -127,14 +128,32 @@ func (c *attachResults) Execute(ctx context.Context,
 		return errors.Wrapf(err, "reading report file '%s'", reportFileLoc)
 	}
 
-	if err := c.sendTestLogs(ctx, conf, logger, comm, &nativeResults); err != nil {
+	return sendNativeTestResults(ctx, conf, logger, comm, &nativeResults)
+}
+
+// AttachTestResults reads test results in the same JSON format as the
+// attach.results command and attaches them to the task.
+func AttachTestResults(ctx context.Context, comm client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig, r io.ReadCloser) error {
+	var nativeResults nativeTestResults
+	if err := utility.ReadJSON(r, &nativeResults); err != nil {
+		return errors.Wrap(err, "reading test results")
+	}
+	if len(nativeResults.Results) == 0 {
+		return errors.New("must specify at least one test result")
+	}
+
+	return sendNativeTestResults(ctx, conf, logger, comm, &nativeResults)
+}
+
+func sendNativeTestResults(ctx context.Context, conf *internal.TaskConfig, logger client.LoggerProducer, comm client.Communicator, results *nativeTestResults) error {
+	if err := sendNativeTestLogs(ctx, conf, logger, comm, results); err != nil {
 		return errors.Wrap(err, "sending test logs")
 	}
 
-	return sendTestResults(ctx, comm, logger, conf, nativeResults.convertToService())
+	return sendTestResults(ctx, comm, logger, conf, results.convertToService())
 }
 
-func (c *attachResults) sendTestLogs(ctx context.Context, conf *internal.TaskConfig, logger client.LoggerProducer, comm client.Communicator, results *nativeTestResults) error {
+func sendNativeTestLogs(ctx context.Context, conf *internal.TaskConfig, logger client.LoggerProducer, comm client.Communicator, results *nativeTestResults) error {
 	logger.Execution().Info("Posting test logs...")
 	for i, res := range results.Results {
 		if err := ctx.Err(); err != nil {
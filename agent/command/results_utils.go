@@ -38,9 +38,15 @@ func sendTestResults(ctx context.Context, comm client.Communicator, logger clien
 	return nil
 }
 
-// sendTestLog sends test logs to the backend logging service.
+// sendTestLog sends test logs to the backend logging service. Secrets
+// registered with the task's redactor are redacted from the sent log.
 func sendTestLog(ctx context.Context, comm client.Communicator, conf *internal.TaskConfig, log *model.TestLog) error {
-	return errors.Wrap(sendTestLogToCedar(ctx, conf.Task, comm, log), "sending test logs to Cedar")
+	redacted := *log
+	redacted.Lines = make([]string, 0, len(log.Lines))
+	for _, line := range log.Lines {
+		redacted.Lines = append(redacted.Lines, conf.Redactor.Redact(line))
+	}
+	return errors.Wrap(sendTestLogToCedar(ctx, conf.Task, comm, &redacted), "sending test logs to Cedar")
 }
 
 // sendTestLogsAndResults sends the test logs and test results to backend
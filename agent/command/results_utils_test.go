@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/agent/internal"
 	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/testresult"
@@ -259,6 +260,27 @@ func TestSendTestLog(t *testing.T) {
 
 				},
 			},
+			{
+				name: "RedactsSecrets",
+				testCase: func(t *testing.T, srv *timberutil.MockBuildloggerServer) {
+					redactedConf := &internal.TaskConfig{
+						Task:       conf.Task,
+						ProjectRef: conf.ProjectRef,
+						Redactor:   redactor.New(),
+					}
+					redactedConf.Redactor.Add("password", "line 2")
+					require.NoError(t, sendTestLog(ctx, comm, redactedConf, log))
+
+					require.Len(t, srv.Data, 1)
+					for _, data := range srv.Data {
+						require.Len(t, data, 1)
+						require.Len(t, data[0].Lines, 2)
+						assert.EqualValues(t, "log line 1", data[0].Lines[0].Data)
+						assert.EqualValues(t, "log <REDACTED:password>", data[0].Lines[1].Data)
+					}
+					assert.Equal(t, "log line 2", log.Lines[1], "original log should not be modified")
+				},
+			},
 		} {
 			t.Run(test.name, func(t *testing.T) {
 				srv := setupCedarServer(ctx, t, comm)
@@ -2,8 +2,10 @@ package command
 
 import (
 	"context"
+	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/endpoints"
@@ -48,6 +50,9 @@ type s3copy struct {
 	//  E.g. text/html, application/pdf, image/jpeg, ...
 	ContentType string `mapstructure:"content_type" plugin:"expand"`
 
+	// Concurrency is the number of files to copy at once. Defaults to 1.
+	Concurrency int `mapstructure:"concurrency"`
+
 	base
 }
 
@@ -128,6 +133,8 @@ func (c *s3copy) validate() error {
 		catcher.New("AWS secret cannot be blank")
 	}
 
+	catcher.NewWhen(c.Concurrency < 0, "concurrency cannot be negative")
+
 	for _, s3CopyFile := range c.S3CopyFiles {
 		if s3CopyFile.Source.Path == "" {
 			catcher.New("S3 source path cannot be blank")
@@ -194,146 +201,215 @@ func (c *s3copy) Execute(ctx context.Context,
 
 func (c *s3copy) copyWithRetry(ctx context.Context,
 	comm client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig) error {
-	backoffCounter := getS3OpBackoff()
-	timer := time.NewTimer(0)
-	defer timer.Stop()
-
 	td := client.TaskData{ID: conf.Task.Id, Secret: conf.Task.Secret}
 
 	client := utility.GetHTTPClient()
 	client.Timeout = 10 * time.Minute
 	defer utility.PutHTTPClient(client)
-	for _, s3CopyFile := range c.S3CopyFiles {
-		logger.Task().WarningWhen(strings.Contains(s3CopyFile.Destination.Bucket, "."), "Destination bucket names containing dots that are created after Sept. 30, 2020 are not guaranteed to have valid attached URLs.")
-
-		timer.Reset(0)
 
+	toCopy := make(chan *s3CopyFile, len(c.S3CopyFiles))
+	for _, s3CopyFile := range c.S3CopyFiles {
 		if len(s3CopyFile.BuildVariants) > 0 && !utility.StringSliceContains(
 			s3CopyFile.BuildVariants, conf.BuildVariant.Name) {
 			continue
 		}
+		toCopy <- s3CopyFile
+	}
+	close(toCopy)
 
-		if err := ctx.Err(); err != nil {
-			return errors.Wrap(err, "command was cancelled")
-		}
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
 
-		logger.Execution().Infof("Making API push copy call to "+
-			"transfer %v/%v => %v/%v", s3CopyFile.Source.Bucket,
-			s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket,
-			s3CopyFile.Destination.Path)
-
-		s3CopyReq := apimodels.S3CopyRequest{
-			S3SourceRegion:      s3CopyFile.Source.Region,
-			S3SourceBucket:      s3CopyFile.Source.Bucket,
-			S3SourcePath:        s3CopyFile.Source.Path,
-			S3DestinationRegion: s3CopyFile.Destination.Region,
-			S3DestinationBucket: s3CopyFile.Destination.Bucket,
-			S3DestinationPath:   s3CopyFile.Destination.Path,
-			S3DisplayName:       s3CopyFile.DisplayName,
-			S3Permissions:       s3CopyFile.Permissions,
-		}
-		newPushLog, err := comm.NewPush(ctx, td, &s3CopyReq)
-		if err != nil {
-			return errors.Wrap(err, "adding push log")
-		}
-		if newPushLog.TaskId == "" {
-			logger.Task().Infof("noop, this version is currently in the process of trying to push, or has already succeeded in pushing the file: '%s/%s'", s3CopyFile.Destination.Bucket, s3CopyFile.Destination.Path)
-			continue
-		}
+	// Stop copying the remaining files as soon as a file fails to copy.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	catcher := grip.NewBasicCatcher()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s3CopyFile := range toCopy {
+				if err := ctx.Err(); err != nil {
+					return
+				}
+				if err := c.copyFile(ctx, comm, logger, td, client, s3CopyFile); err != nil {
+					catcher.Add(err)
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
 
-		s3CopyReq.AwsKey = c.AwsKey
-		s3CopyReq.AwsSecret = c.AwsSecret
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+	return errors.Wrap(ctx.Err(), "command was cancelled")
+}
 
-		srcOpts := pail.S3Options{
-			Credentials: pail.CreateAWSCredentials(s3CopyReq.AwsKey, s3CopyReq.AwsSecret, ""),
-			Region:      s3CopyReq.S3SourceRegion,
-			Name:        s3CopyReq.S3SourceBucket,
-			Permissions: pail.S3Permissions(s3CopyReq.S3Permissions),
-		}
+// copyFile copies a single file from its source to its destination,
+// retrying if the copy fails.
+func (c *s3copy) copyFile(ctx context.Context, comm client.Communicator, logger client.LoggerProducer,
+	td client.TaskData, httpClient *http.Client, s3CopyFile *s3CopyFile) error {
+	backoffCounter := getS3OpBackoff()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
-		srcBucket, err := pail.NewS3MultiPartBucketWithHTTPClient(client, srcOpts)
-		if err != nil {
-			catcher := grip.NewBasicCatcher()
-			catcher.Wrap(err, "initializing S3 source bucket")
+	logger.Task().WarningWhen(strings.Contains(s3CopyFile.Destination.Bucket, "."), "Destination bucket names containing dots that are created after Sept. 30, 2020 are not guaranteed to have valid attached URLs.")
+
+	logger.Execution().Infof("Making API push copy call to "+
+		"transfer %v/%v => %v/%v", s3CopyFile.Source.Bucket,
+		s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket,
+		s3CopyFile.Destination.Path)
+
+	s3CopyReq := apimodels.S3CopyRequest{
+		S3SourceRegion:      s3CopyFile.Source.Region,
+		S3SourceBucket:      s3CopyFile.Source.Bucket,
+		S3SourcePath:        s3CopyFile.Source.Path,
+		S3DestinationRegion: s3CopyFile.Destination.Region,
+		S3DestinationBucket: s3CopyFile.Destination.Bucket,
+		S3DestinationPath:   s3CopyFile.Destination.Path,
+		S3DisplayName:       s3CopyFile.DisplayName,
+		S3Permissions:       s3CopyFile.Permissions,
+	}
+	newPushLog, err := comm.NewPush(ctx, td, &s3CopyReq)
+	if err != nil {
+		return errors.Wrap(err, "adding push log")
+	}
+	if newPushLog.TaskId == "" {
+		logger.Task().Infof("noop, this version is currently in the process of trying to push, or has already succeeded in pushing the file: '%s/%s'", s3CopyFile.Destination.Bucket, s3CopyFile.Destination.Path)
+		return nil
+	}
 
-			newPushLog.Status = pushLogFailed
-			catcher.Wrap(comm.UpdatePushStatus(ctx, td, newPushLog), "updating push log to failed")
+	s3CopyReq.AwsKey = c.AwsKey
+	s3CopyReq.AwsSecret = c.AwsSecret
 
-			return catcher.Resolve()
-		}
+	srcOpts := pail.S3Options{
+		Credentials: pail.CreateAWSCredentials(s3CopyReq.AwsKey, s3CopyReq.AwsSecret, ""),
+		Region:      s3CopyReq.S3SourceRegion,
+		Name:        s3CopyReq.S3SourceBucket,
+		Permissions: pail.S3Permissions(s3CopyReq.S3Permissions),
+	}
 
-		if err := srcBucket.Check(ctx); err != nil {
-			catcher := grip.NewBasicCatcher()
-			catcher.Wrap(err, "checking bucket")
+	srcBucket, err := pail.NewS3MultiPartBucketWithHTTPClient(httpClient, srcOpts)
+	if err != nil {
+		catcher := grip.NewBasicCatcher()
+		catcher.Wrap(err, "initializing S3 source bucket")
 
-			newPushLog.Status = pushLogFailed
-			catcher.Wrap(comm.UpdatePushStatus(ctx, td, newPushLog), "updating push log to failed")
+		newPushLog.Status = pushLogFailed
+		catcher.Wrap(comm.UpdatePushStatus(ctx, td, newPushLog), "updating push log to failed")
 
-			return catcher.Resolve()
-		}
-		destOpts := pail.S3Options{
-			Credentials: pail.CreateAWSCredentials(s3CopyReq.AwsKey, s3CopyReq.AwsSecret, ""),
-			Region:      s3CopyReq.S3DestinationRegion,
-			Name:        s3CopyReq.S3DestinationBucket,
-			Permissions: pail.S3Permissions(s3CopyReq.S3Permissions),
-		}
-		destBucket, err := pail.NewS3MultiPartBucket(destOpts)
-		if err != nil {
-			catcher := grip.NewBasicCatcher()
-			catcher.Wrap(err, "initializing S3 destination bucket")
+		return catcher.Resolve()
+	}
 
-			newPushLog.Status = pushLogFailed
-			catcher.Wrap(comm.UpdatePushStatus(ctx, td, newPushLog), "updating push log to failed")
+	if err := srcBucket.Check(ctx); err != nil {
+		catcher := grip.NewBasicCatcher()
+		catcher.Wrap(err, "checking bucket")
 
-			return catcher.Resolve()
-		}
+		newPushLog.Status = pushLogFailed
+		catcher.Wrap(comm.UpdatePushStatus(ctx, td, newPushLog), "updating push log to failed")
+
+		return catcher.Resolve()
+	}
+	destOpts := pail.S3Options{
+		Credentials: pail.CreateAWSCredentials(s3CopyReq.AwsKey, s3CopyReq.AwsSecret, ""),
+		Region:      s3CopyReq.S3DestinationRegion,
+		Name:        s3CopyReq.S3DestinationBucket,
+		Permissions: pail.S3Permissions(s3CopyReq.S3Permissions),
+	}
+	destBucket, err := pail.NewS3MultiPartBucket(destOpts)
+	if err != nil {
+		catcher := grip.NewBasicCatcher()
+		catcher.Wrap(err, "initializing S3 destination bucket")
+
+		newPushLog.Status = pushLogFailed
+		catcher.Wrap(comm.UpdatePushStatus(ctx, td, newPushLog), "updating push log to failed")
 
-	retryLoop:
-		for i := 0; i < maxS3OpAttempts; i++ {
-			select {
-			case <-ctx.Done():
-				return errors.Errorf("command '%s' canceled", c.Name())
-			case <-timer.C:
-				copyOpts := pail.CopyOptions{
-					SourceKey:         s3CopyReq.S3SourcePath,
-					DestinationKey:    s3CopyReq.S3DestinationPath,
-					DestinationBucket: destBucket,
+		return catcher.Resolve()
+	}
+
+retryLoop:
+	for i := 0; i < maxS3OpAttempts; i++ {
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("command '%s' canceled", c.Name())
+		case <-timer.C:
+			copyOpts := pail.CopyOptions{
+				SourceKey:         s3CopyReq.S3SourcePath,
+				DestinationKey:    s3CopyReq.S3DestinationPath,
+				DestinationBucket: destBucket,
+			}
+			err = srcBucket.Copy(ctx, copyOpts)
+			if err == nil {
+				err = c.verifyCopy(ctx, httpClient, s3CopyReq)
+			}
+			if err != nil {
+				newPushLog.Status = pushLogFailed
+				if err := comm.UpdatePushStatus(ctx, td, newPushLog); err != nil {
+					return errors.Wrap(err, "updating push log status failed for task")
 				}
-				err = srcBucket.Copy(ctx, copyOpts)
-				if err != nil {
-					newPushLog.Status = pushLogFailed
-					if err := comm.UpdatePushStatus(ctx, td, newPushLog); err != nil {
-						return errors.Wrap(err, "updating push log status failed for task")
-					}
-					if s3CopyFile.Optional {
-						logger.Execution().Error(err)
-						logger.Execution().Errorf("S3 push copy failed to copy '%s' to '%s' and file is optional, continuing.",
-							s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket)
-						timer.Reset(backoffCounter.Duration())
-						continue retryLoop
-					} else {
-						logger.Execution().Errorf("S3 push copy failed to copy '%s' to '%s' and file is not optional, exiting.", s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket)
-						return errors.Wrapf(err, "S3 push copy failed to copy '%s' to '%s'", s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket)
-					}
+				if s3CopyFile.Optional {
+					logger.Execution().Error(err)
+					logger.Execution().Errorf("S3 push copy failed to copy '%s' to '%s' and file is optional, continuing.",
+						s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket)
+					timer.Reset(backoffCounter.Duration())
+					continue retryLoop
 				} else {
-					newPushLog.Status = pushLogSuccess
-					if err := comm.UpdatePushStatus(ctx, td, newPushLog); err != nil {
-						return errors.Wrap(err, "updating push log status to success for task")
-					}
-					if err = c.attachFiles(ctx, comm, logger, td, s3CopyReq); err != nil {
-						return errors.Wrap(err, "attaching files")
-					}
-					break retryLoop
+					logger.Execution().Errorf("S3 push copy failed to copy '%s' to '%s' and file is not optional, exiting.", s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket)
+					return errors.Wrapf(err, "S3 push copy failed to copy '%s' to '%s'", s3CopyFile.Source.Path, s3CopyFile.Destination.Bucket)
+				}
+			} else {
+				newPushLog.Status = pushLogSuccess
+				if err := comm.UpdatePushStatus(ctx, td, newPushLog); err != nil {
+					return errors.Wrap(err, "updating push log status to success for task")
+				}
+				if err = c.attachFiles(ctx, comm, logger, td, s3CopyReq); err != nil {
+					return errors.Wrap(err, "attaching files")
 				}
+				break retryLoop
 			}
 		}
-
-		logger.Task().Infof("Successfully copied source file '%s' to destination path '%s'.", s3CopyFile.Source.Path, s3CopyFile.Destination.Path)
 	}
 
+	logger.Task().Infof("Successfully copied source file '%s' to destination path '%s'.", s3CopyFile.Source.Path, s3CopyFile.Destination.Path)
+
 	return nil
 }
 
+// verifyCopy checks that the copied file has the same SHA-256 digest as the
+// source file, if the source file has a recorded digest. The digest is read
+// from the objects' metadata rather than computed by the agent, so it's not
+// attached to the copied file.
+func (c *s3copy) verifyCopy(ctx context.Context, httpClient *http.Client, request apimodels.S3CopyRequest) error {
+	srcClient, err := newS3Client(httpClient, c.AwsKey, c.AwsSecret, request.S3SourceRegion)
+	if err != nil {
+		return errors.Wrap(err, "creating S3 source client")
+	}
+	srcDigest, err := getS3ObjectSHA256(ctx, srcClient, request.S3SourceBucket, request.S3SourcePath)
+	if err != nil {
+		return errors.Wrap(err, "getting digest of source file")
+	}
+	if srcDigest == "" {
+		return nil
+	}
+
+	destClient, err := newS3Client(httpClient, c.AwsKey, c.AwsSecret, request.S3DestinationRegion)
+	if err != nil {
+		return errors.Wrap(err, "creating S3 destination client")
+	}
+	destDigest, err := getS3ObjectSHA256(ctx, destClient, request.S3DestinationBucket, request.S3DestinationPath)
+	if err != nil {
+		return errors.Wrap(err, "getting digest of destination file")
+	}
+
+	return checkSHA256(request.S3DestinationPath, srcDigest, destDigest)
+}
+
 // attachFiles is responsible for sending the specified file to the API Server.
 func (c *s3copy) attachFiles(ctx context.Context, comm client.Communicator,
 	logger client.LoggerProducer, td client.TaskData, request apimodels.S3CopyRequest) error {
@@ -346,8 +422,11 @@ func (c *s3copy) attachFiles(ctx context.Context, comm client.Communicator,
 	}
 	logger.Execution().Infof("Attaching file '%s'.", displayName)
 	file := artifact.File{
-		Name: displayName,
-		Link: fileLink,
+		Name:    displayName,
+		Link:    fileLink,
+		Bucket:  request.S3DestinationBucket,
+		FileKey: remotePath,
+		Region:  request.S3DestinationRegion,
 	}
 	files := []*artifact.File{&file}
 	if err := comm.AttachFiles(ctx, td, files); err != nil {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/evergreen-ci/evergreen/agent/internal"
 	"github.com/evergreen-ci/evergreen/agent/internal/client"
 	agentutil "github.com/evergreen-ci/evergreen/agent/util"
@@ -47,7 +48,21 @@ type s3get struct {
 	LocalFile string `mapstructure:"local_file" plugin:"expand"`
 	ExtractTo string `mapstructure:"extract_to" plugin:"expand"`
 
+	// SHA256 is the expected hex-encoded SHA-256 digest of the remote file.
+	// If it's not set, the file is verified against the digest that s3.put
+	// recorded when it uploaded the file, if any.
+	SHA256 string `mapstructure:"sha256" plugin:"expand"`
+
+	// PartConcurrency is the number of parts of the file to download at
+	// once. Defaults to 4.
+	PartConcurrency int `mapstructure:"part_concurrency"`
+
+	// PartSizeMB is the size in MB of each part of the file to download.
+	// Defaults to 16.
+	PartSizeMB int `mapstructure:"part_size_mb"`
+
 	bucket pail.Bucket
+	client s3iface.S3API
 
 	base
 }
@@ -91,6 +106,10 @@ func (c *s3get) validateParams() error {
 		return errors.Wrapf(err, "validating bucket name '%s'", c.Bucket)
 	}
 
+	if err := validateS3TransferParams(0, c.PartConcurrency, c.PartSizeMB); err != nil {
+		return errors.Wrap(err, "validating transfer params")
+	}
+
 	// make sure local file and extract-to dir aren't both specified
 	if c.LocalFile != "" && c.ExtractTo != "" {
 		return errors.New("cannot specify both local file path and directory to extract to")
@@ -212,7 +231,7 @@ func (c *s3get) getWithRetry(ctx context.Context, logger client.LoggerProducer)
 		case <-ctx.Done():
 			return errors.Errorf("canceled while running command '%s'", c.Name())
 		case <-timer.C:
-			err := errors.WithStack(c.get(ctx))
+			err := errors.WithStack(c.get(ctx, logger))
 			if err == nil {
 				return nil
 			}
@@ -227,7 +246,12 @@ func (c *s3get) getWithRetry(ctx context.Context, logger client.LoggerProducer)
 }
 
 // Fetch the specified resource from s3.
-func (c *s3get) get(ctx context.Context) error {
+func (c *s3get) get(ctx context.Context, logger client.LoggerProducer) error {
+	expectedDigest, err := c.getExpectedDigest(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "getting digest of remote file '%s'", c.RemoteFile)
+	}
+
 	// either untar the remote, or just write to a file
 	if c.LocalFile != "" {
 		// remove the file, if it exists
@@ -237,23 +261,80 @@ func (c *s3get) get(ctx context.Context) error {
 			}
 		}
 
-		// download to local file
-		return errors.Wrapf(c.bucket.Download(ctx, c.RemoteFile, c.LocalFile),
-			"downloading remote file '%s' to local file '%s'", c.RemoteFile, c.LocalFile)
+		if err := c.download(ctx, c.LocalFile, logger); err != nil {
+			return errors.Wrapf(err, "downloading remote file '%s' to local file '%s'", c.RemoteFile, c.LocalFile)
+		}
+		return c.checkDigest(c.LocalFile, expectedDigest, logger)
+	}
+
+	// Download the archive in full and verify it before extracting it so
+	// that nothing from a corrupt archive is written to the directory.
+	tmpFile, err := os.CreateTemp(filepath.Dir(c.ExtractTo), "s3-get-*.tgz")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary file for archive")
+	}
+	archive := tmpFile.Name()
+	defer func() {
+		logger.Execution().Warning(errors.Wrapf(os.RemoveAll(archive), "removing downloaded archive '%s'", archive))
+	}()
+	if err = tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "closing temporary file '%s'", archive)
+	}
 
+	if err = c.download(ctx, archive, logger); err != nil {
+		return errors.Wrapf(err, "downloading remote file '%s' to local file '%s'", c.RemoteFile, archive)
+	}
+	if err = c.checkDigest(archive, expectedDigest, logger); err != nil {
+		return err
 	}
 
-	reader, err := c.bucket.Reader(ctx, c.RemoteFile)
+	f, err := os.Open(archive)
 	if err != nil {
-		return errors.Wrapf(err, "getting reader for remote file '%s'", c.RemoteFile)
+		return errors.Wrapf(err, "opening archive '%s'", archive)
 	}
-	if err := agentutil.ExtractTarball(ctx, reader, c.ExtractTo, []string{}); err != nil {
+	defer f.Close()
+	if err := agentutil.ExtractTarball(ctx, f, c.ExtractTo, []string{}); err != nil {
 		return errors.Wrapf(err, "extracting file '%s' from archive to destination '%s'", c.RemoteFile, c.ExtractTo)
 	}
 
 	return nil
 }
 
+// download downloads the remote file to the local path.
+func (c *s3get) download(ctx context.Context, path string, logger client.LoggerProducer) error {
+	if c.client != nil {
+		return downloadS3File(ctx, c.client, c.Bucket, c.RemoteFile, path, newS3TransferOptions(c.PartConcurrency, c.PartSizeMB), logger.Execution())
+	}
+	return c.bucket.Download(ctx, c.RemoteFile, path)
+}
+
+// checkDigest checks that the downloaded file has the expected digest, if
+// there is one, and removes the file if it doesn't.
+func (c *s3get) checkDigest(path, expectedDigest string, logger client.LoggerProducer) error {
+	if expectedDigest == "" {
+		return nil
+	}
+	digest, err := util.FileSHA256(path)
+	if err != nil {
+		return errors.Wrapf(err, "computing digest of local file '%s'", path)
+	}
+	if err := checkSHA256(c.RemoteFile, expectedDigest, digest); err != nil {
+		logger.Execution().Warning(errors.Wrapf(os.RemoveAll(path), "removing corrupt local file '%s'", path))
+		return err
+	}
+	return nil
+}
+
+// getExpectedDigest returns the digest that the remote file must have. If
+// it's not specified by the command, it's the digest that was recorded when
+// the file was uploaded, if any.
+func (c *s3get) getExpectedDigest(ctx context.Context) (string, error) {
+	if c.SHA256 != "" || c.client == nil {
+		return c.SHA256, nil
+	}
+	return getS3ObjectSHA256(ctx, c.client, c.Bucket, c.RemoteFile)
+}
+
 func (c *s3get) createPailBucket(httpClient *http.Client) error {
 	opts := pail.S3Options{
 		Credentials: pail.CreateAWSCredentials(c.AwsKey, c.AwsSecret, ""),
@@ -261,6 +342,11 @@ func (c *s3get) createPailBucket(httpClient *http.Client) error {
 		Name:        c.Bucket,
 	}
 	bucket, err := pail.NewS3BucketWithHTTPClient(httpClient, opts)
+	if err != nil {
+		return err
+	}
 	c.bucket = bucket
+
+	c.client, err = newS3Client(httpClient, c.AwsKey, c.AwsSecret, c.Region)
 	return err
 }
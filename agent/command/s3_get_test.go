@@ -1,11 +1,23 @@
 package command
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
 	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/pail"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestS3GetValidateParams(t *testing.T) {
@@ -155,3 +167,108 @@ func TestExpandS3GetParams(t *testing.T) {
 
 	})
 }
+
+func TestS3GetVerifiesDigest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The SHA-256 digest of "foo".
+	const fooDigest = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	comm := client.NewMock("url")
+	logger, err := comm.GetLoggerProducer(ctx, client.TaskData{ID: "task"}, nil)
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "file", Mode: 0644, Size: 3}))
+	_, err = tw.Write([]byte("foo"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	archiveDigest, err := func() (string, error) {
+		path := filepath.Join(t.TempDir(), "archive.tgz")
+		if err := os.WriteFile(path, archive.Bytes(), 0644); err != nil {
+			return "", err
+		}
+		return util.FileSHA256(path)
+	}()
+	require.NoError(t, err)
+
+	for tName, tCase := range map[string]func(t *testing.T, c *s3get){
+		"SucceedsWithMatchingDigest": func(t *testing.T, c *s3get) {
+			c.SHA256 = fooDigest
+			require.NoError(t, c.get(ctx, logger))
+			assert.FileExists(t, c.LocalFile)
+		},
+		"FailsWithMismatchedDigest": func(t *testing.T, c *s3get) {
+			c.SHA256 = strings.Repeat("0", 64)
+			err := c.get(ctx, logger)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "SHA-256 mismatch")
+			assert.NoFileExists(t, c.LocalFile)
+		},
+		"SucceedsWithoutDigest": func(t *testing.T, c *s3get) {
+			require.NoError(t, c.get(ctx, logger))
+			assert.FileExists(t, c.LocalFile)
+		},
+		"UsesRecordedDigest": func(t *testing.T, c *s3get) {
+			client := newMockS3Client()
+			client.objects["remote"] = []byte("foo")
+			client.metadata["remote"] = map[string]*string{s3SHA256MetadataKey: aws.String(fooDigest)}
+			c.client = client
+
+			digest, err := c.getExpectedDigest(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, fooDigest, digest)
+
+			c.SHA256 = "explicit"
+			digest, err = c.getExpectedDigest(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "explicit", digest)
+		},
+		"ExtractsArchiveWithMatchingDigest": func(t *testing.T, c *s3get) {
+			c.RemoteFile = "archive.tgz"
+			c.LocalFile = ""
+			c.ExtractTo = filepath.Join(t.TempDir(), "extracted")
+			c.SHA256 = archiveDigest
+			require.NoError(t, c.get(ctx, logger))
+			assert.FileExists(t, filepath.Join(c.ExtractTo, "file"))
+
+			entries, err := os.ReadDir(filepath.Dir(c.ExtractTo))
+			require.NoError(t, err)
+			assert.Len(t, entries, 1, "downloaded archive should be removed")
+		},
+		"DoesNotExtractArchiveWithMismatchedDigest": func(t *testing.T, c *s3get) {
+			c.RemoteFile = "archive.tgz"
+			c.LocalFile = ""
+			c.ExtractTo = filepath.Join(t.TempDir(), "extracted")
+			c.SHA256 = strings.Repeat("0", 64)
+			err := c.get(ctx, logger)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "SHA-256 mismatch")
+			assert.NoDirExists(t, c.ExtractTo)
+
+			entries, err := os.ReadDir(filepath.Dir(c.ExtractTo))
+			require.NoError(t, err)
+			assert.Empty(t, entries, "downloaded archive should be removed")
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+			require.NoError(t, err)
+			remote := filepath.Join(t.TempDir(), "remote")
+			require.NoError(t, os.WriteFile(remote, []byte("foo"), 0644))
+			require.NoError(t, bucket.Upload(ctx, "remote", remote))
+			require.NoError(t, bucket.Put(ctx, "archive.tgz", bytes.NewReader(archive.Bytes())))
+
+			tCase(t, &s3get{
+				Bucket:     "bucket",
+				RemoteFile: "remote",
+				LocalFile:  filepath.Join(t.TempDir(), "local"),
+				bucket:     bucket,
+			})
+		})
+	}
+}
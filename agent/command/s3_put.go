@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -99,6 +100,18 @@ type s3put struct {
 	// SkipExisting, when set to true, will not upload files if they already exist in s3.
 	SkipExisting string `mapstructure:"skip_existing" plugin:"expand"`
 
+	// Concurrency is the number of files to upload at once when uploading
+	// multiple files with LocalFilesIncludeFilter. Defaults to 1.
+	Concurrency int `mapstructure:"concurrency"`
+
+	// PartConcurrency is the number of parts of a large file to upload at
+	// once. Defaults to 4.
+	PartConcurrency int `mapstructure:"part_concurrency"`
+
+	// PartSizeMB is the size in MB of each part of a large file. Files
+	// smaller than this are uploaded in a single request. Defaults to 16.
+	PartSizeMB int `mapstructure:"part_size_mb"`
+
 	// workDir sets the working directory relative to which s3put should look for files to upload.
 	// workDir will be empty if an absolute path is provided to the file.
 	workDir          string
@@ -108,7 +121,11 @@ type s3put struct {
 	isPatchable      bool
 	isPatchOnly      bool
 
-	bucket pail.Bucket
+	bucket   pail.Bucket
+	uploader s3FileUploader
+	// digests maps each uploaded file to the SHA-256 digest of its
+	// contents, so the digest can be recorded when the file is attached.
+	digests map[string]string
 
 	taskdata client.TaskData
 	base
@@ -180,6 +197,8 @@ func (s3pc *s3put) validate() error {
 		s3pc.Region = endpoints.UsEast1RegionID
 	}
 
+	catcher.Add(validateS3TransferParams(s3pc.Concurrency, s3pc.PartConcurrency, s3pc.PartSizeMB))
+
 	// make sure the bucket is valid
 	if err := validateS3BucketName(s3pc.Bucket); err != nil {
 		catcher.Wrapf(err, "invalid bucket name '%s'", s3pc.Bucket)
@@ -287,7 +306,7 @@ func (s3pc *s3put) Execute(ctx context.Context,
 	httpClient := utility.GetHTTPClient()
 	httpClient.Timeout = s3HTTPClientTimeout
 	defer utility.PutHTTPClient(httpClient)
-	if err := s3pc.createPailBucket(httpClient); err != nil {
+	if err := s3pc.createPailBucket(httpClient, logger); err != nil {
 		return errors.Wrap(err, "connecting to S3")
 	}
 
@@ -339,6 +358,15 @@ func (s3pc *s3put) Execute(ctx context.Context,
 
 }
 
+// s3PutResult is the result of uploading a single file.
+type s3PutResult struct {
+	// uploadedFile is the name the file is attached with, or empty if the
+	// file was not uploaded because it already exists remotely.
+	uploadedFile string
+	digest       string
+	err          error
+}
+
 // Wrapper around the Put() function to retry it.
 func (s3pc *s3put) putWithRetry(ctx context.Context, comm client.Communicator, logger client.LoggerProducer) error {
 	backoffCounter := getS3OpBackoff()
@@ -348,6 +376,10 @@ func (s3pc *s3put) putWithRetry(ctx context.Context, comm client.Communicator, l
 		uploadedFiles []string
 		filesList     []string
 	)
+	// completed holds the results of the files that have been uploaded so
+	// that retries only upload the files that failed.
+	completed := map[string]s3PutResult{}
+	uploader := s3pc.getUploader()
 
 	timer := time.NewTimer(0)
 	defer timer.Stop()
@@ -386,75 +418,61 @@ retryLoop:
 				}
 			}
 
+			results := s3pc.uploadFiles(ctx, logger, uploader, filesList, completed)
+			if err := ctx.Err(); err != nil {
+				return errors.Wrap(err, "canceled while uploading files")
+			}
+
 			// reset to avoid duplicated uploaded references
 			uploadedFiles = []string{}
-
-		uploadLoop:
-			for _, fpath := range filesList {
-				if err := ctx.Err(); err != nil {
-					return errors.Wrapf(err, "canceled while processing file '%s'", fpath)
+			s3pc.digests = map[string]string{}
+			retry := false
+			for idx, fpath := range filesList {
+				res := results[idx]
+				if res.err == nil {
+					completed[fpath] = res
+					if res.uploadedFile != "" {
+						uploadedFiles = append(uploadedFiles, res.uploadedFile)
+						s3pc.digests[res.uploadedFile] = res.digest
+					}
+					continue
 				}
 
-				remoteName := s3pc.RemoteFile
-				if s3pc.isMulti() {
-					if s3pc.preservePath {
-						remoteName = filepath.Join(s3pc.RemoteFile, fpath)
+				// retry errors other than "file doesn't exist", which we handle differently based on what
+				// kind of upload it is
+				if os.IsNotExist(errors.Cause(res.err)) {
+					if s3pc.isMulti() {
+						// try the remaining multi uploads in the group, effectively ignoring this
+						// error.
+						logger.Task().Infof("File '%s' not found, but continuing to upload other files.", fpath)
+						continue
+					} else if s3pc.skipMissing {
+						// single optional file uploads should return early.
+						logger.Task().Infof("File '%s' not found and skip missing is true, exiting without error.", fpath)
+						return nil
 					} else {
-						// put all files in the same directory
-						fname := filepath.Base(fpath)
-						remoteName = fmt.Sprintf("%s%s", s3pc.RemoteFile, fname)
+						// single required uploads should return an error asap.
+						return errors.Wrapf(res.err, "missing file '%s'", fpath)
 					}
 				}
 
-				fpath = filepath.Join(filepath.Join(s3pc.workDir, s3pc.LocalFilesIncludeFilterPrefix), fpath)
-
-				if s3pc.skipExistingBool {
-					exists, err := s3pc.remoteFileExists(remoteName)
-					if err != nil {
-						return errors.Wrapf(err, "checking if file '%s' exists", remoteName)
-					}
-					if exists {
-						logger.Task().Infof("Not uploading file '%s' because remote file '%s' already exists. Continuing to upload other files.", fpath, remoteName)
-						continue uploadLoop
-					}
-				}
-				err = s3pc.bucket.Upload(ctx, remoteName, fpath)
-				if err != nil {
-					// retry errors other than "file doesn't exist", which we handle differently based on what
-					// kind of upload it is
-					if os.IsNotExist(errors.Cause(err)) {
-						if s3pc.isMulti() {
-							// try the remaining multi uploads in the group, effectively ignoring this
-							// error.
-							logger.Task().Infof("File '%s' not found, but continuing to upload other files.", fpath)
-							continue uploadLoop
-						} else if s3pc.skipMissing {
-							// single optional file uploads should return early.
-							logger.Task().Infof("File '%s' not found and skip missing is true, exiting without error.", fpath)
-							return nil
-						} else {
-							// single required uploads should return an error asap.
-							return errors.Wrapf(err, "missing file '%s'", fpath)
-						}
-					}
-
-					// in all other cases, log an error and retry after an interval.
-					logger.Task().Error(errors.WithMessage(err, "putting S3 file"))
-					timer.Reset(backoffCounter.Duration())
-					continue retryLoop
-				}
+				// in all other cases, log an error and retry after an interval.
+				logger.Task().Error(errors.WithMessage(res.err, "putting S3 file"))
+				retry = true
+			}
 
-				if s3pc.preservePath {
-					uploadedFiles = append(uploadedFiles, remoteName)
-				} else {
-					uploadedFiles = append(uploadedFiles, fpath)
-				}
+			if retry && i < maxS3OpAttempts {
+				timer.Reset(backoffCounter.Duration())
+				continue retryLoop
 			}
 
 			break retryLoop
 		}
 	}
 
+	// Don't leave behind the parts of uploads that never finished.
+	logger.Task().Warning(errors.Wrap(uploader.abort(ctx), "aborting incomplete uploads"))
+
 	if len(uploadedFiles) == 0 && s3pc.skipMissing {
 		logger.Task().Info("S3 put uploaded no files")
 		return nil
@@ -475,6 +493,94 @@ retryLoop:
 	return nil
 }
 
+// uploadFiles uploads the files that have not already been uploaded,
+// uploading up to the configured number of files at once. It returns the
+// result for each file in the same order as the files.
+func (s3pc *s3put) uploadFiles(ctx context.Context, logger client.LoggerProducer, uploader s3FileUploader, filesList []string, completed map[string]s3PutResult) []s3PutResult {
+	results := make([]s3PutResult, len(filesList))
+	toUpload := make(chan int, len(filesList))
+	for idx, fpath := range filesList {
+		if res, ok := completed[fpath]; ok {
+			results[idx] = res
+			continue
+		}
+		toUpload <- idx
+	}
+	close(toUpload)
+
+	concurrency := s3pc.Concurrency
+	if concurrency <= 0 || !s3pc.isMulti() {
+		concurrency = 1
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range toUpload {
+				if err := ctx.Err(); err != nil {
+					results[idx] = s3PutResult{err: errors.Wrapf(err, "canceled while processing file '%s'", filesList[idx])}
+					continue
+				}
+				results[idx] = s3pc.uploadFile(ctx, logger, uploader, filesList[idx])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// uploadFile uploads a single file from the list of files to upload.
+func (s3pc *s3put) uploadFile(ctx context.Context, logger client.LoggerProducer, uploader s3FileUploader, fpath string) s3PutResult {
+	remoteName := s3pc.RemoteFile
+	if s3pc.isMulti() {
+		if s3pc.preservePath {
+			remoteName = filepath.Join(s3pc.RemoteFile, fpath)
+		} else {
+			// put all files in the same directory
+			fname := filepath.Base(fpath)
+			remoteName = fmt.Sprintf("%s%s", s3pc.RemoteFile, fname)
+		}
+	}
+
+	fpath = filepath.Join(filepath.Join(s3pc.workDir, s3pc.LocalFilesIncludeFilterPrefix), fpath)
+
+	if s3pc.skipExistingBool {
+		exists, err := s3pc.remoteFileExists(remoteName)
+		if err != nil {
+			return s3PutResult{err: errors.Wrapf(err, "checking if file '%s' exists", remoteName)}
+		}
+		if exists {
+			logger.Task().Infof("Not uploading file '%s' because remote file '%s' already exists. Continuing to upload other files.", fpath, remoteName)
+			return s3PutResult{}
+		}
+	}
+
+	digest, err := util.FileSHA256(fpath)
+	if err != nil {
+		return s3PutResult{err: errors.Wrapf(err, "computing digest of file '%s'", fpath)}
+	}
+	if err = uploader.upload(ctx, remoteName, fpath, digest); err != nil {
+		return s3PutResult{err: err}
+	}
+
+	uploadedFile := fpath
+	if s3pc.preservePath {
+		uploadedFile = remoteName
+	}
+	return s3PutResult{uploadedFile: uploadedFile, digest: digest}
+}
+
+// getUploader returns the uploader for the command's bucket.
+func (s3pc *s3put) getUploader() s3FileUploader {
+	if s3pc.uploader != nil {
+		return s3pc.uploader
+	}
+	return &pailUploader{bucket: s3pc.bucket}
+}
+
 // attachTaskFiles is responsible for sending the
 // specified file to the API Server. Does not support multiple file putting.
 func (s3pc *s3put) attachFiles(ctx context.Context, comm client.Communicator, logger client.LoggerProducer, localFiles []string, remoteFile string) error {
@@ -500,12 +606,13 @@ func (s3pc *s3put) attachFiles(ctx context.Context, comm client.Communicator, lo
 		} else if s3pc.isMulti() {
 			displayName = fmt.Sprintf("%s %s", s3pc.ResourceDisplayName, filepath.Base(fn))
 		}
-		var key, secret, bucket, fileKey string
+		// The bucket, file key and region are always recorded so that the
+		// project's artifact retention policy can delete the file. The
+		// credentials are only recorded to sign links.
+		var key, secret string
 		if s3pc.Visibility == artifact.Signed {
 			key = s3pc.AwsKey
 			secret = s3pc.AwsSecret
-			bucket = s3pc.Bucket
-			fileKey = remoteFileName
 		}
 
 		files = append(files, &artifact.File{
@@ -514,8 +621,10 @@ func (s3pc *s3put) attachFiles(ctx context.Context, comm client.Communicator, lo
 			Visibility: s3pc.Visibility,
 			AwsKey:     key,
 			AwsSecret:  secret,
-			Bucket:     bucket,
-			FileKey:    fileKey,
+			Bucket:     s3pc.Bucket,
+			FileKey:    remoteFileName,
+			Region:     s3pc.Region,
+			SHA256:     s3pc.digests[fn],
 		})
 	}
 
@@ -527,7 +636,7 @@ func (s3pc *s3put) attachFiles(ctx context.Context, comm client.Communicator, lo
 	return nil
 }
 
-func (s3pc *s3put) createPailBucket(httpClient *http.Client) error {
+func (s3pc *s3put) createPailBucket(httpClient *http.Client, logger client.LoggerProducer) error {
 	if s3pc.bucket != nil {
 		return nil
 	}
@@ -539,8 +648,17 @@ func (s3pc *s3put) createPailBucket(httpClient *http.Client) error {
 		ContentType: s3pc.ContentType,
 	}
 	bucket, err := pail.NewS3MultiPartBucketWithHTTPClient(httpClient, opts)
+	if err != nil {
+		return err
+	}
 	s3pc.bucket = bucket
-	return err
+
+	s3Client, err := newS3Client(httpClient, s3pc.AwsKey, s3pc.AwsSecret, s3pc.Region)
+	if err != nil {
+		return err
+	}
+	s3pc.uploader = newS3MultipartUploader(s3Client, s3pc.Bucket, s3pc.Permissions, s3pc.ContentType, newS3TransferOptions(s3pc.PartConcurrency, s3pc.PartSizeMB), logger.Execution())
+	return nil
 }
 
 func (s3pc *s3put) isPrivate(visibility string) bool {
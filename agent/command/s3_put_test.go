@@ -2,6 +2,8 @@ package command
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -569,3 +571,122 @@ func TestPreservePath(t *testing.T) {
 	}
 
 }
+
+func TestS3PutRecordsDigests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo"), []byte("foo"), 0644))
+
+	s := s3put{
+		AwsKey:                  "key",
+		AwsSecret:               "secret",
+		Bucket:                  "bucket",
+		BuildVariants:           []string{},
+		ContentType:             "content-type",
+		LocalFilesIncludeFilter: []string{"foo"},
+		Permissions:             s3.BucketCannedACLPublicRead,
+		RemoteFile:              "remote",
+	}
+	var err error
+	s.bucket, err = pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+	require.NoError(t, err)
+	comm := client.NewMock("http://localhost.com")
+	conf := &internal.TaskConfig{
+		Expansions:   &util.Expansions{},
+		Task:         &task.Task{Id: "mock_id", Secret: "mock_secret"},
+		Project:      &model.Project{},
+		WorkDir:      dir,
+		BuildVariant: &model.BuildVariant{},
+	}
+	logger, err := comm.GetLoggerProducer(ctx, client.TaskData{ID: conf.Task.Id, Secret: conf.Task.Secret}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Execute(ctx, comm, logger, conf))
+
+	files := comm.AttachedFiles[conf.Task.Id]
+	require.Len(t, files, 1)
+	// The SHA-256 digest of "foo".
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", files[0].SHA256)
+	assert.Equal(t, "remotefoo", files[0].FileKey)
+}
+
+func TestS3PutConcurrentUploads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	var fileNames []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("file%02d", i)
+		fileNames = append(fileNames, name)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	s := s3put{
+		AwsKey:                  "key",
+		AwsSecret:               "secret",
+		Bucket:                  "bucket",
+		BuildVariants:           []string{},
+		ContentType:             "content-type",
+		LocalFilesIncludeFilter: []string{"file*"},
+		Permissions:             s3.BucketCannedACLPublicRead,
+		RemoteFile:              "remote/",
+		Concurrency:             4,
+	}
+	var err error
+	s.bucket, err = pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+	require.NoError(t, err)
+	comm := client.NewMock("http://localhost.com")
+	conf := &internal.TaskConfig{
+		Expansions:   &util.Expansions{},
+		Task:         &task.Task{Id: "mock_id", Secret: "mock_secret"},
+		Project:      &model.Project{},
+		WorkDir:      dir,
+		BuildVariant: &model.BuildVariant{},
+	}
+	logger, err := comm.GetLoggerProducer(ctx, client.TaskData{ID: conf.Task.Id, Secret: conf.Task.Secret}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Execute(ctx, comm, logger, conf))
+
+	files := comm.AttachedFiles[conf.Task.Id]
+	require.Len(t, files, len(fileNames))
+	for i, f := range files {
+		assert.Equal(t, "remote/"+fileNames[i], f.FileKey, "files should be attached in order")
+		digest, err := util.FileSHA256(filepath.Join(dir, fileNames[i]))
+		require.NoError(t, err)
+		assert.Equal(t, digest, f.SHA256)
+
+		r, err := s.bucket.Get(ctx, f.FileKey)
+		require.NoError(t, err)
+		contents, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, fileNames[i], string(contents))
+	}
+}
+
+func TestS3PutValidatesTransferParams(t *testing.T) {
+	params := map[string]interface{}{
+		"aws_key":      "key",
+		"aws_secret":   "secret",
+		"local_file":   "local",
+		"remote_file":  "remote",
+		"bucket":       "bck",
+		"permissions":  s3.BucketCannedACLPublicRead,
+		"content_type": "application/x-tar",
+		"concurrency":  "8",
+		"part_size_mb": 32,
+	}
+	cmd := &s3put{}
+	require.NoError(t, cmd.ParseParams(params))
+	assert.Equal(t, 8, cmd.Concurrency)
+	assert.Equal(t, 32, cmd.PartSizeMB)
+
+	params["part_size_mb"] = 1
+	assert.Error(t, (&s3put{}).ParseParams(params))
+
+	params["part_size_mb"] = 64
+	assert.Error(t, (&s3put{}).ParseParams(params), "part buffers should not exceed the memory limit")
+}
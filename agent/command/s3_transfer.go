@@ -0,0 +1,451 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// s3SHA256MetadataKey is the S3 object metadata key that holds the
+	// hex-encoded SHA-256 digest of an object uploaded by the agent.
+	s3SHA256MetadataKey = "sha256"
+
+	defaultS3PartConcurrency = 4
+	defaultS3PartSizeMB      = 16
+	// minS3PartSizeMB is the smallest part size that S3 allows for all but
+	// the last part of a multipart upload.
+	minS3PartSizeMB = 5
+	// maxS3Parts is the maximum number of parts in a multipart upload.
+	maxS3Parts = 10000
+	// maxS3TransferBufferMB is the most memory that a command may use to
+	// buffer parts, which is the part size times the part concurrency times
+	// the number of files transferred at once.
+	maxS3TransferBufferMB = 1024
+)
+
+// s3TransferOptions configure how individual files are split up and
+// transferred to and from S3.
+type s3TransferOptions struct {
+	// PartConcurrency is the number of parts of a single file to transfer at
+	// once.
+	PartConcurrency int
+	// PartSize is the size in bytes of each part of a file.
+	PartSize int64
+}
+
+func newS3TransferOptions(partConcurrency, partSizeMB int) s3TransferOptions {
+	if partConcurrency <= 0 {
+		partConcurrency = defaultS3PartConcurrency
+	}
+	if partSizeMB <= 0 {
+		partSizeMB = defaultS3PartSizeMB
+	}
+	return s3TransferOptions{
+		PartConcurrency: partConcurrency,
+		PartSize:        int64(partSizeMB) * 1024 * 1024,
+	}
+}
+
+func validateS3TransferParams(fileConcurrency, partConcurrency, partSizeMB int) error {
+	catcher := grip.NewSimpleCatcher()
+	catcher.NewWhen(fileConcurrency < 0, "concurrency cannot be negative")
+	catcher.NewWhen(partConcurrency < 0, "part concurrency cannot be negative")
+	catcher.ErrorfWhen(partSizeMB != 0 && partSizeMB < minS3PartSizeMB, "part size must be at least %d MB", minS3PartSizeMB)
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if fileConcurrency == 0 {
+		fileConcurrency = 1
+	}
+	opts := newS3TransferOptions(partConcurrency, partSizeMB)
+	bufferMB := int64(fileConcurrency) * int64(opts.PartConcurrency) * opts.PartSize / (1024 * 1024)
+	catcher.ErrorfWhen(bufferMB > maxS3TransferBufferMB, "part size (%d MB) times part concurrency (%d) times concurrency (%d) must be at most %d MB", opts.PartSize/(1024*1024), opts.PartConcurrency, fileConcurrency, maxS3TransferBufferMB)
+	return catcher.Resolve()
+}
+
+// newS3Client returns an S3 client for the bucket's region.
+func newS3Client(httpClient *http.Client, key, secret, region string) (s3iface.S3API, error) {
+	sess, err := session.NewSession(&aws.Config{
+		HTTPClient:  httpClient,
+		Region:      aws.String(region),
+		Credentials: pail.CreateAWSCredentials(key, secret, ""),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating AWS session")
+	}
+	return s3.New(sess), nil
+}
+
+// getS3ObjectSHA256 returns the SHA-256 digest recorded in the object's
+// metadata when it was uploaded, or an empty string if the object has no
+// recorded digest.
+func getS3ObjectSHA256(ctx context.Context, client s3iface.S3API, bucket, key string) (string, error) {
+	out, err := client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "getting metadata for object '%s'", key)
+	}
+	// S3 canonicalizes the case of metadata keys, so the key may not be
+	// returned exactly as it was set.
+	for k, v := range out.Metadata {
+		if strings.EqualFold(k, s3SHA256MetadataKey) {
+			return aws.StringValue(v), nil
+		}
+	}
+	return "", nil
+}
+
+// checkSHA256 returns an error if the actual digest does not match the
+// expected one.
+func checkSHA256(name, expected, actual string) error {
+	if !strings.EqualFold(expected, actual) {
+		return errors.Errorf("SHA-256 mismatch for '%s': expected '%s' but got '%s'", name, expected, actual)
+	}
+	return nil
+}
+
+// s3FileUploader uploads local files to S3.
+type s3FileUploader interface {
+	// upload uploads the file at the path to the key, recording its SHA-256
+	// digest so it can be verified when the file is downloaded.
+	upload(ctx context.Context, key, path, sha256 string) error
+	// abort cleans up any uploads that were started but not finished.
+	abort(ctx context.Context) error
+}
+
+// pailUploader uploads files to a pail bucket. It cannot record digests, so
+// it is only used when the command is given a non-S3 bucket.
+type pailUploader struct {
+	bucket pail.Bucket
+}
+
+func (u *pailUploader) upload(ctx context.Context, key, path, _ string) error {
+	return u.bucket.Upload(ctx, key, path)
+}
+
+func (u *pailUploader) abort(context.Context) error { return nil }
+
+// s3MultipartUploader uploads files to S3, splitting large files into parts
+// that are uploaded in parallel. S3 verifies the SHA-256 checksum of each
+// part as it's received. If an upload fails, the uploader remembers the parts
+// that were already uploaded so that uploading the same file again with the
+// same uploader only uploads the parts that are missing. This state is only
+// kept in memory, so it lasts for the retries of a single command; the
+// command aborts any uploads that are still incomplete when it finishes.
+type s3MultipartUploader struct {
+	client      s3iface.S3API
+	bucket      string
+	permissions string
+	contentType string
+	opts        s3TransferOptions
+	logger      grip.Journaler
+
+	mu sync.Mutex
+	// inProgress maps keys to the multipart uploads that have been started
+	// for them but not completed.
+	inProgress map[string]*s3MultipartUpload
+}
+
+// s3MultipartUpload is the state of an incomplete multipart upload.
+type s3MultipartUpload struct {
+	uploadID string
+	path     string
+	sha256   string
+	size     int64
+	modTime  time.Time
+	parts    map[int64]*s3.CompletedPart
+}
+
+func newS3MultipartUploader(client s3iface.S3API, bucket, permissions, contentType string, opts s3TransferOptions, logger grip.Journaler) *s3MultipartUploader {
+	return &s3MultipartUploader{
+		client:      client,
+		bucket:      bucket,
+		permissions: permissions,
+		contentType: contentType,
+		opts:        opts,
+		logger:      logger,
+		inProgress:  map[string]*s3MultipartUpload{},
+	}
+}
+
+func (u *s3MultipartUploader) upload(ctx context.Context, key, path, digest string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "getting info for file '%s'", path)
+	}
+
+	partSize := u.opts.PartSize
+	if info.Size() > partSize*maxS3Parts {
+		// Grow the parts so the file fits within S3's part limit.
+		partSize = info.Size()/maxS3Parts + 1
+	}
+	if info.Size() <= partSize {
+		return u.putObject(ctx, key, path, digest)
+	}
+
+	mpu, err := u.getOrCreateUpload(ctx, key, path, digest, info)
+	if err != nil {
+		return errors.Wrapf(err, "starting multipart upload of file '%s'", path)
+	}
+
+	if err := u.uploadParts(ctx, key, mpu, partSize); err != nil {
+		return errors.Wrapf(err, "uploading parts of file '%s'", path)
+	}
+
+	parts := make([]*s3.CompletedPart, 0, len(mpu.parts))
+	for _, part := range mpu.parts {
+		parts = append(parts, part)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.Int64Value(parts[i].PartNumber) < aws.Int64Value(parts[j].PartNumber)
+	})
+	if _, err = u.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(mpu.uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	}); err != nil {
+		return errors.Wrapf(err, "completing multipart upload of file '%s'", path)
+	}
+
+	u.mu.Lock()
+	delete(u.inProgress, key)
+	u.mu.Unlock()
+
+	return nil
+}
+
+// putObject uploads a file that's small enough to upload in a single
+// request.
+func (u *s3MultipartUploader) putObject(ctx context.Context, key, path, digest string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "opening file '%s'", path)
+	}
+	defer f.Close()
+
+	checksum, err := base64SHA256(digest)
+	if err != nil {
+		return errors.Wrapf(err, "encoding checksum of file '%s'", path)
+	}
+
+	_, err = u.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:         aws.String(u.bucket),
+		Key:            aws.String(key),
+		Body:           f,
+		ACL:            aws.String(u.permissions),
+		ContentType:    aws.String(u.contentType),
+		Metadata:       map[string]*string{s3SHA256MetadataKey: aws.String(digest)},
+		ChecksumSHA256: aws.String(checksum),
+	})
+	return errors.Wrapf(err, "putting file '%s'", path)
+}
+
+// getOrCreateUpload returns the incomplete upload of the file to the key if
+// the file hasn't changed since it was started. Otherwise, it starts a new
+// multipart upload.
+func (u *s3MultipartUploader) getOrCreateUpload(ctx context.Context, key, path, digest string, info os.FileInfo) (*s3MultipartUpload, error) {
+	u.mu.Lock()
+	existing := u.inProgress[key]
+	u.mu.Unlock()
+
+	if existing != nil {
+		if existing.path == path && existing.sha256 == digest && existing.size == info.Size() && existing.modTime.Equal(info.ModTime()) {
+			return existing, nil
+		}
+		u.logger.Warning(errors.Wrapf(u.abortUpload(ctx, key, existing), "aborting stale multipart upload of file '%s'", existing.path))
+	}
+
+	out, err := u.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(u.bucket),
+		Key:               aws.String(key),
+		ACL:               aws.String(u.permissions),
+		ContentType:       aws.String(u.contentType),
+		Metadata:          map[string]*string{s3SHA256MetadataKey: aws.String(digest)},
+		ChecksumAlgorithm: aws.String(s3.ChecksumAlgorithmSha256),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mpu := &s3MultipartUpload{
+		uploadID: aws.StringValue(out.UploadId),
+		path:     path,
+		sha256:   digest,
+		size:     info.Size(),
+		modTime:  info.ModTime(),
+		parts:    map[int64]*s3.CompletedPart{},
+	}
+	u.mu.Lock()
+	u.inProgress[key] = mpu
+	u.mu.Unlock()
+
+	return mpu, nil
+}
+
+// uploadParts uploads all the parts of the file that have not already been
+// uploaded. Each worker buffers one part, so if the parts had to grow to fit
+// S3's part limit, fewer workers are used to keep within the memory that the
+// configured part size and concurrency allow.
+func (u *s3MultipartUploader) uploadParts(ctx context.Context, key string, mpu *s3MultipartUpload, partSize int64) error {
+	f, err := os.Open(mpu.path)
+	if err != nil {
+		return errors.Wrapf(err, "opening file '%s'", mpu.path)
+	}
+	defer f.Close()
+
+	numParts := (mpu.size + partSize - 1) / partSize
+	partNumbers := make(chan int64, numParts)
+	u.mu.Lock()
+	for partNum := int64(1); partNum <= numParts; partNum++ {
+		if _, ok := mpu.parts[partNum]; !ok {
+			partNumbers <- partNum
+		}
+	}
+	u.mu.Unlock()
+	close(partNumbers)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := int(int64(u.opts.PartConcurrency) * u.opts.PartSize / partSize)
+	if workers < 1 {
+		workers = 1
+	}
+
+	catcher := grip.NewBasicCatcher()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, partSize)
+			for partNum := range partNumbers {
+				if ctx.Err() != nil {
+					return
+				}
+				offset := (partNum - 1) * partSize
+				n, err := f.ReadAt(buf, offset)
+				if err != nil && err != io.EOF {
+					catcher.Wrapf(err, "reading part %d", partNum)
+					cancel()
+					return
+				}
+				part, err := u.uploadPart(ctx, key, mpu.uploadID, partNum, buf[:n])
+				if err != nil {
+					catcher.Wrapf(err, "uploading part %d", partNum)
+					cancel()
+					return
+				}
+				u.mu.Lock()
+				mpu.parts[partNum] = part
+				u.mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return catcher.Resolve()
+}
+
+func (u *s3MultipartUploader) uploadPart(ctx context.Context, key, uploadID string, partNum int64, data []byte) (*s3.CompletedPart, error) {
+	sum := sha256.Sum256(data)
+	checksum := base64.StdEncoding.EncodeToString(sum[:])
+	out, err := u.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:         aws.String(u.bucket),
+		Key:            aws.String(key),
+		UploadId:       aws.String(uploadID),
+		PartNumber:     aws.Int64(partNum),
+		Body:           bytes.NewReader(data),
+		ChecksumSHA256: aws.String(checksum),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.CompletedPart{
+		ETag:           out.ETag,
+		PartNumber:     aws.Int64(partNum),
+		ChecksumSHA256: aws.String(checksum),
+	}, nil
+}
+
+// abort aborts all the multipart uploads that have not been completed so
+// that S3 does not keep their parts.
+func (u *s3MultipartUploader) abort(ctx context.Context) error {
+	u.mu.Lock()
+	inProgress := u.inProgress
+	u.inProgress = map[string]*s3MultipartUpload{}
+	u.mu.Unlock()
+
+	catcher := grip.NewBasicCatcher()
+	for key, mpu := range inProgress {
+		catcher.Wrapf(u.abortUpload(ctx, key, mpu), "aborting multipart upload of file '%s'", mpu.path)
+	}
+	return catcher.Resolve()
+}
+
+func (u *s3MultipartUploader) abortUpload(ctx context.Context, key string, mpu *s3MultipartUpload) error {
+	_, err := u.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(u.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(mpu.uploadID),
+	})
+	return err
+}
+
+// downloadS3File downloads the object to the local file, fetching ranges of
+// the object in parallel.
+func downloadS3File(ctx context.Context, client s3iface.S3API, bucket, key, path string, opts s3TransferOptions, logger grip.Journaler) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "creating file '%s'", path)
+	}
+
+	downloader := s3manager.NewDownloaderWithClient(client, func(d *s3manager.Downloader) {
+		d.Concurrency = opts.PartConcurrency
+		d.PartSize = opts.PartSize
+	})
+	_, err = downloader.DownloadWithContext(ctx, f, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrapf(err, "downloading object '%s'", key)
+	catcher.Wrapf(f.Close(), "closing file '%s'", path)
+	if catcher.HasErrors() {
+		logger.Warning(errors.Wrapf(os.RemoveAll(path), "removing partially downloaded file '%s'", path))
+	}
+	return catcher.Resolve()
+}
+
+// base64SHA256 converts a hex-encoded SHA-256 digest to the base64 encoding
+// that S3 checksums use.
+func base64SHA256(digest string) (string, error) {
+	sum, err := hex.DecodeString(digest)
+	if err != nil {
+		return "", errors.Wrap(err, "decoding hex digest")
+	}
+	return base64.StdEncoding.EncodeToString(sum), nil
+}
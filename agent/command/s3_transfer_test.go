@@ -0,0 +1,270 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockS3Client is an in-memory S3 client that supports the operations used
+// to upload files.
+type mockS3Client struct {
+	s3iface.S3API
+
+	mu           sync.Mutex
+	objects      map[string][]byte
+	metadata     map[string]map[string]*string
+	uploads      map[string]map[int64][]byte
+	aborted      []string
+	partUploads  int
+	failPartOnce map[int64]bool
+}
+
+func newMockS3Client() *mockS3Client {
+	return &mockS3Client{
+		objects:      map[string][]byte{},
+		metadata:     map[string]map[string]*string{},
+		uploads:      map[string]map[int64][]byte{},
+		failPartOnce: map[int64]bool{},
+	}
+}
+
+func (c *mockS3Client) PutObjectWithContext(_ aws.Context, in *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMockChecksum(data, in.ChecksumSHA256); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[aws.StringValue(in.Key)] = data
+	c.metadata[aws.StringValue(in.Key)] = in.Metadata
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (c *mockS3Client) CreateMultipartUploadWithContext(_ aws.Context, in *s3.CreateMultipartUploadInput, _ ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	uploadID := aws.StringValue(in.Key) + time.Now().String()
+	c.uploads[uploadID] = map[int64][]byte{}
+	c.metadata[aws.StringValue(in.Key)] = in.Metadata
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(uploadID)}, nil
+}
+
+func (c *mockS3Client) UploadPartWithContext(_ aws.Context, in *s3.UploadPartInput, _ ...request.Option) (*s3.UploadPartOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMockChecksum(data, in.ChecksumSHA256); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	partNum := aws.Int64Value(in.PartNumber)
+	if c.failPartOnce[partNum] {
+		delete(c.failPartOnce, partNum)
+		return nil, errors.Errorf("part %d failed", partNum)
+	}
+	parts, ok := c.uploads[aws.StringValue(in.UploadId)]
+	if !ok {
+		return nil, errors.New("upload not found")
+	}
+	parts[partNum] = data
+	c.partUploads++
+	return &s3.UploadPartOutput{ETag: aws.String("etag")}, nil
+}
+
+func (c *mockS3Client) CompleteMultipartUploadWithContext(_ aws.Context, in *s3.CompleteMultipartUploadInput, _ ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	parts, ok := c.uploads[aws.StringValue(in.UploadId)]
+	if !ok {
+		return nil, errors.New("upload not found")
+	}
+	if len(in.MultipartUpload.Parts) != len(parts) {
+		return nil, errors.New("missing parts")
+	}
+	var data []byte
+	for i, part := range in.MultipartUpload.Parts {
+		if aws.Int64Value(part.PartNumber) != int64(i+1) {
+			return nil, errors.New("parts out of order")
+		}
+		data = append(data, parts[aws.Int64Value(part.PartNumber)]...)
+	}
+	c.objects[aws.StringValue(in.Key)] = data
+	delete(c.uploads, aws.StringValue(in.UploadId))
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (c *mockS3Client) AbortMultipartUploadWithContext(_ aws.Context, in *s3.AbortMultipartUploadInput, _ ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.uploads, aws.StringValue(in.UploadId))
+	c.aborted = append(c.aborted, aws.StringValue(in.UploadId))
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (c *mockS3Client) HeadObjectWithContext(_ aws.Context, in *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.objects[aws.StringValue(in.Key)]; !ok {
+		return nil, errors.New("object not found")
+	}
+	// S3 returns metadata keys in canonical header case.
+	metadata := map[string]*string{}
+	for k, v := range c.metadata[aws.StringValue(in.Key)] {
+		metadata[http.CanonicalHeaderKey(k)] = v
+	}
+	return &s3.HeadObjectOutput{Metadata: metadata}, nil
+}
+
+func checkMockChecksum(data []byte, checksum *string) error {
+	sum := sha256.Sum256(data)
+	if base64.StdEncoding.EncodeToString(sum[:]) != aws.StringValue(checksum) {
+		return errors.New("checksum mismatch")
+	}
+	return nil
+}
+
+func TestS3MultipartUploader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writeFile := func(t *testing.T, contents string) (string, string) {
+		path := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		digest, err := util.FileSHA256(path)
+		require.NoError(t, err)
+		return path, digest
+	}
+	opts := s3TransferOptions{PartConcurrency: 2, PartSize: 4}
+
+	for tName, tCase := range map[string]func(t *testing.T, client *mockS3Client, u *s3MultipartUploader){
+		"UploadsSmallFileInSingleRequest": func(t *testing.T, client *mockS3Client, u *s3MultipartUploader) {
+			path, digest := writeFile(t, "foo")
+			require.NoError(t, u.upload(ctx, "key", path, digest))
+
+			assert.Equal(t, []byte("foo"), client.objects["key"])
+			assert.Zero(t, client.partUploads)
+			objDigest, err := getS3ObjectSHA256(ctx, client, "bucket", "key")
+			require.NoError(t, err)
+			assert.Equal(t, digest, objDigest)
+		},
+		"UploadsLargeFileInParts": func(t *testing.T, client *mockS3Client, u *s3MultipartUploader) {
+			path, digest := writeFile(t, "0123456789abcdefghij")
+			require.NoError(t, u.upload(ctx, "key", path, digest))
+
+			assert.Equal(t, []byte("0123456789abcdefghij"), client.objects["key"])
+			assert.Equal(t, 5, client.partUploads)
+			assert.Empty(t, u.inProgress)
+			objDigest, err := getS3ObjectSHA256(ctx, client, "bucket", "key")
+			require.NoError(t, err)
+			assert.Equal(t, digest, objDigest)
+		},
+		"ResumesFailedUploadWithMissingParts": func(t *testing.T, client *mockS3Client, u *s3MultipartUploader) {
+			path, digest := writeFile(t, "0123456789abcdefghij")
+			client.failPartOnce[3] = true
+			assert.Error(t, u.upload(ctx, "key", path, digest))
+			assert.NotContains(t, client.objects, "key")
+			require.Contains(t, u.inProgress, "key")
+			uploaded := client.partUploads
+			assert.Less(t, uploaded, 5)
+
+			require.NoError(t, u.upload(ctx, "key", path, digest))
+			assert.Equal(t, []byte("0123456789abcdefghij"), client.objects["key"])
+			assert.Equal(t, 5, client.partUploads, "retry should only upload the missing parts")
+			assert.Empty(t, client.aborted)
+		},
+		"RestartsUploadWhenFileChanges": func(t *testing.T, client *mockS3Client, u *s3MultipartUploader) {
+			path, digest := writeFile(t, "0123456789abcdefghij")
+			client.failPartOnce[3] = true
+			assert.Error(t, u.upload(ctx, "key", path, digest))
+			require.Contains(t, u.inProgress, "key")
+
+			require.NoError(t, os.WriteFile(path, []byte("abcdefghij0123456789"), 0644))
+			newDigest, err := util.FileSHA256(path)
+			require.NoError(t, err)
+			require.NoError(t, u.upload(ctx, "key", path, newDigest))
+			assert.Equal(t, []byte("abcdefghij0123456789"), client.objects["key"])
+			assert.Len(t, client.aborted, 1)
+		},
+		"AbortCleansUpIncompleteUploads": func(t *testing.T, client *mockS3Client, u *s3MultipartUploader) {
+			path, digest := writeFile(t, "0123456789abcdefghij")
+			client.failPartOnce[1] = true
+			assert.Error(t, u.upload(ctx, "key", path, digest))
+
+			require.NoError(t, u.abort(ctx))
+			assert.Len(t, client.aborted, 1)
+			assert.Empty(t, client.uploads)
+			assert.Empty(t, u.inProgress)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			client := newMockS3Client()
+			tCase(t, client, newS3MultipartUploader(client, "bucket", s3.BucketCannedACLPrivate, "text/plain", opts, grip.NewJournaler("test")))
+		})
+	}
+}
+
+func TestS3MultipartUploaderGrowsPartsToFitPartLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "file")
+	contents := bytes.Repeat([]byte("a"), maxS3Parts+1)
+	require.NoError(t, os.WriteFile(path, contents, 0644))
+	digest, err := util.FileSHA256(path)
+	require.NoError(t, err)
+
+	client := newMockS3Client()
+	u := newS3MultipartUploader(client, "bucket", s3.BucketCannedACLPrivate, "text/plain", s3TransferOptions{PartConcurrency: 8, PartSize: 1}, grip.NewJournaler("test"))
+	require.NoError(t, u.upload(ctx, "key", path, digest))
+
+	assert.Equal(t, contents, client.objects["key"])
+	assert.LessOrEqual(t, client.partUploads, maxS3Parts)
+}
+
+func TestValidateS3TransferParams(t *testing.T) {
+	assert.NoError(t, validateS3TransferParams(0, 0, 0))
+	assert.NoError(t, validateS3TransferParams(8, 4, minS3PartSizeMB))
+	assert.Error(t, validateS3TransferParams(-1, 0, 0))
+	assert.Error(t, validateS3TransferParams(0, -1, 0))
+	assert.Error(t, validateS3TransferParams(0, 0, minS3PartSizeMB-1))
+	assert.NoError(t, validateS3TransferParams(4, 16, 16))
+	assert.Error(t, validateS3TransferParams(8, 16, 16), "part buffers should not exceed the memory limit")
+	assert.Error(t, validateS3TransferParams(0, 0, 2*maxS3TransferBufferMB))
+}
+
+func TestBase64SHA256(t *testing.T) {
+	sum := sha256.Sum256([]byte("foo"))
+	encoded, err := base64SHA256("2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+	require.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), encoded)
+
+	_, err = base64SHA256("not-hex")
+	assert.Error(t, err)
+}
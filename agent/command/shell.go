@@ -138,6 +138,8 @@ func (c *shellExec) Execute(ctx context.Context, _ client.Communicator, logger c
 
 	c.Env = defaultAndApplyExpansionsToEnv(c.Env, modifyEnvOptions{
 		taskID:                 conf.Task.Id,
+		localAPIURL:            conf.LocalAPIURL,
+		localAPIToken:          conf.LocalAPIToken,
 		workingDir:             c.WorkingDir,
 		tmpDir:                 taskTmpDir,
 		expansions:             *conf.Expansions,
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// diskSpaceWatch checks whether the disk has run out of space at regular
+// intervals while a command is running, so that a failure can be attributed
+// to running out of disk space based on what happened during the command
+// rather than the state of the disk after the task is done.
+type diskSpaceWatch struct {
+	check  func(context.Context) bool
+	full   atomic.Bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startDiskSpaceWatch starts checking the disk at the given interval until
+// the watch is stopped or the context is done.
+func startDiskSpaceWatch(ctx context.Context, interval time.Duration, check func(context.Context) bool) *diskSpaceWatch {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &diskSpaceWatch{
+		check:  check,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if check(ctx) {
+					w.full.Store(true)
+					return
+				}
+			}
+		}
+	}()
+
+	return w
+}
+
+// stop stops the watch and returns whether the disk ran out of space while
+// the command was running. If the command failed, it also checks whether the
+// command failed because it couldn't write to the disk and checks the disk
+// once more, since a command that runs out of space usually fails right
+// away.
+func (w *diskSpaceWatch) stop(cmdErr error) bool {
+	if w == nil {
+		return false
+	}
+	w.cancel()
+	<-w.done
+
+	if w.full.Load() {
+		return true
+	}
+	if cmdErr == nil {
+		return false
+	}
+	return errors.Is(cmdErr, syscall.ENOSPC) || w.check(context.Background())
+}
+
+// isDiskFull returns whether the disk holding the agent's working directory
+// has run out of space.
+func (a *Agent) isDiskFull(ctx context.Context, tc *taskContext) bool {
+	usage, err := disk.UsageWithContext(ctx, a.opts.WorkingDirectory)
+	if err != nil {
+		tc.logger.Execution().Warning(errors.Wrapf(err, "getting disk usage for working directory '%s'", a.opts.WorkingDirectory))
+		return false
+	}
+	return usage.Free < minFreeDiskSpaceBytes
+}
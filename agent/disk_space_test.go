@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskSpaceWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for tName, tCase := range map[string]func(t *testing.T, full *atomic.Bool, w *diskSpaceWatch){
+		"DetectsFullDiskWhileRunning": func(t *testing.T, full *atomic.Bool, w *diskSpaceWatch) {
+			full.Store(true)
+			assert.Eventually(t, w.full.Load, time.Second, time.Millisecond)
+			full.Store(false)
+			assert.True(t, w.stop(nil), "disk that ran out of space during the command should be reported after it's freed")
+		},
+		"ChecksDiskWhenCommandFails": func(t *testing.T, full *atomic.Bool, w *diskSpaceWatch) {
+			w.cancel()
+			<-w.done
+			full.Store(true)
+			assert.True(t, w.stop(errors.New("command failed")))
+		},
+		"DoesNotCheckDiskWhenCommandSucceeds": func(t *testing.T, full *atomic.Bool, w *diskSpaceWatch) {
+			w.cancel()
+			<-w.done
+			full.Store(true)
+			assert.False(t, w.stop(nil))
+		},
+		"DetectsNoSpaceError": func(t *testing.T, full *atomic.Bool, w *diskSpaceWatch) {
+			err := errors.Wrap(&os.PathError{Op: "write", Path: "file", Err: syscall.ENOSPC}, "writing file")
+			assert.True(t, w.stop(err))
+		},
+		"ReportsOtherFailuresAsNotFull": func(t *testing.T, full *atomic.Bool, w *diskSpaceWatch) {
+			assert.False(t, w.stop(errors.New("command failed")))
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			full := &atomic.Bool{}
+			w := startDiskSpaceWatch(ctx, time.Millisecond, func(context.Context) bool { return full.Load() })
+			tCase(t, full, w)
+		})
+	}
+
+	t.Run("NilWatchIsNotFull", func(t *testing.T) {
+		var w *diskSpaceWatch
+		assert.False(t, w.stop(errors.New("command failed")))
+	})
+}
@@ -32,6 +32,23 @@ const (
 	// to API server
 	defaultStatsInterval = time.Minute
 
+	// defaultResourceUsageInterval is the interval at which the agent samples
+	// the resource usage of the task's processes.
+	defaultResourceUsageInterval = 10 * time.Second
+
+	// defaultResourceUsageFlushSize is the number of resource usage samples
+	// that the agent sends to the API server at once.
+	defaultResourceUsageFlushSize = 6
+
+	// diskSpaceCheckInterval is how often the agent checks whether the disk
+	// has run out of space while a command that can fail the task is
+	// running.
+	diskSpaceCheckInterval = 10 * time.Second
+
+	// resourceUsageFlushTimeout is the maximum time to spend sending the
+	// remaining resource usage samples after a task is done.
+	resourceUsageFlushTimeout = 10 * time.Second
+
 	// defaultCallbackCmdTimeout specifies the duration after when the "post" or
 	// "timeout" command sets should be shut down.
 	defaultCallbackCmdTimeout = 15 * time.Minute
@@ -15,9 +15,11 @@ import (
 	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/annotations"
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/model/manifest"
 	patchmodel "github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
 	"github.com/evergreen-ci/evergreen/model/task"
 	restmodel "github.com/evergreen-ci/evergreen/rest/model"
 	"github.com/evergreen-ci/evergreen/util"
@@ -317,6 +319,25 @@ func (c *baseCommunicator) GetExpansionsAndVars(ctx context.Context, taskData Ta
 	return &expAndVars, nil
 }
 
+func (c *baseCommunicator) GetShardTests(ctx context.Context, taskData TaskData) (*apimodels.ShardTests, error) {
+	info := requestInfo{
+		method:   http.MethodGet,
+		taskData: &taskData,
+	}
+	info.setTaskPathSuffix("shard_tests")
+	resp, err := c.retryRequest(ctx, info, nil)
+	if err != nil {
+		return nil, util.RespErrorf(resp, errors.Wrap(err, "getting shard tests").Error())
+	}
+	defer resp.Body.Close()
+
+	var shardTests apimodels.ShardTests
+	if err = utility.ReadJSON(resp.Body, &shardTests); err != nil {
+		return nil, errors.Wrap(err, "reading shard tests from response")
+	}
+	return &shardTests, nil
+}
+
 // TaskConflict is a special agent-internal message that the heartbeat uses to
 // indicate that the task is failing because it's being aborted.
 const TaskConflict = "task-conflict"
@@ -390,6 +411,12 @@ func (c *baseCommunicator) GetLoggerProducer(ctx context.Context, td TaskData, c
 	}
 	underlying = append(underlying, senders...)
 
+	if config.Redactor != nil {
+		exec = makeRedactingSender(exec, config.Redactor)
+		task = makeRedactingSender(task, config.Redactor)
+		system = makeRedactingSender(system, config.Redactor)
+	}
+
 	return &logHarness{
 		execution:                 logging.MakeGrip(exec),
 		task:                      logging.MakeGrip(task),
@@ -789,6 +816,43 @@ func (c *baseCommunicator) AttachFiles(ctx context.Context, taskData TaskData, t
 	return nil
 }
 
+// SetAnnotationMetadataLinks sets the metadata links on the task's annotation.
+func (c *baseCommunicator) SetAnnotationMetadataLinks(ctx context.Context, taskData TaskData, links []annotations.MetadataLink) error {
+	info := requestInfo{
+		method:   http.MethodPost,
+		taskData: &taskData,
+	}
+	info.setTaskPathSuffix("annotation/metadata_links")
+	resp, err := c.retryRequest(ctx, info, links)
+	if err != nil {
+		return util.RespErrorf(resp, errors.Wrap(err, "setting annotation metadata links").Error())
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// SendResourceUsage sends samples of the resource usage of a task execution's
+// processes.
+func (c *baseCommunicator) SendResourceUsage(ctx context.Context, taskData TaskData, execution int, samples []resourceusage.Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	info := requestInfo{
+		method:   http.MethodPost,
+		taskData: &taskData,
+	}
+	info.setTaskPathSuffix(fmt.Sprintf("resource_usage?execution=%d", execution))
+	resp, err := c.retryRequest(ctx, info, samples)
+	if err != nil {
+		return util.RespErrorf(resp, errors.Wrap(err, "sending resource usage").Error())
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
 func (c *baseCommunicator) SetDownstreamParams(ctx context.Context, downstreamParams []patchmodel.Parameter, taskData TaskData) error {
 	info := requestInfo{
 		method:   http.MethodPost,
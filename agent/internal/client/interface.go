@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
 	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/annotations"
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/model/manifest"
 	patchmodel "github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
 	"github.com/evergreen-ci/evergreen/model/task"
 	restmodel "github.com/evergreen-ci/evergreen/rest/model"
 	"github.com/mongodb/grip"
@@ -68,6 +71,9 @@ type SharedCommunicator interface {
 	// project variables, project private variables, and version parameters are
 	// included, but not project parameters.
 	GetExpansionsAndVars(context.Context, TaskData) (*apimodels.ExpansionsAndVars, error)
+	// GetShardTests returns the tests assigned to the task's shard if the
+	// task's tests are split into shards.
+	GetShardTests(context.Context, TaskData) (*apimodels.ShardTests, error)
 	// GetCedarConfig returns the Cedar service configuration.
 	GetCedarConfig(context.Context) (*apimodels.CedarConfig, error)
 	// GetCedarGRPCConn returns the client connection to cedar if it exists, or
@@ -90,6 +96,9 @@ type SharedCommunicator interface {
 
 	// SendLogMessages sends a group of log messages to the API Server
 	SendLogMessages(context.Context, TaskData, []apimodels.LogMessage) error
+	// SendResourceUsage sends samples of the resource usage of a task
+	// execution's processes.
+	SendResourceUsage(context.Context, TaskData, int, []resourceusage.Sample) error
 
 	// The following operations are used by task commands.
 	SendTestLog(context.Context, TaskData, *model.TestLog) (string, error)
@@ -99,6 +108,9 @@ type SharedCommunicator interface {
 	NewPush(context.Context, TaskData, *apimodels.S3CopyRequest) (*model.PushLog, error)
 	UpdatePushStatus(context.Context, TaskData, *model.PushLog) error
 	AttachFiles(context.Context, TaskData, []*artifact.File) error
+	// SetAnnotationMetadataLinks sets the metadata links on the task's
+	// annotation.
+	SetAnnotationMetadataLinks(context.Context, TaskData, []annotations.MetadataLink) error
 	GetManifest(context.Context, TaskData) (*manifest.Manifest, error)
 	KeyValInc(context.Context, TaskData, *model.KeyVal) error
 
@@ -136,6 +148,9 @@ type LoggerConfig struct {
 	Agent              []LogOpts
 	Task               []LogOpts
 	SendToGlobalSender bool
+	// Redactor, if set, replaces secret values in every log channel before
+	// they are sent.
+	Redactor *redactor.Redactor
 }
 
 type LogOpts struct {
@@ -14,9 +14,11 @@ import (
 	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/cloud"
 	serviceModel "github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/annotations"
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/model/manifest"
 	patchmodel "github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/testresult"
 	"github.com/evergreen-ci/evergreen/rest/model"
@@ -57,10 +59,13 @@ type Mock struct {
 	HeartbeatCount              int
 	TaskExecution               int
 	CreatedHost                 apimodels.CreateHost
+	ShardTests                  *apimodels.ShardTests
 
 	CedarGRPCConn *grpc.ClientConn
 
 	AttachedFiles    map[string][]*artifact.File
+	MetadataLinks    map[string][]annotations.MetadataLink
+	ResourceUsage    map[string][]resourceusage.Sample
 	LogID            string
 	LocalTestResults []testresult.TestResult
 	ResultsService   string
@@ -94,6 +99,8 @@ func NewMock(serverURL string) *Mock {
 		PatchFiles:    make(map[string]string),
 		keyVal:        make(map[string]*serviceModel.KeyVal),
 		AttachedFiles: make(map[string][]*artifact.File),
+		MetadataLinks: make(map[string][]annotations.MetadataLink),
+		ResourceUsage: make(map[string][]resourceusage.Sample),
 		serverURL:     serverURL,
 	}
 }
@@ -238,6 +245,16 @@ func (c *Mock) GetExpansionsAndVars(ctx context.Context, taskData TaskData) (*ap
 	}, nil
 }
 
+// GetShardTests returns the mock's shard tests.
+func (c *Mock) GetShardTests(ctx context.Context, taskData TaskData) (*apimodels.ShardTests, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.ShardTests == nil {
+		return &apimodels.ShardTests{}, nil
+	}
+	return c.ShardTests, nil
+}
+
 func (c *Mock) Heartbeat(ctx context.Context, td TaskData) (string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -362,7 +379,11 @@ func (c *Mock) GetMockMessages() map[string][]apimodels.LogMessage {
 
 // GetLoggerProducer constructs a single channel log producer.
 func (c *Mock) GetLoggerProducer(ctx context.Context, td TaskData, config *LoggerConfig) (LoggerProducer, error) {
-	return NewSingleChannelLogHarness(td.ID, newEvergreenLogSender(ctx, c, apimodels.AgentLogPrefix, td, defaultLogBufferSize, defaultLogBufferTime)), nil
+	sender := newEvergreenLogSender(ctx, c, apimodels.AgentLogPrefix, td, defaultLogBufferSize, defaultLogBufferTime)
+	if config != nil && config.Redactor != nil {
+		sender = makeRedactingSender(sender, config.Redactor)
+	}
+	return NewSingleChannelLogHarness(td.ID, sender), nil
 }
 
 func (c *Mock) GetPatchFile(ctx context.Context, td TaskData, patchFileID string) (string, error) {
@@ -432,6 +453,34 @@ func (c *Mock) AttachFiles(ctx context.Context, td TaskData, taskFiles []*artifa
 	return nil
 }
 
+// SetAnnotationMetadataLinks stores the task's annotation metadata links.
+func (c *Mock) SetAnnotationMetadataLinks(ctx context.Context, td TaskData, links []annotations.MetadataLink) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.MetadataLinks[td.ID] = links
+
+	return nil
+}
+
+// SendResourceUsage stores the resource usage samples.
+func (c *Mock) SendResourceUsage(ctx context.Context, td TaskData, execution int, samples []resourceusage.Sample) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ResourceUsage[td.ID] = append(c.ResourceUsage[td.ID], samples...)
+
+	return nil
+}
+
+// GetResourceUsage returns the resource usage samples sent for the task.
+func (c *Mock) GetResourceUsage(taskID string) []resourceusage.Sample {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]resourceusage.Sample{}, c.ResourceUsage[taskID]...)
+}
+
 func (c *Mock) SetDownstreamParams(ctx context.Context, downstreamParams []patchmodel.Parameter, taskData TaskData) error {
 	c.DownstreamParams = downstreamParams
 	return nil
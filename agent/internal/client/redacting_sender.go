@@ -0,0 +1,34 @@
+package client
+
+import (
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
+	"github.com/mongodb/grip/message"
+	"github.com/mongodb/grip/send"
+)
+
+// redactingSender replaces secret values in log messages before passing them
+// on to the wrapped sender.
+type redactingSender struct {
+	send.Sender
+	redactor *redactor.Redactor
+}
+
+func (s *redactingSender) Send(m message.Composer) {
+	if !m.Loggable() {
+		return
+	}
+	original := m.String()
+	redacted := s.redactor.Redact(original)
+	if redacted == original {
+		s.Sender.Send(m)
+		return
+	}
+	s.Sender.Send(message.NewDefaultMessage(m.Priority(), redacted))
+}
+
+func makeRedactingSender(sender send.Sender, r *redactor.Redactor) send.Sender {
+	return &redactingSender{
+		Sender:   sender,
+		redactor: r,
+	}
+}
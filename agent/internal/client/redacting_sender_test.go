@@ -0,0 +1,39 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/mongodb/grip/send"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactingSender(t *testing.T) {
+	r := redactor.New()
+	r.Add("token", "s3cr3t-value")
+
+	inner, err := send.NewInternalLogger("test", send.LevelInfo{Default: level.Info, Threshold: level.Debug})
+	require.NoError(t, err)
+	sender := makeRedactingSender(inner, r)
+
+	sender.Send(message.NewDefaultMessage(level.Info, "+ curl -H 'token: s3cr3t-value'"))
+	msg := inner.GetMessage()
+	require.NotNil(t, msg)
+	assert.Equal(t, "+ curl -H 'token: <REDACTED:token>'", msg.Message.String())
+	assert.Equal(t, level.Info, msg.Priority)
+
+	sender.Send(message.NewDefaultMessage(level.Error, "nothing to hide"))
+	msg = inner.GetMessage()
+	require.NotNil(t, msg)
+	assert.Equal(t, "nothing to hide", msg.Message.String())
+	assert.Equal(t, level.Error, msg.Priority)
+
+	r.Add("password", "added-later")
+	sender.Send(message.NewFormattedMessage(level.Warning, "password is %s", "added-later"))
+	msg = inner.GetMessage()
+	require.NotNil(t, msg)
+	assert.Equal(t, "password is <REDACTED:password>", msg.Message.String())
+}
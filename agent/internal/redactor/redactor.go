@@ -0,0 +1,91 @@
+// Package redactor replaces secret values in text with placeholders so that
+// they aren't leaked into task logs.
+package redactor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// minSecretLength is the shortest value that will be redacted. Redacting very
+// short values (e.g. "1" or "true") would mangle unrelated log output without
+// meaningfully protecting anything.
+const minSecretLength = 4
+
+// Redactor replaces registered secret values with <REDACTED:name>. It is safe
+// for concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	names    map[string]string
+	replacer *strings.Replacer
+}
+
+// New returns an empty Redactor.
+func New() *Redactor {
+	return &Redactor{names: map[string]string{}}
+}
+
+// Add registers a secret value to be redacted under the given name. Values
+// shorter than the minimum secret length are ignored. If the same value is
+// registered more than once, the first name is kept.
+func (r *Redactor) Add(name, value string) {
+	if r == nil || len(value) < minSecretLength {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.names[value]; ok {
+		return
+	}
+	r.names[value] = name
+	r.replacer = nil
+}
+
+// Redact returns s with every registered secret value replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	replacer := r.getReplacer()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+func (r *Redactor) getReplacer() *strings.Replacer {
+	r.mu.RLock()
+	replacer := r.replacer
+	numValues := len(r.names)
+	r.mu.RUnlock()
+	if replacer != nil || numValues == 0 {
+		return replacer
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replacer != nil {
+		return r.replacer
+	}
+	values := make([]string, 0, len(r.names))
+	for value := range r.names {
+		values = append(values, value)
+	}
+	// strings.Replacer tries the old strings in argument order at each
+	// position, so longer values go first to avoid partially redacting a
+	// secret that contains a shorter one.
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	oldnew := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		oldnew = append(oldnew, value, fmt.Sprintf("<REDACTED:%s>", r.names[value]))
+	}
+	r.replacer = strings.NewReplacer(oldnew...)
+	return r.replacer
+}
@@ -0,0 +1,62 @@
+package redactor
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	t.Run("ReplacesRegisteredValues", func(t *testing.T) {
+		r := New()
+		r.Add("token", "abcd1234")
+		r.Add("password", "hunter22")
+		assert.Equal(t, "+ curl -H 'Authorization: <REDACTED:token>' -u me:<REDACTED:password>",
+			r.Redact("+ curl -H 'Authorization: abcd1234' -u me:hunter22"))
+	})
+	t.Run("PrefersLongerValues", func(t *testing.T) {
+		r := New()
+		r.Add("short", "secret")
+		r.Add("long", "secret-suffix")
+		assert.Equal(t, "<REDACTED:long> <REDACTED:short>", r.Redact("secret-suffix secret"))
+	})
+	t.Run("IgnoresShortValues", func(t *testing.T) {
+		r := New()
+		r.Add("flag", "1")
+		r.Add("empty", "")
+		assert.Equal(t, "exit code 1", r.Redact("exit code 1"))
+	})
+	t.Run("KeepsFirstNameForDuplicateValues", func(t *testing.T) {
+		r := New()
+		r.Add("first", "duplicated")
+		r.Add("second", "duplicated")
+		assert.Equal(t, "<REDACTED:first>", r.Redact("duplicated"))
+	})
+	t.Run("RedactsValuesAddedLater", func(t *testing.T) {
+		r := New()
+		r.Add("a", "aaaa")
+		assert.Equal(t, "<REDACTED:a> bbbb", r.Redact("aaaa bbbb"))
+		r.Add("b", "bbbb")
+		assert.Equal(t, "<REDACTED:a> <REDACTED:b>", r.Redact("aaaa bbbb"))
+	})
+	t.Run("NilIsNoop", func(t *testing.T) {
+		var r *Redactor
+		r.Add("a", "aaaa")
+		assert.Equal(t, "aaaa", r.Redact("aaaa"))
+	})
+	t.Run("ConcurrentUse", func(t *testing.T) {
+		r := New()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.Add("secret", "concurrent")
+				_ = r.Redact("concurrent")
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, "<REDACTED:secret>", r.Redact("concurrent"))
+	})
+}
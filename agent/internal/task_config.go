@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
 	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/patch"
@@ -22,13 +23,16 @@ import (
 )
 
 type TaskConfig struct {
-	Distro             *apimodels.DistroView
-	ProjectRef         *model.ProjectRef
-	Project            *model.Project
-	Task               *task.Task
-	BuildVariant       *model.BuildVariant
-	Expansions         *util.Expansions
-	Redacted           map[string]bool
+	Distro       *apimodels.DistroView
+	ProjectRef   *model.ProjectRef
+	Project      *model.Project
+	Task         *task.Task
+	BuildVariant *model.BuildVariant
+	Expansions   *util.Expansions
+	Redacted     map[string]bool
+	// Redactor replaces secret values in the task's logs. Commands can
+	// register additional secrets with it while the task is running.
+	Redactor           *redactor.Redactor
 	WorkDir            string
 	GithubPatchData    thirdparty.GithubPatch
 	GithubMergeData    thirdparty.GithubMergeGroup
@@ -37,10 +41,23 @@ type TaskConfig struct {
 	EC2Keys            []evergreen.EC2Key
 	ModulePaths        map[string]string
 	CedarTestResultsID string
+	// LocalAPIURL and LocalAPIToken are how the task's processes reach the
+	// agent's local API.
+	LocalAPIURL   string
+	LocalAPIToken string
 
 	mu sync.RWMutex
 }
 
+const (
+	// LocalAPIURLEnv is the environment variable that holds the URL of the
+	// agent's local API in the task's processes.
+	LocalAPIURLEnv = "EVR_AGENT_API_URL"
+	// LocalAPITokenEnv is the environment variable that holds the token that
+	// authenticates the task's processes to the agent's local API.
+	LocalAPITokenEnv = "EVR_AGENT_API_TOKEN"
+)
+
 type Timeout struct {
 	IdleTimeoutSecs int
 	ExecTimeoutSecs int
@@ -93,6 +110,7 @@ func NewTaskConfig(workDir string, d *apimodels.DistroView, p *model.Project, t
 		Task:         t,
 		BuildVariant: bv,
 		Expansions:   &e,
+		Redactor:     redactor.New(),
 		WorkDir:      workDir,
 	}
 	if patchDoc != nil {
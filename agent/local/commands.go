@@ -0,0 +1,348 @@
+package local
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/agent/command"
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/utility"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// supportedCommands are the commands that don't depend on the Evergreen
+// server, so they run locally exactly as they would in a task.
+var supportedCommands = []string{
+	"archive.targz_pack",
+	"archive.targz_extract",
+	"archive.zip_pack",
+	"archive.zip_extract",
+	"archive.auto_extract",
+	"archive.tar_pack",
+	"archive.tar_extract",
+	"expansions.update",
+	"expansions.write",
+	"setup.initial",
+	"timeout.update",
+	evergreen.ShellExecCommandName,
+	"subprocess.exec",
+}
+
+// localCommandFactory creates the local replacement for a command from its
+// parameters.
+type localCommandFactory func(e *executor, params map[string]interface{}) (localExecuteFunc, error)
+
+type localExecuteFunc func(ctx context.Context, logger client.LoggerProducer, conf *internal.TaskConfig) error
+
+// localCommandFactories are the commands that depend on the Evergreen server
+// or on remote resources, but have a local equivalent. All other commands are
+// skipped.
+var localCommandFactories = map[string]localCommandFactory{
+	"git.get_project": localGitGetProjectFactory,
+	"s3.put":          localS3PutFactory,
+	"s3.get":          localS3GetFactory,
+}
+
+// localCommand runs a local replacement in place of a command, keeping the
+// original command's name, display name and timeout.
+type localCommand struct {
+	command.Command
+	execute localExecuteFunc
+}
+
+func (c *localCommand) Execute(ctx context.Context, _ client.Communicator, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+	return c.execute(ctx, logger, conf)
+}
+
+// unsupportedCommand is a command that can't run outside of Evergreen, so it
+// is skipped.
+type unsupportedCommand struct {
+	command.Command
+}
+
+// decodeParams decodes the command's parameters into out, which must be a
+// pointer to a struct.
+func decodeParams(params map[string]interface{}, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating params decoder")
+	}
+	return errors.Wrap(decoder.Decode(params), "decoding params")
+}
+
+type localGitGetProjectParams struct {
+	Directory string `mapstructure:"directory" plugin:"expand"`
+}
+
+// localGitGetProjectFactory replaces cloning the project with a copy of the
+// local source directory, so the task runs against the local checkout,
+// including any uncommitted changes. The task gets a copy rather than the
+// source directory itself so that commands that modify or clean the checkout
+// can't change the user's working tree.
+func localGitGetProjectFactory(e *executor, params map[string]interface{}) (localExecuteFunc, error) {
+	p := localGitGetProjectParams{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Directory == "" {
+		return nil, errors.New("must specify a directory")
+	}
+
+	return func(ctx context.Context, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+		if err := util.ExpandValues(&p, conf.Expansions); err != nil {
+			return errors.Wrap(err, "applying expansions")
+		}
+		dir, err := localCheckoutDir(conf.WorkDir, p.Directory)
+		if err != nil {
+			return err
+		}
+		if err = removeLocalCheckout(dir); err != nil {
+			return err
+		}
+		// The scratch directory may be inside the source directory, in which
+		// case it must not be copied into itself.
+		if err = copyDir(e.opts.SourceDir, dir, e.opts.Dir); err != nil {
+			return errors.Wrapf(err, "copying source directory '%s' to '%s'", e.opts.SourceDir, dir)
+		}
+		logger.Task().Infof("Copied local source directory '%s' to '%s' instead of cloning the project. Modules are not fetched.", e.opts.SourceDir, dir)
+		return nil
+	}, nil
+}
+
+// localCheckoutDir returns the absolute path of the checkout directory, which
+// must be inside the task's working directory.
+func localCheckoutDir(workDir, dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	if !isInDir(workDir, dir) {
+		return "", errors.Errorf("directory '%s' must be inside the working directory '%s'", dir, workDir)
+	}
+	return dir, nil
+}
+
+// isInDir returns whether the path is inside the directory, not counting the
+// directory itself.
+func isInDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeLocalCheckout removes the checkout left by a previous task in the same
+// scratch directory so that it can be replaced. The checkout is always inside
+// the task's working directory, so it never belongs to the user.
+func removeLocalCheckout(dir string) error {
+	info, err := os.Lstat(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "checking directory '%s'", dir)
+	}
+	if info.Mode()&os.ModeSymlink == 0 && !info.IsDir() {
+		return errors.Errorf("'%s' already exists and is not a directory", dir)
+	}
+	// RemoveAll doesn't follow symlinks, so a link left by an older version
+	// only removes the link.
+	return errors.Wrapf(os.RemoveAll(dir), "removing existing directory '%s'", dir)
+}
+
+// copyDir recursively copies the source directory to the destination,
+// skipping the excluded directory. Symlinks are copied as symlinks rather than
+// followed, and other special files are skipped.
+func copyDir(src, dst, exclude string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (path == exclude || path == dst) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return errors.Wrapf(err, "getting relative path of '%s'", path)
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return errors.Wrapf(err, "getting info for '%s'", path)
+		}
+
+		switch {
+		case d.IsDir():
+			return errors.Wrapf(os.MkdirAll(target, info.Mode().Perm()|0700), "creating directory '%s'", target)
+		case d.Type()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return errors.Wrapf(err, "reading symlink '%s'", path)
+			}
+			return errors.Wrapf(os.Symlink(link, target), "creating symlink '%s'", target)
+		case d.Type().IsRegular():
+			if err := copyFile(path, target); err != nil {
+				return err
+			}
+			return errors.Wrapf(os.Chmod(target, info.Mode().Perm()), "setting permissions of '%s'", target)
+		default:
+			return nil
+		}
+	})
+}
+
+type localS3PutParams struct {
+	LocalFile                     string   `mapstructure:"local_file" plugin:"expand"`
+	LocalFilesIncludeFilter       []string `mapstructure:"local_files_include_filter" plugin:"expand"`
+	LocalFilesIncludeFilterPrefix string   `mapstructure:"local_files_include_filter_prefix" plugin:"expand"`
+	RemoteFile                    string   `mapstructure:"remote_file" plugin:"expand"`
+	PreservePath                  string   `mapstructure:"preserve_path" plugin:"expand"`
+	Bucket                        string   `mapstructure:"bucket" plugin:"expand"`
+	Optional                      string   `mapstructure:"optional" plugin:"expand"`
+}
+
+// localS3PutFactory replaces uploading files to S3 with copying them to a
+// local directory for the bucket in the scratch directory.
+func localS3PutFactory(e *executor, params map[string]interface{}) (localExecuteFunc, error) {
+	p := localS3PutParams{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+		if err := util.ExpandValues(&p, conf.Expansions); err != nil {
+			return errors.Wrap(err, "applying expansions")
+		}
+		if p.Bucket == "" || p.RemoteFile == "" {
+			return errors.New("must specify a bucket and remote file")
+		}
+		optional, _ := strconv.ParseBool(p.Optional)
+		preservePath, _ := strconv.ParseBool(p.PreservePath)
+
+		files := map[string]string{}
+		if len(p.LocalFilesIncludeFilter) == 0 {
+			files[absPath(conf.WorkDir, p.LocalFile)] = p.RemoteFile
+		} else {
+			workDir := filepath.Join(conf.WorkDir, p.LocalFilesIncludeFilterPrefix)
+			b := utility.FileListBuilder{
+				WorkingDir: workDir,
+				Include:    utility.NewGitIgnoreFileMatcher(workDir, p.LocalFilesIncludeFilter...),
+			}
+			matches, err := b.Build()
+			if err != nil {
+				return errors.Wrapf(err, "processing local files include filter '%s'", strings.Join(p.LocalFilesIncludeFilter, " "))
+			}
+			for _, match := range matches {
+				remote := p.RemoteFile + filepath.Base(match)
+				if preservePath {
+					remote = filepath.Join(p.RemoteFile, match)
+				}
+				files[filepath.Join(workDir, match)] = remote
+			}
+		}
+
+		for local, remote := range files {
+			dst, err := localBucketPath(e.s3Dir, p.Bucket, remote)
+			if err != nil {
+				return err
+			}
+			if err := copyFile(local, dst); err != nil {
+				if os.IsNotExist(errors.Cause(err)) && optional {
+					logger.Task().Infof("File '%s' not found and optional is true, skipping.", local)
+					continue
+				}
+				return errors.Wrapf(err, "copying '%s' to local bucket", local)
+			}
+			logger.Task().Infof("Copied '%s' to '%s' instead of uploading it to S3.", local, dst)
+		}
+		return nil
+	}, nil
+}
+
+type localS3GetParams struct {
+	RemoteFile string `mapstructure:"remote_file" plugin:"expand"`
+	Bucket     string `mapstructure:"bucket" plugin:"expand"`
+	LocalFile  string `mapstructure:"local_file" plugin:"expand"`
+	ExtractTo  string `mapstructure:"extract_to" plugin:"expand"`
+}
+
+// localS3GetFactory replaces downloading a file from S3 with copying it from
+// the local directory for the bucket, so that it can get files that were put
+// by an earlier local run that used the same scratch directory.
+func localS3GetFactory(e *executor, params map[string]interface{}) (localExecuteFunc, error) {
+	p := localS3GetParams{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, logger client.LoggerProducer, conf *internal.TaskConfig) error {
+		if err := util.ExpandValues(&p, conf.Expansions); err != nil {
+			return errors.Wrap(err, "applying expansions")
+		}
+		if p.ExtractTo != "" {
+			return errors.New("extract_to is not supported when running locally")
+		}
+		if p.Bucket == "" || p.RemoteFile == "" || p.LocalFile == "" {
+			return errors.New("must specify a bucket, remote file and local file")
+		}
+
+		src, err := localBucketPath(e.s3Dir, p.Bucket, p.RemoteFile)
+		if err != nil {
+			return err
+		}
+		dst := absPath(conf.WorkDir, p.LocalFile)
+		if err := copyFile(src, dst); err != nil {
+			return errors.Wrapf(err, "copying '%s' from local bucket", src)
+		}
+		logger.Task().Infof("Copied '%s' to '%s' instead of downloading it from S3.", src, dst)
+		return nil
+	}, nil
+}
+
+// localBucketPath returns the path of the remote file in the local directory
+// for the bucket, which must be inside the local S3 directory.
+func localBucketPath(s3Dir, bucket, remote string) (string, error) {
+	path := filepath.Join(s3Dir, bucket, remote)
+	if !isInDir(filepath.Join(s3Dir, bucket), path) || !isInDir(s3Dir, filepath.Join(s3Dir, bucket)) {
+		return "", errors.Errorf("remote file '%s' in bucket '%s' must be inside the local S3 directory '%s'", remote, bucket, s3Dir)
+	}
+	return path, nil
+}
+
+func absPath(workDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workDir, path)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "opening file '%s'", src)
+	}
+	defer in.Close()
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return errors.Wrapf(err, "creating directory for file '%s'", dst)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrapf(err, "creating file '%s'", dst)
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return errors.Wrapf(err, "copying file '%s' to '%s'", src, dst)
+	}
+	return errors.Wrapf(out.Close(), "closing file '%s'", dst)
+}
@@ -0,0 +1,422 @@
+// Package local runs a task's commands on the local machine, outside of
+// Evergreen, so that changes to a project's commands can be tried out without
+// creating a patch.
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/agent/command"
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/recovery"
+	"github.com/mongodb/grip/send"
+	"github.com/mongodb/jasper"
+	"github.com/pkg/errors"
+)
+
+const (
+	preBlock           = "pre"
+	setupTaskBlock     = "setup_task"
+	teardownTaskBlock  = "teardown_task"
+	setupGroupBlock    = "setup_group"
+	teardownGroupBlock = "teardown_group"
+	postBlock          = "post"
+
+	// taskDirName and s3DirName are the directories within the scratch
+	// directory that are used as the task's working directory and as the
+	// local replacement for S3 buckets, respectively.
+	taskDirName = "task"
+	s3DirName   = "s3"
+)
+
+// Options configure running a task locally.
+type Options struct {
+	// Project is the project containing the task.
+	Project *model.Project
+	// ProjectID is the project's identifier, which is used to populate
+	// expansions. It is optional.
+	ProjectID string
+	// TaskName is the name of the task to run.
+	TaskName string
+	// VariantName is the name of the build variant to run the task on.
+	VariantName string
+	// Dir is the scratch directory to run the task in. If it's not set, a
+	// temporary directory is created.
+	Dir string
+	// SourceDir is the directory containing the project source code, which
+	// replaces the checkout that git.get_project would normally create. If
+	// it's not set, the current working directory is used.
+	SourceDir string
+	// Expansions are additional expansions to set for the task, which take
+	// precedence over the default ones.
+	Expansions map[string]string
+	// Sender is where task logs are sent. If it's not set, logs are written to
+	// standard output.
+	Sender send.Sender
+}
+
+func (o *Options) validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.Project == nil, "must specify a project")
+	catcher.NewWhen(o.TaskName == "", "must specify a task name")
+	catcher.NewWhen(o.VariantName == "", "must specify a build variant name")
+	return catcher.Resolve()
+}
+
+// Result describes the outcome of running a task locally.
+type Result struct {
+	// Dir is the scratch directory that the task ran in.
+	Dir string
+	// WorkDir is the task's working directory.
+	WorkDir string
+	// Skipped are the names of the commands that were skipped because they
+	// can't run outside of Evergreen.
+	Skipped []string
+}
+
+// executor runs a single task's commands.
+type executor struct {
+	opts      Options
+	conf      *internal.TaskConfig
+	taskGroup string
+	logger    client.LoggerProducer
+	jasper    jasper.Manager
+	s3Dir     string
+	skipped   []string
+}
+
+// Run runs a task's pre, main and post commands in a scratch directory on the
+// local machine. Commands that depend on the Evergreen server are replaced
+// with local equivalents if possible, and skipped otherwise. It returns an
+// error if the task fails.
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid options")
+	}
+
+	e, err := newExecutor(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		grip.Error(errors.Wrap(e.logger.Close(), "closing task logger"))
+	}()
+
+	res := &Result{Dir: e.opts.Dir, WorkDir: e.conf.WorkDir}
+	err = e.run(ctx)
+	res.Skipped = e.skipped
+	return res, err
+}
+
+func newExecutor(opts Options) (*executor, error) {
+	p := opts.Project
+	bvt := p.FindTaskForVariant(opts.TaskName, opts.VariantName)
+	if bvt == nil {
+		return nil, errors.Errorf("task '%s' does not run on build variant '%s'", opts.TaskName, opts.VariantName)
+	}
+	pt := p.FindProjectTask(opts.TaskName)
+	if pt == nil {
+		return nil, errors.Errorf("task '%s' not found in project", opts.TaskName)
+	}
+
+	var err error
+	if opts.Dir == "" {
+		opts.Dir, err = os.MkdirTemp("", "evergreen-local-")
+		if err != nil {
+			return nil, errors.Wrap(err, "creating scratch directory")
+		}
+	}
+	if opts.Dir, err = filepath.Abs(opts.Dir); err != nil {
+		return nil, errors.Wrap(err, "getting absolute path of scratch directory")
+	}
+	if opts.SourceDir == "" {
+		if opts.SourceDir, err = os.Getwd(); err != nil {
+			return nil, errors.Wrap(err, "getting current working directory")
+		}
+	}
+	if opts.SourceDir, err = filepath.Abs(opts.SourceDir); err != nil {
+		return nil, errors.Wrap(err, "getting absolute path of source directory")
+	}
+
+	workDir := filepath.Join(opts.Dir, taskDirName)
+	s3Dir := filepath.Join(opts.Dir, s3DirName)
+	for _, dir := range []string{workDir, filepath.Join(workDir, "tmp"), s3Dir} {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrapf(err, "creating directory '%s'", dir)
+		}
+	}
+
+	t := &task.Task{
+		Id:           fmt.Sprintf("local_%s_%s", opts.VariantName, opts.TaskName),
+		Version:      "local",
+		BuildId:      fmt.Sprintf("local_%s", opts.VariantName),
+		DisplayName:  opts.TaskName,
+		BuildVariant: opts.VariantName,
+		Project:      opts.ProjectID,
+	}
+	// A task in a task group is found by its group's build variant task
+	// unit, which keeps the group's name.
+	if bvt.Name != opts.TaskName {
+		t.TaskGroup = bvt.Name
+	}
+
+	conf, err := internal.NewTaskConfig(workDir, nil, p, t, &model.ProjectRef{Id: opts.ProjectID, Identifier: opts.ProjectID}, nil, localExpansions(opts, t, pt, workDir))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating task config")
+	}
+
+	jpm, err := jasper.NewSynchronizedManager(false)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating Jasper process manager")
+	}
+
+	sender := opts.Sender
+	if sender == nil {
+		sender, err = send.NewPlainLogger("local", send.LevelInfo{Default: level.Info, Threshold: level.Info})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating log sender")
+		}
+	}
+
+	return &executor{
+		opts:      opts,
+		conf:      conf,
+		taskGroup: t.TaskGroup,
+		logger:    client.NewSingleChannelLogHarness(t.Id, sender),
+		jasper:    jpm,
+		s3Dir:     s3Dir,
+	}, nil
+}
+
+// localExpansions returns the task's expansions, in increasing order of
+// precedence: the default task expansions, the build variant's expansions,
+// the task's own expansions, and the user-provided expansions.
+func localExpansions(opts Options, t *task.Task, pt *model.ProjectTask, workDir string) util.Expansions {
+	exp := util.Expansions{}
+	exp.Put("execution", "0")
+	exp.Put("version_id", t.Version)
+	exp.Put("build_id", t.BuildId)
+	exp.Put("task_id", t.Id)
+	exp.Put("task_name", t.DisplayName)
+	exp.Put("build_variant", t.BuildVariant)
+	exp.Put("workdir", workDir)
+	if opts.ProjectID != "" {
+		exp.Put("project", opts.ProjectID)
+		exp.Put("project_identifier", opts.ProjectID)
+		exp.Put("project_id", opts.ProjectID)
+	}
+	if bv := opts.Project.FindBuildVariant(t.BuildVariant); bv != nil {
+		exp.Update(bv.Expansions)
+	}
+	exp.Update(pt.Expansions)
+	exp.Update(opts.Expansions)
+	return exp
+}
+
+// run runs all the task's commands in the same order that the agent would.
+func (e *executor) run(ctx context.Context) error {
+	defer e.killProcs(ctx)
+
+	e.logger.Task().Infof("Running task '%s' on build variant '%s' in directory '%s'.", e.conf.Task.DisplayName, e.conf.Task.BuildVariant, e.conf.WorkDir)
+
+	tg, err := e.conf.GetTaskGroup(e.taskGroup)
+	if err != nil {
+		return errors.Wrap(err, "getting task group")
+	}
+	if tg != nil && tg.TeardownGroup != nil {
+		defer func() {
+			e.logger.Task().Error(errors.Wrap(e.runBlock(ctx, tg.TeardownGroup.List(), teardownGroupBlock, false), "running teardown group commands"))
+		}()
+	}
+	if tg != nil && tg.SetupGroup != nil {
+		err = e.runBlock(ctx, tg.SetupGroup.List(), setupGroupBlock, tg.SetupGroupFailTask)
+		if err != nil && tg.SetupGroupFailTask {
+			return errors.Wrap(err, "running setup group commands")
+		}
+		e.logger.Task().Error(errors.Wrap(err, "running setup group commands"))
+	}
+
+	pre, err := e.conf.GetPre(e.taskGroup)
+	if err != nil {
+		return errors.Wrap(err, "getting pre-task commands")
+	}
+	block := preBlock
+	if e.taskGroup != "" {
+		block = setupTaskBlock
+	}
+	if pre.Commands != nil {
+		err = e.runBlock(ctx, pre.Commands.List(), block, pre.CanFailTask)
+		if err != nil && pre.CanFailTask {
+			return errors.Wrap(err, "running pre-task commands")
+		}
+		e.logger.Task().Error(errors.Wrap(err, "running pre-task commands"))
+	}
+
+	pt := e.conf.Project.FindProjectTask(e.conf.Task.DisplayName)
+	taskErr := errors.Wrap(e.runBlock(ctx, pt.Commands, "", true), "running task commands")
+
+	post, err := e.conf.GetPost(e.taskGroup)
+	if err != nil {
+		return errors.Wrap(err, "getting post-task commands")
+	}
+	block = postBlock
+	if e.taskGroup != "" {
+		block = teardownTaskBlock
+	}
+	if post.Commands != nil {
+		err = e.runBlock(ctx, post.Commands.List(), block, post.CanFailTask)
+		if err != nil && post.CanFailTask && taskErr == nil {
+			taskErr = errors.Wrap(err, "running post-task commands")
+		}
+		e.logger.Task().Error(errors.Wrap(err, "running post-task commands"))
+	}
+
+	if taskErr != nil {
+		e.logger.Task().Errorf("Task '%s' failed.", e.conf.Task.DisplayName)
+		return taskErr
+	}
+	e.logger.Task().Infof("Task '%s' succeeded.", e.conf.Task.DisplayName)
+	return nil
+}
+
+// runBlock runs a block of commands. If canFail is true, it stops at the first
+// command that fails and returns its error; otherwise, failed commands are
+// logged and ignored.
+func (e *executor) runBlock(ctx context.Context, commands []model.PluginCommandConf, block string, canFail bool) error {
+	for i, commandInfo := range commands {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "canceled while running commands")
+		}
+		blockInfo := command.BlockInfo{
+			Block:     block,
+			CmdNum:    i + 1,
+			TotalCmds: len(commands),
+		}
+		cmds, err := e.render(commandInfo, blockInfo)
+		if err != nil {
+			return errors.Wrapf(err, "rendering command '%s'", commandInfo.Command)
+		}
+		for j, cmd := range cmds {
+			funcInfo := command.FunctionInfo{
+				Function:     commandInfo.Function,
+				SubCmdNum:    j + 1,
+				TotalSubCmds: len(cmds),
+			}
+			displayName := command.GetDefaultDisplayName(cmd.Name(), blockInfo, funcInfo)
+			if !commandInfo.RunOnVariant(e.conf.BuildVariant.Name) {
+				e.logger.Task().Infof("Skipping command %s on variant %s.", displayName, e.conf.BuildVariant.Name)
+				continue
+			}
+			if _, ok := cmd.(*unsupportedCommand); ok {
+				e.logger.Task().Warningf("Skipping command %s because it cannot run outside of Evergreen.", displayName)
+				e.skipped = append(e.skipped, displayName)
+				continue
+			}
+
+			if err := e.runCommand(ctx, commandInfo, cmd, displayName); err != nil {
+				e.logger.Task().Errorf("Command %s failed: %s.", displayName, err)
+				if canFail {
+					return errors.Wrapf(err, "running command %s", displayName)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// render returns the commands to run for the command specification. Commands
+// that have a local replacement are swapped for it, and commands that can't
+// run locally are marked as unsupported.
+func (e *executor) render(commandInfo model.PluginCommandConf, blockInfo command.BlockInfo) ([]command.Command, error) {
+	cmds, err := command.Render(commandInfo, e.conf.Project, blockInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	confs := []model.PluginCommandConf{commandInfo}
+	if commandInfo.Function != "" {
+		confs = nil
+		if funcCmds := e.conf.Project.Functions[commandInfo.Function]; funcCmds != nil {
+			confs = funcCmds.List()
+		}
+	}
+	if len(confs) != len(cmds) {
+		return nil, errors.Errorf("rendered %d command(s) but expected %d", len(cmds), len(confs))
+	}
+
+	for i, cmd := range cmds {
+		if utility.StringSliceContains(supportedCommands, cmd.Name()) {
+			continue
+		}
+		factory, ok := localCommandFactories[cmd.Name()]
+		if !ok {
+			cmds[i] = &unsupportedCommand{Command: cmd}
+			continue
+		}
+		local, err := factory(e, confs[i].Params)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating local replacement for command '%s'", cmd.Name())
+		}
+		cmds[i] = &localCommand{Command: cmd, execute: local}
+	}
+	return cmds, nil
+}
+
+// runCommand runs a single command with the command's function variables set.
+func (e *executor) runCommand(ctx context.Context, commandInfo model.PluginCommandConf, cmd command.Command, displayName string) (err error) {
+	e.logger.Task().Infof("Running command %s.", displayName)
+
+	prevExp := map[string]string{}
+	for key, val := range commandInfo.Vars {
+		prevExp[key] = e.conf.Expansions.Get(key)
+		newVal, err := e.conf.Expansions.ExpandString(val)
+		if err != nil {
+			return errors.Wrapf(err, "expanding '%s'", val)
+		}
+		e.conf.Expansions.Put(key, newVal)
+	}
+	defer func() {
+		for key, functionValue := range commandInfo.Vars {
+			if currentValue := e.conf.Expansions.Get(key); currentValue != functionValue {
+				prevExp[key] = currentValue
+			}
+		}
+		e.conf.Expansions.Update(prevExp)
+	}()
+
+	if timeout := cmd.IdleTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if pErr := recovery.HandlePanicWithError(recover(), nil, fmt.Sprintf("running command %s", displayName)); pErr != nil {
+			err = pErr
+		}
+	}()
+
+	cmd.SetJasperManager(e.jasper)
+	start := time.Now()
+	if err = cmd.Execute(ctx, nil, e.logger, e.conf); err != nil {
+		return err
+	}
+	e.logger.Task().Infof("Finished command %s in %s.", displayName, time.Since(start))
+	return nil
+}
+
+// killProcs kills any processes that the task left running.
+func (e *executor) killProcs(ctx context.Context) {
+	e.logger.Task().Error(errors.Wrap(e.jasper.Close(ctx), "killing task processes"))
+}
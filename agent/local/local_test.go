@@ -0,0 +1,326 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/mongodb/grip/send"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadProject(t *testing.T, yml string) *model.Project {
+	p := &model.Project{}
+	_, err := model.LoadProjectInto(context.Background(), []byte(yml), nil, "", p)
+	require.NoError(t, err)
+	return p
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const projYml = `
+functions:
+  write:
+    command: shell.exec
+    params:
+      script: echo "${content}" > ${file}
+pre:
+  - command: shell.exec
+    params:
+      script: echo pre > pre.txt
+post:
+  - command: shell.exec
+    params:
+      script: echo post > post.txt
+tasks:
+  - name: hello
+    commands:
+      - func: write
+        vars:
+          file: hello.txt
+          content: ${greeting} from ${task_name} on ${build_variant}
+      - command: attach.results
+        params:
+          file_location: results.json
+  - name: fail
+    commands:
+      - command: shell.exec
+        params:
+          script: exit 1
+      - command: shell.exec
+        params:
+          script: echo unreachable > unreachable.txt
+  - name: upload
+    commands:
+      - command: git.get_project
+        params:
+          directory: src
+      - command: shell.exec
+        params:
+          working_dir: src
+          script: cp source.txt ${workdir}/upload.txt && echo modified > modified.txt
+      - command: s3.put
+        params:
+          aws_key: key
+          aws_secret: secret
+          bucket: bucket
+          local_file: upload.txt
+          remote_file: ${build_variant}/upload.txt
+          content_type: text/plain
+          permissions: private
+      - command: s3.get
+        params:
+          aws_key: key
+          aws_secret: secret
+          bucket: bucket
+          remote_file: ${build_variant}/upload.txt
+          local_file: download.txt
+buildvariants:
+  - name: bv
+    display_name: bv
+    expansions:
+      greeting: hi
+    tasks:
+      - name: hello
+      - name: fail
+      - name: upload
+`
+	p := loadProject(t, projYml)
+
+	t.Run("RunsPreTaskAndPostCommands", func(t *testing.T) {
+		res, err := Run(ctx, Options{
+			Project:     p,
+			TaskName:    "hello",
+			VariantName: "bv",
+			Dir:         t.TempDir(),
+			Sender:      send.MakeInternalLogger(),
+		})
+		require.NoError(t, err)
+
+		for file, content := range map[string]string{
+			"pre.txt":   "pre",
+			"hello.txt": "hi from hello on bv",
+			"post.txt":  "post",
+		} {
+			out, err := os.ReadFile(filepath.Join(res.WorkDir, file))
+			require.NoError(t, err, file)
+			assert.Equal(t, content, strings.TrimSpace(string(out)))
+		}
+		require.Len(t, res.Skipped, 1)
+		assert.Contains(t, res.Skipped[0], "attach.results")
+	})
+	t.Run("UserExpansionsTakePrecedence", func(t *testing.T) {
+		res, err := Run(ctx, Options{
+			Project:     p,
+			TaskName:    "hello",
+			VariantName: "bv",
+			Dir:         t.TempDir(),
+			Expansions:  map[string]string{"greeting": "hello"},
+			Sender:      send.MakeInternalLogger(),
+		})
+		require.NoError(t, err)
+
+		out, err := os.ReadFile(filepath.Join(res.WorkDir, "hello.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello from hello on bv", strings.TrimSpace(string(out)))
+	})
+	t.Run("FailsOnFailedCommandAndStillRunsPost", func(t *testing.T) {
+		res, err := Run(ctx, Options{
+			Project:     p,
+			TaskName:    "fail",
+			VariantName: "bv",
+			Dir:         t.TempDir(),
+			Sender:      send.MakeInternalLogger(),
+		})
+		assert.Error(t, err)
+		require.NotNil(t, res)
+		assert.NoFileExists(t, filepath.Join(res.WorkDir, "unreachable.txt"))
+		assert.FileExists(t, filepath.Join(res.WorkDir, "post.txt"))
+	})
+	t.Run("UsesLocalReplacements", func(t *testing.T) {
+		sourceDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "source.txt"), []byte("source"), 0644))
+
+		res, err := Run(ctx, Options{
+			Project:     p,
+			TaskName:    "upload",
+			VariantName: "bv",
+			Dir:         t.TempDir(),
+			SourceDir:   sourceDir,
+			Sender:      send.MakeInternalLogger(),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, res.Skipped)
+
+		assert.FileExists(t, filepath.Join(res.Dir, s3DirName, "bucket", "bv", "upload.txt"))
+		out, err := os.ReadFile(filepath.Join(res.WorkDir, "download.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "source", string(out))
+
+		assert.NoFileExists(t, filepath.Join(sourceDir, "modified.txt"), "task should not modify the source directory")
+		assert.FileExists(t, filepath.Join(res.WorkDir, "src", "modified.txt"))
+	})
+	t.Run("FailsForTaskNotOnVariant", func(t *testing.T) {
+		_, err := Run(ctx, Options{
+			Project:     p,
+			TaskName:    "nonexistent",
+			VariantName: "bv",
+			Dir:         t.TempDir(),
+			Sender:      send.MakeInternalLogger(),
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestRunTaskGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := loadProject(t, `
+pre:
+  - command: shell.exec
+    params:
+      script: echo pre > pre.txt
+tasks:
+  - name: t1
+    commands:
+      - command: shell.exec
+        params:
+          script: echo t1 >> order.txt
+task_groups:
+  - name: tg
+    setup_group:
+      - command: shell.exec
+        params:
+          script: echo setup_group >> order.txt
+    setup_task:
+      - command: shell.exec
+        params:
+          script: echo setup_task >> order.txt
+    teardown_task:
+      - command: shell.exec
+        params:
+          script: echo teardown_task >> order.txt
+    teardown_group:
+      - command: shell.exec
+        params:
+          script: echo teardown_group >> order.txt
+    tasks:
+      - t1
+buildvariants:
+  - name: bv
+    display_name: bv
+    tasks:
+      - name: tg
+`)
+
+	res, err := Run(ctx, Options{
+		Project:     p,
+		TaskName:    "t1",
+		VariantName: "bv",
+		Dir:         t.TempDir(),
+		Sender:      send.MakeInternalLogger(),
+	})
+	require.NoError(t, err)
+
+	out, err := os.ReadFile(filepath.Join(res.WorkDir, "order.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"setup_group", "setup_task", "t1", "teardown_task", "teardown_group"}, strings.Fields(string(out)))
+	assert.NoFileExists(t, filepath.Join(res.WorkDir, "pre.txt"))
+}
+
+func TestLocalCheckoutDir(t *testing.T) {
+	workDir := t.TempDir()
+
+	dir, err := localCheckoutDir(workDir, "src")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(workDir, "src"), dir)
+
+	for _, bad := range []string{".", "..", "../src", "/etc", filepath.Join(workDir, "..", "other")} {
+		_, err = localCheckoutDir(workDir, bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRemoveLocalCheckout(t *testing.T) {
+	workDir := t.TempDir()
+
+	t.Run("RemovesSymlink", func(t *testing.T) {
+		target := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(target, "keep.txt"), []byte("keep"), 0644))
+		link := filepath.Join(workDir, "link")
+		require.NoError(t, os.Symlink(target, link))
+
+		require.NoError(t, removeLocalCheckout(link))
+		_, err := os.Lstat(link)
+		assert.True(t, os.IsNotExist(err))
+		assert.FileExists(t, filepath.Join(target, "keep.txt"))
+	})
+	t.Run("RemovesEmptyDirectory", func(t *testing.T) {
+		dir := filepath.Join(workDir, "empty")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		require.NoError(t, removeLocalCheckout(dir))
+		assert.NoDirExists(t, dir)
+	})
+	t.Run("RemovesPreviousCopy", func(t *testing.T) {
+		dir := filepath.Join(workDir, "full")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("data"), 0644))
+		require.NoError(t, removeLocalCheckout(dir))
+		assert.NoDirExists(t, dir)
+	})
+	t.Run("FailsForFile", func(t *testing.T) {
+		file := filepath.Join(workDir, "file.txt")
+		require.NoError(t, os.WriteFile(file, []byte("data"), 0644))
+		assert.Error(t, removeLocalCheckout(file))
+		assert.FileExists(t, file)
+	})
+	t.Run("IgnoresMissingDirectory", func(t *testing.T) {
+		assert.NoError(t, removeLocalCheckout(filepath.Join(workDir, "missing")))
+	})
+}
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "run.sh"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, os.Symlink("sub/run.sh", filepath.Join(src, "link")))
+	scratch := filepath.Join(src, "scratch")
+	dst := filepath.Join(scratch, "task", "src")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0755))
+
+	require.NoError(t, copyDir(src, dst, scratch))
+
+	info, err := os.Stat(filepath.Join(dst, "sub", "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	link, err := os.Readlink(filepath.Join(dst, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "sub/run.sh", link)
+	assert.NoDirExists(t, filepath.Join(dst, "scratch"), "scratch directory should not be copied into itself")
+}
+
+func TestLocalBucketPath(t *testing.T) {
+	s3Dir := t.TempDir()
+
+	path, err := localBucketPath(s3Dir, "bucket", "dir/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(s3Dir, "bucket", "dir", "file.txt"), path)
+
+	for _, bad := range []struct{ bucket, remote string }{
+		{bucket: "bucket", remote: "../../outside.txt"},
+		{bucket: "bucket", remote: "../other/file.txt"},
+		{bucket: "..", remote: "outside.txt"},
+		{bucket: "../..", remote: "tmp/outside.txt"},
+		{bucket: "bucket", remote: "."},
+	} {
+		_, err = localBucketPath(s3Dir, bad.bucket, bad.remote)
+		assert.Error(t, err, "bucket '%s' remote file '%s'", bad.bucket, bad.remote)
+	}
+}
@@ -0,0 +1,333 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/evergreen-ci/evergreen/agent/command"
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/model/annotations"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+// localAPIPrefix is the path prefix of the routes that a running task's
+// processes can call.
+const localAPIPrefix = "/task"
+
+// localTaskAPI is the state of the agent's local API, which lets the processes
+// of the running task report to the agent while they run rather than relying
+// on a command after they finish. Requests must include the token that's
+// generated for the task and passed to its processes, so that only the running
+// task can make them.
+type localTaskAPI struct {
+	mu    sync.Mutex
+	comm  client.Communicator
+	tc    *taskContext
+	token string
+	// taskCtx is canceled when the task is cleared so that requests that are
+	// still running for the task stop.
+	taskCtx    context.Context
+	taskCancel context.CancelFunc
+	// pendingExpansions are expansion updates that are applied before the
+	// next command runs, since the current command may be reading the
+	// expansions.
+	pendingExpansions map[string]string
+}
+
+// setTask makes the local API available to the task and returns the token that
+// authenticates its processes. The token is redacted from the task's logs,
+// since it's exported to the task's processes.
+func (l *localTaskAPI) setTask(comm client.Communicator, tc *taskContext) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.taskCancel != nil {
+		l.taskCancel()
+	}
+	l.comm = comm
+	l.tc = tc
+	l.token = utility.RandomString()
+	if tc.taskConfig != nil {
+		tc.taskConfig.Redactor.Add(internal.LocalAPITokenEnv, l.token)
+	}
+	l.taskCtx, l.taskCancel = context.WithCancel(context.Background())
+	l.pendingExpansions = nil
+
+	return l.token
+}
+
+// clearTask makes the local API unavailable once the task is finished.
+func (l *localTaskAPI) clearTask() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.taskCancel != nil {
+		l.taskCancel()
+	}
+	l.tc = nil
+	l.token = ""
+	l.taskCtx, l.taskCancel = nil, nil
+	l.pendingExpansions = nil
+}
+
+// applyExpansions applies the expansion updates that the task requested since
+// the previous command.
+func (l *localTaskAPI) applyExpansions(tc *taskContext) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.tc != tc || len(l.pendingExpansions) == 0 {
+		return
+	}
+	for k, v := range l.pendingExpansions {
+		tc.taskConfig.Expansions.Put(k, v)
+	}
+	tc.logger.Execution().Infof("Updated %d expansion(s) set through the agent's local API.", len(l.pendingExpansions))
+	l.pendingExpansions = nil
+}
+
+// localAPIHandler handles an authenticated request from the running task.
+type localAPIHandler func(ctx context.Context, comm client.Communicator, tc *taskContext, r *http.Request) (interface{}, error)
+
+// handler wraps the local API handler so that it only runs for requests from
+// the running task. The handler doesn't hold the lock while it runs, so slow
+// requests don't hold up the task or other requests. Requests that are still
+// running when the task is cleared are canceled.
+func (l *localTaskAPI) handler(h localAPIHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l.mu.Lock()
+		comm, tc, expectedToken, taskCtx := l.comm, l.tc, l.token, l.taskCtx
+		l.mu.Unlock()
+
+		if tc == nil {
+			gimlet.WriteJSONResponse(w, http.StatusConflict, gimlet.ErrorResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "no task is running",
+			})
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expectedToken)) != 1 {
+			gimlet.WriteJSONResponse(w, http.StatusUnauthorized, gimlet.ErrorResponse{
+				StatusCode: http.StatusUnauthorized,
+				Message:    "invalid local API token",
+			})
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-taskCtx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		resp, err := h(ctx, comm, tc, r)
+		if err != nil {
+			errResp, ok := errors.Cause(err).(gimlet.ErrorResponse)
+			if !ok {
+				errResp = gimlet.ErrorResponse{
+					StatusCode: http.StatusInternalServerError,
+					Message:    err.Error(),
+				}
+			}
+			grip.Debug(message.WrapError(err, message.Fields{
+				"message": "local API request failed",
+				"path":    r.URL.Path,
+				"task":    tc.task.ID,
+			}))
+			gimlet.WriteJSONResponse(w, errResp.StatusCode, errResp)
+			return
+		}
+		if resp == nil {
+			resp = struct{}{}
+		}
+		gimlet.WriteJSON(w, resp)
+	}
+}
+
+// addRoutes registers the local API routes.
+func (l *localTaskAPI) addRoutes(app *gimlet.APIApp) {
+	app.AddRoute(localAPIPrefix + "/expansions").Handler(l.handler(l.setExpansions)).Post()
+	app.AddRoute(localAPIPrefix + "/artifacts").Handler(l.handler(l.attachArtifacts)).Post()
+	app.AddRoute(localAPIPrefix + "/test_results").Handler(l.handler(l.attachTestResults)).Post()
+	app.AddRoute(localAPIPrefix + "/timeout").Handler(l.handler(l.updateTimeout)).Post()
+	app.AddRoute(localAPIPrefix + "/log").Handler(l.handler(l.log)).Post()
+	app.AddRoute(localAPIPrefix + "/annotation/metadata_links").Handler(l.handler(l.setMetadataLinks)).Post()
+}
+
+func badLocalAPIRequest(err error) error {
+	return gimlet.ErrorResponse{
+		StatusCode: http.StatusBadRequest,
+		Message:    err.Error(),
+	}
+}
+
+// setExpansions sets expansions for the commands that run after the current
+// one.
+func (l *localTaskAPI) setExpansions(ctx context.Context, comm client.Communicator, tc *taskContext, r *http.Request) (interface{}, error) {
+	updates := map[string]string{}
+	if err := utility.ReadJSON(r.Body, &updates); err != nil {
+		return nil, badLocalAPIRequest(errors.Wrap(err, "reading expansions"))
+	}
+	if _, ok := updates[""]; ok {
+		return nil, badLocalAPIRequest(errors.New("expansion key must not be a blank string"))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tc != tc {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "task is no longer running",
+		}
+	}
+	if l.pendingExpansions == nil {
+		l.pendingExpansions = map[string]string{}
+	}
+	for k, v := range updates {
+		l.pendingExpansions[k] = v
+	}
+
+	return nil, nil
+}
+
+// attachArtifacts attaches the artifacts to the task.
+func (l *localTaskAPI) attachArtifacts(ctx context.Context, comm client.Communicator, tc *taskContext, r *http.Request) (interface{}, error) {
+	files := []*artifact.File{}
+	if err := utility.ReadJSON(r.Body, &files); err != nil {
+		return nil, badLocalAPIRequest(errors.Wrap(err, "reading artifacts"))
+	}
+	if len(files) == 0 {
+		return nil, badLocalAPIRequest(errors.New("must specify at least one artifact"))
+	}
+	for i, f := range files {
+		if f == nil {
+			return nil, badLocalAPIRequest(errors.Errorf("artifact at index %d must not be null", i))
+		}
+		if err := f.Validate(); err != nil {
+			return nil, badLocalAPIRequest(errors.Wrapf(err, "artifact at index %d", i))
+		}
+	}
+	if err := command.SetArtifactDigests(tc.taskConfig.WorkDir, files, tc.logger.Task()); err != nil {
+		return nil, badLocalAPIRequest(errors.Wrap(err, "computing artifact digests"))
+	}
+
+	if err := comm.AttachFiles(ctx, tc.task, files); err != nil {
+		return nil, errors.Wrap(err, "attaching artifacts")
+	}
+	tc.logger.Task().Infof("Attached %d artifact(s) through the agent's local API.", len(files))
+
+	return nil, nil
+}
+
+// attachTestResults attaches test results in the attach.results format to the
+// task.
+func (l *localTaskAPI) attachTestResults(ctx context.Context, comm client.Communicator, tc *taskContext, r *http.Request) (interface{}, error) {
+	if err := command.AttachTestResults(ctx, comm, tc.logger, tc.taskConfig, r.Body); err != nil {
+		return nil, errors.Wrap(err, "attaching test results")
+	}
+
+	return nil, nil
+}
+
+type localAPITimeout struct {
+	TimeoutSecs     int `json:"timeout_secs"`
+	ExecTimeoutSecs int `json:"exec_timeout_secs"`
+}
+
+// updateTimeout sets the task's idle or exec timeout in the same way as the
+// timeout.update command.
+func (l *localTaskAPI) updateTimeout(ctx context.Context, comm client.Communicator, tc *taskContext, r *http.Request) (interface{}, error) {
+	timeout := localAPITimeout{}
+	if err := utility.ReadJSON(r.Body, &timeout); err != nil {
+		return nil, badLocalAPIRequest(errors.Wrap(err, "reading timeout"))
+	}
+	if timeout.TimeoutSecs < 0 || timeout.ExecTimeoutSecs < 0 {
+		return nil, badLocalAPIRequest(errors.New("timeouts cannot be negative"))
+	}
+	if timeout.TimeoutSecs == 0 && timeout.ExecTimeoutSecs == 0 {
+		return nil, badLocalAPIRequest(errors.New("must specify an idle or exec timeout"))
+	}
+
+	if timeout.TimeoutSecs != 0 {
+		tc.taskConfig.SetIdleTimeout(timeout.TimeoutSecs)
+		tc.logger.Execution().Infof("Set idle timeout to %d seconds through the agent's local API.", timeout.TimeoutSecs)
+	}
+	if timeout.ExecTimeoutSecs != 0 {
+		tc.taskConfig.SetExecTimeout(timeout.ExecTimeoutSecs)
+		tc.logger.Execution().Infof("Set exec timeout to %d seconds through the agent's local API.", timeout.ExecTimeoutSecs)
+	}
+
+	return nil, nil
+}
+
+type localAPILogLine struct {
+	Severity string                 `json:"severity"`
+	Message  string                 `json:"message"`
+	Fields   map[string]interface{} `json:"fields"`
+}
+
+// log writes a structured line to the task log.
+func (l *localTaskAPI) log(ctx context.Context, comm client.Communicator, tc *taskContext, r *http.Request) (interface{}, error) {
+	line := localAPILogLine{}
+	if err := utility.ReadJSON(r.Body, &line); err != nil {
+		return nil, badLocalAPIRequest(errors.Wrap(err, "reading log line"))
+	}
+	if line.Message == "" && len(line.Fields) == 0 {
+		return nil, badLocalAPIRequest(errors.New("must specify a message or fields"))
+	}
+	priority := level.Info
+	if line.Severity != "" {
+		priority = level.FromString(line.Severity)
+		if !priority.IsValid() {
+			return nil, badLocalAPIRequest(errors.Errorf("invalid severity '%s'", line.Severity))
+		}
+	}
+
+	if len(line.Fields) == 0 {
+		tc.logger.Task().Log(priority, line.Message)
+		return nil, nil
+	}
+	fields := message.Fields{}
+	for k, v := range line.Fields {
+		fields[k] = v
+	}
+	if line.Message != "" {
+		fields["message"] = line.Message
+	}
+	tc.logger.Task().Log(priority, fields)
+
+	return nil, nil
+}
+
+// setMetadataLinks sets the metadata links on the task's annotation.
+func (l *localTaskAPI) setMetadataLinks(ctx context.Context, comm client.Communicator, tc *taskContext, r *http.Request) (interface{}, error) {
+	links := []annotations.MetadataLink{}
+	if err := utility.ReadJSON(r.Body, &links); err != nil {
+		return nil, badLocalAPIRequest(errors.Wrap(err, "reading metadata links"))
+	}
+	if err := annotations.ValidateMetadataLinks(links...); err != nil {
+		return nil, badLocalAPIRequest(errors.Wrap(err, "invalid metadata links"))
+	}
+
+	if err := comm.SetAnnotationMetadataLinks(ctx, tc.task, links); err != nil {
+		return nil, errors.Wrap(err, "setting annotation metadata links")
+	}
+	tc.logger.Task().Infof("Set %d annotation metadata link(s) through the agent's local API.", len(links))
+
+	return nil, nil
+}
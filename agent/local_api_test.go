@@ -0,0 +1,219 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/agent/internal"
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalTaskAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	makeTaskContext := func(t *testing.T, comm *client.Mock) *taskContext {
+		tsk := &task.Task{
+			Id:           "task_id",
+			DisplayName:  "some_task",
+			BuildVariant: "some_build_variant",
+		}
+		project := &model.Project{
+			Tasks:         []model.ProjectTask{{Name: tsk.DisplayName}},
+			BuildVariants: []model.BuildVariant{{Name: tsk.BuildVariant}},
+		}
+		taskConfig, err := internal.NewTaskConfig(t.TempDir(), &apimodels.DistroView{}, project, tsk, &model.ProjectRef{Id: "project_id"}, &patch.Patch{}, util.Expansions{})
+		require.NoError(t, err)
+		tc := &taskContext{
+			task: client.TaskData{
+				ID:     tsk.Id,
+				Secret: "task_secret",
+			},
+			taskConfig: taskConfig,
+		}
+		tc.logger, err = comm.GetLoggerProducer(ctx, tc.task, nil)
+		require.NoError(t, err)
+		return tc
+	}
+	request := func(t *testing.T, h http.HandlerFunc, token, body string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest(http.MethodPost, localAPIPrefix, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rw := httptest.NewRecorder()
+		h(rw, r)
+		return rw
+	}
+
+	for tName, tCase := range map[string]func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string){
+		"RejectsRequestsWithoutToken": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.setExpansions), "", `{"foo": "bar"}`)
+			assert.Equal(t, http.StatusUnauthorized, rw.Code)
+			rw = request(t, l.handler(l.setExpansions), "wrong", `{"foo": "bar"}`)
+			assert.Equal(t, http.StatusUnauthorized, rw.Code)
+			assert.Empty(t, l.pendingExpansions)
+		},
+		"GeneratesNewTokenForEachTask": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			nextToken := l.setTask(comm, makeTaskContext(t, comm))
+			assert.NotEqual(t, token, nextToken)
+			rw := request(t, l.handler(l.setExpansions), token, `{"foo": "bar"}`)
+			assert.Equal(t, http.StatusUnauthorized, rw.Code, "previous task's token should not be valid")
+		},
+		"RejectsRequestsAfterTaskIsCleared": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			l.clearTask()
+			rw := request(t, l.handler(l.setExpansions), token, `{"foo": "bar"}`)
+			assert.Equal(t, http.StatusConflict, rw.Code)
+		},
+		"SetsExpansionsBeforeNextCommand": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.setExpansions), token, `{"foo": "bar", "bat": "baz"}`)
+			require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+			assert.False(t, tc.taskConfig.Expansions.Exists("foo"), "expansions should not change while a command is running")
+
+			l.applyExpansions(tc)
+			assert.Equal(t, "bar", tc.taskConfig.Expansions.Get("foo"))
+			assert.Equal(t, "baz", tc.taskConfig.Expansions.Get("bat"))
+			assert.Empty(t, l.pendingExpansions)
+		},
+		"RejectsBlankExpansionKey": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.setExpansions), token, `{"": "bar"}`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+		},
+		"AttachesArtifacts": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.attachArtifacts), token, `[{"name": "report", "link": "https://example.com/report.html", "visibility": "public"}]`)
+			require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+			files := comm.AttachedFiles[tc.task.ID]
+			require.Len(t, files, 1)
+			assert.Equal(t, "report", files[0].Name)
+			assert.Equal(t, "https://example.com/report.html", files[0].Link)
+		},
+		"ComputesArtifactDigestsFromLocalPaths": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			require.NoError(t, os.WriteFile(filepath.Join(tc.taskConfig.WorkDir, "binary"), []byte("foo"), 0644))
+			rw := request(t, l.handler(l.attachArtifacts), token, `[
+				{"name": "binary", "link": "https://example.com/binary", "local_path": "binary"},
+				{"name": "other", "link": "https://example.com/other", "sha256": "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"}
+			]`)
+			require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+			files := comm.AttachedFiles[tc.task.ID]
+			require.Len(t, files, 2)
+			// The SHA-256 digest of "foo".
+			assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", files[0].SHA256)
+			assert.Empty(t, files[0].LocalPath)
+			assert.Empty(t, files[1].SHA256, "digest without a local path should not be recorded")
+		},
+		"RejectsArtifactsWithoutLink": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.attachArtifacts), token, `[{"name": "report"}]`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.Empty(t, comm.AttachedFiles[tc.task.ID])
+		},
+		"RejectsInvalidArtifacts": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.attachArtifacts), token, `[{"name": "report", "link": "https://example.com/report.html", "sha256": "not-a-digest"}]`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			rw = request(t, l.handler(l.attachArtifacts), token, `[{"name": "report", "link": "https://bucket.s3.amazonaws.com/report.html", "bucket": "bucket", "filekey": "other.html"}]`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			rw = request(t, l.handler(l.attachArtifacts), token, `[{"name": "report", "link": "https://example.com/report.html", "bucket": "bucket"}]`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.Empty(t, comm.AttachedFiles[tc.task.ID])
+		},
+		"ClearsTaskWhileRequestIsRunning": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			started := make(chan struct{})
+			blocking := func(ctx context.Context, _ client.Communicator, _ *taskContext, _ *http.Request) (interface{}, error) {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			done := make(chan *httptest.ResponseRecorder)
+			go func() {
+				done <- request(t, l.handler(blocking), token, `{}`)
+			}()
+			<-started
+
+			cleared := make(chan struct{})
+			go func() {
+				l.clearTask()
+				close(cleared)
+			}()
+			select {
+			case <-cleared:
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "clearing the task should not wait for running requests")
+			}
+			select {
+			case rw := <-done:
+				assert.Equal(t, http.StatusInternalServerError, rw.Code)
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "running request should be canceled when the task is cleared")
+			}
+		},
+		"DoesNotSetExpansionsForClearedTask": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			l.clearTask()
+			_, err := l.setExpansions(ctx, comm, tc, httptest.NewRequest(http.MethodPost, localAPIPrefix, bytes.NewBufferString(`{"foo": "bar"}`)))
+			assert.Error(t, err)
+			assert.Empty(t, l.pendingExpansions)
+		},
+		"UpdatesTimeouts": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.updateTimeout), token, `{"timeout_secs": 100, "exec_timeout_secs": 200}`)
+			require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+			assert.Equal(t, 100, tc.taskConfig.GetIdleTimeout())
+			assert.Equal(t, 200, tc.taskConfig.GetExecTimeout())
+		},
+		"RejectsMissingTimeout": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.updateTimeout), token, `{}`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			rw = request(t, l.handler(l.updateTimeout), token, `{"timeout_secs": -1}`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+		},
+		"LogsStructuredLines": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.log), token, `{"severity": "warning", "message": "halfway done", "fields": {"progress": 50}}`)
+			require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+			rw = request(t, l.handler(l.log), token, `{"message": "plain line"}`)
+			require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+
+			require.NoError(t, tc.logger.Close())
+			checkMockLogs(t, comm, tc.task.ID, []string{"halfway done", "progress='50'", "plain line"}, nil)
+		},
+		"RejectsInvalidLogSeverity": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.log), token, `{"severity": "loud", "message": "line"}`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+		},
+		"SetsAnnotationMetadataLinks": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.setMetadataLinks), token, `[{"url": "https://example.com/progress", "text": "progress"}]`)
+			require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+			links := comm.MetadataLinks[tc.task.ID]
+			require.Len(t, links, 1)
+			assert.Equal(t, "https://example.com/progress", links[0].URL)
+			assert.Equal(t, "progress", links[0].Text)
+		},
+		"RedactsToken": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			assert.Equal(t, "token=<REDACTED:"+internal.LocalAPITokenEnv+">", tc.taskConfig.Redactor.Redact("token="+token))
+		},
+		"RejectsInvalidAnnotationMetadataLinks": func(t *testing.T, l *localTaskAPI, tc *taskContext, comm *client.Mock, token string) {
+			rw := request(t, l.handler(l.setMetadataLinks), token, `[{"url": "https://example.com/progress", "text": ""}]`)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.Empty(t, comm.MetadataLinks[tc.task.ID])
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			comm := client.NewMock("url")
+			tc := makeTaskContext(t, comm)
+			l := &localTaskAPI{}
+			token := l.setTask(comm, tc)
+			require.NotZero(t, token)
+
+			tCase(t, l, tc, comm, token)
+		})
+	}
+}
@@ -114,6 +114,9 @@ func (a *Agent) prepLogger(tc *taskContext, c *model.LoggerConfig, commandName s
 	config := client.LoggerConfig{
 		SendToGlobalSender: a.opts.SendTaskLogsToGlobalSender,
 	}
+	if tc.taskConfig != nil {
+		config.Redactor = tc.taskConfig.Redactor
+	}
 
 	var defaultLogger string
 	if tc.taskConfig != nil && tc.taskConfig.ProjectRef != nil {
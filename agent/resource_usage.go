@@ -0,0 +1,262 @@
+package agent
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
+	"github.com/mongodb/grip/message"
+	"github.com/mongodb/grip/recovery"
+	"github.com/pkg/errors"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// maxCommandLength is the longest process name kept in a sample.
+const maxCommandLength = 64
+
+// resourceUsageCollector samples the resource usage of a task's processes at
+// a regular interval and sends it to the API server as a time series. A
+// task's processes are the ones with the task ID marker in their environment,
+// which are the same processes that are cleaned up when the task ends.
+type resourceUsageCollector struct {
+	comm      client.Communicator
+	logger    client.LoggerProducer
+	taskData  client.TaskData
+	execution int
+	// redactor removes the task's secrets from process names, which are
+	// stored outside of the task's logs.
+	redactor *redactor.Redactor
+	// interval is how often the usage is sampled.
+	interval time.Duration
+	// flushSize is the number of samples that are sent to the API server at
+	// once.
+	flushSize int
+
+	pending  []resourceusage.Sample
+	prevTime time.Time
+	prevNet  *net.IOCountersStat
+	prev     map[int32]processCounters
+	known    map[int32]knownProcess
+}
+
+// processCounters are the cumulative counters of a process that samples
+// report the change in.
+type processCounters struct {
+	cpuSecs    float64
+	readBytes  uint64
+	writeBytes uint64
+}
+
+// knownProcess caches whether a process belongs to the task, since reading
+// every process's environment at each sample is expensive. The creation time
+// distinguishes processes that reuse a PID.
+type knownProcess struct {
+	createTime int64
+	isTask     bool
+}
+
+func newResourceUsageCollector(comm client.Communicator, logger client.LoggerProducer, td client.TaskData, execution int, r *redactor.Redactor) *resourceUsageCollector {
+	return &resourceUsageCollector{
+		comm:      comm,
+		logger:    logger,
+		taskData:  td,
+		execution: execution,
+		redactor:  r,
+		interval:  defaultResourceUsageInterval,
+		flushSize: defaultResourceUsageFlushSize,
+		prev:      map[int32]processCounters{},
+		known:     map[int32]knownProcess{},
+	}
+}
+
+// start samples the resource usage until the context is done, then sends
+// the remaining samples.
+func (c *resourceUsageCollector) start(ctx context.Context) {
+	go func() {
+		defer recovery.LogStackTraceAndContinue("encountered issue in resource usage collector")
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		c.collect(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				flushCtx, cancel := context.WithTimeout(context.Background(), resourceUsageFlushTimeout)
+				defer cancel()
+				c.flush(flushCtx)
+				return
+			case <-ticker.C:
+				c.collect(ctx)
+				if len(c.pending) >= c.flushSize {
+					c.flush(ctx)
+				}
+			}
+		}
+	}()
+}
+
+func (c *resourceUsageCollector) collect(ctx context.Context) {
+	s, err := c.sample(ctx)
+	if err != nil {
+		c.logger.System().Debug(errors.Wrap(err, "sampling task resource usage"))
+		return
+	}
+	c.pending = append(c.pending, *s)
+}
+
+// flush sends the pending samples to the API server. If they can't be sent,
+// they're kept to try again with the next samples.
+func (c *resourceUsageCollector) flush(ctx context.Context) {
+	if len(c.pending) == 0 {
+		return
+	}
+	if err := c.comm.SendResourceUsage(ctx, c.taskData, c.execution, c.pending); err != nil {
+		c.logger.System().Warning(message.WrapError(err, message.Fields{
+			"message": "sending task resource usage",
+			"samples": len(c.pending),
+		}))
+		if len(c.pending) > resourceusage.MaxSamples {
+			c.pending = c.pending[len(c.pending)-resourceusage.MaxSamples:]
+		}
+		return
+	}
+	c.pending = nil
+}
+
+// sample returns the current resource usage of the task's processes. CPU,
+// disk and network usage are the change since the previous sample, so they're
+// zero in the first one.
+func (c *resourceUsageCollector) sample(ctx context.Context) (*resourceusage.Sample, error) {
+	now := time.Now()
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing processes")
+	}
+
+	s := &resourceusage.Sample{Time: now}
+	var cpuSecs float64
+	current := map[int32]processCounters{}
+	seen := map[int32]bool{}
+	for _, p := range procs {
+		seen[p.Pid] = true
+		if !c.isTaskProcess(ctx, p) {
+			continue
+		}
+
+		usage := resourceusage.ProcessUsage{PID: p.Pid}
+		if memInfo, err := p.MemoryInfoWithContext(ctx); err == nil {
+			usage.RSSBytes = memInfo.RSS
+		}
+		// Only the process name is kept because command line arguments
+		// often contain secrets.
+		if name, err := p.NameWithContext(ctx); err == nil {
+			usage.Command = c.redactor.Redact(name)
+		}
+		if len(usage.Command) > maxCommandLength {
+			usage.Command = usage.Command[:maxCommandLength]
+		}
+
+		counters := processCounters{}
+		if times, err := p.TimesWithContext(ctx); err == nil {
+			counters.cpuSecs = times.User + times.System
+		}
+		if io, err := p.IOCountersWithContext(ctx); err == nil {
+			counters.readBytes = io.ReadBytes
+			counters.writeBytes = io.WriteBytes
+		}
+		current[p.Pid] = counters
+
+		// Processes that started since the previous sample used all of their
+		// counters since then.
+		if !c.prevTime.IsZero() {
+			prev := c.prev[p.Pid]
+			if counters.cpuSecs > prev.cpuSecs {
+				cpuSecs += counters.cpuSecs - prev.cpuSecs
+			}
+			if counters.readBytes > prev.readBytes {
+				s.DiskReadBytes += counters.readBytes - prev.readBytes
+			}
+			if counters.writeBytes > prev.writeBytes {
+				s.DiskWriteBytes += counters.writeBytes - prev.writeBytes
+			}
+		}
+
+		s.NumProcesses++
+		s.MemoryRSSBytes += usage.RSSBytes
+		s.Processes = append(s.Processes, usage)
+	}
+	for pid := range c.known {
+		if !seen[pid] {
+			delete(c.known, pid)
+		}
+	}
+
+	sort.SliceStable(s.Processes, func(i, j int) bool {
+		return s.Processes[i].RSSBytes > s.Processes[j].RSSBytes
+	})
+	if len(s.Processes) > resourceusage.MaxProcesses {
+		s.Processes = s.Processes[:resourceusage.MaxProcesses]
+	}
+
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		s.MemoryAvailableBytes = vm.Available
+	}
+
+	var netCounters *net.IOCountersStat
+	if counters, err := net.IOCountersWithContext(ctx, false); err == nil && len(counters) == 1 {
+		netCounters = &counters[0]
+	}
+	if netCounters != nil && c.prevNet != nil {
+		if netCounters.BytesRecv > c.prevNet.BytesRecv {
+			s.NetworkRecvBytes = netCounters.BytesRecv - c.prevNet.BytesRecv
+		}
+		if netCounters.BytesSent > c.prevNet.BytesSent {
+			s.NetworkSentBytes = netCounters.BytesSent - c.prevNet.BytesSent
+		}
+	}
+
+	if !c.prevTime.IsZero() {
+		if elapsed := now.Sub(c.prevTime).Seconds(); elapsed > 0 {
+			s.CPUPercent = 100 * cpuSecs / elapsed
+		}
+	}
+
+	c.prevTime = now
+	c.prevNet = netCounters
+	c.prev = current
+
+	return s, nil
+}
+
+// isTaskProcess returns whether the process was started by the task.
+func (c *resourceUsageCollector) isTaskProcess(ctx context.Context, p *process.Process) bool {
+	createTime, err := p.CreateTimeWithContext(ctx)
+	if err != nil {
+		return false
+	}
+	if known, ok := c.known[p.Pid]; ok && known.createTime == createTime {
+		return known.isTask
+	}
+
+	env, err := p.EnvironWithContext(ctx)
+	isTask := err == nil && envHasTaskID(env, c.taskData.ID)
+	c.known[p.Pid] = knownProcess{createTime: createTime, isTask: isTask}
+	return isTask
+}
+
+func envHasTaskID(env []string, taskID string) bool {
+	marker := agentutil.MarkerTaskID + "=" + taskID
+	for _, envVar := range env {
+		if envVar == marker {
+			return true
+		}
+	}
+	return false
+}
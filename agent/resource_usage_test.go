@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/agent/internal/client"
+	"github.com/evergreen-ci/evergreen/agent/internal/redactor"
+	agentutil "github.com/evergreen-ci/evergreen/agent/util"
+	"github.com/mongodb/grip/send"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvHasTaskID(t *testing.T) {
+	assert.True(t, envHasTaskID([]string{"PATH=/bin", agentutil.MarkerTaskID + "=task"}, "task"))
+	assert.False(t, envHasTaskID([]string{agentutil.MarkerTaskID + "=task_2"}, "task"))
+	assert.False(t, envHasTaskID([]string{"PATH=/bin"}, "task"))
+	assert.False(t, envHasTaskID(nil, "task"))
+}
+
+func TestResourceUsageCollector(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process environments can only be read on Linux")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	comm := client.NewMock("url")
+	logger := client.NewSingleChannelLogHarness("test", send.MakeInternalLogger())
+	td := client.TaskData{ID: "resource_usage_task_" + time.Now().Format("150405.000000"), Secret: "secret"}
+
+	cmd := exec.CommandContext(ctx, "sleep", "30")
+	cmd.Env = append(os.Environ(), agentutil.MarkerTaskID+"="+td.ID)
+	require.NoError(t, cmd.Start())
+	defer func() {
+		assert.NoError(t, cmd.Process.Kill())
+		_ = cmd.Wait()
+	}()
+	other := exec.CommandContext(ctx, "sleep", "30")
+	other.Env = append(os.Environ(), agentutil.MarkerTaskID+"="+td.ID+"_other")
+	require.NoError(t, other.Start())
+	defer func() {
+		assert.NoError(t, other.Process.Kill())
+		_ = other.Wait()
+	}()
+
+	t.Run("SampleIncludesOnlyTaskProcesses", func(t *testing.T) {
+		c := newResourceUsageCollector(comm, logger, td, 0, nil)
+		first, err := c.sample(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, first.CPUPercent)
+		assert.Zero(t, first.NetworkRecvBytes)
+
+		s, err := c.sample(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, s.NumProcesses)
+		require.Len(t, s.Processes, 1)
+		assert.EqualValues(t, cmd.Process.Pid, s.Processes[0].PID)
+		assert.True(t, strings.HasPrefix(s.Processes[0].Command, "sleep"), s.Processes[0].Command)
+		assert.NotZero(t, s.MemoryRSSBytes)
+		assert.Equal(t, s.Processes[0].RSSBytes, s.MemoryRSSBytes)
+		assert.NotZero(t, s.MemoryAvailableBytes)
+		assert.True(t, s.Time.After(first.Time))
+	})
+	t.Run("SampleRedactsProcessNames", func(t *testing.T) {
+		r := redactor.New()
+		r.Add("secret", "sleep")
+		c := newResourceUsageCollector(comm, logger, td, 0, r)
+		s, err := c.sample(ctx)
+		require.NoError(t, err)
+		require.Len(t, s.Processes, 1)
+		assert.NotContains(t, s.Processes[0].Command, "sleep")
+		assert.Contains(t, s.Processes[0].Command, "REDACTED")
+	})
+	t.Run("SendsRemainingSamplesWhenDone", func(t *testing.T) {
+		collectorCtx, collectorCancel := context.WithCancel(ctx)
+		c := newResourceUsageCollector(comm, logger, td, 0, nil)
+		c.interval = 10 * time.Millisecond
+		c.flushSize = 1000
+		c.start(collectorCtx)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Empty(t, comm.GetResourceUsage(td.ID))
+		collectorCancel()
+
+		assert.Eventually(t, func() bool {
+			return len(comm.GetResourceUsage(td.ID)) > 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	shardTestsFileName      = "shard_tests.txt"
+	shardOtherTestsFileName = "shard_other_tests.txt"
+)
+
+// setupShardTests writes the tests assigned to the task's shard, and the tests
+// assigned to all other shards, to files in the task directory, and sets
+// expansions to their paths. It does nothing if the task's tests are not split
+// into shards or if there was no test history to split between shards.
+func (a *Agent) setupShardTests(ctx context.Context, tc *taskContext) error {
+	if tc.taskConfig.Project == nil {
+		return nil
+	}
+	pt := tc.taskConfig.Project.FindProjectTask(tc.taskConfig.Task.DisplayName)
+	if pt == nil || pt.Shard == nil {
+		return nil
+	}
+
+	shardTests, err := a.comm.GetShardTests(ctx, tc.task)
+	if err != nil {
+		return errors.Wrap(err, "getting shard tests")
+	}
+	if !shardTests.HasHistory {
+		tc.logger.Execution().Infof("No test history is available to split between the shards of '%s'.", pt.Shard.Group)
+		return nil
+	}
+
+	files := []struct {
+		name      string
+		expansion string
+		tests     []string
+	}{
+		{name: shardTestsFileName, expansion: model.TaskShardTestsFileExpansion, tests: shardTests.Tests},
+		{name: shardOtherTestsFileName, expansion: model.TaskShardOtherTestsFileExpansion, tests: shardTests.OtherTests},
+	}
+	for _, f := range files {
+		path := filepath.Join(tc.taskConfig.WorkDir, f.name)
+		var content string
+		if len(f.tests) > 0 {
+			content = strings.Join(f.tests, "\n") + "\n"
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return errors.Wrapf(err, "writing shard tests file '%s'", path)
+		}
+		tc.taskConfig.Expansions.Put(f.expansion, path)
+	}
+	if len(shardTests.Tests) == 0 {
+		tc.logger.Execution().Infof("No tests are assigned to shard %d of %d of '%s' because fewer shards are needed to meet its target duration.", pt.Shard.Index, pt.Shard.Count, pt.Shard.Group)
+	} else {
+		tc.logger.Execution().Infof("Assigned %d test(s) to shard %d of %d of '%s'.", len(shardTests.Tests), pt.Shard.Index, pt.Shard.Count, pt.Shard.Group)
+	}
+
+	return nil
+}
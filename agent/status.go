@@ -35,6 +35,7 @@ func (a *Agent) startStatusServer(ctx context.Context, port int) error {
 	app.AddMiddleware(gimlet.MakeRecoveryLogger())
 	app.AddRoute("/status").Handler(a.statusHandler()).Get()
 	app.AddRoute("/task_status").Handler(a.endTaskHandler).Post()
+	a.localAPI.addRoutes(app)
 	app.AddRoute("/oom/clear").Handler(http.RedirectHandler("/jasper/v1/list/oom", http.StatusMovedPermanently).ServeHTTP).Delete()
 	app.AddRoute("/oom/check").Handler(http.RedirectHandler("/jasper/v1/list/oom", http.StatusMovedPermanently).ServeHTTP).Get()
 
@@ -76,6 +76,27 @@ func (tc *taskContext) hadTimedOut() bool {
 	return tc.timedOut()
 }
 
+// recordCommandFailure records whether the disk ran out of space while a
+// command that failed the task was running. Only the first failure is
+// recorded, since that's the one that decides the task's status.
+func (tc *taskContext) recordCommandFailure(diskFull bool) {
+	tc.Lock()
+	defer tc.Unlock()
+
+	if tc.failureRecorded {
+		return
+	}
+	tc.failureRecorded = true
+	tc.failedWithDiskFull = diskFull
+}
+
+func (tc *taskContext) ranOutOfDiskSpace() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+
+	return tc.failedWithDiskFull
+}
+
 func (tc *taskContext) getOomTrackerInfo() *apimodels.OOMTrackerInfo {
 	lines, pids := tc.oomTracker.Report()
 	if len(lines) == 0 {
@@ -164,6 +185,7 @@ func (a *Agent) makeTaskConfig(ctx context.Context, tc *taskContext) (*internal.
 		return nil, err
 	}
 	taskConfig.Redacted = tc.privateVars
+	command.RegisterRedactedExpansions(taskConfig)
 	taskConfig.TaskSync = a.opts.SetupData.TaskSync
 	taskConfig.EC2Keys = a.opts.SetupData.EC2Keys
 
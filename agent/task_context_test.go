@@ -19,3 +19,16 @@ func TestGetOomTrackerInfo(t *testing.T) {
 	assert.True(t, info.Detected)
 	assert.Equal(t, []int{1, 2, 3}, info.Pids)
 }
+
+func TestRecordCommandFailure(t *testing.T) {
+	tc := taskContext{}
+	assert.False(t, tc.ranOutOfDiskSpace())
+
+	tc.recordCommandFailure(false)
+	tc.recordCommandFailure(true)
+	assert.False(t, tc.ranOutOfDiskSpace(), "only the first failure should be recorded")
+
+	tc = taskContext{}
+	tc.recordCommandFailure(true)
+	assert.True(t, tc.ranOutOfDiskSpace())
+}
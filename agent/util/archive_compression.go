@@ -0,0 +1,247 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+// TarCompression is the compression format of a tar archive.
+type TarCompression string
+
+const (
+	TarCompressionGzip TarCompression = "gzip"
+	TarCompressionZstd TarCompression = "zstd"
+	TarCompressionXz   TarCompression = "xz"
+	TarCompressionNone TarCompression = "none"
+)
+
+// pgzipBlockSize is the size of the blocks that gzip compresses in parallel.
+const pgzipBlockSize = 1 << 20
+
+// Validate checks that the compression format is supported.
+func (c TarCompression) Validate() error {
+	switch c {
+	case TarCompressionGzip, TarCompressionZstd, TarCompressionXz, TarCompressionNone:
+		return nil
+	default:
+		return errors.Errorf("unsupported compression '%s'", c)
+	}
+}
+
+var tarCompressionExtensions = []struct {
+	suffix      string
+	compression TarCompression
+}{
+	{suffix: ".tar.gz", compression: TarCompressionGzip},
+	{suffix: ".tgz", compression: TarCompressionGzip},
+	{suffix: ".tar.zst", compression: TarCompressionZstd},
+	{suffix: ".tzst", compression: TarCompressionZstd},
+	{suffix: ".tar.xz", compression: TarCompressionXz},
+	{suffix: ".txz", compression: TarCompressionXz},
+	{suffix: ".tar", compression: TarCompressionNone},
+}
+
+// TarCompressionFromFileName returns the compression format of the tar archive
+// based on its file extension. It returns false if the extension is not one of
+// a known tar archive format.
+func TarCompressionFromFileName(name string) (TarCompression, bool) {
+	name = strings.ToLower(name)
+	for _, ext := range tarCompressionExtensions {
+		if strings.HasSuffix(name, ext.suffix) {
+			return ext.compression, true
+		}
+	}
+	return "", false
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	// tarMagic is at tarMagicOffset in the header of POSIX tar archives.
+	tarMagic       = []byte("ustar")
+	tarMagicOffset = 257
+)
+
+// DetectTarCompression returns the compression format of the tar archive
+// based on the first bytes of its contents.
+func DetectTarCompression(r io.Reader) (TarCompression, error) {
+	header := make([]byte, tarMagicOffset+len(tarMagic))
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", errors.Wrap(err, "reading archive header")
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return TarCompressionGzip, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		return TarCompressionZstd, nil
+	case bytes.HasPrefix(header, xzMagic):
+		return TarCompressionXz, nil
+	case len(header) == tarMagicOffset+len(tarMagic) && bytes.Equal(header[tarMagicOffset:], tarMagic):
+		return TarCompressionNone, nil
+	default:
+		return "", errors.New("could not detect archive compression")
+	}
+}
+
+// DetectTarCompressionForFile returns the compression format of the tar
+// archive at the path based on its contents.
+func DetectTarCompressionForFile(path string) (TarCompression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "opening file '%s'", path)
+	}
+	defer f.Close()
+
+	compression, err := DetectTarCompression(f)
+	return compression, errors.Wrapf(err, "detecting compression of file '%s'", path)
+}
+
+// TarCompressionOptions configure how a tar archive is compressed.
+type TarCompressionOptions struct {
+	Compression TarCompression
+	// Level is the compression level. Gzip supports levels 1 to 9 and zstd
+	// supports levels 1 to 22. If it's 0, the format's default level is used.
+	// Xz and no compression don't support levels.
+	Level int
+	// Concurrency is how many goroutines compress the archive. It defaults
+	// to the number of CPUs. Xz compression is always single-threaded.
+	Concurrency int
+}
+
+// Validate checks that the options are valid for the compression format.
+func (o *TarCompressionOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Add(o.Compression.Validate())
+	catcher.NewWhen(o.Concurrency < 0, "concurrency cannot be negative")
+	switch o.Compression {
+	case TarCompressionGzip:
+		catcher.ErrorfWhen(o.Level < 0 || o.Level > gzip.BestCompression, "gzip compression level must be between 1 and %d", gzip.BestCompression)
+	case TarCompressionZstd:
+		catcher.NewWhen(o.Level < 0 || o.Level > 22, "zstd compression level must be between 1 and 22")
+	default:
+		catcher.ErrorfWhen(o.Level != 0, "compression '%s' does not support levels", o.Compression)
+	}
+	return catcher.Resolve()
+}
+
+func (o *TarCompressionOptions) concurrency() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return runtime.NumCPU()
+}
+
+// NewTarCompressor returns a writer that compresses the data written to it
+// into w. The writer must be closed to flush the compressed data.
+func NewTarCompressor(w io.Writer, opts TarCompressionOptions) (io.WriteCloser, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid compression options")
+	}
+
+	switch opts.Compression {
+	case TarCompressionGzip:
+		level := opts.Level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gz, err := pgzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating gzip writer")
+		}
+		if err = gz.SetConcurrency(pgzipBlockSize, opts.concurrency()); err != nil {
+			return nil, errors.Wrap(err, "setting gzip concurrency")
+		}
+		return gz, nil
+	case TarCompressionZstd:
+		zstdOpts := []zstd.EOption{zstd.WithEncoderConcurrency(opts.concurrency())}
+		if opts.Level != 0 {
+			zstdOpts = append(zstdOpts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)))
+		}
+		enc, err := zstd.NewWriter(w, zstdOpts...)
+		return enc, errors.Wrap(err, "creating zstd writer")
+	case TarCompressionXz:
+		xzWriter, err := xz.NewWriter(w)
+		return xzWriter, errors.Wrap(err, "creating xz writer")
+	default:
+		return nopWriteCloser{Writer: w}, nil
+	}
+}
+
+// NewTarDecompressor returns a reader that decompresses the data read from r.
+func NewTarDecompressor(r io.Reader, compression TarCompression) (io.ReadCloser, error) {
+	switch compression {
+	case TarCompressionGzip:
+		gz, err := pgzip.NewReaderN(r, pgzipBlockSize, runtime.NumCPU())
+		return gz, errors.Wrap(err, "creating gzip reader")
+	case TarCompressionZstd:
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(runtime.NumCPU()))
+		if err != nil {
+			return nil, errors.Wrap(err, "creating zstd reader")
+		}
+		return dec.IOReadCloser(), nil
+	case TarCompressionXz:
+		xzReader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating xz reader")
+		}
+		return io.NopCloser(xzReader), nil
+	case TarCompressionNone:
+		return io.NopCloser(r), nil
+	default:
+		return nil, errors.Errorf("unsupported compression '%s'", compression)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// TarWriter returns a file, compressing writer, and tar writer for the path.
+// The tar writer wraps the compressing writer, which wraps the file.
+func TarWriter(path string, opts TarCompressionOptions) (f, compressor io.WriteCloser, tarWriter *tar.Writer, err error) {
+	f, err = os.Create(path)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "creating file '%s'", path)
+	}
+	compressor, err = NewTarCompressor(f, opts)
+	if err != nil {
+		defer f.Close()
+		return nil, nil, nil, errors.Wrap(err, "initializing compressor")
+	}
+	tarWriter = tar.NewWriter(compressor)
+	return f, compressor, tarWriter, nil
+}
+
+// ExtractTar decompresses the tar archive in the reader and extracts it into
+// rootPath.
+func ExtractTar(ctx context.Context, reader io.Reader, rootPath string, excludes []string, compression TarCompression) error {
+	decompressor, err := NewTarDecompressor(reader, compression)
+	if err != nil {
+		return errors.Wrap(err, "initializing decompressor")
+	}
+	defer decompressor.Close()
+
+	if err = extractTarArchive(ctx, tar.NewReader(decompressor), rootPath, excludes); err != nil {
+		return errors.Wrapf(err, "extracting path '%s'", rootPath)
+	}
+
+	return nil
+}
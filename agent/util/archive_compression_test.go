@@ -0,0 +1,108 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mongodb/grip/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarCompressionRoundTrip(t *testing.T) {
+	testDir := getDirectoryOfFile()
+	logger := logging.NewGrip("test.archive")
+
+	for _, opts := range []TarCompressionOptions{
+		{Compression: TarCompressionGzip},
+		{Compression: TarCompressionGzip, Level: 1, Concurrency: 2},
+		{Compression: TarCompressionZstd},
+		{Compression: TarCompressionZstd, Level: 19, Concurrency: 1},
+		{Compression: TarCompressionXz},
+		{Compression: TarCompressionNone},
+	} {
+		t.Run(string(opts.Compression), func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "archive")
+			f, compressor, tarWriter, err := TarWriter(archivePath, opts)
+			require.NoError(t, err)
+			numFiles, err := BuildArchive(context.Background(), tarWriter, filepath.Join(testDir, "testdata", "artifacts_in"), []string{"dir1/**"}, []string{"*.pdb"}, logger)
+			require.NoError(t, err)
+			assert.Equal(t, 2, numFiles)
+			require.NoError(t, tarWriter.Close())
+			require.NoError(t, compressor.Close())
+			require.NoError(t, f.Close())
+
+			detected, err := DetectTarCompressionForFile(archivePath)
+			require.NoError(t, err)
+			assert.Equal(t, opts.Compression, detected)
+
+			archive, err := os.Open(archivePath)
+			require.NoError(t, err)
+			defer archive.Close()
+			outputDir := t.TempDir()
+			require.NoError(t, ExtractTar(context.Background(), archive, outputDir, nil, opts.Compression))
+
+			data, err := os.ReadFile(filepath.Join(outputDir, "dir1", "dir2", "testfile.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "test\n", string(data))
+			assert.NoFileExists(t, filepath.Join(outputDir, "dir1", "dir2", "test.pdb"))
+		})
+	}
+}
+
+func TestTarCompressionFromFileName(t *testing.T) {
+	for name, expected := range map[string]TarCompression{
+		"archive.tar.gz":  TarCompressionGzip,
+		"archive.TGZ":     TarCompressionGzip,
+		"archive.tar.zst": TarCompressionZstd,
+		"archive.tzst":    TarCompressionZstd,
+		"archive.tar.xz":  TarCompressionXz,
+		"archive.txz":     TarCompressionXz,
+		"archive.tar":     TarCompressionNone,
+	} {
+		compression, ok := TarCompressionFromFileName(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, expected, compression, name)
+	}
+
+	_, ok := TarCompressionFromFileName("archive.zip")
+	assert.False(t, ok)
+}
+
+func TestDetectTarCompression(t *testing.T) {
+	t.Run("FailsForUnknownContents", func(t *testing.T) {
+		_, err := DetectTarCompression(bytes.NewBufferString("not an archive"))
+		assert.Error(t, err)
+	})
+	t.Run("FailsForEmptyContents", func(t *testing.T) {
+		_, err := DetectTarCompression(&bytes.Buffer{})
+		assert.Error(t, err)
+	})
+	t.Run("DetectsGzipFixture", func(t *testing.T) {
+		compression, err := DetectTarCompressionForFile(filepath.Join(getDirectoryOfFile(), "testdata", "artifacts.tar.gz"))
+		require.NoError(t, err)
+		assert.Equal(t, TarCompressionGzip, compression)
+	})
+}
+
+func TestTarCompressionOptionsValidate(t *testing.T) {
+	for name, opts := range map[string]TarCompressionOptions{
+		"UnknownCompression":       {Compression: "bzip2"},
+		"NegativeConcurrency":      {Compression: TarCompressionZstd, Concurrency: -1},
+		"GzipLevelTooHigh":         {Compression: TarCompressionGzip, Level: 10},
+		"ZstdLevelTooHigh":         {Compression: TarCompressionZstd, Level: 23},
+		"XzWithLevel":              {Compression: TarCompressionXz, Level: 5},
+		"NoCompressionWithLevel":   {Compression: TarCompressionNone, Level: 1},
+		"NegativeCompressionLevel": {Compression: TarCompressionGzip, Level: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, opts.Validate())
+		})
+	}
+
+	valid := TarCompressionOptions{Compression: TarCompressionZstd, Level: 3, Concurrency: 4}
+	assert.NoError(t, valid.Validate())
+}
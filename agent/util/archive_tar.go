@@ -117,20 +117,9 @@ FileLoop:
 	return numFilesArchived, nil
 }
 
+// ExtractTarball extracts the gzipped tar archive in the reader into rootPath.
 func ExtractTarball(ctx context.Context, reader io.Reader, rootPath string, excludes []string) error {
-	// wrap the reader in a gzip reader and a tar reader
-	gzipReader, err := gzip.NewReader(reader)
-	if err != nil {
-		return errors.Wrap(err, "creating gzip reader")
-	}
-
-	tarReader := tar.NewReader(gzipReader)
-	err = extractTarArchive(ctx, tarReader, rootPath, excludes)
-	if err != nil {
-		return errors.Wrapf(err, "extracting path '%s'", rootPath)
-	}
-
-	return nil
+	return ExtractTar(ctx, reader, rootPath, excludes, TarCompressionGzip)
 }
 
 // Extract unpacks the tar.Reader into rootPath.
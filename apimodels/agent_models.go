@@ -66,6 +66,7 @@ type TaskEndDetail struct {
 	TimeoutType     string          `bson:"timeout_type,omitempty" json:"timeout_type,omitempty"`
 	TimeoutDuration time.Duration   `bson:"timeout_duration,omitempty" json:"timeout_duration,omitempty"`
 	OOMTracker      *OOMTrackerInfo `bson:"oom_killer,omitempty" json:"oom_killer,omitempty"`
+	DiskFull        bool            `bson:"disk_full,omitempty" json:"disk_full,omitempty"`
 	Modules         ModuleCloneInfo `bson:"modules,omitempty" json:"modules,omitempty"`
 	TraceID         string          `bson:"trace_id,omitempty" json:"trace_id,omitempty"`
 }
@@ -348,3 +349,14 @@ type ExpansionsAndVars struct {
 	// PrivateVars contain the project private variables.
 	PrivateVars map[string]bool `json:"private_vars"`
 }
+
+// ShardTests represents the tests assigned to a shard of a sharded task.
+type ShardTests struct {
+	// HasHistory indicates whether there was any test history to split
+	// between shards. If false, no tests are assigned to any shard.
+	HasHistory bool `json:"has_history"`
+	// Tests are the tests assigned to the shard.
+	Tests []string `json:"tests"`
+	// OtherTests are the tests assigned to all other shards.
+	OtherTests []string `json:"other_tests"`
+}
@@ -28,6 +28,12 @@ func TestLoadUserManager(t *testing.T) {
 		Issuer:       "issuer",
 		UserGroup:    "user_group",
 	}
+	oidc := evergreen.OIDCConfig{
+		Issuer:       "https://issuer.example.com",
+		ClientID:     "client_id",
+		ClientSecret: "client_secret",
+		EmailDomain:  "example.com",
+	}
 	multi := evergreen.MultiAuthConfig{
 		ReadWrite: []string{evergreen.AuthLDAPKey},
 		ReadOnly:  []string{evergreen.AuthNaiveKey},
@@ -65,6 +71,18 @@ func TestLoadUserManager(t *testing.T) {
 	assert.True(t, info.CanReauthorize)
 	assert.NotNil(t, um, "a UserManager should be created if one AuthConfig type is Okta")
 
+	a = evergreen.AuthConfig{OIDC: &oidc}
+	um, info, err = LoadUserManager(&evergreen.Settings{AuthConfig: a})
+	assert.NoError(t, err, "a UserManager should be created if one AuthConfig type is OIDC")
+	assert.True(t, info.CanClearTokens)
+	assert.True(t, info.CanReauthorize)
+	assert.NotNil(t, um, "a UserManager should be created if one AuthConfig type is OIDC")
+
+	a = evergreen.AuthConfig{OIDC: &evergreen.OIDCConfig{ClientID: "client_id"}}
+	um, _, err = LoadUserManager(&evergreen.Settings{AuthConfig: a})
+	assert.Error(t, err, "a UserManager should not be created if the OIDC issuer is missing")
+	assert.Nil(t, um)
+
 	a = evergreen.AuthConfig{Naive: &naive}
 	um, info, err = LoadUserManager(&evergreen.Settings{AuthConfig: a})
 	assert.NoError(t, err, "a UserManager should be created if one AuthConfig type is Naive")
@@ -0,0 +1,777 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/golang-jwt/jwt"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateCookieName        = "oidc-state"
+	oidcNonceCookieName        = "oidc-nonce"
+	oidcCodeVerifierCookieName = "oidc-code-verifier"
+	oidcRedirectCookieName     = "oidc-redirect"
+
+	// oidcCookieTTL is how long the user has to log in with the identity
+	// provider before the login attempt is abandoned.
+	oidcCookieTTL = 10 * time.Minute
+	// oidcKeyRefreshInterval is the minimum time between fetching the
+	// identity provider's signing keys when a token is signed with an unknown
+	// key.
+	oidcKeyRefreshInterval = time.Minute
+	// oidcClockSkew is the leeway allowed when checking token timestamps.
+	oidcClockSkew      = time.Minute
+	oidcRequestTimeout = 30 * time.Second
+)
+
+// oidcUserManager implements gimlet.UserManager for any OpenID Connect
+// identity provider. Users log in with the authorization code flow using PKCE.
+// The provider's endpoints and signing keys are discovered from its issuer
+// URL. Users' groups are read from a configurable claim and mapped to
+// Evergreen roles, and users are reauthorized in the background using their
+// refresh token.
+type oidcUserManager struct {
+	conf        evergreen.OIDCConfig
+	redirectURI string
+	loginDomain string
+	expireAfter time.Duration
+	// mappedRoles are all the roles that the group role mappings can grant.
+	// Only these roles are added or removed when a user's groups change.
+	mappedRoles []string
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// oidcDiscovery is the subset of the identity provider's discovery document
+// that is used to log users in.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcIdentity is the user's identity according to the identity provider.
+type oidcIdentity struct {
+	username    string
+	displayName string
+	email       string
+	groups      []string
+}
+
+// NewOIDCUserManager returns a user manager that authenticates users with an
+// OpenID Connect identity provider.
+func NewOIDCUserManager(conf *evergreen.OIDCConfig, evgURL, loginDomain string) (gimlet.UserManager, error) {
+	if conf == nil {
+		return nil, errors.New("OIDC config cannot be nil")
+	}
+	c := *conf
+	if err := c.ValidateAndDefault(); err != nil {
+		return nil, errors.Wrap(err, "invalid OIDC config")
+	}
+	c.Issuer = strings.TrimRight(c.Issuer, "/")
+
+	var mappedRoles []string
+	for _, gr := range c.GroupRoles {
+		for _, role := range gr.Roles {
+			if !utility.StringSliceContains(mappedRoles, role) {
+				mappedRoles = append(mappedRoles, role)
+			}
+		}
+	}
+
+	return &oidcUserManager{
+		conf:        c,
+		redirectURI: strings.TrimRight(evgURL, "/") + "/login/redirect/callback",
+		loginDomain: loginDomain,
+		expireAfter: time.Duration(c.ExpireAfterMinutes) * time.Minute,
+		mappedRoles: mappedRoles,
+	}, nil
+}
+
+func (m *oidcUserManager) GetUserByToken(_ context.Context, token string) (gimlet.User, error) {
+	u, valid, err := user.GetLoginCache(token, m.expireAfter)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting cached user")
+	}
+	if u == nil {
+		return nil, errors.New("user not found in cache")
+	}
+	if !valid {
+		if err := m.ReauthorizeUser(u); err != nil {
+			return u, gimlet.ErrNeedsReauthentication
+		}
+	}
+	return u, nil
+}
+
+func (m *oidcUserManager) GetUserByID(id string) (gimlet.User, error) {
+	u, valid, err := getUserByIdWithExpiration(id, m.expireAfter)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting user by ID")
+	}
+	if !valid {
+		if err := m.ReauthorizeUser(u); err != nil {
+			return u, gimlet.ErrNeedsReauthentication
+		}
+	}
+	return u, nil
+}
+
+// ReauthorizeUser refreshes the user's tokens and updates their roles from
+// their current groups. If the identity provider no longer accepts the user's
+// refresh token or the user is no longer allowed to log in, the returned error
+// is caused by gimlet.ErrNeedsReauthentication.
+func (m *oidcUserManager) ReauthorizeUser(u gimlet.User) error {
+	refreshToken := u.GetRefreshToken()
+	if refreshToken == "" {
+		return errors.Errorf("user '%s' cannot reauthorize because user is missing refresh token", u.Username())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcRequestTimeout)
+	defer cancel()
+	identity, tokens, err := m.refreshTokens(ctx, refreshToken)
+	if err != nil {
+		return errors.Wrapf(err, "refreshing tokens for user '%s'", u.Username())
+	}
+	if identity.username != u.Username() {
+		return errors.Errorf("user name '%s' from identity provider did not match user name '%s' to reauthorize", identity.username, u.Username())
+	}
+	if err = m.authorize(identity); err != nil {
+		return errors.Wrap(gimlet.ErrNeedsReauthentication, err.Error())
+	}
+
+	dbUser, err := m.saveUser(identity, tokens)
+	if err != nil {
+		return errors.Wrapf(err, "updating reauthorized user '%s'", u.Username())
+	}
+	_, err = user.PutLoginCache(dbUser)
+	return errors.Wrapf(err, "updating login cache for reauthorized user '%s'", u.Username())
+}
+
+func (*oidcUserManager) CreateUserToken(string, string) (string, error) {
+	return "", errors.New("creating user tokens is not supported for OIDC")
+}
+
+func (m *oidcUserManager) GetLoginHandler(string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), oidcRequestTimeout)
+		defer cancel()
+		discovery, err := m.getDiscovery(ctx)
+		if err != nil {
+			err = errors.Wrap(err, "discovering OIDC provider configuration")
+			grip.Error(err)
+			gimlet.WriteResponse(w, gimlet.MakeTextErrorResponder(err))
+			return
+		}
+
+		state := utility.RandomString()
+		nonce := utility.RandomString()
+		verifier, err := newCodeVerifier()
+		if err != nil {
+			grip.Error(err)
+			gimlet.WriteResponse(w, gimlet.MakeTextErrorResponder(err))
+			return
+		}
+
+		m.setTemporaryCookie(w, oidcStateCookieName, state)
+		m.setTemporaryCookie(w, oidcNonceCookieName, nonce)
+		m.setTemporaryCookie(w, oidcCodeVerifierCookieName, verifier)
+		m.setTemporaryCookie(w, oidcRedirectCookieName, getSafeRedirect(r.URL.Query().Get("redirect")))
+
+		authURL := m.oauth2Config(discovery).AuthCodeURL(state,
+			oauth2.SetAuthURLParam("nonce", nonce),
+			oauth2.SetAuthURLParam("code_challenge", codeChallenge(verifier)),
+			oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+		)
+		w.Header().Set("Cache-Control", "no-cache,no-store")
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+func (m *oidcUserManager) GetLoginCallbackHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if errCode := q.Get("error"); errCode != "" {
+			err := errors.Errorf("OIDC provider returned error '%s': %s", errCode, q.Get("error_description"))
+			grip.Error(err)
+			writeOIDCError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		state, err := getCookieValue(r, oidcStateCookieName)
+		if err != nil {
+			writeOIDCError(w, http.StatusBadRequest, err)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(state), []byte(q.Get("state"))) != 1 {
+			writeOIDCError(w, http.StatusBadRequest, errors.New("state received from OIDC provider did not match expected state"))
+			return
+		}
+		nonce, err := getCookieValue(r, oidcNonceCookieName)
+		if err != nil {
+			writeOIDCError(w, http.StatusBadRequest, err)
+			return
+		}
+		verifier, err := getCookieValue(r, oidcCodeVerifierCookieName)
+		if err != nil {
+			writeOIDCError(w, http.StatusBadRequest, err)
+			return
+		}
+		redirect, err := getCookieValue(r, oidcRedirectCookieName)
+		if err != nil {
+			redirect = "/"
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), oidcRequestTimeout)
+		defer cancel()
+		identity, tokens, err := m.exchangeCode(ctx, q.Get("code"), verifier, nonce)
+		if err != nil {
+			err = errors.Wrap(err, "redeeming OIDC authorization code")
+			grip.Error(err)
+			writeOIDCError(w, http.StatusUnauthorized, err)
+			return
+		}
+		if err = m.authorize(identity); err != nil {
+			grip.Info(message.WrapError(err, message.Fields{
+				"message": "user is not authorized to log in",
+				"user":    identity.username,
+				"groups":  identity.groups,
+			}))
+			writeOIDCError(w, http.StatusForbidden, err)
+			return
+		}
+
+		dbUser, err := m.saveUser(identity, tokens)
+		if err != nil {
+			err = errors.Wrapf(err, "saving user '%s'", identity.username)
+			grip.Error(err)
+			writeOIDCError(w, http.StatusInternalServerError, err)
+			return
+		}
+		loginToken, err := user.PutLoginCache(dbUser)
+		if err != nil {
+			err = errors.Wrapf(err, "caching user '%s'", identity.username)
+			grip.Error(err)
+			writeOIDCError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, name := range []string{oidcStateCookieName, oidcNonceCookieName, oidcCodeVerifierCookieName, oidcRedirectCookieName} {
+			m.unsetTemporaryCookie(w, name)
+		}
+		SetLoginToken(loginToken, m.loginDomain, w)
+		http.Redirect(w, r, getSafeRedirect(redirect), http.StatusFound)
+	}
+}
+
+func (*oidcUserManager) IsRedirect() bool { return true }
+
+func (*oidcUserManager) GetOrCreateUser(u gimlet.User) (gimlet.User, error) {
+	return getOrCreateUser(u)
+}
+
+func (*oidcUserManager) ClearUser(u gimlet.User, all bool) error {
+	if all {
+		return user.ClearAllLoginCaches()
+	}
+	return user.ClearLoginCache(u)
+}
+
+func (*oidcUserManager) GetGroupsForUser(string) ([]string, error) {
+	return nil, errors.New("GetGroupsForUser has not yet been implemented for the OIDC user manager")
+}
+
+// exchangeCode redeems the authorization code for tokens and returns the
+// identity of the user from the validated ID token.
+func (m *oidcUserManager) exchangeCode(ctx context.Context, code, verifier, nonce string) (*oidcIdentity, *oauth2.Token, error) {
+	if code == "" {
+		return nil, nil, errors.New("missing authorization code")
+	}
+	discovery, err := m.getDiscovery(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "discovering OIDC provider configuration")
+	}
+
+	client := utility.GetHTTPClient()
+	defer utility.PutHTTPClient(client)
+	tokens, err := m.oauth2Config(discovery).Exchange(context.WithValue(ctx, oauth2.HTTPClient, client), code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "exchanging authorization code for tokens")
+	}
+	rawIDToken, _ := tokens.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, nil, errors.New("token response is missing ID token")
+	}
+	claims, err := m.validateIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid ID token")
+	}
+	identity, err := m.getIdentity(ctx, claims, tokens.AccessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	return identity, tokens, nil
+}
+
+// refreshTokens uses the refresh token to get new tokens and returns the
+// current identity of the user. Providers don't have to return a new ID token
+// when tokens are refreshed, in which case the identity comes from the user
+// info endpoint.
+func (m *oidcUserManager) refreshTokens(ctx context.Context, refreshToken string) (*oidcIdentity, *oauth2.Token, error) {
+	discovery, err := m.getDiscovery(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "discovering OIDC provider configuration")
+	}
+
+	client := utility.GetHTTPClient()
+	defer utility.PutHTTPClient(client)
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+	tokens, err := m.oauth2Config(discovery).TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			return nil, nil, errors.Wrap(gimlet.ErrNeedsReauthentication, err.Error())
+		}
+		return nil, nil, errors.Wrap(err, "refreshing tokens")
+	}
+
+	claims := jwt.MapClaims{}
+	if rawIDToken, _ := tokens.Extra("id_token").(string); rawIDToken != "" {
+		if claims, err = m.validateIDToken(ctx, rawIDToken, ""); err != nil {
+			return nil, nil, errors.Wrap(err, "invalid ID token")
+		}
+	} else if claims, err = m.getUserInfo(ctx, discovery, tokens.AccessToken); err != nil {
+		return nil, nil, errors.Wrap(err, "getting user info")
+	}
+	identity, err := m.getIdentity(ctx, claims, tokens.AccessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	return identity, tokens, nil
+}
+
+// validateIDToken checks the ID token's signature and claims and returns its
+// claims. If nonce is empty, the nonce is not checked.
+func (m *oidcUserManager) validateIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	discovery, err := m.getDiscovery(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "discovering OIDC provider configuration")
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.Parser{
+		ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"},
+		// Timestamps are checked below to allow for clock skew.
+		SkipClaimsValidation: true,
+	}
+	if _, err = parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return m.getSigningKey(ctx, kid)
+	}); err != nil {
+		return nil, errors.Wrap(err, "verifying token signature")
+	}
+
+	now := time.Now()
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(!claims.VerifyIssuer(discovery.Issuer, true), "token has wrong issuer")
+	catcher.NewWhen(!claims.VerifyAudience(m.conf.ClientID, true), "token has wrong audience")
+	catcher.NewWhen(!claims.VerifyExpiresAt(now.Add(-oidcClockSkew).Unix(), true), "token is expired")
+	catcher.NewWhen(!claims.VerifyNotBefore(now.Add(oidcClockSkew).Unix(), false), "token is not valid yet")
+	if nonce != "" {
+		tokenNonce, _ := claims["nonce"].(string)
+		catcher.NewWhen(subtle.ConstantTimeCompare([]byte(nonce), []byte(tokenNonce)) != 1, "token has wrong nonce")
+	}
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+	return claims, nil
+}
+
+// getIdentity returns the user's identity from their claims. If the claims
+// don't include the user's groups, they're looked up from the user info
+// endpoint.
+func (m *oidcUserManager) getIdentity(ctx context.Context, claims jwt.MapClaims, accessToken string) (*oidcIdentity, error) {
+	username, _ := claims[m.conf.UsernameClaim].(string)
+	if username == "" {
+		return nil, errors.Errorf("claims are missing username claim '%s'", m.conf.UsernameClaim)
+	}
+	if idx := strings.LastIndex(username, "@"); idx != -1 {
+		// Removing the domain would let users from any domain log in as
+		// the user with the same name, so the domain must be the allowed
+		// one and the provider must have verified that the user owns the
+		// address.
+		if m.conf.EmailDomain == "" || !strings.EqualFold(username[idx+1:], m.conf.EmailDomain) {
+			return nil, errors.Errorf("username '%s' is not in the allowed email domain", username)
+		}
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return nil, errors.Errorf("email address '%s' is not verified", username)
+		}
+		username = username[:idx]
+	}
+	identity := &oidcIdentity{username: username}
+	identity.email, _ = claims["email"].(string)
+	identity.displayName, _ = claims["name"].(string)
+	if identity.displayName == "" {
+		identity.displayName = username
+	}
+
+	groupsClaim, ok := claims[m.conf.GroupsClaim]
+	if !ok && accessToken != "" && (m.conf.UserGroup != "" || len(m.conf.GroupRoles) > 0) {
+		discovery, err := m.getDiscovery(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "discovering OIDC provider configuration")
+		}
+		if discovery.UserInfoEndpoint != "" {
+			userInfo, err := m.getUserInfo(ctx, discovery, accessToken)
+			if err != nil {
+				return nil, errors.Wrap(err, "getting groups from user info")
+			}
+			groupsClaim = userInfo[m.conf.GroupsClaim]
+		}
+	}
+	switch groups := groupsClaim.(type) {
+	case string:
+		identity.groups = []string{groups}
+	case []interface{}:
+		for _, group := range groups {
+			if g, ok := group.(string); ok {
+				identity.groups = append(identity.groups, g)
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+// authorize checks that the user is allowed to log in.
+func (m *oidcUserManager) authorize(identity *oidcIdentity) error {
+	if m.conf.UserGroup != "" && !utility.StringSliceContains(identity.groups, m.conf.UserGroup) {
+		return errors.Errorf("user '%s' is not in group '%s'", identity.username, m.conf.UserGroup)
+	}
+	return nil
+}
+
+// getRolesForGroups returns the roles granted to members of the given groups.
+func (m *oidcUserManager) getRolesForGroups(groups []string) []string {
+	var roles []string
+	for _, gr := range m.conf.GroupRoles {
+		if !utility.StringSliceContains(groups, gr.Group) {
+			continue
+		}
+		for _, role := range gr.Roles {
+			if !utility.StringSliceContains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// saveUser creates or updates the user and syncs their mapped roles with their
+// current groups. Roles that aren't granted by a group mapping are left alone.
+func (m *oidcUserManager) saveUser(identity *oidcIdentity, tokens *oauth2.Token) (*user.DBUser, error) {
+	roles := m.getRolesForGroups(identity.groups)
+	u, err := user.GetOrCreateUser(identity.username, identity.displayName, identity.email, tokens.AccessToken, tokens.RefreshToken, roles)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting or creating user")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	for _, role := range m.mappedRoles {
+		granted := utility.StringSliceContains(roles, role)
+		hasRole := utility.StringSliceContains(u.SystemRoles, role)
+		if granted && !hasRole {
+			catcher.Wrapf(u.AddRole(role), "adding role '%s'", role)
+		} else if !granted && hasRole {
+			catcher.Wrapf(u.RemoveRole(role), "removing role '%s'", role)
+		}
+	}
+	return u, errors.Wrapf(catcher.Resolve(), "syncing roles for user '%s'", identity.username)
+}
+
+func (m *oidcUserManager) oauth2Config(discovery *oidcDiscovery) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     m.conf.ClientID,
+		ClientSecret: m.conf.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+		RedirectURL: m.redirectURI,
+		Scopes:      m.conf.Scopes,
+	}
+}
+
+// getDiscovery returns the identity provider's discovery document, fetching it
+// if it hasn't been fetched yet.
+func (m *oidcUserManager) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.discovery != nil {
+		return m.discovery, nil
+	}
+
+	discovery := &oidcDiscovery{}
+	if err := getOIDCJSON(ctx, m.conf.Issuer+"/.well-known/openid-configuration", "", discovery); err != nil {
+		return nil, errors.Wrap(err, "getting discovery document")
+	}
+	catcher := grip.NewBasicCatcher()
+	catcher.ErrorfWhen(strings.TrimRight(discovery.Issuer, "/") != m.conf.Issuer, "discovery document issuer '%s' does not match configured issuer '%s'", discovery.Issuer, m.conf.Issuer)
+	catcher.NewWhen(discovery.AuthorizationEndpoint == "", "discovery document is missing authorization endpoint")
+	catcher.NewWhen(discovery.TokenEndpoint == "", "discovery document is missing token endpoint")
+	catcher.NewWhen(discovery.JWKSURI == "", "discovery document is missing JWKS URI")
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+	m.discovery = discovery
+	return discovery, nil
+}
+
+// getSigningKey returns the identity provider's public key with the given key
+// ID. The provider's keys are fetched again if the key is unknown, since
+// providers rotate their keys.
+func (m *oidcUserManager) getSigningKey(ctx context.Context, kid string) (interface{}, error) {
+	discovery, err := m.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key, ok := findSigningKey(m.keys, kid); ok {
+		return key, nil
+	}
+	if time.Since(m.keysFetchedAt) < oidcKeyRefreshInterval {
+		return nil, errors.Errorf("unknown signing key '%s'", kid)
+	}
+
+	jwks := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err = getOIDCJSON(ctx, discovery.JWKSURI, "", &jwks); err != nil {
+		return nil, errors.Wrap(err, "getting signing keys")
+	}
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			grip.Warning(message.WrapError(err, message.Fields{
+				"message": "skipping invalid OIDC signing key",
+				"kid":     jwk.KeyID,
+			}))
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	m.keys = keys
+	m.keysFetchedAt = time.Now()
+
+	if key, ok := findSigningKey(m.keys, kid); ok {
+		return key, nil
+	}
+	return nil, errors.Errorf("unknown signing key '%s'", kid)
+}
+
+// findSigningKey returns the key with the given ID. If the token doesn't
+// specify a key ID, the provider must have exactly one key.
+func findSigningKey(keys map[string]interface{}, kid string) (interface{}, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+func (m *oidcUserManager) getUserInfo(ctx context.Context, discovery *oidcDiscovery, accessToken string) (jwt.MapClaims, error) {
+	if discovery.UserInfoEndpoint == "" {
+		return nil, errors.New("OIDC provider does not have a user info endpoint")
+	}
+	userInfo := jwt.MapClaims{}
+	if err := getOIDCJSON(ctx, discovery.UserInfoEndpoint, accessToken, &userInfo); err != nil {
+		return nil, err
+	}
+	return userInfo, nil
+}
+
+// jsonWebKey is a public key in a JSON Web Key Set.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	// RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// Elliptic curve keys.
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding RSA modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding RSA exponent")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported elliptic curve '%s'", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding elliptic curve x coordinate")
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding elliptic curve y coordinate")
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported key type '%s'", k.KeyType)
+	}
+}
+
+func getOIDCJSON(ctx context.Context, url, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	client := utility.GetHTTPClient()
+	defer utility.PutHTTPClient(client)
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "requesting '%s'", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("request to '%s' returned status %d", url, resp.StatusCode)
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "decoding response from '%s'", url)
+}
+
+// newCodeVerifier returns a random PKCE code verifier.
+func newCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generating PKCE code verifier")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE code challenge for the code verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// getSafeRedirect returns the redirect if it's a path within Evergreen, so
+// that the login flow can't be used to redirect users to another site.
+func getSafeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+	return redirect
+}
+
+func (m *oidcUserManager) setTemporaryCookie(w http.ResponseWriter, name, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     "/",
+		Domain:   m.loginDomain,
+		Expires:  time.Now().Add(oidcCookieTTL),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(m.redirectURI, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (m *oidcUserManager) unsetTemporaryCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   name,
+		Path:   "/",
+		Domain: m.loginDomain,
+		MaxAge: -1,
+	})
+}
+
+func getCookieValue(r *http.Request, name string) (string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", errors.Errorf("missing cookie '%s'", name)
+	}
+	value, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		return "", errors.Wrapf(err, "decoding cookie '%s'", name)
+	}
+	if value == "" {
+		return "", errors.Errorf("cookie '%s' is empty", name)
+	}
+	return value, nil
+}
+
+func writeOIDCError(w http.ResponseWriter, status int, err error) {
+	gimlet.WriteResponse(w, gimlet.MakeTextErrorResponder(gimlet.ErrorResponse{
+		StatusCode: status,
+		Message:    fmt.Sprintf("logging in with OIDC: %s", err.Error()),
+	}))
+}
@@ -0,0 +1,539 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOIDCProvider is an in-process OpenID Connect identity provider that
+// issues tokens for a single user.
+type mockOIDCProvider struct {
+	srv          *httptest.Server
+	key          *rsa.PrivateKey
+	kid          string
+	clientID     string
+	clientSecret string
+
+	mu sync.Mutex
+	// claims are the claims about the user issued in ID tokens and returned
+	// from the user info endpoint.
+	claims jwt.MapClaims
+	// omitIDTokenOnRefresh makes the token endpoint omit the ID token when
+	// refreshing tokens.
+	omitIDTokenOnRefresh bool
+	codes                map[string]mockAuthorization
+	refreshTokens        map[string]bool
+	accessTokens         map[string]bool
+}
+
+type mockAuthorization struct {
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &mockOIDCProvider{
+		key:           key,
+		kid:           "key1",
+		clientID:      "client_id",
+		clientSecret:  "client_secret",
+		codes:         map[string]mockAuthorization{},
+		refreshTokens: map[string]bool{},
+		accessTokens:  map[string]bool{},
+		claims: jwt.MapClaims{
+			"sub":    "1234",
+			"email":  "alice@example.com",
+			"name":   "Alice",
+			"groups": []string{"evergreen-users", "admins"},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 p.srv.URL,
+			"authorization_endpoint": p.srv.URL + "/authorize",
+			"token_endpoint":         p.srv.URL + "/token",
+			"userinfo_endpoint":      p.srv.URL + "/userinfo",
+			"jwks_uri":               p.srv.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": p.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			}},
+		})
+	})
+	// The authorize endpoint logs the user in immediately.
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != p.clientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "invalid authorization request", http.StatusBadRequest)
+			return
+		}
+		code := utility.RandomString()
+		p.mu.Lock()
+		p.codes[code] = mockAuthorization{
+			nonce:         q.Get("nonce"),
+			codeChallenge: q.Get("code_challenge"),
+			redirectURI:   q.Get("redirect_uri"),
+		}
+		p.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		if clientID != p.clientID || clientSecret != p.clientSecret {
+			writeMockJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			auth, ok := p.codes[r.PostForm.Get("code")]
+			delete(p.codes, r.PostForm.Get("code"))
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if !ok || auth.codeChallenge != base64.RawURLEncoding.EncodeToString(sum[:]) || auth.redirectURI != r.PostForm.Get("redirect_uri") {
+				writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			writeMockJSON(w, http.StatusOK, p.issueTokens(t, auth.nonce, true))
+		case "refresh_token":
+			refreshToken := r.PostForm.Get("refresh_token")
+			if !p.refreshTokens[refreshToken] {
+				writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "refresh token is invalid or expired"})
+				return
+			}
+			delete(p.refreshTokens, refreshToken)
+			writeMockJSON(w, http.StatusOK, p.issueTokens(t, "", !p.omitIDTokenOnRefresh))
+		default:
+			writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		}
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !p.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeMockJSON(w, http.StatusOK, p.claims)
+	})
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+
+	return p
+}
+
+// issueTokens returns a token response. The caller must hold the lock.
+func (p *mockOIDCProvider) issueTokens(t *testing.T, nonce string, includeIDToken bool) map[string]interface{} {
+	accessToken := utility.RandomString()
+	refreshToken := utility.RandomString()
+	p.accessTokens[accessToken] = true
+	p.refreshTokens[refreshToken] = true
+	resp := map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	}
+	if includeIDToken {
+		claims := jwt.MapClaims{
+			"iss": p.srv.URL,
+			"aud": p.clientID,
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		if nonce != "" {
+			claims["nonce"] = nonce
+		}
+		for k, v := range p.claims {
+			claims[k] = v
+		}
+		resp["id_token"] = p.signToken(t, claims)
+	}
+	return resp
+}
+
+func (p *mockOIDCProvider) signToken(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	signed, err := token.SignedString(p.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (p *mockOIDCProvider) setClaims(claims jwt.MapClaims) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims = claims
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func makeTestOIDCUserManager(t *testing.T, p *mockOIDCProvider, conf evergreen.OIDCConfig) *oidcUserManager {
+	conf.Issuer = p.srv.URL
+	conf.ClientID = p.clientID
+	conf.ClientSecret = p.clientSecret
+	if conf.EmailDomain == "" {
+		conf.EmailDomain = "example.com"
+	}
+	um, err := NewOIDCUserManager(&conf, "https://evergreen.example.com", "")
+	require.NoError(t, err)
+	m, ok := um.(*oidcUserManager)
+	require.True(t, ok)
+	return m
+}
+
+// login starts the login flow and follows the redirect to the mock identity
+// provider. It returns the request to the callback handler, including the
+// login cookies.
+func login(t *testing.T, m *oidcUserManager, redirect string) *http.Request {
+	rec := httptest.NewRecorder()
+	m.GetLoginHandler("")(rec, httptest.NewRequest(http.MethodGet, "/login/redirect?redirect="+url.QueryEscape(redirect), nil))
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback := httptest.NewRequest(http.MethodGet, resp.Header.Get("Location"), nil)
+	for _, cookie := range rec.Result().Cookies() {
+		callback.AddCookie(cookie)
+	}
+	return callback
+}
+
+func getTestCookie(t *testing.T, r *http.Request, name string) string {
+	value, err := getCookieValue(r, name)
+	require.NoError(t, err)
+	return value
+}
+
+func TestOIDCUserManager(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for tName, tCase := range map[string]func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager){
+		"LoginRedirectsToProviderWithPKCE": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			rec := httptest.NewRecorder()
+			m.GetLoginHandler("")(rec, httptest.NewRequest(http.MethodGet, "/login/redirect?redirect=/waterfall", nil))
+			require.Equal(t, http.StatusFound, rec.Code)
+
+			loc, err := url.Parse(rec.Header().Get("Location"))
+			require.NoError(t, err)
+			assert.Equal(t, p.srv.URL+"/authorize", loc.Scheme+"://"+loc.Host+loc.Path)
+			q := loc.Query()
+			assert.Equal(t, p.clientID, q.Get("client_id"))
+			assert.Equal(t, "code", q.Get("response_type"))
+			assert.Equal(t, "https://evergreen.example.com/login/redirect/callback", q.Get("redirect_uri"))
+			assert.Equal(t, "openid email profile offline_access", q.Get("scope"))
+			assert.Equal(t, "S256", q.Get("code_challenge_method"))
+
+			cookies := map[string]string{}
+			for _, cookie := range rec.Result().Cookies() {
+				assert.True(t, cookie.HttpOnly)
+				assert.True(t, cookie.Secure)
+				value, err := url.QueryUnescape(cookie.Value)
+				require.NoError(t, err)
+				cookies[cookie.Name] = value
+			}
+			assert.Equal(t, cookies[oidcStateCookieName], q.Get("state"))
+			assert.Equal(t, cookies[oidcNonceCookieName], q.Get("nonce"))
+			assert.Equal(t, codeChallenge(cookies[oidcCodeVerifierCookieName]), q.Get("code_challenge"))
+			assert.Equal(t, "/waterfall", cookies[oidcRedirectCookieName])
+		},
+		"ExchangeCodeReturnsIdentity": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			callback := login(t, m, "/")
+			identity, tokens, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), getTestCookie(t, callback, oidcNonceCookieName))
+			require.NoError(t, err)
+			assert.Equal(t, "alice", identity.username)
+			assert.Equal(t, "Alice", identity.displayName)
+			assert.Equal(t, "alice@example.com", identity.email)
+			assert.Equal(t, []string{"evergreen-users", "admins"}, identity.groups)
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
+		},
+		"ExchangeCodeRejectsOtherEmailDomain": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			p.setClaims(jwt.MapClaims{"sub": "1234", "email": "alice@attacker.com"})
+			callback := login(t, m, "/")
+			_, _, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), getTestCookie(t, callback, oidcNonceCookieName))
+			assert.Error(t, err)
+		},
+		"ExchangeCodeRejectsUnverifiedEmail": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			p.setClaims(jwt.MapClaims{"sub": "1234", "email": "alice@example.com", "email_verified": false})
+			callback := login(t, m, "/")
+			_, _, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), getTestCookie(t, callback, oidcNonceCookieName))
+			assert.Error(t, err)
+		},
+		"ExchangeCodeUsesNonEmailUsernameClaim": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			m.conf.UsernameClaim = "preferred_username"
+			m.conf.EmailDomain = ""
+			p.setClaims(jwt.MapClaims{"sub": "1234", "preferred_username": "alice", "email": "alice@attacker.com", "email_verified": false})
+			callback := login(t, m, "/")
+			identity, _, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), getTestCookie(t, callback, oidcNonceCookieName))
+			require.NoError(t, err)
+			assert.Equal(t, "alice", identity.username)
+		},
+		"ExchangeCodeFailsWithWrongCodeVerifier": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			callback := login(t, m, "/")
+			verifier, err := newCodeVerifier()
+			require.NoError(t, err)
+			_, _, err = m.exchangeCode(ctx, callback.URL.Query().Get("code"), verifier, getTestCookie(t, callback, oidcNonceCookieName))
+			assert.Error(t, err)
+		},
+		"ExchangeCodeFailsWithWrongNonce": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			callback := login(t, m, "/")
+			_, _, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), "wrong-nonce")
+			assert.Error(t, err)
+		},
+		"ExchangeCodeGetsGroupsFromUserInfo": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			p.setClaims(jwt.MapClaims{"sub": "1234", "email": "alice@example.com"})
+			callback := login(t, m, "/")
+			p.setClaims(jwt.MapClaims{"sub": "1234", "email": "alice@example.com", "groups": []string{"evergreen-users"}})
+			identity, _, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), getTestCookie(t, callback, oidcNonceCookieName))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"evergreen-users"}, identity.groups)
+		},
+		"CallbackRejectsMismatchedState": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			callback := login(t, m, "/")
+			q := callback.URL.Query()
+			q.Set("state", "wrong-state")
+			callback.URL.RawQuery = q.Encode()
+
+			rec := httptest.NewRecorder()
+			m.GetLoginCallbackHandler()(rec, callback)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		},
+		"CallbackRejectsProviderError": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			rec := httptest.NewRecorder()
+			m.GetLoginCallbackHandler()(rec, httptest.NewRequest(http.MethodGet, "/login/redirect/callback?error=access_denied", nil))
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		},
+		"ValidateIDTokenRejectsInvalidTokens": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			validClaims := func() jwt.MapClaims {
+				return jwt.MapClaims{
+					"iss":   p.srv.URL,
+					"aud":   p.clientID,
+					"exp":   time.Now().Add(time.Hour).Unix(),
+					"nonce": "nonce",
+					"email": "alice@example.com",
+				}
+			}
+			_, err := m.validateIDToken(ctx, p.signToken(t, validClaims()), "nonce")
+			require.NoError(t, err)
+
+			claims := validClaims()
+			claims["aud"] = "other-client"
+			_, err = m.validateIDToken(ctx, p.signToken(t, claims), "nonce")
+			assert.Error(t, err, "should reject wrong audience")
+
+			claims = validClaims()
+			claims["iss"] = "https://other-issuer.example.com"
+			_, err = m.validateIDToken(ctx, p.signToken(t, claims), "nonce")
+			assert.Error(t, err, "should reject wrong issuer")
+
+			claims = validClaims()
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			_, err = m.validateIDToken(ctx, p.signToken(t, claims), "nonce")
+			assert.Error(t, err, "should reject expired token")
+
+			otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			require.NoError(t, err)
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+			token.Header["kid"] = p.kid
+			signed, err := token.SignedString(otherKey)
+			require.NoError(t, err)
+			_, err = m.validateIDToken(ctx, signed, "nonce")
+			assert.Error(t, err, "should reject token with invalid signature")
+
+			unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			require.NoError(t, err)
+			_, err = m.validateIDToken(ctx, unsigned, "nonce")
+			assert.Error(t, err, "should reject unsigned token")
+		},
+		"RefreshTokensReturnsIdentity": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			callback := login(t, m, "/")
+			_, tokens, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), getTestCookie(t, callback, oidcNonceCookieName))
+			require.NoError(t, err)
+
+			p.setClaims(jwt.MapClaims{"sub": "1234", "email": "alice@example.com", "groups": []string{"evergreen-users"}})
+			identity, refreshed, err := m.refreshTokens(ctx, tokens.RefreshToken)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", identity.username)
+			assert.Equal(t, []string{"evergreen-users"}, identity.groups)
+			assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+		},
+		"RefreshTokensWithoutIDTokenUsesUserInfo": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			callback := login(t, m, "/")
+			_, tokens, err := m.exchangeCode(ctx, callback.URL.Query().Get("code"), getTestCookie(t, callback, oidcCodeVerifierCookieName), getTestCookie(t, callback, oidcNonceCookieName))
+			require.NoError(t, err)
+
+			p.omitIDTokenOnRefresh = true
+			identity, _, err := m.refreshTokens(ctx, tokens.RefreshToken)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", identity.username)
+			assert.Equal(t, []string{"evergreen-users", "admins"}, identity.groups)
+		},
+		"RefreshTokensWithInvalidGrantNeedsReauthentication": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			_, _, err := m.refreshTokens(ctx, "unknown-refresh-token")
+			require.Error(t, err)
+			assert.Equal(t, gimlet.ErrNeedsReauthentication, errors.Cause(err))
+		},
+		"AuthorizeRequiresUserGroup": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			m.conf.UserGroup = "evergreen-users"
+			assert.NoError(t, m.authorize(&oidcIdentity{username: "alice", groups: []string{"evergreen-users"}}))
+			assert.Error(t, m.authorize(&oidcIdentity{username: "alice", groups: []string{"admins"}}))
+		},
+		"GetRolesForGroups": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			assert.ElementsMatch(t, []string{"superuser", "viewer"}, m.getRolesForGroups([]string{"admins", "evergreen-users"}))
+			assert.Equal(t, []string{"viewer"}, m.getRolesForGroups([]string{"evergreen-users"}))
+			assert.Empty(t, m.getRolesForGroups([]string{"other"}))
+			assert.ElementsMatch(t, []string{"superuser", "viewer"}, m.mappedRoles)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			p := newMockOIDCProvider(t)
+			m := makeTestOIDCUserManager(t, p, evergreen.OIDCConfig{
+				GroupRoles: []evergreen.OIDCGroupRoles{
+					{Group: "admins", Roles: []string{"superuser", "viewer"}},
+					{Group: "evergreen-users", Roles: []string{"viewer"}},
+				},
+			})
+			tCase(t, p, m)
+		})
+	}
+}
+
+func TestGetSafeRedirect(t *testing.T) {
+	assert.Equal(t, "/waterfall?project=evg", getSafeRedirect("/waterfall?project=evg"))
+	assert.Equal(t, "/", getSafeRedirect(""))
+	assert.Equal(t, "/", getSafeRedirect("https://attacker.example.com"))
+	assert.Equal(t, "/", getSafeRedirect("//attacker.example.com"))
+	assert.Equal(t, "/", getSafeRedirect("/\\attacker.example.com"))
+}
+
+func TestOIDCUserManagerLogin(t *testing.T) {
+	for tName, tCase := range map[string]func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager){
+		"CallbackCreatesUserWithMappedRoles": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			rec := httptest.NewRecorder()
+			m.GetLoginCallbackHandler()(rec, login(t, m, "/waterfall"))
+			require.Equal(t, http.StatusFound, rec.Code)
+			assert.Equal(t, "/waterfall", rec.Header().Get("Location"))
+
+			var loginToken string
+			for _, cookie := range rec.Result().Cookies() {
+				if cookie.Name == evergreen.AuthTokenCookie {
+					loginToken = cookie.Value
+				}
+			}
+			require.NotEmpty(t, loginToken)
+
+			u, err := m.GetUserByToken(context.Background(), loginToken)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", u.Username())
+			assert.Equal(t, "alice@example.com", u.Email())
+			assert.ElementsMatch(t, []string{"superuser", "viewer"}, u.Roles())
+		},
+		"CallbackRejectsUserNotInGroup": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			m.conf.UserGroup = "other-group"
+			rec := httptest.NewRecorder()
+			m.GetLoginCallbackHandler()(rec, login(t, m, "/"))
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+
+			u, err := user.FindOneById("alice")
+			require.NoError(t, err)
+			assert.Nil(t, u)
+		},
+		"ReauthorizeUserSyncsMappedRoles": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			rec := httptest.NewRecorder()
+			m.GetLoginCallbackHandler()(rec, login(t, m, "/"))
+			require.Equal(t, http.StatusFound, rec.Code)
+
+			u, err := user.FindOneById("alice")
+			require.NoError(t, err)
+			require.NotNil(t, u)
+			require.NoError(t, u.AddRole("manually-granted"))
+
+			p.setClaims(jwt.MapClaims{"sub": "1234", "email": "alice@example.com", "groups": []string{"evergreen-users"}})
+			require.NoError(t, m.ReauthorizeUser(u))
+
+			u, err = user.FindOneById("alice")
+			require.NoError(t, err)
+			require.NotNil(t, u)
+			assert.ElementsMatch(t, []string{"viewer", "manually-granted"}, u.Roles())
+		},
+		"ReauthorizeUserNeedsReauthenticationWhenRemovedFromGroup": func(t *testing.T, p *mockOIDCProvider, m *oidcUserManager) {
+			rec := httptest.NewRecorder()
+			m.GetLoginCallbackHandler()(rec, login(t, m, "/"))
+			require.Equal(t, http.StatusFound, rec.Code)
+
+			u, err := user.FindOneById("alice")
+			require.NoError(t, err)
+			require.NotNil(t, u)
+
+			m.conf.UserGroup = "evergreen-users"
+			p.setClaims(jwt.MapClaims{"sub": "1234", "email": "alice@example.com", "groups": []string{"admins"}})
+			err = m.ReauthorizeUser(u)
+			require.Error(t, err)
+			assert.Equal(t, gimlet.ErrNeedsReauthentication, errors.Cause(err))
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.Clear(user.Collection))
+			defer func() {
+				assert.NoError(t, db.Clear(user.Collection))
+			}()
+			p := newMockOIDCProvider(t)
+			m := makeTestOIDCUserManager(t, p, evergreen.OIDCConfig{
+				GroupRoles: []evergreen.OIDCGroupRoles{
+					{Group: "admins", Roles: []string{"superuser", "viewer"}},
+					{Group: "evergreen-users", Roles: []string{"viewer"}},
+				},
+			})
+			tCase(t, p, m)
+		})
+	}
+}
@@ -52,10 +52,15 @@ func buildApp() *cli.App {
 
 		// Top-level commands.
 		operations.Keys(),
+		operations.Tokens(),
+		operations.VerifyArtifact(),
 		operations.Fetch(),
 		operations.Pull(),
 		operations.Evaluate(),
+		operations.Local(),
 		operations.Validate(),
+		operations.Format(),
+		operations.LSP(),
 		operations.List(),
 		operations.LastGreen(),
 		operations.Subscriptions(),
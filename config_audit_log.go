@@ -0,0 +1,119 @@
+package evergreen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// AuditLogSinkSyslog streams audit log entries to syslog.
+	AuditLogSinkSyslog = "syslog"
+	// AuditLogSinkFile streams audit log entries to a local file as JSON
+	// lines.
+	AuditLogSinkFile = "file"
+
+	defaultAuditLogSyslogTag = "evergreen-audit"
+)
+
+// AuditLogConfig configures where the audit log of admin settings, project
+// settings, and permission changes is streamed.
+type AuditLogConfig struct {
+	// SinkType is the kind of sink that audit log entries are streamed to.
+	// If empty, the audit log is not streamed, but it can still be queried
+	// and exported.
+	SinkType string `bson:"sink_type" json:"sink_type" yaml:"sink_type"`
+	// FilePath is the path on the app servers of the file that entries are
+	// appended to for the file sink.
+	FilePath string `bson:"file_path" json:"file_path" yaml:"file_path"`
+	// SyslogNetwork and SyslogAddress identify a remote syslog server for the
+	// syslog sink. If they are empty, entries are sent to the local syslog
+	// daemon.
+	SyslogNetwork string `bson:"syslog_network" json:"syslog_network" yaml:"syslog_network"`
+	SyslogAddress string `bson:"syslog_address" json:"syslog_address" yaml:"syslog_address"`
+	// SyslogTag is the tag that entries are sent to syslog with.
+	SyslogTag string `bson:"syslog_tag" json:"syslog_tag" yaml:"syslog_tag"`
+	// HMACKeyFile is the path on the app servers to a file containing the
+	// secret key that signs each audit log entry when it's written, so that
+	// entries modified in the database can be detected. If it's not set,
+	// entries aren't signed.
+	HMACKeyFile string `bson:"hmac_key_file" json:"hmac_key_file" yaml:"hmac_key_file"`
+}
+
+func (c *AuditLogConfig) SectionId() string { return "audit_log" }
+
+func (c *AuditLogConfig) Get(ctx context.Context) error {
+	res := GetEnvironment().DB().Collection(ConfigCollection).FindOne(ctx, byId(c.SectionId()))
+	if err := res.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			*c = AuditLogConfig{}
+			return nil
+		}
+		return errors.Wrapf(err, "getting config section '%s'", c.SectionId())
+	}
+
+	if err := res.Decode(&c); err != nil {
+		return errors.Wrapf(err, "decoding config section '%s'", c.SectionId())
+	}
+
+	return nil
+}
+
+func (c *AuditLogConfig) Set(ctx context.Context) error {
+	_, err := GetEnvironment().DB().Collection(ConfigCollection).UpdateOne(ctx, byId(c.SectionId()), bson.M{
+		"$set": bson.M{
+			"sink_type":      c.SinkType,
+			"file_path":      c.FilePath,
+			"syslog_network": c.SyslogNetwork,
+			"syslog_address": c.SyslogAddress,
+			"syslog_tag":     c.SyslogTag,
+			"hmac_key_file":  c.HMACKeyFile,
+		},
+	}, options.Update().SetUpsert(true))
+
+	return errors.Wrapf(err, "updating config section '%s'", c.SectionId())
+}
+
+func (c *AuditLogConfig) ValidateAndDefault() error {
+	catcher := grip.NewBasicCatcher()
+	switch c.SinkType {
+	case "":
+	case AuditLogSinkFile:
+		catcher.NewWhen(c.FilePath == "", "file path must be set for the file audit log sink")
+		catcher.ErrorfWhen(c.FilePath != "" && !filepath.IsAbs(c.FilePath), "audit log file path '%s' must be absolute", c.FilePath)
+	case AuditLogSinkSyslog:
+		catcher.NewWhen((c.SyslogNetwork == "") != (c.SyslogAddress == ""), "syslog network and address must be set together")
+		if c.SyslogTag == "" {
+			c.SyslogTag = defaultAuditLogSyslogTag
+		}
+	default:
+		catcher.Errorf("invalid audit log sink type '%s'", c.SinkType)
+	}
+	catcher.ErrorfWhen(c.HMACKeyFile != "" && !filepath.IsAbs(c.HMACKeyFile), "audit log HMAC key file path '%s' must be absolute", c.HMACKeyFile)
+
+	return catcher.Resolve()
+}
+
+// HMACKey returns the key that signs audit log entries, or nil if signing
+// isn't configured.
+func (c *AuditLogConfig) HMACKey() ([]byte, error) {
+	if c.HMACKeyFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(c.HMACKeyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading audit log HMAC key file '%s'", c.HMACKeyFile)
+	}
+	key := []byte(strings.TrimSpace(string(data)))
+	if len(key) == 0 {
+		return nil, errors.Errorf("audit log HMAC key file '%s' is empty", c.HMACKeyFile)
+	}
+	return key, nil
+}
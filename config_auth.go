@@ -2,6 +2,7 @@ package evergreen
 
 import (
 	"context"
+	"strings"
 
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/anser/bsonutil"
@@ -23,6 +24,7 @@ type AuthUser struct {
 var (
 	AuthLDAPKey                    = bsonutil.MustHaveTag(AuthConfig{}, "LDAP")
 	AuthOktaKey                    = bsonutil.MustHaveTag(AuthConfig{}, "Okta")
+	AuthOIDCKey                    = bsonutil.MustHaveTag(AuthConfig{}, "OIDC")
 	AuthGithubKey                  = bsonutil.MustHaveTag(AuthConfig{}, "Github")
 	AuthNaiveKey                   = bsonutil.MustHaveTag(AuthConfig{}, "Naive")
 	AuthOnlyAPIKey                 = bsonutil.MustHaveTag(AuthConfig{}, "OnlyAPI")
@@ -72,6 +74,66 @@ type OktaConfig struct {
 	ExpireAfterMinutes int      `bson:"expire_after_minutes" json:"expire_after_minutes" yaml:"expire_after_minutes"`
 }
 
+// OIDCConfig contains settings for authenticating users with a generic OpenID
+// Connect identity provider (e.g. Keycloak, Azure AD or Google).
+type OIDCConfig struct {
+	// Issuer is the identity provider's issuer URL, which is used to discover
+	// its endpoints.
+	Issuer       string   `bson:"issuer" json:"issuer" yaml:"issuer"`
+	ClientID     string   `bson:"client_id" json:"client_id" yaml:"client_id"`
+	ClientSecret string   `bson:"client_secret" json:"client_secret" yaml:"client_secret"`
+	Scopes       []string `bson:"scopes" json:"scopes" yaml:"scopes"`
+	// UsernameClaim is the claim that identifies the user. If the claim is an
+	// email address, the domain is removed. Defaults to "email".
+	UsernameClaim string `bson:"username_claim" json:"username_claim" yaml:"username_claim"`
+	// EmailDomain is the domain that usernames that are email addresses must
+	// belong to, since the domain is removed from them. It's required if the
+	// username claim is "email".
+	EmailDomain string `bson:"email_domain" json:"email_domain" yaml:"email_domain"`
+	// GroupsClaim is the claim that lists the user's groups. Defaults to
+	// "groups".
+	GroupsClaim string `bson:"groups_claim" json:"groups_claim" yaml:"groups_claim"`
+	// UserGroup, if set, is the group that users must belong to in order to
+	// log in.
+	UserGroup string `bson:"user_group" json:"user_group" yaml:"user_group"`
+	// GroupRoles maps the user's groups to Evergreen roles.
+	GroupRoles         []OIDCGroupRoles `bson:"group_roles" json:"group_roles" yaml:"group_roles"`
+	ExpireAfterMinutes int              `bson:"expire_after_minutes" json:"expire_after_minutes" yaml:"expire_after_minutes"`
+}
+
+// OIDCGroupRoles grants roles to members of an identity provider group.
+type OIDCGroupRoles struct {
+	Group string   `bson:"group" json:"group" yaml:"group"`
+	Roles []string `bson:"roles" json:"roles" yaml:"roles"`
+}
+
+// ValidateAndDefault checks that the required OIDC settings are set and
+// fills in defaults for the rest.
+func (c *OIDCConfig) ValidateAndDefault() error {
+	catcher := grip.NewSimpleCatcher()
+	catcher.NewWhen(c.Issuer == "", "OIDC issuer cannot be empty")
+	catcher.NewWhen(c.ClientID == "", "OIDC client ID cannot be empty")
+	for _, gr := range c.GroupRoles {
+		catcher.NewWhen(gr.Group == "", "OIDC group role mapping must specify a group")
+		catcher.ErrorfWhen(len(gr.Roles) == 0, "OIDC group role mapping for group '%s' must specify at least one role", gr.Group)
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "email", "profile", "offline_access"}
+	}
+	if c.UsernameClaim == "" {
+		c.UsernameClaim = "email"
+	}
+	c.EmailDomain = strings.TrimPrefix(c.EmailDomain, "@")
+	catcher.NewWhen(c.UsernameClaim == "email" && c.EmailDomain == "", "OIDC email domain must be set if the username claim is 'email'")
+	if c.GroupsClaim == "" {
+		c.GroupsClaim = "groups"
+	}
+	if c.ExpireAfterMinutes <= 0 {
+		c.ExpireAfterMinutes = 60
+	}
+	return catcher.Resolve()
+}
+
 // GithubAuthConfig contains settings for interacting with Github Authentication
 // including the ClientID, ClientSecret and CallbackUri which are given when
 // registering the application Furthermore,
@@ -101,6 +163,7 @@ func (c *MultiAuthConfig) IsZero() bool {
 type AuthConfig struct {
 	LDAP                    *LDAPConfig        `bson:"ldap,omitempty" json:"ldap" yaml:"ldap"`
 	Okta                    *OktaConfig        `bson:"okta,omitempty" json:"okta" yaml:"okta"`
+	OIDC                    *OIDCConfig        `bson:"oidc,omitempty" json:"oidc" yaml:"oidc"`
 	Naive                   *NaiveAuthConfig   `bson:"naive,omitempty" json:"naive" yaml:"naive"`
 	OnlyAPI                 *OnlyAPIAuthConfig `bson:"only_api,omitempty" json:"only_api" yaml:"only_api"` // deprecated
 	Github                  *GithubAuthConfig  `bson:"github,omitempty" json:"github" yaml:"github"`
@@ -134,6 +197,7 @@ func (c *AuthConfig) Set(ctx context.Context) error {
 		"$set": bson.M{
 			AuthLDAPKey:                    c.LDAP,
 			AuthOktaKey:                    c.Okta,
+			AuthOIDCKey:                    c.OIDC,
 			AuthNaiveKey:                   c.Naive,
 			AuthOnlyAPIKey:                 c.OnlyAPI,
 			AuthGithubKey:                  c.Github,
@@ -173,16 +237,21 @@ func (c *AuthConfig) ValidateAndDefault() error {
 		"",
 		AuthLDAPKey,
 		AuthOktaKey,
+		AuthOIDCKey,
 		AuthNaiveKey,
 		AuthGithubKey,
 		AuthMultiKey}, c.PreferredType), "invalid auth type '%s'", c.PreferredType)
 
-	if c.LDAP == nil && c.Naive == nil && c.OnlyAPI == nil && c.Github == nil && c.Okta == nil && c.Multi == nil {
+	if c.LDAP == nil && c.Naive == nil && c.OnlyAPI == nil && c.Github == nil && c.Okta == nil && c.OIDC == nil && c.Multi == nil {
 		catcher.Add(errors.New("must specify one form of authentication"))
 	}
 
 	catcher.Add(c.checkDuplicateUsers())
 
+	if c.OIDC != nil {
+		catcher.Add(c.OIDC.ValidateAndDefault())
+	}
+
 	if c.OnlyAPI != nil {
 		// Generate API key if none are explicitly set.
 		for i := range c.OnlyAPI.Users {
@@ -203,6 +272,8 @@ func (c *AuthConfig) ValidateAndDefault() error {
 				catcher.NewWhen(c.LDAP == nil, "LDAP settings cannot be empty if using in multi auth")
 			case AuthOktaKey:
 				catcher.NewWhen(c.Okta == nil, "Okta settings cannot be empty if using in multi auth")
+			case AuthOIDCKey:
+				catcher.NewWhen(c.OIDC == nil, "OIDC settings cannot be empty if using in multi auth")
 			case AuthGithubKey:
 				catcher.NewWhen(c.Github == nil, "GitHub settings cannot be empty if using in multi auth")
 			case AuthNaiveKey:
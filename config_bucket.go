@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -18,9 +19,16 @@ const (
 // BucketConfig represents the admin config section for bucket storage.
 type BucketConfig struct {
 	LogBucket Bucket `bson:"log_bucket" json:"log_bucket" yaml:"log_bucket"`
+	// ArtifactBuckets are the buckets that tasks upload artifacts to that
+	// Evergreen can delete files from once they pass their project's
+	// artifact retention period.
+	ArtifactBuckets []ArtifactBucket `bson:"artifact_buckets" json:"artifact_buckets" yaml:"artifact_buckets"`
 }
 
-var bucketConfigLogBucketKey = bsonutil.MustHaveTag(BucketConfig{}, "LogBucket")
+var (
+	bucketConfigLogBucketKey       = bsonutil.MustHaveTag(BucketConfig{}, "LogBucket")
+	bucketConfigArtifactBucketsKey = bsonutil.MustHaveTag(BucketConfig{}, "ArtifactBuckets")
+)
 
 // Bucket represents the admin config for an individual bucket.
 type Bucket struct {
@@ -28,6 +36,37 @@ type Bucket struct {
 	Type string `bson:"type" json:"type" yaml:"type"`
 }
 
+// ArtifactBucket is an S3 bucket that tasks upload artifacts to, along with
+// the credentials that Evergreen deletes expired artifacts from it with.
+type ArtifactBucket struct {
+	Name string `bson:"name" json:"name" yaml:"name"`
+	// Region is the bucket's region. If it's not set, the region that the
+	// file was uploaded to is used, or else it's looked up.
+	Region string `bson:"region" json:"region" yaml:"region"`
+	Key    string `bson:"key" json:"key" yaml:"key"`
+	Secret string `bson:"secret" json:"secret" yaml:"secret"`
+}
+
+// GetArtifactBucket returns the artifact bucket with the given name, or nil
+// if it's not configured.
+func (c *BucketConfig) GetArtifactBucket(name string) *ArtifactBucket {
+	for i := range c.ArtifactBuckets {
+		if c.ArtifactBuckets[i].Name == name {
+			return &c.ArtifactBuckets[i]
+		}
+	}
+	return nil
+}
+
+// ArtifactBucketNames returns the names of the configured artifact buckets.
+func (c *BucketConfig) ArtifactBucketNames() []string {
+	names := make([]string, 0, len(c.ArtifactBuckets))
+	for _, b := range c.ArtifactBuckets {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
 func (*BucketConfig) SectionId() string { return "buckets" }
 
 func (c *BucketConfig) Get(ctx context.Context) error {
@@ -54,7 +93,8 @@ func (c *BucketConfig) Set(ctx context.Context) error {
 
 	_, err := coll.UpdateOne(ctx, byId(c.SectionId()), bson.M{
 		"$set": bson.M{
-			bucketConfigLogBucketKey: c.LogBucket,
+			bucketConfigLogBucketKey:       c.LogBucket,
+			bucketConfigArtifactBucketsKey: c.ArtifactBuckets,
 		},
 	}, options.Update().SetUpsert(true))
 
@@ -66,5 +106,14 @@ func (c *BucketConfig) ValidateAndDefault() error {
 		c.LogBucket.Type = BucketTypeS3
 	}
 
-	return nil
+	catcher := grip.NewBasicCatcher()
+	names := map[string]bool{}
+	for _, b := range c.ArtifactBuckets {
+		catcher.NewWhen(b.Name == "", "artifact bucket name cannot be empty")
+		catcher.ErrorfWhen(names[b.Name], "artifact bucket '%s' is configured more than once", b.Name)
+		catcher.ErrorfWhen(b.Key == "" || b.Secret == "", "artifact bucket '%s' must have a key and secret", b.Name)
+		names[b.Name] = true
+	}
+
+	return catcher.Resolve()
 }
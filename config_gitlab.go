@@ -0,0 +1,82 @@
+package evergreen
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GitlabConfig configures the integration with a GitLab instance, which is
+// used to test merge requests for projects hosted on GitLab.
+type GitlabConfig struct {
+	// URL is the base URL of the GitLab instance (e.g.
+	// https://gitlab.example.com).
+	URL string `bson:"url" json:"url" yaml:"url"`
+	// Token is a GitLab access token with the api scope, which is used to
+	// read merge requests, files and project members and to set commit
+	// statuses.
+	Token string `bson:"token" json:"token" yaml:"token"`
+}
+
+func (c *GitlabConfig) SectionId() string { return "gitlab" }
+
+func (c *GitlabConfig) Get(ctx context.Context) error {
+	res := GetEnvironment().DB().Collection(ConfigCollection).FindOne(ctx, byId(c.SectionId()))
+	if err := res.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			*c = GitlabConfig{}
+			return nil
+		}
+		return errors.Wrapf(err, "getting config section '%s'", c.SectionId())
+	}
+
+	if err := res.Decode(&c); err != nil {
+		return errors.Wrapf(err, "decoding config section '%s'", c.SectionId())
+	}
+
+	return nil
+}
+
+func (c *GitlabConfig) Set(ctx context.Context) error {
+	_, err := GetEnvironment().DB().Collection(ConfigCollection).UpdateOne(ctx, byId(c.SectionId()), bson.M{
+		"$set": bson.M{
+			"url":   c.URL,
+			"token": c.Token,
+		},
+	}, options.Update().SetUpsert(true))
+
+	return errors.Wrapf(err, "updating config section '%s'", c.SectionId())
+}
+
+func (c *GitlabConfig) ValidateAndDefault() error {
+	c.URL = strings.TrimSuffix(c.URL, "/")
+	if c.URL == "" {
+		if c.Token != "" {
+			return errors.New("GitLab URL must be set if a token is set")
+		}
+		return nil
+	}
+	if _, err := url.ParseRequestURI(c.URL); err != nil {
+		return errors.Wrapf(err, "parsing GitLab URL '%s'", c.URL)
+	}
+	return nil
+}
+
+// IsConfigured returns whether Evergreen can call the GitLab API.
+func (c *GitlabConfig) IsConfigured() bool {
+	return c.URL != "" && c.Token != ""
+}
+
+// Host returns the lowercased host name of the GitLab instance.
+func (c *GitlabConfig) Host() string {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
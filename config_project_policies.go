@@ -0,0 +1,161 @@
+package evergreen
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProjectPolicySeverity determines what happens when a project configuration
+// violates a policy.
+type ProjectPolicySeverity string
+
+const (
+	// ProjectPolicySeverityWarn reports violations as validation warnings.
+	ProjectPolicySeverityWarn ProjectPolicySeverity = "warn"
+	// ProjectPolicySeverityBlock reports violations as validation errors,
+	// which prevent versions and patches from being created.
+	ProjectPolicySeverityBlock ProjectPolicySeverity = "block"
+)
+
+// ProjectPolicyTarget is the part of the project configuration that a policy
+// is evaluated against.
+type ProjectPolicyTarget string
+
+const (
+	// ProjectPolicyTargetProject evaluates the policy once against the
+	// top-level project settings.
+	ProjectPolicyTargetProject ProjectPolicyTarget = "project"
+	// ProjectPolicyTargetVariant evaluates the policy against each build
+	// variant.
+	ProjectPolicyTargetVariant ProjectPolicyTarget = "variant"
+	// ProjectPolicyTargetTask evaluates the policy against each task in each
+	// build variant that it runs on.
+	ProjectPolicyTargetTask ProjectPolicyTarget = "task"
+	// ProjectPolicyTargetCommand evaluates the policy against each command in
+	// the project's tasks, functions, and pre, post, and timeout blocks.
+	ProjectPolicyTargetCommand ProjectPolicyTarget = "command"
+)
+
+var validProjectPolicyTargets = []ProjectPolicyTarget{
+	ProjectPolicyTargetProject,
+	ProjectPolicyTargetVariant,
+	ProjectPolicyTargetTask,
+	ProjectPolicyTargetCommand,
+}
+
+// ProjectPolicy is an org-wide rule that project configurations must follow.
+type ProjectPolicy struct {
+	// Name uniquely identifies the policy.
+	Name string `bson:"name" json:"name" yaml:"name"`
+	// Description explains the policy to users whose configuration violates
+	// it.
+	Description string                `bson:"description" json:"description" yaml:"description"`
+	Severity    ProjectPolicySeverity `bson:"severity" json:"severity" yaml:"severity"`
+	Target      ProjectPolicyTarget   `bson:"target" json:"target" yaml:"target"`
+	// Projects restricts the policy to the given project IDs or identifiers.
+	// If empty, the policy applies to all projects.
+	Projects []string `bson:"projects" json:"projects" yaml:"projects"`
+	// When is an optional expression that selects the targets the policy
+	// applies to.
+	When string `bson:"when" json:"when" yaml:"when"`
+	// Condition is the expression that every selected target must satisfy.
+	Condition string `bson:"condition" json:"condition" yaml:"condition"`
+}
+
+// AppliesToProject returns whether the policy applies to the project with
+// the given ID and identifier.
+func (p *ProjectPolicy) AppliesToProject(id, identifier string) bool {
+	if len(p.Projects) == 0 {
+		return true
+	}
+	for _, project := range p.Projects {
+		if project != "" && (project == id || project == identifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the policy is well-formed and that its expressions
+// compile.
+func (p *ProjectPolicy) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(p.Name == "", "policy name must be set")
+	catcher.ErrorfWhen(p.Severity != ProjectPolicySeverityWarn && p.Severity != ProjectPolicySeverityBlock,
+		"invalid severity '%s', must be '%s' or '%s'", p.Severity, ProjectPolicySeverityWarn, ProjectPolicySeverityBlock)
+	validTarget := false
+	for _, target := range validProjectPolicyTargets {
+		if p.Target == target {
+			validTarget = true
+		}
+	}
+	catcher.ErrorfWhen(!validTarget, "invalid target '%s', must be one of: %v", p.Target, validProjectPolicyTargets)
+	if p.When != "" {
+		_, err := util.CompilePolicyExpression(p.When)
+		catcher.Wrap(err, "invalid when expression")
+	}
+	if p.Condition == "" {
+		catcher.New("condition must be set")
+	} else {
+		_, err := util.CompilePolicyExpression(p.Condition)
+		catcher.Wrap(err, "invalid condition expression")
+	}
+	return catcher.Resolve()
+}
+
+// ProjectPoliciesConfig holds the policies that all project configurations
+// are checked against when versions and patches are created and when
+// configurations are validated.
+type ProjectPoliciesConfig struct {
+	Policies []ProjectPolicy `bson:"policies" json:"policies" yaml:"policies"`
+}
+
+func (c *ProjectPoliciesConfig) SectionId() string { return "project_policies" }
+
+func (c *ProjectPoliciesConfig) Get(ctx context.Context) error {
+	res := GetEnvironment().DB().Collection(ConfigCollection).FindOne(ctx, byId(c.SectionId()))
+	if err := res.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			*c = ProjectPoliciesConfig{}
+			return nil
+		}
+		return errors.Wrapf(err, "getting config section '%s'", c.SectionId())
+	}
+
+	if err := res.Decode(&c); err != nil {
+		return errors.Wrapf(err, "decoding config section '%s'", c.SectionId())
+	}
+
+	return nil
+}
+
+func (c *ProjectPoliciesConfig) Set(ctx context.Context) error {
+	_, err := GetEnvironment().DB().Collection(ConfigCollection).UpdateOne(ctx, byId(c.SectionId()), bson.M{
+		"$set": bson.M{
+			"policies": c.Policies,
+		},
+	}, options.Update().SetUpsert(true))
+
+	return errors.Wrapf(err, "updating config section '%s'", c.SectionId())
+}
+
+func (c *ProjectPoliciesConfig) ValidateAndDefault() error {
+	catcher := grip.NewBasicCatcher()
+	names := []string{}
+	for i := range c.Policies {
+		if c.Policies[i].Severity == "" {
+			c.Policies[i].Severity = ProjectPolicySeverityWarn
+		}
+		catcher.Wrapf(c.Policies[i].Validate(), "invalid policy '%s'", c.Policies[i].Name)
+		catcher.ErrorfWhen(utility.StringSliceContains(names, c.Policies[i].Name), "duplicate policy name '%s'", c.Policies[i].Name)
+		names = append(names, c.Policies[i].Name)
+	}
+	return catcher.Resolve()
+}
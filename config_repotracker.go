@@ -14,6 +14,10 @@ type RepoTrackerConfig struct {
 	NumNewRepoRevisionsToFetch int `bson:"revs_to_fetch" json:"revs_to_fetch" yaml:"numnewreporevisionstofetch"`
 	MaxRepoRevisionsToSearch   int `bson:"max_revs_to_search" json:"max_revs_to_search" yaml:"maxreporevisionstosearch"`
 	MaxConcurrentRequests      int `bson:"max_con_requests" json:"max_con_requests" yaml:"maxconcurrentrequests"`
+	// AllowLocalGitURLs allows projects to track local repositories, given as
+	// absolute paths or file:// URLs, which the repotracker reads from the
+	// app server's file system.
+	AllowLocalGitURLs bool `bson:"allow_local_git_urls" json:"allow_local_git_urls" yaml:"allow_local_git_urls"`
 }
 
 func (c *RepoTrackerConfig) SectionId() string { return "repotracker" }
@@ -38,9 +42,10 @@ func (c *RepoTrackerConfig) Get(ctx context.Context) error {
 func (c *RepoTrackerConfig) Set(ctx context.Context) error {
 	_, err := GetEnvironment().DB().Collection(ConfigCollection).UpdateOne(ctx, byId(c.SectionId()), bson.M{
 		"$set": bson.M{
-			"revs_to_fetch":      c.NumNewRepoRevisionsToFetch,
-			"max_revs_to_search": c.MaxRepoRevisionsToSearch,
-			"max_con_requests":   c.MaxConcurrentRequests,
+			"revs_to_fetch":        c.NumNewRepoRevisionsToFetch,
+			"max_revs_to_search":   c.MaxRepoRevisionsToSearch,
+			"max_con_requests":     c.MaxConcurrentRequests,
+			"allow_local_git_urls": c.AllowLocalGitURLs,
 		},
 	}, options.Update().SetUpsert(true))
 
@@ -0,0 +1,122 @@
+package evergreen
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultVaultMountPath = "secret"
+
+// SecretsConfig configures how project variables are protected at rest and
+// the external secret stores that project variables can be resolved from.
+type SecretsConfig struct {
+	// EncryptionEnabled enables envelope encryption of project variables.
+	// Each project's variables are encrypted with a data key, which is
+	// itself encrypted with a key-encryption key from either KMS or the
+	// local keyring. Variables that are already encrypted can still be read
+	// after this is disabled, as long as their key-encryption key is still
+	// configured.
+	EncryptionEnabled bool `bson:"encryption_enabled" json:"encryption_enabled" yaml:"encryption_enabled"`
+	// KMSKeyID is the ID or ARN of the AWS KMS key that encrypts data keys.
+	KMSKeyID string `bson:"kms_key_id" json:"kms_key_id" yaml:"kms_key_id"`
+	// KMSRegion is the AWS region of the KMS key.
+	KMSRegion string `bson:"kms_region" json:"kms_region" yaml:"kms_region"`
+	// KeyringFile is the path on the app servers to a YAML file containing
+	// the local key-encryption keys, which is used if KMS is not configured.
+	KeyringFile string `bson:"keyring_file" json:"keyring_file" yaml:"keyring_file"`
+
+	// Vault configures HashiCorp Vault as an external secret store.
+	Vault VaultConfig `bson:"vault" json:"vault" yaml:"vault"`
+	// FileStoreDirectory is the directory on the app servers containing
+	// secrets for the file-based external secret store, one secret per
+	// file.
+	FileStoreDirectory string `bson:"file_store_directory" json:"file_store_directory" yaml:"file_store_directory"`
+
+	// ProvenanceKeyFile is the path on the app servers to a PEM-encoded
+	// PKCS #8 Ed25519 private key, which signs provenance statements for
+	// the files that tasks attach. If it's not set, provenance isn't
+	// recorded.
+	ProvenanceKeyFile string `bson:"provenance_key_file" json:"provenance_key_file" yaml:"provenance_key_file"`
+}
+
+// VaultConfig configures access to a HashiCorp Vault KV version 2 secrets
+// engine.
+type VaultConfig struct {
+	// URL is the base URL of the Vault server.
+	URL string `bson:"url" json:"url" yaml:"url"`
+	// Token is the Vault token used to read secrets.
+	Token string `bson:"token" json:"token" yaml:"token"`
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string `bson:"namespace" json:"namespace" yaml:"namespace"`
+	// MountPath is the path the KV secrets engine is mounted at. Defaults to
+	// "secret".
+	MountPath string `bson:"mount_path" json:"mount_path" yaml:"mount_path"`
+}
+
+// IsConfigured returns whether Evergreen can read secrets from Vault.
+func (c *VaultConfig) IsConfigured() bool {
+	return c.URL != "" && c.Token != ""
+}
+
+func (c *SecretsConfig) SectionId() string { return "secrets" }
+
+func (c *SecretsConfig) Get(ctx context.Context) error {
+	res := GetEnvironment().DB().Collection(ConfigCollection).FindOne(ctx, byId(c.SectionId()))
+	if err := res.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			*c = SecretsConfig{}
+			return nil
+		}
+		return errors.Wrapf(err, "getting config section '%s'", c.SectionId())
+	}
+
+	if err := res.Decode(&c); err != nil {
+		return errors.Wrapf(err, "decoding config section '%s'", c.SectionId())
+	}
+
+	return nil
+}
+
+func (c *SecretsConfig) Set(ctx context.Context) error {
+	_, err := GetEnvironment().DB().Collection(ConfigCollection).UpdateOne(ctx, byId(c.SectionId()), bson.M{
+		"$set": bson.M{
+			"encryption_enabled":   c.EncryptionEnabled,
+			"kms_key_id":           c.KMSKeyID,
+			"kms_region":           c.KMSRegion,
+			"keyring_file":         c.KeyringFile,
+			"vault":                c.Vault,
+			"file_store_directory": c.FileStoreDirectory,
+			"provenance_key_file":  c.ProvenanceKeyFile,
+		},
+	}, options.Update().SetUpsert(true))
+
+	return errors.Wrapf(err, "updating config section '%s'", c.SectionId())
+}
+
+func (c *SecretsConfig) ValidateAndDefault() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(c.EncryptionEnabled && c.KMSKeyID == "" && c.KeyringFile == "",
+		"either a KMS key or a keyring file must be set to enable project variable encryption")
+	catcher.NewWhen(c.KMSKeyID != "" && c.KeyringFile != "", "cannot set both a KMS key and a keyring file")
+	catcher.NewWhen(c.KMSKeyID != "" && c.KMSRegion == "", "KMS region must be set if a KMS key is set")
+
+	c.Vault.URL = strings.TrimSuffix(c.Vault.URL, "/")
+	if c.Vault.URL != "" {
+		_, err := url.ParseRequestURI(c.Vault.URL)
+		catcher.Wrapf(err, "parsing Vault URL '%s'", c.Vault.URL)
+	}
+	catcher.NewWhen(c.Vault.URL == "" && c.Vault.Token != "", "Vault URL must be set if a Vault token is set")
+	c.Vault.MountPath = strings.Trim(c.Vault.MountPath, "/")
+	if c.Vault.MountPath == "" {
+		c.Vault.MountPath = defaultVaultMountPath
+	}
+
+	return catcher.Resolve()
+}
@@ -24,6 +24,7 @@ func NewConfigSections() ConfigSections {
 	sections := []ConfigSection{
 		&AmboyConfig{},
 		&APIConfig{},
+		&AuditLogConfig{},
 		&AuthConfig{},
 		&BucketConfig{},
 		&CedarConfig{},
@@ -31,6 +32,7 @@ func NewConfigSections() ConfigSections {
 		&CommitQueueConfig{},
 		&ContainerPoolsConfig{},
 		&DataPipesConfig{},
+		&GitlabConfig{},
 		&HostInitConfig{},
 		&HostJasperConfig{},
 		&JiraConfig{},
@@ -39,8 +41,10 @@ func NewConfigSections() ConfigSections {
 		&NotifyConfig{},
 		&PodLifecycleConfig{},
 		&ProjectCreationConfig{},
+		&ProjectPoliciesConfig{},
 		&RepoTrackerConfig{},
 		&SchedulerConfig{},
+		&SecretsConfig{},
 		&ServiceFlags{},
 		&SlackConfig{},
 		&SplunkConfig{},
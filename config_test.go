@@ -330,6 +330,19 @@ func (s *AdminSuite) TestAuthConfig() {
 			UserGroup:          "group",
 			ExpireAfterMinutes: 60,
 		},
+		OIDC: &OIDCConfig{
+			Issuer:        "https://issuer.example.com",
+			ClientID:      "id",
+			ClientSecret:  "secret",
+			Scopes:        []string{"openid", "email"},
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+			UserGroup:     "group",
+			GroupRoles: []OIDCGroupRoles{
+				{Group: "admins", Roles: []string{"superuser"}},
+			},
+			ExpireAfterMinutes: 60,
+		},
 		Naive: &NaiveAuthConfig{
 			Users: []AuthUser{{Username: "user", Password: "pw"}},
 		},
@@ -1041,6 +1054,7 @@ func (s *AdminSuite) TestBucketConfig() {
 			Name: "logs",
 			Type: "s3",
 		},
+		ArtifactBuckets: []ArtifactBucket{{Name: "artifacts", Region: "us-east-1", Key: "key", Secret: "secret"}},
 	}
 
 	err := config.Set(ctx)
@@ -1058,3 +1072,79 @@ func (s *AdminSuite) TestBucketConfig() {
 	s.NotNil(settings)
 	s.Equal(config, settings.Buckets)
 }
+
+func TestBucketConfigValidateAndDefault(t *testing.T) {
+	conf := BucketConfig{ArtifactBuckets: []ArtifactBucket{{Name: "artifacts", Key: "key", Secret: "secret"}}}
+	assert.NoError(t, conf.ValidateAndDefault())
+	assert.Equal(t, BucketTypeS3, conf.LogBucket.Type)
+	assert.NotNil(t, conf.GetArtifactBucket("artifacts"))
+	assert.Nil(t, conf.GetArtifactBucket("other"))
+
+	conf.ArtifactBuckets = append(conf.ArtifactBuckets, ArtifactBucket{Name: "artifacts", Key: "key", Secret: "secret"})
+	assert.Error(t, conf.ValidateAndDefault(), "duplicate bucket should be invalid")
+
+	conf.ArtifactBuckets = []ArtifactBucket{{Name: "artifacts"}}
+	assert.Error(t, conf.ValidateAndDefault(), "bucket without credentials should be invalid")
+}
+
+func (s *AdminSuite) TestAuditLogConfig() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := AuditLogConfig{
+		SinkType: AuditLogSinkFile,
+		FilePath: "/var/log/evergreen/audit.log",
+	}
+	s.NoError(config.ValidateAndDefault())
+	s.NoError(config.Set(ctx))
+
+	settings, err := GetConfig(ctx)
+	s.NoError(err)
+	s.NotNil(settings)
+	s.Equal(config, settings.AuditLog)
+
+	config.FilePath = "audit.log"
+	s.Error(config.ValidateAndDefault(), "file path should be absolute")
+
+	config = AuditLogConfig{SinkType: AuditLogSinkSyslog}
+	s.NoError(config.ValidateAndDefault())
+	s.Equal(defaultAuditLogSyslogTag, config.SyslogTag)
+
+	config.SyslogNetwork = "udp"
+	s.Error(config.ValidateAndDefault(), "syslog address should be required with network")
+
+	config = AuditLogConfig{SinkType: "kafka"}
+	s.Error(config.ValidateAndDefault())
+}
+
+func (s *AdminSuite) TestProjectPoliciesConfig() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := ProjectPoliciesConfig{
+		Policies: []ProjectPolicy{
+			{
+				Name:      "max-exec-timeout",
+				Target:    ProjectPolicyTargetTask,
+				Condition: "exec_timeout_secs <= 7200",
+			},
+		},
+	}
+	s.NoError(config.ValidateAndDefault())
+	s.Equal(ProjectPolicySeverityWarn, config.Policies[0].Severity)
+	s.NoError(config.Set(ctx))
+
+	settings, err := GetConfig(ctx)
+	s.NoError(err)
+	s.NotNil(settings)
+	s.Equal(config, settings.ProjectPolicies)
+
+	config.Policies = append(config.Policies, config.Policies[0])
+	s.Error(config.ValidateAndDefault(), "policy names should be unique")
+
+	config.Policies = []ProjectPolicy{{Name: "bad", Target: ProjectPolicyTargetTask, Condition: "exec_timeout_secs <="}}
+	s.Error(config.ValidateAndDefault(), "condition should compile")
+
+	config.Policies = []ProjectPolicy{{Name: "bad", Target: "host", Condition: "true"}}
+	s.Error(config.ValidateAndDefault(), "target should be valid")
+}
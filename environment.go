@@ -742,6 +742,14 @@ func (e *envState) initSenders(ctx context.Context) error {
 		e.senders[SenderGithubStatus] = sender
 	}
 
+	if gitlab := &e.settings.Gitlab; gitlab.IsConfigured() {
+		sender, err = util.NewGitlabStatusLogger(gitlab.URL, gitlab.Token)
+		if err != nil {
+			return errors.Wrap(err, "setting up GitLab status logger")
+		}
+		e.senders[SenderGitlabStatus] = sender
+	}
+
 	if jira := &e.settings.Jira; len(jira.GetHostURL()) != 0 {
 		sender, err = send.NewJiraLogger(ctx, jira.Export(), levelInfo)
 		if err != nil {
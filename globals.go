@@ -14,6 +14,7 @@ const (
 	User            = "mci"
 	GithubPatchUser = "github_pull_request"
 	GithubMergeUser = "github_merge_queue"
+	GitlabPatchUser = "gitlab_merge_request"
 	ParentPatchUser = "parent_patch"
 
 	HostRunning       = "running"
@@ -211,6 +212,12 @@ const (
 	DefaultTaskActivator   = ""
 	StepbackTaskActivator  = "stepback"
 	APIServerTaskActivator = "apiserver"
+	// RetryPolicyTaskActivator is the caller that restarts tasks according to
+	// their retry policy.
+	RetryPolicyTaskActivator = "retry-policy"
+	// TestShardingTaskActivator is the caller that deactivates shard tasks
+	// that are not needed to meet their target duration.
+	TestShardingTaskActivator = "test-sharding"
 
 	// StaleContainerTaskMonitor is the special name representing the unit
 	// responsible for monitoring container tasks that have not dispatched but
@@ -617,6 +624,7 @@ const (
 	MergeTestRequester          = "merge_test"           // Evergreen commit queue
 	AdHocRequester              = "ad_hoc"               // periodic build
 	GithubMergeRequester        = "github_merge_request" // GitHub merge queue
+	GitlabMRRequester           = "gitlab_merge_request" // GitLab merge request
 )
 
 var AllRequesterTypes = []string{
@@ -628,6 +636,7 @@ var AllRequesterTypes = []string{
 	MergeTestRequester,
 	AdHocRequester,
 	GithubMergeRequester,
+	GitlabMRRequester,
 }
 
 // Constants related to requester types.
@@ -668,12 +677,13 @@ const (
 	SenderJIRAComment
 	SenderEmail
 	SenderGeneric
+	SenderGitlabStatus
 )
 
 func (k SenderKey) Validate() error {
 	switch k {
 	case SenderGithubStatus, SenderEvergreenWebhook, SenderSlack, SenderJIRAComment, SenderJIRAIssue,
-		SenderEmail, SenderGeneric:
+		SenderEmail, SenderGeneric, SenderGitlabStatus:
 		return nil
 	default:
 		return errors.New("invalid sender defined")
@@ -696,6 +706,8 @@ func (k SenderKey) String() string {
 		return "jira-issue"
 	case SenderGeneric:
 		return "generic"
+	case SenderGitlabStatus:
+		return "gitlab-status"
 	default:
 		return "<error:unknown>"
 	}
@@ -724,6 +736,7 @@ var (
 		GithubPRRequester,
 		MergeTestRequester,
 		GithubMergeRequester,
+		GitlabMRRequester,
 	}
 
 	SystemActivators = []string{
@@ -906,13 +919,18 @@ func IsSystemActivator(caller string) bool {
 }
 
 func IsPatchRequester(requester string) bool {
-	return requester == PatchVersionRequester || IsGitHubPatchRequester(requester)
+	return requester == PatchVersionRequester || IsGitHubPatchRequester(requester) || IsGitlabMRRequester(requester)
 }
 
 func IsGitHubPatchRequester(requester string) bool {
 	return requester == GithubPRRequester || requester == MergeTestRequester || requester == GithubMergeRequester
 }
 
+// IsGitlabMRRequester returns true if the requester is a GitLab merge request.
+func IsGitlabMRRequester(requester string) bool {
+	return requester == GitlabMRRequester
+}
+
 func IsGitTagRequester(requester string) bool {
 	return requester == GitTagRequester
 }
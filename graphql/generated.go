@@ -298,6 +298,7 @@ type ComplexityRoot struct {
 	}
 
 	File struct {
+		Expired    func(childComplexity int) int
 		Link       func(childComplexity int) int
 		Name       func(childComplexity int) int
 		Visibility func(childComplexity int) int
@@ -805,6 +806,12 @@ type ComplexityRoot struct {
 		Script func(childComplexity int) int
 	}
 
+	ProcessUsage struct {
+		Command  func(childComplexity int) int
+		PID      func(childComplexity int) int
+		RSSBytes func(childComplexity int) int
+	}
+
 	Project struct {
 		Admins                   func(childComplexity int) int
 		Banner                   func(childComplexity int) int
@@ -1035,6 +1042,19 @@ type ComplexityRoot struct {
 		VirtualMemoryKB func(childComplexity int) int
 	}
 
+	ResourceUsageSample struct {
+		CPUPercent           func(childComplexity int) int
+		DiskReadBytes        func(childComplexity int) int
+		DiskWriteBytes       func(childComplexity int) int
+		MemoryAvailableBytes func(childComplexity int) int
+		MemoryRSSBytes       func(childComplexity int) int
+		NetworkRecvBytes     func(childComplexity int) int
+		NetworkSentBytes     func(childComplexity int) int
+		NumProcesses         func(childComplexity int) int
+		Processes            func(childComplexity int) int
+		Time                 func(childComplexity int) int
+	}
+
 	SaveDistroPayload struct {
 		Distro    func(childComplexity int) int
 		HostCount func(childComplexity int) int
@@ -1158,6 +1178,7 @@ type ComplexityRoot struct {
 		ProjectIdentifier       func(childComplexity int) int
 		Requester               func(childComplexity int) int
 		ResetWhenFinished       func(childComplexity int) int
+		ResourceUsage           func(childComplexity int) int
 		Revision                func(childComplexity int) int
 		ScheduledTime           func(childComplexity int) int
 		SpawnHostLink           func(childComplexity int) int
@@ -1266,6 +1287,12 @@ type ComplexityRoot struct {
 		Version          func(childComplexity int) int
 	}
 
+	TaskResourceUsage struct {
+		Execution func(childComplexity int) int
+		Samples   func(childComplexity int) int
+		TaskID    func(childComplexity int) int
+	}
+
 	TaskSpecifier struct {
 		PatchAlias   func(childComplexity int) int
 		TaskRegex    func(childComplexity int) int
@@ -1724,6 +1751,8 @@ type TaskResolver interface {
 
 	ProjectIdentifier(ctx context.Context, obj *model.APITask) (*string, error)
 
+	ResourceUsage(ctx context.Context, obj *model.APITask) (*model.APITaskResourceUsage, error)
+
 	SpawnHostLink(ctx context.Context, obj *model.APITask) (*string, error)
 
 	Status(ctx context.Context, obj *model.APITask) (string, error)
@@ -2725,6 +2754,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ExternalLinkForMetadata.URL(childComplexity), true
 
+	case "File.expired":
+		if e.complexity.File.Expired == nil {
+			break
+		}
+
+		return e.complexity.File.Expired(childComplexity), true
+
 	case "File.link":
 		if e.complexity.File.Link == nil {
 			break
@@ -5358,6 +5394,27 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.PreconditionScript.Script(childComplexity), true
 
+	case "ProcessUsage.command":
+		if e.complexity.ProcessUsage.Command == nil {
+			break
+		}
+
+		return e.complexity.ProcessUsage.Command(childComplexity), true
+
+	case "ProcessUsage.pid":
+		if e.complexity.ProcessUsage.PID == nil {
+			break
+		}
+
+		return e.complexity.ProcessUsage.PID(childComplexity), true
+
+	case "ProcessUsage.rssBytes":
+		if e.complexity.ProcessUsage.RSSBytes == nil {
+			break
+		}
+
+		return e.complexity.ProcessUsage.RSSBytes(childComplexity), true
+
 	case "Project.admins":
 		if e.complexity.Project.Admins == nil {
 			break
@@ -6751,6 +6808,76 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ResourceLimits.VirtualMemoryKB(childComplexity), true
 
+	case "ResourceUsageSample.cpuPercent":
+		if e.complexity.ResourceUsageSample.CPUPercent == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.CPUPercent(childComplexity), true
+
+	case "ResourceUsageSample.diskReadBytes":
+		if e.complexity.ResourceUsageSample.DiskReadBytes == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.DiskReadBytes(childComplexity), true
+
+	case "ResourceUsageSample.diskWriteBytes":
+		if e.complexity.ResourceUsageSample.DiskWriteBytes == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.DiskWriteBytes(childComplexity), true
+
+	case "ResourceUsageSample.memoryAvailableBytes":
+		if e.complexity.ResourceUsageSample.MemoryAvailableBytes == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.MemoryAvailableBytes(childComplexity), true
+
+	case "ResourceUsageSample.memoryRssBytes":
+		if e.complexity.ResourceUsageSample.MemoryRSSBytes == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.MemoryRSSBytes(childComplexity), true
+
+	case "ResourceUsageSample.networkRecvBytes":
+		if e.complexity.ResourceUsageSample.NetworkRecvBytes == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.NetworkRecvBytes(childComplexity), true
+
+	case "ResourceUsageSample.networkSentBytes":
+		if e.complexity.ResourceUsageSample.NetworkSentBytes == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.NetworkSentBytes(childComplexity), true
+
+	case "ResourceUsageSample.numProcesses":
+		if e.complexity.ResourceUsageSample.NumProcesses == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.NumProcesses(childComplexity), true
+
+	case "ResourceUsageSample.processes":
+		if e.complexity.ResourceUsageSample.Processes == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.Processes(childComplexity), true
+
+	case "ResourceUsageSample.time":
+		if e.complexity.ResourceUsageSample.Time == nil {
+			break
+		}
+
+		return e.complexity.ResourceUsageSample.Time(childComplexity), true
+
 	case "SaveDistroPayload.distro":
 		if e.complexity.SaveDistroPayload.Distro == nil {
 			break
@@ -7395,6 +7522,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Task.ResetWhenFinished(childComplexity), true
 
+	case "Task.resourceUsage":
+		if e.complexity.Task.ResourceUsage == nil {
+			break
+		}
+
+		return e.complexity.Task.ResourceUsage(childComplexity), true
+
 	case "Task.revision":
 		if e.complexity.Task.Revision == nil {
 			break
@@ -7911,6 +8045,27 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.TaskQueueItem.Version(childComplexity), true
 
+	case "TaskResourceUsage.execution":
+		if e.complexity.TaskResourceUsage.Execution == nil {
+			break
+		}
+
+		return e.complexity.TaskResourceUsage.Execution(childComplexity), true
+
+	case "TaskResourceUsage.samples":
+		if e.complexity.TaskResourceUsage.Samples == nil {
+			break
+		}
+
+		return e.complexity.TaskResourceUsage.Samples(childComplexity), true
+
+	case "TaskResourceUsage.taskId":
+		if e.complexity.TaskResourceUsage.TaskID == nil {
+			break
+		}
+
+		return e.complexity.TaskResourceUsage.TaskID(childComplexity), true
+
 	case "TaskSpecifier.patchAlias":
 		if e.complexity.TaskSpecifier.PatchAlias == nil {
 			break
@@ -17290,6 +17445,50 @@ func (ec *executionContext) fieldContext_ExternalLinkForMetadata_displayName(ctx
 	return fc, nil
 }
 
+func (ec *executionContext) _File_expired(ctx context.Context, field graphql.CollectedField, obj *model.APIFile) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_File_expired(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Expired, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(bool)
+	fc.Result = res
+	return ec.marshalNBoolean2bool(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_File_expired(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "File",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Boolean does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _File_link(ctx context.Context, field graphql.CollectedField, obj *model.APIFile) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_File_link(ctx, field)
 	if err != nil {
@@ -18844,6 +19043,8 @@ func (ec *executionContext) fieldContext_GroupedBuildVariant_tasks(ctx context.C
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -18957,6 +19158,8 @@ func (ec *executionContext) fieldContext_GroupedFiles_files(ctx context.Context,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
+			case "expired":
+				return ec.fieldContext_File_expired(ctx, field)
 			case "link":
 				return ec.fieldContext_File_link(ctx, field)
 			case "name":
@@ -23758,6 +23961,8 @@ func (ec *executionContext) fieldContext_LogkeeperBuild_task(ctx context.Context
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -26470,6 +26675,8 @@ func (ec *executionContext) fieldContext_Mutation_scheduleUndispatchedBaseTasks(
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -28881,6 +29088,8 @@ func (ec *executionContext) fieldContext_Mutation_abortTask(ctx context.Context,
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -29080,6 +29289,8 @@ func (ec *executionContext) fieldContext_Mutation_overrideTaskDependencies(ctx c
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -29279,6 +29490,8 @@ func (ec *executionContext) fieldContext_Mutation_restartTask(ctx context.Contex
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -29478,6 +29691,8 @@ func (ec *executionContext) fieldContext_Mutation_scheduleTasks(ctx context.Cont
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -29677,6 +29892,8 @@ func (ec *executionContext) fieldContext_Mutation_setTaskPriority(ctx context.Co
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -29876,6 +30093,8 @@ func (ec *executionContext) fieldContext_Mutation_unscheduleTask(ctx context.Con
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -35090,6 +35309,8 @@ func (ec *executionContext) fieldContext_Pod_task(ctx context.Context, field gra
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -35623,6 +35844,8 @@ func (ec *executionContext) fieldContext_PodEventLogData_task(ctx context.Contex
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -36166,6 +36389,138 @@ func (ec *executionContext) fieldContext_PreconditionScript_script(ctx context.C
 	return fc, nil
 }
 
+func (ec *executionContext) _ProcessUsage_command(ctx context.Context, field graphql.CollectedField, obj *model.APIProcessUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ProcessUsage_command(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Command, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalNString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ProcessUsage_command(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ProcessUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ProcessUsage_pid(ctx context.Context, field graphql.CollectedField, obj *model.APIProcessUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ProcessUsage_pid(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.PID, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ProcessUsage_pid(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ProcessUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ProcessUsage_rssBytes(ctx context.Context, field graphql.CollectedField, obj *model.APIProcessUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ProcessUsage_rssBytes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.RSSBytes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ProcessUsage_rssBytes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ProcessUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _Project_id(ctx context.Context, field graphql.CollectedField, obj *model.APIProjectRef) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_Project_id(ctx, field)
 	if err != nil {
@@ -42840,6 +43195,8 @@ func (ec *executionContext) fieldContext_Query_task(ctx context.Context, field g
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -43039,6 +43396,8 @@ func (ec *executionContext) fieldContext_Query_taskAllExecutions(ctx context.Con
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -47194,6 +47553,454 @@ func (ec *executionContext) fieldContext_ResourceLimits_virtualMemoryKb(ctx cont
 	return fc, nil
 }
 
+func (ec *executionContext) _ResourceUsageSample_cpuPercent(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_cpuPercent(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.CPUPercent, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(float64)
+	fc.Result = res
+	return ec.marshalNFloat2float64(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_cpuPercent(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Float does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_diskReadBytes(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_diskReadBytes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.DiskReadBytes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_diskReadBytes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_diskWriteBytes(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_diskWriteBytes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.DiskWriteBytes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_diskWriteBytes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_memoryAvailableBytes(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_memoryAvailableBytes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.MemoryAvailableBytes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_memoryAvailableBytes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_memoryRssBytes(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_memoryRssBytes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.MemoryRSSBytes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_memoryRssBytes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_networkRecvBytes(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_networkRecvBytes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.NetworkRecvBytes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_networkRecvBytes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_networkSentBytes(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_networkSentBytes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.NetworkSentBytes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_networkSentBytes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_numProcesses(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_numProcesses(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.NumProcesses, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_numProcesses(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_processes(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_processes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Processes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]model.APIProcessUsage)
+	fc.Result = res
+	return ec.marshalNProcessUsage2ᚕgithubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIProcessUsageᚄ(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_processes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "command":
+				return ec.fieldContext_ProcessUsage_command(ctx, field)
+			case "pid":
+				return ec.fieldContext_ProcessUsage_pid(ctx, field)
+			case "rssBytes":
+				return ec.fieldContext_ProcessUsage_rssBytes(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type ProcessUsage", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ResourceUsageSample_time(ctx context.Context, field graphql.CollectedField, obj *model.APIResourceUsageSample) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ResourceUsageSample_time(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Time, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*time.Time)
+	fc.Result = res
+	return ec.marshalNTime2ᚖtimeᚐTime(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ResourceUsageSample_time(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ResourceUsageSample",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Time does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _SaveDistroPayload_distro(ctx context.Context, field graphql.CollectedField, obj *SaveDistroPayload) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_SaveDistroPayload_distro(ctx, field)
 	if err != nil {
@@ -49358,6 +50165,8 @@ func (ec *executionContext) fieldContext_Task_baseTask(ctx context.Context, fiel
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -50430,6 +51239,8 @@ func (ec *executionContext) fieldContext_Task_displayTask(ctx context.Context, f
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -50785,6 +51596,8 @@ func (ec *executionContext) fieldContext_Task_executionTasksFull(ctx context.Con
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -51935,6 +52748,58 @@ func (ec *executionContext) fieldContext_Task_resetWhenFinished(ctx context.Cont
 	return fc, nil
 }
 
+func (ec *executionContext) _Task_resourceUsage(ctx context.Context, field graphql.CollectedField, obj *model.APITask) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Task_resourceUsage(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Task().ResourceUsage(rctx, obj)
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*model.APITaskResourceUsage)
+	fc.Result = res
+	return ec.marshalNTaskResourceUsage2ᚖgithubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPITaskResourceUsage(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Task_resourceUsage(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Task",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "execution":
+				return ec.fieldContext_TaskResourceUsage_execution(ctx, field)
+			case "samples":
+				return ec.fieldContext_TaskResourceUsage_samples(ctx, field)
+			case "taskId":
+				return ec.fieldContext_TaskResourceUsage_taskId(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type TaskResourceUsage", field.Name)
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _Task_revision(ctx context.Context, field graphql.CollectedField, obj *model.APITask) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_Task_revision(ctx, field)
 	if err != nil {
@@ -55283,6 +56148,160 @@ func (ec *executionContext) fieldContext_TaskQueueItem_version(ctx context.Conte
 	return fc, nil
 }
 
+func (ec *executionContext) _TaskResourceUsage_execution(ctx context.Context, field graphql.CollectedField, obj *model.APITaskResourceUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_TaskResourceUsage_execution(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Execution, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_TaskResourceUsage_execution(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "TaskResourceUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _TaskResourceUsage_samples(ctx context.Context, field graphql.CollectedField, obj *model.APITaskResourceUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_TaskResourceUsage_samples(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Samples, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]model.APIResourceUsageSample)
+	fc.Result = res
+	return ec.marshalNResourceUsageSample2ᚕgithubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIResourceUsageSampleᚄ(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_TaskResourceUsage_samples(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "TaskResourceUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "cpuPercent":
+				return ec.fieldContext_ResourceUsageSample_cpuPercent(ctx, field)
+			case "diskReadBytes":
+				return ec.fieldContext_ResourceUsageSample_diskReadBytes(ctx, field)
+			case "diskWriteBytes":
+				return ec.fieldContext_ResourceUsageSample_diskWriteBytes(ctx, field)
+			case "memoryAvailableBytes":
+				return ec.fieldContext_ResourceUsageSample_memoryAvailableBytes(ctx, field)
+			case "memoryRssBytes":
+				return ec.fieldContext_ResourceUsageSample_memoryRssBytes(ctx, field)
+			case "networkRecvBytes":
+				return ec.fieldContext_ResourceUsageSample_networkRecvBytes(ctx, field)
+			case "networkSentBytes":
+				return ec.fieldContext_ResourceUsageSample_networkSentBytes(ctx, field)
+			case "numProcesses":
+				return ec.fieldContext_ResourceUsageSample_numProcesses(ctx, field)
+			case "processes":
+				return ec.fieldContext_ResourceUsageSample_processes(ctx, field)
+			case "time":
+				return ec.fieldContext_ResourceUsageSample_time(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type ResourceUsageSample", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _TaskResourceUsage_taskId(ctx context.Context, field graphql.CollectedField, obj *model.APITaskResourceUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_TaskResourceUsage_taskId(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.TaskID, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalNString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_TaskResourceUsage_taskId(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "TaskResourceUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _TaskSpecifier_patchAlias(ctx context.Context, field graphql.CollectedField, obj *model.APITaskSpecifier) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_TaskSpecifier_patchAlias(ctx, field)
 	if err != nil {
@@ -57751,6 +58770,8 @@ func (ec *executionContext) fieldContext_UpstreamProject_task(ctx context.Contex
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -61299,6 +62320,8 @@ func (ec *executionContext) fieldContext_VersionTasks_data(ctx context.Context,
 				return ec.fieldContext_Task_requester(ctx, field)
 			case "resetWhenFinished":
 				return ec.fieldContext_Task_resetWhenFinished(ctx, field)
+			case "resourceUsage":
+				return ec.fieldContext_Task_resourceUsage(ctx, field)
 			case "revision":
 				return ec.fieldContext_Task_revision(ctx, field)
 			case "scheduledTime":
@@ -71043,6 +72066,13 @@ func (ec *executionContext) _File(ctx context.Context, sel ast.SelectionSet, obj
 		switch field.Name {
 		case "__typename":
 			out.Values[i] = graphql.MarshalString("File")
+		case "expired":
+
+			out.Values[i] = ec._File_expired(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
 		case "link":
 
 			out.Values[i] = ec._File_link(ctx, field, obj)
@@ -74794,6 +75824,48 @@ func (ec *executionContext) _PreconditionScript(ctx context.Context, sel ast.Sel
 	return out
 }
 
+var processUsageImplementors = []string{"ProcessUsage"}
+
+func (ec *executionContext) _ProcessUsage(ctx context.Context, sel ast.SelectionSet, obj *model.APIProcessUsage) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, processUsageImplementors)
+	out := graphql.NewFieldSet(fields)
+	var invalids uint32
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("ProcessUsage")
+		case "command":
+
+			out.Values[i] = ec._ProcessUsage_command(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "pid":
+
+			out.Values[i] = ec._ProcessUsage_pid(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "rssBytes":
+
+			out.Values[i] = ec._ProcessUsage_rssBytes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch()
+	if invalids > 0 {
+		return graphql.Null
+	}
+	return out
+}
+
 var projectImplementors = []string{"Project"}
 
 func (ec *executionContext) _Project(ctx context.Context, sel ast.SelectionSet, obj *model.APIProjectRef) graphql.Marshaler {
@@ -77026,6 +78098,97 @@ func (ec *executionContext) _ResourceLimits(ctx context.Context, sel ast.Selecti
 	return out
 }
 
+var resourceUsageSampleImplementors = []string{"ResourceUsageSample"}
+
+func (ec *executionContext) _ResourceUsageSample(ctx context.Context, sel ast.SelectionSet, obj *model.APIResourceUsageSample) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, resourceUsageSampleImplementors)
+	out := graphql.NewFieldSet(fields)
+	var invalids uint32
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("ResourceUsageSample")
+		case "cpuPercent":
+
+			out.Values[i] = ec._ResourceUsageSample_cpuPercent(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "diskReadBytes":
+
+			out.Values[i] = ec._ResourceUsageSample_diskReadBytes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "diskWriteBytes":
+
+			out.Values[i] = ec._ResourceUsageSample_diskWriteBytes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "memoryAvailableBytes":
+
+			out.Values[i] = ec._ResourceUsageSample_memoryAvailableBytes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "memoryRssBytes":
+
+			out.Values[i] = ec._ResourceUsageSample_memoryRssBytes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "networkRecvBytes":
+
+			out.Values[i] = ec._ResourceUsageSample_networkRecvBytes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "networkSentBytes":
+
+			out.Values[i] = ec._ResourceUsageSample_networkSentBytes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "numProcesses":
+
+			out.Values[i] = ec._ResourceUsageSample_numProcesses(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "processes":
+
+			out.Values[i] = ec._ResourceUsageSample_processes(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "time":
+
+			out.Values[i] = ec._ResourceUsageSample_time(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch()
+	if invalids > 0 {
+		return graphql.Null
+	}
+	return out
+}
+
 var saveDistroPayloadImplementors = []string{"SaveDistroPayload"}
 
 func (ec *executionContext) _SaveDistroPayload(ctx context.Context, sel ast.SelectionSet, obj *SaveDistroPayload) graphql.Marshaler {
@@ -78132,6 +79295,26 @@ func (ec *executionContext) _Task(ctx context.Context, sel ast.SelectionSet, obj
 			if out.Values[i] == graphql.Null {
 				atomic.AddUint32(&invalids, 1)
 			}
+		case "resourceUsage":
+			field := field
+
+			innerFunc := func(ctx context.Context) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._Task_resourceUsage(ctx, field, obj)
+				if res == graphql.Null {
+					atomic.AddUint32(&invalids, 1)
+				}
+				return res
+			}
+
+			out.Concurrently(i, func() graphql.Marshaler {
+				return innerFunc(ctx)
+
+			})
 		case "revision":
 
 			out.Values[i] = ec._Task_revision(ctx, field, obj)
@@ -78993,6 +80176,48 @@ func (ec *executionContext) _TaskQueueItem(ctx context.Context, sel ast.Selectio
 	return out
 }
 
+var taskResourceUsageImplementors = []string{"TaskResourceUsage"}
+
+func (ec *executionContext) _TaskResourceUsage(ctx context.Context, sel ast.SelectionSet, obj *model.APITaskResourceUsage) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, taskResourceUsageImplementors)
+	out := graphql.NewFieldSet(fields)
+	var invalids uint32
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("TaskResourceUsage")
+		case "execution":
+
+			out.Values[i] = ec._TaskResourceUsage_execution(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "samples":
+
+			out.Values[i] = ec._TaskResourceUsage_samples(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "taskId":
+
+			out.Values[i] = ec._TaskResourceUsage_taskId(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch()
+	if invalids > 0 {
+		return graphql.Null
+	}
+	return out
+}
+
 var taskSpecifierImplementors = []string{"TaskSpecifier"}
 
 func (ec *executionContext) _TaskSpecifier(ctx context.Context, sel ast.SelectionSet, obj *model.APITaskSpecifier) graphql.Marshaler {
@@ -83062,6 +84287,54 @@ func (ec *executionContext) unmarshalNPreconditionScriptInput2ᚕgithubᚗcomᚋ
 	return res, nil
 }
 
+func (ec *executionContext) marshalNProcessUsage2githubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIProcessUsage(ctx context.Context, sel ast.SelectionSet, v model.APIProcessUsage) graphql.Marshaler {
+	return ec._ProcessUsage(ctx, sel, &v)
+}
+
+func (ec *executionContext) marshalNProcessUsage2ᚕgithubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIProcessUsageᚄ(ctx context.Context, sel ast.SelectionSet, v []model.APIProcessUsage) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalNProcessUsage2githubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIProcessUsage(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	for _, e := range ret {
+		if e == graphql.Null {
+			return graphql.Null
+		}
+	}
+
+	return ret
+}
+
 func (ec *executionContext) marshalNProject2githubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIProjectRef(ctx context.Context, sel ast.SelectionSet, v model.APIProjectRef) graphql.Marshaler {
 	return ec._Project(ctx, sel, &v)
 }
@@ -83420,6 +84693,54 @@ func (ec *executionContext) unmarshalNResourceLimitsInput2githubᚗcomᚋevergre
 	return res, graphql.ErrorOnPath(ctx, err)
 }
 
+func (ec *executionContext) marshalNResourceUsageSample2githubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIResourceUsageSample(ctx context.Context, sel ast.SelectionSet, v model.APIResourceUsageSample) graphql.Marshaler {
+	return ec._ResourceUsageSample(ctx, sel, &v)
+}
+
+func (ec *executionContext) marshalNResourceUsageSample2ᚕgithubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIResourceUsageSampleᚄ(ctx context.Context, sel ast.SelectionSet, v []model.APIResourceUsageSample) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalNResourceUsageSample2githubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPIResourceUsageSample(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	for _, e := range ret {
+		if e == graphql.Null {
+			return graphql.Null
+		}
+	}
+
+	return ret
+}
+
 func (ec *executionContext) unmarshalNSaveDistroInput2githubᚗcomᚋevergreenᚑciᚋevergreenᚋgraphqlᚐSaveDistroInput(ctx context.Context, v interface{}) (SaveDistroInput, error) {
 	res, err := ec.unmarshalInputSaveDistroInput(ctx, v)
 	return res, graphql.ErrorOnPath(ctx, err)
@@ -84073,6 +85394,20 @@ func (ec *executionContext) marshalNTaskQueueItemType2githubᚗcomᚋevergreen
 	return v
 }
 
+func (ec *executionContext) marshalNTaskResourceUsage2githubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPITaskResourceUsage(ctx context.Context, sel ast.SelectionSet, v model.APITaskResourceUsage) graphql.Marshaler {
+	return ec._TaskResourceUsage(ctx, sel, &v)
+}
+
+func (ec *executionContext) marshalNTaskResourceUsage2ᚖgithubᚗcomᚋevergreenᚑciᚋevergreenᚋrestᚋmodelᚐAPITaskResourceUsage(ctx context.Context, sel ast.SelectionSet, v *model.APITaskResourceUsage) graphql.Marshaler {
+	if v == nil {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			ec.Errorf(ctx, "the requested element is null which the schema does not allow")
+		}
+		return graphql.Null
+	}
+	return ec._TaskResourceUsage(ctx, sel, v)
+}
+
 func (ec *executionContext) unmarshalNTaskSortCategory2githubᚗcomᚋevergreenᚑciᚋevergreenᚋgraphqlᚐTaskSortCategory(ctx context.Context, v interface{}) (TaskSortCategory, error) {
 	var res TaskSortCategory
 	err := res.UnmarshalGQL(v)
@@ -119,7 +119,7 @@ func (r *mutationResolver) SetAnnotationMetadataLinks(ctx context.Context, taskI
 	if err := annotations.ValidateMetadataLinks(modelMetadataLinks...); err != nil {
 		return false, InputValidationError.Send(ctx, fmt.Sprintf("invalid metadata link: %s", err.Error()))
 	}
-	if err := annotations.SetAnnotationMetadataLinks(ctx, taskID, execution, usr.Username(), modelMetadataLinks...); err != nil {
+	if err := annotations.SetAnnotationMetadataLinks(ctx, taskID, execution, usr.Username(), annotations.UIRequester, modelMetadataLinks...); err != nil {
 		return false, InternalServerError.Send(ctx, fmt.Sprintf("couldn't add issue: %s", err.Error()))
 	}
 	return true, nil
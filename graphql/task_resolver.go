@@ -16,6 +16,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	restModel "github.com/evergreen-ci/evergreen/rest/model"
@@ -493,6 +494,21 @@ func (r *taskResolver) ProjectIdentifier(ctx context.Context, obj *restModel.API
 	return obj.ProjectIdentifier, nil
 }
 
+// ResourceUsage is the resolver for the resourceUsage field.
+func (r *taskResolver) ResourceUsage(ctx context.Context, obj *restModel.APITask) (*restModel.APITaskResourceUsage, error) {
+	taskID := utility.FromStringPtr(obj.Id)
+	usage, err := resourceusage.FindOneByTaskIDAndExecution(taskID, obj.Execution)
+	if err != nil {
+		return nil, InternalServerError.Send(ctx, fmt.Sprintf("finding resource usage for task '%s': %s", taskID, err.Error()))
+	}
+	if usage == nil {
+		usage = &resourceusage.TaskResourceUsage{TaskID: taskID, Execution: obj.Execution}
+	}
+	apiUsage := &restModel.APITaskResourceUsage{}
+	apiUsage.BuildFromService(*usage)
+	return apiUsage, nil
+}
+
 // SpawnHostLink is the resolver for the spawnHostLink field.
 func (r *taskResolver) SpawnHostLink(ctx context.Context, obj *restModel.APITask) (*string, error) {
 	host, err := host.FindOne(ctx, host.ById(*obj.HostId))
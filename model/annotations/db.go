@@ -27,6 +27,7 @@ const (
 	UIRequester           = "ui"
 	APIRequester          = "api"
 	WebhookRequester      = "webhook"
+	TaskRequester         = "task"
 	MaxMetadataLinks      = 1
 	MaxMetadataTextLength = 40
 )
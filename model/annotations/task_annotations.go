@@ -140,13 +140,14 @@ func UpdateAnnotationNote(taskId string, execution int, originalMessage, newMess
 }
 
 // SetAnnotationMetadataLinks sets the metadata links for a task annotation.
-func SetAnnotationMetadataLinks(ctx context.Context, taskId string, execution int, username string, metadataLinks ...MetadataLink) error {
+// The requester is where the links come from, e.g. the UI or the task itself.
+func SetAnnotationMetadataLinks(ctx context.Context, taskId string, execution int, username, requester string, metadataLinks ...MetadataLink) error {
 	now := time.Now()
 	for i := range metadataLinks {
 		metadataLinks[i].Source = &Source{
 			Author:    username,
 			Time:      now,
-			Requester: UIRequester,
+			Requester: requester,
 		}
 	}
 
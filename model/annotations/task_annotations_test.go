@@ -88,7 +88,7 @@ func TestSetAnnotationMetadataLinks(t *testing.T) {
 	defer cancel()
 	assert.NoError(t, db.Clear(Collection))
 	taskLink := MetadataLink{URL: "https://issuelink.com", Text: "Hello World"}
-	assert.NoError(t, SetAnnotationMetadataLinks(ctx, "t1", 0, "usr", taskLink))
+	assert.NoError(t, SetAnnotationMetadataLinks(ctx, "t1", 0, "usr", UIRequester, taskLink))
 
 	annotation, err := FindOneByTaskIdAndExecution("t1", 0)
 	assert.NoError(t, err)
@@ -99,16 +99,18 @@ func TestSetAnnotationMetadataLinks(t *testing.T) {
 	assert.Equal(t, "https://issuelink.com", annotation.MetadataLinks[0].URL)
 	assert.NotNil(t, annotation.MetadataLinks[0].Source)
 	assert.Equal(t, "usr", annotation.MetadataLinks[0].Source.Author)
+	assert.Equal(t, UIRequester, annotation.MetadataLinks[0].Source.Requester)
 
 	taskLink.URL = "https://issuelink.com/2"
-	assert.NoError(t, SetAnnotationMetadataLinks(ctx, "t1", 0, "usr", taskLink))
+	assert.NoError(t, SetAnnotationMetadataLinks(ctx, "t1", 0, "t1", TaskRequester, taskLink))
 	annotation, err = FindOneByTaskIdAndExecution("t1", 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, annotation)
 	assert.Len(t, annotation.MetadataLinks, 1)
 	assert.Equal(t, "https://issuelink.com/2", annotation.MetadataLinks[0].URL)
 	assert.NotNil(t, annotation.MetadataLinks[0].Source)
-	assert.Equal(t, "usr", annotation.MetadataLinks[0].Source.Author)
+	assert.Equal(t, "t1", annotation.MetadataLinks[0].Source.Author)
+	assert.Equal(t, TaskRequester, annotation.MetadataLinks[0].Source.Requester)
 }
 
 func TestAddSuspectedIssueToAnnotation(t *testing.T) {
@@ -1,9 +1,14 @@
 package artifact
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
@@ -11,6 +16,9 @@ import (
 const Collection = "artifact_files"
 const PresignExpireTime = 24 * time.Hour
 
+// s3Host is the host of the S3 URLs that commands link uploaded files to.
+const s3Host = "s3.amazonaws.com"
+
 const (
 	// strings for setting visibility
 	Public  = "public"
@@ -32,6 +40,15 @@ type Entry struct {
 	Files           []File    `json:"files" bson:"files"`
 	Execution       int       `json:"execution" bson:"execution"`
 	CreateTime      time.Time `json:"create_time" bson:"create_time"`
+	// Project, Requester and VersionId are copied from the task so that
+	// the project's artifact retention policy can find expired entries.
+	Project   string `json:"project,omitempty" bson:"project,omitempty"`
+	Requester string `json:"requester,omitempty" bson:"requester,omitempty"`
+	VersionId string `json:"version,omitempty" bson:"version,omitempty"`
+	// RetentionRetryAt is set when the artifact retention policy couldn't
+	// expire all of the entry's files, so that the entry isn't checked
+	// again until then.
+	RetentionRetryAt time.Time `json:"retention_retry_at,omitempty" bson:"retention_retry_at,omitempty"`
 }
 
 // Params stores file entries as key-value pairs, for easy parameter parsing.
@@ -58,6 +75,18 @@ type File struct {
 	Bucket string `json:"bucket,omitempty" bson:"bucket,omitempty"`
 	// FileKey is the path to the file in the bucket.
 	FileKey string `json:"filekey,omitempty" bson:"filekey,omitempty"`
+	// Region is the region of the file's bucket.
+	Region string `json:"region,omitempty" bson:"region,omitempty"`
+	// Expired indicates that the file was deleted from its bucket by the
+	// project's artifact retention policy, so its link no longer works.
+	Expired bool `json:"expired,omitempty" bson:"expired,omitempty"`
+	// SHA256 is the hex-encoded SHA-256 digest of the file's contents, if
+	// the agent computed it when the file was attached.
+	SHA256 string `json:"sha256,omitempty" bson:"sha256,omitempty"`
+	// LocalPath is the path to the file on the agent's host, which the
+	// agent uses to compute the file's digest before attaching it. It's
+	// never sent to or stored by the app server.
+	LocalPath string `json:"local_path,omitempty" bson:"-"`
 }
 
 // StripHiddenFiles is a helper for only showing users the files they are allowed to see.
@@ -69,6 +98,9 @@ func StripHiddenFiles(files []File, hasUser bool) ([]File, error) {
 			continue
 		case (file.Visibility == Private || file.Visibility == Signed) && !hasUser:
 			continue
+		case file.Visibility == Signed && hasUser && file.Expired:
+			// There's nothing left to sign a link for.
+			publicFiles = append(publicFiles, file)
 		case file.Visibility == Signed && hasUser:
 			if !file.ContainsSigningParams() {
 				return nil, errors.New("AWS secret, AWS key, S3 bucket, or file key missing")
@@ -98,6 +130,62 @@ func (f *File) ContainsSigningParams() bool {
 	return !(f.AwsSecret == "" || f.AwsKey == "" || f.Bucket == "" || f.FileKey == "")
 }
 
+// S3Location returns the bucket and key of the file in S3. Files attached by
+// commands that upload to S3 record the bucket and key, but older files only
+// have a link, so it falls back to parsing the link as an S3 URL. It returns
+// false if the file's location in S3 is not known.
+func (f *File) S3Location() (bucket, key string, ok bool) {
+	if f.Bucket != "" && f.FileKey != "" {
+		return f.Bucket, f.FileKey, true
+	}
+
+	u, err := url.Parse(f.Link)
+	if err != nil || u.Scheme != "https" {
+		return "", "", false
+	}
+	path := strings.TrimPrefix(u.Path, "/")
+	switch {
+	case u.Host == s3Host:
+		// Path-style URLs have the bucket as the first path segment.
+		parts := strings.SplitN(path, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", false
+		}
+		return parts[0], parts[1], true
+	case strings.HasSuffix(u.Host, "."+s3Host):
+		// Virtual hosted-style URLs have the bucket as the subdomain.
+		bucket = strings.TrimSuffix(u.Host, "."+s3Host)
+		if bucket == "" || path == "" {
+			return "", "", false
+		}
+		return bucket, path, true
+	default:
+		return "", "", false
+	}
+}
+
+// Validate checks that the file has a name and link and that its optional
+// fields are well-formed. If the file has a bucket and key and its link is to
+// S3, the link must be to the same object.
+func (f *File) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(f.Name == "", "artifact must have a name")
+	catcher.NewWhen(f.Link == "", "artifact must have a link")
+	catcher.ErrorfWhen(!utility.StringSliceContains(ValidVisibilities, f.Visibility), "invalid visibility '%s'", f.Visibility)
+	if f.SHA256 != "" {
+		sum, err := hex.DecodeString(f.SHA256)
+		catcher.ErrorfWhen(err != nil || len(sum) != sha256.Size, "SHA-256 digest '%s' must be %d hex characters", f.SHA256, 2*sha256.Size)
+	}
+	catcher.NewWhen((f.Bucket == "") != (f.FileKey == ""), "bucket and file key must be set together")
+	if f.Bucket != "" && f.FileKey != "" {
+		linkOnly := File{Link: f.Link}
+		if bucket, key, ok := linkOnly.S3Location(); ok {
+			catcher.ErrorfWhen(bucket != f.Bucket || key != f.FileKey, "link is to S3 object '%s' in bucket '%s', but the artifact's file key is '%s' in bucket '%s'", key, bucket, f.FileKey, f.Bucket)
+		}
+	}
+	return catcher.Resolve()
+}
+
 func GetAllArtifacts(tasks []TaskIDAndExecution) ([]File, error) {
 	artifacts, err := FindAll(ByTaskIdsAndExecutions(tasks))
 	if err != nil {
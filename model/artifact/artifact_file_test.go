@@ -1,10 +1,14 @@
 package artifact
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	_ "github.com/evergreen-ci/evergreen/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -218,3 +222,154 @@ func (s *TestArtifactFileSuite) TestRotateSecret() {
 	s.NoError(err)
 	s.Equal(entryFromDb.Files[0].AwsSecret, "changedSecret")
 }
+
+func (s *TestArtifactFileSuite) TestMarkFileExpired() {
+	s.NoError(MarkFileExpired("task1", 1, "fast_download", "https://fastdl.mongodb.org"))
+	entry, err := FindOne(ByTaskIdAndExecution("task1", 1))
+	s.NoError(err)
+	s.Require().NotNil(entry)
+	s.False(entry.Files[0].Expired)
+	s.True(entry.Files[1].Expired)
+}
+
+func (s *TestArtifactFileSuite) TestByProjectCreatedBefore() {
+	s.NoError(db.Clear(Collection))
+	now := time.Now()
+	s3File := File{Name: "s3", Link: "https://bucket.s3.amazonaws.com/key", Bucket: "bucket", FileKey: "key"}
+	for _, e := range []Entry{
+		{TaskId: "old_mainline", Project: "p", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v1", CreateTime: now.Add(-48 * time.Hour), Files: []File{s3File}},
+		{TaskId: "old_patch", Project: "p", Requester: evergreen.PatchVersionRequester, VersionId: "v2", CreateTime: now.Add(-48 * time.Hour), Files: []File{s3File}},
+		{TaskId: "new_mainline", Project: "p", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v3", CreateTime: now, Files: []File{s3File}},
+		{TaskId: "kept_version", Project: "p", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v4", CreateTime: now.Add(-48 * time.Hour), Files: []File{s3File}},
+		{TaskId: "other_project", Project: "p2", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v5", CreateTime: now.Add(-48 * time.Hour), Files: []File{s3File}},
+		{TaskId: "not_s3", Project: "p", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v6", CreateTime: now.Add(-48 * time.Hour), Files: []File{{Name: "other", Link: "https://example.com/file"}}},
+		{TaskId: "expired", Project: "p", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v7", CreateTime: now.Add(-48 * time.Hour), Files: []File{{Name: "s3", Link: s3File.Link, Bucket: "bucket", FileKey: "key", Expired: true}}},
+		{TaskId: "unconfigured_bucket", Project: "p", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v8", CreateTime: now.Add(-48 * time.Hour), Files: []File{{Name: "s3", Link: "https://other.s3.amazonaws.com/key", Bucket: "other", FileKey: "key"}}},
+		{TaskId: "retry_later", Project: "p", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v9", CreateTime: now.Add(-48 * time.Hour), Files: []File{s3File}},
+	} {
+		s.NoError(e.Upsert())
+	}
+	s.NoError(SetRetentionRetryAt("retry_later", 0, now.Add(time.Hour)))
+
+	entries, err := FindAll(ByProjectCreatedBefore("p", false, now.Add(-time.Hour), now, []string{"v4"}, []string{"bucket"}))
+	s.NoError(err)
+	s.Require().Len(entries, 1)
+	s.Equal("old_mainline", entries[0].TaskId)
+
+	entries, err = FindAll(ByProjectCreatedBefore("p", true, now.Add(-time.Hour), now, []string{"v4"}, []string{"bucket"}))
+	s.NoError(err)
+	s.Require().Len(entries, 1)
+	s.Equal("old_patch", entries[0].TaskId)
+
+	entries, err = FindAll(ByProjectCreatedBefore("p", false, now.Add(-time.Hour), now.Add(2*time.Hour), []string{"v4"}, []string{"bucket"}))
+	s.NoError(err)
+	s.Len(entries, 2, "entry should be retried once its retry time has passed")
+}
+
+func (s *TestArtifactFileSuite) TestByUnexpiredS3Object() {
+	s.NoError(db.Clear(Collection))
+	for _, e := range []Entry{
+		{TaskId: "same_object", Files: []File{{Name: "f", Link: "l1", Bucket: "bucket", FileKey: "latest/file"}}},
+		{TaskId: "expired", Files: []File{{Name: "f", Link: "l2", Bucket: "bucket", FileKey: "latest/file", Expired: true}}},
+		{TaskId: "other_key", Files: []File{{Name: "f", Link: "l3", Bucket: "bucket", FileKey: "other/file"}}},
+		{TaskId: "other_bucket", Files: []File{{Name: "f", Link: "l4", Bucket: "other", FileKey: "latest/file"}}},
+	} {
+		s.NoError(e.Upsert())
+	}
+
+	entries, err := FindAll(ByUnexpiredS3Object("bucket", "latest/file"))
+	s.NoError(err)
+	s.Require().Len(entries, 1)
+	s.Equal("same_object", entries[0].TaskId)
+}
+
+func TestS3Location(t *testing.T) {
+	for name, test := range map[string]struct {
+		file   File
+		bucket string
+		key    string
+		ok     bool
+	}{
+		"BucketAndKey": {
+			file:   File{Bucket: "bucket", FileKey: "path/to/file", Link: "https://example.com"},
+			bucket: "bucket",
+			key:    "path/to/file",
+			ok:     true,
+		},
+		"PathStyleLink": {
+			file:   File{Link: "https://s3.amazonaws.com/bucket/path/to/file"},
+			bucket: "bucket",
+			key:    "path/to/file",
+			ok:     true,
+		},
+		"VirtualHostedStyleLink": {
+			file:   File{Link: "https://bucket.s3.amazonaws.com/path/to/file"},
+			bucket: "bucket",
+			key:    "path/to/file",
+			ok:     true,
+		},
+		"NonS3Link": {
+			file: File{Link: "https://example.com/bucket/path/to/file"},
+		},
+		"PathStyleLinkWithoutKey": {
+			file: File{Link: "https://s3.amazonaws.com/bucket"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			bucket, key, ok := test.file.S3Location()
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.bucket, bucket)
+			assert.Equal(t, test.key, key)
+		})
+	}
+}
+
+func TestFileValidate(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	for name, test := range map[string]struct {
+		file  File
+		valid bool
+	}{
+		"NameAndLink": {
+			file:  File{Name: "file", Link: "https://example.com/file"},
+			valid: true,
+		},
+		"AllFields": {
+			file:  File{Name: "file", Link: "https://bucket.s3.amazonaws.com/path/to/file", Visibility: Signed, Bucket: "bucket", FileKey: "path/to/file", SHA256: digest},
+			valid: true,
+		},
+		"MissingName": {
+			file: File{Link: "https://example.com/file"},
+		},
+		"MissingLink": {
+			file: File{Name: "file"},
+		},
+		"InvalidVisibility": {
+			file: File{Name: "file", Link: "https://example.com/file", Visibility: "everyone"},
+		},
+		"NonHexDigest": {
+			file: File{Name: "file", Link: "https://example.com/file", SHA256: strings.Repeat("z", 64)},
+		},
+		"ShortDigest": {
+			file: File{Name: "file", Link: "https://example.com/file", SHA256: "abcd"},
+		},
+		"BucketWithoutKey": {
+			file: File{Name: "file", Link: "https://example.com/file", Bucket: "bucket"},
+		},
+		"KeyWithoutBucket": {
+			file: File{Name: "file", Link: "https://example.com/file", FileKey: "path/to/file"},
+		},
+		"LinkToDifferentObject": {
+			file: File{Name: "file", Link: "https://bucket.s3.amazonaws.com/other/file", Bucket: "bucket", FileKey: "path/to/file"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := test.file.Validate()
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package artifact
 
 import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/mongodb/anser/bsonutil"
 	adb "github.com/mongodb/anser/db"
@@ -15,8 +18,15 @@ var (
 	FilesKey      = bsonutil.MustHaveTag(Entry{}, "Files")
 	ExecutionKey  = bsonutil.MustHaveTag(Entry{}, "Execution")
 	CreateTimeKey = bsonutil.MustHaveTag(Entry{}, "CreateTime")
+	ProjectKey    = bsonutil.MustHaveTag(Entry{}, "Project")
+	RequesterKey  = bsonutil.MustHaveTag(Entry{}, "Requester")
+	VersionIdKey  = bsonutil.MustHaveTag(Entry{}, "VersionId")
+	RetryAtKey    = bsonutil.MustHaveTag(Entry{}, "RetentionRetryAt")
 	NameKey       = bsonutil.MustHaveTag(File{}, "Name")
 	LinkKey       = bsonutil.MustHaveTag(File{}, "Link")
+	BucketKey     = bsonutil.MustHaveTag(File{}, "Bucket")
+	FileKeyKey    = bsonutil.MustHaveTag(File{}, "FileKey")
+	ExpiredKey    = bsonutil.MustHaveTag(File{}, "Expired")
 	AwsSecretKey  = "aws_secret"
 )
 
@@ -88,6 +98,50 @@ func BySecret(secret string) db.Q {
 	})
 }
 
+// ByProjectCreatedBefore returns a query for the project's entries that were
+// created before the cutoff and still have files that are not expired in one
+// of the given S3 buckets. If patches is true,
+// it only matches entries for patch requesters, and otherwise it only matches
+// entries for mainline requesters. Entries for the versions in keepVersions
+// and entries that shouldn't be retried until after now are never matched.
+func ByProjectCreatedBefore(projectID string, patches bool, cutoff, now time.Time, keepVersions, buckets []string) db.Q {
+	requesterOp := "$nin"
+	if patches {
+		requesterOp = "$in"
+	}
+	return db.Query(bson.M{
+		ProjectKey:    projectID,
+		RequesterKey:  bson.M{requesterOp: evergreen.PatchRequesters},
+		VersionIdKey:  bson.M{"$nin": keepVersions},
+		CreateTimeKey: bson.M{"$lt": cutoff},
+		"$or": []bson.M{
+			{RetryAtKey: bson.M{"$exists": false}},
+			{RetryAtKey: bson.M{"$lte": now}},
+		},
+		FilesKey: bson.M{
+			"$elemMatch": bson.M{
+				ExpiredKey: bson.M{"$ne": true},
+				BucketKey:  bson.M{"$in": buckets},
+				FileKeyKey: bson.M{"$exists": true},
+			},
+		},
+	}).Sort([]string{CreateTimeKey})
+}
+
+// ByUnexpiredS3Object returns a query for the entries that have a file that
+// is not expired stored at the key in the bucket.
+func ByUnexpiredS3Object(bucket, key string) db.Q {
+	return db.Query(bson.M{
+		FilesKey: bson.M{
+			"$elemMatch": bson.M{
+				ExpiredKey: bson.M{"$ne": true},
+				BucketKey:  bucket,
+				FileKeyKey: key,
+			},
+		},
+	})
+}
+
 // === DB Logic ===
 
 // Upsert updates the files entry in the db if an entry already exists,
@@ -108,7 +162,11 @@ func (e Entry) Upsert() error {
 				},
 			},
 			"$setOnInsert": bson.M{
-				ExecutionKey: e.Execution,
+				ExecutionKey:  e.Execution,
+				CreateTimeKey: e.CreateTime,
+				ProjectKey:    e.Project,
+				RequesterKey:  e.Requester,
+				VersionIdKey:  e.VersionId,
 			},
 		},
 	)
@@ -143,6 +201,44 @@ func (e Entry) Update() error {
 	return err
 }
 
+// MarkFileExpired marks the file with the given name and link in the task
+// execution's entry as expired.
+func MarkFileExpired(taskID string, execution int, name, link string) error {
+	return db.Update(
+		Collection,
+		bson.M{
+			TaskIdKey:    taskID,
+			ExecutionKey: execution,
+			FilesKey: bson.M{
+				"$elemMatch": bson.M{
+					NameKey: name,
+					LinkKey: link,
+				},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				bsonutil.GetDottedKeyName(FilesKey, "$", ExpiredKey): true,
+			},
+		},
+	)
+}
+
+// SetRetentionRetryAt sets when the artifact retention policy should next try
+// to expire the entry's files.
+func SetRetentionRetryAt(taskID string, execution int, retryAt time.Time) error {
+	return db.Update(
+		Collection,
+		bson.M{
+			TaskIdKey:    taskID,
+			ExecutionKey: execution,
+		},
+		bson.M{
+			"$set": bson.M{RetryAtKey: retryAt},
+		},
+	)
+}
+
 // FindOne gets one Entry for the given query
 func FindOne(query db.Q) (*Entry, error) {
 	entry := &Entry{}
@@ -0,0 +1,288 @@
+package artifact
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	ProvenanceCollection = "artifact_provenance"
+
+	// InTotoStatementType is the type of the in-toto statements that
+	// provenance is recorded in.
+	InTotoStatementType = "https://in-toto.io/Statement/v1"
+	// InTotoPayloadType is the DSSE payload type of a signed in-toto
+	// statement.
+	InTotoPayloadType = "application/vnd.in-toto+json"
+	// SLSAProvenancePredicateType is the predicate type of SLSA provenance.
+	SLSAProvenancePredicateType = "https://slsa.dev/provenance/v1"
+	// ProvenanceBuildType identifies the format of the build parameters in
+	// Evergreen's provenance.
+	ProvenanceBuildType = "https://github.com/evergreen-ci/evergreen/provenance/task/v1"
+
+	// SHA256DigestAlgorithm is the name of the SHA-256 digest algorithm in
+	// provenance statements.
+	SHA256DigestAlgorithm = "sha256"
+	// GitCommitDigestAlgorithm is the name of a git commit hash in
+	// provenance statements.
+	GitCommitDigestAlgorithm = "gitCommit"
+)
+
+// Provenance is a signed provenance statement for a file that a task
+// attached.
+type Provenance struct {
+	ID         string    `bson:"_id" json:"id"`
+	TaskID     string    `bson:"task_id" json:"task_id"`
+	Execution  int       `bson:"execution" json:"execution"`
+	FileName   string    `bson:"file_name" json:"file_name"`
+	Link       string    `bson:"link" json:"link"`
+	SHA256     string    `bson:"sha256" json:"sha256"`
+	Envelope   Envelope  `bson:"envelope" json:"envelope"`
+	CreateTime time.Time `bson:"create_time" json:"create_time"`
+}
+
+var (
+	ProvenanceTaskIDKey    = bsonutil.MustHaveTag(Provenance{}, "TaskID")
+	ProvenanceExecutionKey = bsonutil.MustHaveTag(Provenance{}, "Execution")
+	ProvenanceFileNameKey  = bsonutil.MustHaveTag(Provenance{}, "FileName")
+)
+
+// Envelope is a DSSE envelope, which holds a signed payload.
+type Envelope struct {
+	PayloadType string              `bson:"payload_type" json:"payloadType"`
+	Payload     []byte              `bson:"payload" json:"payload"`
+	Signatures  []EnvelopeSignature `bson:"signatures" json:"signatures"`
+}
+
+// EnvelopeSignature is a signature of a DSSE envelope's payload.
+type EnvelopeSignature struct {
+	KeyID string `bson:"keyid" json:"keyid"`
+	Sig   []byte `bson:"sig" json:"sig"`
+}
+
+// Statement is an in-toto statement that makes a claim about the subjects.
+type Statement struct {
+	Type          string              `json:"_type"`
+	Subject       []Subject           `json:"subject"`
+	PredicateType string              `json:"predicateType"`
+	Predicate     ProvenancePredicate `json:"predicate"`
+}
+
+// Subject is a file that a statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// ProvenancePredicate is a SLSA provenance predicate, which describes how a
+// file was built.
+type ProvenancePredicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes the inputs to the build.
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   BuildParameters      `json:"externalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// BuildParameters identify the task that built a file.
+type BuildParameters struct {
+	Project      string   `json:"project"`
+	Version      string   `json:"version"`
+	Revision     string   `json:"revision"`
+	Requester    string   `json:"requester"`
+	BuildVariant string   `json:"buildVariant"`
+	Task         string   `json:"task"`
+	TaskID       string   `json:"taskId"`
+	Execution    int      `json:"execution"`
+	Distro       string   `json:"distro,omitempty"`
+	Commands     []string `json:"commands,omitempty"`
+}
+
+// ResourceDescriptor describes a source that the build used.
+type ResourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// RunDetails describes the system that ran the build.
+type RunDetails struct {
+	Builder  Builder       `json:"builder"`
+	Metadata BuildMetadata `json:"metadata"`
+}
+
+// Builder identifies the system that ran the build.
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// BuildMetadata holds metadata about the build's execution.
+type BuildMetadata struct {
+	InvocationID string     `json:"invocationId"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+}
+
+// SignStatement signs the statement with the key and returns it in a DSSE
+// envelope.
+func SignStatement(s Statement, key ed25519.PrivateKey) (*Envelope, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling statement to JSON")
+	}
+	pub, ok := key.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("signing key does not have an Ed25519 public key")
+	}
+
+	return &Envelope{
+		PayloadType: InTotoPayloadType,
+		Payload:     payload,
+		Signatures: []EnvelopeSignature{{
+			KeyID: ProvenanceKeyID(pub),
+			Sig:   ed25519.Sign(key, preAuthEncoding(InTotoPayloadType, payload)),
+		}},
+	}, nil
+}
+
+// Verify checks that the envelope was signed by the public key and returns
+// the statement it contains.
+func (e *Envelope) Verify(pub ed25519.PublicKey) (*Statement, error) {
+	if e.PayloadType != InTotoPayloadType {
+		return nil, errors.Errorf("unsupported payload type '%s'", e.PayloadType)
+	}
+
+	keyID := ProvenanceKeyID(pub)
+	verified := false
+	for _, sig := range e.Signatures {
+		if sig.KeyID != "" && sig.KeyID != keyID {
+			continue
+		}
+		if ed25519.Verify(pub, preAuthEncoding(e.PayloadType, e.Payload), sig.Sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.Errorf("envelope has no valid signature from key '%s'", keyID)
+	}
+
+	s := &Statement{}
+	if err := json.Unmarshal(e.Payload, s); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling statement from JSON")
+	}
+	if s.Type != InTotoStatementType {
+		return nil, errors.Errorf("unsupported statement type '%s'", s.Type)
+	}
+	if s.PredicateType != SLSAProvenancePredicateType {
+		return nil, errors.Errorf("unsupported predicate type '%s'", s.PredicateType)
+	}
+
+	return s, nil
+}
+
+// preAuthEncoding returns the DSSE pre-authentication encoding of the
+// payload, which is what's actually signed.
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// ProvenanceKeyID returns the ID of the public key, which is the hex-encoded
+// SHA-256 digest of the key.
+func ProvenanceKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseProvenanceSigningKey parses a PEM-encoded PKCS #8 Ed25519 private key.
+func ParseProvenanceSigningKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing PKCS #8 private key")
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("private key is a %T, not an Ed25519 key", key)
+	}
+	return edKey, nil
+}
+
+// ParseProvenancePublicKey parses a PEM-encoded PKIX Ed25519 public key.
+func ParseProvenancePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing PKIX public key")
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("public key is a %T, not an Ed25519 key", key)
+	}
+	return edKey, nil
+}
+
+// MarshalProvenancePublicKey returns the PEM-encoded PKIX public key.
+func MarshalProvenancePublicKey(pub ed25519.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling PKIX public key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// provenanceID returns the ID of the provenance for the file with the given
+// name and digest attached by the task execution.
+func provenanceID(taskID string, execution int, name, sha256 string) string {
+	return fmt.Sprintf("%s-%d-%s-%s", taskID, execution, name, sha256)
+}
+
+// NewProvenance returns the provenance for the file with the given envelope.
+func NewProvenance(taskID string, execution int, file File, envelope Envelope) Provenance {
+	return Provenance{
+		ID:         provenanceID(taskID, execution, file.Name, file.SHA256),
+		TaskID:     taskID,
+		Execution:  execution,
+		FileName:   file.Name,
+		Link:       file.Link,
+		SHA256:     file.SHA256,
+		Envelope:   envelope,
+		CreateTime: time.Now(),
+	}
+}
+
+// Upsert inserts the provenance, or replaces it if it already exists.
+func (p *Provenance) Upsert() error {
+	_, err := db.Upsert(ProvenanceCollection, bson.M{"_id": p.ID}, p)
+	return err
+}
+
+// FindProvenanceByTaskIDAndExecution returns the provenance for all the files
+// attached by the task execution, sorted by file name.
+func FindProvenanceByTaskIDAndExecution(taskID string, execution int) ([]Provenance, error) {
+	provenance := []Provenance{}
+	err := db.FindAllQ(ProvenanceCollection, db.Query(bson.M{
+		ProvenanceTaskIDKey:    taskID,
+		ProvenanceExecutionKey: execution,
+	}).Sort([]string{ProvenanceFileNameKey}), &provenance)
+	return provenance, err
+}
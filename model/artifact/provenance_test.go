@@ -0,0 +1,97 @@
+package artifact
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenanceSigning(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	statement := Statement{
+		Type:          InTotoStatementType,
+		Subject:       []Subject{{Name: "binary", Digest: map[string]string{SHA256DigestAlgorithm: "abc"}}},
+		PredicateType: SLSAProvenancePredicateType,
+		Predicate: ProvenancePredicate{
+			BuildDefinition: BuildDefinition{
+				BuildType: ProvenanceBuildType,
+				ExternalParameters: BuildParameters{
+					Project:  "project",
+					Revision: "abcdef",
+					TaskID:   "task",
+					Commands: []string{"s3.put"},
+				},
+			},
+			RunDetails: RunDetails{Builder: Builder{ID: "https://evergreen.example.com"}},
+		},
+	}
+
+	t.Run("VerifiesSignedStatement", func(t *testing.T) {
+		envelope, err := SignStatement(statement, key)
+		require.NoError(t, err)
+		require.Len(t, envelope.Signatures, 1)
+		assert.Equal(t, ProvenanceKeyID(pub), envelope.Signatures[0].KeyID)
+
+		verified, err := envelope.Verify(pub)
+		require.NoError(t, err)
+		assert.Equal(t, statement, *verified)
+	})
+	t.Run("FailsWithTamperedPayload", func(t *testing.T) {
+		envelope, err := SignStatement(statement, key)
+		require.NoError(t, err)
+		tampered := statement
+		tampered.Subject = []Subject{{Name: "binary", Digest: map[string]string{SHA256DigestAlgorithm: "def"}}}
+		other, err := SignStatement(tampered, key)
+		require.NoError(t, err)
+		envelope.Payload = other.Payload
+
+		_, err = envelope.Verify(pub)
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithDifferentKey", func(t *testing.T) {
+		envelope, err := SignStatement(statement, key)
+		require.NoError(t, err)
+		otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		_, err = envelope.Verify(otherPub)
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithOtherPayloadType", func(t *testing.T) {
+		envelope, err := SignStatement(statement, key)
+		require.NoError(t, err)
+		envelope.PayloadType = "application/json"
+
+		_, err = envelope.Verify(pub)
+		assert.Error(t, err)
+	})
+}
+
+func TestProvenanceKeyParsing(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	parsedKey, err := ParseProvenanceSigningKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, key, parsedKey)
+
+	pubPEM, err := MarshalProvenancePublicKey(pub)
+	require.NoError(t, err)
+	parsedPub, err := ParseProvenancePublicKey(pubPEM)
+	require.NoError(t, err)
+	assert.Equal(t, pub, parsedPub)
+
+	_, err = ParseProvenanceSigningKey([]byte("not a key"))
+	assert.Error(t, err)
+	_, err = ParseProvenancePublicKey(pubPEM[:10])
+	assert.Error(t, err)
+}
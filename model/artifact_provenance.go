@@ -0,0 +1,146 @@
+package model
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// LoadProvenanceSigningKey returns the key that signs provenance statements,
+// or nil if provenance signing is not configured.
+func LoadProvenanceSigningKey(settings *evergreen.Settings) (ed25519.PrivateKey, error) {
+	if settings.Secrets.ProvenanceKeyFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(settings.Secrets.ProvenanceKeyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading provenance key file '%s'", settings.Secrets.ProvenanceKeyFile)
+	}
+	key, err := artifact.ParseProvenanceSigningKey(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing provenance key file '%s'", settings.Secrets.ProvenanceKeyFile)
+	}
+	return key, nil
+}
+
+// CreateArtifactProvenance signs and stores a provenance statement for each
+// of the files that the task attached with a digest. The agent only attaches
+// digests that it computed from the file itself, so the digest is signed as
+// is. It does nothing if provenance signing is not configured.
+func CreateArtifactProvenance(ctx context.Context, settings *evergreen.Settings, t *task.Task, files []artifact.File) error {
+	key, err := LoadProvenanceSigningKey(settings)
+	if err != nil {
+		return errors.Wrap(err, "loading provenance signing key")
+	}
+	if key == nil {
+		return nil
+	}
+
+	var withDigests []artifact.File
+	for _, f := range files {
+		if f.SHA256 != "" {
+			withDigests = append(withDigests, f)
+		}
+	}
+	if len(withDigests) == 0 {
+		return nil
+	}
+
+	params, deps, err := getProvenanceBuildParameters(t)
+	if err != nil {
+		return errors.Wrap(err, "getting build parameters")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	for _, f := range withDigests {
+		statement := artifact.Statement{
+			Type: artifact.InTotoStatementType,
+			Subject: []artifact.Subject{{
+				Name:   f.Name,
+				Digest: map[string]string{artifact.SHA256DigestAlgorithm: f.SHA256},
+			}},
+			PredicateType: artifact.SLSAProvenancePredicateType,
+			Predicate: artifact.ProvenancePredicate{
+				BuildDefinition: artifact.BuildDefinition{
+					BuildType:            artifact.ProvenanceBuildType,
+					ExternalParameters:   *params,
+					ResolvedDependencies: deps,
+				},
+				RunDetails: artifact.RunDetails{
+					Builder: artifact.Builder{ID: settings.ApiUrl},
+					Metadata: artifact.BuildMetadata{
+						InvocationID: fmt.Sprintf("%s-%d", t.Id, t.Execution),
+					},
+				},
+			},
+		}
+		if !utility.IsZeroTime(t.StartTime) {
+			statement.Predicate.RunDetails.Metadata.StartedOn = utility.ToTimePtr(t.StartTime)
+		}
+
+		envelope, err := artifact.SignStatement(statement, key)
+		if err != nil {
+			catcher.Wrapf(err, "signing provenance for file '%s'", f.Name)
+			continue
+		}
+		provenance := artifact.NewProvenance(t.Id, t.Execution, f, *envelope)
+		catcher.Wrapf(provenance.Upsert(), "storing provenance for file '%s'", f.Name)
+	}
+
+	return catcher.Resolve()
+}
+
+// getProvenanceBuildParameters returns the parameters that identify the task
+// and the source repository it was built from.
+func getProvenanceBuildParameters(t *task.Task) (*artifact.BuildParameters, []artifact.ResourceDescriptor, error) {
+	params := &artifact.BuildParameters{
+		Project:      t.Project,
+		Version:      t.Version,
+		Revision:     t.Revision,
+		Requester:    t.Requester,
+		BuildVariant: t.BuildVariant,
+		Task:         t.DisplayName,
+		TaskID:       t.Id,
+		Execution:    t.Execution,
+		Distro:       t.DistroId,
+	}
+
+	project, err := FindProjectFromVersionID(t.Version)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "finding project for version '%s'", t.Version)
+	}
+	if pt := project.FindProjectTask(t.DisplayName); pt != nil {
+		for _, cmd := range pt.Commands {
+			if len(cmd.Variants) != 0 && !utility.StringSliceContains(cmd.Variants, t.BuildVariant) {
+				continue
+			}
+			if cmd.Function != "" {
+				params.Commands = append(params.Commands, fmt.Sprintf("func:%s", cmd.Function))
+				continue
+			}
+			params.Commands = append(params.Commands, cmd.Command)
+		}
+	}
+
+	pRef, err := FindMergedProjectRef(t.Project, t.Version, false)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "finding project ref '%s'", t.Project)
+	}
+	var deps []artifact.ResourceDescriptor
+	if pRef != nil && pRef.Owner != "" && pRef.Repo != "" && t.Revision != "" {
+		deps = append(deps, artifact.ResourceDescriptor{
+			URI:    fmt.Sprintf("git+https://github.com/%s/%s", pRef.Owner, pRef.Repo),
+			Digest: map[string]string{artifact.GitCommitDigestAlgorithm: t.Revision},
+		})
+	}
+
+	return params, deps, nil
+}
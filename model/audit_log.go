@@ -0,0 +1,673 @@
+package model
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	adb "github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// AuditLogStreamStateCollection stores how far the audit log has been
+	// streamed to the configured sink.
+	AuditLogStreamStateCollection = "audit_log_stream_state"
+
+	auditLogStreamStateID = "audit_log"
+
+	// AuditLogFormatJSONL exports one JSON audit log entry per line.
+	AuditLogFormatJSONL = "jsonl"
+	// AuditLogFormatCSV exports audit log entries as CSV with a header row.
+	AuditLogFormatCSV = "csv"
+
+	auditLogRedactedValue = "{REDACTED}"
+
+	// DefaultAuditLogLimit is the maximum number of entries that an audit
+	// log query returns if it doesn't set a limit.
+	DefaultAuditLogLimit = 1000
+)
+
+// AuditLogResourceTypes are the event resource types that make up the audit
+// log.
+var AuditLogResourceTypes = event.AuditLogResourceTypes
+
+// auditLogSecretKeys are substrings of admin settings field names whose
+// values are redacted from the audit log.
+var auditLogSecretKeys = []string{"secret", "token", "password", "key", "credential", "cert"}
+
+// AuditLogEntry is a single admin settings, project settings, or permission
+// change. Each entry includes the hash of the entry before it, so that any
+// modification, insertion, or removal of an entry in an exported audit log
+// can be detected. Events that were modified, removed, or reordered in the
+// database are detected by the hash chain and signatures that are stored
+// with the events when they're written, which are checked whenever the
+// entries are read.
+type AuditLogEntry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`
+	// ResourceType is the type of resource that changed, which is one of
+	// AuditLogResourceTypes.
+	ResourceType string `json:"resource_type"`
+	// ResourceId identifies the resource that changed. It is the config
+	// section for admin changes, the project ID for project changes, and the
+	// user ID for permission changes.
+	ResourceId string           `json:"resource_id"`
+	EventType  string           `json:"event_type"`
+	Changes    []AuditLogChange `json:"changes"`
+	// Seq is the position of the underlying event in the stored audit log
+	// chain, and EventHash is the hash that chains it to the event before
+	// it. They're empty for events written before the chain was added.
+	Seq       int64  `json:"seq,omitempty"`
+	EventHash string `json:"event_hash,omitempty"`
+	// Signature is the HMAC of the underlying event's hash that was stored
+	// when it was written. It's empty for entries written before signing was
+	// configured.
+	Signature string `json:"signature,omitempty"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+}
+
+// AuditLogChange is the change to a single field of a resource. Nested
+// fields are separated by dots.
+type AuditLogChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// computeHash returns the hash of the entry chained to the given previous
+// hash.
+func (e *AuditLogEntry) computeHash(prevHash string) (string, error) {
+	unhashed := *e
+	unhashed.PrevHash = ""
+	unhashed.Hash = ""
+	b, err := json.Marshal(unhashed)
+	if err != nil {
+		return "", errors.Wrapf(err, "marshalling audit log entry '%s'", e.ID)
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(prevHash))
+	_, _ = h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChainAuditLogEntries sets the hashes of the entries in order, starting from
+// the given previous hash. An empty previous hash starts a new chain.
+func ChainAuditLogEntries(entries []AuditLogEntry, prevHash string) error {
+	for i := range entries {
+		hash, err := entries[i].computeHash(prevHash)
+		if err != nil {
+			return err
+		}
+		entries[i].PrevHash = prevHash
+		entries[i].Hash = hash
+		prevHash = hash
+	}
+	return nil
+}
+
+// VerifyAuditLogChain checks that the JSONL-exported audit log has not been
+// modified since it was exported. It returns an error identifying the first
+// line that breaks the chain.
+func VerifyAuditLogChain(r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	prevHash := ""
+	num := 0
+	for {
+		var entry AuditLogEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return num, errors.Wrapf(err, "decoding audit log entry %d", num+1)
+		}
+		num++
+
+		if num > 1 && entry.PrevHash != prevHash {
+			return num, errors.Errorf("audit log entry %d ('%s') does not follow the previous entry", num, entry.ID)
+		}
+		hash, err := entry.computeHash(entry.PrevHash)
+		if err != nil {
+			return num, err
+		}
+		if hash != entry.Hash {
+			return num, errors.Errorf("audit log entry %d ('%s') has been modified", num, entry.ID)
+		}
+		prevHash = entry.Hash
+	}
+
+	return num, nil
+}
+
+// WriteAuditLogJSONL writes the entries as JSON lines.
+func WriteAuditLogJSONL(w io.Writer, entries []AuditLogEntry) error {
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return errors.Wrapf(err, "writing audit log entry '%s'", entry.ID)
+		}
+	}
+	return nil
+}
+
+// WriteAuditLogCSV writes the entries as CSV. The changes to each entry are
+// written as a JSON array in a single column.
+func WriteAuditLogCSV(w io.Writer, entries []AuditLogEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "timestamp", "actor", "resource_type", "resource_id", "event_type", "changes", "seq", "event_hash", "signature", "prev_hash", "hash"}); err != nil {
+		return errors.Wrap(err, "writing CSV header")
+	}
+	for _, entry := range entries {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return errors.Wrapf(err, "marshalling changes for audit log entry '%s'", entry.ID)
+		}
+		if err := cw.Write([]string{
+			entry.ID,
+			entry.Timestamp.Format(time.RFC3339Nano),
+			entry.Actor,
+			entry.ResourceType,
+			entry.ResourceId,
+			entry.EventType,
+			string(changes),
+			strconv.FormatInt(entry.Seq, 10),
+			entry.EventHash,
+			entry.Signature,
+			entry.PrevHash,
+			entry.Hash,
+		}); err != nil {
+			return errors.Wrapf(err, "writing audit log entry '%s'", entry.ID)
+		}
+	}
+	cw.Flush()
+	return errors.Wrap(cw.Error(), "flushing CSV")
+}
+
+// AuditLogQuery filters the audit log. All filters are optional.
+type AuditLogQuery struct {
+	Actor        string
+	ResourceType string
+	ResourceId   string
+	StartTime    time.Time
+	EndTime      time.Time
+	Limit        int
+}
+
+// Validate checks that the query filters are valid and defaults the limit.
+func (q *AuditLogQuery) Validate() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultAuditLogLimit
+	}
+	catcher := grip.NewBasicCatcher()
+	catcher.ErrorfWhen(q.ResourceType != "" && !utility.StringSliceContains(AuditLogResourceTypes, q.ResourceType),
+		"invalid resource type '%s', must be one of: %s", q.ResourceType, strings.Join(AuditLogResourceTypes, ", "))
+	catcher.NewWhen(!utility.IsZeroTime(q.StartTime) && !utility.IsZeroTime(q.EndTime) && q.EndTime.Before(q.StartTime), "end time cannot be before start time")
+	catcher.NewWhen(q.Limit < 0, "limit cannot be negative")
+	return catcher.Resolve()
+}
+
+var (
+	adminEventUserKey    = bsonutil.GetDottedKeyName(event.DataKey, "user")
+	adminEventSectionKey = bsonutil.GetDottedKeyName(event.DataKey, "section")
+	projectEventUserKey  = bsonutil.GetDottedKeyName(event.DataKey, "user")
+	userEventActorKey    = bsonutil.GetDottedKeyName(event.DataKey, event.UserDataActorKey)
+)
+
+func (q *AuditLogQuery) filter() bson.M {
+	filter := bson.M{event.ResourceTypeKey: bson.M{"$in": AuditLogResourceTypes}}
+	if q.ResourceType != "" {
+		filter[event.ResourceTypeKey] = q.ResourceType
+	}
+
+	and := []bson.M{}
+	if q.Actor != "" {
+		and = append(and, bson.M{"$or": []bson.M{
+			{event.ResourceTypeKey: event.ResourceTypeAdmin, adminEventUserKey: q.Actor},
+			{event.ResourceTypeKey: event.EventResourceTypeProject, projectEventUserKey: q.Actor},
+			{event.ResourceTypeKey: event.ResourceTypeUser, userEventActorKey: q.Actor},
+		}})
+	}
+	if q.ResourceId != "" {
+		and = append(and, bson.M{"$or": []bson.M{
+			{event.ResourceTypeKey: event.ResourceTypeAdmin, adminEventSectionKey: q.ResourceId},
+			{event.ResourceTypeKey: bson.M{"$ne": event.ResourceTypeAdmin}, event.ResourceIdKey: q.ResourceId},
+		}})
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
+	ts := bson.M{}
+	if !utility.IsZeroTime(q.StartTime) {
+		ts["$gte"] = q.StartTime
+	}
+	if !utility.IsZeroTime(q.EndTime) {
+		ts["$lte"] = q.EndTime
+	}
+	if len(ts) > 0 {
+		filter[event.TimestampKey] = ts
+	}
+
+	return filter
+}
+
+// FindAuditLog returns the audit log entries matching the query in
+// chronological order, chained together starting from a new export chain. It
+// returns an error if any of the underlying events were modified, removed, or
+// reordered since they were written.
+func FindAuditLog(q AuditLogQuery) ([]AuditLogEntry, error) {
+	if err := q.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid audit log query")
+	}
+	entries, err := findAuditLogEntries(q.filter(), q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := ChainAuditLogEntries(entries, ""); err != nil {
+		return nil, errors.Wrap(err, "chaining audit log entries")
+	}
+	return entries, nil
+}
+
+func findAuditLogEntries(filter bson.M, limit int) ([]AuditLogEntry, error) {
+	key, err := evergreen.GetEnvironment().Settings().AuditLog.HMACKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "loading audit log HMAC key")
+	}
+
+	query := db.Query(filter).Sort([]string{event.TimestampKey, "_id"})
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	events := []auditLogEvent{}
+	if err := db.FindAllQ(event.EventCollection, query, &events); err != nil {
+		return nil, errors.Wrap(err, "finding audit log events")
+	}
+	if err := checkAuditLogEvents(events, key); err != nil {
+		return nil, err
+	}
+
+	entries := make([]AuditLogEntry, 0, len(events))
+	for _, e := range events {
+		entry, err := e.toAuditLogEntry()
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting event '%s' to audit log entry", e.ID)
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+// checkAuditLogEvents returns an error if any of the events was modified
+// since it was written, or if the event before it in the chain was removed or
+// replaced. If signing is configured, it also returns an error if any event
+// written since signing began is missing its signature.
+func checkAuditLogEvents(events []auditLogEvent, key []byte) error {
+	var signedSinceSeq int64
+	if key != nil {
+		state, err := event.FindAuditLogChainState()
+		if err != nil {
+			return err
+		}
+		signedSinceSeq = state.SignedSinceSeq
+	}
+
+	hashes := map[int64]string{}
+	for _, e := range events {
+		if e.AuditSeq > 0 {
+			hashes[e.AuditSeq] = e.AuditHash
+		}
+	}
+	missing := []int64{}
+	for _, e := range events {
+		if _, ok := hashes[e.AuditSeq-1]; e.AuditSeq > 1 && !ok {
+			missing = append(missing, e.AuditSeq-1)
+		}
+	}
+	if len(missing) > 0 {
+		prev := []struct {
+			AuditSeq  int64  `bson:"audit_seq"`
+			AuditHash string `bson:"audit_hash"`
+		}{}
+		query := db.Query(bson.M{event.AuditSeqKey: bson.M{"$in": missing}}).WithFields(event.AuditSeqKey, event.AuditHashKey)
+		if err := db.FindAllQ(event.EventCollection, query, &prev); err != nil {
+			return errors.Wrap(err, "finding preceding audit log events")
+		}
+		for _, p := range prev {
+			hashes[p.AuditSeq] = p.AuditHash
+		}
+	}
+
+	for _, e := range events {
+		if err := e.check(key, signedSinceSeq, hashes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditLogEvent is an event whose data is decoded according to the audit log
+// resource type it belongs to.
+type auditLogEvent struct {
+	event.UnmarshalEventLogEntry `bson:",inline"`
+}
+
+func (e *auditLogEvent) UnmarshalBSON(in []byte) error { return mgobson.Unmarshal(in, e) }
+
+func (e *auditLogEvent) SetBSON(raw mgobson.Raw) error {
+	return errors.Wrap(raw.Unmarshal(&e.UnmarshalEventLogEntry), "unmarshalling event log entry")
+}
+
+func (e *auditLogEvent) id() string {
+	switch v := e.ID.(type) {
+	case string:
+		return v
+	case mgobson.ObjectId:
+		return v.Hex()
+	case primitive.ObjectID:
+		return v.Hex()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// check returns an error if the event doesn't match its hash or signature,
+// or if it doesn't follow the event before it in the chain, whose hash is
+// looked up by sequence number. Events written before the chain was added
+// can't be checked.
+func (e *auditLogEvent) check(key []byte, signedSinceSeq int64, hashes map[int64]string) error {
+	if e.AuditSeq == 0 {
+		return nil
+	}
+	id := e.id()
+	expected := event.AuditLogHash(e.AuditPrevHash, e.AuditSeq, id, e.ResourceType, e.ResourceId, e.EventType, e.Timestamp, e.Data.Data)
+	if expected != e.AuditHash {
+		return errors.Errorf("audit log event '%s' does not match its hash, so it was modified after it was written", id)
+	}
+	if e.AuditSeq == 1 && e.AuditPrevHash != "" {
+		return errors.Errorf("audit log event '%s' is first in the chain but follows another event", id)
+	}
+	if e.AuditSeq > 1 {
+		prevHash, ok := hashes[e.AuditSeq-1]
+		if !ok {
+			return errors.Errorf("the audit log event before '%s' was removed", id)
+		}
+		if prevHash != e.AuditPrevHash {
+			return errors.Errorf("audit log event '%s' does not follow the event before it, so events were modified or reordered", id)
+		}
+	}
+
+	if key == nil {
+		return nil
+	}
+	if e.AuditHMAC == "" {
+		if signedSinceSeq > 0 && e.AuditSeq >= signedSinceSeq {
+			return errors.Errorf("audit log event '%s' is missing its signature, so it was modified after it was written", id)
+		}
+		return nil
+	}
+	if !hmac.Equal([]byte(event.AuditLogHMAC(key, e.AuditHash)), []byte(e.AuditHMAC)) {
+		return errors.Errorf("audit log event '%s' does not match its signature, so it was modified after it was written", id)
+	}
+	return nil
+}
+
+func (e *auditLogEvent) toAuditLogEntry() (*AuditLogEntry, error) {
+	entry := &AuditLogEntry{
+		ID:           e.id(),
+		Timestamp:    e.Timestamp.UTC().Truncate(time.Millisecond),
+		ResourceType: e.ResourceType,
+		ResourceId:   e.ResourceId,
+		EventType:    e.EventType,
+		Seq:          e.AuditSeq,
+		EventHash:    e.AuditHash,
+		Signature:    e.AuditHMAC,
+	}
+
+	var before, after interface{}
+	switch e.ResourceType {
+	case event.ResourceTypeAdmin:
+		data := struct {
+			User    string `bson:"user"`
+			Section string `bson:"section"`
+			Changes struct {
+				Before mgobson.M `bson:"before"`
+				After  mgobson.M `bson:"after"`
+			} `bson:"changes"`
+		}{}
+		if err := e.Data.Unmarshal(&data); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling admin event data")
+		}
+		entry.Actor = data.User
+		entry.ResourceId = data.Section
+		before, after = data.Changes.Before, data.Changes.After
+	case event.EventResourceTypeProject:
+		data := ProjectChangeEvent{}
+		if err := e.Data.Unmarshal(&data); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling project event data")
+		}
+		data.Before.Vars = *data.Before.Vars.RedactPrivateVars()
+		data.After.Vars = *data.After.Vars.RedactPrivateVars()
+		entry.Actor = data.User
+		before, after = data.Before, data.After
+	case event.ResourceTypeUser:
+		data := struct {
+			Actor  string      `bson:"actor"`
+			Before interface{} `bson:"before"`
+			After  interface{} `bson:"after"`
+		}{}
+		if err := e.Data.Unmarshal(&data); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling user event data")
+		}
+		entry.Actor = data.Actor
+		before, after = data.Before, data.After
+	default:
+		return nil, errors.Errorf("resource type '%s' is not part of the audit log", e.ResourceType)
+	}
+
+	changes, err := diffAuditLogValues(before, after, e.ResourceType == event.ResourceTypeAdmin)
+	if err != nil {
+		return nil, err
+	}
+	entry.Changes = changes
+
+	return entry, nil
+}
+
+// diffAuditLogValues returns the fields that differ between the JSON
+// representations of before and after, sorted by field name. Arrays are
+// compared as a whole.
+func diffAuditLogValues(before, after interface{}, redactSecrets bool) ([]AuditLogChange, error) {
+	beforeVal, err := toJSONValue(before)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting before value")
+	}
+	afterVal, err := toJSONValue(after)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting after value")
+	}
+
+	beforeFields := map[string]interface{}{}
+	flattenAuditLogValue("", beforeVal, beforeFields)
+	afterFields := map[string]interface{}{}
+	flattenAuditLogValue("", afterVal, afterFields)
+
+	changes := []AuditLogChange{}
+	for field, b := range beforeFields {
+		a := afterFields[field]
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes = append(changes, AuditLogChange{Field: field, Before: b, After: a})
+	}
+	for field, a := range afterFields {
+		if _, ok := beforeFields[field]; ok {
+			continue
+		}
+		changes = append(changes, AuditLogChange{Field: field, After: a})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	if redactSecrets {
+		for i := range changes {
+			if isAuditLogSecretField(changes[i].Field) {
+				changes[i].Before = redactAuditLogValue(changes[i].Before)
+				changes[i].After = redactAuditLogValue(changes[i].After)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// toJSONValue converts the value to its generic JSON representation.
+func toJSONValue(in interface{}) (interface{}, error) {
+	if in == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func flattenAuditLogValue(prefix string, val interface{}, out map[string]interface{}) {
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		if prefix == "" {
+			prefix = "value"
+		}
+		out[prefix] = val
+		return
+	}
+	for k, v := range obj {
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		flattenAuditLogValue(field, v, out)
+	}
+}
+
+func isAuditLogSecretField(field string) bool {
+	parts := strings.Split(strings.ToLower(field), ".")
+	for _, part := range parts {
+		for _, secret := range auditLogSecretKeys {
+			if strings.Contains(part, secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func redactAuditLogValue(val interface{}) interface{} {
+	if val == nil || val == "" {
+		return val
+	}
+	return auditLogRedactedValue
+}
+
+// AuditLogStreamState tracks the last audit log entry that was streamed to
+// the configured sink, so that streaming can resume where it left off and
+// continue the hash chain.
+type AuditLogStreamState struct {
+	ID            string    `bson:"_id"`
+	LastTimestamp time.Time `bson:"last_timestamp"`
+	LastEventID   string    `bson:"last_event_id"`
+	LastHash      string    `bson:"last_hash"`
+}
+
+var (
+	auditLogStreamStateLastTimestampKey = bsonutil.MustHaveTag(AuditLogStreamState{}, "LastTimestamp")
+	auditLogStreamStateLastEventIDKey   = bsonutil.MustHaveTag(AuditLogStreamState{}, "LastEventID")
+	auditLogStreamStateLastHashKey      = bsonutil.MustHaveTag(AuditLogStreamState{}, "LastHash")
+)
+
+// GetAuditLogStreamState returns the current audit log stream state. If the
+// audit log has never been streamed, it returns an empty state.
+func GetAuditLogStreamState() (*AuditLogStreamState, error) {
+	state := &AuditLogStreamState{}
+	err := db.FindOneQ(AuditLogStreamStateCollection, db.Query(bson.M{"_id": auditLogStreamStateID}), state)
+	if adb.ResultsNotFound(err) {
+		return &AuditLogStreamState{ID: auditLogStreamStateID}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding audit log stream state")
+	}
+	return state, nil
+}
+
+// FindUnstreamedAuditLog returns up to limit audit log entries that come
+// after the stream state and occurred no later than the given time, chained
+// to the last streamed entry.
+func (s *AuditLogStreamState) FindUnstreamedAuditLog(until time.Time, limit int) ([]AuditLogEntry, error) {
+	filter := bson.M{event.ResourceTypeKey: bson.M{"$in": AuditLogResourceTypes}}
+	if utility.IsZeroTime(s.LastTimestamp) {
+		filter[event.TimestampKey] = bson.M{"$lte": until}
+	} else {
+		filter["$or"] = []bson.M{
+			{event.TimestampKey: bson.M{"$gt": s.LastTimestamp, "$lte": until}},
+			{event.TimestampKey: s.LastTimestamp, "_id": bson.M{"$gt": s.LastEventID}},
+		}
+	}
+
+	entries, err := findAuditLogEntries(filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := ChainAuditLogEntries(entries, s.LastHash); err != nil {
+		return nil, errors.Wrap(err, "chaining audit log entries")
+	}
+	return entries, nil
+}
+
+// Advance records that the given entry was the last one streamed.
+func (s *AuditLogStreamState) Advance(last AuditLogEntry) error {
+	_, err := db.Upsert(AuditLogStreamStateCollection, bson.M{"_id": auditLogStreamStateID}, bson.M{
+		"$set": bson.M{
+			auditLogStreamStateLastTimestampKey: last.Timestamp,
+			auditLogStreamStateLastEventIDKey:   last.ID,
+			auditLogStreamStateLastHashKey:      last.Hash,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "updating audit log stream state")
+	}
+	s.ID = auditLogStreamStateID
+	s.LastTimestamp = last.Timestamp
+	s.LastEventID = last.ID
+	s.LastHash = last.Hash
+	return nil
+}
@@ -0,0 +1,319 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAuditLogChain(t *testing.T) {
+	makeEntries := func() []AuditLogEntry {
+		return []AuditLogEntry{
+			{
+				ID:           "e1",
+				Timestamp:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				Actor:        "admin",
+				ResourceType: event.ResourceTypeAdmin,
+				ResourceId:   "amboy",
+				EventType:    event.EventTypeValueChanged,
+				Changes:      []AuditLogChange{{Field: "pool_size_local", Before: 1, After: 2.5}},
+			},
+			{
+				ID:           "e2",
+				Timestamp:    time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+				Actor:        "admin",
+				ResourceType: event.ResourceTypeUser,
+				ResourceId:   "user",
+				EventType:    string(event.UserEventTypeRolesUpdate),
+				Changes:      []AuditLogChange{{Field: "value", Before: []interface{}{}, After: []interface{}{"superuser"}}},
+			},
+		}
+	}
+	export := func(t *testing.T, entries []AuditLogEntry) string {
+		require.NoError(t, ChainAuditLogEntries(entries, ""))
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteAuditLogJSONL(buf, entries))
+		return buf.String()
+	}
+
+	t.Run("ExportedLogVerifies", func(t *testing.T) {
+		entries := makeEntries()
+		out := export(t, entries)
+		assert.Empty(t, entries[0].PrevHash)
+		assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+
+		num, err := VerifyAuditLogChain(strings.NewReader(out))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, num)
+	})
+	t.Run("ModifiedEntryFails", func(t *testing.T) {
+		out := export(t, makeEntries())
+		out = strings.Replace(out, "superuser", "nobody", 1)
+
+		num, err := VerifyAuditLogChain(strings.NewReader(out))
+		assert.Error(t, err)
+		assert.Equal(t, 2, num)
+	})
+	t.Run("RemovedEntryFails", func(t *testing.T) {
+		entries := makeEntries()
+		entries = append(entries, AuditLogEntry{ID: "e3", ResourceType: event.ResourceTypeUser})
+		out := export(t, entries)
+		lines := strings.SplitAfter(out, "\n")
+		out = lines[0] + lines[2]
+
+		num, err := VerifyAuditLogChain(strings.NewReader(out))
+		assert.Error(t, err)
+		assert.Equal(t, 2, num)
+	})
+	t.Run("ChainContinuesFromPreviousHash", func(t *testing.T) {
+		entries := makeEntries()
+		require.NoError(t, ChainAuditLogEntries(entries[:1], ""))
+		continued := entries[1:]
+		require.NoError(t, ChainAuditLogEntries(continued, entries[0].Hash))
+
+		whole := makeEntries()
+		require.NoError(t, ChainAuditLogEntries(whole, ""))
+		assert.Equal(t, whole[1].Hash, continued[0].Hash)
+	})
+	t.Run("CSVIncludesHeaderAndChanges", func(t *testing.T) {
+		entries := makeEntries()
+		require.NoError(t, ChainAuditLogEntries(entries, ""))
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteAuditLogCSV(buf, entries))
+
+		records, err := csv.NewReader(buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, "id", records[0][0])
+		assert.Equal(t, "e1", records[1][0])
+		assert.Equal(t, `[{"field":"pool_size_local","before":1,"after":2.5}]`, records[1][6])
+		assert.Equal(t, entries[1].Hash, records[2][11])
+	})
+}
+
+func TestDiffAuditLogValues(t *testing.T) {
+	t.Run("NestedFieldsAreFlattened", func(t *testing.T) {
+		before := map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": "same"}, "list": []string{"x"}}
+		after := map[string]interface{}{"a": map[string]interface{}{"b": 2, "c": "same"}, "list": []string{"x", "y"}, "new": true}
+
+		changes, err := diffAuditLogValues(before, after, false)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+		assert.Equal(t, "a.b", changes[0].Field)
+		assert.Equal(t, "list", changes[1].Field)
+		assert.Equal(t, "new", changes[2].Field)
+		assert.Nil(t, changes[2].Before)
+		assert.Equal(t, true, changes[2].After)
+	})
+	t.Run("NonObjectValuesAreCompared", func(t *testing.T) {
+		changes, err := diffAuditLogValues([]string{"r1"}, []string{"r1", "r2"}, false)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "value", changes[0].Field)
+	})
+	t.Run("SecretsAreRedacted", func(t *testing.T) {
+		before := map[string]interface{}{"github_token": "old", "client_secret": "", "url": "a"}
+		after := map[string]interface{}{"github_token": "new", "client_secret": "s", "url": "b"}
+
+		changes, err := diffAuditLogValues(before, after, true)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+		assert.Equal(t, "client_secret", changes[0].Field)
+		assert.Equal(t, "", changes[0].Before)
+		assert.Equal(t, auditLogRedactedValue, changes[0].After)
+		assert.Equal(t, auditLogRedactedValue, changes[1].Before)
+		assert.Equal(t, auditLogRedactedValue, changes[1].After)
+		assert.Equal(t, "b", changes[2].After)
+	})
+}
+
+func TestAuditLogQueryValidate(t *testing.T) {
+	q := &AuditLogQuery{}
+	assert.NoError(t, q.Validate())
+	assert.Equal(t, DefaultAuditLogLimit, q.Limit)
+	assert.NoError(t, (&AuditLogQuery{ResourceType: event.EventResourceTypeProject}).Validate())
+	assert.Error(t, (&AuditLogQuery{ResourceType: event.ResourceTypeHost}).Validate())
+	assert.Error(t, (&AuditLogQuery{StartTime: time.Now(), EndTime: time.Now().Add(-time.Hour)}).Validate())
+	assert.Error(t, (&AuditLogQuery{Limit: -1}).Validate())
+}
+
+func TestFindAuditLog(t *testing.T) {
+	require.NoError(t, db.ClearCollections(event.EventCollection, AuditLogStreamStateCollection, event.AuditLogChainCollection))
+	defer func() {
+		assert.NoError(t, db.ClearCollections(event.EventCollection, AuditLogStreamStateCollection, event.AuditLogChainCollection))
+	}()
+
+	require.NoError(t, event.LogUserEventByActor("u1", "admin", event.UserEventTypeRolesUpdate, []string{}, []string{"r1"}))
+	before := &ProjectSettings{ProjectRef: ProjectRef{Id: "p1", Enabled: true}, Vars: ProjectVars{
+		Vars:        map[string]string{"secret": "hidden"},
+		PrivateVars: map[string]bool{"secret": true},
+	}}
+	after := &ProjectSettings{ProjectRef: ProjectRef{Id: "p1"}, Vars: ProjectVars{
+		Vars:        map[string]string{"secret": "changed"},
+		PrivateVars: map[string]bool{"secret": true},
+	}}
+	require.NoError(t, LogProjectModified("p1", "me", before, after))
+	require.NoError(t, event.LogUserEvent("u2", event.UserEventTypeFavoriteProjectsUpdate, []string{}, []string{"p1"}))
+
+	t.Run("AllEntries", func(t *testing.T) {
+		entries, err := FindAuditLog(AuditLogQuery{})
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "admin", entries[0].Actor)
+		assert.Equal(t, "u1", entries[0].ResourceId)
+		assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+		for _, c := range entries[1].Changes {
+			assert.NotContains(t, c.Field, "vars.vars.secret", "private variables should be redacted")
+		}
+	})
+	t.Run("FilterByActor", func(t *testing.T) {
+		entries, err := FindAuditLog(AuditLogQuery{Actor: "me"})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, event.EventResourceTypeProject, entries[0].ResourceType)
+		assert.Equal(t, "p1", entries[0].ResourceId)
+	})
+	t.Run("FilterByResource", func(t *testing.T) {
+		entries, err := FindAuditLog(AuditLogQuery{ResourceType: event.ResourceTypeUser, ResourceId: "u2"})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Empty(t, entries[0].Actor)
+	})
+	t.Run("StreamStateResumes", func(t *testing.T) {
+		state, err := GetAuditLogStreamState()
+		require.NoError(t, err)
+		entries, err := state.FindUnstreamedAuditLog(time.Now(), 2)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.NoError(t, state.Advance(entries[1]))
+
+		state, err = GetAuditLogStreamState()
+		require.NoError(t, err)
+		remaining, err := state.FindUnstreamedAuditLog(time.Now(), 0)
+		require.NoError(t, err)
+		require.Len(t, remaining, 1)
+		assert.Equal(t, entries[1].Hash, remaining[0].PrevHash)
+	})
+}
+
+func TestFindAuditLogChecksChain(t *testing.T) {
+	setup := func(t *testing.T) []AuditLogEntry {
+		require.NoError(t, db.ClearCollections(event.EventCollection, event.AuditLogChainCollection))
+		for _, u := range []string{"u1", "u2", "u3"} {
+			require.NoError(t, event.LogUserEventByActor(u, "admin", event.UserEventTypeRolesUpdate, []string{}, []string{"r1"}))
+		}
+		entries, err := FindAuditLog(AuditLogQuery{})
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		return entries
+	}
+	defer func() {
+		assert.NoError(t, db.ClearCollections(event.EventCollection, event.AuditLogChainCollection))
+	}()
+
+	t.Run("EventsAreChainedWhenWritten", func(t *testing.T) {
+		entries := setup(t)
+		for i, entry := range entries {
+			assert.EqualValues(t, i+1, entry.Seq)
+			assert.NotEmpty(t, entry.EventHash)
+		}
+
+		e := event.EventLogEntry{}
+		require.NoError(t, db.FindOneQ(event.EventCollection, db.Query(bson.M{"_id": entries[1].ID}), &e))
+		assert.Equal(t, entries[0].EventHash, e.AuditPrevHash)
+	})
+	t.Run("RemovedEventFails", func(t *testing.T) {
+		entries := setup(t)
+		require.NoError(t, db.Remove(event.EventCollection, bson.M{"_id": entries[1].ID}))
+
+		_, err := FindAuditLog(AuditLogQuery{})
+		assert.Error(t, err)
+		_, err = FindAuditLog(AuditLogQuery{ResourceId: "u3"})
+		assert.Error(t, err, "the preceding event should be checked even if it's filtered out")
+	})
+	t.Run("ReorderedEventsFail", func(t *testing.T) {
+		entries := setup(t)
+		require.NoError(t, db.Update(event.EventCollection, bson.M{"_id": entries[0].ID}, bson.M{"$set": bson.M{event.AuditSeqKey: 2}}))
+		require.NoError(t, db.Update(event.EventCollection, bson.M{"_id": entries[1].ID}, bson.M{"$set": bson.M{event.AuditSeqKey: 1}}))
+
+		_, err := FindAuditLog(AuditLogQuery{})
+		assert.Error(t, err)
+	})
+	t.Run("ModifiedEventFails", func(t *testing.T) {
+		entries := setup(t)
+		require.NoError(t, db.Update(event.EventCollection, bson.M{"_id": entries[2].ID}, bson.M{
+			"$set": bson.M{bsonutil.GetDottedKeyName(event.DataKey, event.UserDataActorKey): "someone_else"},
+		}))
+
+		_, err := FindAuditLog(AuditLogQuery{})
+		assert.Error(t, err)
+	})
+}
+
+func TestFindAuditLogChecksSignatures(t *testing.T) {
+	require.NoError(t, db.ClearCollections(event.EventCollection, event.AuditLogChainCollection))
+	defer func() {
+		assert.NoError(t, db.ClearCollections(event.EventCollection, event.AuditLogChainCollection))
+	}()
+
+	settings := evergreen.GetEnvironment().Settings()
+	oldKeyFile := settings.AuditLog.HMACKeyFile
+	defer func() {
+		settings.AuditLog.HMACKeyFile = oldKeyFile
+	}()
+
+	settings.AuditLog.HMACKeyFile = ""
+	require.NoError(t, event.LogUserEventByActor("u1", "admin", event.UserEventTypeRolesUpdate, []string{}, []string{"r1"}))
+
+	keyFile := filepath.Join(t.TempDir(), "audit_key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("secret\n"), 0600))
+	settings.AuditLog.HMACKeyFile = keyFile
+	require.NoError(t, event.LogUserEventByActor("u2", "admin", event.UserEventTypeRolesUpdate, []string{}, []string{"r1"}))
+
+	entries, err := FindAuditLog(AuditLogQuery{})
+	require.NoError(t, err, "events written before signing began should not need a signature")
+	require.Len(t, entries, 2)
+	assert.Empty(t, entries[0].Signature)
+	assert.NotEmpty(t, entries[1].Signature)
+	assert.Equal(t, "admin", entries[1].Actor)
+
+	t.Run("MissingSignatureFails", func(t *testing.T) {
+		require.NoError(t, db.Update(event.EventCollection, bson.M{"_id": entries[1].ID}, bson.M{
+			"$unset": bson.M{"audit_hmac": 1},
+		}))
+		defer func() {
+			assert.NoError(t, db.Update(event.EventCollection, bson.M{"_id": entries[1].ID}, bson.M{
+				"$set": bson.M{"audit_hmac": entries[1].Signature},
+			}))
+		}()
+
+		_, err := FindAuditLog(AuditLogQuery{})
+		assert.Error(t, err, "event written after signing began should be signed")
+	})
+	t.Run("RechainedEventFails", func(t *testing.T) {
+		e := auditLogEvent{}
+		require.NoError(t, db.FindOneQ(event.EventCollection, db.Query(bson.M{"_id": entries[1].ID}), &e))
+		hash := event.AuditLogHash(e.AuditPrevHash, e.AuditSeq, entries[1].ID, e.ResourceType, e.ResourceId, e.EventType, e.Timestamp, e.Data.Data)
+		require.Equal(t, e.AuditHash, hash)
+
+		require.NoError(t, db.Update(event.EventCollection, bson.M{"_id": entries[1].ID}, bson.M{
+			"$set": bson.M{"audit_hash": event.AuditLogHash("", 1, entries[1].ID, e.ResourceType, e.ResourceId, e.EventType, e.Timestamp, e.Data.Data), "audit_prev_hash": "", event.AuditSeqKey: 1},
+		}))
+		require.NoError(t, db.Remove(event.EventCollection, bson.M{"_id": entries[0].ID}))
+
+		_, err := FindAuditLog(AuditLogQuery{})
+		assert.Error(t, err, "re-chained event should not match its signature")
+	})
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/evergreen-ci/evergreen"
+	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
 	"github.com/evergreen-ci/evergreen/model/commitqueue"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/patch"
 	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/thirdparty"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/google/go-github/v52/github"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 )
 
@@ -78,9 +84,135 @@ func CommitQueueRemoveItem(ctx context.Context, cq *commitqueue.CommitQueue, ite
 			Message:    errors.Errorf("item '%s' not found in commit queue", item.Issue).Error(),
 		}
 	}
+	if removed.BatchedInto != "" {
+		// The item's changes are already being tested in another item's
+		// merge patch, so that merge patch has to be tested again without
+		// them.
+		if err = RestartTasksInVersion(ctx, removed.BatchedInto, true, user); err != nil {
+			return nil, errors.Wrapf(err, "restarting batch '%s' without removed item", removed.BatchedInto)
+		}
+	}
 	return removed, nil
 }
 
+// BisectCommitQueueBatch handles a failure in a merge patch that tests a batch
+// of commit queue items. Instead of dequeueing the whole batch, it aborts the
+// merge patch and splits the batch in half so that each half is tested on its
+// own. The batch is bisected again each time a half fails until the item that
+// caused the failure is tested alone, at which point it's dequeued like any
+// other failed item. If the batch was already bisected, e.g. because another
+// task in the merge patch failed at the same time, it does nothing.
+func BisectCommitQueueBatch(ctx context.Context, cq *commitqueue.CommitQueue, version, caller, reason string) error {
+	idx := cq.FindItem(version)
+	if idx < 0 {
+		return errors.Errorf("item '%s' not found in commit queue for project '%s'", version, cq.ProjectID)
+	}
+	tail := cq.Queue[idx]
+	batch := append(cq.BatchMembers(tail.Issue), tail)
+
+	p, err := patch.FindOneId(version)
+	if err != nil {
+		return errors.Wrapf(err, "finding patch '%s'", version)
+	}
+	if p == nil {
+		return errors.Errorf("patch '%s' not found", version)
+	}
+
+	// The aborted merge patch can't be finalized again, so the item that
+	// owned it is retested with a copy of it. The batch is split before
+	// anything else is done so that only one caller can bisect it.
+	retestID := mgobson.NewObjectId()
+	split, err := cq.SplitForBisection(tail.Issue, version, batch, map[string]string{tail.Issue: retestID.Hex()})
+	if err != nil {
+		return errors.Wrap(err, "splitting batch for bisection")
+	}
+	if !split {
+		grip.Info(message.Fields{
+			"message": "commit queue batch was already bisected",
+			"source":  "commit queue",
+			"reason":  reason,
+			"caller":  caller,
+			"project": cq.ProjectID,
+			"version": version,
+		})
+		return nil
+	}
+
+	retest, err := makeBisectionPatch(p, retestID)
+	if err != nil {
+		return errors.Wrapf(err, "making patch to retest item '%s'", tail.Issue)
+	}
+	if err = preventMergeForItem(tail, caller); err != nil {
+		return errors.Wrapf(err, "preventing merge for item '%s'", tail.Issue)
+	}
+	if err = CancelPatch(p, task.AbortInfo{User: caller}); err != nil {
+		return errors.Wrapf(err, "aborting merge patch '%s'", version)
+	}
+
+	grip.Info(message.Fields{
+		"message":      "commit queue batch failed and is being bisected",
+		"source":       "commit queue",
+		"reason":       reason,
+		"caller":       caller,
+		"project":      cq.ProjectID,
+		"version":      version,
+		"batch_size":   len(batch),
+		"retest_patch": retest.Id.Hex(),
+	})
+
+	return nil
+}
+
+// makeBisectionPatch creates an unfinalized copy of a commit queue merge
+// patch with the given ID.
+func makeBisectionPatch(p *patch.Patch, id mgobson.ObjectId) (*patch.Patch, error) {
+	patchDoc := &patch.Patch{
+		Id:                   id,
+		Description:          p.Description,
+		Author:               p.Author,
+		Project:              p.Project,
+		Githash:              p.Githash,
+		Status:               evergreen.PatchCreated,
+		Alias:                p.Alias,
+		Patches:              p.Patches,
+		PatchedProjectConfig: p.PatchedProjectConfig,
+		CreateTime:           time.Now(),
+		MergedFrom:           p.MergedFrom,
+	}
+
+	u, err := user.FindOneById(patchDoc.Author)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding user for patch author '%s'", patchDoc.Author)
+	}
+	if u == nil {
+		return nil, errors.Errorf("patch author '%s' not found", patchDoc.Author)
+	}
+	patchDoc.PatchNumber, err = u.IncPatchNumber()
+	if err != nil {
+		return nil, errors.Wrap(err, "computing patch num")
+	}
+	if err = patchDoc.Insert(); err != nil {
+		return nil, errors.Wrap(err, "inserting patch")
+	}
+
+	if p.MergedFrom != "" {
+		existingPatch, err := patch.FindOneId(p.MergedFrom)
+		grip.Error(message.WrapError(err, message.Fields{
+			"message":        "can't find patch that the merge patch was made from",
+			"existing_patch": p.MergedFrom,
+		}))
+		if existingPatch != nil {
+			grip.Error(message.WrapError(existingPatch.SetMergePatch(patchDoc.Id.Hex()), message.Fields{
+				"message":        "can't mark patch enqueued",
+				"existing_patch": existingPatch.Id.Hex(),
+				"merge_patch":    patchDoc.Id.Hex(),
+			}))
+		}
+	}
+
+	return patchDoc, nil
+}
+
 func RemoveCommitQueueItemForVersion(projectId, version string, user string) (*commitqueue.CommitQueueItem, error) {
 	cq, err := commitqueue.FindOneId(projectId)
 	if err != nil {
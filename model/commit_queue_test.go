@@ -6,23 +6,28 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
+	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/commitqueue"
 	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/patch"
 	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/stretchr/testify/suite"
 )
 
 type CommitQueueSuite struct {
 	suite.Suite
-	q *commitqueue.CommitQueue
+	q   *commitqueue.CommitQueue
+	ctx context.Context
 }
 
 func TestCommitQueueSuite(t *testing.T) {
 	s := new(CommitQueueSuite)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	s.ctx = ctx
 	originalEnv := evergreen.GetEnvironment()
 	env := testutil.NewEnvironment(ctx, t)
 	evergreen.SetEnvironment(env)
@@ -76,3 +81,163 @@ func (s *CommitQueueSuite) TestPreventMergeForItem() {
 	s.Equal(evergreen.DisabledTaskPriority, mergeTask.Priority)
 	s.False(mergeTask.Activated)
 }
+
+// insertMergePatch inserts a finalized commit queue merge patch along with its
+// version, its merge task, and a failed task in it, and returns the failed
+// task.
+func (s *CommitQueueSuite) insertMergePatch(id mgobson.ObjectId) *task.Task {
+	p := &patch.Patch{
+		Id:      id,
+		Project: s.q.ProjectID,
+		Author:  "me",
+		Alias:   evergreen.CommitQueueAlias,
+		Version: id.Hex(),
+		Status:  evergreen.PatchStarted,
+		Patches: []patch.ModulePatch{{Githash: "abcdef"}},
+	}
+	s.Require().NoError(p.Insert())
+	v := &Version{Id: id.Hex()}
+	s.Require().NoError(v.Insert())
+	b := &build.Build{Id: id.Hex(), Version: id.Hex()}
+	s.Require().NoError(b.Insert())
+	mergeTask := &task.Task{
+		Id:               "merge-" + id.Hex(),
+		Version:          id.Hex(),
+		BuildId:          id.Hex(),
+		Project:          s.q.ProjectID,
+		Status:           evergreen.TaskUndispatched,
+		Activated:        true,
+		Requester:        evergreen.MergeTestRequester,
+		CommitQueueMerge: true,
+	}
+	s.Require().NoError(mergeTask.Insert())
+	failedTask := &task.Task{
+		Id:          "test-" + id.Hex(),
+		DisplayName: "test",
+		Version:     id.Hex(),
+		BuildId:     id.Hex(),
+		Project:     s.q.ProjectID,
+		Status:      evergreen.TaskFailed,
+		Requester:   evergreen.MergeTestRequester,
+	}
+	s.Require().NoError(failedTask.Insert())
+	return failedTask
+}
+
+func (s *CommitQueueSuite) TestFailedBatchIsBisected() {
+	s.NoError(db.ClearCollections(commitqueue.Collection, patch.Collection, VersionCollection, build.Collection, task.Collection, user.Collection))
+	u := &user.DBUser{Id: "me"}
+	s.Require().NoError(u.Insert())
+
+	tail := mgobson.NewObjectId()
+	failedTask := s.insertMergePatch(tail)
+	members := []string{mgobson.NewObjectId().Hex(), mgobson.NewObjectId().Hex(), mgobson.NewObjectId().Hex()}
+	s.q.Queue = nil
+	for _, member := range members {
+		s.q.Queue = append(s.q.Queue, commitqueue.CommitQueueItem{Issue: member, PatchId: member, Source: commitqueue.SourceDiff, BatchedInto: tail.Hex()})
+	}
+	s.q.Queue = append(s.q.Queue, commitqueue.CommitQueueItem{Issue: tail.Hex(), PatchId: tail.Hex(), Source: commitqueue.SourceDiff, Version: tail.Hex()})
+	s.Require().NoError(db.ClearCollections(commitqueue.Collection))
+	s.Require().NoError(commitqueue.InsertQueue(s.q))
+
+	s.NoError(HandleEndTaskForCommitQueueTask(s.ctx, failedTask, evergreen.TaskFailed))
+
+	cq, err := commitqueue.FindOneId(s.q.ProjectID)
+	s.Require().NoError(err)
+	s.Require().Len(cq.Queue, 4, "no item should be dequeued until the failure is narrowed down to one item")
+	s.False(cq.Processing())
+	for i, issue := range members {
+		s.Equal(issue, cq.Queue[i].Issue)
+	}
+	retestID := cq.Queue[3].Issue
+	s.NotEqual(tail.Hex(), retestID, "aborted merge patch should be replaced")
+	s.Equal(retestID, cq.Queue[3].PatchId)
+
+	s.NotEmpty(cq.Queue[0].BisectionGroup)
+	s.Equal(cq.Queue[0].BisectionGroup, cq.Queue[1].BisectionGroup)
+	s.NotEmpty(cq.Queue[2].BisectionGroup)
+	s.Equal(cq.Queue[2].BisectionGroup, cq.Queue[3].BisectionGroup)
+	s.NotEqual(cq.Queue[0].BisectionGroup, cq.Queue[2].BisectionGroup)
+
+	batch := cq.NextBatch(10)
+	s.Require().Len(batch, 2, "only the first half should be tested next")
+	s.Equal(members[0], batch[0].Issue)
+	s.Equal(members[1], batch[1].Issue)
+
+	retest, err := patch.FindOneId(retestID)
+	s.Require().NoError(err)
+	s.Require().NotNil(retest)
+	s.Equal(evergreen.PatchCreated, retest.Status)
+	s.Empty(retest.Version)
+	s.Equal(s.q.ProjectID, retest.Project)
+	s.Equal("me", retest.Author)
+	s.Require().Len(retest.Patches, 1)
+	s.Equal("abcdef", retest.Patches[0].Githash)
+
+	mergeTask, err := task.FindOneId("merge-" + tail.Hex())
+	s.Require().NoError(err)
+	s.Require().NotNil(mergeTask)
+	s.False(mergeTask.Activated, "aborted merge patch should not merge")
+}
+
+func (s *CommitQueueSuite) TestBatchIsOnlyBisectedOnce() {
+	s.NoError(db.ClearCollections(commitqueue.Collection, patch.Collection, VersionCollection, build.Collection, task.Collection, user.Collection))
+	u := &user.DBUser{Id: "me"}
+	s.Require().NoError(u.Insert())
+
+	tail := mgobson.NewObjectId()
+	s.insertMergePatch(tail)
+	member := mgobson.NewObjectId().Hex()
+	s.q.Queue = []commitqueue.CommitQueueItem{
+		{Issue: member, PatchId: member, Source: commitqueue.SourceDiff, BatchedInto: tail.Hex()},
+		{Issue: tail.Hex(), PatchId: tail.Hex(), Source: commitqueue.SourceDiff, Version: tail.Hex()},
+	}
+	s.Require().NoError(db.ClearCollections(commitqueue.Collection))
+	s.Require().NoError(commitqueue.InsertQueue(s.q))
+
+	// Both callers read the queue before either of them bisects the batch.
+	first, err := commitqueue.FindOneId(s.q.ProjectID)
+	s.Require().NoError(err)
+	second, err := commitqueue.FindOneId(s.q.ProjectID)
+	s.Require().NoError(err)
+	s.Require().NoError(BisectCommitQueueBatch(s.ctx, first, tail.Hex(), "caller", "first failure"))
+	s.Require().NoError(BisectCommitQueueBatch(s.ctx, second, tail.Hex(), "caller", "second failure"))
+
+	cq, err := commitqueue.FindOneId(s.q.ProjectID)
+	s.Require().NoError(err)
+	s.Require().Len(cq.Queue, 2)
+	s.Equal(member, cq.Queue[0].Issue)
+	s.Equal(first.Queue[1].Issue, cq.Queue[1].Issue)
+	s.NotEqual(cq.Queue[0].BisectionGroup, cq.Queue[1].BisectionGroup)
+
+	patches, err := patch.Find(patch.ByProjectAndCommitQueue(s.q.ProjectID, false))
+	s.Require().NoError(err)
+	s.Len(patches, 2, "only one patch should be made to retest the batch's item")
+}
+
+func (s *CommitQueueSuite) TestFailedSingleItemIsDequeued() {
+	s.NoError(db.ClearCollections(commitqueue.Collection, patch.Collection, VersionCollection, build.Collection, task.Collection))
+
+	item := mgobson.NewObjectId()
+	failedTask := s.insertMergePatch(item)
+	next := mgobson.NewObjectId().Hex()
+	s.q.Queue = []commitqueue.CommitQueueItem{
+		{Issue: item.Hex(), PatchId: item.Hex(), Source: commitqueue.SourceDiff, Version: item.Hex(), BisectionGroup: "group"},
+		{Issue: next, PatchId: next, Source: commitqueue.SourceDiff},
+	}
+	s.Require().NoError(db.ClearCollections(commitqueue.Collection))
+	s.Require().NoError(commitqueue.InsertQueue(s.q))
+
+	s.NoError(HandleEndTaskForCommitQueueTask(s.ctx, failedTask, evergreen.TaskFailed))
+
+	cq, err := commitqueue.FindOneId(s.q.ProjectID)
+	s.Require().NoError(err)
+	s.Require().Len(cq.Queue, 1, "item that failed on its own should be dequeued")
+	s.Equal(next, cq.Queue[0].Issue)
+	s.Empty(cq.Queue[0].BisectionGroup)
+
+	mergeTask, err := task.FindOneId("merge-" + item.Hex())
+	s.Require().NoError(err)
+	s.Require().NotNil(mergeTask)
+	s.False(mergeTask.Activated)
+}
@@ -36,6 +36,20 @@ type CommitQueueItem struct {
 	// QueueLengthAtEnqueue is the length of the queue when the item was enqueued. Used for tracking the speed of the
 	// commit queue as this value is logged when a commit queue item is processed.
 	QueueLengthAtEnqueue int `bson:"queue_length_at_enqueue"`
+	// BatchedInto is the patch ID of the item whose merge patch tests and
+	// merges this item's changes along with its own. Items that are batched
+	// into another item don't have a version of their own.
+	BatchedInto string `bson:"batched_into,omitempty"`
+	// BisectionGroup identifies the items that were split off together from a
+	// batch that failed. Items in a bisection group are only batched with each
+	// other.
+	BisectionGroup string `bson:"bisection_group,omitempty"`
+}
+
+// InProgress returns whether the item is being tested, either with its own
+// version or as part of another item's batch.
+func (i *CommitQueueItem) InProgress() bool {
+	return i.Version != "" || i.BatchedInto != ""
 }
 
 func (i *CommitQueueItem) MarshalBSON() ([]byte, error)  { return mgobson.Marshal(i) }
@@ -85,7 +99,7 @@ func (q *CommitQueue) EnqueueAtFront(item CommitQueueItem) (int, error) {
 
 	newPos := 0
 	for i, item := range q.Queue {
-		if item.Version != "" {
+		if item.InProgress() {
 			newPos = i + 1
 		} else {
 			break
@@ -127,7 +141,7 @@ func (q *CommitQueue) NextUnprocessed(n int) []CommitQueueItem {
 		if i+1 > n {
 			return items
 		}
-		if item.Version != "" {
+		if item.InProgress() {
 			continue
 		}
 		items = append(items, item)
@@ -136,9 +150,147 @@ func (q *CommitQueue) NextUnprocessed(n int) []CommitQueueItem {
 	return items
 }
 
+// NextBatch returns the unprocessed items at the front of the queue that can
+// be tested and merged together in one merge patch. Only CLI patches can be
+// batched, and items that were split off from a failed batch are only batched
+// with the rest of their bisection group. It returns nothing while any item is
+// being tested.
+func (q *CommitQueue) NextBatch(maxSize int) []CommitQueueItem {
+	if q.Processing() || len(q.Queue) == 0 {
+		return nil
+	}
+
+	first := q.Queue[0]
+	items := []CommitQueueItem{first}
+	if first.Source != SourceDiff {
+		return items
+	}
+	for _, item := range q.Queue[1:] {
+		if item.Source != SourceDiff || item.BisectionGroup != first.BisectionGroup {
+			break
+		}
+		if first.BisectionGroup == "" && len(items) >= maxSize {
+			break
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// BatchMembers returns the items that are batched into the given item's merge
+// patch.
+func (q *CommitQueue) BatchMembers(issue string) []CommitQueueItem {
+	members := []CommitQueueItem{}
+	for _, item := range q.Queue {
+		if item.BatchedInto != "" && item.BatchedInto == issue {
+			members = append(members, item)
+		}
+	}
+	return members
+}
+
+// SetBatch batches the items into the merge patch of the given item.
+func (q *CommitQueue) SetBatch(members []CommitQueueItem, issue string) error {
+	for _, member := range members {
+		if err := setBatchedInto(q.ProjectID, member.Issue, issue); err != nil {
+			return errors.Wrapf(err, "batching item '%s' into item '%s'", member.Issue, issue)
+		}
+		for i := range q.Queue {
+			if q.Queue[i].Issue == member.Issue {
+				q.Queue[i].BatchedInto = issue
+			}
+		}
+	}
+	return nil
+}
+
+// ReleaseBatch removes the items from the given item's batch so that they can
+// be tested again. If the issue is empty, it releases the items whose batch's
+// item is no longer in the queue.
+func (q *CommitQueue) ReleaseBatch(issue string) error {
+	catcher := grip.NewBasicCatcher()
+	for i, item := range q.Queue {
+		if item.BatchedInto == "" {
+			continue
+		}
+		if issue == "" && q.FindItem(item.BatchedInto) >= 0 {
+			continue
+		}
+		if issue != "" && item.BatchedInto != issue {
+			continue
+		}
+		if err := setBatchedInto(q.ProjectID, item.Issue, ""); err != nil {
+			catcher.Wrapf(err, "releasing item '%s' from batch", item.Issue)
+			continue
+		}
+		q.Queue[i].BatchedInto = ""
+	}
+	return catcher.Resolve()
+}
+
+// SplitForBisection splits the items into a bisection group of the first
+// half of the items and another of the rest, so that each half is tested as
+// its own batch. The owner is the item whose merge patch tested the batch and
+// version is that merge patch's version. replacements maps the issues of
+// items that have to be tested with a new patch to the new patch's ID. It
+// returns false without splitting the items if the owner is no longer being
+// tested with the version or the other items are no longer batched into it,
+// e.g. because the batch was already split.
+func (q *CommitQueue) SplitForBisection(owner, version string, items []CommitQueueItem, replacements map[string]string) (bool, error) {
+	firstGroup := mgobson.NewObjectId().Hex()
+	secondGroup := mgobson.NewObjectId().Hex()
+	half := (len(items) + 1) / 2
+	resets := make([]bisectionReset, 0, len(items))
+	for i, item := range items {
+		group := firstGroup
+		if i >= half {
+			group = secondGroup
+		}
+		newIssue, ok := replacements[item.Issue]
+		if !ok {
+			newIssue = item.Issue
+		}
+		resets = append(resets, bisectionReset{issue: item.Issue, newIssue: newIssue, group: group})
+	}
+
+	split, err := resetForBisection(q.ProjectID, owner, version, resets)
+	if err != nil {
+		return false, err
+	}
+	if !split {
+		return false, nil
+	}
+	for _, reset := range resets {
+		for j := range q.Queue {
+			if q.Queue[j].Issue != reset.issue {
+				continue
+			}
+			q.Queue[j].Issue = reset.newIssue
+			q.Queue[j].PatchId = reset.newIssue
+			q.Queue[j].Version = ""
+			q.Queue[j].BatchedInto = ""
+			q.Queue[j].BisectionGroup = reset.group
+		}
+	}
+	return true, nil
+}
+
+// NumProcessing returns the number of items in the queue that are currently
+// being tested.
+func (q *CommitQueue) NumProcessing() int {
+	count := 0
+	for _, item := range q.Queue {
+		if item.InProgress() {
+			count++
+		}
+	}
+	return count
+}
+
 func (q *CommitQueue) Processing() bool {
 	for _, item := range q.Queue {
-		if item.Version != "" {
+		if item.InProgress() {
 			return true
 		}
 	}
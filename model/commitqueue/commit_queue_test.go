@@ -302,6 +302,121 @@ func (s *CommitQueueSuite) TestNextUnprocessed() {
 	s.Len(next4, 3)
 }
 
+func (s *CommitQueueSuite) TestNumProcessing() {
+	q := CommitQueue{
+		Queue: []CommitQueueItem{
+			{Issue: "1", Version: "1"},
+			{Issue: "2", Version: "2"},
+			{Issue: "3"},
+		},
+	}
+	s.Equal(2, q.NumProcessing())
+
+	q = CommitQueue{}
+	s.Zero(q.NumProcessing())
+}
+
+func (s *CommitQueueSuite) TestNextBatch() {
+	q := CommitQueue{
+		Queue: []CommitQueueItem{
+			{Issue: "1", Source: SourceDiff},
+			{Issue: "2", Source: SourceDiff},
+			{Issue: "3", Source: SourceDiff},
+			{Issue: "4", Source: SourcePullRequest},
+		},
+	}
+	s.Len(q.NextBatch(10), 3)
+	s.Len(q.NextBatch(2), 2)
+
+	q.Queue[0].BatchedInto = "2"
+	s.Empty(q.NextBatch(10))
+
+	// Bisection groups are batched on their own regardless of size.
+	q.Queue[0].BatchedInto = ""
+	q.Queue[0].BisectionGroup = "a"
+	q.Queue[1].BisectionGroup = "a"
+	q.Queue[2].BisectionGroup = "b"
+	batch := q.NextBatch(1)
+	s.Require().Len(batch, 2)
+	s.Equal("1", batch[0].Issue)
+	s.Equal("2", batch[1].Issue)
+
+	q = CommitQueue{
+		Queue: []CommitQueueItem{
+			{Issue: "1", Source: SourcePullRequest},
+			{Issue: "2", Source: SourceDiff},
+		},
+	}
+	s.Len(q.NextBatch(10), 1)
+}
+
+func (s *CommitQueueSuite) TestBatching() {
+	for _, issue := range []string{"1", "2", "3"} {
+		_, err := s.q.Enqueue(CommitQueueItem{Issue: issue, PatchId: issue, Source: SourceDiff})
+		s.Require().NoError(err)
+	}
+	s.NoError(s.q.SetBatch(s.q.Queue[:2], "3"))
+	s.Len(s.q.BatchMembers("3"), 2)
+	s.Equal(2, s.q.NumProcessing())
+
+	dbq, err := FindOneId("mci")
+	s.Require().NoError(err)
+	s.Len(dbq.BatchMembers("3"), 2)
+
+	// The batch is only released when its item is gone.
+	s.NoError(s.q.ReleaseBatch(""))
+	s.Len(s.q.BatchMembers("3"), 2)
+	_, err = s.q.Remove("3")
+	s.Require().NoError(err)
+	s.NoError(s.q.ReleaseBatch(""))
+	s.Empty(s.q.BatchMembers("3"))
+	s.False(s.q.Processing())
+}
+
+func (s *CommitQueueSuite) TestSplitForBisection() {
+	for _, issue := range []string{"1", "2", "3"} {
+		_, err := s.q.Enqueue(CommitQueueItem{Issue: issue, PatchId: issue, Source: SourceDiff})
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(s.q.SetBatch(s.q.Queue[:2], "3"))
+	s.Require().NoError(s.q.UpdateVersion(&CommitQueueItem{Issue: "3", Version: "3"}))
+
+	staleQueue, err := FindOneId("mci")
+	s.Require().NoError(err)
+
+	split, err := s.q.SplitForBisection("3", "3", s.q.Queue, map[string]string{"3": "4"})
+	s.NoError(err)
+	s.True(split)
+	s.False(s.q.Processing())
+	s.Equal("4", s.q.Queue[2].Issue)
+	s.Equal("4", s.q.Queue[2].PatchId)
+	s.Equal(s.q.Queue[0].BisectionGroup, s.q.Queue[1].BisectionGroup)
+	s.NotEqual(s.q.Queue[1].BisectionGroup, s.q.Queue[2].BisectionGroup)
+
+	dbq, err := FindOneId("mci")
+	s.Require().NoError(err)
+	s.Require().Len(dbq.Queue, 3)
+	for i := range dbq.Queue {
+		s.Equal(s.q.Queue[i].Issue, dbq.Queue[i].Issue)
+		s.Equal(s.q.Queue[i].BisectionGroup, dbq.Queue[i].BisectionGroup)
+		s.False(dbq.Queue[i].InProgress())
+	}
+	batch := dbq.NextBatch(1)
+	s.Len(batch, 2)
+
+	// A batch can only be split once.
+	split, err = staleQueue.SplitForBisection("3", "3", staleQueue.Queue, map[string]string{"3": "5"})
+	s.NoError(err)
+	s.False(split)
+	s.Equal("3", staleQueue.Queue[2].Issue)
+	dbq, err = FindOneId("mci")
+	s.Require().NoError(err)
+	for i := range dbq.Queue {
+		s.Equal(s.q.Queue[i].Issue, dbq.Queue[i].Issue)
+		s.Equal(s.q.Queue[i].BisectionGroup, dbq.Queue[i].BisectionGroup)
+	}
+}
+
 func (s *CommitQueueSuite) TestProcessing() {
 	q := CommitQueue{
 		Queue: []CommitQueueItem{
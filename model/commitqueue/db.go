@@ -1,13 +1,16 @@
 package commitqueue
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/mongodb/anser/bsonutil"
 	adb "github.com/mongodb/anser/db"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const Collection = "commit_queue"
@@ -17,10 +20,13 @@ var (
 	IdKey                   = bsonutil.MustHaveTag(CommitQueue{}, "ProjectID")
 	QueueKey                = bsonutil.MustHaveTag(CommitQueue{}, "Queue")
 	IssueKey                = bsonutil.MustHaveTag(CommitQueueItem{}, "Issue")
+	PatchIdKey              = bsonutil.MustHaveTag(CommitQueueItem{}, "PatchId")
 	VersionKey              = bsonutil.MustHaveTag(CommitQueueItem{}, "Version")
 	EnqueueTimeKey          = bsonutil.MustHaveTag(CommitQueueItem{}, "EnqueueTime")
 	ProcessingStartTimeKey  = bsonutil.MustHaveTag(CommitQueueItem{}, "ProcessingStartTime")
 	QueueLengthAtEnqueueKey = bsonutil.MustHaveTag(CommitQueueItem{}, "QueueLengthAtEnqueue")
+	BatchedIntoKey          = bsonutil.MustHaveTag(CommitQueueItem{}, "BatchedInto")
+	BisectionGroupKey       = bsonutil.MustHaveTag(CommitQueueItem{}, "BisectionGroup")
 )
 
 func updateOne(query interface{}, update interface{}) error {
@@ -106,6 +112,70 @@ func addVersionAndTime(id string, item CommitQueueItem) error {
 		})
 }
 
+func setBatchedInto(id, issue, batchedInto string) error {
+	return updateOne(
+		bson.M{
+			IdKey: id,
+			bsonutil.GetDottedKeyName(QueueKey, IssueKey): issue,
+		},
+		bson.M{
+			"$set": bson.M{
+				bsonutil.GetDottedKeyName(QueueKey, "$", BatchedIntoKey): batchedInto,
+			},
+		})
+}
+
+// bisectionReset describes how an item is reset for bisection. The item's
+// issue and patch are replaced if it will be tested with a new patch. Only CLI
+// patches are batched, so their issue is their patch ID.
+type bisectionReset struct {
+	issue    string
+	newIssue string
+	group    string
+}
+
+// resetForBisection marks the items as untested and puts them in their
+// bisection groups in a single update. The update only happens if the owner
+// is still being tested with the given version and every other item is still
+// batched into it, so that a batch can only be split once. It returns whether
+// the items were reset.
+func resetForBisection(id, owner, version string, resets []bisectionReset) (bool, error) {
+	conditions := bson.A{
+		bson.M{QueueKey: bson.M{"$elemMatch": bson.M{IssueKey: owner, VersionKey: version}}},
+	}
+	set := bson.M{}
+	filters := make([]interface{}, 0, len(resets))
+	for i, reset := range resets {
+		if reset.issue != owner {
+			conditions = append(conditions, bson.M{QueueKey: bson.M{"$elemMatch": bson.M{IssueKey: reset.issue, BatchedIntoKey: owner}}})
+		}
+		elem := fmt.Sprintf("item%d", i)
+		filters = append(filters, bson.M{bsonutil.GetDottedKeyName(elem, IssueKey): reset.issue})
+		elemKey := fmt.Sprintf("$[%s]", elem)
+		set[bsonutil.GetDottedKeyName(QueueKey, elemKey, IssueKey)] = reset.newIssue
+		set[bsonutil.GetDottedKeyName(QueueKey, elemKey, PatchIdKey)] = reset.newIssue
+		set[bsonutil.GetDottedKeyName(QueueKey, elemKey, VersionKey)] = ""
+		set[bsonutil.GetDottedKeyName(QueueKey, elemKey, BatchedIntoKey)] = ""
+		set[bsonutil.GetDottedKeyName(QueueKey, elemKey, BisectionGroupKey)] = reset.group
+	}
+
+	env := evergreen.GetEnvironment()
+	ctx, cancel := env.Context()
+	defer cancel()
+	res, err := env.DB().Collection(Collection).UpdateOne(ctx,
+		bson.M{
+			IdKey:  id,
+			"$and": conditions,
+		},
+		bson.M{"$set": set},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: filters}),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "resetting items for bisection")
+	}
+	return res.MatchedCount > 0, nil
+}
+
 // remove removes a given item from a project's commit queue. Make sure to pass the actual
 // issue identifier and not the patch or version
 func remove(project, issue string) error {
@@ -0,0 +1,153 @@
+package event
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	adb "github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// AuditLogChainCollection stores the state of the audit log hash chain.
+	AuditLogChainCollection = "audit_log_chain"
+
+	auditLogChainID = "audit_log"
+
+	// maxAuditLogAppendAttempts is the number of times appending an event
+	// to the audit log chain is attempted when other events are appended
+	// concurrently.
+	maxAuditLogAppendAttempts = 10
+)
+
+// AuditLogResourceTypes are the resource types of the events that make up
+// the audit log.
+var AuditLogResourceTypes = []string{
+	ResourceTypeAdmin,
+	EventResourceTypeProject,
+	ResourceTypeUser,
+}
+
+// AuditLogChainState records when audit log events began to be signed, so
+// that a signature that's missing from a later event can be detected.
+type AuditLogChainState struct {
+	ID string `bson:"_id"`
+	// SignedSinceSeq is the sequence number of the first signed event, or
+	// zero if no event has been signed.
+	SignedSinceSeq int64 `bson:"signed_since_seq"`
+}
+
+var auditLogChainStateSignedSinceSeqKey = bsonutil.MustHaveTag(AuditLogChainState{}, "SignedSinceSeq")
+
+// FindAuditLogChainState returns the state of the audit log hash chain. If no
+// event has been signed, it returns an empty state.
+func FindAuditLogChainState() (*AuditLogChainState, error) {
+	state := &AuditLogChainState{}
+	err := db.FindOneQ(AuditLogChainCollection, db.Query(bson.M{"_id": auditLogChainID}), state)
+	if adb.ResultsNotFound(err) {
+		return &AuditLogChainState{ID: auditLogChainID}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding audit log chain state")
+	}
+	return state, nil
+}
+
+// AuditLogHash returns the hash of an audit log event chained to the hash of
+// the event before it. It covers the event's position in the chain, its
+// identity, its time to the millisecond that it's stored with, and its data
+// exactly as it's stored.
+func AuditLogHash(prevHash string, seq int64, id, resourceType, resourceID, eventType string, ts time.Time, data []byte) string {
+	h := sha256.New()
+	for _, field := range []string{prevHash, strconv.FormatInt(seq, 10), id, resourceType, resourceID, eventType, strconv.FormatInt(ts.UnixMilli(), 10)} {
+		_, _ = h.Write([]byte(field))
+		_, _ = h.Write([]byte{0})
+	}
+	_, _ = h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditLogHMAC returns the HMAC of an audit log event's hash. Since the hash
+// covers the hash of the event before it, the events can't be re-chained
+// without the key.
+func AuditLogHMAC(key []byte, hash string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *EventLogEntry) isAuditLogEvent() bool {
+	return utility.StringSliceContains(AuditLogResourceTypes, e.ResourceType)
+}
+
+// logAuditLogEvent appends the event to the end of the audit log chain and
+// signs it if signing is configured. Appends are serialized by the unique
+// index on the sequence number: if another event takes the next sequence
+// number first, the insert fails and is retried after the new last event.
+func (e *EventLogEntry) logAuditLogEvent() error {
+	var key []byte
+	if env := evergreen.GetEnvironment(); env != nil && env.Settings() != nil {
+		var err error
+		key, err = env.Settings().AuditLog.HMACKey()
+		if err != nil {
+			return errors.Wrap(err, "loading audit log HMAC key")
+		}
+	}
+
+	// The data is marshalled ahead of time, so that the hashed bytes are
+	// exactly the ones that are stored.
+	data, err := mgobson.Marshal(e.Data)
+	if err != nil {
+		return errors.Wrap(err, "marshalling event data")
+	}
+
+	for attempt := 0; attempt < maxAuditLogAppendAttempts; attempt++ {
+		last := struct {
+			AuditSeq  int64  `bson:"audit_seq"`
+			AuditHash string `bson:"audit_hash"`
+		}{}
+		err = db.FindOneQ(EventCollection, db.Query(bson.M{AuditSeqKey: bson.M{"$exists": true}}).
+			WithFields(AuditSeqKey, AuditHashKey).
+			Sort([]string{"-" + AuditSeqKey}), &last)
+		if err != nil && !adb.ResultsNotFound(err) {
+			return errors.Wrap(err, "finding last audit log event")
+		}
+
+		e.AuditSeq = last.AuditSeq + 1
+		e.AuditPrevHash = last.AuditHash
+		e.AuditHash = AuditLogHash(e.AuditPrevHash, e.AuditSeq, e.ID, e.ResourceType, e.ResourceId, e.EventType, e.Timestamp, data)
+		e.AuditHMAC = ""
+		if key != nil {
+			e.AuditHMAC = AuditLogHMAC(key, e.AuditHash)
+		}
+
+		toInsert := *e
+		toInsert.Data = mgobson.Raw{Kind: 0x03, Data: data}
+		err = db.Insert(EventCollection, &toInsert)
+		if db.IsDuplicateKey(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "inserting audit log event")
+		}
+
+		if key == nil {
+			return nil
+		}
+		_, err = db.Upsert(AuditLogChainCollection, bson.M{"_id": auditLogChainID}, bson.M{
+			"$min": bson.M{auditLogChainStateSignedSinceSeqKey: e.AuditSeq},
+		})
+		return errors.Wrap(err, "recording first signed audit log event")
+	}
+
+	return errors.Wrapf(err, "appending event '%s' to the audit log after %d attempts", e.ID, maxAuditLogAppendAttempts)
+}
@@ -3,6 +3,7 @@ package event
 import (
 	"time"
 
+	"github.com/mongodb/anser/bsonutil"
 	"github.com/pkg/errors"
 )
 
@@ -35,18 +36,33 @@ func (e UserEventType) validate() error {
 
 type userData struct {
 	User   string      `bson:"user" json:"user"`
+	Actor  string      `bson:"actor,omitempty" json:"actor,omitempty"`
 	Before interface{} `bson:"before" json:"before"`
 	After  interface{} `bson:"after" json:"after"`
 }
 
+var (
+	// UserDataActorKey is the key in the event data of a user event that
+	// identifies the user who made the change.
+	UserDataActorKey = bsonutil.MustHaveTag(userData{}, "Actor")
+)
+
 // LogUserEvent logs a DB User change to the event log collection.
 func LogUserEvent(user string, eventType UserEventType, before, after interface{}) error {
+	return LogUserEventByActor(user, "", eventType, before, after)
+}
+
+// LogUserEventByActor logs a DB User change made by the given actor to the
+// event log collection. The actor is empty if the change was not made by a
+// particular user.
+func LogUserEventByActor(user, actor string, eventType UserEventType, before, after interface{}) error {
 	if err := eventType.validate(); err != nil {
 		return errors.Wrapf(err, "invalid user event for user '%s'", user)
 	}
 
 	data := userData{
 		User:   user,
+		Actor:  actor,
 		Before: before,
 		After:  after,
 	}
@@ -23,6 +23,16 @@ type EventLogEntry struct {
 	ResourceId string      `bson:"r_id" json:"resource_id"`
 	EventType  string      `bson:"e_type" json:"event_type"`
 	Data       interface{} `bson:"data" json:"data"`
+
+	// AuditSeq, AuditPrevHash and AuditHash chain the events that are part
+	// of the audit log together in the order that they're written, so that
+	// removed or reordered events can be detected.
+	AuditSeq      int64  `bson:"audit_seq,omitempty" json:"-"`
+	AuditPrevHash string `bson:"audit_prev_hash,omitempty" json:"-"`
+	AuditHash     string `bson:"audit_hash,omitempty" json:"-"`
+	// AuditHMAC signs the audit hash of events that are part of the audit
+	// log when they're written. It's empty if signing isn't configured.
+	AuditHMAC string `bson:"audit_hmac,omitempty" json:"-"`
 }
 
 // Processed is whether or not this event has been processed. An event
@@ -45,6 +55,11 @@ type UnmarshalEventLogEntry struct {
 	ResourceId string      `bson:"r_id" json:"resource_id"`
 	EventType  string      `bson:"e_type" json:"event_type"`
 	Data       mgobson.Raw `bson:"data" json:"data"`
+
+	AuditSeq      int64  `bson:"audit_seq,omitempty" json:"-"`
+	AuditPrevHash string `bson:"audit_prev_hash,omitempty" json:"-"`
+	AuditHash     string `bson:"audit_hash,omitempty" json:"-"`
+	AuditHMAC     string `bson:"audit_hmac,omitempty" json:"-"`
 }
 
 var (
@@ -57,6 +72,8 @@ var (
 	processedAtKey  = bsonutil.MustHaveTag(EventLogEntry{}, "ProcessedAt")
 	TypeKey         = bsonutil.MustHaveTag(EventLogEntry{}, "EventType")
 	DataKey         = bsonutil.MustHaveTag(EventLogEntry{}, "Data")
+	AuditSeqKey     = bsonutil.MustHaveTag(EventLogEntry{}, "AuditSeq")
+	AuditHashKey    = bsonutil.MustHaveTag(EventLogEntry{}, "AuditHash")
 )
 
 const resourceTypeKey = "r_type"
@@ -96,6 +113,10 @@ func (e *EventLogEntry) SetBSON(raw mgobson.Raw) error {
 	e.ProcessedAt = temp.ProcessedAt
 	e.ResourceType = temp.ResourceType
 	e.Expirable = temp.Expirable
+	e.AuditSeq = temp.AuditSeq
+	e.AuditPrevHash = temp.AuditPrevHash
+	e.AuditHash = temp.AuditHash
+	e.AuditHMAC = temp.AuditHMAC
 
 	return nil
 }
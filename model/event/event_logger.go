@@ -13,6 +13,9 @@ func (e *EventLogEntry) Log() error {
 	if err := e.validateEvent(); err != nil {
 		return errors.Wrap(err, "not logging event, event is invalid")
 	}
+	if e.isAuditLogEvent() {
+		return errors.Wrap(e.logAuditLogEvent(), "logging audit log event")
+	}
 
 	return errors.Wrap(db.Insert(EventCollection, e), "inserting event")
 }
@@ -45,18 +48,31 @@ func (e *EventLogEntry) MarkProcessed() error {
 
 func LogManyEvents(events []EventLogEntry) error {
 	catcher := grip.NewBasicCatcher()
-	interfaces := make([]interface{}, len(events))
+	interfaces := []interface{}{}
+	auditLogEvents := []*EventLogEntry{}
 	for i := range events {
 		e := &events[i]
 		if err := e.validateEvent(); err != nil {
 			catcher.Add(err)
 			continue
 		}
-		interfaces[i] = &events[i]
+		// Audit log events are chained one at a time in the order that
+		// they're written.
+		if e.isAuditLogEvent() {
+			auditLogEvents = append(auditLogEvents, e)
+			continue
+		}
+		interfaces = append(interfaces, e)
 	}
 	if catcher.HasErrors() {
 		return errors.Wrap(catcher.Resolve(), "invalid events")
 	}
 
-	return db.InsertMany(EventCollection, interfaces...)
+	if err := db.InsertMany(EventCollection, interfaces...); err != nil {
+		return err
+	}
+	for _, e := range auditLogEvents {
+		catcher.Wrapf(e.logAuditLogEvent(), "logging audit log event '%s'", e.ID)
+	}
+	return catcher.Resolve()
 }
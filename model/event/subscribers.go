@@ -11,17 +11,18 @@ import (
 )
 
 const (
-	GithubPullRequestSubscriberType = "github_pull_request"
-	GithubCheckSubscriberType       = "github_check"
-	GithubMergeSubscriberType       = "github_merge"
-	JIRAIssueSubscriberType         = "jira-issue"
-	JIRACommentSubscriberType       = "jira-comment"
-	EvergreenWebhookSubscriberType  = "evergreen-webhook"
-	EmailSubscriberType             = "email"
-	SlackSubscriberType             = "slack"
-	EnqueuePatchSubscriberType      = "enqueue-patch"
-	SubscriberTypeNone              = "none"
-	RunChildPatchSubscriberType     = "run-child-patch"
+	GithubPullRequestSubscriberType  = "github_pull_request"
+	GithubCheckSubscriberType        = "github_check"
+	GithubMergeSubscriberType        = "github_merge"
+	GitlabMergeRequestSubscriberType = "gitlab_merge_request"
+	JIRAIssueSubscriberType          = "jira-issue"
+	JIRACommentSubscriberType        = "jira-comment"
+	EvergreenWebhookSubscriberType   = "evergreen-webhook"
+	EmailSubscriberType              = "email"
+	SlackSubscriberType              = "slack"
+	EnqueuePatchSubscriberType       = "enqueue-patch"
+	SubscriberTypeNone               = "none"
+	RunChildPatchSubscriberType      = "run-child-patch"
 
 	webhookRetryLimit    = 10
 	webhookMinDelayLimit = 10000
@@ -32,6 +33,7 @@ var SubscriberTypes = []string{
 	GithubPullRequestSubscriberType,
 	GithubCheckSubscriberType,
 	GithubMergeSubscriberType,
+	GitlabMergeRequestSubscriberType,
 	JIRAIssueSubscriberType,
 	JIRACommentSubscriberType,
 	EvergreenWebhookSubscriberType,
@@ -78,6 +80,8 @@ func (s *Subscriber) SetBSON(raw mgobson.Raw) error {
 		s.Target = &GithubCheckSubscriber{}
 	case GithubMergeSubscriberType:
 		s.Target = &GithubMergeSubscriber{}
+	case GitlabMergeRequestSubscriberType:
+		s.Target = &GitlabMergeRequestSubscriber{}
 	case EvergreenWebhookSubscriberType:
 		s.Target = &WebhookSubscriber{}
 	case JIRAIssueSubscriberType:
@@ -210,6 +214,20 @@ type GithubMergeSubscriber struct {
 	Ref   string `bson:"ref"`
 }
 
+// GitlabMergeRequestSubscriber sets commit statuses on a GitLab merge
+// request's head commit.
+type GitlabMergeRequestSubscriber struct {
+	// ProjectPath is the full path of the GitLab project, e.g. group/project.
+	ProjectPath string `bson:"project_path"`
+	MRNumber    int    `bson:"mr_number"`
+	Ref         string `bson:"ref"`
+	ChildId     string `bson:"child"`
+}
+
+func (s *GitlabMergeRequestSubscriber) String() string {
+	return fmt.Sprintf("%s-%d-%s-%s", s.ProjectPath, s.MRNumber, s.Ref, s.ChildId)
+}
+
 type ChildPatchSubscriber struct {
 	ParentStatus string `bson:"parent_status"`
 	ChildPatchId string `bson:"child_patch_id"`
@@ -255,6 +273,13 @@ func NewGithubMergeAPISubscriber(s GithubMergeSubscriber) Subscriber {
 	}
 }
 
+func NewGitlabStatusAPISubscriber(s GitlabMergeRequestSubscriber) Subscriber {
+	return Subscriber{
+		Type:   GitlabMergeRequestSubscriberType,
+		Target: s,
+	}
+}
+
 func NewEmailSubscriber(t string) Subscriber {
 	return Subscriber{
 		Type:   EmailSubscriberType,
@@ -394,6 +394,42 @@ func FindSubscriptionsByAttributes(resourceType string, eventAttributes Attribut
 	return filterRegexSelectors(selectorFiltered, eventAttributes), nil
 }
 
+// MatchesAttributes returns whether the subscription's filter and regex
+// selectors match the attributes of an event. It is the in-memory equivalent
+// of the query performed by FindSubscriptionsByAttributes, for subscriptions
+// that have not been saved.
+func (s *Subscription) MatchesAttributes(eventAttributes Attributes) bool {
+	if eventAttributes.isUnset() {
+		return false
+	}
+
+	filterFields := []struct {
+		filterValue     string
+		attributeValues []string
+	}{
+		{filterValue: s.Filter.Object, attributeValues: eventAttributes.Object},
+		{filterValue: s.Filter.ID, attributeValues: eventAttributes.ID},
+		{filterValue: s.Filter.Project, attributeValues: eventAttributes.Project},
+		{filterValue: s.Filter.Owner, attributeValues: eventAttributes.Owner},
+		{filterValue: s.Filter.Requester, attributeValues: eventAttributes.Requester},
+		{filterValue: s.Filter.Status, attributeValues: eventAttributes.Status},
+		{filterValue: s.Filter.DisplayName, attributeValues: eventAttributes.DisplayName},
+		{filterValue: s.Filter.BuildVariant, attributeValues: eventAttributes.BuildVariant},
+		{filterValue: s.Filter.InVersion, attributeValues: eventAttributes.InVersion},
+		{filterValue: s.Filter.InBuild, attributeValues: eventAttributes.InBuild},
+	}
+	for _, field := range filterFields {
+		if field.filterValue == "" {
+			continue
+		}
+		if !utility.StringSliceContains(field.attributeValues, field.filterValue) {
+			return false
+		}
+	}
+
+	return regexSelectorsMatchEvent(s.RegexSelectors, eventAttributes)
+}
+
 func filterRegexSelectors(subscriptions []Subscription, eventAttributes Attributes) []Subscription {
 	var regexFiltered []Subscription
 	for _, sub := range subscriptions {
@@ -404,6 +404,47 @@ func (s *subscriptionsSuite) TestFilterRegexSelectors() {
 	})
 }
 
+func (s *subscriptionsSuite) TestMatchesAttributes() {
+	eventAttributes := Attributes{
+		ID:      []string{"something"},
+		Project: []string{"proj"},
+		Status:  []string{"failed"},
+	}
+
+	s.Run("EmptyFilterMatches", func() {
+		sub := Subscription{}
+		s.True(sub.MatchesAttributes(eventAttributes))
+	})
+
+	s.Run("MatchingFilter", func() {
+		sub := Subscription{Filter: Filter{ID: "something", Project: "proj"}}
+		s.True(sub.MatchesAttributes(eventAttributes))
+	})
+
+	s.Run("MismatchedFilter", func() {
+		sub := Subscription{Filter: Filter{ID: "something", Status: "success"}}
+		s.False(sub.MatchesAttributes(eventAttributes))
+	})
+
+	s.Run("FilterOnMissingAttribute", func() {
+		sub := Subscription{Filter: Filter{BuildVariant: "bv"}}
+		s.False(sub.MatchesAttributes(eventAttributes))
+	})
+
+	s.Run("MismatchedRegexSelector", func() {
+		sub := Subscription{
+			Filter:         Filter{ID: "something"},
+			RegexSelectors: []Selector{{Type: SelectorStatus, Data: "^succ"}},
+		}
+		s.False(sub.MatchesAttributes(eventAttributes))
+	})
+
+	s.Run("UnsetAttributes", func() {
+		sub := Subscription{}
+		s.False(sub.MatchesAttributes(Attributes{}))
+	})
+}
+
 func (s *subscriptionsSuite) TestRegexSelectorsMatchEvent() {
 	eventAttributes := Attributes{
 		Object: []string{"apple"},
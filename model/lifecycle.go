@@ -1227,9 +1227,13 @@ func createOneTask(id string, creationInfo TaskCreationInfo, buildVarTask BuildV
 		IsEssentialToSucceed:    creationInfo.ActivatedTasksAreEssentialToSucceed && activateTask,
 	}
 
+	t.RetryPolicy = buildVarTask.RetryPolicy
 	projectTask := creationInfo.Project.FindProjectTask(buildVarTask.Name)
 	if projectTask != nil {
 		t.MustHaveResults = utility.FromBoolPtr(projectTask.MustHaveResults)
+		if t.RetryPolicy == nil {
+			t.RetryPolicy = projectTask.RetryPolicy
+		}
 	}
 
 	t.ExecutionPlatform = shouldRunOnContainer(buildVarTask.RunOn, creationInfo.BuildVariant.RunOn, creationInfo.Project.Containers)
@@ -10,6 +10,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/evergreen/thirdparty"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/anser/bsonutil"
 	"github.com/mongodb/grip"
@@ -83,6 +84,9 @@ func (n *Notification) SenderKey() (evergreen.SenderKey, error) {
 	case event.GithubPullRequestSubscriberType, event.GithubCheckSubscriberType, event.GithubMergeSubscriberType:
 		return evergreen.SenderGithubStatus, nil
 
+	case event.GitlabMergeRequestSubscriberType:
+		return evergreen.SenderGitlabStatus, nil
+
 	case event.EnqueuePatchSubscriberType:
 		return evergreen.SenderGeneric, nil
 	default:
@@ -214,6 +218,22 @@ func (n *Notification) Composer(env evergreen.Environment) (message.Composer, er
 		payload.Ref = sub.Ref
 		return message.NewGithubStatusMessageWithRepo(level.Notice, *payload), nil
 
+	case event.GitlabMergeRequestSubscriberType:
+		sub := n.Subscriber.Target.(*event.GitlabMergeRequestSubscriber)
+		payload, ok := n.Payload.(*message.GithubStatus)
+		if !ok || payload == nil {
+			return nil, errors.New("gitlab-merge-request payload is invalid")
+		}
+		// The GitLab status sender addresses the project as owner/repo.
+		owner, repo, err := thirdparty.SplitGitlabProjectPath(sub.ProjectPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "gitlab-merge-request subscriber is invalid")
+		}
+		payload.Owner = owner
+		payload.Repo = repo
+		payload.Ref = sub.Ref
+		return message.NewGithubStatusMessageWithRepo(level.Notice, *payload), nil
+
 	case event.EnqueuePatchSubscriberType:
 		payload, ok := n.Payload.(*model.EnqueuePatch)
 		if !ok || payload == nil {
@@ -309,6 +329,7 @@ type NotificationStats struct {
 	Slack             int `json:"slack" bson:"slack" yaml:"slack"`
 	GithubCheck       int `json:"github_check" bson:"github_check" yaml:"github_check"`
 	GithubMerge       int `json:"github_merge" bson:"github_merge" yaml:"github_merge"`
+	GitlabMR          int `json:"gitlab_merge_request" bson:"gitlab_merge_request" yaml:"gitlab_merge_request"`
 	EnqueuePatch      int `json:"enqueue_patch" bson:"enqueue_patch" yaml:"enqueue_patch"`
 }
 
@@ -354,6 +375,9 @@ func CollectUnsentNotificationStats() (*NotificationStats, error) {
 		case event.GithubMergeSubscriberType:
 			nStats.GithubMerge = data.Count
 
+		case event.GitlabMergeRequestSubscriberType:
+			nStats.GitlabMR = data.Count
+
 		case event.JIRAIssueSubscriberType:
 			nStats.JIRAIssue = data.Count
 
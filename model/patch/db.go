@@ -49,6 +49,7 @@ var (
 	PatchedProjectConfigKey = bsonutil.MustHaveTag(Patch{}, "PatchedProjectConfig")
 	AliasKey                = bsonutil.MustHaveTag(Patch{}, "Alias")
 	githubPatchDataKey      = bsonutil.MustHaveTag(Patch{}, "GithubPatchData")
+	gitlabPatchDataKey      = bsonutil.MustHaveTag(Patch{}, "GitlabPatchData")
 	MergePatchKey           = bsonutil.MustHaveTag(Patch{}, "MergePatch")
 	TriggersKey             = bsonutil.MustHaveTag(Patch{}, "Triggers")
 	HiddenKey               = bsonutil.MustHaveTag(Patch{}, "Hidden")
@@ -356,6 +357,18 @@ func ByGithubPRAndCreatedBefore(t time.Time, owner, repo string, prNumber int) d
 	})
 }
 
+// ByGitlabMRAndCreatedBefore finds all patches that were created for a GitLab
+// merge request before the given timestamp.
+func ByGitlabMRAndCreatedBefore(t time.Time, projectPath string, mrNumber int) db.Q {
+	return db.Query(bson.M{
+		CreateTimeKey: bson.M{
+			"$lt": t,
+		},
+		bsonutil.GetDottedKeyName(gitlabPatchDataKey, thirdparty.GitlabPatchProjectPathKey): projectPath,
+		bsonutil.GetDottedKeyName(gitlabPatchDataKey, thirdparty.GitlabPatchMRNumberKey):    mrNumber,
+	})
+}
+
 // FindLatestGithubPRPatch returns the latest PR patch for the given PR, if there is one.
 func FindLatestGithubPRPatch(owner, repo string, prNumber int) (*Patch, error) {
 	patches, err := Find(db.Query(bson.M{
@@ -0,0 +1,210 @@
+package patch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// GitlabIntentType represents patch intents created for GitLab merge
+	// requests.
+	GitlabIntentType = "gitlab"
+)
+
+// gitlabIntent represents an intent to create a patch build as a result of a
+// GitLab merge request webhook. These intents are processed asynchronously by
+// an amboy queue.
+type gitlabIntent struct {
+	// DocumentID is created by the driver and has no special meaning to the application.
+	DocumentID string `bson:"_id"`
+
+	// MsgID is a GUID provided by GitLab (X-Gitlab-Event-UUID) for the event.
+	MsgID string `bson:"msg_id"`
+
+	// ProjectPath is the full path of the GitLab project, ex: group/project,
+	// that this merge request will be merged into.
+	ProjectPath string `bson:"project_path"`
+
+	// BaseBranch is the branch that this merge request was opened against.
+	BaseBranch string `bson:"base_branch"`
+
+	// HeadBranch is the branch that contains the changes to be merged.
+	HeadBranch string `bson:"head_branch"`
+
+	// MRNumber is the merge request's number (IID) in the GitLab project.
+	MRNumber int `bson:"mr_number"`
+
+	// User is the username of the GitLab user that opened the merge request.
+	User string `bson:"user"`
+
+	// UID is the merge request author's GitLab user ID.
+	UID int `bson:"author_uid"`
+
+	// HeadHash is the hash of the most recent commit in the merge request.
+	HeadHash string `bson:"head_hash"`
+
+	// BaseHash is the commit that the merge request's diff is based on.
+	BaseHash string `bson:"base_hash"`
+
+	// Title is the title of the merge request.
+	Title string `bson:"title"`
+
+	// URL is the merge request's web URL.
+	URL string `bson:"url"`
+
+	// CreatedAt is the time that this intent was stored in the database.
+	CreatedAt time.Time `bson:"created_at"`
+
+	// Processed indicates whether a patch intent has been processed by the amboy queue.
+	Processed bool `bson:"processed"`
+
+	// ProcessedAt is the time that this intent was processed.
+	ProcessedAt time.Time `bson:"processed_at"`
+
+	// IntentType indicates the type of the patch intent, i.e., GitlabIntentType.
+	IntentType string `bson:"intent_type"`
+
+	// CalledBy indicates whether the intent was created automatically by Evergreen or by a user.
+	CalledBy string `bson:"called_by"`
+}
+
+// NewGitlabIntent creates an Intent from a GitLab merge request, or returns an
+// error if the merge request is incomplete.
+func NewGitlabIntent(msgID, calledBy, projectPath string, mr *thirdparty.GitlabMergeRequest) (Intent, error) {
+	if mr == nil {
+		return nil, errors.New("merge request cannot be nil")
+	}
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(msgID == "", "unique msg ID cannot be empty")
+	_, _, err := thirdparty.SplitGitlabProjectPath(projectPath)
+	catcher.Add(err)
+	catcher.NewWhen(mr.IID == 0, "merge request number must not be 0")
+	catcher.NewWhen(mr.TargetBranch == "", "target branch is empty")
+	catcher.NewWhen(mr.Author.Username == "", "merge request author is missing username")
+	catcher.NewWhen(mr.DiffRefs.HeadSHA == "", "head hash must not be empty")
+	catcher.NewWhen(mr.DiffRefs.BaseSHA == "", "base hash must not be empty")
+	catcher.NewWhen(mr.Title == "", "merge request title must not be empty")
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return &gitlabIntent{
+		DocumentID:  msgID,
+		MsgID:       msgID,
+		ProjectPath: projectPath,
+		BaseBranch:  mr.TargetBranch,
+		HeadBranch:  mr.SourceBranch,
+		MRNumber:    mr.IID,
+		User:        mr.Author.Username,
+		UID:         mr.Author.ID,
+		HeadHash:    mr.DiffRefs.HeadSHA,
+		BaseHash:    mr.DiffRefs.BaseSHA,
+		Title:       mr.Title,
+		URL:         mr.WebURL,
+		IntentType:  GitlabIntentType,
+		CalledBy:    calledBy,
+	}, nil
+}
+
+// SetProcessed should be called by an amboy queue after creating a patch from an intent.
+func (g *gitlabIntent) SetProcessed() error {
+	g.Processed = true
+	g.ProcessedAt = time.Now().UTC().Round(time.Millisecond)
+	return updateOneIntent(
+		bson.M{documentIDKey: g.DocumentID},
+		bson.M{"$set": bson.M{
+			processedKey:   g.Processed,
+			processedAtKey: g.ProcessedAt,
+		}},
+	)
+}
+
+// IsProcessed returns whether the intent has been processed.
+func (g *gitlabIntent) IsProcessed() bool {
+	return g.Processed
+}
+
+// GetType returns the patch intent, i.e., GitlabIntentType.
+func (g *gitlabIntent) GetType() string {
+	return g.IntentType
+}
+
+// Insert inserts a patch intent in the database.
+func (g *gitlabIntent) Insert() error {
+	g.CreatedAt = time.Now().UTC().Round(time.Millisecond)
+	err := db.Insert(IntentCollection, g)
+	if err != nil {
+		g.CreatedAt = time.Time{}
+		return err
+	}
+
+	return nil
+}
+
+// ID returns the GitLab event UUID, which is also the document ID.
+func (g *gitlabIntent) ID() string {
+	return g.MsgID
+}
+
+// ShouldFinalizePatch returns true, since merge request patches should always
+// be scheduled.
+func (g *gitlabIntent) ShouldFinalizePatch() bool {
+	return true
+}
+
+// RepeatPreviousPatchDefinition does not apply to GitLab merge requests.
+func (g *gitlabIntent) RepeatPreviousPatchDefinition() (string, bool) {
+	return "", false
+}
+
+// RepeatFailedTasksAndVariants does not apply to GitLab merge requests.
+func (g *gitlabIntent) RepeatFailedTasksAndVariants() (string, bool) {
+	return "", false
+}
+
+// RequesterIdentity returns the requester, i.e., GitlabMRRequester.
+func (g *gitlabIntent) RequesterIdentity() string {
+	return evergreen.GitlabMRRequester
+}
+
+// GetCalledBy returns the caller of the intent, e.g., patch.AutomatedCaller.
+func (g *gitlabIntent) GetCalledBy() string {
+	return g.CalledBy
+}
+
+// NewPatch creates a patch document from a GitLab merge request intent. The
+// patch's diff is not known until the intent is processed.
+func (g *gitlabIntent) NewPatch() *Patch {
+	return &Patch{
+		Id:          mgobson.NewObjectId(),
+		Alias:       g.GetAlias(),
+		Description: fmt.Sprintf("'%s' merge request !%d by %s: %s (%s)", g.ProjectPath, g.MRNumber, g.User, g.Title, g.URL),
+		Author:      evergreen.GitlabPatchUser,
+		Status:      evergreen.PatchCreated,
+		Githash:     g.BaseHash,
+		GitlabPatchData: thirdparty.GitlabPatch{
+			ProjectPath: g.ProjectPath,
+			MRNumber:    g.MRNumber,
+			BaseBranch:  g.BaseBranch,
+			HeadBranch:  g.HeadBranch,
+			HeadHash:    g.HeadHash,
+			Author:      g.User,
+			AuthorID:    g.UID,
+			URL:         g.URL,
+		},
+	}
+}
+
+// GetAlias returns the alias that GitLab merge request patches run, which is
+// the same as for GitHub pull requests.
+func (g *gitlabIntent) GetAlias() string {
+	return evergreen.GithubPRAlias
+}
@@ -0,0 +1,98 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitlabIntent(t *testing.T) {
+	defer func() {
+		require.NoError(t, db.Clear(IntentCollection))
+	}()
+	for tName, tCase := range map[string]func(t *testing.T, mr *thirdparty.GitlabMergeRequest){
+		"EmptyMessageIDErrors": func(t *testing.T, mr *thirdparty.GitlabMergeRequest) {
+			intent, err := NewGitlabIntent("", AutomatedCaller, "group/project", mr)
+			assert.Nil(t, intent)
+			assert.Error(t, err)
+		},
+		"InvalidProjectPathErrors": func(t *testing.T, mr *thirdparty.GitlabMergeRequest) {
+			intent, err := NewGitlabIntent("abc123", AutomatedCaller, "project", mr)
+			assert.Nil(t, intent)
+			assert.Error(t, err)
+		},
+		"MissingBaseHashErrors": func(t *testing.T, mr *thirdparty.GitlabMergeRequest) {
+			mr.DiffRefs.BaseSHA = ""
+			intent, err := NewGitlabIntent("abc123", AutomatedCaller, "group/project", mr)
+			assert.Nil(t, intent)
+			assert.Error(t, err)
+		},
+		"MissingAuthorErrors": func(t *testing.T, mr *thirdparty.GitlabMergeRequest) {
+			mr.Author = thirdparty.GitlabUser{}
+			intent, err := NewGitlabIntent("abc123", AutomatedCaller, "group/project", mr)
+			assert.Nil(t, intent)
+			assert.Error(t, err)
+		},
+		"RoundTrip": func(t *testing.T, mr *thirdparty.GitlabMergeRequest) {
+			intent, err := NewGitlabIntent("abc123", AutomatedCaller, "group/project", mr)
+			require.NoError(t, err)
+			require.NoError(t, intent.Insert())
+			assert.NoError(t, intent.SetProcessed())
+
+			found, err := FindIntent("abc123", GitlabIntentType)
+			require.NoError(t, err)
+			assert.True(t, found.IsProcessed())
+			assert.Equal(t, intent.NewPatch().GitlabPatchData, found.NewPatch().GitlabPatchData)
+		},
+		"Accessors": func(t *testing.T, mr *thirdparty.GitlabMergeRequest) {
+			intent, err := NewGitlabIntent("abc123", ManualCaller, "group/project", mr)
+			require.NoError(t, err)
+			assert.Equal(t, GitlabIntentType, intent.GetType())
+			assert.Equal(t, "abc123", intent.ID())
+			assert.True(t, intent.ShouldFinalizePatch())
+			_, repeat := intent.RepeatPreviousPatchDefinition()
+			assert.False(t, repeat)
+			assert.Equal(t, evergreen.GitlabMRRequester, intent.RequesterIdentity())
+			assert.Equal(t, ManualCaller, intent.GetCalledBy())
+			assert.Equal(t, evergreen.GithubPRAlias, intent.GetAlias())
+		},
+		"NewPatch": func(t *testing.T, mr *thirdparty.GitlabMergeRequest) {
+			intent, err := NewGitlabIntent("abc123", AutomatedCaller, "group/project", mr)
+			require.NoError(t, err)
+			p := intent.NewPatch()
+			assert.Equal(t, evergreen.GithubPRAlias, p.Alias)
+			assert.Equal(t, evergreen.GitlabPatchUser, p.Author)
+			assert.Equal(t, "base", p.Githash)
+			assert.Equal(t, "'group/project' merge request !5 by octocat: title (https://gitlab.example.com/group/project/-/merge_requests/5)", p.Description)
+			assert.True(t, p.IsGitlabMRPatch())
+			assert.False(t, p.IsGithubPRPatch())
+			assert.Equal(t, thirdparty.GitlabPatch{
+				ProjectPath: "group/project",
+				MRNumber:    5,
+				BaseBranch:  "main",
+				HeadBranch:  "feature",
+				HeadHash:    "head",
+				Author:      "octocat",
+				AuthorID:    7,
+				URL:         "https://gitlab.example.com/group/project/-/merge_requests/5",
+			}, p.GitlabPatchData)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.Clear(IntentCollection))
+			tCase(t, &thirdparty.GitlabMergeRequest{
+				IID:          5,
+				Title:        "title",
+				SourceBranch: "feature",
+				TargetBranch: "main",
+				WebURL:       "https://gitlab.example.com/group/project/-/merge_requests/5",
+				Author:       thirdparty.GitlabUser{ID: 7, Username: "octocat"},
+				DiffRefs:     thirdparty.GitlabDiffRefs{BaseSHA: "base", HeadSHA: "head"},
+			})
+		})
+	}
+}
@@ -187,6 +187,7 @@ type Patch struct {
 	MergePatch           string                      `bson:"merge_patch"`
 	GithubPatchData      thirdparty.GithubPatch      `bson:"github_patch_data,omitempty"`
 	GithubMergeData      thirdparty.GithubMergeGroup `bson:"github_merge_data,omitempty"`
+	GitlabPatchData      thirdparty.GitlabPatch      `bson:"gitlab_patch_data,omitempty"`
 	GitInfo              *GitMetadata                `bson:"git_info,omitempty"`
 	// DisplayNewUI is only used when roundtripping the patch via the CLI
 	DisplayNewUI bool `bson:"display_new_ui,omitempty"`
@@ -812,6 +813,11 @@ func (p *Patch) IsGithubMergePatch() bool {
 	return p.GithubMergeData.HeadSHA != ""
 }
 
+// IsGitlabMRPatch returns true if the patch is from a GitLab merge request.
+func (p *Patch) IsGitlabMRPatch() bool {
+	return p.GitlabPatchData.ProjectPath != ""
+}
+
 func (p *Patch) IsBackport() bool {
 	return len(p.BackportOf.PatchID) != 0 || len(p.BackportOf.SHA) != 0
 }
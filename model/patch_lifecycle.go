@@ -951,6 +951,37 @@ func AbortPatchesWithGithubPatchData(ctx context.Context, createdBefore time.Tim
 	return errors.Wrap(catcher.Resolve(), "aborting patches")
 }
 
+// AbortPatchesWithGitlabPatchData aborts patches created before the given time
+// for the same GitLab merge request. Tasks which are abortable will be aborted,
+// while completed tasks will not be affected.
+func AbortPatchesWithGitlabPatchData(createdBefore time.Time, closed bool, newPatch, projectPath string, mrNumber int) error {
+	patches, err := patch.Find(patch.ByGitlabMRAndCreatedBefore(createdBefore, projectPath, mrNumber))
+	if err != nil {
+		return errors.Wrap(err, "fetching patches for merge request")
+	}
+
+	catcher := grip.NewSimpleCatcher()
+	for _, p := range patches {
+		if p.Version == "" { // Skip anything unfinalized
+			continue
+		}
+		err = CancelPatch(&p, task.AbortInfo{User: evergreen.GitlabPatchUser, NewVersion: newPatch, PRClosed: closed})
+		grip.Error(message.WrapError(err, message.Fields{
+			"source":         "gitlab hook",
+			"created_before": createdBefore.String(),
+			"project_path":   projectPath,
+			"message":        "aborting patch's version",
+			"patch_id":       p.Id.Hex(),
+			"mr":             mrNumber,
+			"project":        p.Project,
+			"version":        p.Version,
+		}))
+		catcher.Add(err)
+	}
+
+	return errors.Wrap(catcher.Resolve(), "aborting patches")
+}
+
 func MakeCommitQueueDescription(patches []patch.ModulePatch, projectRef *ProjectRef, project *Project,
 	githubMergePatch bool, githubMergeSHA string) string {
 	commitFmtString := "'%s' into '%s/%s:%s'"
@@ -119,6 +119,8 @@ type BuildVariantTaskUnit struct {
 	// currently unsupported (TODO EVG-578)
 	ExecTimeoutSecs int   `yaml:"exec_timeout_secs,omitempty" bson:"exec_timeout_secs"`
 	Stepback        *bool `yaml:"stepback,omitempty" bson:"stepback,omitempty"`
+	// RetryPolicy configures automatic retries of the task when it fails.
+	RetryPolicy *task.RetryPolicy `yaml:"retry,omitempty" bson:"retry,omitempty"`
 
 	CommitQueueMerge bool `yaml:"commit_queue_merge,omitempty" bson:"commit_queue_merge"`
 
@@ -210,6 +212,9 @@ func (bvt *BuildVariantTaskUnit) Populate(pt ProjectTask, bv BuildVariant) {
 	if bvt.Stepback == nil {
 		bvt.Stepback = pt.Stepback
 	}
+	if bvt.RetryPolicy == nil {
+		bvt.RetryPolicy = pt.RetryPolicy
+	}
 
 	// Build variant level settings are lower priority than project task level
 	// settings.
@@ -671,6 +676,13 @@ type ProjectTask struct {
 	GitTagOnly      *bool `yaml:"git_tag_only,omitempty" bson:"git_tag_only,omitempty"`
 	Stepback        *bool `yaml:"stepback,omitempty" bson:"stepback,omitempty"`
 	MustHaveResults *bool `yaml:"must_have_test_results,omitempty" bson:"must_have_test_results,omitempty"`
+	// RetryPolicy configures automatic retries of the task when it fails.
+	RetryPolicy *task.RetryPolicy `yaml:"retry,omitempty" bson:"retry,omitempty"`
+	// Expansions are set for tasks generated from a task matrix and contain
+	// the task's matrix parameter values.
+	Expansions util.Expansions `yaml:"expansions,omitempty" bson:"expansions,omitempty"`
+	// Shard is set for tasks generated by sharding a task's tests.
+	Shard *TaskShard `yaml:"shard,omitempty" bson:"shard,omitempty"`
 }
 
 type LoggerConfig struct {
@@ -1147,6 +1159,8 @@ func PopulateExpansions(t *task.Task, h *host.Host, oauthToken, appToken string)
 		requesterExpansion = "ad_hoc"
 	case evergreen.GithubMergeRequester:
 		requesterExpansion = "github_merge_queue"
+	case evergreen.GitlabMRRequester:
+		requesterExpansion = "gitlab_mr"
 	default:
 		requesterExpansion = "unknown_requester"
 	}
@@ -1185,6 +1199,12 @@ func PopulateExpansions(t *task.Task, h *host.Host, oauthToken, appToken string)
 			expansions.Put("github_author", p.GithubPatchData.Author)
 			expansions.Put("github_commit", p.GithubPatchData.HeadHash)
 		}
+		if v.Requester == evergreen.GitlabMRRequester {
+			expansions.Put("gitlab_mr_number", fmt.Sprintf("%d", p.GitlabPatchData.MRNumber))
+			expansions.Put("gitlab_project", p.GitlabPatchData.ProjectPath)
+			expansions.Put("gitlab_author", p.GitlabPatchData.Author)
+			expansions.Put("gitlab_commit", p.GitlabPatchData.HeadHash)
+		}
 	} else {
 		expansions.Put("is_patch", "")
 		expansions.Put("revision_order_id", strconv.Itoa(v.RevisionOrderNumber))
@@ -1598,6 +1618,7 @@ func (p *Project) tasksFromGroup(bvTaskGroup BuildVariantTaskUnit) []BuildVarian
 			RunOn:            bvTaskGroup.RunOn,
 			ExecTimeoutSecs:  bvTaskGroup.ExecTimeoutSecs,
 			Stepback:         bvTaskGroup.Stepback,
+			RetryPolicy:      bvTaskGroup.RetryPolicy,
 			Activate:         bvTaskGroup.Activate,
 			CommitQueueMerge: bvTaskGroup.CommitQueueMerge,
 		}
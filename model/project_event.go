@@ -294,5 +294,12 @@ func constructProjectChangeEvent(username string, before, after *ProjectSettings
 		Before: *before.resolveDefaults(),
 		After:  *after.resolveDefaults(),
 	}
+	// Private variables are redacted whenever events are read, so if
+	// variables are encrypted at rest, they're redacted before the event is
+	// stored as well so that they aren't kept in plaintext in the event log.
+	if getSecretsConfig().EncryptionEnabled {
+		eventData.Before.Vars = *eventData.Before.Vars.RedactPrivateVars()
+		eventData.After.Vars = *eventData.After.Vars.RedactPrivateVars()
+	}
 	return &eventData
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/evergreen-ci/evergreen/db"
 	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/thirdparty"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/evergreen-ci/utility"
@@ -153,6 +155,16 @@ type parserTask struct {
 	GitTagOnly      *bool               `yaml:"git_tag_only,omitempty" bson:"git_tag_only,omitempty"`
 	Stepback        *bool               `yaml:"stepback,omitempty" bson:"stepback,omitempty"`
 	MustHaveResults *bool               `yaml:"must_have_test_results,omitempty" bson:"must_have_test_results,omitempty"`
+	RetryPolicy     *task.RetryPolicy   `yaml:"retry,omitempty" bson:"retry,omitempty"`
+	// Matrix defines parameters whose combinations of values expand the task
+	// into many tasks.
+	Matrix matrixDefinition `yaml:"matrix,omitempty" bson:"matrix,omitempty"`
+	// Shard splits the task's tests across many tasks.
+	Shard *TaskShardConfig `yaml:"shard,omitempty" bson:"shard,omitempty"`
+
+	// internal matrix stuff
+	MatrixVal  matrixValue `yaml:"matrix_val,omitempty" bson:"matrix_val,omitempty"`
+	ShardGroup string      `yaml:"shard_group,omitempty" bson:"shard_group,omitempty"`
 }
 
 func (pp *ParserProject) Insert() error {
@@ -418,6 +430,7 @@ type parserBVTaskUnit struct {
 	DependsOn        parserDependencies `yaml:"depends_on,omitempty" bson:"depends_on,omitempty"`
 	ExecTimeoutSecs  int                `yaml:"exec_timeout_secs,omitempty" bson:"exec_timeout_secs,omitempty"`
 	Stepback         *bool              `yaml:"stepback,omitempty" bson:"stepback,omitempty"`
+	RetryPolicy      *task.RetryPolicy  `yaml:"retry,omitempty" bson:"retry,omitempty"`
 	Distros          parserStringSlice  `yaml:"distros,omitempty" bson:"distros,omitempty"`
 	RunOn            parserStringSlice  `yaml:"run_on,omitempty" bson:"run_on,omitempty"` // Alias for "Distros" TODO: deprecate Distros
 	CommitQueueMerge bool               `yaml:"commit_queue_merge,omitempty" bson:"commit_queue_merge,omitempty"`
@@ -682,6 +695,7 @@ const (
 	ReadFromLocal     = "local"
 	ReadFromPatch     = "patch"
 	ReadFromPatchDiff = "patch_diff"
+	ReadFromGit       = "git"
 )
 
 type GetProjectOpts struct {
@@ -694,6 +708,9 @@ type GetProjectOpts struct {
 	ReadFileFrom    string
 	Identifier      string
 	UnmarshalStrict bool
+	// GitDir is the local git repository to read files from when reading
+	// from git.
+	GitDir string
 }
 
 type PatchOpts struct {
@@ -724,6 +741,12 @@ func retrieveFile(ctx context.Context, opts GetProjectOpts) ([]byte, error) {
 			return nil, errors.Wrap(err, "reading project config")
 		}
 		return fileContents, nil
+	case ReadFromGit:
+		fileContents, err := thirdparty.GitShowFile(ctx, opts.GitDir, opts.Revision, opts.RemotePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching project file for project '%s' at revision '%s'", opts.Identifier, opts.Revision)
+		}
+		return fileContents, nil
 	case ReadFromPatch:
 		fileContents, err := getFileForPatchDiff(ctx, opts)
 		if err != nil {
@@ -805,6 +828,9 @@ func getFileForPatchDiff(ctx context.Context, opts GetProjectOpts) ([]byte, erro
 	if opts.Ref == nil {
 		return nil, errors.New("project not passed in")
 	}
+	if opts.PatchOpts.patch != nil && opts.PatchOpts.patch.IsGitlabMRPatch() {
+		return getGitlabFileForPatchDiff(ctx, opts)
+	}
 	var projectFileBytes []byte
 	githubFile, err := thirdparty.GetGithubFile(ctx, opts.Token, opts.Ref.Owner,
 		opts.Ref.Repo, opts.RemotePath, opts.Revision)
@@ -827,6 +853,26 @@ func getFileForPatchDiff(ctx context.Context, opts GetProjectOpts) ([]byte, erro
 	return projectFileBytes, nil
 }
 
+// getGitlabFileForPatchDiff gets the base version of a file for a GitLab merge
+// request patch from GitLab.
+func getGitlabFileForPatchDiff(ctx context.Context, opts GetProjectOpts) ([]byte, error) {
+	settings, err := evergreen.GetConfig(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting evergreen configuration")
+	}
+	projectPath := opts.PatchOpts.patch.GitlabPatchData.ProjectPath
+	projectFileBytes, err := thirdparty.GetGitlabFile(ctx, settings.Gitlab, projectPath, opts.RemotePath, opts.Revision)
+	if err != nil {
+		// if the project file doesn't exist, but our patch includes a project file,
+		// we try to apply the diff and proceed.
+		if opts.PatchOpts.patch.ConfigChanged(opts.RemotePath) && thirdparty.IsFileNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "getting GitLab file at '%s'@%s: %s", projectPath, opts.RemotePath, opts.Revision)
+	}
+	return projectFileBytes, nil
+}
+
 // fetchProjectFilesTimeout is the maximum timeout to fetch project
 // configuration files from its source.
 const fetchProjectFilesTimeout = time.Minute
@@ -924,16 +970,28 @@ func TranslateProject(pp *ParserProject) (*Project, error) {
 		Loggers:            pp.Loggers,
 	}
 	catcher := grip.NewBasicCatcher()
-	tse := NewParserTaskSelectorEvaluator(pp.Tasks)
-	tgse := newTaskGroupSelectorEvaluator(pp.TaskGroups)
+	tasks, matrixTasks, errs := expandTaskMatrices(pp.Tasks)
+	catcher.Extend(errs)
+	taskGroups := pp.TaskGroups
+	if len(matrixTasks) > 0 {
+		taskGroups = make([]parserTaskGroup, 0, len(pp.TaskGroups))
+		for _, tg := range pp.TaskGroups {
+			taskGroups = append(taskGroups, matrixTasks.expandTaskGroup(tg))
+		}
+	}
+	tse := NewParserTaskSelectorEvaluator(tasks)
+	tgse := newTaskGroupSelectorEvaluator(taskGroups)
 	ase := NewAxisSelectorEvaluator(pp.Axes)
 	buildVariants, errs := GetVariantsWithMatrices(ase, pp.Axes, pp.BuildVariants)
 	catcher.Extend(errs)
+	for i := range buildVariants {
+		buildVariants[i] = matrixTasks.expandBuildVariant(buildVariants[i])
+	}
 	vse := NewVariantSelectorEvaluator(buildVariants, ase)
-	proj.Tasks, proj.TaskGroups, errs = evaluateTaskUnits(tse, tgse, vse, pp.Tasks, pp.TaskGroups, pp.Containers)
+	proj.Tasks, proj.TaskGroups, errs = evaluateTaskUnits(tse, tgse, vse, tasks, taskGroups, pp.Containers)
 	catcher.Extend(errs)
 
-	proj.BuildVariants, errs = evaluateBuildVariants(tse, tgse, vse, buildVariants, pp.Tasks, proj.TaskGroups)
+	proj.BuildVariants, errs = evaluateBuildVariants(tse, tgse, vse, buildVariants, tasks, proj.TaskGroups)
 	catcher.Extend(errs)
 	return proj, errors.Wrap(catcher.Resolve(), TranslateProjectError)
 }
@@ -1013,6 +1071,19 @@ func evaluateTaskUnits(tse *taskSelectorEvaluator, tgse *tagSelectorEvaluator, v
 			GitTagOnly:      pt.GitTagOnly,
 			Stepback:        pt.Stepback,
 			MustHaveResults: pt.MustHaveResults,
+			RetryPolicy:     pt.RetryPolicy,
+		}
+		if len(pt.MatrixVal) > 0 {
+			t.Expansions = *util.NewExpansions(pt.MatrixVal)
+		}
+		if pt.Shard != nil && pt.ShardGroup != "" {
+			shard, err := newTaskShard(pt)
+			if err != nil {
+				evalErrs = append(evalErrs, errors.Wrapf(err, "task '%s'", pt.Name))
+			} else {
+				t.Shard = shard
+				t.Expansions.Put(TaskShardCountExpansion, strconv.Itoa(shard.Count))
+			}
 		}
 		if strings.Contains(strings.TrimSpace(pt.Name), " ") {
 			evalErrs = append(evalErrs, errors.Errorf("spaces are not allowed in task names ('%s')", pt.Name))
@@ -1291,6 +1362,7 @@ func getParserBuildVariantTaskUnit(name string, pt parserTask, bvt parserBVTaskU
 		Priority:         bvt.Priority,
 		ExecTimeoutSecs:  bvt.ExecTimeoutSecs,
 		Stepback:         bvt.Stepback,
+		RetryPolicy:      bvt.RetryPolicy,
 		RunOn:            bvt.RunOn,
 		CommitQueueMerge: bvt.CommitQueueMerge,
 		CronBatchTime:    bvt.CronBatchTime,
@@ -1338,6 +1410,9 @@ func getParserBuildVariantTaskUnit(name string, pt parserTask, bvt parserBVTaskU
 	if res.Stepback == nil {
 		res.Stepback = pt.Stepback
 	}
+	if res.RetryPolicy == nil {
+		res.RetryPolicy = pt.RetryPolicy
+	}
 	if len(res.RunOn) == 0 {
 		// first consider that we may be using the legacy "distros" field
 		res.RunOn = bvt.Distros
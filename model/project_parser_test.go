@@ -1569,6 +1569,48 @@ buildvariants:
 	assert.Nil(proj.BuildVariants[2].Tasks[0].PatchOnly)
 }
 
+func TestRetryPolicyTasks(t *testing.T) {
+	yml := `
+tasks:
+- name: task_1
+  retry:
+    max_attempts: 3
+    failure_types: [system, setup]
+    backoff_secs: 60
+- name: task_2
+buildvariants:
+- name: bv_1
+  display_name: "bv_display"
+  tasks:
+  - name: task_1
+  - name: task_2
+    retry:
+      max_attempts: 2
+      test_failure_regexes: ["^flaky_"]
+`
+
+	proj := &Project{}
+	_, err := LoadProjectInto(context.Background(), []byte(yml), nil, "id", proj)
+	require.NoError(t, err)
+
+	pt := proj.FindProjectTask("task_1")
+	require.NotNil(t, pt)
+	require.NotNil(t, pt.RetryPolicy)
+	assert.Equal(t, 3, pt.RetryPolicy.MaxAttempts)
+	assert.Equal(t, []string{evergreen.CommandTypeSystem, evergreen.CommandTypeSetup}, pt.RetryPolicy.FailureTypes)
+	assert.Equal(t, 60, pt.RetryPolicy.BackoffSecs)
+
+	require.Len(t, proj.BuildVariants, 1)
+	require.Len(t, proj.BuildVariants[0].Tasks, 2)
+	inherited := proj.BuildVariants[0].Tasks[0].RetryPolicy
+	require.NotNil(t, inherited)
+	assert.Equal(t, 3, inherited.MaxAttempts)
+	overridden := proj.BuildVariants[0].Tasks[1].RetryPolicy
+	require.NotNil(t, overridden)
+	assert.Equal(t, 2, overridden.MaxAttempts)
+	assert.Equal(t, []string{"^flaky_"}, overridden.TestFailureRegexes)
+}
+
 func TestAllowForGitTagTasks(t *testing.T) {
 	yml := `
 tasks:
@@ -55,6 +55,8 @@ type ProjectRef struct {
 	Repo                   string              `bson:"repo_name" json:"repo_name" yaml:"repo"`
 	Branch                 string              `bson:"branch_name" json:"branch_name" yaml:"branch"`
 	RemotePath             string              `bson:"remote_path" json:"remote_path" yaml:"remote_path"`
+	GitURL                 string              `bson:"git_url,omitempty" json:"git_url,omitempty" yaml:"git_url"`
+	GitlabWebhookSecret    string              `bson:"gitlab_webhook_secret,omitempty" json:"gitlab_webhook_secret,omitempty" yaml:"gitlab_webhook_secret"`
 	PatchingDisabled       *bool               `bson:"patching_disabled,omitempty" json:"patching_disabled,omitempty"`
 	RepotrackerDisabled    *bool               `bson:"repotracker_disabled,omitempty" json:"repotracker_disabled,omitempty" yaml:"repotracker_disabled"`
 	DispatchingDisabled    *bool               `bson:"dispatching_disabled,omitempty" json:"dispatching_disabled,omitempty" yaml:"dispatching_disabled"`
@@ -87,6 +89,10 @@ type ProjectRef struct {
 	// TaskSync holds settings for synchronizing task directories to S3.
 	TaskSync TaskSyncOptions `bson:"task_sync" json:"task_sync" yaml:"task_sync"`
 
+	// ArtifactRetention holds settings for how long the files that tasks
+	// upload are kept in their buckets.
+	ArtifactRetention ArtifactRetentionSettings `bson:"artifact_retention,omitempty" json:"artifact_retention,omitempty" yaml:"artifact_retention,omitempty"`
+
 	// GitTagAuthorizedUsers contains a list of users who are able to create versions from git tags.
 	GitTagAuthorizedUsers []string `bson:"git_tag_authorized_users" json:"git_tag_authorized_users"`
 	GitTagAuthorizedTeams []string `bson:"git_tag_authorized_teams" json:"git_tag_authorized_teams"`
@@ -165,8 +171,25 @@ type CommitQueueParams struct {
 	MergeMethod string     `bson:"merge_method" json:"merge_method" yaml:"merge_method"`
 	MergeQueue  MergeQueue `bson:"merge_queue" json:"merge_queue" yaml:"merge_queue"`
 	Message     string     `bson:"message,omitempty" json:"message,omitempty" yaml:"message"`
+	// MergeTrainSize is the maximum number of items at the front of the
+	// commit queue that are tested at once, each on top of the items ahead of
+	// it. If it's zero, the commit queue uses the global batch size and waits
+	// for a batch to finish before it starts testing the next one.
+	MergeTrainSize int `bson:"merge_train_size,omitempty" json:"merge_train_size,omitempty" yaml:"merge_train_size,omitempty"`
+	// BatchedMergeSize is the maximum number of CLI items that are combined
+	// into a single merge patch. If the merge patch fails, the batch is
+	// bisected until the item that caused the failure is found.
+	BatchedMergeSize int `bson:"batched_merge_size,omitempty" json:"batched_merge_size,omitempty" yaml:"batched_merge_size,omitempty"`
 }
 
+// MaxMergeTrainSize is the largest number of commit queue items that can be
+// tested at once in a merge train.
+const MaxMergeTrainSize = 20
+
+// MaxBatchedMergeSize is the largest number of commit queue items that can be
+// combined into a single merge patch.
+const MaxBatchedMergeSize = 20
+
 // TaskSyncOptions contains information about which features are allowed for
 // syncing task directories to S3.
 type TaskSyncOptions struct {
@@ -174,6 +197,31 @@ type TaskSyncOptions struct {
 	PatchEnabled  *bool `bson:"patch_enabled" json:"patch_enabled" yaml:"patch_enabled"`
 }
 
+// ArtifactRetentionSettings are how long the files that a project's tasks
+// upload to S3 are kept before they're deleted. Files from versions created
+// by or tagged with a git tag are always kept.
+type ArtifactRetentionSettings struct {
+	// MainlineDays is how many days files from mainline versions are kept.
+	// If it's 0, they're kept forever.
+	MainlineDays int `bson:"mainline_days,omitempty" json:"mainline_days,omitempty" yaml:"mainline_days,omitempty"`
+	// PatchDays is how many days files from patches are kept. If it's 0,
+	// they're kept forever.
+	PatchDays int `bson:"patch_days,omitempty" json:"patch_days,omitempty" yaml:"patch_days,omitempty"`
+}
+
+// IsEnabled returns whether files from any versions are deleted.
+func (s ArtifactRetentionSettings) IsEnabled() bool {
+	return s.MainlineDays > 0 || s.PatchDays > 0
+}
+
+// Validate checks that the retention periods are valid.
+func (s ArtifactRetentionSettings) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(s.MainlineDays < 0, "mainline artifact retention days cannot be negative")
+	catcher.NewWhen(s.PatchDays < 0, "patch artifact retention days cannot be negative")
+	return catcher.Resolve()
+}
+
 // RepositoryErrorDetails indicates whether or not there is an invalid revision and if there is one,
 // what the guessed merge base revision is.
 type RepositoryErrorDetails struct {
@@ -312,6 +360,8 @@ var (
 	ProjectRefDisplayNameKey              = bsonutil.MustHaveTag(ProjectRef{}, "DisplayName")
 	ProjectRefDeactivatePreviousKey       = bsonutil.MustHaveTag(ProjectRef{}, "DeactivatePrevious")
 	ProjectRefRemotePathKey               = bsonutil.MustHaveTag(ProjectRef{}, "RemotePath")
+	ProjectRefGitURLKey                   = bsonutil.MustHaveTag(ProjectRef{}, "GitURL")
+	ProjectRefGitlabWebhookSecretKey      = bsonutil.MustHaveTag(ProjectRef{}, "GitlabWebhookSecret")
 	ProjectRefHiddenKey                   = bsonutil.MustHaveTag(ProjectRef{}, "Hidden")
 	ProjectRefRepotrackerErrorKey         = bsonutil.MustHaveTag(ProjectRef{}, "RepotrackerError")
 	ProjectRefDisabledStatsCacheKey       = bsonutil.MustHaveTag(ProjectRef{}, "DisabledStatsCache")
@@ -326,6 +376,7 @@ var (
 	projectRefRepotrackerDisabledKey      = bsonutil.MustHaveTag(ProjectRef{}, "RepotrackerDisabled")
 	projectRefCommitQueueKey              = bsonutil.MustHaveTag(ProjectRef{}, "CommitQueue")
 	projectRefTaskSyncKey                 = bsonutil.MustHaveTag(ProjectRef{}, "TaskSync")
+	ProjectRefArtifactRetentionKey        = bsonutil.MustHaveTag(ProjectRef{}, "ArtifactRetention")
 	projectRefPatchingDisabledKey         = bsonutil.MustHaveTag(ProjectRef{}, "PatchingDisabled")
 	projectRefDispatchingDisabledKey      = bsonutil.MustHaveTag(ProjectRef{}, "DispatchingDisabled")
 	projectRefStepbackDisabledKey         = bsonutil.MustHaveTag(ProjectRef{}, "StepbackDisabled")
@@ -445,6 +496,32 @@ func (p *CommitQueueParams) IsEnabled() bool {
 	return utility.FromBoolPtr(p.Enabled)
 }
 
+// IsMergeTrain returns whether the commit queue tests several items at once
+// as a merge train.
+func (p *CommitQueueParams) IsMergeTrain() bool {
+	return p.MergeTrainSize > 0
+}
+
+// IsBatchedMerge returns whether the commit queue combines several items into
+// a single merge patch.
+func (p *CommitQueueParams) IsBatchedMerge() bool {
+	return p.BatchedMergeSize > 0
+}
+
+// Validate checks that the commit queue settings are valid.
+func (p *CommitQueueParams) Validate() error {
+	if p.MergeTrainSize < 0 || p.MergeTrainSize > MaxMergeTrainSize {
+		return errors.Errorf("merge train size must be between 0 and %d", MaxMergeTrainSize)
+	}
+	if p.BatchedMergeSize < 0 || p.BatchedMergeSize > MaxBatchedMergeSize {
+		return errors.Errorf("batched merge size must be between 0 and %d", MaxBatchedMergeSize)
+	}
+	if p.IsMergeTrain() && p.IsBatchedMerge() {
+		return errors.New("merge trains and batched merges cannot both be enabled")
+	}
+	return nil
+}
+
 func (ts *TaskSyncOptions) IsPatchEnabled() bool {
 	return utility.FromBoolPtr(ts.PatchEnabled)
 }
@@ -1452,7 +1529,7 @@ func FindMergedEnabledProjectRefsByRepoAndBranch(owner, repoName, branch string)
 	if err != nil {
 		return nil, err
 	}
-	return filterProjectsByBranch(mergedProjects, branch), nil
+	return filterGithubProjects(filterProjectsByBranch(mergedProjects, branch)), nil
 }
 
 // FindMergedProjectRefsThatUseRepoSettingsByRepoAndBranch finds ProjectRef with matching repo/branch that
@@ -1471,7 +1548,19 @@ func FindMergedProjectRefsThatUseRepoSettingsByRepoAndBranch(owner, repoName, br
 	if err != nil {
 		return nil, err
 	}
-	return filterProjectsByBranch(mergedProjects, branch), nil
+	return filterGithubProjects(filterProjectsByBranch(mergedProjects, branch)), nil
+}
+
+// filterGithubProjects removes projects that aren't hosted on GitHub, whose
+// owner and repo don't refer to a GitHub repository.
+func filterGithubProjects(pRefs []ProjectRef) []ProjectRef {
+	res := []ProjectRef{}
+	for _, p := range pRefs {
+		if p.GitURL == "" {
+			res = append(res, p)
+		}
+	}
+	return res
 }
 
 func filterProjectsByBranch(pRefs []ProjectRef, branch string) []ProjectRef {
@@ -1545,7 +1634,7 @@ func FindOneProjectRefByRepoAndBranchWithPRTesting(owner, repo, branch, calledBy
 	if err != nil {
 		return nil, errors.Wrapf(err, "finding merged repo refs for repo '%s/%s'", owner, repo)
 	}
-	if repoRef == nil || !repoRef.IsPRTestingEnabledByCaller(calledBy) || repoRef.RemotePath == "" {
+	if repoRef == nil || !repoRef.IsPRTestingEnabledByCaller(calledBy) || repoRef.RemotePath == "" || repoRef.GitURL != "" {
 		grip.Debug(message.Fields{
 			"source":  "find project ref for PR testing",
 			"message": "repo ref not configured for PR testing untracked branches",
@@ -1702,6 +1791,64 @@ func UpdateProjectRevision(projectID, revision string) error {
 	return nil
 }
 
+// IsGitlabProject returns whether the project's git URL refers to the project
+// with the given path on the GitLab host.
+func (p *ProjectRef) IsGitlabProject(gitlabHost, projectPath string) bool {
+	if p.GitURL == "" {
+		return false
+	}
+	host, path, err := thirdparty.GitURLHostAndPath(p.GitURL)
+	if err != nil {
+		return false
+	}
+	return host == strings.ToLower(gitlabHost) && path == projectPath
+}
+
+// FindMergedProjectRefsByGitlabProject finds the project refs whose git URL
+// refers to the project with the given path on the GitLab host, merged with
+// their repo settings. Only project refs that set the git URL themselves are
+// considered.
+func FindMergedProjectRefsByGitlabProject(gitlabHost, projectPath string) ([]ProjectRef, error) {
+	projectRefs := []ProjectRef{}
+	match := bson.M{
+		ProjectRefGitURLKey: bson.M{
+			"$regex":   fmt.Sprintf("[/:]%s(\\.git)?/?$", regexp.QuoteMeta(projectPath)),
+			"$options": "i",
+		},
+	}
+	pipeline := []bson.M{{"$match": match}, lookupRepoStep}
+	if err := db.Aggregate(ProjectRefCollection, pipeline, &projectRefs); err != nil {
+		return nil, err
+	}
+	mergedProjects, err := addLoggerAndRepoSettingsToProjects(projectRefs)
+	if err != nil {
+		return nil, err
+	}
+	res := []ProjectRef{}
+	for _, p := range mergedProjects {
+		if p.IsGitlabProject(gitlabHost, projectPath) {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// FindOneProjectRefByGitlabProjectWithMRTesting finds the enabled project ref
+// that tracks the branch of the GitLab project and has merge request testing
+// enabled for the caller.
+func FindOneProjectRefByGitlabProjectWithMRTesting(gitlabHost, projectPath, branch, calledBy string) (*ProjectRef, error) {
+	projectRefs, err := FindMergedProjectRefsByGitlabProject(gitlabHost, projectPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching project refs for GitLab project '%s'", projectPath)
+	}
+	for _, p := range projectRefs {
+		if p.Enabled && p.Branch == branch && p.IsPRTestingEnabledByCaller(calledBy) {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
 func FindHiddenProjectRefByOwnerRepoAndBranch(owner, repo, branch string) (*ProjectRef, error) {
 	// don't need to include undefined branches here since hidden projects explicitly define them
 	q := byOwnerRepoAndBranch(owner, repo, branch, false)
@@ -1722,7 +1869,11 @@ func FindMergedEnabledProjectRefsByOwnerAndRepo(owner, repo string) ([]ProjectRe
 		return nil, err
 	}
 
-	return addLoggerAndRepoSettingsToProjects(projectRefs)
+	mergedProjects, err := addLoggerAndRepoSettingsToProjects(projectRefs)
+	if err != nil {
+		return nil, err
+	}
+	return filterGithubProjects(mergedProjects), nil
 }
 
 // FindMergedProjectRefsForRepo considers either owner/repo and repo ref ID, in case the owner/repo of the repo ref is going to change.
@@ -1961,6 +2112,7 @@ func SaveProjectPageForSection(projectId string, p *ProjectRef, section ProjectP
 			ProjectRefBranchKey:                p.Branch,
 			ProjectRefBatchTimeKey:             p.BatchTime,
 			ProjectRefRemotePathKey:            p.RemotePath,
+			ProjectRefGitURLKey:                p.GitURL,
 			projectRefSpawnHostScriptPathKey:   p.SpawnHostScriptPath,
 			projectRefDispatchingDisabledKey:   p.DispatchingDisabled,
 			projectRefStepbackDisabledKey:      p.StepbackDisabled,
@@ -1969,6 +2121,7 @@ func SaveProjectPageForSection(projectId string, p *ProjectRef, section ProjectP
 			projectRefRepotrackerDisabledKey:   p.RepotrackerDisabled,
 			projectRefPatchingDisabledKey:      p.PatchingDisabled,
 			projectRefTaskSyncKey:              p.TaskSync,
+			ProjectRefArtifactRetentionKey:     p.ArtifactRetention,
 			ProjectRefDisabledStatsCacheKey:    p.DisabledStatsCache,
 		}
 		// Unlike other fields, this will only be set if we're actually modifying it since it's used by the backend.
@@ -2142,6 +2295,8 @@ func DefaultSectionToRepo(projectId string, section ProjectPageSection, userId s
 			bson.M{
 				"$unset": bson.M{
 					projectVarsMapKey:   1,
+					encryptedVarsMapKey: 1,
+					dataKeyKey:          1,
 					privateVarsMapKey:   1,
 					adminOnlyVarsMapKey: 1,
 				},
@@ -2402,10 +2557,35 @@ func (p *ProjectRef) ValidateOwnerAndRepo(validOrgs []string) error {
 	if p.Owner == "" || p.Repo == "" {
 		return errors.New("no owner/repo specified")
 	}
+	if p.GitURL != "" {
+		// Projects with a git URL aren't hosted on GitHub, so their owner
+		// and repo don't refer to a GitHub organization.
+		return nil
+	}
 
 	return validateOwner(p.Owner, validOrgs)
 }
 
+// ValidateGitURL checks that the project's git URL, if it has one, is a remote
+// that's safe to fetch from. Local repositories are only allowed if the admin
+// settings allow them.
+func (p *ProjectRef) ValidateGitURL() error {
+	if p.GitURL == "" {
+		return nil
+	}
+	allowLocal := evergreen.GetEnvironment().Settings().RepoTracker.AllowLocalGitURLs
+	return errors.Wrap(thirdparty.ValidateGitURL(p.GitURL, allowLocal), "validating git URL")
+}
+
+// RestoreRedactedGitURL keeps the original git URL if the new one is just its
+// redacted form. Users only ever see redacted git URLs, so sending one back
+// shouldn't overwrite the credentials it hides.
+func (p *ProjectRef) RestoreRedactedGitURL(original string) {
+	if p.GitURL != original && p.GitURL == thirdparty.RedactGitURL(original) {
+		p.GitURL = original
+	}
+}
+
 func validateOwner(owner string, validOrgs []string) error {
 	if len(validOrgs) > 0 && !utility.StringSliceContains(validOrgs, owner) {
 		return errors.New("owner not authorized")
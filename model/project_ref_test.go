@@ -3140,3 +3140,53 @@ func TestSetContainerSecrets(t *testing.T) {
 		assert.Empty(t, dbProjRef.RepotrackerError)
 	})
 }
+
+func TestCommitQueueParamsValidate(t *testing.T) {
+	for _, size := range []int{0, 1, MaxMergeTrainSize} {
+		params := CommitQueueParams{MergeTrainSize: size}
+		assert.NoError(t, params.Validate(), size)
+	}
+	for _, size := range []int{-1, MaxMergeTrainSize + 1} {
+		params := CommitQueueParams{MergeTrainSize: size}
+		assert.Error(t, params.Validate(), size)
+	}
+	assert.False(t, (&CommitQueueParams{}).IsMergeTrain())
+	assert.True(t, (&CommitQueueParams{MergeTrainSize: 3}).IsMergeTrain())
+
+	for _, size := range []int{0, 1, MaxBatchedMergeSize} {
+		params := CommitQueueParams{BatchedMergeSize: size}
+		assert.NoError(t, params.Validate(), size)
+	}
+	for _, size := range []int{-1, MaxBatchedMergeSize + 1} {
+		params := CommitQueueParams{BatchedMergeSize: size}
+		assert.Error(t, params.Validate(), size)
+	}
+	assert.Error(t, (&CommitQueueParams{MergeTrainSize: 2, BatchedMergeSize: 2}).Validate())
+	assert.False(t, (&CommitQueueParams{}).IsBatchedMerge())
+	assert.True(t, (&CommitQueueParams{BatchedMergeSize: 3}).IsBatchedMerge())
+}
+
+func TestIsGitlabProject(t *testing.T) {
+	p := ProjectRef{GitURL: "git@GitLab.example.com:group/project.git"}
+	assert.True(t, p.IsGitlabProject("gitlab.example.com", "group/project"))
+	assert.False(t, p.IsGitlabProject("gitlab.example.com", "other/group/project"))
+	assert.False(t, p.IsGitlabProject("gitlab.other.com", "group/project"))
+	assert.False(t, (&ProjectRef{Owner: "group", Repo: "project"}).IsGitlabProject("gitlab.example.com", "group/project"))
+}
+
+func TestFindMergedProjectRefsByGitlabProject(t *testing.T) {
+	require.NoError(t, db.ClearCollections(ProjectRefCollection, RepoRefCollection))
+	for _, p := range []ProjectRef{
+		{Id: "gitlab", GitURL: "https://gitlab.example.com/group/project.git", Branch: "main", Enabled: true},
+		{Id: "other_host", GitURL: "https://gitlab.other.com/group/project.git", Branch: "main", Enabled: true},
+		{Id: "subgroup", GitURL: "https://gitlab.example.com/other/group/project.git", Branch: "main", Enabled: true},
+		{Id: "github", Owner: "group", Repo: "project", Branch: "main", Enabled: true},
+	} {
+		require.NoError(t, p.Insert())
+	}
+
+	projectRefs, err := FindMergedProjectRefsByGitlabProject("gitlab.example.com", "group/project")
+	require.NoError(t, err)
+	require.Len(t, projectRefs, 1)
+	assert.Equal(t, "gitlab", projectRefs[0].Id)
+}
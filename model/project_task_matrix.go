@@ -0,0 +1,327 @@
+package model
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// This file contains the code for task matrix generation.
+// Task matrices are a shortcut for defining many tasks that only differ by a
+// set of parameters, such as a test suite name or a shard number.
+//
+// Sharded tasks (see project_task_shard.go) are expanded the same way, using
+// an additional "shard" parameter.
+//
+// On a high level, task matrix construction takes the following steps:
+//  1. Each task that defines a matrix is expanded into one task per
+//     combination of parameter values. Each instance has the parameter values
+//     set as expansions.
+//  2. Any reference to the task by name (in dependencies, task groups, build
+//     variant tasks, display tasks, and matrix rules) is replaced with
+//     references to all of its instances.
+//  3. Build variants that list the task by name group its instances into a
+//     display task with the original task's name, unless the build variant
+//     already places them in a display task.
+//
+// This all happens on copies of the parser project's tasks and variants during
+// TranslateProject, so the stored parser project keeps the original matrix
+// definition.
+
+// taskMatrixRangeRegex matches an inclusive range of integers, e.g. "0..15".
+var taskMatrixRangeRegex = regexp.MustCompile(`^(-?\d+)\.\.(-?\d+)$`)
+
+// maxTaskMatrixInstances is the maximum number of tasks that a single task
+// matrix can expand into.
+const maxTaskMatrixInstances = 1000
+
+// taskMatrixInstances maps the name of each task that defines a matrix to the
+// names of the tasks that it expands into.
+type taskMatrixInstances map[string][]string
+
+// expandTaskMatrices expands every task that defines a matrix or shards its
+// tests into its instances and returns the resulting tasks, along with the
+// names of the instances for each expanded task. Dependencies of the returned tasks that
+// reference an expanded task are replaced with dependencies on all of its
+// instances.
+func expandTaskMatrices(pts []parserTask) ([]parserTask, taskMatrixInstances, []error) {
+	var errs []error
+	instances := taskMatrixInstances{}
+	tasks := make([]parserTask, 0, len(pts))
+	for _, pt := range pts {
+		if len(pt.Matrix) == 0 && pt.Shard == nil {
+			tasks = append(tasks, pt)
+			continue
+		}
+		expanded, err := expandTaskMatrix(pt)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "expanding matrix for task '%s'", pt.Name))
+			continue
+		}
+		for _, t := range expanded {
+			instances[pt.Name] = append(instances[pt.Name], t.Name)
+		}
+		tasks = append(tasks, expanded...)
+	}
+	if len(instances) == 0 {
+		return tasks, nil, errs
+	}
+
+	for i := range tasks {
+		tasks[i].DependsOn = instances.expandDependencies(tasks[i].DependsOn)
+	}
+	return tasks, instances, errs
+}
+
+// expandTaskMatrix returns one task for every combination of the task's matrix
+// parameter values.
+func expandTaskMatrix(pt parserTask) ([]parserTask, error) {
+	matrix := pt.Matrix
+	if pt.Shard != nil {
+		var err error
+		if matrix, err = addShardParameter(matrix, pt.Shard); err != nil {
+			return nil, err
+		}
+	}
+	params := make([]string, 0, len(matrix))
+	for param := range matrix {
+		params = append(params, param)
+	}
+	// Iterate over parameters in a consistent order so that instance names and
+	// the order of instances are stable.
+	sort.Strings(params)
+
+	cells := []matrixValue{{}}
+	for _, param := range params {
+		values, err := expandTaskMatrixValues(matrix[param])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parameter '%s'", param)
+		}
+		if len(values) == 0 {
+			return nil, errors.Errorf("parameter '%s' has no values", param)
+		}
+		if len(cells)*len(values) > maxTaskMatrixInstances {
+			return nil, errors.Errorf("matrix cannot expand into more than %d tasks", maxTaskMatrixInstances)
+		}
+		next := make([]matrixValue, 0, len(cells)*len(values))
+		for _, cell := range cells {
+			for _, v := range values {
+				c := matrixValue{param: v}
+				for k, prev := range cell {
+					c[k] = prev
+				}
+				next = append(next, c)
+			}
+		}
+		cells = next
+	}
+
+	tasks := make([]parserTask, 0, len(cells))
+	seen := map[string]bool{}
+	for _, cell := range cells {
+		t := pt
+		t.Name = taskMatrixInstanceName(pt.Name, params, cell)
+		t.Matrix = nil
+		t.MatrixVal = cell
+		if pt.Shard != nil {
+			t.ShardGroup = shardGroupName(pt.Name, params, cell)
+		}
+		if seen[t.Name] {
+			return nil, errors.Errorf("duplicate matrix task '%s'", t.Name)
+		}
+		seen[t.Name] = true
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// expandTaskMatrixValues expands any integer ranges in the parameter values.
+func expandTaskMatrixValues(values []string) ([]string, error) {
+	var expanded []string
+	for _, v := range values {
+		match := taskMatrixRangeRegex.FindStringSubmatch(strings.TrimSpace(v))
+		if match == nil {
+			expanded = append(expanded, v)
+			continue
+		}
+		start, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing start of range '%s'", v)
+		}
+		end, err := strconv.Atoi(match[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing end of range '%s'", v)
+		}
+		if start > end {
+			return nil, errors.Errorf("range '%s' must not end before it starts", v)
+		}
+		if end-start >= maxTaskMatrixInstances {
+			return nil, errors.Errorf("range '%s' cannot have more than %d values", v, maxTaskMatrixInstances)
+		}
+		for i := start; i <= end; i++ {
+			expanded = append(expanded, strconv.Itoa(i))
+		}
+	}
+	return utility.UniqueStrings(expanded), nil
+}
+
+// taskMatrixInstanceName returns the name of the task for a matrix cell, which
+// follows the same format as matrix variant names (e.g. "test__shard~0").
+func taskMatrixInstanceName(name string, params []string, cell matrixValue) string {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteString("__")
+	for i, param := range params {
+		if i > 0 {
+			b.WriteRune('_')
+		}
+		b.WriteString(param)
+		b.WriteRune('~')
+		b.WriteString(cell[param])
+	}
+	return b.String()
+}
+
+// expandNames replaces the names of any tasks that define a matrix with the
+// names of their instances.
+func (tmi taskMatrixInstances) expandNames(names []string) []string {
+	if len(tmi) == 0 || len(names) == 0 {
+		return names
+	}
+	expanded := make([]string, 0, len(names))
+	for _, name := range names {
+		if instances, ok := tmi[name]; ok {
+			expanded = append(expanded, instances...)
+			continue
+		}
+		expanded = append(expanded, name)
+	}
+	return expanded
+}
+
+// expandDependencies replaces dependencies on any tasks that define a matrix
+// with dependencies on each of their instances.
+func (tmi taskMatrixInstances) expandDependencies(deps parserDependencies) parserDependencies {
+	if len(tmi) == 0 || len(deps) == 0 {
+		return deps
+	}
+	expanded := make(parserDependencies, 0, len(deps))
+	for _, dep := range deps {
+		instances, ok := tmi[dep.TaskSelector.Name]
+		if !ok {
+			expanded = append(expanded, dep)
+			continue
+		}
+		for _, name := range instances {
+			instanceDep := dep
+			instanceDep.TaskSelector.Name = name
+			expanded = append(expanded, instanceDep)
+		}
+	}
+	return expanded
+}
+
+// expandTaskGroup returns a copy of the task group with references to any
+// tasks that define a matrix replaced with references to their instances.
+func (tmi taskMatrixInstances) expandTaskGroup(tg parserTaskGroup) parserTaskGroup {
+	tg.Tasks = tmi.expandNames(tg.Tasks)
+	tg.DependsOn = tmi.expandDependencies(tg.DependsOn)
+	return tg
+}
+
+// expandBVTaskUnits replaces build variant tasks that reference a task that
+// defines a matrix with one build variant task per instance. It also returns
+// the names of the matrix tasks that were referenced.
+func (tmi taskMatrixInstances) expandBVTaskUnits(units parserBVTaskUnits) (parserBVTaskUnits, []string) {
+	if len(tmi) == 0 || len(units) == 0 {
+		return units, nil
+	}
+	var referenced []string
+	expanded := make(parserBVTaskUnits, 0, len(units))
+	for _, unit := range units {
+		unit.DependsOn = tmi.expandDependencies(unit.DependsOn)
+		if unit.TaskGroup != nil {
+			tg := tmi.expandTaskGroup(*unit.TaskGroup)
+			unit.TaskGroup = &tg
+			expanded = append(expanded, unit)
+			continue
+		}
+		instances, ok := tmi[unit.Name]
+		if !ok {
+			expanded = append(expanded, unit)
+			continue
+		}
+		referenced = append(referenced, unit.Name)
+		for _, name := range instances {
+			instanceUnit := unit
+			instanceUnit.Name = name
+			expanded = append(expanded, instanceUnit)
+		}
+	}
+	return expanded, referenced
+}
+
+// expandBuildVariant returns a copy of the build variant with references to
+// any tasks that define a matrix replaced with references to their instances.
+// If the build variant lists a matrix task by name and does not already put it
+// in a display task, its instances are grouped into a display task with the
+// matrix task's name.
+func (tmi taskMatrixInstances) expandBuildVariant(pbv parserBV) parserBV {
+	if len(tmi) == 0 {
+		return pbv
+	}
+	var referenced []string
+	pbv.Tasks, referenced = tmi.expandBVTaskUnits(pbv.Tasks)
+	pbv.DependsOn = tmi.expandDependencies(pbv.DependsOn)
+
+	if len(pbv.MatrixRules) > 0 {
+		rules := make([]ruleAction, 0, len(pbv.MatrixRules))
+		for _, r := range pbv.MatrixRules {
+			var added []string
+			r.RemoveTasks = tmi.expandNames(r.RemoveTasks)
+			r.AddTasks, added = tmi.expandBVTaskUnits(r.AddTasks)
+			referenced = append(referenced, added...)
+			rules = append(rules, r)
+		}
+		pbv.MatrixRules = rules
+	}
+
+	matrixTaskOf := map[string]string{}
+	for name, instances := range tmi {
+		matrixTaskOf[name] = name
+		for _, instance := range instances {
+			matrixTaskOf[instance] = name
+		}
+	}
+	displayTasks := make([]displayTask, 0, len(pbv.DisplayTasks))
+	// Matrix tasks whose instances are already grouped into a display task, or
+	// whose name is already taken by a display task, don't need a generated one.
+	grouped := map[string]bool{}
+	for _, dt := range pbv.DisplayTasks {
+		grouped[dt.Name] = true
+		for _, et := range dt.ExecutionTasks {
+			if name, ok := matrixTaskOf[et]; ok {
+				grouped[name] = true
+			}
+		}
+		dt.ExecutionTasks = tmi.expandNames(dt.ExecutionTasks)
+		displayTasks = append(displayTasks, dt)
+	}
+	for _, name := range utility.UniqueStrings(referenced) {
+		if grouped[name] {
+			continue
+		}
+		displayTasks = append(displayTasks, displayTask{
+			Name:           name,
+			ExecutionTasks: tmi[name],
+		})
+	}
+	if len(displayTasks) > 0 {
+		pbv.DisplayTasks = displayTasks
+	}
+	return pbv
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandTaskMatrixValues(t *testing.T) {
+	values, err := expandTaskMatrixValues([]string{"0..3"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3"}, values)
+
+	values, err = expandTaskMatrixValues([]string{"a", "1..2", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "1", "2", "b"}, values)
+
+	values, err = expandTaskMatrixValues([]string{"a", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, values)
+
+	_, err = expandTaskMatrixValues([]string{"3..1"})
+	assert.Error(t, err)
+
+	_, err = expandTaskMatrixValues([]string{"0..100000"})
+	assert.Error(t, err)
+}
+
+func TestExpandTaskMatrices(t *testing.T) {
+	t.Run("ExpandsAllCombinations", func(t *testing.T) {
+		tasks, instances, errs := expandTaskMatrices([]parserTask{
+			{
+				Name:   "test",
+				Tags:   []string{"unit"},
+				Matrix: matrixDefinition{"suite": {"a", "b"}, "shard": {"0..1"}},
+			},
+			{
+				Name:      "report",
+				DependsOn: parserDependencies{{TaskSelector: taskSelector{Name: "test"}}},
+			},
+		})
+		require.Empty(t, errs)
+		expected := []string{
+			"test__shard~0_suite~a",
+			"test__shard~0_suite~b",
+			"test__shard~1_suite~a",
+			"test__shard~1_suite~b",
+		}
+		assert.Equal(t, expected, instances["test"])
+		require.Len(t, tasks, 5)
+		for i, name := range expected {
+			assert.Equal(t, name, tasks[i].Name)
+			assert.Empty(t, tasks[i].Matrix)
+			assert.EqualValues(t, []string{"unit"}, tasks[i].Tags)
+		}
+		assert.Equal(t, matrixValue{"shard": "1", "suite": "a"}, tasks[2].MatrixVal)
+
+		require.Len(t, tasks[4].DependsOn, 4)
+		for i, name := range expected {
+			assert.Equal(t, name, tasks[4].DependsOn[i].TaskSelector.Name)
+		}
+	})
+	t.Run("NoMatrices", func(t *testing.T) {
+		tasks, instances, errs := expandTaskMatrices([]parserTask{{Name: "test"}})
+		assert.Empty(t, errs)
+		assert.Empty(t, instances)
+		assert.Len(t, tasks, 1)
+	})
+	t.Run("TooManyInstances", func(t *testing.T) {
+		_, _, errs := expandTaskMatrices([]parserTask{
+			{Name: "test", Matrix: matrixDefinition{"a": {"0..99"}, "b": {"0..99"}}},
+		})
+		assert.Len(t, errs, 1)
+	})
+}
+
+func TestExpandBuildVariantTaskMatrix(t *testing.T) {
+	instances := taskMatrixInstances{"test": {"test__shard~0", "test__shard~1"}}
+
+	t.Run("GeneratesDisplayTask", func(t *testing.T) {
+		pbv := instances.expandBuildVariant(parserBV{
+			Name:  "bv",
+			Tasks: parserBVTaskUnits{{Name: "test", Priority: 10}, {Name: "other"}},
+		})
+		require.Len(t, pbv.Tasks, 3)
+		assert.Equal(t, "test__shard~0", pbv.Tasks[0].Name)
+		assert.EqualValues(t, 10, pbv.Tasks[0].Priority)
+		assert.Equal(t, "test__shard~1", pbv.Tasks[1].Name)
+		assert.Equal(t, "other", pbv.Tasks[2].Name)
+		require.Len(t, pbv.DisplayTasks, 1)
+		assert.Equal(t, "test", pbv.DisplayTasks[0].Name)
+		assert.Equal(t, instances["test"], pbv.DisplayTasks[0].ExecutionTasks)
+	})
+	t.Run("KeepsExistingDisplayTask", func(t *testing.T) {
+		pbv := instances.expandBuildVariant(parserBV{
+			Name:         "bv",
+			Tasks:        parserBVTaskUnits{{Name: "test"}, {Name: "other"}},
+			DisplayTasks: []displayTask{{Name: "everything", ExecutionTasks: []string{"test", "other"}}},
+		})
+		require.Len(t, pbv.DisplayTasks, 1)
+		assert.Equal(t, "everything", pbv.DisplayTasks[0].Name)
+		assert.Equal(t, []string{"test__shard~0", "test__shard~1", "other"}, pbv.DisplayTasks[0].ExecutionTasks)
+	})
+	t.Run("DoesNotModifyOriginal", func(t *testing.T) {
+		original := parserBV{Name: "bv", Tasks: parserBVTaskUnits{{Name: "test"}}}
+		_ = instances.expandBuildVariant(original)
+		require.Len(t, original.Tasks, 1)
+		assert.Equal(t, "test", original.Tasks[0].Name)
+		assert.Empty(t, original.DisplayTasks)
+	})
+}
+
+func TestTaskMatrixTranslation(t *testing.T) {
+	yml := `
+tasks:
+- name: test
+  matrix:
+    shard: [0..2]
+  commands:
+  - command: shell.exec
+    params:
+      script: "run-tests --shard ${shard}"
+- name: lint
+  depends_on: test
+task_groups:
+- name: tg
+  tasks:
+  - test
+buildvariants:
+- name: bv1
+  display_name: bv1
+  tasks:
+  - name: test
+  - name: lint
+- name: bv2
+  display_name: bv2
+  tasks:
+  - name: tg
+`
+	proj := &Project{}
+	pp, err := LoadProjectInto(context.Background(), []byte(yml), nil, "id", proj)
+	require.NoError(t, err)
+	require.NotNil(t, pp)
+
+	// The parser project keeps the original definition.
+	require.Len(t, pp.Tasks, 2)
+	assert.Equal(t, "test", pp.Tasks[0].Name)
+	assert.Len(t, pp.Tasks[0].Matrix, 1)
+
+	expected := []string{"test__shard~0", "test__shard~1", "test__shard~2"}
+	require.Len(t, proj.Tasks, 4)
+	for i, name := range expected {
+		assert.Equal(t, name, proj.Tasks[i].Name)
+		assert.Equal(t, map[string]string{"shard": expected[i][len(expected[i])-1:]}, proj.Tasks[i].Expansions.Map())
+	}
+	lint := proj.FindProjectTask("lint")
+	require.NotNil(t, lint)
+	require.Len(t, lint.DependsOn, 3)
+	for i, name := range expected {
+		assert.Equal(t, name, lint.DependsOn[i].Name)
+	}
+
+	require.Len(t, proj.TaskGroups, 1)
+	assert.Equal(t, expected, proj.TaskGroups[0].Tasks)
+
+	bv1 := proj.FindBuildVariant("bv1")
+	require.NotNil(t, bv1)
+	require.Len(t, bv1.Tasks, 4)
+	require.Len(t, bv1.DisplayTasks, 1)
+	assert.Equal(t, patch.DisplayTask{Name: "test", ExecTasks: expected}, bv1.DisplayTasks[0])
+
+	bv2 := proj.FindBuildVariant("bv2")
+	require.NotNil(t, bv2)
+	assert.Empty(t, bv2.DisplayTasks)
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// This file contains the code for splitting a task's tests into shards.
+// A task with a `shard` field is expanded into one task per shard using the
+// same logic as task matrices, with an additional "shard" parameter. When a
+// shard task starts, the tests that ran in previous executions of the task are
+// split between its shards so that each shard is expected to take about the
+// same amount of time (see test_shard_assignment.go).
+
+const (
+	// TaskShardExpansion is the expansion containing the task's shard index.
+	TaskShardExpansion = "shard"
+	// TaskShardCountExpansion is the expansion containing the number of
+	// shards.
+	TaskShardCountExpansion = "shard_count"
+	// TaskShardTestsFileExpansion is the expansion containing the path to the
+	// file listing the tests assigned to the task's shard.
+	TaskShardTestsFileExpansion = "shard_tests_file"
+	// TaskShardOtherTestsFileExpansion is the expansion containing the path
+	// to the file listing the tests assigned to all other shards.
+	TaskShardOtherTestsFileExpansion = "shard_other_tests_file"
+
+	// defaultMaxTaskShards is the maximum number of shards used when sharding
+	// by target duration if no count is given.
+	defaultMaxTaskShards = 10
+	// maxTaskShards is the maximum number of shards a task can be split into.
+	maxTaskShards = 100
+)
+
+// TaskShardConfig configures splitting a task's tests into shards.
+type TaskShardConfig struct {
+	// Count is the number of shards. If TargetDurationSecs is set, this is the
+	// maximum number of shards that tests are split across.
+	Count int `yaml:"count,omitempty" bson:"count,omitempty" json:"count,omitempty"`
+	// TargetDurationSecs is the target duration of each shard. If set, tests
+	// are split across as few shards as possible while keeping each shard at
+	// or under the target duration.
+	TargetDurationSecs int `yaml:"target_duration_secs,omitempty" bson:"target_duration_secs,omitempty" json:"target_duration_secs,omitempty"`
+}
+
+// Validate checks that the shard configuration is well-formed.
+func (c *TaskShardConfig) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(c.Count < 0, "shard count cannot be negative")
+	catcher.NewWhen(c.TargetDurationSecs < 0, "target duration cannot be negative")
+	catcher.NewWhen(c.Count == 0 && c.TargetDurationSecs == 0, "must specify either a shard count or a target duration")
+	catcher.NewWhen(c.Count == 1, "shard count must be at least 2")
+	catcher.ErrorfWhen(c.Count > maxTaskShards, "shard count cannot exceed %d", maxTaskShards)
+	return catcher.Resolve()
+}
+
+// numShards returns the number of shard tasks to create.
+func (c *TaskShardConfig) numShards() int {
+	if c.Count == 0 {
+		return defaultMaxTaskShards
+	}
+	return c.Count
+}
+
+// TaskShard describes one shard of a task whose tests are split into shards.
+type TaskShard struct {
+	// Group is the name shared by all the shards of the task.
+	Group string `yaml:"group,omitempty" bson:"group,omitempty" json:"group,omitempty"`
+	// Index is the shard's index, from 0 to Count-1.
+	Index int `yaml:"index" bson:"index" json:"index"`
+	// Count is the number of shards.
+	Count int `yaml:"count,omitempty" bson:"count,omitempty" json:"count,omitempty"`
+	// TargetDurationSecs is the target duration of each shard.
+	TargetDurationSecs int `yaml:"target_duration_secs,omitempty" bson:"target_duration_secs,omitempty" json:"target_duration_secs,omitempty"`
+}
+
+// addShardParameter returns a copy of the matrix definition with an additional
+// parameter for the shard index.
+func addShardParameter(def matrixDefinition, config *TaskShardConfig) (matrixDefinition, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid shard configuration")
+	}
+	if _, ok := def[TaskShardExpansion]; ok {
+		return nil, errors.Errorf("matrix cannot define parameter '%s' when the task is sharded", TaskShardExpansion)
+	}
+	withShard := matrixDefinition{}
+	for param, values := range def {
+		withShard[param] = values
+	}
+	withShard[TaskShardExpansion] = parserStringSlice{fmt.Sprintf("0..%d", config.numShards()-1)}
+	return withShard, nil
+}
+
+// shardGroupName returns the name shared by all the shards of a matrix cell,
+// which is the name that the cell's task would have if it weren't sharded.
+func shardGroupName(name string, params []string, cell matrixValue) string {
+	var groupParams []string
+	for _, param := range params {
+		if param != TaskShardExpansion {
+			groupParams = append(groupParams, param)
+		}
+	}
+	if len(groupParams) == 0 {
+		return name
+	}
+	return taskMatrixInstanceName(name, groupParams, cell)
+}
+
+// newTaskShard returns the shard information for a task generated by sharding.
+func newTaskShard(pt parserTask) (*TaskShard, error) {
+	index, err := strconv.Atoi(pt.MatrixVal[TaskShardExpansion])
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing shard index")
+	}
+	return &TaskShard{
+		Group:              pt.ShardGroup,
+		Index:              index,
+		Count:              pt.Shard.numShards(),
+		TargetDurationSecs: pt.Shard.TargetDurationSecs,
+	}, nil
+}
+
+// balanceTestShards splits the tests into at most the given number of shards
+// so that the total test duration of each shard is as even as possible. If a
+// target duration is given, only as many shards as are needed to keep each
+// shard at or under the target, accounting for the overhead of running a
+// shard, are returned.
+func balanceTestShards(durations map[string]time.Duration, count int, target, overhead time.Duration) [][]string {
+	if count < 1 {
+		return nil
+	}
+	tests := make([]string, 0, len(durations))
+	var total time.Duration
+	for name, d := range durations {
+		tests = append(tests, name)
+		total += d
+	}
+	// Assign the longest tests first, which keeps the shards close to even.
+	sort.Slice(tests, func(i, j int) bool {
+		if durations[tests[i]] != durations[tests[j]] {
+			return durations[tests[i]] > durations[tests[j]]
+		}
+		return tests[i] < tests[j]
+	})
+
+	numUsed := count
+	if perShard := target - overhead; target > 0 && perShard > 0 {
+		numUsed = int((total + perShard - 1) / perShard)
+		if numUsed < 1 {
+			numUsed = 1
+		}
+		if numUsed > count {
+			numUsed = count
+		}
+	}
+
+	shards := make([][]string, numUsed)
+	loads := make([]time.Duration, numUsed)
+	for i, name := range tests {
+		// Spread tests without any duration evenly instead of piling them
+		// onto the same shard.
+		shard := i % numUsed
+		if durations[name] > 0 {
+			shard = 0
+			for j := range loads {
+				if loads[j] < loads[shard] {
+					shard = j
+				}
+			}
+		}
+		loads[shard] += durations[name]
+		shards[shard] = append(shards[shard], name)
+	}
+	for i := range shards {
+		sort.Strings(shards[i])
+	}
+	return shards
+}
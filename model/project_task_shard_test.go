@@ -0,0 +1,150 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskShardConfigValidate(t *testing.T) {
+	assert.NoError(t, (&TaskShardConfig{Count: 4}).Validate())
+	assert.NoError(t, (&TaskShardConfig{TargetDurationSecs: 600}).Validate())
+	assert.NoError(t, (&TaskShardConfig{Count: 8, TargetDurationSecs: 600}).Validate())
+
+	assert.Error(t, (&TaskShardConfig{}).Validate())
+	assert.Error(t, (&TaskShardConfig{Count: 1}).Validate())
+	assert.Error(t, (&TaskShardConfig{Count: -1}).Validate())
+	assert.Error(t, (&TaskShardConfig{Count: maxTaskShards + 1}).Validate())
+	assert.Error(t, (&TaskShardConfig{Count: 2, TargetDurationSecs: -1}).Validate())
+}
+
+func TestBalanceTestShards(t *testing.T) {
+	durations := map[string]time.Duration{
+		"a": 8 * time.Minute,
+		"b": 7 * time.Minute,
+		"c": 6 * time.Minute,
+		"d": 5 * time.Minute,
+		"e": 4 * time.Minute,
+	}
+
+	t.Run("EvenlySplitsByCount", func(t *testing.T) {
+		shards := balanceTestShards(durations, 2, 0, 0)
+		require.Len(t, shards, 2)
+		assert.Equal(t, []string{"a", "d", "e"}, shards[0])
+		assert.Equal(t, []string{"b", "c"}, shards[1])
+	})
+	t.Run("UsesFewestShardsForTargetDuration", func(t *testing.T) {
+		shards := balanceTestShards(durations, 10, 12*time.Minute, 0)
+		require.Len(t, shards, 3)
+		assert.Equal(t, []string{"a"}, shards[0])
+		assert.Equal(t, []string{"b", "e"}, shards[1])
+		assert.Equal(t, []string{"c", "d"}, shards[2])
+	})
+	t.Run("AccountsForOverhead", func(t *testing.T) {
+		shards := balanceTestShards(durations, 10, 15*time.Minute, 5*time.Minute)
+		assert.Len(t, shards, 3)
+	})
+	t.Run("TargetDurationIsLimitedByCount", func(t *testing.T) {
+		shards := balanceTestShards(durations, 2, time.Minute, 0)
+		require.Len(t, shards, 2)
+		assert.NotEmpty(t, shards[0])
+		assert.NotEmpty(t, shards[1])
+	})
+	t.Run("SpreadsTestsWithoutDurations", func(t *testing.T) {
+		shards := balanceTestShards(map[string]time.Duration{"x": 0, "y": 0, "z": 0}, 3, 0, 0)
+		require.Len(t, shards, 3)
+		for _, shard := range shards {
+			assert.Len(t, shard, 1)
+		}
+	})
+}
+
+func TestTaskShardTranslation(t *testing.T) {
+	t.Run("ShardCount", func(t *testing.T) {
+		yml := `
+tasks:
+- name: test
+  shard:
+    count: 3
+buildvariants:
+- name: bv
+  display_name: bv
+  tasks:
+  - name: test
+`
+		proj := &Project{}
+		_, err := LoadProjectInto(context.Background(), []byte(yml), nil, "id", proj)
+		require.NoError(t, err)
+
+		require.Len(t, proj.Tasks, 3)
+		for i, pt := range proj.Tasks {
+			require.NotNil(t, pt.Shard)
+			assert.Equal(t, "test", pt.Shard.Group)
+			assert.Equal(t, i, pt.Shard.Index)
+			assert.Equal(t, 3, pt.Shard.Count)
+			assert.Equal(t, "3", pt.Expansions.Get(TaskShardCountExpansion))
+		}
+		assert.Equal(t, "test__shard~1", proj.Tasks[1].Name)
+		assert.Equal(t, "1", proj.Tasks[1].Expansions.Get(TaskShardExpansion))
+
+		bv := proj.FindBuildVariant("bv")
+		require.NotNil(t, bv)
+		require.Len(t, bv.DisplayTasks, 1)
+		assert.Equal(t, "test", bv.DisplayTasks[0].Name)
+		assert.Len(t, bv.DisplayTasks[0].ExecTasks, 3)
+	})
+	t.Run("TargetDurationWithMatrix", func(t *testing.T) {
+		yml := `
+tasks:
+- name: test
+  matrix:
+    suite: [a, b]
+  shard:
+    target_duration_secs: 600
+buildvariants:
+- name: bv
+  display_name: bv
+  tasks:
+  - name: test
+`
+		proj := &Project{}
+		_, err := LoadProjectInto(context.Background(), []byte(yml), nil, "id", proj)
+		require.NoError(t, err)
+
+		require.Len(t, proj.Tasks, 2*defaultMaxTaskShards)
+		pt := proj.FindProjectTask("test__shard~0_suite~b")
+		require.NotNil(t, pt)
+		require.NotNil(t, pt.Shard)
+		assert.Equal(t, "test__suite~b", pt.Shard.Group)
+		assert.Equal(t, defaultMaxTaskShards, pt.Shard.Count)
+		assert.Equal(t, 600, pt.Shard.TargetDurationSecs)
+		assert.Equal(t, "b", pt.Expansions.Get("suite"))
+	})
+	t.Run("InvalidConfig", func(t *testing.T) {
+		yml := `
+tasks:
+- name: test
+  shard:
+    count: 1
+`
+		proj := &Project{}
+		_, err := LoadProjectInto(context.Background(), []byte(yml), nil, "id", proj)
+		assert.Error(t, err)
+	})
+	t.Run("MatrixCannotDefineShard", func(t *testing.T) {
+		yml := `
+tasks:
+- name: test
+  matrix:
+    shard: [0..1]
+  shard:
+    count: 2
+`
+		proj := &Project{}
+		_, err := LoadProjectInto(context.Background(), []byte(yml), nil, "id", proj)
+		assert.Error(t, err)
+	})
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/evergreen-ci/evergreen"
@@ -22,6 +23,10 @@ var (
 	projectVarsMapKey   = bsonutil.MustHaveTag(ProjectVars{}, "Vars")
 	privateVarsMapKey   = bsonutil.MustHaveTag(ProjectVars{}, "PrivateVars")
 	adminOnlyVarsMapKey = bsonutil.MustHaveTag(ProjectVars{}, "AdminOnlyVars")
+	encryptedVarsMapKey = bsonutil.MustHaveTag(ProjectVars{}, "EncryptedVars")
+	dataKeyKey          = bsonutil.MustHaveTag(ProjectVars{}, "DataKey")
+	dataKeyIDKey        = bsonutil.MustHaveTag(EncryptedDataKey{}, "ID")
+	dataKeyKEKIDKey     = bsonutil.MustHaveTag(EncryptedDataKey{}, "KeyEncryptionKeyID")
 )
 
 const (
@@ -48,6 +53,14 @@ type ProjectVars struct {
 
 	// AdminOnlyVars keeps track of variables that are only accessible by project admins
 	AdminOnlyVars map[string]bool `bson:"admin_only_vars" json:"admin_only_vars"`
+
+	// EncryptedVars holds the values of variables encrypted with the data key.
+	// When project variable encryption is enabled, variables are stored here
+	// instead of in Vars, and are decrypted into Vars when they're read.
+	EncryptedVars map[string][]byte `bson:"encrypted_vars,omitempty" json:"-"`
+
+	// DataKey is the encrypted key that EncryptedVars are encrypted with.
+	DataKey *EncryptedDataKey `bson:"data_key,omitempty" json:"-"`
 }
 
 type AWSSSHKey struct {
@@ -65,6 +78,9 @@ func FindOneProjectVars(projectId string) (*ProjectVars, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = projectVars.decrypt(context.Background()); err != nil {
+		return nil, errors.Wrapf(err, "decrypting variables for project '%s'", projectId)
+	}
 	return projectVars, nil
 }
 
@@ -202,6 +218,37 @@ func GetAWSKeyForProject(projectId string) (*AWSSSHKey, error) {
 }
 
 func (projectVars *ProjectVars) Upsert() (*adb.ChangeInfo, error) {
+	ctx := context.Background()
+	ke, err := getEncrypterForWrite()
+	if err != nil {
+		return nil, err
+	}
+	if ke == nil {
+		return db.Upsert(
+			ProjectVarsCollection,
+			bson.M{
+				projectVarIdKey: projectVars.Id,
+			},
+			bson.M{
+				"$set": bson.M{
+					projectVarsMapKey:   projectVars.Vars,
+					privateVarsMapKey:   projectVars.PrivateVars,
+					adminOnlyVarsMapKey: projectVars.AdminOnlyVars,
+				},
+				"$unset": bson.M{
+					encryptedVarsMapKey: 1,
+					dataKeyKey:          1,
+				},
+			},
+		)
+	}
+
+	// All the variables are replaced, so they can be encrypted with a new
+	// data key.
+	encrypted, err := projectVars.encrypted(ctx, ke)
+	if err != nil {
+		return nil, err
+	}
 	return db.Upsert(
 		ProjectVarsCollection,
 		bson.M{
@@ -209,31 +256,101 @@ func (projectVars *ProjectVars) Upsert() (*adb.ChangeInfo, error) {
 		},
 		bson.M{
 			"$set": bson.M{
-				projectVarsMapKey:   projectVars.Vars,
+				encryptedVarsMapKey: encrypted.EncryptedVars,
+				dataKeyKey:          encrypted.DataKey,
 				privateVarsMapKey:   projectVars.PrivateVars,
 				adminOnlyVarsMapKey: projectVars.AdminOnlyVars,
 			},
+			"$unset": bson.M{
+				projectVarsMapKey: 1,
+			},
 		},
 	)
 }
 
 func (projectVars *ProjectVars) Insert() error {
+	ke, err := getEncrypterForWrite()
+	if err != nil {
+		return err
+	}
+	if ke == nil {
+		return db.Insert(
+			ProjectVarsCollection,
+			projectVars,
+		)
+	}
+
+	encrypted, err := projectVars.encrypted(context.Background(), ke)
+	if err != nil {
+		return err
+	}
 	return db.Insert(
 		ProjectVarsCollection,
-		projectVars,
+		encrypted,
 	)
 }
 
+// maxDataKeyConflictAttempts is the number of times FindAndModify retries
+// when the project's data key is replaced while it's updating the variables.
+const maxDataKeyConflictAttempts = 3
+
 func (projectVars *ProjectVars) FindAndModify(varsToDelete []string) (*adb.ChangeInfo, error) {
-	setUpdate := bson.M{}
-	unsetUpdate := bson.M{}
-	update := bson.M{}
 	if len(projectVars.Vars) == 0 && len(projectVars.PrivateVars) == 0 &&
 		len(projectVars.AdminOnlyVars) == 0 && len(varsToDelete) == 0 {
 		return nil, nil
 	}
+	ctx := context.Background()
+	ke, err := getEncrypterForWrite()
+	if err != nil {
+		return nil, err
+	}
+	if ke == nil || len(projectVars.Vars) == 0 {
+		return projectVars.findAndModify(ctx, varsToDelete, nil, nil)
+	}
+
+	for attempt := 0; attempt < maxDataKeyConflictAttempts; attempt++ {
+		dataKey, plaintextKey, err := getOrCreateDataKey(ctx, ke, projectVars.Id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting data key for project '%s'", projectVars.Id)
+		}
+		changeInfo, err := projectVars.findAndModify(ctx, varsToDelete, dataKey, plaintextKey)
+		if db.IsDuplicateKey(err) {
+			// The filter includes the data key, so the upsert tries to
+			// insert a duplicate document if another writer replaced the
+			// data key in the meantime.
+			continue
+		}
+		return changeInfo, err
+	}
+	return nil, errors.Errorf("project '%s' data key changed during update %d times", projectVars.Id, maxDataKeyConflictAttempts)
+}
+
+// findAndModify updates the given variables. If a data key is given, the
+// variables are encrypted with it, and the update only applies if the project
+// still uses that data key.
+func (projectVars *ProjectVars) findAndModify(ctx context.Context, varsToDelete []string, dataKey *EncryptedDataKey, plaintextKey []byte) (*adb.ChangeInfo, error) {
+	setUpdate := bson.M{}
+	unsetUpdate := bson.M{}
+	update := bson.M{}
+	query := bson.M{projectVarIdKey: projectVars.Id}
 	for key, val := range projectVars.Vars {
+		// Each variable is only stored in one form, so setting it in one form
+		// removes it from the other.
 		setUpdate[bsonutil.GetDottedKeyName(projectVarsMapKey, key)] = val
+		unsetUpdate[bsonutil.GetDottedKeyName(encryptedVarsMapKey, key)] = 1
+	}
+	if dataKey != nil {
+		setUpdate = bson.M{}
+		unsetUpdate = bson.M{}
+		for name, val := range projectVars.Vars {
+			ciphertext, err := encryptVar(plaintextKey, projectVars.Id, name, val)
+			if err != nil {
+				return nil, err
+			}
+			setUpdate[bsonutil.GetDottedKeyName(encryptedVarsMapKey, name)] = ciphertext
+			unsetUpdate[bsonutil.GetDottedKeyName(projectVarsMapKey, name)] = 1
+		}
+		query[bsonutil.GetDottedKeyName(dataKeyKey, dataKeyIDKey)] = dataKey.ID
 	}
 	for key, val := range projectVars.PrivateVars {
 		setUpdate[bsonutil.GetDottedKeyName(privateVarsMapKey, key)] = val
@@ -247,15 +364,16 @@ func (projectVars *ProjectVars) FindAndModify(varsToDelete []string) (*adb.Chang
 
 	for _, val := range varsToDelete {
 		unsetUpdate[bsonutil.GetDottedKeyName(projectVarsMapKey, val)] = 1
+		unsetUpdate[bsonutil.GetDottedKeyName(encryptedVarsMapKey, val)] = 1
 		unsetUpdate[bsonutil.GetDottedKeyName(privateVarsMapKey, val)] = 1
 		unsetUpdate[bsonutil.GetDottedKeyName(adminOnlyVarsMapKey, val)] = 1
 	}
 	if len(unsetUpdate) > 0 {
 		update["$unset"] = unsetUpdate
 	}
-	return db.FindAndModify(
+	changeInfo, err := db.FindAndModify(
 		ProjectVarsCollection,
-		bson.M{projectVarIdKey: projectVars.Id},
+		query,
 		nil,
 		adb.Change{
 			Update:    update,
@@ -264,6 +382,10 @@ func (projectVars *ProjectVars) FindAndModify(varsToDelete []string) (*adb.Chang
 		},
 		projectVars,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return changeInfo, errors.Wrapf(projectVars.decrypt(ctx), "decrypting variables for project '%s'", projectVars.Id)
 }
 
 func (projectVars *ProjectVars) GetVars(t *task.Task) map[string]string {
@@ -338,23 +460,55 @@ func (projectVars *ProjectVars) RedactPrivateVars() *ProjectVars {
 	return res
 }
 
+// GetVarsByValue returns the variables of all projects that have a variable
+// with the given value.
 func GetVarsByValue(val string) ([]*ProjectVars, error) {
+	// Plaintext variables are matched in the database. Encrypted variables
+	// can't be, so only projects that have them are decrypted, one at a time,
+	// and only the matching ones are kept.
 	matchingProjects := []*ProjectVars{}
-	pipeline := []bson.M{
-		{"$addFields": bson.M{projectVarsMapKey: bson.M{"$objectToArray": "$" + projectVarsMapKey}}},
-		{"$match": bson.M{bsonutil.GetDottedKeyName(projectVarsMapKey, "v"): val}},
-		{"$addFields": bson.M{projectVarsMapKey: bson.M{"$arrayToObject": "$" + projectVarsMapKey}}},
+	plaintextMatch := bson.M{
+		encryptedVarsMapKey: bson.M{"$exists": false},
+		"$expr": bson.M{"$in": bson.A{val, bson.M{
+			"$map": bson.M{
+				"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$" + projectVarsMapKey, bson.M{}}}},
+				"in":    "$$this.v",
+			},
+		}}},
 	}
-
-	err := db.Aggregate(ProjectVarsCollection, pipeline, &matchingProjects)
-
-	if adb.ResultsNotFound(err) {
-		return nil, nil
+	if err := db.Aggregate(ProjectVarsCollection, []bson.M{{"$match": plaintextMatch}}, &matchingProjects); err != nil {
+		return nil, errors.Wrap(err, "finding plaintext variables with matching value")
 	}
+
+	ctx := context.Background()
+	cur, err := evergreen.GetEnvironment().DB().Collection(ProjectVarsCollection).Find(ctx, bson.M{
+		encryptedVarsMapKey: bson.M{"$exists": true},
+	})
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "finding encrypted variables")
+	}
+	defer cur.Close(ctx)
+
+	catcher := grip.NewBasicCatcher()
+	for cur.Next(ctx) {
+		projectVars := &ProjectVars{}
+		if err := cur.Decode(projectVars); err != nil {
+			catcher.Wrap(err, "decoding project variables")
+			continue
+		}
+		if err := projectVars.decrypt(ctx); err != nil {
+			catcher.Wrapf(err, "decrypting variables for project '%s'", projectVars.Id)
+			continue
+		}
+		for _, v := range projectVars.Vars {
+			if v == val {
+				matchingProjects = append(matchingProjects, projectVars)
+				break
+			}
+		}
 	}
-	return matchingProjects, nil
+	catcher.Wrap(cur.Err(), "iterating over encrypted variables")
+	return matchingProjects, catcher.Resolve()
 }
 
 // MergeWithRepoVars merges the project and repo variables
@@ -0,0 +1,574 @@
+package model
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	adb "github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// dataKeySize is the size of data keys and local key-encryption keys,
+	// which are AES-256 keys.
+	dataKeySize = 32
+	// dataKeyCacheTTL is how long decrypted data keys are kept in memory,
+	// which avoids decrypting a project's data key with KMS every time its
+	// variables are read.
+	dataKeyCacheTTL = 5 * time.Minute
+	// maxCachedDataKeys bounds the number of decrypted data keys kept in
+	// memory.
+	maxCachedDataKeys = 1000
+)
+
+// EncryptedDataKey is the data key that encrypts a project's variables,
+// encrypted with a key-encryption key.
+type EncryptedDataKey struct {
+	// ID uniquely identifies the data key. It stays the same when the data key
+	// is re-encrypted with a different key-encryption key.
+	ID string `bson:"id" json:"id"`
+	// KeyEncryptionKeyID identifies the key-encryption key that encrypted
+	// the data key.
+	KeyEncryptionKeyID string `bson:"kek_id" json:"kek_id"`
+	// Ciphertext is the encrypted data key.
+	Ciphertext []byte `bson:"ciphertext" json:"ciphertext"`
+}
+
+// keyEncrypter encrypts and decrypts data keys with key-encryption keys.
+type keyEncrypter interface {
+	// activeKeyID returns the ID of the key-encryption key that new data keys
+	// are encrypted with.
+	activeKeyID() string
+	// encryptDataKey encrypts the data key with the active key-encryption
+	// key.
+	encryptDataKey(ctx context.Context, id string, dataKey []byte) (*EncryptedDataKey, error)
+	// decryptDataKey decrypts a data key.
+	decryptDataKey(ctx context.Context, key *EncryptedDataKey) ([]byte, error)
+}
+
+// localKeyring holds key-encryption keys read from a keyring file on the app
+// servers. To rotate keys, a new key is added and made active; the old key
+// can be removed once the project vars encryption job has re-encrypted every
+// data key with the new one.
+type localKeyring struct {
+	// Active is the ID of the key that new data keys are encrypted with.
+	Active string `yaml:"active"`
+	// Keys maps key IDs to base64-encoded 256-bit keys.
+	Keys map[string]string `yaml:"keys"`
+
+	decodedKeys map[string][]byte
+}
+
+func parseLocalKeyring(data []byte) (*localKeyring, error) {
+	kr := &localKeyring{}
+	if err := yaml.Unmarshal(data, kr); err != nil {
+		return nil, errors.Wrap(err, "parsing keyring")
+	}
+	catcher := grip.NewBasicCatcher()
+	kr.decodedKeys = map[string][]byte{}
+	for id, encoded := range kr.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			catcher.Wrapf(err, "decoding key '%s'", id)
+			continue
+		}
+		if len(key) != dataKeySize {
+			catcher.Errorf("key '%s' must be %d bytes but is %d bytes", id, dataKeySize, len(key))
+			continue
+		}
+		kr.decodedKeys[id] = key
+	}
+	catcher.NewWhen(kr.Active == "", "keyring must have an active key")
+	catcher.ErrorfWhen(kr.Active != "" && kr.Keys[kr.Active] == "", "active key '%s' is not in the keyring", kr.Active)
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+	return kr, nil
+}
+
+func (kr *localKeyring) activeKeyID() string { return kr.Active }
+
+func (kr *localKeyring) encryptDataKey(_ context.Context, id string, dataKey []byte) (*EncryptedDataKey, error) {
+	ciphertext, err := seal(kr.decodedKeys[kr.Active], dataKey, []byte(id))
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting data key")
+	}
+	return &EncryptedDataKey{
+		ID:                 id,
+		KeyEncryptionKeyID: kr.Active,
+		Ciphertext:         ciphertext,
+	}, nil
+}
+
+func (kr *localKeyring) decryptDataKey(_ context.Context, key *EncryptedDataKey) ([]byte, error) {
+	kek, ok := kr.decodedKeys[key.KeyEncryptionKeyID]
+	if !ok {
+		return nil, errors.Errorf("key-encryption key '%s' is not in the keyring", key.KeyEncryptionKeyID)
+	}
+	dataKey, err := open(kek, key.Ciphertext, []byte(key.ID))
+	return dataKey, errors.Wrap(err, "decrypting data key")
+}
+
+// kmsKeyEncrypter encrypts data keys with an AWS KMS key.
+type kmsKeyEncrypter struct {
+	client kmsiface.KMSAPI
+	keyID  string
+}
+
+func (k *kmsKeyEncrypter) activeKeyID() string { return k.keyID }
+
+func (k *kmsKeyEncrypter) encryptDataKey(ctx context.Context, id string, dataKey []byte) (*EncryptedDataKey, error) {
+	out, err := k.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(k.keyID),
+		Plaintext:         dataKey,
+		EncryptionContext: map[string]*string{"data_key_id": aws.String(id)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting data key with KMS")
+	}
+	return &EncryptedDataKey{
+		ID:                 id,
+		KeyEncryptionKeyID: k.keyID,
+		Ciphertext:         out.CiphertextBlob,
+	}, nil
+}
+
+func (k *kmsKeyEncrypter) decryptDataKey(ctx context.Context, key *EncryptedDataKey) ([]byte, error) {
+	// KMS ciphertexts identify the key that encrypted them, so data keys
+	// encrypted with a previous KMS key can still be decrypted.
+	out, err := k.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		CiphertextBlob:    key.Ciphertext,
+		EncryptionContext: map[string]*string{"data_key_id": aws.String(key.ID)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data key with KMS")
+	}
+	return out.Plaintext, nil
+}
+
+type cachedKeyring struct {
+	path    string
+	modTime time.Time
+	keyring *localKeyring
+}
+
+type cachedDataKey struct {
+	key     []byte
+	expires time.Time
+}
+
+var (
+	keyEncrypterMu sync.Mutex
+	keyringCache   cachedKeyring
+	kmsClients     = map[string]kmsiface.KMSAPI{}
+
+	dataKeyCacheMu sync.Mutex
+	dataKeyCache   = map[string]cachedDataKey{}
+)
+
+// getKeyEncrypter returns the configured key encrypter, or nil if project
+// variable encryption is not configured.
+func getKeyEncrypter(conf evergreen.SecretsConfig) (keyEncrypter, error) {
+	keyEncrypterMu.Lock()
+	defer keyEncrypterMu.Unlock()
+
+	if conf.KMSKeyID != "" {
+		client, ok := kmsClients[conf.KMSRegion]
+		if !ok {
+			sess, err := session.NewSession(&aws.Config{
+				Region: aws.String(conf.KMSRegion),
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "creating AWS session")
+			}
+			client = kms.New(sess)
+			kmsClients[conf.KMSRegion] = client
+		}
+		return &kmsKeyEncrypter{client: client, keyID: conf.KMSKeyID}, nil
+	}
+
+	if conf.KeyringFile != "" {
+		// The keyring is reloaded when the file changes so that keys can be
+		// rotated without restarting the app servers.
+		info, err := os.Stat(conf.KeyringFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading keyring file")
+		}
+		if keyringCache.keyring != nil && keyringCache.path == conf.KeyringFile && keyringCache.modTime.Equal(info.ModTime()) {
+			return keyringCache.keyring, nil
+		}
+		data, err := os.ReadFile(conf.KeyringFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading keyring file")
+		}
+		kr, err := parseLocalKeyring(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading keyring file '%s'", conf.KeyringFile)
+		}
+		keyringCache = cachedKeyring{path: conf.KeyringFile, modTime: info.ModTime(), keyring: kr}
+		return kr, nil
+	}
+
+	return nil, nil
+}
+
+// getSecretsConfig returns the admin settings for project variable secrets.
+func getSecretsConfig() evergreen.SecretsConfig {
+	env := evergreen.GetEnvironment()
+	if env == nil || env.Settings() == nil {
+		return evergreen.SecretsConfig{}
+	}
+	return env.Settings().Secrets
+}
+
+// newDataKey generates a data key and encrypts it with the active
+// key-encryption key.
+func newDataKey(ctx context.Context, ke keyEncrypter) (*EncryptedDataKey, []byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, errors.Wrap(err, "generating data key")
+	}
+	encrypted, err := ke.encryptDataKey(ctx, utility.RandomString(), dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheDataKey(encrypted.ID, dataKey)
+	return encrypted, dataKey, nil
+}
+
+// decryptDataKey decrypts the data key, using the in-memory cache if
+// possible.
+func decryptDataKey(ctx context.Context, ke keyEncrypter, key *EncryptedDataKey) ([]byte, error) {
+	dataKeyCacheMu.Lock()
+	cached, ok := dataKeyCache[key.ID]
+	dataKeyCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	if ke == nil {
+		return nil, errors.New("project variables are encrypted but no key-encryption key is configured")
+	}
+	dataKey, err := ke.decryptDataKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	cacheDataKey(key.ID, dataKey)
+	return dataKey, nil
+}
+
+func cacheDataKey(id string, dataKey []byte) {
+	dataKeyCacheMu.Lock()
+	defer dataKeyCacheMu.Unlock()
+	if len(dataKeyCache) >= maxCachedDataKeys {
+		dataKeyCache = map[string]cachedDataKey{}
+	}
+	dataKeyCache[id] = cachedDataKey{key: dataKey, expires: time.Now().Add(dataKeyCacheTTL)}
+}
+
+// varAdditionalData binds an encrypted variable to its project and name so
+// that encrypted values can't be moved to another variable.
+func varAdditionalData(projectID, name string) []byte {
+	return []byte(projectID + "\x00" + name)
+}
+
+// encryptVar encrypts a single project variable value with the data key.
+func encryptVar(dataKey []byte, projectID, name, value string) ([]byte, error) {
+	ciphertext, err := seal(dataKey, []byte(value), varAdditionalData(projectID, name))
+	return ciphertext, errors.Wrapf(err, "encrypting variable '%s'", name)
+}
+
+// decryptVar decrypts a single project variable value with the data key.
+func decryptVar(dataKey []byte, projectID, name string, ciphertext []byte) (string, error) {
+	plaintext, err := open(dataKey, ciphertext, varAdditionalData(projectID, name))
+	if err != nil {
+		return "", errors.Wrapf(err, "decrypting variable '%s'", name)
+	}
+	return string(plaintext), nil
+}
+
+// seal encrypts the plaintext with AES-GCM and prepends the nonce to the
+// ciphertext.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext produced by seal.
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+	return plaintext, errors.Wrap(err, "decrypting")
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	return gcm, errors.Wrap(err, "creating GCM")
+}
+
+// getEncrypterForWrite returns the key encrypter that variables should be
+// encrypted with when they're written, or nil if encryption is disabled.
+func getEncrypterForWrite() (keyEncrypter, error) {
+	conf := getSecretsConfig()
+	if !conf.EncryptionEnabled {
+		return nil, nil
+	}
+	ke, err := getKeyEncrypter(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting key-encryption key")
+	}
+	if ke == nil {
+		return nil, errors.New("project variable encryption is enabled but no key-encryption key is configured")
+	}
+	return ke, nil
+}
+
+// decrypt decrypts the encrypted variables into Vars. Encrypted variables
+// are read even if encryption has since been disabled, as long as their
+// key-encryption key is still configured.
+func (projectVars *ProjectVars) decrypt(ctx context.Context) error {
+	if projectVars == nil || len(projectVars.EncryptedVars) == 0 {
+		projectVars.clearEncrypted()
+		return nil
+	}
+	if projectVars.DataKey == nil {
+		return errors.New("project variables are encrypted but have no data key")
+	}
+	ke, err := getKeyEncrypter(getSecretsConfig())
+	if err != nil {
+		return errors.Wrap(err, "getting key-encryption key")
+	}
+	dataKey, err := decryptDataKey(ctx, ke, projectVars.DataKey)
+	if err != nil {
+		return err
+	}
+	return projectVars.decryptWithKey(dataKey)
+}
+
+func (projectVars *ProjectVars) decryptWithKey(dataKey []byte) error {
+	if projectVars.Vars == nil {
+		projectVars.Vars = map[string]string{}
+	}
+	catcher := grip.NewBasicCatcher()
+	for name, ciphertext := range projectVars.EncryptedVars {
+		if _, ok := projectVars.Vars[name]; ok {
+			// Writes remove the other form of the variable, so a variable
+			// only has both forms if it was written in plaintext directly
+			// to the database, in which case the plaintext is newer.
+			continue
+		}
+		val, err := decryptVar(dataKey, projectVars.Id, name, ciphertext)
+		if err != nil {
+			catcher.Add(err)
+			continue
+		}
+		projectVars.Vars[name] = val
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+	projectVars.clearEncrypted()
+	return nil
+}
+
+// clearEncrypted removes the encrypted form of the variables so that it's not
+// copied elsewhere, such as into project modification events.
+func (projectVars *ProjectVars) clearEncrypted() {
+	if projectVars == nil {
+		return
+	}
+	projectVars.EncryptedVars = nil
+	projectVars.DataKey = nil
+}
+
+// encrypted returns a copy of the variables that are encrypted with a new data
+// key.
+func (projectVars *ProjectVars) encrypted(ctx context.Context, ke keyEncrypter) (*ProjectVars, error) {
+	dataKey, key, err := newDataKey(ctx, ke)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating data key for project '%s'", projectVars.Id)
+	}
+	return projectVars.encryptedWithKey(dataKey, key)
+}
+
+func (projectVars *ProjectVars) encryptedWithKey(dataKey *EncryptedDataKey, key []byte) (*ProjectVars, error) {
+	res := &ProjectVars{
+		Id:            projectVars.Id,
+		PrivateVars:   projectVars.PrivateVars,
+		AdminOnlyVars: projectVars.AdminOnlyVars,
+		EncryptedVars: map[string][]byte{},
+		DataKey:       dataKey,
+	}
+	for name, val := range projectVars.Vars {
+		ciphertext, err := encryptVar(key, projectVars.Id, name, val)
+		if err != nil {
+			return nil, err
+		}
+		res.EncryptedVars[name] = ciphertext
+	}
+	return res, nil
+}
+
+// getOrCreateDataKey returns the project's data key, creating one if the
+// project doesn't have one yet.
+func getOrCreateDataKey(ctx context.Context, ke keyEncrypter, projectID string) (*EncryptedDataKey, []byte, error) {
+	existing := &ProjectVars{}
+	err := db.FindOneQ(ProjectVarsCollection, db.Query(bson.M{projectVarIdKey: projectID}).WithFields(dataKeyKey), existing)
+	if err != nil && !adb.ResultsNotFound(err) {
+		return nil, nil, errors.Wrap(err, "finding existing data key")
+	}
+	if existing.DataKey != nil {
+		key, err := decryptDataKey(ctx, ke, existing.DataKey)
+		return existing.DataKey, key, err
+	}
+
+	dataKey, key, err := newDataKey(ctx, ke)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = db.Upsert(ProjectVarsCollection,
+		bson.M{
+			projectVarIdKey: projectID,
+			dataKeyKey:      bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{dataKeyKey: dataKey}},
+	)
+	if db.IsDuplicateKey(err) {
+		// Another writer created a data key first, so use theirs.
+		return getOrCreateDataKey(ctx, ke, projectID)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "saving new data key")
+	}
+	return dataKey, key, nil
+}
+
+// EncryptProjectVars brings the stored project variables up to date with the
+// encryption settings. It encrypts any variables that are still stored in
+// plaintext and re-encrypts data keys that aren't encrypted with the active
+// key-encryption key, which completes a key-encryption key rotation. Updates
+// are conditional on the variables being unchanged, so documents that are
+// modified concurrently are left for the next run.
+func EncryptProjectVars(ctx context.Context) error {
+	ke, err := getEncrypterForWrite()
+	if err != nil {
+		return err
+	}
+	if ke == nil {
+		return nil
+	}
+
+	allVars := []ProjectVars{}
+	if err := db.FindAllQ(ProjectVarsCollection, db.Query(bson.M{}), &allVars); err != nil {
+		return errors.Wrap(err, "finding project variables")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	for _, projectVars := range allVars {
+		if ctx.Err() != nil {
+			catcher.Add(ctx.Err())
+			break
+		}
+		if projectVars.DataKey != nil && projectVars.DataKey.KeyEncryptionKeyID != ke.activeKeyID() {
+			catcher.Wrapf(rewrapDataKey(ctx, ke, projectVars.Id, projectVars.DataKey), "re-encrypting data key for project '%s'", projectVars.Id)
+		}
+		if len(projectVars.Vars) > 0 {
+			catcher.Wrapf(encryptPlaintextVars(ctx, ke, projectVars.Id, projectVars.Vars), "encrypting variables for project '%s'", projectVars.Id)
+		}
+	}
+
+	return catcher.Resolve()
+}
+
+// rewrapDataKey re-encrypts the data key with the active key-encryption key.
+// The variables themselves don't change.
+func rewrapDataKey(ctx context.Context, ke keyEncrypter, projectID string, dataKey *EncryptedDataKey) error {
+	key, err := decryptDataKey(ctx, ke, dataKey)
+	if err != nil {
+		return err
+	}
+	rewrapped, err := ke.encryptDataKey(ctx, dataKey.ID, key)
+	if err != nil {
+		return err
+	}
+	err = db.Update(ProjectVarsCollection,
+		bson.M{
+			projectVarIdKey: projectID,
+			bsonutil.GetDottedKeyName(dataKeyKey, dataKeyIDKey):    dataKey.ID,
+			bsonutil.GetDottedKeyName(dataKeyKey, dataKeyKEKIDKey): dataKey.KeyEncryptionKeyID,
+		},
+		bson.M{"$set": bson.M{dataKeyKey: rewrapped}},
+	)
+	if adb.ResultsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// encryptPlaintextVars moves variables that are stored in plaintext to their
+// encrypted form.
+func encryptPlaintextVars(ctx context.Context, ke keyEncrypter, projectID string, vars map[string]string) error {
+	dataKey, key, err := getOrCreateDataKey(ctx, ke, projectID)
+	if err != nil {
+		return errors.Wrap(err, "getting data key")
+	}
+
+	query := bson.M{
+		projectVarIdKey: projectID,
+		bsonutil.GetDottedKeyName(dataKeyKey, dataKeyIDKey): dataKey.ID,
+	}
+	setUpdate := bson.M{}
+	unsetUpdate := bson.M{}
+	for name, val := range vars {
+		ciphertext, err := encryptVar(key, projectID, name, val)
+		if err != nil {
+			return err
+		}
+		query[bsonutil.GetDottedKeyName(projectVarsMapKey, name)] = val
+		setUpdate[bsonutil.GetDottedKeyName(encryptedVarsMapKey, name)] = ciphertext
+		unsetUpdate[bsonutil.GetDottedKeyName(projectVarsMapKey, name)] = 1
+	}
+	err = db.Update(ProjectVarsCollection, query, bson.M{
+		"$set":   setUpdate,
+		"$unset": unsetUpdate,
+	})
+	if adb.ResultsNotFound(err) {
+		return nil
+	}
+	return err
+}
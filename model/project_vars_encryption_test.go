@@ -0,0 +1,228 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func makeTestKeyringFile(t *testing.T, active string, ids ...string) string {
+	contents := fmt.Sprintf("active: %s\nkeys:\n", active)
+	for _, id := range ids {
+		key := make([]byte, dataKeySize)
+		_, err := rand.Read(key)
+		require.NoError(t, err)
+		contents += fmt.Sprintf("  %s: %s\n", id, base64.StdEncoding.EncodeToString(key))
+	}
+	path := filepath.Join(t.TempDir(), "keyring.yml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestParseLocalKeyring(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, dataKeySize))
+	t.Run("Succeeds", func(t *testing.T) {
+		kr, err := parseLocalKeyring([]byte(fmt.Sprintf("active: new\nkeys:\n  old: %s\n  new: %s\n", key, key)))
+		require.NoError(t, err)
+		assert.Equal(t, "new", kr.activeKeyID())
+		assert.Len(t, kr.decodedKeys, 2)
+	})
+	t.Run("FailsWithoutActiveKey", func(t *testing.T) {
+		_, err := parseLocalKeyring([]byte(fmt.Sprintf("keys:\n  old: %s\n", key)))
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithMissingActiveKey", func(t *testing.T) {
+		_, err := parseLocalKeyring([]byte(fmt.Sprintf("active: new\nkeys:\n  old: %s\n", key)))
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithWrongKeySize", func(t *testing.T) {
+		_, err := parseLocalKeyring([]byte(fmt.Sprintf("active: old\nkeys:\n  old: %s\n", base64.StdEncoding.EncodeToString([]byte("short")))))
+		assert.Error(t, err)
+	})
+}
+
+func TestProjectVarsEnvelopeEncryption(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := makeTestKeyringFile(t, "k1", "k1", "k2")
+	ke, err := getKeyEncrypter(evergreen.SecretsConfig{KeyringFile: path})
+	require.NoError(t, err)
+	require.NotNil(t, ke)
+
+	vars := &ProjectVars{
+		Id:          "project",
+		Vars:        map[string]string{"a": "1", "b": "2"},
+		PrivateVars: map[string]bool{"b": true},
+	}
+
+	t.Run("RoundTrips", func(t *testing.T) {
+		dataKey, key, err := newDataKey(ctx, ke)
+		require.NoError(t, err)
+		assert.Equal(t, "k1", dataKey.KeyEncryptionKeyID)
+
+		encrypted, err := vars.encryptedWithKey(dataKey, key)
+		require.NoError(t, err)
+		assert.Empty(t, encrypted.Vars)
+		assert.Equal(t, vars.PrivateVars, encrypted.PrivateVars)
+		require.Len(t, encrypted.EncryptedVars, 2)
+		assert.NotContains(t, string(encrypted.EncryptedVars["a"]), "1")
+
+		decryptedKey, err := ke.decryptDataKey(ctx, dataKey)
+		require.NoError(t, err)
+		require.NoError(t, encrypted.decryptWithKey(decryptedKey))
+		assert.Equal(t, vars.Vars, encrypted.Vars)
+		assert.Nil(t, encrypted.EncryptedVars)
+		assert.Nil(t, encrypted.DataKey)
+	})
+	t.Run("RejectsSwappedValues", func(t *testing.T) {
+		dataKey, key, err := newDataKey(ctx, ke)
+		require.NoError(t, err)
+		encrypted, err := vars.encryptedWithKey(dataKey, key)
+		require.NoError(t, err)
+
+		encrypted.EncryptedVars["a"], encrypted.EncryptedVars["b"] = encrypted.EncryptedVars["b"], encrypted.EncryptedVars["a"]
+		assert.Error(t, encrypted.decryptWithKey(key))
+	})
+	t.Run("DecryptsDataKeyAfterRotation", func(t *testing.T) {
+		dataKey, key, err := newDataKey(ctx, ke)
+		require.NoError(t, err)
+
+		rotatedPath := filepath.Join(t.TempDir(), "keyring.yml")
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(rotatedPath, []byte("active: k2\n"+string(contents[len("active: k1\n"):])), 0600))
+		rotated, err := getKeyEncrypter(evergreen.SecretsConfig{KeyringFile: rotatedPath})
+		require.NoError(t, err)
+		assert.Equal(t, "k2", rotated.activeKeyID())
+
+		decrypted, err := rotated.decryptDataKey(ctx, dataKey)
+		require.NoError(t, err)
+		assert.Equal(t, key, decrypted)
+
+		rewrapped, err := rotated.encryptDataKey(ctx, dataKey.ID, decrypted)
+		require.NoError(t, err)
+		assert.Equal(t, dataKey.ID, rewrapped.ID)
+		assert.Equal(t, "k2", rewrapped.KeyEncryptionKeyID)
+	})
+	t.Run("FailsForUnknownKeyEncryptionKey", func(t *testing.T) {
+		dataKey, _, err := newDataKey(ctx, ke)
+		require.NoError(t, err)
+		dataKey.KeyEncryptionKeyID = "k3"
+		_, err = ke.decryptDataKey(ctx, dataKey)
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptedProjectVarsStorage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := evergreen.GetEnvironment().Settings()
+	oldSecrets := settings.Secrets
+	defer func() {
+		settings.Secrets = oldSecrets
+	}()
+
+	for tName, tCase := range map[string]func(t *testing.T){
+		"UpsertStoresOnlyCiphertext": func(t *testing.T) {
+			vars := &ProjectVars{Id: "project", Vars: map[string]string{"token": "secret"}}
+			_, err := vars.Upsert()
+			require.NoError(t, err)
+
+			raw := bson.M{}
+			require.NoError(t, db.FindOneQ(ProjectVarsCollection, db.Query(bson.M{projectVarIdKey: "project"}), &raw))
+			assert.NotContains(t, raw, projectVarsMapKey)
+			assert.Contains(t, raw, encryptedVarsMapKey)
+			assert.Contains(t, raw, dataKeyKey)
+
+			dbVars, err := FindOneProjectVars("project")
+			require.NoError(t, err)
+			require.NotNil(t, dbVars)
+			assert.Equal(t, map[string]string{"token": "secret"}, dbVars.Vars)
+			assert.Nil(t, dbVars.EncryptedVars)
+		},
+		"FindAndModifyEncryptsNewVars": func(t *testing.T) {
+			vars := &ProjectVars{Id: "project", Vars: map[string]string{"a": "1", "b": "2"}}
+			_, err := vars.Upsert()
+			require.NoError(t, err)
+
+			update := &ProjectVars{Id: "project", Vars: map[string]string{"c": "3"}}
+			_, err = update.FindAndModify([]string{"a"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"b": "2", "c": "3"}, update.Vars)
+
+			dbVars, err := FindOneProjectVars("project")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"b": "2", "c": "3"}, dbVars.Vars)
+		},
+		"EncryptProjectVarsMigratesPlaintext": func(t *testing.T) {
+			settings.Secrets.EncryptionEnabled = false
+			vars := &ProjectVars{Id: "project", Vars: map[string]string{"a": "1"}}
+			_, err := vars.Upsert()
+			require.NoError(t, err)
+
+			settings.Secrets.EncryptionEnabled = true
+			require.NoError(t, EncryptProjectVars(ctx))
+
+			raw := bson.M{}
+			require.NoError(t, db.FindOneQ(ProjectVarsCollection, db.Query(bson.M{projectVarIdKey: "project"}), &raw))
+			assert.Empty(t, raw[projectVarsMapKey])
+			assert.Contains(t, raw, encryptedVarsMapKey)
+
+			dbVars, err := FindOneProjectVars("project")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"a": "1"}, dbVars.Vars)
+		},
+		"EncryptProjectVarsRotatesKeyEncryptionKey": func(t *testing.T) {
+			vars := &ProjectVars{Id: "project", Vars: map[string]string{"a": "1"}}
+			_, err := vars.Upsert()
+			require.NoError(t, err)
+
+			contents, err := os.ReadFile(settings.Secrets.KeyringFile)
+			require.NoError(t, err)
+			rotatedPath := filepath.Join(t.TempDir(), "keyring.yml")
+			require.NoError(t, os.WriteFile(rotatedPath, []byte("active: k2\n"+string(contents[len("active: k1\n"):])), 0600))
+			settings.Secrets.KeyringFile = rotatedPath
+			require.NoError(t, EncryptProjectVars(ctx))
+
+			stored := ProjectVars{}
+			require.NoError(t, db.FindOneQ(ProjectVarsCollection, db.Query(bson.M{projectVarIdKey: "project"}), &stored))
+			require.NotNil(t, stored.DataKey)
+			assert.Equal(t, "k2", stored.DataKey.KeyEncryptionKeyID)
+
+			dbVars, err := FindOneProjectVars("project")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"a": "1"}, dbVars.Vars)
+		},
+		"GetVarsByValueMatchesEncryptedVars": func(t *testing.T) {
+			vars := &ProjectVars{Id: "project", Vars: map[string]string{"a": "needle"}}
+			_, err := vars.Upsert()
+			require.NoError(t, err)
+
+			matching, err := GetVarsByValue("needle")
+			require.NoError(t, err)
+			require.Len(t, matching, 1)
+			assert.Equal(t, "project", matching[0].Id)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.Clear(ProjectVarsCollection))
+			settings.Secrets = evergreen.SecretsConfig{
+				EncryptionEnabled: true,
+				KeyringFile:       makeTestKeyringFile(t, "k1", "k1", "k2"),
+			}
+			tCase(t)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// vaultSecretPrefix is the prefix of project variable values that refer
+	// to a field of a Vault secret, in the form vault:<path>#<field>.
+	vaultSecretPrefix = "vault:"
+	// fileSecretPrefix is the prefix of project variable values that refer
+	// to a secret in the file-based secret store, in the form file:<name>.
+	fileSecretPrefix = "file:"
+)
+
+// ValidateExternalVarRefs checks that the variables of a project or repo only
+// refer to its own secrets in the external secret stores. Each project's
+// secrets are stored under its ID, e.g. vault:<id>/<path>#<field> or
+// file:<id>/<name>, so that one project can't read another project's secrets.
+func ValidateExternalVarRefs(ownerID string, vars map[string]string) error {
+	catcher := grip.NewBasicCatcher()
+	for name, val := range vars {
+		catcher.Wrapf(validateExternalVarRef([]string{ownerID}, val), "invalid variable '%s'", name)
+	}
+	return catcher.Resolve()
+}
+
+// validateExternalVarRef checks that the value, if it refers to an external
+// secret, refers to a secret under one of the owners' IDs.
+func validateExternalVarRef(ownerIDs []string, val string) error {
+	var secretPath string
+	switch {
+	case strings.HasPrefix(val, vaultSecretPrefix):
+		secretPath, _, _ = strings.Cut(strings.TrimPrefix(val, vaultSecretPrefix), "#")
+	case strings.HasPrefix(val, fileSecretPrefix):
+		secretPath = strings.TrimPrefix(val, fileSecretPrefix)
+	default:
+		return nil
+	}
+	segments := strings.Split(secretPath, "/")
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.Contains(segment, "\\") {
+			return errors.Errorf("secret path '%s' is invalid", secretPath)
+		}
+	}
+	if len(segments) < 2 || !utility.StringSliceContains(ownerIDs, segments[0]) {
+		return errors.Errorf("secret path '%s' must be under the ID of the project or repo ('%s')", secretPath, strings.Join(ownerIDs, "', '"))
+	}
+	return nil
+}
+
+// ResolveExternalVars replaces the values of variables that refer to a secret
+// in an external secret store with the secret itself. References are only
+// resolved for secret stores that are configured, so values that merely look
+// like references are left alone otherwise. If any reference can't be
+// resolved, it returns an error rather than the unresolved reference. The
+// owner IDs are the IDs of the project and repo that the variables belong to,
+// which references must be under.
+func ResolveExternalVars(ctx context.Context, conf evergreen.SecretsConfig, ownerIDs []string, vars map[string]string) error {
+	vaultSecrets := map[string]map[string]string{}
+	catcher := grip.NewBasicCatcher()
+	for name, val := range vars {
+		isVaultRef := conf.Vault.IsConfigured() && strings.HasPrefix(val, vaultSecretPrefix)
+		isFileRef := conf.FileStoreDirectory != "" && strings.HasPrefix(val, fileSecretPrefix)
+		if isVaultRef || isFileRef {
+			if err := validateExternalVarRef(ownerIDs, val); err != nil {
+				catcher.Wrapf(err, "resolving variable '%s'", name)
+				continue
+			}
+		}
+		switch {
+		case isVaultRef:
+			path, field, ok := strings.Cut(strings.TrimPrefix(val, vaultSecretPrefix), "#")
+			if !ok || path == "" || field == "" {
+				catcher.Errorf("variable '%s' must refer to a Vault secret in the form %s<path>#<field>", name, vaultSecretPrefix)
+				continue
+			}
+			secret, ok := vaultSecrets[path]
+			if !ok {
+				var err error
+				secret, err = thirdparty.GetVaultSecret(ctx, conf.Vault, path)
+				if err != nil {
+					catcher.Wrapf(err, "resolving variable '%s'", name)
+					continue
+				}
+				vaultSecrets[path] = secret
+			}
+			resolved, ok := secret[field]
+			if !ok {
+				catcher.Errorf("resolving variable '%s': Vault secret '%s' has no field '%s'", name, path, field)
+				continue
+			}
+			vars[name] = resolved
+		case isFileRef:
+			resolved, err := readFileSecret(conf.FileStoreDirectory, strings.TrimPrefix(val, fileSecretPrefix))
+			if err != nil {
+				catcher.Wrapf(err, "resolving variable '%s'", name)
+				continue
+			}
+			vars[name] = resolved
+		}
+	}
+
+	return catcher.Resolve()
+}
+
+// readFileSecret reads the named secret from the file-based secret store.
+func readFileSecret(dir, name string) (string, error) {
+	if name == "" || !filepath.IsLocal(name) {
+		return "", errors.Errorf("invalid secret name '%s'", name)
+	}
+	contents, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret '%s'", name)
+	}
+	// Secret files commonly end with a newline that isn't part of the secret.
+	return strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r"), nil
+}
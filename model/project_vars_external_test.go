@@ -0,0 +1,114 @@
+package model
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveExternalVars(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v1/secret/data/proj/deploy" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"token": "vault-token", "user": "deployer"}}}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "proj"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "proj", "api_key"), []byte("file-secret\n"), 0600))
+
+	conf := evergreen.SecretsConfig{
+		Vault:              evergreen.VaultConfig{URL: srv.URL, Token: "token", MountPath: "secret"},
+		FileStoreDirectory: dir,
+	}
+
+	owners := []string{"proj", "repo"}
+
+	t.Run("ResolvesReferences", func(t *testing.T) {
+		requests = 0
+		vars := map[string]string{
+			"token":   "vault:proj/deploy#token",
+			"user":    "vault:proj/deploy#user",
+			"api_key": "file:proj/api_key",
+			"plain":   "value",
+		}
+		require.NoError(t, ResolveExternalVars(ctx, conf, owners, vars))
+		assert.Equal(t, map[string]string{
+			"token":   "vault-token",
+			"user":    "deployer",
+			"api_key": "file-secret",
+			"plain":   "value",
+		}, vars)
+		assert.Equal(t, 1, requests, "secret should only be fetched once")
+	})
+	t.Run("IgnoresReferencesToUnconfiguredStores", func(t *testing.T) {
+		vars := map[string]string{
+			"token":   "vault:proj/deploy#token",
+			"api_key": "file:proj/api_key",
+		}
+		require.NoError(t, ResolveExternalVars(ctx, evergreen.SecretsConfig{}, owners, vars))
+		assert.Equal(t, "vault:proj/deploy#token", vars["token"])
+		assert.Equal(t, "file:proj/api_key", vars["api_key"])
+	})
+	t.Run("FailsForMissingField", func(t *testing.T) {
+		vars := map[string]string{"token": "vault:proj/deploy#password"}
+		assert.Error(t, ResolveExternalVars(ctx, conf, owners, vars))
+	})
+	t.Run("FailsForMalformedVaultReference", func(t *testing.T) {
+		vars := map[string]string{"token": "vault:proj/deploy"}
+		assert.Error(t, ResolveExternalVars(ctx, conf, owners, vars))
+	})
+	t.Run("FailsForMissingSecret", func(t *testing.T) {
+		vars := map[string]string{"token": "vault:proj/other#token"}
+		assert.Error(t, ResolveExternalVars(ctx, conf, owners, vars))
+	})
+	t.Run("FailsForFileOutsideStore", func(t *testing.T) {
+		vars := map[string]string{"api_key": "file:proj/../../api_key"}
+		assert.Error(t, ResolveExternalVars(ctx, conf, owners, vars))
+	})
+	t.Run("FailsForOtherProjectsSecrets", func(t *testing.T) {
+		requests = 0
+		vars := map[string]string{"token": "vault:other/deploy#token"}
+		assert.Error(t, ResolveExternalVars(ctx, conf, owners, vars))
+		assert.Equal(t, "vault:other/deploy#token", vars["token"])
+		assert.Zero(t, requests, "secret should not be fetched")
+
+		vars = map[string]string{"api_key": "file:other/api_key"}
+		assert.Error(t, ResolveExternalVars(ctx, conf, owners, vars))
+	})
+}
+
+func TestValidateExternalVarRefs(t *testing.T) {
+	assert.NoError(t, ValidateExternalVarRefs("proj", map[string]string{
+		"plain": "value",
+		"token": "vault:proj/deploy#token",
+		"key":   "file:proj/team/api_key",
+	}))
+	for _, val := range []string{
+		"vault:other/deploy#token",
+		"vault:proj#token",
+		"vault:proj/../other/deploy#token",
+		"vault:/proj/deploy#token",
+		"file:api_key",
+		"file:proj/../other/api_key",
+		"file:proj//api_key",
+		"file:proj\\..\\other",
+	} {
+		assert.Error(t, ValidateExternalVarRefs("proj", map[string]string{"var": val}), val)
+	}
+}
@@ -163,26 +163,38 @@ func (filter TaskReliabilityFilter) buildGroupID() bson.M {
 func (filter TaskReliabilityFilter) BuildTaskStatsQueryGroupStage() bson.M {
 	return bson.M{
 		"$group": bson.M{
-			"_id":                                 filter.buildGroupID(),
-			taskstats.TaskStatsNumSuccessKey:      bson.M{"$sum": "$" + taskstats.DBTaskStatsNumSuccessKey},
-			taskstats.TaskStatsNumFailedKey:       bson.M{"$sum": "$" + taskstats.DBTaskStatsNumFailedKey},
-			taskstats.TaskStatsNumTimeoutKey:      bson.M{"$sum": "$" + taskstats.DBTaskStatsNumTimeoutKey},
-			taskstats.TaskStatsNumTestFailedKey:   bson.M{"$sum": "$" + taskstats.DBTaskStatsNumTestFailedKey},
-			taskstats.TaskStatsNumSystemFailedKey: bson.M{"$sum": "$" + taskstats.DBTaskStatsNumSystemFailedKey},
-			taskstats.TaskStatsNumSetupFailedKey:  bson.M{"$sum": "$" + taskstats.DBTaskStatsNumSetupFailedKey},
-			"total_duration_success":              bson.M{"$sum": bson.M{"$multiply": taskstats.Array{"$" + taskstats.DBTaskStatsNumSuccessKey, "$" + taskstats.DBTaskStatsAvgDurationSuccessKey}}},
+			"_id":                                           filter.buildGroupID(),
+			taskstats.TaskStatsNumSuccessKey:                bson.M{"$sum": "$" + taskstats.DBTaskStatsNumSuccessKey},
+			taskstats.TaskStatsNumFailedKey:                 bson.M{"$sum": "$" + taskstats.DBTaskStatsNumFailedKey},
+			taskstats.TaskStatsNumTimeoutKey:                bson.M{"$sum": "$" + taskstats.DBTaskStatsNumTimeoutKey},
+			taskstats.TaskStatsNumTestFailedKey:             bson.M{"$sum": "$" + taskstats.DBTaskStatsNumTestFailedKey},
+			taskstats.TaskStatsNumSystemFailedKey:           bson.M{"$sum": "$" + taskstats.DBTaskStatsNumSystemFailedKey},
+			taskstats.TaskStatsNumSetupFailedKey:            bson.M{"$sum": "$" + taskstats.DBTaskStatsNumSetupFailedKey},
+			taskstats.TaskStatsNumOOMKey:                    bson.M{"$sum": "$" + taskstats.DBTaskStatsNumOOMKey},
+			taskstats.TaskStatsNumDiskFullKey:               bson.M{"$sum": "$" + taskstats.DBTaskStatsNumDiskFullKey},
+			taskstats.TaskStatsNumExecTimeoutKey:            bson.M{"$sum": "$" + taskstats.DBTaskStatsNumExecTimeoutKey},
+			taskstats.TaskStatsNumIdleTimeoutKey:            bson.M{"$sum": "$" + taskstats.DBTaskStatsNumIdleTimeoutKey},
+			taskstats.TaskStatsNumClassifiedSystemFailedKey: bson.M{"$sum": "$" + taskstats.DBTaskStatsNumClassifiedSystemFailedKey},
+			taskstats.TaskStatsNumClassifiedSetupFailedKey:  bson.M{"$sum": "$" + taskstats.DBTaskStatsNumClassifiedSetupFailedKey},
+			taskstats.TaskStatsNumClassifiedTestFailedKey:   bson.M{"$sum": "$" + taskstats.DBTaskStatsNumClassifiedTestFailedKey},
+			"total_duration_success":                        bson.M{"$sum": bson.M{"$multiply": taskstats.Array{"$" + taskstats.DBTaskStatsNumSuccessKey, "$" + taskstats.DBTaskStatsAvgDurationSuccessKey}}},
 		}}
 }
 
 // TaskReliabilityQueryPipeline creates an aggregation pipeline to query task statistics for reliability.
 func (filter TaskReliabilityFilter) taskReliabilityQueryPipeline() []bson.M {
-	return []bson.M{
+	pipeline := []bson.M{
 		filter.buildMatchStageForTask(),
 		filter.BuildTaskStatsQueryGroupStage(),
 		filter.BuildTaskStatsQueryProjectStage(),
-		filter.BuildTaskStatsQuerySortStage(),
-		{"$limit": filter.Limit},
 	}
+	if match := filter.BuildFailureClassificationMatchStage(); match != nil {
+		pipeline = append(pipeline, match)
+	}
+	return append(pipeline,
+		filter.BuildTaskStatsQuerySortStage(),
+		bson.M{"$limit": filter.Limit},
+	)
 }
 
 // GetTaskStats create an aggregation to find task stats matching the filter state.
@@ -79,6 +79,10 @@ type TaskReliability struct {
 	NumTestFailed      int
 	NumSystemFailed    int
 	NumSetupFailed     int
+	NumOOM             int
+	NumDiskFull        int
+	NumExecTimeout     int
+	NumIdleTimeout     int
 	AvgDurationSuccess float64
 	SuccessRate        float64
 	Z                  float64
@@ -133,6 +137,10 @@ func newTaskReliability(taskStat taskstats.TaskStats, z float64) TaskReliability
 		NumTestFailed:      taskStat.NumTestFailed,
 		NumSystemFailed:    taskStat.NumSystemFailed,
 		NumSetupFailed:     taskStat.NumSetupFailed,
+		NumOOM:             taskStat.NumOOM,
+		NumDiskFull:        taskStat.NumDiskFull,
+		NumExecTimeout:     taskStat.NumExecTimeout,
+		NumIdleTimeout:     taskStat.NumIdleTimeout,
 		AvgDurationSuccess: taskStat.AvgDurationSuccess,
 		LastUpdate:         taskStat.LastUpdate,
 		Z:                  z,
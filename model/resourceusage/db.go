@@ -0,0 +1,61 @@
+package resourceusage
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/mongodb/anser/bsonutil"
+	adb "github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	IDKey         = bsonutil.MustHaveTag(TaskResourceUsage{}, "ID")
+	TaskIDKey     = bsonutil.MustHaveTag(TaskResourceUsage{}, "TaskID")
+	ExecutionKey  = bsonutil.MustHaveTag(TaskResourceUsage{}, "Execution")
+	SamplesKey    = bsonutil.MustHaveTag(TaskResourceUsage{}, "Samples")
+	CreateTimeKey = bsonutil.MustHaveTag(TaskResourceUsage{}, "CreateTime")
+)
+
+// AppendSamples adds samples to the end of a task execution's time series,
+// dropping the oldest samples if it has more than MaxSamples.
+func AppendSamples(taskID string, execution int, samples []Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	_, err := db.Upsert(
+		Collection,
+		bson.M{IDKey: documentID(taskID, execution)},
+		bson.M{
+			"$set": bson.M{
+				TaskIDKey:    taskID,
+				ExecutionKey: execution,
+			},
+			"$setOnInsert": bson.M{
+				CreateTimeKey: time.Now(),
+			},
+			"$push": bson.M{
+				SamplesKey: bson.M{
+					"$each":  samples,
+					"$slice": -MaxSamples,
+				},
+			},
+		},
+	)
+	return errors.Wrapf(err, "appending resource usage samples for task '%s' execution %d", taskID, execution)
+}
+
+// FindOneByTaskIDAndExecution returns the time series for a task execution,
+// or nil if the task has none.
+func FindOneByTaskIDAndExecution(taskID string, execution int) (*TaskResourceUsage, error) {
+	usage := &TaskResourceUsage{}
+	err := db.FindOneQ(Collection, db.Query(bson.M{IDKey: documentID(taskID, execution)}), usage)
+	if adb.ResultsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding resource usage for task '%s' execution %d", taskID, execution)
+	}
+	return usage, nil
+}
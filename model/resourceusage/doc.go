@@ -0,0 +1,3 @@
+// Package resourceusage models the time series of resource usage that the
+// agent samples from a task's processes while the task runs.
+package resourceusage
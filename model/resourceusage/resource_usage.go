@@ -0,0 +1,72 @@
+package resourceusage
+
+import (
+	"fmt"
+	"time"
+)
+
+const Collection = "task_resource_usage"
+
+// MaxSamples is the maximum number of samples kept for a task execution,
+// which is 12 hours of samples at the agent's default interval. Once a task
+// has more samples than this, only the most recent ones are kept since they
+// show what the task was doing when it ended. Along with MaxProcesses and the
+// agent only keeping process names, this keeps each document to a few
+// megabytes.
+const MaxSamples = 4320
+
+// MaxProcesses is the maximum number of processes whose usage is kept in each
+// sample.
+const MaxProcesses = 5
+
+// TaskResourceUsage is the time series of resource usage for a single task
+// execution.
+type TaskResourceUsage struct {
+	ID        string   `bson:"_id" json:"id"`
+	TaskID    string   `bson:"task_id" json:"task_id"`
+	Execution int      `bson:"execution" json:"execution"`
+	Samples   []Sample `bson:"samples" json:"samples"`
+	// CreateTime is when the first samples were added. Time series expire 30
+	// days after they're created.
+	CreateTime time.Time `bson:"create_time" json:"create_time"`
+}
+
+// Sample is the resource usage of a task's processes at a point in time. The
+// BSON field names are abbreviated to keep long time series compact.
+type Sample struct {
+	Time time.Time `bson:"t" json:"time"`
+	// CPUPercent is the CPU time used by the task's processes since the
+	// previous sample as a percentage of the elapsed time. It can exceed 100
+	// if the processes use more than one core.
+	CPUPercent float64 `bson:"cpu" json:"cpu_percent"`
+	// MemoryRSSBytes is the total resident memory of the task's processes.
+	MemoryRSSBytes uint64 `bson:"rss" json:"memory_rss_bytes"`
+	// MemoryAvailableBytes is the memory available on the host.
+	MemoryAvailableBytes uint64 `bson:"mem_avail" json:"memory_available_bytes"`
+	// DiskReadBytes and DiskWriteBytes are the bytes that the task's
+	// processes read from and wrote to disk since the previous sample.
+	DiskReadBytes  uint64 `bson:"disk_r" json:"disk_read_bytes"`
+	DiskWriteBytes uint64 `bson:"disk_w" json:"disk_write_bytes"`
+	// NetworkRecvBytes and NetworkSentBytes are the bytes that the host
+	// received and sent over the network since the previous sample. Network
+	// usage isn't tracked per process, so they include traffic from outside
+	// the task.
+	NetworkRecvBytes uint64 `bson:"net_r" json:"network_recv_bytes"`
+	NetworkSentBytes uint64 `bson:"net_s" json:"network_sent_bytes"`
+	// NumProcesses is the number of processes that the task was running.
+	NumProcesses int `bson:"procs" json:"num_processes"`
+	// Processes are the task's processes with the most resident memory.
+	Processes []ProcessUsage `bson:"p,omitempty" json:"processes,omitempty"`
+}
+
+// ProcessUsage is the resource usage of a single process in a sample.
+type ProcessUsage struct {
+	PID int32 `bson:"pid" json:"pid"`
+	// Command is the name of the process, without its arguments.
+	Command  string `bson:"cmd" json:"command"`
+	RSSBytes uint64 `bson:"rss" json:"rss_bytes"`
+}
+
+func documentID(taskID string, execution int) string {
+	return fmt.Sprintf("%s_%d", taskID, execution)
+}
@@ -0,0 +1,70 @@
+package resourceusage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	_ "github.com/evergreen-ci/evergreen/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendSamples(t *testing.T) {
+	require.NoError(t, db.Clear(Collection))
+	defer func() {
+		assert.NoError(t, db.Clear(Collection))
+	}()
+
+	start := time.Now().Truncate(time.Second)
+	assert.NoError(t, AppendSamples("t1", 0, []Sample{
+		{Time: start, CPUPercent: 50, MemoryRSSBytes: 100},
+		{Time: start.Add(time.Second), CPUPercent: 150, MemoryRSSBytes: 200, Processes: []ProcessUsage{{PID: 1, Command: "make", RSSBytes: 200}}},
+	}))
+	assert.NoError(t, AppendSamples("t1", 0, []Sample{{Time: start.Add(2 * time.Second), MemoryRSSBytes: 300}}))
+	assert.NoError(t, AppendSamples("t1", 1, []Sample{{Time: start, MemoryRSSBytes: 400}}))
+	assert.NoError(t, AppendSamples("t1", 2, nil))
+
+	usage, err := FindOneByTaskIDAndExecution("t1", 0)
+	require.NoError(t, err)
+	require.NotNil(t, usage)
+	assert.Equal(t, "t1", usage.TaskID)
+	assert.Equal(t, 0, usage.Execution)
+	assert.False(t, usage.CreateTime.IsZero())
+	require.Len(t, usage.Samples, 3)
+	assert.EqualValues(t, 100, usage.Samples[0].MemoryRSSBytes)
+	assert.EqualValues(t, 150, usage.Samples[1].CPUPercent)
+	require.Len(t, usage.Samples[1].Processes, 1)
+	assert.Equal(t, "make", usage.Samples[1].Processes[0].Command)
+	assert.EqualValues(t, 300, usage.Samples[2].MemoryRSSBytes)
+
+	usage, err = FindOneByTaskIDAndExecution("t1", 1)
+	require.NoError(t, err)
+	require.NotNil(t, usage)
+	assert.Len(t, usage.Samples, 1)
+
+	usage, err = FindOneByTaskIDAndExecution("t1", 2)
+	assert.NoError(t, err)
+	assert.Nil(t, usage)
+}
+
+func TestAppendSamplesKeepsMostRecent(t *testing.T) {
+	require.NoError(t, db.Clear(Collection))
+	defer func() {
+		assert.NoError(t, db.Clear(Collection))
+	}()
+
+	start := time.Now().Truncate(time.Second)
+	samples := make([]Sample, MaxSamples+10)
+	for i := range samples {
+		samples[i] = Sample{Time: start.Add(time.Duration(i) * time.Second), NumProcesses: i}
+	}
+	require.NoError(t, AppendSamples("t1", 0, samples))
+
+	usage, err := FindOneByTaskIDAndExecution("t1", 0)
+	require.NoError(t, err)
+	require.NotNil(t, usage)
+	require.Len(t, usage.Samples, MaxSamples)
+	assert.Equal(t, 10, usage.Samples[0].NumProcesses)
+	assert.Equal(t, MaxSamples+9, usage.Samples[MaxSamples-1].NumProcesses)
+}
@@ -80,6 +80,7 @@ var (
 	DetailsKey                     = bsonutil.MustHaveTag(Task{}, "Details")
 	AbortedKey                     = bsonutil.MustHaveTag(Task{}, "Aborted")
 	AbortInfoKey                   = bsonutil.MustHaveTag(Task{}, "AbortInfo")
+	FailureClassificationKey       = bsonutil.MustHaveTag(Task{}, "FailureClassification")
 	TimeTakenKey                   = bsonutil.MustHaveTag(Task{}, "TimeTaken")
 	ExpectedDurationKey            = bsonutil.MustHaveTag(Task{}, "ExpectedDuration")
 	ExpectedDurationStddevKey      = bsonutil.MustHaveTag(Task{}, "ExpectedDurationStdDev")
@@ -109,6 +110,8 @@ var (
 	GeneratedTasksToActivateKey = bsonutil.MustHaveTag(Task{}, "GeneratedTasksToActivate")
 	ResetWhenFinishedKey        = bsonutil.MustHaveTag(Task{}, "ResetWhenFinished")
 	ResetFailedWhenFinishedKey  = bsonutil.MustHaveTag(Task{}, "ResetFailedWhenFinished")
+	RetryPolicyKey              = bsonutil.MustHaveTag(Task{}, "RetryPolicy")
+	RetryAtKey                  = bsonutil.MustHaveTag(Task{}, "RetryAt")
 	CommitQueueMergeKey         = bsonutil.MustHaveTag(Task{}, "CommitQueueMerge")
 	DisplayStatusKey            = bsonutil.MustHaveTag(Task{}, "DisplayStatus")
 	BaseTaskKey                 = bsonutil.MustHaveTag(Task{}, "BaseTask")
@@ -0,0 +1,90 @@
+package task
+
+import (
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+)
+
+// Failure classifications describe the cause of a task failure.
+const (
+	// FailureClassificationOOM means that the OOM killer killed one of the
+	// task's processes.
+	FailureClassificationOOM = "oom"
+	// FailureClassificationDiskFull means that the host ran out of disk
+	// space while the command that failed the task was running.
+	FailureClassificationDiskFull = "disk-full"
+	// FailureClassificationExecTimeout means that the task exceeded its exec
+	// timeout.
+	FailureClassificationExecTimeout = "exec-timeout"
+	// FailureClassificationIdleTimeout means that a command exceeded its idle
+	// timeout.
+	FailureClassificationIdleTimeout = "idle-timeout"
+	// FailureClassificationSystem means that a system command failed or the
+	// task failed because of a problem with Evergreen or the host.
+	FailureClassificationSystem = "system"
+	// FailureClassificationSetup means that a setup command failed.
+	FailureClassificationSetup = "setup"
+	// FailureClassificationTest means that a test command failed.
+	FailureClassificationTest = "test"
+)
+
+// FailureClassifications are all the valid failure classifications, in the
+// order of precedence used to classify a failure.
+var FailureClassifications = []string{
+	FailureClassificationOOM,
+	FailureClassificationDiskFull,
+	FailureClassificationExecTimeout,
+	FailureClassificationIdleTimeout,
+	FailureClassificationSystem,
+	FailureClassificationSetup,
+	FailureClassificationTest,
+}
+
+const (
+	// These are the timeout types that the agent reports in the task end
+	// details.
+	execTimeoutType = "exec"
+	idleTimeoutType = "idle"
+)
+
+// ClassifyFailure returns the failure classification of a task that finished
+// with the given details, or an empty string if the task did not fail.
+// Resource exhaustion takes precedence over timeouts, since running out of
+// memory or disk often causes a task to hang until it times out. The agent
+// only reports that the disk was full if it ran out of space while the
+// command that failed the task was running, so a disk filled up afterwards
+// by the post block doesn't hide the real cause.
+func ClassifyFailure(details apimodels.TaskEndDetail) string {
+	if details.Status != evergreen.TaskFailed {
+		return ""
+	}
+
+	switch {
+	case details.OOMTracker != nil && details.OOMTracker.Detected:
+		return FailureClassificationOOM
+	case details.DiskFull:
+		return FailureClassificationDiskFull
+	case details.TimedOut && details.TimeoutType == execTimeoutType:
+		return FailureClassificationExecTimeout
+	case details.TimedOut && details.TimeoutType == idleTimeoutType:
+		return FailureClassificationIdleTimeout
+	case details.Type == evergreen.CommandTypeSystem:
+		return FailureClassificationSystem
+	case details.Type == evergreen.CommandTypeSetup:
+		return FailureClassificationSetup
+	default:
+		return FailureClassificationTest
+	}
+}
+
+// ValidateFailureClassifications checks that all the given failure
+// classifications are valid.
+func ValidateFailureClassifications(classifications []string) error {
+	catcher := grip.NewBasicCatcher()
+	for _, c := range classifications {
+		catcher.ErrorfWhen(!utility.StringSliceContains(FailureClassifications, c), "invalid failure classification '%s'", c)
+	}
+	return catcher.Resolve()
+}
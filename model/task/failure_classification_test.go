@@ -0,0 +1,89 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	for name, tc := range map[string]struct {
+		details  apimodels.TaskEndDetail
+		expected string
+	}{
+		"SucceededTaskIsNotClassified": {
+			details: apimodels.TaskEndDetail{Status: evergreen.TaskSucceeded},
+		},
+		"OOM": {
+			details: apimodels.TaskEndDetail{
+				Status:     evergreen.TaskFailed,
+				Type:       evergreen.CommandTypeTest,
+				OOMTracker: &apimodels.OOMTrackerInfo{Detected: true},
+			},
+			expected: FailureClassificationOOM,
+		},
+		"OOMTakesPrecedenceOverTimeout": {
+			details: apimodels.TaskEndDetail{
+				Status:      evergreen.TaskFailed,
+				TimedOut:    true,
+				TimeoutType: execTimeoutType,
+				OOMTracker:  &apimodels.OOMTrackerInfo{Detected: true},
+			},
+			expected: FailureClassificationOOM,
+		},
+		"DiskFull": {
+			details: apimodels.TaskEndDetail{
+				Status:      evergreen.TaskFailed,
+				TimedOut:    true,
+				TimeoutType: idleTimeoutType,
+				DiskFull:    true,
+			},
+			expected: FailureClassificationDiskFull,
+		},
+		"ExecTimeout": {
+			details: apimodels.TaskEndDetail{
+				Status:      evergreen.TaskFailed,
+				Type:        evergreen.CommandTypeSystem,
+				TimedOut:    true,
+				TimeoutType: execTimeoutType,
+			},
+			expected: FailureClassificationExecTimeout,
+		},
+		"IdleTimeout": {
+			details: apimodels.TaskEndDetail{
+				Status:      evergreen.TaskFailed,
+				TimedOut:    true,
+				TimeoutType: idleTimeoutType,
+			},
+			expected: FailureClassificationIdleTimeout,
+		},
+		"System": {
+			details:  apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: evergreen.CommandTypeSystem},
+			expected: FailureClassificationSystem,
+		},
+		"Setup": {
+			details:  apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: evergreen.CommandTypeSetup},
+			expected: FailureClassificationSetup,
+		},
+		"Test": {
+			details:  apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: evergreen.CommandTypeTest},
+			expected: FailureClassificationTest,
+		},
+		"DefaultsToTest": {
+			details:  apimodels.TaskEndDetail{Status: evergreen.TaskFailed},
+			expected: FailureClassificationTest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ClassifyFailure(tc.details))
+		})
+	}
+}
+
+func TestValidateFailureClassifications(t *testing.T) {
+	assert.NoError(t, ValidateFailureClassifications(nil))
+	assert.NoError(t, ValidateFailureClassifications(FailureClassifications))
+	assert.Error(t, ValidateFailureClassifications([]string{FailureClassificationOOM, "out-of-memory"}))
+}
@@ -0,0 +1,160 @@
+package task
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/testresult"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RetryPolicy configures automatic retries for a task that fails. A failed
+// task that matches the policy is restarted as a new execution, optionally
+// after waiting for a backoff delay.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the task can run, including
+	// the original execution.
+	MaxAttempts int `yaml:"max_attempts,omitempty" bson:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+	// FailureTypes are the failure types (system, setup or test) that should
+	// be retried.
+	FailureTypes []string `yaml:"failure_types,omitempty" bson:"failure_types,omitempty" json:"failure_types,omitempty"`
+	// TestFailureRegexes are regexes matching names of failed tests that
+	// should be retried. A test failure is retried if any failed test matches
+	// any of the regexes.
+	TestFailureRegexes []string `yaml:"test_failure_regexes,omitempty" bson:"test_failure_regexes,omitempty" json:"test_failure_regexes,omitempty"`
+	// BackoffSecs is the number of seconds to wait after the task fails
+	// before retrying it.
+	BackoffSecs int `yaml:"backoff_secs,omitempty" bson:"backoff_secs,omitempty" json:"backoff_secs,omitempty"`
+}
+
+// Validate checks that the retry policy is well-formed.
+func (p *RetryPolicy) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(p.MaxAttempts < 2, "max attempts must be at least 2")
+	catcher.ErrorfWhen(p.MaxAttempts > evergreen.MaxTaskExecution+1, "max attempts cannot exceed %d", evergreen.MaxTaskExecution+1)
+	catcher.NewWhen(p.BackoffSecs < 0, "backoff cannot be negative")
+	for _, failureType := range p.FailureTypes {
+		catcher.ErrorfWhen(!utility.StringSliceContains(evergreen.ValidCommandTypes, failureType), "invalid failure type '%s'", failureType)
+	}
+	for _, pattern := range p.TestFailureRegexes {
+		_, err := regexp.Compile(pattern)
+		catcher.Wrapf(err, "invalid test failure regex '%s'", pattern)
+	}
+	return catcher.Resolve()
+}
+
+// HasAttemptsRemaining returns whether the task can be retried after the given
+// execution has finished.
+func (p *RetryPolicy) HasAttemptsRemaining(execution int) bool {
+	return execution+1 < p.MaxAttempts
+}
+
+// NeedsFailedTests returns whether matching a test failure against the policy
+// requires the names of the failed tests.
+func (p *RetryPolicy) NeedsFailedTests(failureType string) bool {
+	return failureType == evergreen.CommandTypeTest &&
+		!utility.StringSliceContains(p.FailureTypes, failureType) &&
+		len(p.TestFailureRegexes) > 0
+}
+
+// MatchesFailure returns whether a failure of the given type should be
+// retried. If the policy specifies neither failure types nor test failure
+// regexes, any failure is retried.
+func (p *RetryPolicy) MatchesFailure(failureType string, failedTests []string) bool {
+	if len(p.FailureTypes) == 0 && len(p.TestFailureRegexes) == 0 {
+		return true
+	}
+	if utility.StringSliceContains(p.FailureTypes, failureType) {
+		return true
+	}
+	if failureType != evergreen.CommandTypeTest {
+		return false
+	}
+
+	for _, pattern := range p.TestFailureRegexes {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		for _, testName := range failedTests {
+			if regex.MatchString(testName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GetFailedTestNames returns the display names of all the task's failed tests.
+func (t *Task) GetFailedTestNames(ctx context.Context, env evergreen.Environment) ([]string, error) {
+	results, err := t.GetTestResults(ctx, env, &testresult.FilterOptions{
+		Statuses: []string{evergreen.TestFailedStatus},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting failed test results")
+	}
+
+	names := make([]string, 0, len(results.Results))
+	for _, result := range results.Results {
+		names = append(names, result.GetDisplayTestName())
+	}
+	return names, nil
+}
+
+// SetRetryAt sets the time at which the task should be retried according to
+// its retry policy.
+func (t *Task) SetRetryAt(retryAt time.Time) error {
+	if err := UpdateOne(
+		bson.M{
+			IdKey: t.Id,
+		},
+		bson.M{
+			"$set": bson.M{
+				RetryAtKey: retryAt,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "setting retry time")
+	}
+	t.RetryAt = retryAt
+	return nil
+}
+
+// UnsetRetryAt clears the time at which the task should be retried.
+func (t *Task) UnsetRetryAt() error {
+	if err := UpdateOne(
+		bson.M{
+			IdKey: t.Id,
+		},
+		bson.M{
+			"$unset": bson.M{
+				RetryAtKey: 1,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "clearing retry time")
+	}
+	t.RetryAt = time.Time{}
+	return nil
+}
+
+// FindRetryable returns all finished tasks whose retry time has elapsed as of
+// the given time.
+func FindRetryable(ts time.Time) ([]Task, error) {
+	q := bson.M{
+		RetryAtKey: bson.M{"$lte": ts},
+		StatusKey:  bson.M{"$in": evergreen.TaskCompletedStatuses},
+	}
+	tasks, err := FindAll(db.Query(q))
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding tasks to retry as of %s", ts)
+	}
+	return tasks, nil
+}
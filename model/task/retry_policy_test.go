@@ -0,0 +1,52 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyValidate(t *testing.T) {
+	assert.NoError(t, (&RetryPolicy{MaxAttempts: 2}).Validate())
+	assert.NoError(t, (&RetryPolicy{
+		MaxAttempts:        3,
+		FailureTypes:       []string{evergreen.CommandTypeSystem, evergreen.CommandTypeTest},
+		TestFailureRegexes: []string{"^flaky_"},
+		BackoffSecs:        30,
+	}).Validate())
+
+	assert.Error(t, (&RetryPolicy{MaxAttempts: 1}).Validate())
+	assert.Error(t, (&RetryPolicy{MaxAttempts: evergreen.MaxTaskExecution + 2}).Validate())
+	assert.Error(t, (&RetryPolicy{MaxAttempts: 2, BackoffSecs: -1}).Validate())
+	assert.Error(t, (&RetryPolicy{MaxAttempts: 2, FailureTypes: []string{"bogus"}}).Validate())
+	assert.Error(t, (&RetryPolicy{MaxAttempts: 2, TestFailureRegexes: []string{"("}}).Validate())
+}
+
+func TestRetryPolicyHasAttemptsRemaining(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 3}
+	assert.True(t, p.HasAttemptsRemaining(0))
+	assert.True(t, p.HasAttemptsRemaining(1))
+	assert.False(t, p.HasAttemptsRemaining(2))
+}
+
+func TestRetryPolicyMatchesFailure(t *testing.T) {
+	t.Run("EmptyPolicyMatchesAnyFailure", func(t *testing.T) {
+		p := &RetryPolicy{MaxAttempts: 2}
+		assert.True(t, p.MatchesFailure(evergreen.CommandTypeSetup, nil))
+		assert.True(t, p.MatchesFailure(evergreen.CommandTypeTest, nil))
+	})
+	t.Run("FailureTypes", func(t *testing.T) {
+		p := &RetryPolicy{MaxAttempts: 2, FailureTypes: []string{evergreen.CommandTypeSystem}}
+		assert.True(t, p.MatchesFailure(evergreen.CommandTypeSystem, nil))
+		assert.False(t, p.MatchesFailure(evergreen.CommandTypeTest, nil))
+		assert.False(t, p.NeedsFailedTests(evergreen.CommandTypeSystem))
+	})
+	t.Run("TestFailureRegexes", func(t *testing.T) {
+		p := &RetryPolicy{MaxAttempts: 2, TestFailureRegexes: []string{"^flaky_"}}
+		assert.True(t, p.NeedsFailedTests(evergreen.CommandTypeTest))
+		assert.True(t, p.MatchesFailure(evergreen.CommandTypeTest, []string{"stable", "flaky_test"}))
+		assert.False(t, p.MatchesFailure(evergreen.CommandTypeTest, []string{"stable"}))
+		assert.False(t, p.MatchesFailure(evergreen.CommandTypeSystem, []string{"flaky_test"}))
+	})
+}
@@ -192,6 +192,10 @@ type Task struct {
 	Details   apimodels.TaskEndDetail `bson:"details" json:"task_end_details"`
 	Aborted   bool                    `bson:"abort,omitempty" json:"abort"`
 	AbortInfo AbortInfo               `bson:"abort_info,omitempty" json:"abort_info,omitempty"`
+	// FailureClassification is the cause of the task's failure, which is
+	// derived from its end details when it finishes. It is empty if the task
+	// did not fail.
+	FailureClassification string `bson:"failure_classification,omitempty" json:"failure_classification,omitempty"`
 
 	// HostCreateDetails stores information about why host.create failed for this task
 	HostCreateDetails []HostCreateDetail `bson:"host_create_details,omitempty" json:"host_create_details,omitempty"`
@@ -229,6 +233,13 @@ type Task struct {
 	ResetFailedWhenFinished bool  `bson:"reset_failed_when_finished,omitempty" json:"reset_failed_when_finished,omitempty"`
 	DisplayTask             *Task `bson:"-" json:"-"` // this is a local pointer from an exec to display task
 
+	// RetryPolicy, if set, configures automatic retries of the task when it
+	// fails.
+	RetryPolicy *RetryPolicy `bson:"retry_policy,omitempty" json:"retry_policy,omitempty"`
+	// RetryAt is the time at which the task is due to be retried according to
+	// its retry policy. It is only set while a retry is pending.
+	RetryAt time.Time `bson:"retry_at,omitempty" json:"retry_at,omitempty"`
+
 	// DisplayTaskId is set to the display task ID if the task is an execution task, the empty string if it's not an execution task,
 	// and is nil if we haven't yet checked whether or not this task has a display task.
 	DisplayTaskId *string `bson:"display_task_id,omitempty" json:"display_task_id,omitempty"`
@@ -976,9 +987,10 @@ func (t *Task) markAsHostDispatchedWithFunc(doUpdate func(update bson.M) error,
 			AgentVersionKey:  agentRevision,
 		},
 		"$unset": bson.M{
-			AbortedKey:   "",
-			AbortInfoKey: "",
-			DetailsKey:   "",
+			AbortedKey:               "",
+			AbortInfoKey:             "",
+			DetailsKey:               "",
+			FailureClassificationKey: "",
 		},
 	}); err != nil {
 		return err
@@ -993,6 +1005,7 @@ func (t *Task) markAsHostDispatchedWithFunc(doUpdate func(update bson.M) error,
 	t.Aborted = false
 	t.AbortInfo = AbortInfo{}
 	t.Details = apimodels.TaskEndDetail{}
+	t.FailureClassification = ""
 
 	return nil
 }
@@ -1017,11 +1030,12 @@ func (t *Task) markAsHostUndispatchedWithFunc(doUpdate func(update bson.M) error
 			LastHeartbeatKey: utility.ZeroTime,
 		},
 		"$unset": bson.M{
-			HostIdKey:       "",
-			AgentVersionKey: "",
-			AbortedKey:      "",
-			AbortInfoKey:    "",
-			DetailsKey:      "",
+			HostIdKey:                "",
+			AgentVersionKey:          "",
+			AbortedKey:               "",
+			AbortInfoKey:             "",
+			DetailsKey:               "",
+			FailureClassificationKey: "",
 		},
 	}
 
@@ -1037,6 +1051,7 @@ func (t *Task) markAsHostUndispatchedWithFunc(doUpdate func(update bson.M) error
 	t.Aborted = false
 	t.AbortInfo = AbortInfo{}
 	t.Details = apimodels.TaskEndDetail{}
+	t.FailureClassification = ""
 
 	return nil
 }
@@ -1902,6 +1917,7 @@ func (t *Task) MarkEnd(finishTime time.Time, detail *apimodels.TaskEndDetail) er
 	t.Status = detail.Status
 	t.FinishTime = finishTime
 	t.Details = *detail
+	t.FailureClassification = ClassifyFailure(*detail)
 	t.ContainerAllocated = false
 	t.ContainerAllocatedTime = time.Time{}
 	return UpdateOne(
@@ -1910,12 +1926,13 @@ func (t *Task) MarkEnd(finishTime time.Time, detail *apimodels.TaskEndDetail) er
 		},
 		bson.M{
 			"$set": bson.M{
-				FinishTimeKey:         finishTime,
-				StatusKey:             detail.Status,
-				TimeTakenKey:          t.TimeTaken,
-				DetailsKey:            detail,
-				StartTimeKey:          t.StartTime,
-				ContainerAllocatedKey: false,
+				FinishTimeKey:            finishTime,
+				StatusKey:                detail.Status,
+				TimeTakenKey:             t.TimeTaken,
+				DetailsKey:               detail,
+				FailureClassificationKey: t.FailureClassification,
+				StartTimeKey:             t.StartTime,
+				ContainerAllocatedKey:    false,
 			},
 			"$unset": bson.M{
 				ContainerAllocatedTimeKey: 1,
@@ -2055,12 +2072,14 @@ func resetTaskUpdate(t *Task) []bson.M {
 		t.TimeTaken = 0
 		t.LastHeartbeat = utility.ZeroTime
 		t.Details = apimodels.TaskEndDetail{}
+		t.FailureClassification = ""
 		t.LogServiceVersion = nil
 		t.ResultsService = ""
 		t.ResultsFailed = false
 		t.HasCedarResults = false
 		t.ResetWhenFinished = false
 		t.ResetFailedWhenFinished = false
+		t.RetryAt = time.Time{}
 		t.AgentVersion = ""
 		t.HostCreateDetails = []HostCreateDetail{}
 		t.OverrideDependencies = false
@@ -2093,12 +2112,14 @@ func resetTaskUpdate(t *Task) []bson.M {
 		{
 			"$unset": []string{
 				DetailsKey,
+				FailureClassificationKey,
 				LogServiceVersionKey,
 				ResultsServiceKey,
 				ResultsFailedKey,
 				HasCedarResultsKey,
 				ResetWhenFinishedKey,
 				ResetFailedWhenFinishedKey,
+				RetryAtKey,
 				AgentVersionKey,
 				HostIdKey,
 				PodIDKey,
@@ -656,8 +656,19 @@ func MarkEnd(ctx context.Context, settings *evergreen.Settings, t *task.Task, ca
 		}
 	}
 
-	// activate/deactivate other task if this is not a patch request's task
-	if !evergreen.IsPatchRequester(t.Requester) {
+	// A failure to retry the task should not prevent it from being marked
+	// finished.
+	retrying, err := evalRetryPolicy(ctx, settings, t)
+	grip.Error(message.WrapError(err, message.Fields{
+		"message":   "could not evaluate retry policy for task",
+		"task_id":   t.Id,
+		"execution": t.Execution,
+	}))
+
+	// activate/deactivate other task if this is not a patch request's task.
+	// A task that is being retried hasn't finished failing yet, so it
+	// shouldn't step back.
+	if !evergreen.IsPatchRequester(t.Requester) && !retrying {
 		if t.IsPartOfDisplay() {
 			_, err = t.GetDisplayTask()
 			if err != nil {
@@ -687,6 +698,79 @@ func MarkEnd(ctx context.Context, settings *evergreen.Settings, t *task.Task, ca
 	return nil
 }
 
+// evalRetryPolicy restarts a failed task if it matches its retry policy. If
+// the policy has a backoff, the task is marked to be retried later instead. It
+// returns whether the task was restarted or marked to be retried. Execution
+// tasks and tasks in single-host task groups can only be restarted along with
+// the rest of their display task or task group, so the project validator
+// rejects retry policies for them and they are never retried here.
+func evalRetryPolicy(ctx context.Context, settings *evergreen.Settings, t *task.Task) (bool, error) {
+	policy := t.RetryPolicy
+	if policy == nil || t.Status != evergreen.TaskFailed || t.Aborted {
+		return false, nil
+	}
+	if t.IsPartOfDisplay() || t.IsPartOfSingleHostTaskGroup() {
+		return false, nil
+	}
+	// The task is already going to be reset when it finishes.
+	if t.ResetWhenFinished || t.ResetFailedWhenFinished {
+		return false, nil
+	}
+	if !policy.HasAttemptsRemaining(t.Execution) {
+		return false, nil
+	}
+
+	failureType := t.Details.Type
+	if failureType == "" {
+		failureType = evergreen.CommandTypeTest
+	}
+	var failedTests []string
+	if policy.NeedsFailedTests(failureType) {
+		var err error
+		failedTests, err = t.GetFailedTestNames(ctx, evergreen.GetEnvironment())
+		if err != nil {
+			return false, errors.Wrapf(err, "getting failed tests for task '%s'", t.Id)
+		}
+	}
+	if !policy.MatchesFailure(failureType, failedTests) {
+		return false, nil
+	}
+
+	grip.Info(message.Fields{
+		"message":      "retrying failed task according to its retry policy",
+		"task_id":      t.Id,
+		"execution":    t.Execution,
+		"failure_type": failureType,
+		"backoff_secs": policy.BackoffSecs,
+	})
+
+	if policy.BackoffSecs > 0 {
+		if err := t.SetRetryAt(time.Now().Add(time.Duration(policy.BackoffSecs) * time.Second)); err != nil {
+			return false, errors.Wrapf(err, "setting retry time for task '%s'", t.Id)
+		}
+		return true, nil
+	}
+
+	if err := TryResetTask(ctx, settings, t.Id, evergreen.RetryPolicyTaskActivator, "", nil); err != nil {
+		return false, errors.Wrapf(err, "restarting task '%s'", t.Id)
+	}
+	return true, nil
+}
+
+// RetryTask restarts a task whose retry policy backoff has elapsed. The retry
+// time is cleared first, so the task is only considered once even if it can't
+// be restarted, for example because it was already restarted or its version
+// was aborted.
+func RetryTask(ctx context.Context, settings *evergreen.Settings, t *task.Task) error {
+	if utility.IsZeroTime(t.RetryAt) || time.Now().Before(t.RetryAt) {
+		return nil
+	}
+	if err := t.UnsetRetryAt(); err != nil {
+		return errors.Wrapf(err, "clearing retry time for task '%s'", t.Id)
+	}
+	return TryResetTask(ctx, settings, t.Id, evergreen.RetryPolicyTaskActivator, "", nil)
+}
+
 // logTaskEndStats logs information a task after it
 // completes. It also logs information about the total runtime and instance
 // type, which can be used to measure the cost of running a task.
@@ -948,6 +1032,11 @@ func dequeueAndRestartItem(ctx context.Context, opts dequeueAndRestartOptions) (
 	if err != nil {
 		return nil, errors.Wrapf(err, "dequeueing and aborting commit queue item '%s'", opts.itemVersionID)
 	}
+	// Items batched into the removed item's merge patch won't be merged by
+	// it, so they have to be tested again.
+	if err = opts.cq.ReleaseBatch(removed.Issue); err != nil {
+		return nil, errors.Wrapf(err, "releasing items batched into commit queue item '%s'", removed.Issue)
+	}
 
 	grip.Info(message.Fields{
 		"message":      "commit queue item was dequeued and later items were restarted",
@@ -1024,6 +1113,11 @@ func HandleEndTaskForCommitQueueTask(ctx context.Context, t *task.Task, status s
 // Otherwise, the failure may be a result of those untested commits so we will wait for the earlier tasks to run
 // and handle dequeuing (merge still won't run for failed task versions because of dependencies).
 func dequeueAndRestartWithStepback(ctx context.Context, cq *commitqueue.CommitQueue, t *task.Task, caller, reason string) error {
+	if i := cq.FindItem(t.Version); i >= 0 && len(cq.BatchMembers(cq.Queue[i].Issue)) > 0 {
+		// The failure could have been caused by any item in the batch, so
+		// bisect it to find the item instead of dequeueing all of them.
+		return BisectCommitQueueBatch(ctx, cq, t.Version, caller, reason)
+	}
 	if i := cq.FindItem(t.Version); i > 0 {
 		prevVersions := []string{}
 		for j := 0; j < i; j++ {
@@ -2214,14 +2308,16 @@ func UpdateDisplayTaskForTask(t *task.Task) error {
 		// "started" even if there aren't currently running tasks
 		statusTask.Status = evergreen.TaskStarted
 		statusTask.Details = apimodels.TaskEndDetail{}
+		statusTask.FailureClassification = ""
 	}
 
 	update := bson.M{
-		task.StatusKey:        statusTask.Status,
-		task.ActivatedKey:     dt.Activated,
-		task.ActivatedTimeKey: dt.ActivatedTime,
-		task.TimeTakenKey:     timeTaken,
-		task.DetailsKey:       statusTask.Details,
+		task.StatusKey:                statusTask.Status,
+		task.ActivatedKey:             dt.Activated,
+		task.ActivatedTimeKey:         dt.ActivatedTime,
+		task.TimeTakenKey:             timeTaken,
+		task.DetailsKey:               statusTask.Details,
+		task.FailureClassificationKey: statusTask.FailureClassification,
 	}
 
 	if startTime != time.Unix(1<<62, 0) {
@@ -2252,6 +2348,7 @@ func UpdateDisplayTaskForTask(t *task.Task) error {
 	}
 	dt.Status = statusTask.Status
 	dt.Details = statusTask.Details
+	dt.FailureClassification = statusTask.FailureClassification
 	dt.TimeTaken = timeTaken
 	if !wasFinished && dt.IsFinished() {
 		event.LogTaskFinished(dt.Id, dt.Execution, dt.GetDisplayStatus())
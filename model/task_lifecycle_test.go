@@ -2337,6 +2337,252 @@ func TestMarkEndWithTaskGroup(t *testing.T) {
 	}
 }
 
+func TestMarkEndWithRetryPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := &evergreen.Settings{
+		CommitQueue: evergreen.CommitQueueConfig{
+			MaxSystemFailedTaskRetries: 2,
+		},
+	}
+	systemFailure := &apimodels.TaskEndDetail{
+		Status: evergreen.TaskFailed,
+		Type:   evergreen.CommandTypeSystem,
+	}
+
+	for tName, tCase := range map[string]func(t *testing.T, tsk *task.Task){
+		"ResetsTaskForMatchingFailure": func(t *testing.T, tsk *task.Task) {
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, evergreen.TaskUndispatched, dbTask.Status)
+			assert.Equal(t, 1, dbTask.Execution)
+			assert.True(t, dbTask.Activated)
+			assert.Zero(t, dbTask.RetryAt)
+		},
+		"DoesNotResetTaskForOtherFailure": func(t *testing.T, tsk *task.Task) {
+			detail := &apimodels.TaskEndDetail{
+				Status: evergreen.TaskFailed,
+				Type:   evergreen.CommandTypeTest,
+			}
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), detail, false))
+
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, evergreen.TaskFailed, dbTask.Status)
+			assert.Equal(t, 0, dbTask.Execution)
+			assert.Zero(t, dbTask.RetryAt)
+		},
+		"SetsRetryTimeWithBackoff": func(t *testing.T, tsk *task.Task) {
+			tsk.RetryPolicy.BackoffSecs = 600
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, evergreen.TaskFailed, dbTask.Status)
+			assert.Equal(t, 0, dbTask.Execution)
+			assert.WithinDuration(t, time.Now().Add(10*time.Minute), dbTask.RetryAt, time.Minute)
+		},
+		"StopsResettingTaskAtMaxAttempts": func(t *testing.T, tsk *task.Task) {
+			for execution := 1; execution < tsk.RetryPolicy.MaxAttempts; execution++ {
+				require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+				dbTask, err := task.FindOneId(tsk.Id)
+				require.NoError(t, err)
+				require.NotZero(t, dbTask)
+				require.Equal(t, execution, dbTask.Execution)
+				require.Equal(t, evergreen.TaskUndispatched, dbTask.Status)
+
+				require.NoError(t, dbTask.MarkStart(time.Now()))
+				tsk = dbTask
+			}
+
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, tsk.RetryPolicy.MaxAttempts-1, dbTask.Execution)
+			assert.Equal(t, evergreen.TaskFailed, dbTask.Status)
+		},
+		"SkipsExecutionTasks": func(t *testing.T, tsk *task.Task) {
+			require.NoError(t, db.Clear(task.Collection))
+			tsk.Status = evergreen.TaskFailed
+			tsk.Details = *systemFailure
+			tsk.DisplayTaskId = utility.ToStringPtr("display_task")
+			require.NoError(t, tsk.Insert())
+			retrying, err := evalRetryPolicy(ctx, settings, tsk)
+			require.NoError(t, err)
+			assert.False(t, retrying)
+
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, 0, dbTask.Execution)
+			assert.Equal(t, evergreen.TaskFailed, dbTask.Status)
+			assert.Zero(t, dbTask.RetryAt)
+		},
+		"SkipsSingleHostTaskGroupTasks": func(t *testing.T, tsk *task.Task) {
+			require.NoError(t, db.Clear(task.Collection))
+			tsk.Status = evergreen.TaskFailed
+			tsk.Details = *systemFailure
+			tsk.TaskGroup = "task_group"
+			tsk.TaskGroupMaxHosts = 1
+			require.NoError(t, tsk.Insert())
+			retrying, err := evalRetryPolicy(ctx, settings, tsk)
+			require.NoError(t, err)
+			assert.False(t, retrying)
+
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, 0, dbTask.Execution)
+			assert.Equal(t, evergreen.TaskFailed, dbTask.Status)
+			assert.Zero(t, dbTask.RetryAt)
+		},
+		"DoesNotStepBackWhileRetrying": func(t *testing.T, tsk *task.Task) {
+			insertStepbackCandidates(t)
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+
+			dbTask, err := task.FindOneId("stepback_candidate")
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.False(t, dbTask.Activated, "task being retried should not step back")
+		},
+		"StepsBackWhenOutOfAttempts": func(t *testing.T, tsk *task.Task) {
+			insertStepbackCandidates(t)
+			tsk.RetryPolicy.MaxAttempts = 1
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+
+			dbTask, err := task.FindOneId("stepback_candidate")
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.True(t, dbTask.Activated, "task that won't be retried should step back")
+		},
+		"RetryTaskClearsRetryTimeWhenNotReset": func(t *testing.T, tsk *task.Task) {
+			tsk.RetryPolicy.BackoffSecs = 600
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+			// The task can't be reset once it reaches the max execution.
+			require.NoError(t, task.UpdateOne(
+				mgobson.M{task.IdKey: tsk.Id},
+				mgobson.M{"$set": mgobson.M{task.ExecutionKey: evergreen.MaxTaskExecution}},
+			))
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			require.NoError(t, dbTask.SetRetryAt(time.Now().Add(-time.Minute)))
+
+			require.NoError(t, RetryTask(ctx, settings, dbTask))
+
+			dbTask, err = task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, evergreen.MaxTaskExecution, dbTask.Execution)
+			assert.Equal(t, evergreen.TaskFailed, dbTask.Status)
+			assert.Zero(t, dbTask.RetryAt, "retry time should be cleared even though the task wasn't reset")
+			retryable, err := task.FindRetryable(time.Now())
+			require.NoError(t, err)
+			assert.Empty(t, retryable)
+		},
+		"RetryTaskWaitsForRetryTime": func(t *testing.T, tsk *task.Task) {
+			tsk.RetryPolicy.BackoffSecs = 600
+			require.NoError(t, MarkEnd(ctx, settings, tsk, "test", time.Now(), systemFailure, false))
+			dbTask, err := task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+
+			require.NoError(t, RetryTask(ctx, settings, dbTask))
+			dbTask, err = task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, evergreen.TaskFailed, dbTask.Status, "task should not be retried before its retry time")
+			assert.Equal(t, 0, dbTask.Execution)
+
+			require.NoError(t, dbTask.SetRetryAt(time.Now().Add(-time.Minute)))
+			require.NoError(t, RetryTask(ctx, settings, dbTask))
+			dbTask, err = task.FindOneId(tsk.Id)
+			require.NoError(t, err)
+			require.NotZero(t, dbTask)
+			assert.Equal(t, evergreen.TaskUndispatched, dbTask.Status)
+			assert.Equal(t, 1, dbTask.Execution)
+			assert.Zero(t, dbTask.RetryAt)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.ClearCollections(host.Collection, task.Collection, task.OldCollection, build.Collection,
+				VersionCollection, ParserProjectCollection, ProjectRefCollection))
+
+			b := build.Build{
+				Id:      "build",
+				Status:  evergreen.BuildStarted,
+				Version: "version",
+			}
+			require.NoError(t, b.Insert())
+			v := &Version{
+				Id:         b.Version,
+				Identifier: "project",
+				Status:     evergreen.VersionStarted,
+			}
+			require.NoError(t, v.Insert())
+			pRef := &ProjectRef{Id: "project"}
+			require.NoError(t, pRef.Insert())
+			pp := &ParserProject{
+				Id:         b.Version,
+				Identifier: utility.ToStringPtr("project"),
+				Stepback:   utility.TruePtr(),
+			}
+			require.NoError(t, pp.Insert())
+			tsk := &task.Task{
+				Id:                  "task",
+				DisplayName:         "task",
+				Activated:           true,
+				BuildId:             b.Id,
+				BuildVariant:        "bv",
+				Project:             pRef.Id,
+				Requester:           evergreen.RepotrackerVersionRequester,
+				RevisionOrderNumber: 3,
+				Status:              evergreen.TaskStarted,
+				Version:             b.Version,
+				HostId:              "host",
+				RetryPolicy: &task.RetryPolicy{
+					MaxAttempts:  3,
+					FailureTypes: []string{evergreen.CommandTypeSystem},
+				},
+			}
+			require.NoError(t, tsk.Insert())
+			h := host.Host{
+				Id:          "host",
+				RunningTask: tsk.Id,
+			}
+			require.NoError(t, h.Insert(ctx))
+
+			tCase(t, tsk)
+		})
+	}
+}
+
+// insertStepbackCandidates inserts an earlier successful task and an inactive
+// task between it and the task named "task", which stepback would activate.
+func insertStepbackCandidates(t *testing.T) {
+	for _, tsk := range []task.Task{
+		{Id: "previous_success", RevisionOrderNumber: 1, Status: evergreen.TaskSucceeded, Activated: true},
+		{Id: "stepback_candidate", RevisionOrderNumber: 2, Status: evergreen.TaskUndispatched},
+	} {
+		tsk.BuildId = "build_" + tsk.Id
+		tsk.BuildVariant = "bv"
+		tsk.DisplayName = "task"
+		tsk.Project = "project"
+		tsk.Requester = evergreen.RepotrackerVersionRequester
+		tsk.Version = "version"
+		require.NoError(t, tsk.Insert())
+		b := build.Build{Id: tsk.BuildId, BuildVariant: "bv"}
+		require.NoError(t, b.Insert())
+	}
+}
+
 func TestTryResetTask(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -25,6 +25,10 @@ package taskstats
 //   "num_setup_failed": <Number of times the task failed with a details type of 'setup' (int)>,
 //   "num_system_failed": <Number of times the task failed with a details type of 'system' (int)>,
 //   "num_timeout": <Number of times the task failed with a timeout (int)>,
+//   "num_oom": <Number of times the task failed with a failure classification of 'oom' (int)>,
+//   "num_disk_full": <Number of times the task failed with a failure classification of 'disk-full' (int)>,
+//   "num_exec_timeout": <Number of times the task failed with a failure classification of 'exec-timeout' (int)>,
+//   "num_idle_timeout": <Number of times the task failed with a failure classification of 'idle-timeout' (int)>,
 //   "avg_duration_success": <Average duration in seconds of the successful tasks (double)>,
 //   "last_update": <Date of the job run that last updated this document (date)>
 // }
@@ -69,6 +73,8 @@ var (
 	taskStatusKeyRef       = "$" + task.StatusKey
 	taskDetailsKeyRef      = "$" + task.DetailsKey
 	taskTimeTakenKeyRef    = "$" + task.TimeTakenKey
+
+	taskFailureClassificationKeyRef = "$" + task.FailureClassificationKey
 )
 
 // Convenient type to use for arrays in pipeline definitions.
@@ -99,15 +105,26 @@ type DBTaskStatsID struct {
 
 // DBTaskStats represents the daily_task_stats documents.
 type DBTaskStats struct {
-	Id                 DBTaskStatsID `bson:"_id"`
-	NumSuccess         int           `bson:"num_success"`
-	NumFailed          int           `bson:"num_failed"`
-	NumTimeout         int           `bson:"num_timeout"`
-	NumTestFailed      int           `bson:"num_test_failed"`
-	NumSystemFailed    int           `bson:"num_system_failed"`
-	NumSetupFailed     int           `bson:"num_setup_failed"`
-	AvgDurationSuccess float64       `bson:"avg_duration_success"`
-	LastUpdate         time.Time     `bson:"last_update"`
+	Id              DBTaskStatsID `bson:"_id"`
+	NumSuccess      int           `bson:"num_success"`
+	NumFailed       int           `bson:"num_failed"`
+	NumTimeout      int           `bson:"num_timeout"`
+	NumTestFailed   int           `bson:"num_test_failed"`
+	NumSystemFailed int           `bson:"num_system_failed"`
+	NumSetupFailed  int           `bson:"num_setup_failed"`
+	NumOOM          int           `bson:"num_oom"`
+	NumDiskFull     int           `bson:"num_disk_full"`
+	NumExecTimeout  int           `bson:"num_exec_timeout"`
+	NumIdleTimeout  int           `bson:"num_idle_timeout"`
+	// The classified system, setup and test failure counts only include
+	// failures with that failure classification, unlike the system, setup
+	// and test failure counts, which are based on the failing command's type
+	// and so also include failures classified as OOM or disk full.
+	NumClassifiedSystemFailed int       `bson:"num_classified_system_failed"`
+	NumClassifiedSetupFailed  int       `bson:"num_classified_setup_failed"`
+	NumClassifiedTestFailed   int       `bson:"num_classified_test_failed"`
+	AvgDurationSuccess        float64   `bson:"avg_duration_success"`
+	LastUpdate                time.Time `bson:"last_update"`
 }
 
 func (d *DBTaskStats) MarshalBSON() ([]byte, error)  { return mgobson.Marshal(d) }
@@ -123,15 +140,22 @@ var (
 	DBTaskStatsIDDateKey         = bsonutil.MustHaveTag(DBTaskStatsID{}, "Date")
 
 	// BSON fields for the task stats struct.
-	DBTaskStatsIDKey                 = bsonutil.MustHaveTag(DBTaskStats{}, "Id")
-	DBTaskStatsNumSuccessKey         = bsonutil.MustHaveTag(DBTaskStats{}, "NumSuccess")
-	DBTaskStatsNumFailedKey          = bsonutil.MustHaveTag(DBTaskStats{}, "NumFailed")
-	DBTaskStatsNumTestFailedKey      = bsonutil.MustHaveTag(DBTaskStats{}, "NumTestFailed")
-	DBTaskStatsNumSetupFailedKey     = bsonutil.MustHaveTag(DBTaskStats{}, "NumSetupFailed")
-	DBTaskStatsNumSystemFailedKey    = bsonutil.MustHaveTag(DBTaskStats{}, "NumSystemFailed")
-	DBTaskStatsNumTimeoutKey         = bsonutil.MustHaveTag(DBTaskStats{}, "NumTimeout")
-	DBTaskStatsAvgDurationSuccessKey = bsonutil.MustHaveTag(DBTaskStats{}, "AvgDurationSuccess")
-	DBTaskStatsLastUpdateKey         = bsonutil.MustHaveTag(DBTaskStats{}, "LastUpdate")
+	DBTaskStatsIDKey                        = bsonutil.MustHaveTag(DBTaskStats{}, "Id")
+	DBTaskStatsNumSuccessKey                = bsonutil.MustHaveTag(DBTaskStats{}, "NumSuccess")
+	DBTaskStatsNumFailedKey                 = bsonutil.MustHaveTag(DBTaskStats{}, "NumFailed")
+	DBTaskStatsNumTestFailedKey             = bsonutil.MustHaveTag(DBTaskStats{}, "NumTestFailed")
+	DBTaskStatsNumSetupFailedKey            = bsonutil.MustHaveTag(DBTaskStats{}, "NumSetupFailed")
+	DBTaskStatsNumSystemFailedKey           = bsonutil.MustHaveTag(DBTaskStats{}, "NumSystemFailed")
+	DBTaskStatsNumTimeoutKey                = bsonutil.MustHaveTag(DBTaskStats{}, "NumTimeout")
+	DBTaskStatsNumOOMKey                    = bsonutil.MustHaveTag(DBTaskStats{}, "NumOOM")
+	DBTaskStatsNumDiskFullKey               = bsonutil.MustHaveTag(DBTaskStats{}, "NumDiskFull")
+	DBTaskStatsNumExecTimeoutKey            = bsonutil.MustHaveTag(DBTaskStats{}, "NumExecTimeout")
+	DBTaskStatsNumIdleTimeoutKey            = bsonutil.MustHaveTag(DBTaskStats{}, "NumIdleTimeout")
+	DBTaskStatsNumClassifiedSystemFailedKey = bsonutil.MustHaveTag(DBTaskStats{}, "NumClassifiedSystemFailed")
+	DBTaskStatsNumClassifiedSetupFailedKey  = bsonutil.MustHaveTag(DBTaskStats{}, "NumClassifiedSetupFailed")
+	DBTaskStatsNumClassifiedTestFailedKey   = bsonutil.MustHaveTag(DBTaskStats{}, "NumClassifiedTestFailed")
+	DBTaskStatsAvgDurationSuccessKey        = bsonutil.MustHaveTag(DBTaskStats{}, "AvgDurationSuccess")
+	DBTaskStatsLastUpdateKey                = bsonutil.MustHaveTag(DBTaskStats{}, "LastUpdate")
 
 	// BSON dotted field names for task stats ID elements.
 	DBTaskStatsIDTaskNameKeyFull     = bsonutil.GetDottedKeyName(DBTaskStatsIDKey, DBTaskStatsIDTaskNameKey)
@@ -153,17 +177,18 @@ func statsPipeline(projectId string, requester string, start time.Time, end time
 			task.DisplayNameKey: bson.M{"$in": tasks},
 		}},
 		{"$project": bson.M{
-			task.IdKey:                   0,
-			"task_id":                    taskIdKeyRef,
-			"execution":                  taskExecutionKeyRef,
-			DBTaskStatsIDProjectKey:      taskProjectKeyRef,
-			DBTaskStatsIDTaskNameKey:     taskDisplayNameKeyRef,
-			DBTaskStatsIDBuildVariantKey: taskBuildVariantKeyRef,
-			DBTaskStatsIDDistroKey:       taskDistroIdKeyRef,
-			DBTaskStatsIDRequesterKey:    taskRequesterKeyRef,
-			task.StatusKey:               1,
-			task.DetailsKey:              1,
-			"time_taken":                 bson.M{"$divide": Array{taskTimeTakenKeyRef, nsInASecond}},
+			task.IdKey:                    0,
+			"task_id":                     taskIdKeyRef,
+			"execution":                   taskExecutionKeyRef,
+			DBTaskStatsIDProjectKey:       taskProjectKeyRef,
+			DBTaskStatsIDTaskNameKey:      taskDisplayNameKeyRef,
+			DBTaskStatsIDBuildVariantKey:  taskBuildVariantKeyRef,
+			DBTaskStatsIDDistroKey:        taskDistroIdKeyRef,
+			DBTaskStatsIDRequesterKey:     taskRequesterKeyRef,
+			task.StatusKey:                1,
+			task.DetailsKey:               1,
+			task.FailureClassificationKey: 1,
+			"time_taken":                  bson.M{"$divide": Array{taskTimeTakenKeyRef, nsInASecond}},
 		}},
 		{"$lookup": bson.M{
 			"from":         task.Collection,
@@ -196,6 +221,13 @@ func statsPipeline(projectId string, requester string, start time.Time, end time
 				bson.M{"$eq": Array{taskStatusKeyRef, "failed"}},
 				bson.M{"$eq": Array{bsonutil.GetDottedKeyName(taskDetailsKeyRef, task.TaskEndDetailType), evergreen.CommandTypeSetup}},
 				bson.M{"$ne": Array{bsonutil.GetDottedKeyName(taskDetailsKeyRef, task.TaskEndDetailTimedOut), true}}}}),
+			DBTaskStatsNumOOMKey:                    makeSum(bson.M{"$eq": Array{taskFailureClassificationKeyRef, task.FailureClassificationOOM}}),
+			DBTaskStatsNumDiskFullKey:               makeSum(bson.M{"$eq": Array{taskFailureClassificationKeyRef, task.FailureClassificationDiskFull}}),
+			DBTaskStatsNumExecTimeoutKey:            makeSum(bson.M{"$eq": Array{taskFailureClassificationKeyRef, task.FailureClassificationExecTimeout}}),
+			DBTaskStatsNumIdleTimeoutKey:            makeSum(bson.M{"$eq": Array{taskFailureClassificationKeyRef, task.FailureClassificationIdleTimeout}}),
+			DBTaskStatsNumClassifiedSystemFailedKey: makeSum(bson.M{"$eq": Array{taskFailureClassificationKeyRef, task.FailureClassificationSystem}}),
+			DBTaskStatsNumClassifiedSetupFailedKey:  makeSum(bson.M{"$eq": Array{taskFailureClassificationKeyRef, task.FailureClassificationSetup}}),
+			DBTaskStatsNumClassifiedTestFailedKey:   makeSum(bson.M{"$eq": Array{taskFailureClassificationKeyRef, task.FailureClassificationTest}}),
 			DBTaskStatsAvgDurationSuccessKey: bson.M{"$avg": bson.M{"$cond": bson.M{"if": bson.M{"$eq": Array{taskStatusKeyRef, "success"}},
 				"then": "$time_taken", "else": "IGNORE"}}}}},
 		{"$addFields": bson.M{
@@ -259,19 +291,26 @@ var (
 
 var (
 	// BSON fields for the task stats struct
-	TaskStatsTaskNameKey           = bsonutil.MustHaveTag(TaskStats{}, "TaskName")
-	TaskStatsBuildVariantKey       = bsonutil.MustHaveTag(TaskStats{}, "BuildVariant")
-	TaskStatsDistroKey             = bsonutil.MustHaveTag(TaskStats{}, "Distro")
-	TaskStatsDateKey               = bsonutil.MustHaveTag(TaskStats{}, "Date")
-	TaskStatsNumSuccessKey         = bsonutil.MustHaveTag(TaskStats{}, "NumSuccess")
-	TaskStatsNumFailedKey          = bsonutil.MustHaveTag(TaskStats{}, "NumFailed")
-	TaskStatsNumTotalKey           = bsonutil.MustHaveTag(TaskStats{}, "NumTotal")
-	TaskStatsNumTestFailedKey      = bsonutil.MustHaveTag(TaskStats{}, "NumTestFailed")
-	TaskStatsNumSetupFailedKey     = bsonutil.MustHaveTag(TaskStats{}, "NumSetupFailed")
-	TaskStatsNumSystemFailedKey    = bsonutil.MustHaveTag(TaskStats{}, "NumSystemFailed")
-	TaskStatsNumTimeoutKey         = bsonutil.MustHaveTag(TaskStats{}, "NumTimeout")
-	TaskStatsAvgDurationSuccessKey = bsonutil.MustHaveTag(TaskStats{}, "AvgDurationSuccess")
-	TaskStatsLastUpdateKey         = bsonutil.MustHaveTag(TaskStats{}, "LastUpdate")
+	TaskStatsTaskNameKey                  = bsonutil.MustHaveTag(TaskStats{}, "TaskName")
+	TaskStatsBuildVariantKey              = bsonutil.MustHaveTag(TaskStats{}, "BuildVariant")
+	TaskStatsDistroKey                    = bsonutil.MustHaveTag(TaskStats{}, "Distro")
+	TaskStatsDateKey                      = bsonutil.MustHaveTag(TaskStats{}, "Date")
+	TaskStatsNumSuccessKey                = bsonutil.MustHaveTag(TaskStats{}, "NumSuccess")
+	TaskStatsNumFailedKey                 = bsonutil.MustHaveTag(TaskStats{}, "NumFailed")
+	TaskStatsNumTotalKey                  = bsonutil.MustHaveTag(TaskStats{}, "NumTotal")
+	TaskStatsNumTestFailedKey             = bsonutil.MustHaveTag(TaskStats{}, "NumTestFailed")
+	TaskStatsNumSetupFailedKey            = bsonutil.MustHaveTag(TaskStats{}, "NumSetupFailed")
+	TaskStatsNumSystemFailedKey           = bsonutil.MustHaveTag(TaskStats{}, "NumSystemFailed")
+	TaskStatsNumTimeoutKey                = bsonutil.MustHaveTag(TaskStats{}, "NumTimeout")
+	TaskStatsNumOOMKey                    = bsonutil.MustHaveTag(TaskStats{}, "NumOOM")
+	TaskStatsNumDiskFullKey               = bsonutil.MustHaveTag(TaskStats{}, "NumDiskFull")
+	TaskStatsNumExecTimeoutKey            = bsonutil.MustHaveTag(TaskStats{}, "NumExecTimeout")
+	TaskStatsNumIdleTimeoutKey            = bsonutil.MustHaveTag(TaskStats{}, "NumIdleTimeout")
+	TaskStatsNumClassifiedSystemFailedKey = bsonutil.MustHaveTag(TaskStats{}, "NumClassifiedSystemFailed")
+	TaskStatsNumClassifiedSetupFailedKey  = bsonutil.MustHaveTag(TaskStats{}, "NumClassifiedSetupFailed")
+	TaskStatsNumClassifiedTestFailedKey   = bsonutil.MustHaveTag(TaskStats{}, "NumClassifiedTestFailed")
+	TaskStatsAvgDurationSuccessKey        = bsonutil.MustHaveTag(TaskStats{}, "AvgDurationSuccess")
+	TaskStatsLastUpdateKey                = bsonutil.MustHaveTag(TaskStats{}, "LastUpdate")
 )
 
 // buildAddFieldsDateStage builds the $addFields stage that sets the start date of the grouped
@@ -342,14 +381,21 @@ func (filter StatsFilter) getNextDate() time.Time {
 func (filter StatsFilter) BuildTaskStatsQueryGroupStage() bson.M {
 	return bson.M{
 		"$group": bson.M{
-			"_id":                       buildGroupId(filter.GroupBy),
-			TaskStatsNumSuccessKey:      bson.M{"$sum": "$" + DBTaskStatsNumSuccessKey},
-			TaskStatsNumFailedKey:       bson.M{"$sum": "$" + DBTaskStatsNumFailedKey},
-			TaskStatsNumTimeoutKey:      bson.M{"$sum": "$" + DBTaskStatsNumTimeoutKey},
-			TaskStatsNumTestFailedKey:   bson.M{"$sum": "$" + DBTaskStatsNumTestFailedKey},
-			TaskStatsNumSystemFailedKey: bson.M{"$sum": "$" + DBTaskStatsNumSystemFailedKey},
-			TaskStatsNumSetupFailedKey:  bson.M{"$sum": "$" + DBTaskStatsNumSetupFailedKey},
-			"total_duration_success":    bson.M{"$sum": bson.M{"$multiply": Array{"$" + DBTaskStatsNumSuccessKey, "$" + DBTaskStatsAvgDurationSuccessKey}}},
+			"_id":                                 buildGroupId(filter.GroupBy),
+			TaskStatsNumSuccessKey:                bson.M{"$sum": "$" + DBTaskStatsNumSuccessKey},
+			TaskStatsNumFailedKey:                 bson.M{"$sum": "$" + DBTaskStatsNumFailedKey},
+			TaskStatsNumTimeoutKey:                bson.M{"$sum": "$" + DBTaskStatsNumTimeoutKey},
+			TaskStatsNumTestFailedKey:             bson.M{"$sum": "$" + DBTaskStatsNumTestFailedKey},
+			TaskStatsNumSystemFailedKey:           bson.M{"$sum": "$" + DBTaskStatsNumSystemFailedKey},
+			TaskStatsNumSetupFailedKey:            bson.M{"$sum": "$" + DBTaskStatsNumSetupFailedKey},
+			TaskStatsNumOOMKey:                    bson.M{"$sum": "$" + DBTaskStatsNumOOMKey},
+			TaskStatsNumDiskFullKey:               bson.M{"$sum": "$" + DBTaskStatsNumDiskFullKey},
+			TaskStatsNumExecTimeoutKey:            bson.M{"$sum": "$" + DBTaskStatsNumExecTimeoutKey},
+			TaskStatsNumIdleTimeoutKey:            bson.M{"$sum": "$" + DBTaskStatsNumIdleTimeoutKey},
+			TaskStatsNumClassifiedSystemFailedKey: bson.M{"$sum": "$" + DBTaskStatsNumClassifiedSystemFailedKey},
+			TaskStatsNumClassifiedSetupFailedKey:  bson.M{"$sum": "$" + DBTaskStatsNumClassifiedSetupFailedKey},
+			TaskStatsNumClassifiedTestFailedKey:   bson.M{"$sum": "$" + DBTaskStatsNumClassifiedTestFailedKey},
+			"total_duration_success":              bson.M{"$sum": bson.M{"$multiply": Array{"$" + DBTaskStatsNumSuccessKey, "$" + DBTaskStatsAvgDurationSuccessKey}}},
 		}}
 }
 
@@ -366,34 +412,72 @@ func (filter StatsFilter) BuildTaskStatsQuerySortStage() bson.M {
 // buildTaskStatsQueryProjectStage creates an aggregation project stage to query task statistics.
 func (filter StatsFilter) BuildTaskStatsQueryProjectStage() bson.M {
 	return bson.M{"$project": bson.M{
-		TaskStatsTaskNameKey:        "$" + DBTaskStatsIDTaskNameKeyFull,
-		TaskStatsBuildVariantKey:    "$" + DBTaskStatsIDBuildVariantKeyFull,
-		TaskStatsDistroKey:          "$" + DBTaskStatsIDDistroKeyFull,
-		TaskStatsDateKey:            "$" + DBTaskStatsIDDateKeyFull,
-		TaskStatsNumSuccessKey:      1,
-		TaskStatsNumFailedKey:       1,
-		TaskStatsNumTotalKey:        bson.M{"$add": Array{"$" + TaskStatsNumSuccessKey, "$" + TaskStatsNumFailedKey}},
-		TaskStatsNumTimeoutKey:      1,
-		TaskStatsNumTestFailedKey:   1,
-		TaskStatsNumSystemFailedKey: 1,
-		TaskStatsNumSetupFailedKey:  1,
+		TaskStatsTaskNameKey:                  "$" + DBTaskStatsIDTaskNameKeyFull,
+		TaskStatsBuildVariantKey:              "$" + DBTaskStatsIDBuildVariantKeyFull,
+		TaskStatsDistroKey:                    "$" + DBTaskStatsIDDistroKeyFull,
+		TaskStatsDateKey:                      "$" + DBTaskStatsIDDateKeyFull,
+		TaskStatsNumSuccessKey:                1,
+		TaskStatsNumFailedKey:                 1,
+		TaskStatsNumTotalKey:                  bson.M{"$add": Array{"$" + TaskStatsNumSuccessKey, "$" + TaskStatsNumFailedKey}},
+		TaskStatsNumTimeoutKey:                1,
+		TaskStatsNumTestFailedKey:             1,
+		TaskStatsNumSystemFailedKey:           1,
+		TaskStatsNumSetupFailedKey:            1,
+		TaskStatsNumOOMKey:                    1,
+		TaskStatsNumDiskFullKey:               1,
+		TaskStatsNumExecTimeoutKey:            1,
+		TaskStatsNumIdleTimeoutKey:            1,
+		TaskStatsNumClassifiedSystemFailedKey: 1,
+		TaskStatsNumClassifiedSetupFailedKey:  1,
+		TaskStatsNumClassifiedTestFailedKey:   1,
 		TaskStatsAvgDurationSuccessKey: bson.M{"$cond": bson.M{"if": bson.M{"$ne": Array{"$" + TaskStatsNumSuccessKey, 0}},
 			"then": bson.M{"$divide": Array{"$total_duration_success", "$" + TaskStatsNumSuccessKey}},
 			"else": nil}},
 	}}
 }
 
+// failureClassificationStatsKeys maps each failure classification to the
+// task stats field that counts it.
+var failureClassificationStatsKeys = map[string]string{
+	task.FailureClassificationOOM:         TaskStatsNumOOMKey,
+	task.FailureClassificationDiskFull:    TaskStatsNumDiskFullKey,
+	task.FailureClassificationExecTimeout: TaskStatsNumExecTimeoutKey,
+	task.FailureClassificationIdleTimeout: TaskStatsNumIdleTimeoutKey,
+	task.FailureClassificationSystem:      TaskStatsNumClassifiedSystemFailedKey,
+	task.FailureClassificationSetup:       TaskStatsNumClassifiedSetupFailedKey,
+	task.FailureClassificationTest:        TaskStatsNumClassifiedTestFailedKey,
+}
+
+// BuildFailureClassificationMatchStage creates an aggregation match stage
+// that only keeps the grouped task statistics with at least one failure of
+// any of the filter's failure classifications. It returns nil if the filter
+// does not restrict the failure classifications.
+func (filter StatsFilter) BuildFailureClassificationMatchStage() bson.M {
+	if len(filter.FailureClassifications) == 0 {
+		return nil
+	}
+	or := make([]bson.M, 0, len(filter.FailureClassifications))
+	for _, classification := range filter.FailureClassifications {
+		or = append(or, bson.M{failureClassificationStatsKeys[classification]: bson.M{"$gt": 0}})
+	}
+	return bson.M{"$match": bson.M{"$or": or}}
+}
+
 // TaskStatsQueryPipeline creates an aggregation pipeline to query task statistics.
 func (filter StatsFilter) TaskStatsQueryPipeline() []bson.M {
-
-	return []bson.M{
+	pipeline := []bson.M{
 		filter.buildMatchStageForTask(),
 		buildAddFieldsDateStage("date", DBTaskStatsIDDateKeyFull, filter.AfterDate, filter.BeforeDate, filter.GroupNumDays),
 		filter.BuildTaskStatsQueryGroupStage(),
 		filter.BuildTaskStatsQueryProjectStage(),
-		filter.BuildTaskStatsQuerySortStage(),
-		{"$limit": filter.Limit},
 	}
+	if match := filter.BuildFailureClassificationMatchStage(); match != nil {
+		pipeline = append(pipeline, match)
+	}
+	return append(pipeline,
+		filter.BuildTaskStatsQuerySortStage(),
+		bson.M{"$limit": filter.Limit},
+	)
 }
 
 // BuildMatchStageForTask builds the match stage of the task query pipeline based on the filter options.
@@ -5,6 +5,7 @@ import (
 
 	"github.com/evergreen-ci/evergreen/db"
 	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
@@ -114,6 +115,9 @@ type StatsFilter struct {
 	Tasks         []string
 	BuildVariants []string
 	Distros       []string
+	// FailureClassifications, if set, restricts the results to the
+	// statistics with at least one failure of any of these classifications.
+	FailureClassifications []string
 
 	GroupNumDays int
 	GroupBy      GroupBy
@@ -134,6 +138,7 @@ func (f *StatsFilter) ValidateCommon() error {
 	catcher.NewWhen(len(f.Requesters) == 0, "missing requesters")
 	catcher.Add(f.Sort.validate())
 	catcher.Add(f.GroupBy.validate())
+	catcher.Add(task.ValidateFailureClassifications(f.FailureClassifications))
 
 	return catcher.Resolve()
 }
@@ -177,15 +182,24 @@ type TaskStats struct {
 	Distro       string    `bson:"distro"`
 	Date         time.Time `bson:"date"`
 
-	NumTotal           int       `bson:"num_total"`
-	NumSuccess         int       `bson:"num_success"`
-	NumFailed          int       `bson:"num_failed"`
-	NumTimeout         int       `bson:"num_timeout"`
-	NumTestFailed      int       `bson:"num_test_failed"`
-	NumSystemFailed    int       `bson:"num_system_failed"`
-	NumSetupFailed     int       `bson:"num_setup_failed"`
-	AvgDurationSuccess float64   `bson:"avg_duration_success"`
-	LastUpdate         time.Time `bson:"last_update"`
+	NumTotal        int `bson:"num_total"`
+	NumSuccess      int `bson:"num_success"`
+	NumFailed       int `bson:"num_failed"`
+	NumTimeout      int `bson:"num_timeout"`
+	NumTestFailed   int `bson:"num_test_failed"`
+	NumSystemFailed int `bson:"num_system_failed"`
+	NumSetupFailed  int `bson:"num_setup_failed"`
+	NumOOM          int `bson:"num_oom"`
+	NumDiskFull     int `bson:"num_disk_full"`
+	NumExecTimeout  int `bson:"num_exec_timeout"`
+	NumIdleTimeout  int `bson:"num_idle_timeout"`
+	// The classified system, setup and test failure counts only include
+	// failures with that failure classification.
+	NumClassifiedSystemFailed int       `bson:"num_classified_system_failed"`
+	NumClassifiedSetupFailed  int       `bson:"num_classified_setup_failed"`
+	NumClassifiedTestFailed   int       `bson:"num_classified_test_failed"`
+	AvgDurationSuccess        float64   `bson:"avg_duration_success"`
+	LastUpdate                time.Time `bson:"last_update"`
 }
 
 func (s *TaskStats) MarshalBSON() ([]byte, error)  { return mgobson.Marshal(s) }
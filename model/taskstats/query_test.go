@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
 	_ "github.com/evergreen-ci/evergreen/testutil"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
@@ -319,6 +320,36 @@ func (s *statsQuerySuite) TestGetTaskStatsFilterScope() {
 	s.checkTaskStats(docs[1], "task1", "v1", "d1", day2, 20, 7, 7, 0, 0, 0, float64(20))
 }
 
+func (s *statsQuerySuite) TestGetTaskStatsFailureClassifications() {
+	require := s.Require()
+
+	// A system command that ran out of memory counts as a system failure
+	// but is classified as OOM.
+	s.insertDailyTaskStats("p1", "r1", "task1", "v1", "d1", day1, 1, 1, 0, 0, 1, 0, 10)
+	require.NoError(db.Update(DailyTaskStatsCollection,
+		bson.M{DBTaskStatsIDDateKeyFull: day1},
+		bson.M{"$set": bson.M{DBTaskStatsNumOOMKey: 1}}))
+	s.insertDailyTaskStats("p1", "r1", "task1", "v1", "d1", day2, 1, 1, 0, 0, 1, 0, 10)
+	require.NoError(db.Update(DailyTaskStatsCollection,
+		bson.M{DBTaskStatsIDDateKeyFull: day2},
+		bson.M{"$set": bson.M{DBTaskStatsNumClassifiedSystemFailedKey: 1}}))
+
+	s.baseTaskFilter.FailureClassifications = []string{task.FailureClassificationSystem}
+	docs, err := GetTaskStats(s.baseTaskFilter)
+	require.NoError(err)
+	require.Len(docs, 1)
+	s.checkTaskStats(docs[0], "task1", "v1", "d1", day2, 1, 1, 0, 0, 1, 0, float64(10))
+	s.Equal(1, docs[0].NumClassifiedSystemFailed)
+
+	s.baseTaskFilter.FailureClassifications = []string{task.FailureClassificationOOM}
+	docs, err = GetTaskStats(s.baseTaskFilter)
+	require.NoError(err)
+	require.Len(docs, 1)
+	s.checkTaskStats(docs[0], "task1", "v1", "d1", day1, 1, 1, 0, 0, 1, 0, float64(10))
+	s.Equal(1, docs[0].NumOOM)
+	s.Zero(docs[0].NumClassifiedSystemFailed)
+}
+
 func (s *statsQuerySuite) TestGetTaskStatsSortOrder() {
 	require := s.Require()
 
@@ -0,0 +1,297 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/testresult"
+	"github.com/mongodb/anser/bsonutil"
+	adb "github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const TestShardAssignmentsCollection = "test_shard_assignments"
+
+// shardHistoryDepth is the number of previous executions of each shard that
+// are considered when looking up test history.
+const shardHistoryDepth = 5
+
+// TestShardAssignment records how tests are split between the shards of a
+// sharded task in a build, so that all the shards use the same split.
+type TestShardAssignment struct {
+	ID      string `bson:"_id"`
+	BuildID string `bson:"build_id"`
+	Group   string `bson:"group"`
+	// Tasks are the display names of the group's shard tasks, ordered by
+	// shard index.
+	Tasks []string `bson:"tasks,omitempty"`
+	// Shards contains the tests assigned to each shard. It is empty if there
+	// was no test history to split. When sharding by target duration, it only
+	// contains the shards that are needed to meet the target.
+	Shards     [][]string `bson:"shards,omitempty"`
+	CreateTime time.Time  `bson:"create_time"`
+}
+
+var (
+	testShardAssignmentIDKey      = bsonutil.MustHaveTag(TestShardAssignment{}, "ID")
+	testShardAssignmentBuildIDKey = bsonutil.MustHaveTag(TestShardAssignment{}, "BuildID")
+	testShardAssignmentTasksKey   = bsonutil.MustHaveTag(TestShardAssignment{}, "Tasks")
+)
+
+func testShardAssignmentID(buildID, group string) string {
+	return fmt.Sprintf("%s_%s", buildID, group)
+}
+
+// FindTestShardAssignment returns the assignment of tests to shards for the
+// shard group in the build, if it exists.
+func FindTestShardAssignment(buildID, group string) (*TestShardAssignment, error) {
+	a := &TestShardAssignment{}
+	err := db.FindOneQ(
+		TestShardAssignmentsCollection,
+		db.Query(bson.M{testShardAssignmentIDKey: testShardAssignmentID(buildID, group)}),
+		a,
+	)
+	if adb.ResultsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding test shard assignment for group '%s' in build '%s'", group, buildID)
+	}
+	return a, nil
+}
+
+// FindTestShardAssignmentForTask returns the assignment of tests to shards
+// that includes the shard task with the given display name in the build, if it
+// exists.
+func FindTestShardAssignmentForTask(buildID, displayName string) (*TestShardAssignment, error) {
+	a := &TestShardAssignment{}
+	err := db.FindOneQ(
+		TestShardAssignmentsCollection,
+		db.Query(bson.M{
+			testShardAssignmentBuildIDKey: buildID,
+			testShardAssignmentTasksKey:   displayName,
+		}),
+		a,
+	)
+	if adb.ResultsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding test shard assignment for task '%s' in build '%s'", displayName, buildID)
+	}
+	return a, nil
+}
+
+// ShardIndex returns the shard index of the task with the given display name,
+// or -1 if the task is not one of the assignment's shards.
+func (a *TestShardAssignment) ShardIndex(displayName string) int {
+	for i, name := range a.Tasks {
+		if name == displayName {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasHistory returns whether any tests were assigned to shards.
+func (a *TestShardAssignment) HasHistory() bool {
+	return len(a.Shards) > 0
+}
+
+// TestsForShard returns the tests assigned to the shard, along with the tests
+// assigned to all other shards.
+func (a *TestShardAssignment) TestsForShard(index int) (tests []string, otherTests []string) {
+	for i, shardTests := range a.Shards {
+		if i == index {
+			tests = append(tests, shardTests...)
+		} else {
+			otherTests = append(otherTests, shardTests...)
+		}
+	}
+	return tests, otherTests
+}
+
+// GetTestShardAssignment returns the assignment of tests to shards for the
+// sharded task's build. If this is the first shard of the build to ask for it,
+// the assignment is created by splitting the tests from the task's recent
+// history between the shards.
+func GetTestShardAssignment(ctx context.Context, env evergreen.Environment, p *Project, t *task.Task) (*TestShardAssignment, error) {
+	pt := p.FindProjectTask(t.DisplayName)
+	if pt == nil || pt.Shard == nil {
+		return nil, errors.Errorf("task '%s' is not sharded", t.DisplayName)
+	}
+	shard := pt.Shard
+
+	existing, err := FindTestShardAssignment(t.BuildId, shard.Group)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	durations, overhead, err := getShardTestHistory(ctx, env, p, t, shard.Group)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting test history for shard group '%s'", shard.Group)
+	}
+	a := &TestShardAssignment{
+		ID:         testShardAssignmentID(t.BuildId, shard.Group),
+		BuildID:    t.BuildId,
+		Group:      shard.Group,
+		Tasks:      shardTaskNames(p, shard),
+		CreateTime: time.Now(),
+	}
+	if len(durations) > 0 {
+		a.Shards = balanceTestShards(durations, shard.Count, time.Duration(shard.TargetDurationSecs)*time.Second, overhead)
+	}
+
+	if err = db.Insert(TestShardAssignmentsCollection, a); err != nil {
+		if !db.IsDuplicateKey(err) {
+			return nil, errors.Wrapf(err, "inserting test shard assignment for group '%s' in build '%s'", shard.Group, t.BuildId)
+		}
+		// Another shard created the assignment first, so use that one instead.
+		existing, err = FindTestShardAssignment(t.BuildId, shard.Group)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, errors.Errorf("test shard assignment for group '%s' in build '%s' not found", shard.Group, t.BuildId)
+		}
+		return existing, nil
+	}
+
+	if a.HasHistory() && len(a.Shards) < len(a.Tasks) {
+		// Failing to deactivate the unused shards only means that they run
+		// without any tests assigned to them.
+		grip.Error(message.WrapError(deactivateUnusedShards(t.BuildId, a.Tasks[len(a.Shards):]), message.Fields{
+			"message":  "could not deactivate unused shard tasks",
+			"build_id": t.BuildId,
+			"group":    shard.Group,
+		}))
+	}
+
+	return a, nil
+}
+
+// shardTaskNames returns the display names of the shard tasks in the shard's
+// group, ordered by shard index.
+func shardTaskNames(p *Project, shard *TaskShard) []string {
+	names := make([]string, shard.Count)
+	for _, pt := range p.Tasks {
+		if pt.Shard != nil && pt.Shard.Group == shard.Group && pt.Shard.Index < shard.Count {
+			names[pt.Shard.Index] = pt.Name
+		}
+	}
+	return names
+}
+
+// deactivateUnusedShards deactivates the shard tasks in the build that are not
+// needed to meet the target duration and have not been dispatched yet.
+func deactivateUnusedShards(buildID string, displayNames []string) error {
+	tasks, err := task.FindAll(db.Query(bson.M{
+		task.BuildIdKey:     buildID,
+		task.DisplayNameKey: bson.M{"$in": displayNames},
+		task.StatusKey:      evergreen.TaskUndispatched,
+		task.ActivatedKey:   true,
+	}))
+	if err != nil {
+		return errors.Wrap(err, "finding unused shard tasks")
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	if err = task.DeactivateTasks(tasks, false, evergreen.TestShardingTaskActivator); err != nil {
+		return errors.Wrap(err, "deactivating unused shard tasks")
+	}
+	if tasks[0].IsPartOfDisplay() {
+		return errors.Wrap(UpdateDisplayTaskForTask(&tasks[0]), "updating display task")
+	}
+	return nil
+}
+
+// getShardTestHistory returns the duration of each test from the most recent
+// mainline executions of the shard group's tasks, along with the average time
+// that those tasks spent outside of running tests.
+func getShardTestHistory(ctx context.Context, env evergreen.Environment, p *Project, t *task.Task, group string) (map[string]time.Duration, time.Duration, error) {
+	// Include the task name from before it was sharded, so tests can be split
+	// the first time a task is sharded.
+	names := []string{group}
+	for _, pt := range p.Tasks {
+		if pt.Shard != nil && pt.Shard.Group == group {
+			names = append(names, pt.Name)
+		}
+	}
+
+	history, err := task.FindAll(db.Query(bson.M{
+		task.ProjectKey:      t.Project,
+		task.BuildVariantKey: t.BuildVariant,
+		task.DisplayNameKey:  bson.M{"$in": names},
+		task.RequesterKey:    bson.M{"$in": evergreen.SystemVersionRequesterTypes},
+		task.StatusKey:       bson.M{"$in": []string{evergreen.TaskSucceeded, evergreen.TaskFailed}},
+		task.DisplayOnlyKey:  bson.M{"$ne": true},
+	}).Sort([]string{"-" + task.RevisionOrderNumberKey}).Limit(len(names) * shardHistoryDepth))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "finding previous tasks")
+	}
+
+	// Only use the most recent execution of each task.
+	var taskOpts []testresult.TaskOptions
+	latest := map[string]task.Task{}
+	for _, prev := range history {
+		if _, ok := latest[prev.DisplayName]; ok {
+			continue
+		}
+		opts, err := prev.CreateTestResultsTaskOptions()
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "creating test results task options for task '%s'", prev.Id)
+		}
+		if len(opts) == 0 {
+			continue
+		}
+		latest[prev.DisplayName] = prev
+		taskOpts = append(taskOpts, opts...)
+	}
+	if len(taskOpts) == 0 {
+		return nil, 0, nil
+	}
+
+	results, err := testresult.GetMergedTaskTestResults(ctx, env, taskOpts, nil)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "getting test results")
+	}
+	durations := map[string]time.Duration{}
+	testTimeByTask := map[string]time.Duration{}
+	for _, result := range results.Results {
+		name := result.GetDisplayTestName()
+		d := result.Duration()
+		if d > durations[name] {
+			durations[name] = d
+		}
+		testTimeByTask[result.TaskID] += d
+	}
+
+	var totalOverhead time.Duration
+	numTasks := 0
+	for _, prev := range latest {
+		if prev.TimeTaken <= 0 {
+			continue
+		}
+		if overhead := prev.TimeTaken - testTimeByTask[prev.Id]; overhead > 0 {
+			totalOverhead += overhead
+		}
+		numTasks++
+	}
+	var overhead time.Duration
+	if numTasks > 0 {
+		overhead = totalOverhead / time.Duration(numTasks)
+	}
+
+	return durations, overhead, nil
+}
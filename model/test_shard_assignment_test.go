@@ -0,0 +1,179 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/testresult"
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTestShardAssignment(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := testutil.NewEnvironment(ctx, t)
+
+	p := &Project{
+		Tasks: []ProjectTask{
+			{Name: "test__shard~0", Shard: &TaskShard{Group: "test", Index: 0, Count: 2}},
+			{Name: "test__shard~1", Shard: &TaskShard{Group: "test", Index: 1, Count: 2}},
+			{Name: "lint"},
+		},
+	}
+	current := &task.Task{
+		Id:           "current",
+		BuildId:      "build",
+		Project:      "project",
+		BuildVariant: "bv",
+		DisplayName:  "test__shard~1",
+	}
+
+	start := time.Now().Add(-time.Hour)
+	insertHistory := func(t *testing.T) {
+		prev := task.Task{
+			Id:                  "prev",
+			Project:             "project",
+			BuildVariant:        "bv",
+			DisplayName:         "test",
+			Requester:           evergreen.RepotrackerVersionRequester,
+			Status:              evergreen.TaskSucceeded,
+			RevisionOrderNumber: 1,
+			ResultsService:      testresult.TestResultsServiceLocal,
+			TimeTaken:           20 * time.Minute,
+		}
+		require.NoError(t, prev.Insert())
+		var results []testresult.TestResult
+		for name, d := range map[string]time.Duration{"a": 8 * time.Minute, "b": 5 * time.Minute, "c": 4 * time.Minute} {
+			results = append(results, testresult.TestResult{
+				TaskID:        prev.Id,
+				TestName:      name,
+				Status:        evergreen.TestSucceededStatus,
+				TestStartTime: start,
+				TestEndTime:   start.Add(d),
+			})
+		}
+		require.NoError(t, testresult.InsertLocal(ctx, env, results...))
+	}
+
+	for tName, tCase := range map[string]func(t *testing.T){
+		"SplitsTestsFromHistory": func(t *testing.T) {
+			insertHistory(t)
+
+			a, err := GetTestShardAssignment(ctx, env, p, current)
+			require.NoError(t, err)
+			require.True(t, a.HasHistory())
+			assert.Equal(t, [][]string{{"a"}, {"b", "c"}}, a.Shards)
+
+			tests, otherTests := a.TestsForShard(1)
+			assert.Equal(t, []string{"b", "c"}, tests)
+			assert.Equal(t, []string{"a"}, otherTests)
+
+			dbAssignment, err := FindTestShardAssignment("build", "test")
+			require.NoError(t, err)
+			require.NotNil(t, dbAssignment)
+			assert.Equal(t, a.Shards, dbAssignment.Shards)
+
+			dbAssignment, err = FindTestShardAssignmentForTask("build", "test__shard~1")
+			require.NoError(t, err)
+			require.NotNil(t, dbAssignment)
+			assert.Equal(t, []string{"test__shard~0", "test__shard~1"}, dbAssignment.Tasks)
+			assert.Equal(t, 1, dbAssignment.ShardIndex("test__shard~1"))
+			assert.Equal(t, -1, dbAssignment.ShardIndex("lint"))
+		},
+		"DeactivatesUnusedShardsForTargetDuration": func(t *testing.T) {
+			insertHistory(t)
+			targetProject := &Project{
+				Tasks: []ProjectTask{
+					{Name: "test__shard~0", Shard: &TaskShard{Group: "test", Index: 0, Count: 3, TargetDurationSecs: 3600}},
+					{Name: "test__shard~1", Shard: &TaskShard{Group: "test", Index: 1, Count: 3, TargetDurationSecs: 3600}},
+					{Name: "test__shard~2", Shard: &TaskShard{Group: "test", Index: 2, Count: 3, TargetDurationSecs: 3600}},
+				},
+			}
+			unused := task.Task{
+				Id:          "unused",
+				BuildId:     "build",
+				DisplayName: "test__shard~2",
+				Status:      evergreen.TaskUndispatched,
+				Activated:   true,
+			}
+			require.NoError(t, unused.Insert())
+			first := &task.Task{Id: "first", BuildId: "build", DisplayName: "test__shard~0"}
+
+			a, err := GetTestShardAssignment(ctx, env, targetProject, first)
+			require.NoError(t, err)
+			require.Len(t, a.Shards, 1)
+			assert.ElementsMatch(t, []string{"a", "b", "c"}, a.Shards[0])
+
+			dbUnused, err := task.FindOneId(unused.Id)
+			require.NoError(t, err)
+			require.NotNil(t, dbUnused)
+			assert.False(t, dbUnused.Activated)
+			assert.Equal(t, evergreen.TestShardingTaskActivator, dbUnused.ActivatedBy)
+
+			tests, otherTests := a.TestsForShard(a.ShardIndex("test__shard~2"))
+			assert.Empty(t, tests)
+			assert.Len(t, otherTests, 3)
+		},
+		"ReusesExistingAssignment": func(t *testing.T) {
+			existing := &TestShardAssignment{
+				ID:      testShardAssignmentID("build", "test"),
+				BuildID: "build",
+				Group:   "test",
+				Shards:  [][]string{{"x"}, {"y"}},
+			}
+			require.NoError(t, db.Insert(TestShardAssignmentsCollection, existing))
+			insertHistory(t)
+
+			a, err := GetTestShardAssignment(ctx, env, p, current)
+			require.NoError(t, err)
+			assert.Equal(t, existing.Shards, a.Shards)
+		},
+		"NoHistory": func(t *testing.T) {
+			a, err := GetTestShardAssignment(ctx, env, p, current)
+			require.NoError(t, err)
+			assert.False(t, a.HasHistory())
+			tests, otherTests := a.TestsForShard(0)
+			assert.Empty(t, tests)
+			assert.Empty(t, otherTests)
+		},
+		"IgnoresPatchHistory": func(t *testing.T) {
+			prev := task.Task{
+				Id:             "prev",
+				Project:        "project",
+				BuildVariant:   "bv",
+				DisplayName:    "test__shard~0",
+				Requester:      evergreen.PatchVersionRequester,
+				Status:         evergreen.TaskSucceeded,
+				ResultsService: testresult.TestResultsServiceLocal,
+			}
+			require.NoError(t, prev.Insert())
+			require.NoError(t, testresult.InsertLocal(ctx, env, testresult.TestResult{
+				TaskID:        prev.Id,
+				TestName:      "a",
+				Status:        evergreen.TestSucceededStatus,
+				TestStartTime: start,
+				TestEndTime:   start.Add(time.Minute),
+			}))
+
+			a, err := GetTestShardAssignment(ctx, env, p, current)
+			require.NoError(t, err)
+			assert.False(t, a.HasHistory())
+		},
+		"FailsForUnshardedTask": func(t *testing.T) {
+			_, err := GetTestShardAssignment(ctx, env, p, &task.Task{Id: "lint", BuildId: "build", DisplayName: "lint"})
+			assert.Error(t, err)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.ClearCollections(task.Collection, TestShardAssignmentsCollection))
+			require.NoError(t, testresult.ClearLocal(ctx, env))
+			tCase(t)
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	adb "github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	AccessTokenCollection = "user_access_tokens"
+
+	// AccessTokenPrefix is prepended to every personal access token so that
+	// tokens can be distinguished from legacy API keys and recognized by
+	// secret scanners.
+	AccessTokenPrefix = "evg_pat_"
+
+	// accessTokenLastUsedGranularity limits how often the last used time is
+	// written to the database for a token that is in active use.
+	accessTokenLastUsedGranularity = 10 * time.Minute
+)
+
+// AccessTokenScope describes the kinds of requests that a personal access
+// token is permitted to make.
+type AccessTokenScope string
+
+const (
+	// AccessTokenScopeAll permits any request that the owning user can make.
+	AccessTokenScopeAll AccessTokenScope = "all"
+	// AccessTokenScopeReadOnly permits only requests that do not modify
+	// state.
+	AccessTokenScopeReadOnly AccessTokenScope = "read_only"
+	// AccessTokenScopePatchSubmit permits read-only requests as well as
+	// creating and modifying patches.
+	AccessTokenScopePatchSubmit AccessTokenScope = "patch_submit"
+)
+
+// Validate checks that the scope is a recognized scope.
+func (s AccessTokenScope) Validate() error {
+	switch s {
+	case AccessTokenScopeAll, AccessTokenScopeReadOnly, AccessTokenScopePatchSubmit:
+		return nil
+	default:
+		return errors.Errorf("invalid access token scope '%s'", s)
+	}
+}
+
+// AccessToken is a named, scoped, expiring credential that a user can mint
+// for API access in place of their API key. Only a hash of the token is
+// stored.
+type AccessToken struct {
+	Id         string             `bson:"_id"`
+	UserId     string             `bson:"user_id"`
+	Name       string             `bson:"name"`
+	TokenHash  string             `bson:"token_hash"`
+	Scopes     []AccessTokenScope `bson:"scopes"`
+	Projects   []string           `bson:"projects,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+	LastUsedAt time.Time          `bson:"last_used_at,omitempty"`
+}
+
+var (
+	AccessTokenIdKey         = bsonutil.MustHaveTag(AccessToken{}, "Id")
+	AccessTokenUserIdKey     = bsonutil.MustHaveTag(AccessToken{}, "UserId")
+	AccessTokenNameKey       = bsonutil.MustHaveTag(AccessToken{}, "Name")
+	AccessTokenTokenHashKey  = bsonutil.MustHaveTag(AccessToken{}, "TokenHash")
+	AccessTokenExpiresAtKey  = bsonutil.MustHaveTag(AccessToken{}, "ExpiresAt")
+	AccessTokenLastUsedAtKey = bsonutil.MustHaveTag(AccessToken{}, "LastUsedAt")
+)
+
+// IsAccessToken returns whether the given credential has the format of a
+// personal access token.
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// hashAccessToken returns the hash of the token under which it is stored.
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAccessToken mints a new personal access token for the user and stores
+// its hash. The plaintext token is returned and cannot be retrieved again.
+func CreateAccessToken(userId, name string, scopes []AccessTokenScope, projects []string, expiresAt time.Time) (*AccessToken, string, error) {
+	if userId == "" {
+		return nil, "", errors.New("user ID cannot be empty")
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, "", errors.New("access token name cannot be empty")
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.New("access token must have at least one scope")
+	}
+	for _, s := range scopes {
+		if err := s.Validate(); err != nil {
+			return nil, "", err
+		}
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, "", errors.New("access token expiration must be in the future")
+	}
+
+	existing, err := FindOneAccessToken(db.Query(bson.M{
+		AccessTokenUserIdKey: userId,
+		AccessTokenNameKey:   name,
+	}))
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		return nil, "", errors.Errorf("access token '%s' already exists for user '%s'", name, userId)
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return nil, "", errors.Wrap(err, "generating access token")
+	}
+	token := AccessTokenPrefix + hex.EncodeToString(secret)
+
+	t := &AccessToken{
+		Id:        utility.RandomString(),
+		UserId:    userId,
+		Name:      name,
+		TokenHash: hashAccessToken(token),
+		Scopes:    scopes,
+		Projects:  projects,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if err = db.Insert(AccessTokenCollection, t); err != nil {
+		return nil, "", errors.Wrapf(err, "inserting access token '%s' for user '%s'", name, userId)
+	}
+
+	return t, token, nil
+}
+
+// AccessTokenByUserAndId returns a query that matches the user's access token
+// with the given ID.
+func AccessTokenByUserAndId(userId, tokenId string) db.Q {
+	return db.Query(bson.M{
+		AccessTokenIdKey:     tokenId,
+		AccessTokenUserIdKey: userId,
+	})
+}
+
+// FindOneAccessToken gets one access token for the given query.
+func FindOneAccessToken(query db.Q) (*AccessToken, error) {
+	t := &AccessToken{}
+	err := db.FindOneQ(AccessTokenCollection, query, t)
+	if adb.ResultsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding access token")
+	}
+	return t, nil
+}
+
+// FindAccessTokensByUser returns all of the user's access tokens, including
+// expired ones.
+func FindAccessTokensByUser(userId string) ([]AccessToken, error) {
+	tokens := []AccessToken{}
+	query := db.Query(bson.M{AccessTokenUserIdKey: userId}).Sort([]string{AccessTokenNameKey})
+	if err := db.FindAllQ(AccessTokenCollection, query, &tokens); err != nil {
+		return nil, errors.Wrapf(err, "finding access tokens for user '%s'", userId)
+	}
+	return tokens, nil
+}
+
+// FindAccessTokenByToken returns the unexpired access token matching the
+// plaintext token, if any.
+func FindAccessTokenByToken(token string) (*AccessToken, error) {
+	if !IsAccessToken(token) {
+		return nil, nil
+	}
+	return FindOneAccessToken(db.Query(bson.M{
+		AccessTokenTokenHashKey: hashAccessToken(token),
+		AccessTokenExpiresAtKey: bson.M{"$gt": time.Now()},
+	}))
+}
+
+// RevokeAccessToken deletes the user's access token with the given ID.
+func RevokeAccessToken(userId, tokenId string) error {
+	err := db.Remove(AccessTokenCollection, bson.M{
+		AccessTokenIdKey:     tokenId,
+		AccessTokenUserIdKey: userId,
+	})
+	if adb.ResultsNotFound(err) {
+		return errors.Errorf("access token '%s' not found for user '%s'", tokenId, userId)
+	}
+	return errors.Wrapf(err, "revoking access token '%s' for user '%s'", tokenId, userId)
+}
+
+// RevokeAllAccessTokens deletes all of the user's access tokens.
+func RevokeAllAccessTokens(userId string) error {
+	return errors.Wrapf(db.RemoveAll(AccessTokenCollection, bson.M{AccessTokenUserIdKey: userId}), "revoking access tokens for user '%s'", userId)
+}
+
+// IsExpired returns whether the token can no longer be used.
+func (t *AccessToken) IsExpired() bool {
+	return !t.ExpiresAt.After(time.Now())
+}
+
+// MarkUsed records that the token was just used. To avoid a write on every
+// request, the time is only updated once it is sufficiently stale.
+func (t *AccessToken) MarkUsed() error {
+	now := time.Now()
+	if now.Sub(t.LastUsedAt) < accessTokenLastUsedGranularity {
+		return nil
+	}
+	if err := db.Update(AccessTokenCollection, bson.M{AccessTokenIdKey: t.Id}, bson.M{
+		"$set": bson.M{AccessTokenLastUsedAtKey: now},
+	}); err != nil {
+		return errors.Wrapf(err, "updating last used time for access token '%s'", t.Id)
+	}
+	t.LastUsedAt = now
+	return nil
+}
+
+// AllowsRequest returns whether any of the token's scopes permit a request
+// with the given method. isPatchRequest indicates whether the request creates
+// or modifies a patch.
+func (t *AccessToken) AllowsRequest(method string, isPatchRequest bool) bool {
+	readOnly := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	for _, s := range t.Scopes {
+		switch s {
+		case AccessTokenScopeAll:
+			return true
+		case AccessTokenScopeReadOnly:
+			if readOnly {
+				return true
+			}
+		case AccessTokenScopePatchSubmit:
+			if readOnly || isPatchRequest {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsProjectRestricted returns whether the token may only be used with a
+// specific set of projects.
+func (t *AccessToken) IsProjectRestricted() bool {
+	return len(t.Projects) > 0
+}
+
+// AllowsProject returns whether the token may be used to access the project
+// with the given ID.
+func (t *AccessToken) AllowsProject(projectId string) bool {
+	return !t.IsProjectRestricted() || utility.StringSliceContains(t.Projects, projectId)
+}
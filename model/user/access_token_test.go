@@ -0,0 +1,194 @@
+package user
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAccessTokenScopes(t *testing.T) {
+	for tName, tCase := range map[string]struct {
+		scopes         []AccessTokenScope
+		method         string
+		isPatchRequest bool
+		allowed        bool
+	}{
+		"ReadOnlyAllowsGet": {
+			scopes:  []AccessTokenScope{AccessTokenScopeReadOnly},
+			method:  http.MethodGet,
+			allowed: true,
+		},
+		"ReadOnlyRejectsPost": {
+			scopes: []AccessTokenScope{AccessTokenScopeReadOnly},
+			method: http.MethodPost,
+		},
+		"ReadOnlyRejectsPatchSubmission": {
+			scopes:         []AccessTokenScope{AccessTokenScopeReadOnly},
+			method:         http.MethodPut,
+			isPatchRequest: true,
+		},
+		"PatchSubmitAllowsGet": {
+			scopes:  []AccessTokenScope{AccessTokenScopePatchSubmit},
+			method:  http.MethodGet,
+			allowed: true,
+		},
+		"PatchSubmitAllowsPatchSubmission": {
+			scopes:         []AccessTokenScope{AccessTokenScopePatchSubmit},
+			method:         http.MethodPut,
+			isPatchRequest: true,
+			allowed:        true,
+		},
+		"PatchSubmitRejectsOtherModifications": {
+			scopes: []AccessTokenScope{AccessTokenScopePatchSubmit},
+			method: http.MethodDelete,
+		},
+		"AllAllowsAnything": {
+			scopes:  []AccessTokenScope{AccessTokenScopeAll},
+			method:  http.MethodDelete,
+			allowed: true,
+		},
+		"AnyScopeCanPermit": {
+			scopes:         []AccessTokenScope{AccessTokenScopeReadOnly, AccessTokenScopePatchSubmit},
+			method:         http.MethodPost,
+			isPatchRequest: true,
+			allowed:        true,
+		},
+		"NoScopesRejectsEverything": {
+			method: http.MethodGet,
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			token := AccessToken{Scopes: tCase.scopes}
+			assert.Equal(t, tCase.allowed, token.AllowsRequest(tCase.method, tCase.isPatchRequest))
+		})
+	}
+
+	t.Run("ValidateRejectsUnknownScope", func(t *testing.T) {
+		assert.NoError(t, AccessTokenScopeReadOnly.Validate())
+		assert.Error(t, AccessTokenScope("admin").Validate())
+	})
+	t.Run("ProjectRestriction", func(t *testing.T) {
+		unrestricted := AccessToken{}
+		assert.False(t, unrestricted.IsProjectRestricted())
+		assert.True(t, unrestricted.AllowsProject("p1"))
+
+		restricted := AccessToken{Projects: []string{"p1"}}
+		assert.True(t, restricted.IsProjectRestricted())
+		assert.True(t, restricted.AllowsProject("p1"))
+		assert.False(t, restricted.AllowsProject("p2"))
+	})
+}
+
+func TestAccessTokens(t *testing.T) {
+	for tName, tCase := range map[string]func(t *testing.T){
+		"CreateStoresOnlyHash": func(t *testing.T) {
+			created, token, err := CreateAccessToken("u1", "ci", []AccessTokenScope{AccessTokenScopeReadOnly}, []string{"p1"}, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.True(t, IsAccessToken(token))
+			assert.True(t, strings.HasPrefix(token, AccessTokenPrefix))
+
+			dbToken, err := FindOneAccessToken(AccessTokenByUserAndId("u1", created.Id))
+			require.NoError(t, err)
+			require.NotNil(t, dbToken)
+			assert.NotContains(t, dbToken.TokenHash, strings.TrimPrefix(token, AccessTokenPrefix))
+			assert.Equal(t, hashAccessToken(token), dbToken.TokenHash)
+			assert.Equal(t, []string{"p1"}, dbToken.Projects)
+		},
+		"CreateFailsWithDuplicateName": func(t *testing.T) {
+			_, _, err := CreateAccessToken("u1", "ci", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			_, _, err = CreateAccessToken("u1", "ci", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			assert.Error(t, err)
+
+			_, _, err = CreateAccessToken("u2", "ci", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			assert.NoError(t, err, "different users should be able to use the same name")
+		},
+		"CreateFailsWithInvalidInput": func(t *testing.T) {
+			_, _, err := CreateAccessToken("u1", "", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			assert.Error(t, err)
+			_, _, err = CreateAccessToken("u1", "ci", nil, nil, time.Now().Add(time.Hour))
+			assert.Error(t, err)
+			_, _, err = CreateAccessToken("u1", "ci", []AccessTokenScope{"admin"}, nil, time.Now().Add(time.Hour))
+			assert.Error(t, err)
+			_, _, err = CreateAccessToken("u1", "ci", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(-time.Hour))
+			assert.Error(t, err)
+		},
+		"FindByTokenIgnoresExpiredTokens": func(t *testing.T) {
+			created, token, err := CreateAccessToken("u1", "ci", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+
+			found, err := FindAccessTokenByToken(token)
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.Equal(t, created.Id, found.Id)
+
+			require.NoError(t, db.UpdateId(AccessTokenCollection, created.Id, bson.M{
+				"$set": bson.M{AccessTokenExpiresAtKey: time.Now().Add(-time.Minute)},
+			}))
+			found, err = FindAccessTokenByToken(token)
+			require.NoError(t, err)
+			assert.Nil(t, found)
+		},
+		"FindByTokenIgnoresUnknownTokens": func(t *testing.T) {
+			found, err := FindAccessTokenByToken(AccessTokenPrefix + "unknown")
+			assert.NoError(t, err)
+			assert.Nil(t, found)
+
+			found, err = FindAccessTokenByToken("legacy_api_key")
+			assert.NoError(t, err)
+			assert.Nil(t, found)
+		},
+		"RevokeOnlyAffectsOwner": func(t *testing.T) {
+			created, token, err := CreateAccessToken("u1", "ci", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+
+			assert.Error(t, RevokeAccessToken("u2", created.Id))
+			require.NoError(t, RevokeAccessToken("u1", created.Id))
+
+			found, err := FindAccessTokenByToken(token)
+			require.NoError(t, err)
+			assert.Nil(t, found)
+		},
+		"ListAndRevokeAll": func(t *testing.T) {
+			for _, name := range []string{"b", "a"} {
+				_, _, err := CreateAccessToken("u1", name, []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+				require.NoError(t, err)
+			}
+
+			tokens, err := FindAccessTokensByUser("u1")
+			require.NoError(t, err)
+			require.Len(t, tokens, 2)
+			assert.Equal(t, "a", tokens[0].Name)
+			assert.Equal(t, "b", tokens[1].Name)
+
+			require.NoError(t, RevokeAllAccessTokens("u1"))
+			tokens, err = FindAccessTokensByUser("u1")
+			require.NoError(t, err)
+			assert.Empty(t, tokens)
+		},
+		"MarkUsedUpdatesLastUsedTime": func(t *testing.T) {
+			created, _, err := CreateAccessToken("u1", "ci", []AccessTokenScope{AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			require.NoError(t, created.MarkUsed())
+
+			dbToken, err := FindOneAccessToken(AccessTokenByUserAndId("u1", created.Id))
+			require.NoError(t, err)
+			require.NotNil(t, dbToken)
+			assert.WithinDuration(t, time.Now(), dbToken.LastUsedAt, time.Minute)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.ClearCollections(AccessTokenCollection))
+			defer func() {
+				assert.NoError(t, db.ClearCollections(AccessTokenCollection))
+			}()
+			tCase(t)
+		})
+	}
+}
@@ -486,7 +486,8 @@ func ClearLoginCache(user gimlet.User) error {
 	return nil
 }
 
-// ClearUser clears one user's settings, roles, and login cache.
+// ClearUser clears one user's settings, roles, and login cache, and revokes
+// their personal access tokens.
 func ClearUser(userId string) error {
 	update := bson.M{
 		"$unset": bson.M{
@@ -499,7 +500,7 @@ func ClearUser(userId string) error {
 		return errors.Wrap(err, "unsetting user settings")
 	}
 
-	return nil
+	return RevokeAllAccessTokens(userId)
 }
 
 // ClearAllLoginCaches clears all users' login caches, forcibly logging them
@@ -305,6 +305,12 @@ func (u *DBUser) RemoveFavoriteProject(identifier string) error {
 }
 
 func (u *DBUser) AddRole(role string) error {
+	return u.AddRoleBy("", role)
+}
+
+// AddRoleBy adds the role to the user and records the actor who made the
+// change in the event log.
+func (u *DBUser) AddRoleBy(actor, role string) error {
 	if utility.StringSliceContains(u.SystemRoles, role) {
 		return nil
 	}
@@ -316,10 +322,16 @@ func (u *DBUser) AddRole(role string) error {
 	}
 	u.SystemRoles = append(u.SystemRoles, role)
 
-	return event.LogUserEvent(u.Id, event.UserEventTypeRolesUpdate, u.SystemRoles[:len(u.SystemRoles)-1], u.SystemRoles)
+	return event.LogUserEventByActor(u.Id, actor, event.UserEventTypeRolesUpdate, u.SystemRoles[:len(u.SystemRoles)-1], u.SystemRoles)
 }
 
 func (u *DBUser) RemoveRole(role string) error {
+	return u.RemoveRoleBy("", role)
+}
+
+// RemoveRoleBy removes the role from the user and records the actor who made
+// the change in the event log.
+func (u *DBUser) RemoveRoleBy(actor, role string) error {
 	before := u.SystemRoles
 	update := bson.M{
 		"$pull": bson.M{RolesKey: role},
@@ -333,7 +345,7 @@ func (u *DBUser) RemoveRole(role string) error {
 		}
 	}
 
-	return event.LogUserEvent(u.Id, event.UserEventTypeRolesUpdate, before, u.SystemRoles)
+	return event.LogUserEventByActor(u.Id, actor, event.UserEventTypeRolesUpdate, before, u.SystemRoles)
 }
 
 // GetViewableProjects returns the lists of projects/repos the user can view.
@@ -398,6 +410,13 @@ func (u *DBUser) HasProjectCreatePermission() (bool, error) {
 }
 
 func (u *DBUser) DeleteAllRoles() error {
+	return u.DeleteAllRolesBy("")
+}
+
+// DeleteAllRolesBy removes all of the user's roles and records the actor who
+// made the change in the event log.
+func (u *DBUser) DeleteAllRolesBy(actor string) error {
+	before := append([]string{}, u.SystemRoles...)
 	info, err := db.FindAndModify(
 		Collection,
 		bson.M{IdKey: u.Id},
@@ -406,6 +425,7 @@ func (u *DBUser) DeleteAllRoles() error {
 			Update: bson.M{
 				"$set": bson.M{RolesKey: []string{}},
 			},
+			ReturnNew: true,
 		}, u)
 	if err != nil {
 		return errors.Wrap(err, "clearing user roles")
@@ -413,13 +433,20 @@ func (u *DBUser) DeleteAllRoles() error {
 	if info.Updated != 1 {
 		return errors.Errorf("could not find user '%s' to update", u.Id)
 	}
-	return nil
+	return event.LogUserEventByActor(u.Id, actor, event.UserEventTypeRolesUpdate, before, u.SystemRoles)
 }
 
 func (u *DBUser) DeleteRoles(roles []string) error {
+	return u.DeleteRolesBy("", roles)
+}
+
+// DeleteRolesBy removes the roles from the user and records the actor who made
+// the change in the event log.
+func (u *DBUser) DeleteRolesBy(actor string, roles []string) error {
 	if len(roles) == 0 {
 		return nil
 	}
+	before := append([]string{}, u.SystemRoles...)
 	info, err := db.FindAndModify(
 		Collection,
 		bson.M{IdKey: u.Id},
@@ -428,6 +455,7 @@ func (u *DBUser) DeleteRoles(roles []string) error {
 			Update: bson.M{
 				"$pullAll": bson.M{RolesKey: roles},
 			},
+			ReturnNew: true,
 		}, u)
 	if err != nil {
 		return errors.Wrap(err, "deleting user roles")
@@ -435,7 +463,7 @@ func (u *DBUser) DeleteRoles(roles []string) error {
 	if info.Updated != 1 {
 		return errors.Errorf("could not find user '%s' to update", u.Id)
 	}
-	return nil
+	return event.LogUserEventByActor(u.Id, actor, event.UserEventTypeRolesUpdate, before, u.SystemRoles)
 }
 
 // GeneralSubscriptionIDs returns a slice of the ids of the user's general subscriptions.
@@ -127,6 +127,26 @@ func BaseVersionByProjectIdAndRevision(projectId, revision string) db.Q {
 		})
 }
 
+// FindGitTagVersionIDs returns the IDs of the project's versions that were
+// created by or tagged with a git tag.
+func FindGitTagVersionIDs(projectId string) ([]string, error) {
+	versions, err := VersionFind(db.Query(bson.M{
+		VersionIdentifierKey: projectId,
+		"$or": []bson.M{
+			{VersionRequesterKey: evergreen.GitTagRequester},
+			{bsonutil.GetDottedKeyName(VersionGitTagsKey, "0"): bson.M{"$exists": true}},
+		},
+	}).WithFields(VersionIdKey))
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding git tag versions for project '%s'", projectId)
+	}
+	ids := make([]string, 0, len(versions))
+	for _, v := range versions {
+		ids = append(ids, v.Id)
+	}
+	return ids, nil
+}
+
 func VersionByProjectIdAndRevisionPrefix(projectId, revisionPrefix string) db.Q {
 	lengthHash := 40 - len(revisionPrefix)
 	return db.Query(
@@ -39,6 +39,7 @@ func Admin() cli.Command {
 			updateServiceUser(),
 			getServiceUsers(),
 			deleteServiceUser(),
+			adminAuditLog(),
 		},
 	}
 }
@@ -0,0 +1,174 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+func adminAuditLog() cli.Command {
+	return cli.Command{
+		Name:  "audit-log",
+		Usage: "export and verify the audit log of admin, project, and permission changes",
+		Subcommands: []cli.Command{
+			adminAuditLogExport(),
+			adminAuditLogVerify(),
+		},
+	}
+}
+
+func adminAuditLogExport() cli.Command {
+	const (
+		actorFlagName        = "actor"
+		resourceTypeFlagName = "resource-type"
+		resourceIdFlagName   = "resource-id"
+		startFlagName        = "start"
+		endFlagName          = "end"
+		formatFlagName       = "format"
+		outputFlagName       = "output"
+	)
+
+	return cli.Command{
+		Name:  "export",
+		Usage: "export audit log entries matching the given filters",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  actorFlagName,
+				Usage: "only export changes made by this user",
+			},
+			cli.StringFlag{
+				Name:  resourceTypeFlagName,
+				Usage: fmt.Sprintf("only export changes to this type of resource (%v)", model.AuditLogResourceTypes),
+			},
+			cli.StringFlag{
+				Name:  resourceIdFlagName,
+				Usage: "only export changes to this resource (config section, project ID, or user ID)",
+			},
+			cli.StringFlag{
+				Name:  startFlagName,
+				Usage: "only export changes at or after this RFC-3339 time",
+			},
+			cli.StringFlag{
+				Name:  endFlagName,
+				Usage: "only export changes at or before this RFC-3339 time",
+			},
+			cli.StringFlag{
+				Name:  formatFlagName,
+				Value: model.AuditLogFormatJSONL,
+				Usage: fmt.Sprintf("export format, either '%s' or '%s'", model.AuditLogFormatJSONL, model.AuditLogFormatCSV),
+			},
+			cli.StringFlag{
+				Name:  joinFlagNames(outputFlagName, "o"),
+				Usage: "write the export to this file instead of standard output",
+			},
+			cli.IntFlag{
+				Name:  limitFlagName,
+				Usage: fmt.Sprintf("maximum number of entries to export (defaults to %d)", model.DefaultAuditLogLimit),
+			},
+		},
+		Before: mergeBeforeFuncs(
+			setPlainLogger,
+			func(c *cli.Context) error {
+				format := c.String(formatFlagName)
+				if format != model.AuditLogFormatJSONL && format != model.AuditLogFormatCSV {
+					return errors.Errorf("invalid format '%s'", format)
+				}
+				return nil
+			}),
+		Action: func(c *cli.Context) error {
+			confPath := c.Parent().Parent().Parent().String(confFlagName)
+
+			q := model.AuditLogQuery{
+				Actor:        c.String(actorFlagName),
+				ResourceType: c.String(resourceTypeFlagName),
+				ResourceId:   c.String(resourceIdFlagName),
+				Limit:        c.Int(limitFlagName),
+			}
+			var err error
+			if start := c.String(startFlagName); start != "" {
+				if q.StartTime, err = time.Parse(time.RFC3339, start); err != nil {
+					return errors.Wrap(err, "parsing start time")
+				}
+			}
+			if end := c.String(endFlagName); end != "" {
+				if q.EndTime, err = time.Parse(time.RFC3339, end); err != nil {
+					return errors.Wrap(err, "parsing end time")
+				}
+			}
+			if err = q.Validate(); err != nil {
+				return errors.Wrap(err, "invalid audit log filters")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			conf, err := NewClientSettings(confPath)
+			if err != nil {
+				return errors.Wrap(err, "loading configuration")
+			}
+			client, err := conf.setupRestCommunicator(ctx, false)
+			if err != nil {
+				return errors.Wrap(err, "setting up REST communicator")
+			}
+			defer client.Close()
+
+			body, err := client.ExportAuditLog(ctx, q, c.String(formatFlagName))
+			if err != nil {
+				return errors.Wrap(err, "exporting audit log")
+			}
+			defer body.Close()
+
+			var out io.Writer = os.Stdout
+			if output := c.String(outputFlagName); output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return errors.Wrapf(err, "creating output file '%s'", output)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			_, err = io.Copy(out, body)
+			return errors.Wrap(err, "writing audit log")
+		},
+	}
+}
+
+func adminAuditLogVerify() cli.Command {
+	return cli.Command{
+		Name:      "verify",
+		Usage:     "verify that a JSONL audit log export has not been tampered with",
+		ArgsUsage: "<file>",
+		Before: mergeBeforeFuncs(
+			setPlainLogger,
+			func(c *cli.Context) error {
+				if c.NArg() != 1 || c.Args().Get(0) == "" {
+					return errors.New("must specify exactly one audit log file to verify")
+				}
+				return nil
+			}),
+		Action: func(c *cli.Context) error {
+			fileName := c.Args().Get(0)
+			f, err := os.Open(fileName)
+			if err != nil {
+				return errors.Wrapf(err, "opening audit log file '%s'", fileName)
+			}
+			defer f.Close()
+
+			num, err := model.VerifyAuditLogChain(f)
+			if err != nil {
+				return errors.Wrapf(err, "verifying audit log file '%s'", fileName)
+			}
+
+			grip.Infof("Verified %d audit log entries in '%s'.", num, fileName)
+			return nil
+		},
+	}
+}
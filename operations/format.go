@@ -0,0 +1,71 @@
+package operations
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/evergreen-ci/evergreen/validator"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+func Format() cli.Command {
+	const (
+		writeFlagName = "write"
+		checkFlagName = "check"
+	)
+
+	return cli.Command{
+		Name:  "format",
+		Usage: "reformat a project configuration with its keys in canonical order, printing the result",
+		Flags: addPathFlag(
+			cli.BoolFlag{
+				Name:  joinFlagNames(writeFlagName, "w"),
+				Usage: "write the result to the file instead of printing it",
+			},
+			cli.BoolFlag{
+				Name:  checkFlagName,
+				Usage: "exit with an error if the file is not formatted instead of printing the result",
+			},
+		),
+		Before: mergeBeforeFuncs(requirePathFlag),
+		Action: func(c *cli.Context) error {
+			path := c.String(pathFlagName)
+			write := c.Bool(writeFlagName)
+			check := c.Bool(checkFlagName)
+
+			configBytes, err := os.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "reading file '%s'", path)
+			}
+			formatted, err := validator.FormatProjectYAML(configBytes)
+			if err != nil {
+				return errors.Wrapf(err, "formatting file '%s'", path)
+			}
+
+			switch {
+			case check:
+				if !bytes.Equal(configBytes, formatted) {
+					return errors.Errorf("%s is not formatted", path)
+				}
+				return nil
+			case write:
+				return errors.Wrapf(writeProjectFile(path, formatted), "writing file '%s'", path)
+			default:
+				fmt.Print(string(formatted))
+				return nil
+			}
+		},
+	}
+}
+
+// writeProjectFile replaces the contents of the project configuration file,
+// keeping its permissions.
+func writeProjectFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrap(err, "getting file info")
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
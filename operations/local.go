@@ -0,0 +1,136 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/evergreen-ci/evergreen/agent/local"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+func Local() cli.Command {
+	return cli.Command{
+		Name:  "local",
+		Usage: "run project configuration on the local machine",
+		Subcommands: []cli.Command{
+			localRun(),
+		},
+	}
+}
+
+func localRun() cli.Command {
+	const (
+		taskFlagName       = "task"
+		variantFlagName    = "variant"
+		expansionFlagName  = "expansion"
+		sourceDirFlagName  = "source_dir"
+		keepDirFlagName    = "keep"
+		defaultProjectPath = "evergreen.yml"
+	)
+
+	return cli.Command{
+		Name:  "run",
+		Usage: "run a task's commands in a scratch directory on the local machine",
+		Description: `Runs the pre, task and post commands of a task (or the setup and teardown
+commands of its task group) without creating a patch. Commands that depend on
+the Evergreen server are skipped, except for git.get_project, which copies the
+local source directory instead of cloning, and s3.put and s3.get, which copy
+files to and from a directory in the scratch directory instead of S3.`,
+		Flags: addPathFlag(
+			cli.StringFlag{
+				Name:  joinFlagNames(taskFlagName, "t"),
+				Usage: "name of the task to run",
+			},
+			cli.StringFlag{
+				Name:  joinFlagNames(variantFlagName, "v"),
+				Usage: "name of the build variant to run the task on",
+			},
+			cli.StringFlag{
+				Name:  joinFlagNames(projectFlagName, "p"),
+				Usage: "project identifier to use in expansions",
+			},
+			cli.StringSliceFlag{
+				Name:  joinFlagNames(expansionFlagName, "e"),
+				Usage: "set an expansion as a KEY=VALUE pair (can be specified multiple times)",
+			},
+			cli.StringFlag{
+				Name:  joinFlagNames(dirFlagName, "d"),
+				Usage: "scratch directory to run the task in (defaults to a new temporary directory)",
+			},
+			cli.StringFlag{
+				Name:  sourceDirFlagName,
+				Usage: "directory containing the project source used by git.get_project (defaults to the current directory)",
+			},
+			cli.BoolFlag{
+				Name:  keepDirFlagName,
+				Usage: "keep the scratch directory after the task finishes, even if it was created automatically",
+			},
+		),
+		Before: mergeBeforeFuncs(setPlainLogger, requireStringFlag(taskFlagName), requireStringFlag(variantFlagName)),
+		Action: func(c *cli.Context) error {
+			path := c.String(pathFlagName)
+			if path == "" {
+				path = defaultProjectPath
+			}
+			dir := c.String(dirFlagName)
+			expansions, err := parseKeyValuePairs(c.StringSlice(expansionFlagName))
+			if err != nil {
+				return errors.Wrap(err, "parsing expansions")
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			configBytes, err := os.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "reading project config '%s'", path)
+			}
+			p := &model.Project{}
+			if _, err = model.LoadProjectInto(ctx, configBytes, &model.GetProjectOpts{ReadFileFrom: model.ReadFromLocal}, c.String(projectFlagName), p); err != nil {
+				return errors.Wrap(err, "loading project")
+			}
+
+			res, err := local.Run(ctx, local.Options{
+				Project:     p,
+				ProjectID:   c.String(projectFlagName),
+				TaskName:    c.String(taskFlagName),
+				VariantName: c.String(variantFlagName),
+				Dir:         dir,
+				SourceDir:   c.String(sourceDirFlagName),
+				Expansions:  expansions,
+			})
+			if res != nil {
+				if len(res.Skipped) > 0 {
+					grip.Infof("Skipped %d command(s) that cannot run locally:\n\t%s", len(res.Skipped), strings.Join(res.Skipped, "\n\t"))
+				}
+				if dir == "" && !c.Bool(keepDirFlagName) {
+					grip.Error(errors.Wrapf(os.RemoveAll(res.Dir), "removing scratch directory '%s'", res.Dir))
+				} else {
+					grip.Infof("Task files are in '%s'.", res.WorkDir)
+				}
+			}
+			return err
+		},
+	}
+}
+
+// parseKeyValuePairs parses KEY=VALUE pairs into a map.
+func parseKeyValuePairs(pairs []string) (map[string]string, error) {
+	out := map[string]string{}
+	catcher := grip.NewBasicCatcher()
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			catcher.Errorf("invalid KEY=VALUE pair '%s'", pair)
+			continue
+		}
+		out[key] = value
+	}
+	return out, catcher.Resolve()
+}
@@ -0,0 +1,30 @@
+package operations
+
+import (
+	"context"
+	"os"
+
+	"github.com/evergreen-ci/evergreen/validator/lsp"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/send"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+func LSP() cli.Command {
+	return cli.Command{
+		Name:  "lsp",
+		Usage: "run a language server for project configurations that communicates over standard input and output",
+		Before: func(c *cli.Context) error {
+			// Standard output is reserved for protocol messages, so log to
+			// standard error instead.
+			return errors.Wrap(grip.SetSender(send.MakePlainErrorLogger()), "setting logger")
+		},
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			return errors.Wrap(lsp.NewServer(os.Stdin, os.Stdout).Run(ctx), "running language server")
+		},
+	}
+}
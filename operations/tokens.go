@@ -0,0 +1,202 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"github.com/evergreen-ci/evergreen/model/user"
+	restmodel "github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+func Tokens() cli.Command {
+	return cli.Command{
+		Name:    "tokens",
+		Aliases: []string{"token"},
+		Usage:   "manage your personal access tokens",
+		Subcommands: []cli.Command{
+			tokensCreate(),
+			tokensList(),
+			tokensRevoke(),
+		},
+	}
+}
+
+func tokensCreate() cli.Command {
+	const (
+		tokenNameFlagName    = "name"
+		tokenScopeFlagName   = "scope"
+		tokenProjectFlagName = "project"
+		tokenExpiresFlagName = "expires-in-days"
+	)
+
+	return cli.Command{
+		Name:  "create",
+		Usage: "create a personal access token, which is only displayed once",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  tokenNameFlagName,
+				Usage: "specify the name of the token",
+			},
+			cli.StringSliceFlag{
+				Name: tokenScopeFlagName,
+				Usage: fmt.Sprintf("specify a scope for the token, one of '%s', '%s', or '%s' (can be specified multiple times)",
+					user.AccessTokenScopeReadOnly, user.AccessTokenScopePatchSubmit, user.AccessTokenScopeAll),
+			},
+			cli.StringSliceFlag{
+				Name:  joinFlagNames(tokenProjectFlagName, "p"),
+				Usage: "restrict the token to a project (can be specified multiple times)",
+			},
+			cli.IntFlag{
+				Name:  tokenExpiresFlagName,
+				Value: 90,
+				Usage: "specify the number of days until the token expires",
+			},
+		},
+		Before: mergeBeforeFuncs(
+			setPlainLogger,
+			func(c *cli.Context) error {
+				if c.String(tokenNameFlagName) == "" {
+					return errors.New("token name cannot be empty")
+				}
+				if len(c.StringSlice(tokenScopeFlagName)) == 0 {
+					return errors.New("must specify at least one scope")
+				}
+				if c.Int(tokenExpiresFlagName) <= 0 {
+					return errors.New("token expiration must be at least one day")
+				}
+				return nil
+			}),
+		Action: func(c *cli.Context) error {
+			confPath := c.Parent().Parent().String(confFlagName)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			conf, err := NewClientSettings(confPath)
+			if err != nil {
+				return errors.Wrap(err, "loading configuration")
+			}
+
+			client, err := conf.setupRestCommunicator(ctx, true)
+			if err != nil {
+				return errors.Wrap(err, "setting up REST communicator")
+			}
+			defer client.Close()
+
+			expiresAt := time.Now().Add(time.Duration(c.Int(tokenExpiresFlagName)) * 24 * time.Hour)
+			token, err := client.CreateAccessToken(ctx, restmodel.APIAccessToken{
+				Name:      utility.ToStringPtr(c.String(tokenNameFlagName)),
+				Scopes:    c.StringSlice(tokenScopeFlagName),
+				Projects:  c.StringSlice(tokenProjectFlagName),
+				ExpiresAt: &expiresAt,
+			})
+			if err != nil {
+				return errors.Wrap(err, "creating access token")
+			}
+
+			grip.Infof("Created access token '%s' (ID '%s'), which expires at %s.",
+				utility.FromStringPtr(token.Name), utility.FromStringPtr(token.Id), utility.FromTimePtr(token.ExpiresAt).Format(time.RFC3339))
+			grip.Info("Store the token securely; it will not be shown again:")
+			grip.Info(utility.FromStringPtr(token.Token))
+
+			return nil
+		},
+	}
+}
+
+func tokensList() cli.Command {
+	return cli.Command{
+		Name:   "list",
+		Usage:  "list all personal access tokens for the current user",
+		Before: setPlainLogger,
+		Action: func(c *cli.Context) error {
+			confPath := c.Parent().Parent().String(confFlagName)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			conf, err := NewClientSettings(confPath)
+			if err != nil {
+				return errors.Wrap(err, "loading configuration")
+			}
+
+			client, err := conf.setupRestCommunicator(ctx, false)
+			if err != nil {
+				return errors.Wrap(err, "setting up REST communicator")
+			}
+			defer client.Close()
+
+			tokens, err := client.GetAccessTokens(ctx)
+			if err != nil {
+				return errors.Wrap(err, "fetching access tokens")
+			}
+
+			if len(tokens) == 0 {
+				grip.Info("No access tokens found")
+				return nil
+			}
+
+			t := tabby.New()
+			t.AddHeader("Id", "Name", "Scopes", "Projects", "Expires", "Last Used")
+			for _, token := range tokens {
+				lastUsed := "never"
+				if lastUsedAt := utility.FromTimePtr(token.LastUsedAt); !utility.IsZeroTime(lastUsedAt) {
+					lastUsed = lastUsedAt.Format(time.RFC3339)
+				}
+				t.AddLine(utility.FromStringPtr(token.Id), utility.FromStringPtr(token.Name), strings.Join(token.Scopes, ","),
+					strings.Join(token.Projects, ","), utility.FromTimePtr(token.ExpiresAt).Format(time.RFC3339), lastUsed)
+			}
+			t.Print()
+
+			return nil
+		},
+	}
+}
+
+func tokensRevoke() cli.Command {
+	return cli.Command{
+		Name:  "revoke",
+		Usage: "revoke a personal access token by ID",
+		Before: mergeBeforeFuncs(
+			setPlainLogger,
+			func(c *cli.Context) error {
+				if c.NArg() != 1 || c.Args().Get(0) == "" {
+					return errors.New("must specify exactly one token ID to revoke")
+				}
+				return nil
+			}),
+		Action: func(c *cli.Context) error {
+			confPath := c.Parent().Parent().String(confFlagName)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			conf, err := NewClientSettings(confPath)
+			if err != nil {
+				return errors.Wrap(err, "loading configuration")
+			}
+
+			client, err := conf.setupRestCommunicator(ctx, true)
+			if err != nil {
+				return errors.Wrap(err, "setting up REST communicator")
+			}
+			defer client.Close()
+
+			tokenID := c.Args().Get(0)
+			if err := client.RevokeAccessToken(ctx, tokenID); err != nil {
+				return errors.Wrap(err, "revoking access token")
+			}
+
+			grip.Infof("Successfully revoked access token '%s'", tokenID)
+
+			return nil
+		},
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/evergreen-ci/evergreen/util"
-
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/validator"
 	"github.com/mongodb/grip"
@@ -18,6 +16,8 @@ import (
 )
 
 func Validate() cli.Command {
+	const fixFlagName = "fix"
+
 	return cli.Command{
 		Name:  "validate",
 		Usage: "verify that an evergreen project config is valid",
@@ -33,6 +33,11 @@ func Validate() cli.Command {
 		}, cli.StringFlag{
 			Name:  joinFlagNames(projectFlagName, "p"),
 			Usage: "specify project identifier in order to run validation requiring project settings",
+		}, cli.BoolFlag{
+			Name: fixFlagName,
+			Usage: "fix issues that can be corrected automatically, such as deprecated commands and unused functions and tasks, " +
+				"and format the file before validating it (only use this on the project's main configuration file; " +
+				"unused functions and tasks are not removed when validating a directory)",
 		}),
 		Before: mergeBeforeFuncs(autoUpdateCLI, setPlainLogger, requirePathFlag),
 		Action: func(c *cli.Context) error {
@@ -41,6 +46,7 @@ func Validate() cli.Command {
 			quiet := c.Bool(quietFlagName)
 			long := c.Bool(longFlagName)
 			projectID := c.String(projectFlagName)
+			fix := c.Bool(fixFlagName)
 			localModulePaths := c.StringSlice(localModulesFlagName)
 			localModuleMap, err := getLocalModulesFromInput(localModulePaths)
 			if err != nil {
@@ -83,14 +89,17 @@ func Validate() cli.Command {
 				if err != nil {
 					return errors.Wrapf(err, "reading directory '%s'", path)
 				}
+				// Each file in the directory is fixed on its own, so a
+				// definition that looks unused in one file may be used by
+				// another file that includes it.
 				catcher := grip.NewSimpleCatcher()
 				for _, file := range files {
-					catcher.Add(validateFile(filepath.Join(path, file.Name()), ac, quiet, long, localModuleMap, projectID))
+					catcher.Add(validateFile(filepath.Join(path, file.Name()), ac, quiet, long, fix, false, localModuleMap, projectID))
 				}
 				return catcher.Resolve()
 			}
 
-			return validateFile(path, ac, quiet, long, localModuleMap, projectID)
+			return validateFile(path, ac, quiet, long, fix, true, localModuleMap, projectID)
 		},
 	}
 }
@@ -108,7 +117,7 @@ func getLocalModulesFromInput(localModulePaths []string) (map[string]string, err
 	return moduleMap, catcher.Resolve()
 }
 
-func validateFile(path string, ac *legacyClient, quiet, includeLong bool, localModuleMap map[string]string, projectID string) error {
+func validateFile(path string, ac *legacyClient, quiet, includeLong, fix, removeUnused bool, localModuleMap map[string]string, projectID string) error {
 	confFile, err := os.ReadFile(path)
 	if err != nil {
 		return errors.Wrapf(err, "reading file '%s'", path)
@@ -122,12 +131,29 @@ func validateFile(path string, ac *legacyClient, quiet, includeLong bool, localM
 	if !quiet {
 		opts.UnmarshalStrict = true
 	}
-	pp, pc, validationErrs := loadProjectIntoWithValidation(ctx, confFile, opts, project)
+	pp, pc, validationErrs := validator.LoadProjectIntoWithValidation(ctx, confFile, opts, project)
 	grip.Info(validationErrs)
 	if validationErrs.HasError() {
 		return errors.Errorf("%s is an invalid configuration", path)
 	}
 
+	if fix {
+		usedBy := project
+		if !removeUnused {
+			usedBy = nil
+		}
+		confFile, err = fixFile(ctx, path, confFile, usedBy, opts)
+		if err != nil {
+			return errors.Wrapf(err, "fixing file '%s'", path)
+		}
+		project = &model.Project{}
+		pp, pc, validationErrs = validator.LoadProjectIntoWithValidation(ctx, confFile, opts, project)
+		if validationErrs.HasError() {
+			grip.Info(validationErrs)
+			return errors.Errorf("%s is an invalid configuration", path)
+		}
+	}
+
 	projectYaml, err := yaml.Marshal(pp)
 	if err != nil {
 		return errors.Wrapf(err, "marshalling parser project into YAML")
@@ -158,38 +184,28 @@ func validateFile(path string, ac *legacyClient, quiet, includeLong bool, localM
 	return nil
 }
 
-// loadProjectIntoWithValidation returns a warning (instead of an error) if there's an error with unmarshalling strictly
-func loadProjectIntoWithValidation(ctx context.Context, data []byte, opts *model.GetProjectOpts,
-	project *model.Project) (*model.ParserProject, *model.ProjectConfig, validator.ValidationErrors) {
-	errs := validator.ValidationErrors{}
-	// We validate the project config regardless if version control is disabled for the project
-	// to ensure that the config will remain valid if it is turned on.
-	pc, err := model.CreateProjectConfig(data, "")
+// fixFile fixes the issues in the project configuration file that can be
+// corrected automatically and writes the result back to the file. Unused
+// functions and tasks are only removed if the project is given. The file is
+// left unchanged if the fixed configuration fails to load.
+func fixFile(ctx context.Context, path string, confFile []byte, project *model.Project, opts *model.GetProjectOpts) ([]byte, error) {
+	fixed, fixes, err := validator.FixProjectYAML(confFile, project)
 	if err != nil {
-		errs = append(errs, validator.ValidationError{
-			Level:   validator.Error,
-			Message: err.Error(),
-		})
+		return nil, err
 	}
-	pp, err := model.LoadProjectInto(ctx, data, opts, "", project)
-	if err != nil {
-		// If the error came from unmarshalling strict, try it again without strict to verify if
-		// it's a legitimate unmarshal error or just an error from strict (which should be a warning)
-		if strings.Contains(err.Error(), util.UnmarshalStrictError) {
-			opts.UnmarshalStrict = false
-			pp, err2 := model.LoadProjectInto(ctx, data, opts, "", project)
-			if err2 == nil {
-				errs = append(errs, validator.ValidationError{
-					Level:   validator.Warning,
-					Message: errors.Wrap(err, "strict unmarshalling YAML").Error(),
-				})
-				return pp, pc, errs
-			}
-		}
-		errs = append(errs, validator.ValidationError{
-			Level:   validator.Error,
-			Message: err.Error(),
-		})
+	if bytes.Equal(fixed, confFile) {
+		return confFile, nil
+	}
+	if _, _, errs := validator.LoadProjectIntoWithValidation(ctx, fixed, opts, &model.Project{}); errs.HasError() {
+		return nil, errors.Errorf("fixed configuration is invalid: %s", validator.ValidationErrorsToString(errs))
+	}
+	if err = writeProjectFile(path, fixed); err != nil {
+		return nil, errors.Wrap(err, "writing fixed configuration")
+	}
+
+	for _, fix := range fixes {
+		grip.Infof("%s: %s", path, fix)
 	}
-	return pp, pc, errs
+	grip.Infof("%s: fixed %d issue(s) and formatted the file", path, len(fixes))
+	return fixed, nil
 }
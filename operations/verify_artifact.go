@@ -0,0 +1,196 @@
+package operations
+
+import (
+	"context"
+	"crypto/ed25519"
+	"os"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	restmodel "github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+func VerifyArtifact() cli.Command {
+	const (
+		taskFlagName      = "task"
+		executionFlagName = "execution"
+		nameFlagName      = "name"
+		publicKeyFlagName = "public-key"
+		allowPatchesFlag  = "allow-patches"
+	)
+
+	return cli.Command{
+		Name:      "verify-artifact",
+		Usage:     "verify that a local file matches the signed provenance of a file attached by a task",
+		ArgsUsage: "<file>",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  joinFlagNames(taskFlagName, "t"),
+				Usage: "the ID of the task that attached the file",
+			},
+			cli.IntFlag{
+				Name:  executionFlagName,
+				Usage: "the execution of the task (defaults to the latest)",
+			},
+			cli.StringFlag{
+				Name:  joinFlagNames(nameFlagName, "n"),
+				Usage: "the name the file was attached with, if the task attached more than one file with the same contents",
+			},
+			cli.StringFlag{
+				Name:  publicKeyFlagName,
+				Usage: "path to a PEM-encoded public key to verify the provenance with (defaults to the key fetched from the Evergreen server)",
+			},
+			cli.BoolFlag{
+				Name:  allowPatchesFlag,
+				Usage: "accept files produced by patch tasks, which can run unreviewed changes",
+			},
+		},
+		Before: mergeBeforeFuncs(
+			setPlainLogger,
+			requireStringFlag(taskFlagName),
+			func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					return errors.New("must specify exactly one file to verify")
+				}
+				return nil
+			}),
+		Action: func(c *cli.Context) error {
+			confPath := c.Parent().String(confFlagName)
+			taskID := c.String(taskFlagName)
+			fileName := c.String(nameFlagName)
+			path := c.Args().First()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			digest, err := util.FileSHA256(path)
+			if err != nil {
+				return err
+			}
+
+			conf, err := NewClientSettings(confPath)
+			if err != nil {
+				return errors.Wrap(err, "loading configuration")
+			}
+			client, err := conf.setupRestCommunicator(ctx, false)
+			if err != nil {
+				return errors.Wrap(err, "setting up REST communicator")
+			}
+			defer client.Close()
+
+			var pub ed25519.PublicKey
+			if keyPath := c.String(publicKeyFlagName); keyPath != "" {
+				data, err := os.ReadFile(keyPath)
+				if err != nil {
+					return errors.Wrapf(err, "reading public key file '%s'", keyPath)
+				}
+				if pub, err = artifact.ParseProvenancePublicKey(data); err != nil {
+					return errors.Wrapf(err, "parsing public key file '%s'", keyPath)
+				}
+			} else {
+				apiKey, err := client.GetProvenancePublicKey(ctx)
+				if err != nil {
+					return errors.Wrap(err, "getting provenance public key")
+				}
+				if pub, err = artifact.ParseProvenancePublicKey([]byte(utility.FromStringPtr(apiKey.PublicKey))); err != nil {
+					return errors.Wrap(err, "parsing provenance public key")
+				}
+			}
+
+			var execution *int
+			if c.IsSet(executionFlagName) {
+				execution = utility.ToIntPtr(c.Int(executionFlagName))
+			}
+			provenance, err := client.GetArtifactProvenance(ctx, taskID, execution)
+			if err != nil {
+				return errors.Wrapf(err, "getting artifact provenance for task '%s'", taskID)
+			}
+
+			statement, err := verifyArtifactProvenance(provenance, pub, taskID, execution, fileName, digest)
+			if err != nil {
+				return errors.Wrapf(err, "verifying file '%s'", path)
+			}
+
+			params := statement.Predicate.BuildDefinition.ExternalParameters
+			if err = checkProvenanceRequester(params.Requester, c.Bool(allowPatchesFlag)); err != nil {
+				return errors.Wrapf(err, "verifying file '%s'", path)
+			}
+			grip.Infof("Verified file '%s' (sha256 '%s') as '%s' from task '%s' (execution %d).", path, digest, statement.Subject[0].Name, params.TaskID, params.Execution)
+			grip.Infof("Project:       %s", params.Project)
+			grip.Infof("Version:       %s", params.Version)
+			grip.Infof("Revision:      %s", params.Revision)
+			grip.Infof("Requester:     %s", params.Requester)
+			grip.Infof("Build variant: %s", params.BuildVariant)
+			grip.Infof("Task:          %s", params.Task)
+			grip.Infof("Distro:        %s", params.Distro)
+			grip.Infof("Commands:      %s", strings.Join(params.Commands, ", "))
+			grip.Infof("Builder:       %s", statement.Predicate.RunDetails.Builder.ID)
+
+			return nil
+		},
+	}
+}
+
+// verifyArtifactProvenance returns the verified statement from the
+// provenance for the file with the given digest and, if it's set, name. The
+// statement must have been signed for the given task and, if it's set,
+// execution.
+func verifyArtifactProvenance(provenance []restmodel.APIArtifactProvenance, pub ed25519.PublicKey, taskID string, execution *int, fileName, digest string) (*artifact.Statement, error) {
+	var lastErr error
+	for _, p := range provenance {
+		if fileName != "" && utility.FromStringPtr(p.FileName) != fileName {
+			continue
+		}
+
+		statement, err := p.Envelope.Verify(pub)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "verifying provenance for file '%s'", utility.FromStringPtr(p.FileName))
+			continue
+		}
+		// Only the signed statement is trusted, not the unsigned fields
+		// that accompany it.
+		params := statement.Predicate.BuildDefinition.ExternalParameters
+		if params.TaskID != taskID {
+			return nil, errors.Errorf("provenance for file '%s' was signed for task '%s', not '%s'", utility.FromStringPtr(p.FileName), params.TaskID, taskID)
+		}
+		if execution != nil && params.Execution != *execution {
+			return nil, errors.Errorf("provenance for file '%s' was signed for execution %d, not %d", utility.FromStringPtr(p.FileName), params.Execution, *execution)
+		}
+		for _, subject := range statement.Subject {
+			if fileName != "" && subject.Name != fileName {
+				continue
+			}
+			if subject.Digest[artifact.SHA256DigestAlgorithm] == digest {
+				statement.Subject = []artifact.Subject{subject}
+				return statement, nil
+			}
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	if fileName != "" {
+		return nil, errors.Errorf("no provenance for file '%s' with sha256 '%s'", fileName, digest)
+	}
+	return nil, errors.Errorf("no provenance for a file with sha256 '%s'", digest)
+}
+
+// checkProvenanceRequester returns an error if the file was produced by a
+// patch task and patches aren't allowed, since a patch can run changes that
+// were never merged.
+func checkProvenanceRequester(requester string, allowPatches bool) error {
+	if requester == "" {
+		return errors.New("provenance does not record the requester")
+	}
+	if evergreen.IsPatchRequester(requester) && !allowPatches {
+		return errors.Errorf("file was produced by a patch task (requester '%s'); pass --allow-patches to accept it", requester)
+	}
+	return nil
+}
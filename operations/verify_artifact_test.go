@@ -0,0 +1,104 @@
+package operations
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	restmodel "github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyArtifactProvenance(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	makeSignedProvenance := func(t *testing.T, taskID string, execution int, name, digest string) restmodel.APIArtifactProvenance {
+		envelope, err := artifact.SignStatement(artifact.Statement{
+			Type:          artifact.InTotoStatementType,
+			Subject:       []artifact.Subject{{Name: name, Digest: map[string]string{artifact.SHA256DigestAlgorithm: digest}}},
+			PredicateType: artifact.SLSAProvenancePredicateType,
+			Predicate: artifact.ProvenancePredicate{
+				BuildDefinition: artifact.BuildDefinition{
+					ExternalParameters: artifact.BuildParameters{
+						TaskID:    taskID,
+						Execution: execution,
+					},
+				},
+			},
+		}, key)
+		require.NoError(t, err)
+		return restmodel.APIArtifactProvenance{
+			FileName: utility.ToStringPtr(name),
+			SHA256:   utility.ToStringPtr(digest),
+			Envelope: *envelope,
+		}
+	}
+	makeProvenance := func(t *testing.T, name, digest string) restmodel.APIArtifactProvenance {
+		return makeSignedProvenance(t, "task", 1, name, digest)
+	}
+
+	t.Run("MatchesDigest", func(t *testing.T) {
+		provenance := []restmodel.APIArtifactProvenance{makeProvenance(t, "a", "aaa"), makeProvenance(t, "b", "bbb")}
+		statement, err := verifyArtifactProvenance(provenance, pub, "task", nil, "", "bbb")
+		require.NoError(t, err)
+		require.Len(t, statement.Subject, 1)
+		assert.Equal(t, "b", statement.Subject[0].Name)
+	})
+	t.Run("MatchesNameAndDigest", func(t *testing.T) {
+		provenance := []restmodel.APIArtifactProvenance{makeProvenance(t, "a", "aaa"), makeProvenance(t, "b", "aaa")}
+		statement, err := verifyArtifactProvenance(provenance, pub, "task", nil, "b", "aaa")
+		require.NoError(t, err)
+		assert.Equal(t, "b", statement.Subject[0].Name)
+
+		_, err = verifyArtifactProvenance(provenance, pub, "task", nil, "c", "aaa")
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithDifferentDigest", func(t *testing.T) {
+		provenance := []restmodel.APIArtifactProvenance{makeProvenance(t, "a", "aaa")}
+		_, err := verifyArtifactProvenance(provenance, pub, "task", nil, "", "bbb")
+		assert.Error(t, err)
+	})
+	t.Run("IgnoresUnsignedDigest", func(t *testing.T) {
+		p := makeProvenance(t, "a", "aaa")
+		p.SHA256 = utility.ToStringPtr("bbb")
+		_, err := verifyArtifactProvenance([]restmodel.APIArtifactProvenance{p}, pub, "task", nil, "", "bbb")
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithDifferentKey", func(t *testing.T) {
+		otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		provenance := []restmodel.APIArtifactProvenance{makeProvenance(t, "a", "aaa")}
+		_, err = verifyArtifactProvenance(provenance, otherPub, "task", nil, "", "aaa")
+		assert.Error(t, err)
+	})
+	t.Run("MatchesExecution", func(t *testing.T) {
+		provenance := []restmodel.APIArtifactProvenance{makeProvenance(t, "a", "aaa")}
+		statement, err := verifyArtifactProvenance(provenance, pub, "task", utility.ToIntPtr(1), "", "aaa")
+		require.NoError(t, err)
+		assert.Equal(t, "a", statement.Subject[0].Name)
+	})
+	t.Run("FailsWithDifferentTask", func(t *testing.T) {
+		provenance := []restmodel.APIArtifactProvenance{makeSignedProvenance(t, "other_task", 1, "a", "aaa")}
+		_, err := verifyArtifactProvenance(provenance, pub, "task", nil, "", "aaa")
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithDifferentExecution", func(t *testing.T) {
+		provenance := []restmodel.APIArtifactProvenance{makeSignedProvenance(t, "task", 0, "a", "aaa")}
+		_, err := verifyArtifactProvenance(provenance, pub, "task", utility.ToIntPtr(1), "", "aaa")
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckProvenanceRequester(t *testing.T) {
+	assert.NoError(t, checkProvenanceRequester(evergreen.RepotrackerVersionRequester, false))
+	assert.NoError(t, checkProvenanceRequester(evergreen.GitTagRequester, false))
+	assert.Error(t, checkProvenanceRequester(evergreen.PatchVersionRequester, false))
+	assert.Error(t, checkProvenanceRequester(evergreen.GithubPRRequester, false))
+	assert.NoError(t, checkProvenanceRequester(evergreen.PatchVersionRequester, true))
+	assert.Error(t, checkProvenanceRequester("", true))
+}
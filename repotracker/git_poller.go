@@ -0,0 +1,225 @@
+package repotracker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	// gitFetchTimeout is generous because the first fetch of a repository
+	// clones its entire history.
+	gitFetchTimeout = 10 * time.Minute
+	gitQueryTimeout = 30 * time.Second
+)
+
+// GitRepositoryPoller is a RepoPoller for repositories that aren't hosted on
+// GitHub. It fetches the project's branch from the project ref's git URL into
+// a local bare repository and reads commits from there using git, so it works
+// with any remote that git can fetch from over SSH or HTTPS, as well as with
+// local repositories.
+type GitRepositoryPoller struct {
+	ProjectRef *model.ProjectRef
+	// Dir is the local bare repository that the branch is fetched into.
+	Dir string
+}
+
+// NewGitRepositoryPoller constructs and returns a pointer to a
+// GitRepositoryPoller struct.
+func NewGitRepositoryPoller(projectRef *model.ProjectRef, dir string) *GitRepositoryPoller {
+	return &GitRepositoryPoller{
+		ProjectRef: projectRef,
+		Dir:        dir,
+	}
+}
+
+// defaultGitRepositoryDir returns the directory that a project's repository is
+// fetched into if no other directory is given.
+func defaultGitRepositoryDir(projectRef *model.ProjectRef) string {
+	return filepath.Join(os.TempDir(), "evergreen-repotracker", projectRef.Id)
+}
+
+func (p *GitRepositoryPoller) branchRef() string {
+	return thirdparty.GitRemoteBranchRef(p.ProjectRef.Branch)
+}
+
+// fetch updates the local repository with the latest commits on the branch.
+func (p *GitRepositoryPoller) fetch(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, gitFetchTimeout)
+	defer cancel()
+
+	if p.ProjectRef.GitURL == "" {
+		return errors.Errorf("project ref '%s' has no git URL", p.ProjectRef.Id)
+	}
+	// The git URL is checked again in case local repositories were
+	// disallowed after it was set.
+	if err := p.ProjectRef.ValidateGitURL(); err != nil {
+		return errors.Wrapf(err, "project ref '%s'", p.ProjectRef.Id)
+	}
+	return errors.Wrapf(thirdparty.GitFetchBranch(ctx, p.Dir, p.ProjectRef.GitURL, p.ProjectRef.Branch),
+		"fetching repository for project ref '%s'", p.ProjectRef.Id)
+}
+
+// gitCommitToRevision converts a git commit to Evergreen's revision model.
+func gitCommitToRevision(commit thirdparty.GitCommit) model.Revision {
+	return model.Revision{
+		Author:          commit.AuthorName,
+		AuthorEmail:     commit.AuthorEmail,
+		RevisionMessage: commit.Message,
+		Revision:        commit.Hash,
+		CreateTime:      commit.CommitTime,
+	}
+}
+
+func gitCommitsToRevisions(commits []thirdparty.GitCommit) []model.Revision {
+	revisions := make([]model.Revision, 0, len(commits))
+	for _, commit := range commits {
+		revisions = append(revisions, gitCommitToRevision(commit))
+	}
+	return revisions
+}
+
+// GetRemoteConfig reads the project's configuration file, and any files it
+// includes, from the local repository as at the given revision.
+func (p *GitRepositoryPoller) GetRemoteConfig(ctx context.Context, projectFileRevision string) (model.ProjectInfo, error) {
+	if !thirdparty.GitHasCommit(ctx, p.Dir, projectFileRevision) {
+		if err := p.fetch(ctx); err != nil {
+			return model.ProjectInfo{}, err
+		}
+	}
+	opts := model.GetProjectOpts{
+		Ref:          p.ProjectRef,
+		RemotePath:   p.ProjectRef.RemotePath,
+		Revision:     projectFileRevision,
+		ReadFileFrom: model.ReadFromGit,
+		GitDir:       p.Dir,
+	}
+	return model.GetProjectFromFile(ctx, opts)
+}
+
+// GetChangedFiles returns the paths of the files modified by the given
+// revision.
+func (p *GitRepositoryPoller) GetChangedFiles(ctx context.Context, commitRevision string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitQueryTimeout)
+	defer cancel()
+
+	files, err := thirdparty.GitChangedFiles(ctx, p.Dir, commitRevision)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading commit '%s'", commitRevision)
+	}
+	return files, nil
+}
+
+// GetRevisionsSince fetches the branch and returns the commits made after
+// 'revision', in order of most to least recent, up to maxRevisionsToSearch
+// commits. If the revision is no longer on the branch (e.g. because the branch
+// was force pushed), it adds the merge base of the revision and the branch as
+// the base revision instead, like GithubRepositoryPoller.
+func (p *GitRepositoryPoller) GetRevisionsSince(revision string, maxRevisionsToSearch int) ([]model.Revision, error) {
+	ctx := context.Background()
+	if err := p.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitQueryTimeout)
+	defer cancel()
+
+	onBranch := false
+	if thirdparty.GitHasCommit(ctx, p.Dir, revision) {
+		var err error
+		onBranch, err = thirdparty.GitIsAncestor(ctx, p.Dir, revision, p.branchRef())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if onBranch {
+		commits, err := thirdparty.GitLog(ctx, p.Dir, revision+".."+p.branchRef(), maxRevisionsToSearch)
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) == 0 {
+			grip.Info(message.Fields{
+				"source":             "git poller",
+				"message":            "no new revisions",
+				"last_revision":      revision,
+				"project":            p.ProjectRef.Id,
+				"project_identifier": p.ProjectRef.Identifier,
+			})
+		}
+		return gitCommitsToRevisions(commits), nil
+	}
+
+	if len(revision) < 10 {
+		return nil, errors.Errorf("invalid revision '%s'", revision)
+	}
+	var baseRevision string
+	err := errors.Errorf("revision '%s' not found", revision)
+	if thirdparty.GitHasCommit(ctx, p.Dir, revision) {
+		baseRevision, err = thirdparty.GitMergeBase(ctx, p.Dir, revision, p.branchRef())
+	}
+	if err != nil {
+		// unable to get merge base commit so set projectRef revision details with a blank base revision
+		revisionDetails := &model.RepositoryErrorDetails{
+			Exists:            true,
+			InvalidRevision:   revision[:10],
+			MergeBaseRevision: "",
+		}
+		revisionError := errors.Wrapf(err,
+			"unable to find a suggested merge base commit for revision '%s', must fix on projects settings page",
+			revision)
+		if err := p.ProjectRef.SetRepotrackerError(revisionDetails); err != nil {
+			return []model.Revision{}, errors.Wrap(err, "setting repotracker error")
+		}
+		return []model.Revision{}, revisionError
+	}
+
+	// automatically set the newly found base revision as base revision and append revisions
+	commits, err := thirdparty.GitLog(ctx, p.Dir, baseRevision+".."+p.branchRef(), maxRevisionsToSearch)
+	if err != nil {
+		return nil, err
+	}
+	base, err := thirdparty.GitLog(ctx, p.Dir, baseRevision, 1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading base commit '%s'", baseRevision)
+	}
+	revisions := gitCommitsToRevisions(append(commits, base...))
+
+	grip.Info(message.Fields{
+		"message":            "updating last repo revision for project",
+		"source":             "git poller",
+		"old_revision":       revision,
+		"new_revision":       baseRevision,
+		"project":            p.ProjectRef.Id,
+		"project_identifier": p.ProjectRef.Identifier,
+	})
+	if err = model.UpdateLastRevision(p.ProjectRef.Id, baseRevision); err != nil {
+		return nil, errors.Wrapf(err, "updating last revision to base revision '%s'", baseRevision)
+	}
+
+	return revisions, nil
+}
+
+// GetRecentRevisions fetches the branch and returns its most recent
+// 'maxRevisions' commits.
+func (p *GitRepositoryPoller) GetRecentRevisions(maxRevisions int) ([]model.Revision, error) {
+	ctx := context.Background()
+	if err := p.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitQueryTimeout)
+	defer cancel()
+
+	commits, err := thirdparty.GitLog(ctx, p.Dir, p.branchRef(), maxRevisions)
+	if err != nil {
+		return nil, err
+	}
+	return gitCommitsToRevisions(commits), nil
+}
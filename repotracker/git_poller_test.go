@@ -0,0 +1,159 @@
+package repotracker
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitTestRepo is a local git repository that a GitRepositoryPoller can poll.
+type gitTestRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newGitTestRepo(t *testing.T) *gitTestRepo {
+	r := &gitTestRepo{t: t, dir: t.TempDir()}
+	r.git("init", "--quiet", "--initial-branch", "main")
+	r.git("config", "user.name", "Test User")
+	r.git("config", "user.email", "test@example.com")
+	return r
+}
+
+func (r *gitTestRepo) git(args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(r.t, err, string(out))
+	return string(out)
+}
+
+// commit writes the files and commits them, returning the new commit's hash.
+func (r *gitTestRepo) commit(message string, files map[string]string) string {
+	for name, content := range files {
+		path := filepath.Join(r.dir, name)
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(r.t, os.WriteFile(path, []byte(content), 0644))
+		r.git("add", name)
+	}
+	r.git("commit", "--quiet", "-m", message)
+	return r.head()
+}
+
+func (r *gitTestRepo) head() string {
+	out := r.git("rev-parse", "HEAD")
+	return out[:len(out)-1]
+}
+
+func TestGitRepositoryPoller(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const config = `
+include:
+  - filename: tasks.yml
+buildvariants:
+  - name: bv
+    display_name: bv
+    run_on: [d]
+    tasks: [compile]
+`
+	const tasks = `
+tasks:
+  - name: compile
+`
+
+	setup := func(t *testing.T) (*gitTestRepo, *GitRepositoryPoller, []string) {
+		repo := newGitTestRepo(t)
+		commits := []string{
+			repo.commit("first", map[string]string{"evergreen.yml": config, "tasks.yml": tasks}),
+			repo.commit("second", map[string]string{"src/a.go": "package a"}),
+			repo.commit("third", map[string]string{"src/a.go": "package a\n", "src/b.go": "package b"}),
+		}
+		poller := NewGitRepositoryPoller(&model.ProjectRef{
+			Id:         "project",
+			Identifier: "project",
+			Branch:     "main",
+			RemotePath: "evergreen.yml",
+			GitURL:     repo.dir,
+		}, filepath.Join(t.TempDir(), "repo.git"))
+		return repo, poller, commits
+	}
+
+	t.Run("GetRecentRevisions", func(t *testing.T) {
+		_, poller, commits := setup(t)
+
+		revisions, err := poller.GetRecentRevisions(2)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.Equal(t, commits[2], revisions[0].Revision)
+		assert.Equal(t, "third", revisions[0].RevisionMessage)
+		assert.Equal(t, "Test User", revisions[0].Author)
+		assert.Equal(t, "test@example.com", revisions[0].AuthorEmail)
+		assert.False(t, revisions[0].CreateTime.IsZero())
+		assert.Equal(t, commits[1], revisions[1].Revision)
+	})
+	t.Run("GetRevisionsSince", func(t *testing.T) {
+		repo, poller, commits := setup(t)
+
+		revisions, err := poller.GetRevisionsSince(commits[0], 10)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.Equal(t, commits[2], revisions[0].Revision)
+		assert.Equal(t, commits[1], revisions[1].Revision)
+
+		revisions, err = poller.GetRevisionsSince(commits[2], 10)
+		require.NoError(t, err)
+		assert.Empty(t, revisions)
+
+		latest := repo.commit("fourth", map[string]string{"README": "readme"})
+		revisions, err = poller.GetRevisionsSince(commits[2], 10)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, latest, revisions[0].Revision)
+	})
+	t.Run("GetRevisionsSinceLimitsRevisions", func(t *testing.T) {
+		_, poller, commits := setup(t)
+
+		revisions, err := poller.GetRevisionsSince(commits[0], 1)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, commits[2], revisions[0].Revision)
+	})
+	t.Run("GetChangedFiles", func(t *testing.T) {
+		_, poller, commits := setup(t)
+		_, err := poller.GetRecentRevisions(1)
+		require.NoError(t, err)
+
+		files, err := poller.GetChangedFiles(ctx, commits[2])
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"src/a.go", "src/b.go"}, files)
+
+		files, err = poller.GetChangedFiles(ctx, commits[0])
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"evergreen.yml", "tasks.yml"}, files)
+	})
+	t.Run("GetRemoteConfig", func(t *testing.T) {
+		_, poller, commits := setup(t)
+
+		info, err := poller.GetRemoteConfig(ctx, commits[1])
+		require.NoError(t, err)
+		require.NotNil(t, info.Project)
+		assert.NotNil(t, info.Project.FindProjectTask("compile"))
+		assert.NotNil(t, info.Project.FindBuildVariant("bv"))
+	})
+	t.Run("FailsWithoutGitURL", func(t *testing.T) {
+		poller := NewGitRepositoryPoller(&model.ProjectRef{Id: "project", Branch: "main"}, t.TempDir())
+		_, err := poller.GetRecentRevisions(1)
+		assert.Error(t, err)
+	})
+}
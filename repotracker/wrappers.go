@@ -22,6 +22,14 @@ const (
 )
 
 func getTracker(conf *evergreen.Settings, project model.ProjectRef) (*RepoTracker, error) {
+	if project.GitURL != "" {
+		return &RepoTracker{
+			Settings:   conf,
+			ProjectRef: &project,
+			RepoPoller: NewGitRepositoryPoller(&project, defaultGitRepositoryDir(&project)),
+		}, nil
+	}
+
 	token, err := conf.GetGithubOauthToken()
 	if err != nil {
 		grip.Warning(message.Fields{
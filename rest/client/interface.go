@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -41,6 +42,9 @@ type Communicator interface {
 	GetSettings(context.Context) (*evergreen.Settings, error)
 	UpdateSettings(context.Context, *restmodel.APIAdminSettings) (*restmodel.APIAdminSettings, error)
 	GetEvents(context.Context, time.Time, int) ([]interface{}, error)
+	// ExportAuditLog returns the audit log entries matching the query,
+	// exported in the given format.
+	ExportAuditLog(context.Context, model.AuditLogQuery, string) (io.ReadCloser, error)
 	RevertSettings(context.Context, string) error
 	ExecuteOnDistro(ctx context.Context, distro string, opts restmodel.APIDistroScriptOptions) (hostIDs []string, err error)
 	GetServiceUsers(ctx context.Context) ([]restmodel.APIDBUser, error)
@@ -80,6 +84,28 @@ type Communicator interface {
 	// Delete a key with specified name from the current authenticated user
 	DeletePublicKey(context.Context, string) error
 
+	// GetAccessTokens returns the current authenticated user's personal
+	// access tokens.
+	GetAccessTokens(context.Context) ([]restmodel.APIAccessToken, error)
+
+	// CreateAccessToken creates a personal access token for the current
+	// authenticated user. The returned token is the only time the plaintext
+	// token is available.
+	CreateAccessToken(context.Context, restmodel.APIAccessToken) (*restmodel.APIAccessToken, error)
+
+	// RevokeAccessToken revokes the current authenticated user's personal
+	// access token with the given ID.
+	RevokeAccessToken(context.Context, string) error
+
+	// GetArtifactProvenance returns the signed provenance for the files that
+	// the task attached. If the execution is nil, it returns the provenance
+	// for the task's latest execution.
+	GetArtifactProvenance(ctx context.Context, taskID string, execution *int) ([]restmodel.APIArtifactProvenance, error)
+
+	// GetProvenancePublicKey returns the public key that verifies provenance
+	// statements.
+	GetProvenancePublicKey(context.Context) (*restmodel.APIProvenancePublicKey, error)
+
 	// List variant/task aliases
 	ListAliases(context.Context, string) ([]model.ProjectAlias, error)
 	ListPatchTriggerAliases(context.Context, string) ([]string, error)
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -651,6 +653,48 @@ func (c *communicatorImpl) GetEvents(ctx context.Context, ts time.Time, limit in
 	return events, nil
 }
 
+func (c *communicatorImpl) ExportAuditLog(ctx context.Context, q serviceModel.AuditLogQuery, format string) (io.ReadCloser, error) {
+	params := url.Values{}
+	params.Set("format", format)
+	if q.Actor != "" {
+		params.Set("actor", q.Actor)
+	}
+	if q.ResourceType != "" {
+		params.Set("resource_type", q.ResourceType)
+	}
+	if q.ResourceId != "" {
+		params.Set("resource_id", q.ResourceId)
+	}
+	if !utility.IsZeroTime(q.StartTime) {
+		params.Set("start_time", q.StartTime.Format(time.RFC3339))
+	}
+	if !utility.IsZeroTime(q.EndTime) {
+		params.Set("end_time", q.EndTime.Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	info := requestInfo{
+		method: http.MethodGet,
+		path:   "admin/audit_log?" + params.Encode(),
+	}
+	resp, err := c.request(ctx, info, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "sending request to export audit log")
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		defer resp.Body.Close()
+		return nil, util.RespErrorf(resp, AuthError)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, util.RespErrorf(resp, "exporting audit log")
+	}
+
+	return resp.Body, nil
+}
+
 func (c *communicatorImpl) RevertSettings(ctx context.Context, guid string) error {
 	info := requestInfo{
 		method: http.MethodPost,
@@ -876,6 +920,140 @@ func (c *communicatorImpl) DeletePublicKey(ctx context.Context, keyName string)
 	return nil
 }
 
+func (c *communicatorImpl) GetAccessTokens(ctx context.Context) ([]model.APIAccessToken, error) {
+	info := requestInfo{
+		method: http.MethodGet,
+		path:   "user/tokens",
+	}
+
+	resp, err := c.request(ctx, info, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "sending request to get access tokens for user '%s'", c.apiUser)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, util.RespErrorf(resp, AuthError)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, util.RespErrorf(resp, "getting access tokens for user '%s'", c.apiUser)
+	}
+
+	tokens := []model.APIAccessToken{}
+	if err = utility.ReadJSON(resp.Body, &tokens); err != nil {
+		return nil, errors.Wrap(err, "reading JSON response body")
+	}
+
+	return tokens, nil
+}
+
+func (c *communicatorImpl) CreateAccessToken(ctx context.Context, token model.APIAccessToken) (*model.APIAccessToken, error) {
+	info := requestInfo{
+		method: http.MethodPost,
+		path:   "user/tokens",
+	}
+
+	resp, err := c.request(ctx, info, token)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sending request to create access token '%s'", utility.FromStringPtr(token.Name))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, util.RespErrorf(resp, AuthError)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, util.RespErrorf(resp, "creating access token '%s'", utility.FromStringPtr(token.Name))
+	}
+
+	created := &model.APIAccessToken{}
+	if err = utility.ReadJSON(resp.Body, created); err != nil {
+		return nil, errors.Wrap(err, "reading JSON response body")
+	}
+
+	return created, nil
+}
+
+func (c *communicatorImpl) RevokeAccessToken(ctx context.Context, tokenID string) error {
+	info := requestInfo{
+		method: http.MethodDelete,
+		path:   "user/tokens/" + tokenID,
+	}
+
+	resp, err := c.request(ctx, info, "")
+	if err != nil {
+		return errors.Wrapf(err, "sending request to revoke access token '%s'", tokenID)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return util.RespErrorf(resp, AuthError)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return util.RespErrorf(resp, "revoking access token '%s'", tokenID)
+	}
+
+	return nil
+}
+
+func (c *communicatorImpl) GetArtifactProvenance(ctx context.Context, taskID string, execution *int) ([]model.APIArtifactProvenance, error) {
+	path := fmt.Sprintf("tasks/%s/artifacts/provenance", taskID)
+	if execution != nil {
+		path = fmt.Sprintf("%s?execution=%d", path, *execution)
+	}
+	info := requestInfo{
+		method: http.MethodGet,
+		path:   path,
+	}
+
+	resp, err := c.request(ctx, info, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "sending request to get artifact provenance for task '%s'", taskID)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, util.RespErrorf(resp, AuthError)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, util.RespErrorf(resp, "getting artifact provenance for task '%s'", taskID)
+	}
+
+	provenance := []model.APIArtifactProvenance{}
+	if err = utility.ReadJSON(resp.Body, &provenance); err != nil {
+		return nil, errors.Wrap(err, "reading JSON response body")
+	}
+
+	return provenance, nil
+}
+
+func (c *communicatorImpl) GetProvenancePublicKey(ctx context.Context) (*model.APIProvenancePublicKey, error) {
+	info := requestInfo{
+		method: http.MethodGet,
+		path:   "artifacts/provenance/public_key",
+	}
+
+	resp, err := c.request(ctx, info, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "sending request to get provenance public key")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, util.RespErrorf(resp, AuthError)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, util.RespErrorf(resp, "getting provenance public key")
+	}
+
+	key := &model.APIProvenancePublicKey{}
+	if err = utility.ReadJSON(resp.Body, key); err != nil {
+		return nil, errors.Wrap(err, "reading JSON response body")
+	}
+
+	return key, nil
+}
+
 func (c *communicatorImpl) ListAliases(ctx context.Context, project string) ([]serviceModel.ProjectAlias, error) {
 	path := fmt.Sprintf("alias/%s", project)
 	info := requestInfo{
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -157,6 +158,9 @@ func (c *Mock) UpdateSettings(ctx context.Context, update *model.APIAdminSetting
 func (c *Mock) GetEvents(ctx context.Context, ts time.Time, limit int) ([]interface{}, error) {
 	return nil, nil
 }
+func (c *Mock) ExportAuditLog(ctx context.Context, q serviceModel.AuditLogQuery, format string) (io.ReadCloser, error) {
+	return nil, errors.New("(c *Mock) ExportAuditLog not implemented")
+}
 func (c *Mock) RevertSettings(ctx context.Context, guid string) error { return nil }
 func (c *Mock) ExecuteOnDistro(context.Context, string, model.APIDistroScriptOptions) ([]string, error) {
 	return nil, nil
@@ -197,6 +201,26 @@ func (c *Mock) DeletePublicKey(ctx context.Context, keyName string) error {
 	return errors.New("(c *Mock) DeletePublicKey not implemented")
 }
 
+func (c *Mock) GetAccessTokens(ctx context.Context) ([]model.APIAccessToken, error) {
+	return nil, errors.New("(c *Mock) GetAccessTokens not implemented")
+}
+
+func (c *Mock) CreateAccessToken(ctx context.Context, token model.APIAccessToken) (*model.APIAccessToken, error) {
+	return nil, errors.New("(c *Mock) CreateAccessToken not implemented")
+}
+
+func (c *Mock) RevokeAccessToken(ctx context.Context, tokenID string) error {
+	return errors.New("(c *Mock) RevokeAccessToken not implemented")
+}
+
+func (c *Mock) GetArtifactProvenance(ctx context.Context, taskID string, execution *int) ([]model.APIArtifactProvenance, error) {
+	return nil, errors.New("(c *Mock) GetArtifactProvenance not implemented")
+}
+
+func (c *Mock) GetProvenancePublicKey(ctx context.Context) (*model.APIProvenancePublicKey, error) {
+	return nil, errors.New("(c *Mock) GetProvenancePublicKey not implemented")
+}
+
 func (c *Mock) ListAliases(ctx context.Context, keyName string) ([]serviceModel.ProjectAlias, error) {
 	return nil, errors.New("(c *Mock) ListAliases not implemented")
 }
@@ -516,6 +516,20 @@ func ConcludeMerge(ctx context.Context, patchID, status string) error {
 	if cq == nil {
 		return errors.Errorf("commit queue for project '%s' not found", p.Project)
 	}
+	// Items batched into the merge patch are merged along with it, so they
+	// conclude with it too. If the merge failed, they're released to be
+	// tested again.
+	members := cq.BatchMembers(patchID)
+	if status == evergreen.MergeTestSucceeded {
+		for _, member := range members {
+			if _, err = cq.Remove(member.Issue); err != nil {
+				return errors.Wrapf(err, "dequeueing batched item '%s' from commit queue", member.Issue)
+			}
+			event.LogCommitQueueConcludeTest(member.Issue, status)
+		}
+	} else if err = cq.ReleaseBatch(patchID); err != nil {
+		return errors.Wrapf(err, "releasing items batched into item '%s'", patchID)
+	}
 	if _, err = cq.Remove(patchID); err != nil {
 		return errors.Wrapf(err, "dequeueing item '%s' from commit queue", patchID)
 	}
@@ -537,7 +551,11 @@ func ConcludeMerge(ctx context.Context, patchID, status string) error {
 	return nil
 }
 
-func GetAdditionalPatches(patchId string) ([]string, error) {
+// GetAdditionalPatches returns the patches whose changes have to be applied
+// before the given commit queue patch's changes. These are the patches for the
+// items that are batched into its merge patch and, unless they're for a merge
+// task, the versions ahead of it in the queue that haven't merged yet.
+func GetAdditionalPatches(patchId string, forMergeTask bool) ([]string, error) {
 	p, err := patch.FindOneId(patchId)
 	if err != nil {
 		return nil, gimlet.ErrorResponse{
@@ -568,7 +586,9 @@ func GetAdditionalPatches(patchId string) ([]string, error) {
 	for _, item := range cq.Queue {
 		if item.Version == patchId {
 			return additionalPatches, nil
-		} else if item.Version != "" {
+		} else if item.BatchedInto == patchId {
+			additionalPatches = append(additionalPatches, item.Issue)
+		} else if item.Version != "" && !forMergeTask {
 			additionalPatches = append(additionalPatches, item.Version)
 		}
 	}
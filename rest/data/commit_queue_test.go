@@ -389,3 +389,70 @@ func TestConcludeMerge(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, queue.Queue, 0)
 }
+
+func TestConcludeMergeForBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	projectID := "evergreen"
+	for status, remaining := range map[string]int{
+		evergreen.MergeTestSucceeded: 0,
+		evergreen.MergeTestFailed:    2,
+	} {
+		require.NoError(t, db.ClearCollections(commitqueue.Collection, patch.Collection))
+		itemID := bson.NewObjectId()
+		p := patch.Patch{
+			Id:      itemID,
+			Project: projectID,
+		}
+		require.NoError(t, p.Insert())
+		queue := &commitqueue.CommitQueue{
+			ProjectID: projectID,
+			Queue: []commitqueue.CommitQueueItem{
+				{Issue: "a", BatchedInto: itemID.Hex()},
+				{Issue: "b", BatchedInto: itemID.Hex()},
+				{Issue: itemID.Hex(), Version: itemID.Hex()},
+			},
+		}
+		require.NoError(t, commitqueue.InsertQueue(queue))
+
+		assert.NoError(t, ConcludeMerge(ctx, itemID.Hex(), status))
+
+		queue, err := commitqueue.FindOneId(projectID)
+		require.NoError(t, err)
+		assert.Len(t, queue.Queue, remaining, status)
+		assert.False(t, queue.Processing(), status)
+	}
+}
+
+func TestGetAdditionalPatchesForBatch(t *testing.T) {
+	require.NoError(t, db.ClearCollections(commitqueue.Collection, patch.Collection))
+
+	projectID := "evergreen"
+	ahead := bson.NewObjectId().Hex()
+	batched := []string{bson.NewObjectId().Hex(), bson.NewObjectId().Hex()}
+	mergeID := bson.NewObjectId()
+	p := patch.Patch{
+		Id:      mergeID,
+		Project: projectID,
+	}
+	require.NoError(t, p.Insert())
+	queue := &commitqueue.CommitQueue{
+		ProjectID: projectID,
+		Queue: []commitqueue.CommitQueueItem{
+			{Issue: ahead, Version: ahead},
+			{Issue: batched[0], BatchedInto: mergeID.Hex()},
+			{Issue: batched[1], BatchedInto: mergeID.Hex()},
+			{Issue: mergeID.Hex(), Version: mergeID.Hex()},
+		},
+	}
+	require.NoError(t, commitqueue.InsertQueue(queue))
+
+	additional, err := GetAdditionalPatches(mergeID.Hex(), true)
+	require.NoError(t, err)
+	assert.Equal(t, batched, additional, "merge task should only apply the patches batched into its merge patch")
+
+	additional, err = GetAdditionalPatches(mergeID.Hex(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ahead, batched[0], batched[1]}, additional, "other tasks should also apply the versions ahead of them")
+}
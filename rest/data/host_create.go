@@ -185,16 +185,23 @@ func makeProjectAndExpansionsFromTask(ctx context.Context, settings *evergreen.S
 		return nil, nil, errors.Errorf("project ref '%s' not found", t.Project)
 	}
 
-	appToken, err := settings.CreateInstallationToken(ctx, pRef.Owner, pRef.Repo, nil)
-	if err != nil {
-		grip.Debug(message.WrapError(err, message.Fields{
-			"ticket":  "EVG-19966",
-			"message": "error creating GitHub app token",
-			"caller":  "makeProjectAndExpansionsFromTask",
-			"owner":   pRef.Owner,
-			"repo":    pRef.Repo,
-			"task":    t.Id,
-		}))
+	// Projects with a git URL aren't hosted on GitHub, so their tasks don't
+	// get GitHub credentials.
+	var appToken string
+	if pRef.GitURL == "" {
+		appToken, err = settings.CreateInstallationToken(ctx, pRef.Owner, pRef.Repo, nil)
+		if err != nil {
+			grip.Debug(message.WrapError(err, message.Fields{
+				"ticket":  "EVG-19966",
+				"message": "error creating GitHub app token",
+				"caller":  "makeProjectAndExpansionsFromTask",
+				"owner":   pRef.Owner,
+				"repo":    pRef.Repo,
+				"task":    t.Id,
+			}))
+		}
+	} else {
+		oauthToken = ""
 	}
 
 	expansions, err := model.PopulateExpansions(t, h, oauthToken, appToken)
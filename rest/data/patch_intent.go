@@ -8,6 +8,7 @@ import (
 	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/thirdparty"
 	"github.com/evergreen-ci/evergreen/units"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/mongodb/amboy"
@@ -18,8 +19,19 @@ import (
 
 func AddPatchIntent(intent patch.Intent, queue amboy.Queue) error {
 	patchDoc := intent.NewPatch()
-	projectRef, err := model.FindOneProjectRefByRepoAndBranchWithPRTesting(patchDoc.GithubPatchData.BaseOwner,
-		patchDoc.GithubPatchData.BaseRepo, patchDoc.GithubPatchData.BaseBranch, intent.GetCalledBy())
+	owner, repo, branch := patchDoc.GithubPatchData.BaseOwner, patchDoc.GithubPatchData.BaseRepo, patchDoc.GithubPatchData.BaseBranch
+	if patchDoc.IsGitlabMRPatch() {
+		var err error
+		owner, repo, err = thirdparty.SplitGitlabProjectPath(patchDoc.GitlabPatchData.ProjectPath)
+		if err != nil {
+			return gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    err.Error(),
+			}
+		}
+		branch = patchDoc.GitlabPatchData.BaseBranch
+	}
+	projectRef, err := model.FindOneProjectRefByRepoAndBranchWithPRTesting(owner, repo, branch, intent.GetCalledBy())
 	if err != nil {
 		return gimlet.ErrorResponse{
 			StatusCode: http.StatusInternalServerError,
@@ -282,6 +282,12 @@ func UpdateProjectVars(projectId string, varsModel *restModel.APIProjectVars, ov
 	}
 	vars := varsModel.ToService()
 	vars.Id = projectId
+	if err := model.ValidateExternalVarRefs(projectId, vars.Vars); err != nil {
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}
+	}
 
 	// Avoid accidentally overwriting private variables, for example if the GET route is used to populate PATCH.
 	for key, val := range varsModel.Vars {
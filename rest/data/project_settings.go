@@ -215,6 +215,7 @@ func SaveProjectSettingsForSection(ctx context.Context, projectId string, change
 	if newProjectRef.RepoRefId == "" {
 		newProjectRef.RepoRefId = before.ProjectRef.RepoRefId
 	}
+	newProjectRef.RestoreRedactedGitURL(before.ProjectRef.GitURL)
 
 	// If the project ref doesn't use the repo, or we're using a repo ref, then this will just be the same as the passed in ref.
 	// Used to verify that if something is set to nil, we properly validate using the merged project ref.
@@ -234,6 +235,12 @@ func SaveProjectSettingsForSection(ctx context.Context, projectId string, change
 	modified := false
 	switch section {
 	case model.ProjectPageGeneralSection:
+		if err = mergedSection.ArtifactRetention.Validate(); err != nil {
+			return nil, errors.Wrap(err, "validating artifact retention settings")
+		}
+		if err = mergedSection.ValidateGitURL(); err != nil {
+			return nil, err
+		}
 		if mergedSection.Identifier != mergedBeforeRef.Identifier {
 			if err = handleIdentifierConflict(mergedSection); err != nil {
 				return nil, err
@@ -349,6 +356,9 @@ func SaveProjectSettingsForSection(ctx context.Context, projectId string, change
 		if err = handleGithubConflicts(mergedSection, "Toggling GitHub features"); err != nil {
 			return nil, err
 		}
+		if err = mergedSection.CommitQueue.Validate(); err != nil {
+			return nil, errors.Wrap(err, "validating commit queue settings")
+		}
 		// At project creation we now insert a commit queue, however older projects still may not have one
 		// so we need to validate that this exists if the feature is being toggled on.
 		if !mergedBeforeRef.CommitQueue.IsEnabled() && mergedSection.CommitQueue.IsEnabled() {
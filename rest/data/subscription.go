@@ -1,10 +1,13 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
+	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
 	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/notification"
 	restModel "github.com/evergreen-ci/evergreen/rest/model"
 	"github.com/evergreen-ci/evergreen/trigger"
 	"github.com/evergreen-ci/gimlet"
@@ -59,6 +62,12 @@ func SaveSubscriptions(owner string, subscriptions []restModel.APISubscription,
 				Message:    errors.Wrap(err, "invalid subscription").Error(),
 			}
 		}
+		if err = trigger.ValidateTriggerData(dbSubscription.TriggerData); err != nil {
+			return gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrap(err, "invalid subscription trigger data").Error(),
+			}
+		}
 
 		dbSubscriptions = append(dbSubscriptions, dbSubscription)
 
@@ -126,3 +135,77 @@ func DeleteSubscriptions(owner string, ids []string) error {
 	}
 	return catcher.Resolve()
 }
+
+// PreviewSubscription returns the notification that the given draft
+// subscription would create for the event with the given ID, without saving
+// the subscription or storing the notification. matched reports whether the
+// subscription's selectors match the event. The returned notification is nil
+// if the subscription does not match or its trigger does not fire. authorize
+// checks that the requester may view the resource that the event is about.
+func PreviewSubscription(ctx context.Context, eventID string, sub event.Subscription, authorize func(event.Attributes) error) (n *notification.Notification, matched bool, err error) {
+	if !trigger.ValidateTrigger(sub.ResourceType, sub.Trigger) {
+		return nil, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("subscription type/trigger is invalid: %s/%s", sub.ResourceType, sub.Trigger),
+		}
+	}
+	if err = sub.ValidateSelectors(); err != nil {
+		return nil, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid subscription selectors").Error(),
+		}
+	}
+	if err = sub.Subscriber.Validate(); err != nil {
+		return nil, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid subscriber").Error(),
+		}
+	}
+	if err = trigger.ValidateTriggerData(sub.TriggerData); err != nil {
+		return nil, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid subscription trigger data").Error(),
+		}
+	}
+
+	e, err := event.FindByID(eventID)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "finding event '%s'", eventID)
+	}
+	if e == nil {
+		return nil, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("event '%s' not found", eventID),
+		}
+	}
+	if e.ResourceType != sub.ResourceType {
+		return nil, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("subscription resource type '%s' does not match event resource type '%s'", sub.ResourceType, e.ResourceType),
+		}
+	}
+
+	// Round-trip the draft through BSON so that its subscriber target has the
+	// same shape as a subscription read from the database. The preview is
+	// given its own ID so that alert records belonging to saved subscriptions
+	// don't suppress the preview.
+	raw, err := mgobson.Marshal(sub)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "marshalling subscription")
+	}
+	draft := event.Subscription{}
+	if err = mgobson.Unmarshal(raw, &draft); err != nil {
+		return nil, false, errors.Wrap(err, "unmarshalling subscription")
+	}
+	draft.ID = "preview-" + mgobson.NewObjectId().Hex()
+
+	n, matched, err = trigger.PreviewNotification(ctx, e, &draft, authorize)
+	if err != nil {
+		if _, ok := errors.Cause(err).(gimlet.ErrorResponse); ok {
+			return nil, false, err
+		}
+		return nil, false, errors.Wrapf(err, "previewing notification for event '%s'", eventID)
+	}
+
+	return n, matched, nil
+}
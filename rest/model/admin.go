@@ -14,6 +14,7 @@ func NewConfigModel() *APIAdminSettings {
 	return &APIAdminSettings{
 		Amboy:             &APIAmboyConfig{},
 		Api:               &APIapiConfig{},
+		AuditLog:          &APIAuditLogConfig{},
 		AuthConfig:        &APIAuthConfig{},
 		Buckets:           &APIBucketConfig{},
 		Cedar:             &APICedarConfig{},
@@ -22,6 +23,7 @@ func NewConfigModel() *APIAdminSettings {
 		Credentials:       map[string]string{},
 		DataPipes:         &APIDataPipesConfig{},
 		Expansions:        map[string]string{},
+		Gitlab:            &APIGitlabConfig{},
 		HostInit:          &APIHostInitConfig{},
 		HostJasper:        &APIHostJasperConfig{},
 		Jira:              &APIJiraConfig{},
@@ -34,9 +36,11 @@ func NewConfigModel() *APIAdminSettings {
 		Plugins:           map[string]map[string]interface{}{},
 		PodLifecycle:      &APIPodLifecycleConfig{},
 		ProjectCreation:   &APIProjectCreationConfig{},
+		ProjectPolicies:   &APIProjectPoliciesConfig{},
 		Providers:         &APICloudProviders{},
 		RepoTracker:       &APIRepoTrackerConfig{},
 		Scheduler:         &APISchedulerConfig{},
+		Secrets:           &APISecretsConfig{},
 		ServiceFlags:      &APIServiceFlags{},
 		Slack:             &APISlackConfig{},
 		Splunk:            &APISplunkConfig{},
@@ -53,6 +57,7 @@ type APIAdminSettings struct {
 	Api                 *APIapiConfig                     `json:"api,omitempty"`
 	ApiUrl              *string                           `json:"api_url,omitempty"`
 	AWSInstanceRole     *string                           `json:"aws_instance_role,omitempty"`
+	AuditLog            *APIAuditLogConfig                `json:"audit_log,omitempty"`
 	AuthConfig          *APIAuthConfig                    `json:"auth,omitempty"`
 	Banner              *string                           `json:"banner,omitempty"`
 	BannerTheme         *string                           `json:"banner_theme,omitempty"`
@@ -69,6 +74,7 @@ type APIAdminSettings struct {
 	GithubPRCreatorOrg  *string                           `json:"github_pr_creator_org,omitempty"`
 	GithubOrgs          []string                          `json:"github_orgs,omitempty"`
 	DisabledGQLQueries  []string                          `json:"disabled_gql_queries"`
+	Gitlab              *APIGitlabConfig                  `json:"gitlab,omitempty"`
 	HostInit            *APIHostInitConfig                `json:"hostinit,omitempty"`
 	HostJasper          *APIHostJasperConfig              `json:"host_jasper,omitempty"`
 	Jira                *APIJiraConfig                    `json:"jira,omitempty"`
@@ -83,9 +89,11 @@ type APIAdminSettings struct {
 	PodLifecycle        *APIPodLifecycleConfig            `json:"pod_lifecycle,omitempty"`
 	PprofPort           *string                           `json:"pprof_port,omitempty"`
 	ProjectCreation     *APIProjectCreationConfig         `json:"project_creation,omitempty"`
+	ProjectPolicies     *APIProjectPoliciesConfig         `json:"project_policies,omitempty"`
 	Providers           *APICloudProviders                `json:"providers,omitempty"`
 	RepoTracker         *APIRepoTrackerConfig             `json:"repotracker,omitempty"`
 	Scheduler           *APISchedulerConfig               `json:"scheduler,omitempty"`
+	Secrets             *APISecretsConfig                 `json:"secrets,omitempty"`
 	ServiceFlags        *APIServiceFlags                  `json:"service_flags,omitempty"`
 	Slack               *APISlackConfig                   `json:"slack,omitempty"`
 	SSHKeyDirectory     *string                           `json:"ssh_key_directory,omitempty"`
@@ -515,6 +523,7 @@ func (a *APIapiConfig) ToService() (interface{}, error) {
 type APIAuthConfig struct {
 	LDAP                    *APILDAPConfig       `json:"ldap"`
 	Okta                    *APIOktaConfig       `json:"okta"`
+	OIDC                    *APIOIDCConfig       `json:"oidc"`
 	Naive                   *APINaiveAuthConfig  `json:"naive"`
 	Github                  *APIGithubAuthConfig `json:"github"`
 	Multi                   *APIMultiAuthConfig  `json:"multi"`
@@ -538,6 +547,12 @@ func (a *APIAuthConfig) BuildFromService(h interface{}) error {
 				return errors.Wrap(err, "converting Okta auth settings to API model")
 			}
 		}
+		if v.OIDC != nil {
+			a.OIDC = &APIOIDCConfig{}
+			if err := a.OIDC.BuildFromService(v.OIDC); err != nil {
+				return errors.Wrap(err, "converting OIDC auth settings to API model")
+			}
+		}
 		if v.Github != nil {
 			a.Github = &APIGithubAuthConfig{}
 			if err := a.Github.BuildFromService(v.Github); err != nil {
@@ -568,6 +583,7 @@ func (a *APIAuthConfig) BuildFromService(h interface{}) error {
 func (a *APIAuthConfig) ToService() (interface{}, error) {
 	var ldap *evergreen.LDAPConfig
 	var okta *evergreen.OktaConfig
+	var oidc *evergreen.OIDCConfig
 	var naive *evergreen.NaiveAuthConfig
 	var github *evergreen.GithubAuthConfig
 	var multi *evergreen.MultiAuthConfig
@@ -594,6 +610,17 @@ func (a *APIAuthConfig) ToService() (interface{}, error) {
 		}
 	}
 
+	i, err = a.OIDC.ToService()
+	if err != nil {
+		return nil, errors.Wrap(err, "converting OIDC auth config to service model")
+	}
+	if i != nil {
+		oidc, ok = i.(*evergreen.OIDCConfig)
+		if !ok {
+			return nil, errors.Errorf("programmatic error: expected OIDC auth config but got type %T", i)
+		}
+	}
+
 	i, err = a.Naive.ToService()
 	if err != nil {
 		return nil, errors.Wrap(err, "converting naive auth config to service model")
@@ -630,6 +657,7 @@ func (a *APIAuthConfig) ToService() (interface{}, error) {
 	return evergreen.AuthConfig{
 		LDAP:                    ldap,
 		Okta:                    okta,
+		OIDC:                    oidc,
 		Naive:                   naive,
 		Github:                  github,
 		Multi:                   multi,
@@ -640,7 +668,8 @@ func (a *APIAuthConfig) ToService() (interface{}, error) {
 }
 
 type APIBucketConfig struct {
-	LogBucket APIBucket `json:"log_bucket"`
+	LogBucket       APIBucket           `json:"log_bucket"`
+	ArtifactBuckets []APIArtifactBucket `json:"artifact_buckets"`
 }
 
 type APIBucket struct {
@@ -648,11 +677,27 @@ type APIBucket struct {
 	Type *string `json:"type"`
 }
 
+type APIArtifactBucket struct {
+	Name   *string `json:"name"`
+	Region *string `json:"region"`
+	Key    *string `json:"key"`
+	Secret *string `json:"secret"`
+}
+
 func (a *APIBucketConfig) BuildFromService(h interface{}) error {
 	switch v := h.(type) {
 	case evergreen.BucketConfig:
 		a.LogBucket.Name = utility.ToStringPtr(v.LogBucket.Name)
 		a.LogBucket.Type = utility.ToStringPtr(v.LogBucket.Type)
+		a.ArtifactBuckets = nil
+		for _, b := range v.ArtifactBuckets {
+			a.ArtifactBuckets = append(a.ArtifactBuckets, APIArtifactBucket{
+				Name:   utility.ToStringPtr(b.Name),
+				Region: utility.ToStringPtr(b.Region),
+				Key:    utility.ToStringPtr(b.Key),
+				Secret: utility.ToStringPtr(b.Secret),
+			})
+		}
 	default:
 		return errors.Errorf("programmatic error: expected bucket config but got type %T", h)
 	}
@@ -660,11 +705,21 @@ func (a *APIBucketConfig) BuildFromService(h interface{}) error {
 }
 
 func (a *APIBucketConfig) ToService() (interface{}, error) {
+	var artifactBuckets []evergreen.ArtifactBucket
+	for _, b := range a.ArtifactBuckets {
+		artifactBuckets = append(artifactBuckets, evergreen.ArtifactBucket{
+			Name:   utility.FromStringPtr(b.Name),
+			Region: utility.FromStringPtr(b.Region),
+			Key:    utility.FromStringPtr(b.Key),
+			Secret: utility.FromStringPtr(b.Secret),
+		})
+	}
 	return evergreen.BucketConfig{
 		LogBucket: evergreen.Bucket{
 			Name: utility.FromStringPtr(a.LogBucket.Name),
 			Type: utility.FromStringPtr(a.LogBucket.Type),
 		},
+		ArtifactBuckets: artifactBuckets,
 	}, nil
 }
 
@@ -697,6 +752,141 @@ func (a *APICedarConfig) ToService() (interface{}, error) {
 	}, nil
 }
 
+type APIAuditLogConfig struct {
+	SinkType      *string `json:"sink_type"`
+	FilePath      *string `json:"file_path"`
+	SyslogNetwork *string `json:"syslog_network"`
+	SyslogAddress *string `json:"syslog_address"`
+	SyslogTag     *string `json:"syslog_tag"`
+	HMACKeyFile   *string `json:"hmac_key_file"`
+}
+
+func (a *APIAuditLogConfig) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case evergreen.AuditLogConfig:
+		a.SinkType = utility.ToStringPtr(v.SinkType)
+		a.FilePath = utility.ToStringPtr(v.FilePath)
+		a.SyslogNetwork = utility.ToStringPtr(v.SyslogNetwork)
+		a.SyslogAddress = utility.ToStringPtr(v.SyslogAddress)
+		a.SyslogTag = utility.ToStringPtr(v.SyslogTag)
+		a.HMACKeyFile = utility.ToStringPtr(v.HMACKeyFile)
+	default:
+		return errors.Errorf("programmatic error: expected audit log config but got type %T", h)
+	}
+	return nil
+}
+
+func (a *APIAuditLogConfig) ToService() (interface{}, error) {
+	return evergreen.AuditLogConfig{
+		SinkType:      utility.FromStringPtr(a.SinkType),
+		FilePath:      utility.FromStringPtr(a.FilePath),
+		SyslogNetwork: utility.FromStringPtr(a.SyslogNetwork),
+		SyslogAddress: utility.FromStringPtr(a.SyslogAddress),
+		SyslogTag:     utility.FromStringPtr(a.SyslogTag),
+		HMACKeyFile:   utility.FromStringPtr(a.HMACKeyFile),
+	}, nil
+}
+
+type APIGitlabConfig struct {
+	URL   *string `json:"url"`
+	Token *string `json:"token"`
+}
+
+func (a *APIGitlabConfig) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case evergreen.GitlabConfig:
+		a.URL = utility.ToStringPtr(v.URL)
+		a.Token = utility.ToStringPtr(v.Token)
+	default:
+		return errors.Errorf("programmatic error: expected GitLab config but got type %T", h)
+	}
+	return nil
+}
+
+func (a *APIGitlabConfig) ToService() (interface{}, error) {
+	return evergreen.GitlabConfig{
+		URL:   utility.FromStringPtr(a.URL),
+		Token: utility.FromStringPtr(a.Token),
+	}, nil
+}
+
+type APISecretsConfig struct {
+	EncryptionEnabled  bool            `json:"encryption_enabled"`
+	KMSKeyID           *string         `json:"kms_key_id"`
+	KMSRegion          *string         `json:"kms_region"`
+	KeyringFile        *string         `json:"keyring_file"`
+	Vault              *APIVaultConfig `json:"vault"`
+	FileStoreDirectory *string         `json:"file_store_directory"`
+	ProvenanceKeyFile  *string         `json:"provenance_key_file"`
+}
+
+func (a *APISecretsConfig) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case evergreen.SecretsConfig:
+		a.EncryptionEnabled = v.EncryptionEnabled
+		a.KMSKeyID = utility.ToStringPtr(v.KMSKeyID)
+		a.KMSRegion = utility.ToStringPtr(v.KMSRegion)
+		a.KeyringFile = utility.ToStringPtr(v.KeyringFile)
+		a.Vault = &APIVaultConfig{}
+		if err := a.Vault.BuildFromService(v.Vault); err != nil {
+			return errors.Wrap(err, "converting Vault config to API model")
+		}
+		a.FileStoreDirectory = utility.ToStringPtr(v.FileStoreDirectory)
+		a.ProvenanceKeyFile = utility.ToStringPtr(v.ProvenanceKeyFile)
+	default:
+		return errors.Errorf("programmatic error: expected secrets config but got type %T", h)
+	}
+	return nil
+}
+
+func (a *APISecretsConfig) ToService() (interface{}, error) {
+	config := evergreen.SecretsConfig{
+		EncryptionEnabled:  a.EncryptionEnabled,
+		KMSKeyID:           utility.FromStringPtr(a.KMSKeyID),
+		KMSRegion:          utility.FromStringPtr(a.KMSRegion),
+		KeyringFile:        utility.FromStringPtr(a.KeyringFile),
+		FileStoreDirectory: utility.FromStringPtr(a.FileStoreDirectory),
+		ProvenanceKeyFile:  utility.FromStringPtr(a.ProvenanceKeyFile),
+	}
+	if a.Vault != nil {
+		vault, err := a.Vault.ToService()
+		if err != nil {
+			return nil, errors.Wrap(err, "converting Vault config to service model")
+		}
+		config.Vault = vault.(evergreen.VaultConfig)
+	}
+	return config, nil
+}
+
+type APIVaultConfig struct {
+	URL       *string `json:"url"`
+	Token     *string `json:"token"`
+	Namespace *string `json:"namespace"`
+	MountPath *string `json:"mount_path"`
+}
+
+func (a *APIVaultConfig) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case evergreen.VaultConfig:
+		a.URL = utility.ToStringPtr(v.URL)
+		a.Token = utility.ToStringPtr(v.Token)
+		a.Namespace = utility.ToStringPtr(v.Namespace)
+		a.MountPath = utility.ToStringPtr(v.MountPath)
+	default:
+		return errors.Errorf("programmatic error: expected Vault config but got type %T", h)
+	}
+	return nil
+}
+
+func (a *APIVaultConfig) ToService() (interface{}, error) {
+	return evergreen.VaultConfig{
+		URL:       utility.FromStringPtr(a.URL),
+		Token:     utility.FromStringPtr(a.Token),
+		Namespace: utility.FromStringPtr(a.Namespace),
+		MountPath: utility.FromStringPtr(a.MountPath),
+	}, nil
+}
+
 type APILDAPConfig struct {
 	URL                *string `json:"url"`
 	Port               *string `json:"port"`
@@ -785,6 +975,77 @@ func (a *APIOktaConfig) ToService() (interface{}, error) {
 	}, nil
 }
 
+type APIOIDCConfig struct {
+	Issuer             *string             `json:"issuer"`
+	ClientID           *string             `json:"client_id"`
+	ClientSecret       *string             `json:"client_secret"`
+	Scopes             []string            `json:"scopes"`
+	UsernameClaim      *string             `json:"username_claim"`
+	EmailDomain        *string             `json:"email_domain"`
+	GroupsClaim        *string             `json:"groups_claim"`
+	UserGroup          *string             `json:"user_group"`
+	GroupRoles         []APIOIDCGroupRoles `json:"group_roles"`
+	ExpireAfterMinutes int                 `json:"expire_after_minutes"`
+}
+
+type APIOIDCGroupRoles struct {
+	Group *string  `json:"group"`
+	Roles []string `json:"roles"`
+}
+
+func (a *APIOIDCConfig) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case *evergreen.OIDCConfig:
+		if v == nil {
+			return nil
+		}
+		a.Issuer = utility.ToStringPtr(v.Issuer)
+		a.ClientID = utility.ToStringPtr(v.ClientID)
+		a.ClientSecret = utility.ToStringPtr(v.ClientSecret)
+		a.Scopes = v.Scopes
+		a.UsernameClaim = utility.ToStringPtr(v.UsernameClaim)
+		a.EmailDomain = utility.ToStringPtr(v.EmailDomain)
+		a.GroupsClaim = utility.ToStringPtr(v.GroupsClaim)
+		a.UserGroup = utility.ToStringPtr(v.UserGroup)
+		a.GroupRoles = nil
+		for _, gr := range v.GroupRoles {
+			a.GroupRoles = append(a.GroupRoles, APIOIDCGroupRoles{
+				Group: utility.ToStringPtr(gr.Group),
+				Roles: gr.Roles,
+			})
+		}
+		a.ExpireAfterMinutes = v.ExpireAfterMinutes
+		return nil
+	default:
+		return errors.Errorf("programmatic error: expected OIDC config but got type %T", h)
+	}
+}
+
+func (a *APIOIDCConfig) ToService() (interface{}, error) {
+	if a == nil {
+		return nil, nil
+	}
+	var groupRoles []evergreen.OIDCGroupRoles
+	for _, gr := range a.GroupRoles {
+		groupRoles = append(groupRoles, evergreen.OIDCGroupRoles{
+			Group: utility.FromStringPtr(gr.Group),
+			Roles: gr.Roles,
+		})
+	}
+	return &evergreen.OIDCConfig{
+		Issuer:             utility.FromStringPtr(a.Issuer),
+		ClientID:           utility.FromStringPtr(a.ClientID),
+		ClientSecret:       utility.FromStringPtr(a.ClientSecret),
+		Scopes:             a.Scopes,
+		UsernameClaim:      utility.FromStringPtr(a.UsernameClaim),
+		EmailDomain:        utility.FromStringPtr(a.EmailDomain),
+		GroupsClaim:        utility.FromStringPtr(a.GroupsClaim),
+		UserGroup:          utility.FromStringPtr(a.UserGroup),
+		GroupRoles:         groupRoles,
+		ExpireAfterMinutes: a.ExpireAfterMinutes,
+	}, nil
+}
+
 type APINaiveAuthConfig struct {
 	Users []APIAuthUser `json:"users"`
 }
@@ -1327,6 +1588,67 @@ func (a *APIProjectCreationConfig) ToService() (interface{}, error) {
 	return config, nil
 }
 
+type APIProjectPolicy struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Severity    *string  `json:"severity"`
+	Target      *string  `json:"target"`
+	Projects    []string `json:"projects"`
+	When        *string  `json:"when"`
+	Condition   *string  `json:"condition"`
+}
+
+func (a *APIProjectPolicy) BuildFromService(p evergreen.ProjectPolicy) {
+	a.Name = utility.ToStringPtr(p.Name)
+	a.Description = utility.ToStringPtr(p.Description)
+	a.Severity = utility.ToStringPtr(string(p.Severity))
+	a.Target = utility.ToStringPtr(string(p.Target))
+	a.Projects = p.Projects
+	a.When = utility.ToStringPtr(p.When)
+	a.Condition = utility.ToStringPtr(p.Condition)
+}
+
+func (a *APIProjectPolicy) ToService() evergreen.ProjectPolicy {
+	return evergreen.ProjectPolicy{
+		Name:        utility.FromStringPtr(a.Name),
+		Description: utility.FromStringPtr(a.Description),
+		Severity:    evergreen.ProjectPolicySeverity(utility.FromStringPtr(a.Severity)),
+		Target:      evergreen.ProjectPolicyTarget(utility.FromStringPtr(a.Target)),
+		Projects:    a.Projects,
+		When:        utility.FromStringPtr(a.When),
+		Condition:   utility.FromStringPtr(a.Condition),
+	}
+}
+
+type APIProjectPoliciesConfig struct {
+	Policies []APIProjectPolicy `json:"policies"`
+}
+
+func (a *APIProjectPoliciesConfig) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case evergreen.ProjectPoliciesConfig:
+		a.Policies = []APIProjectPolicy{}
+		for _, p := range v.Policies {
+			apiPolicy := APIProjectPolicy{}
+			apiPolicy.BuildFromService(p)
+			a.Policies = append(a.Policies, apiPolicy)
+		}
+	default:
+		return errors.Errorf("programmatic error: expected project policies config but got type %T", h)
+	}
+
+	return nil
+}
+
+func (a *APIProjectPoliciesConfig) ToService() (interface{}, error) {
+	config := evergreen.ProjectPoliciesConfig{}
+	for _, p := range a.Policies {
+		config.Policies = append(config.Policies, p.ToService())
+	}
+
+	return config, nil
+}
+
 type APICloudProviders struct {
 	AWS       *APIAWSConfig       `json:"aws"`
 	Docker    *APIDockerConfig    `json:"docker"`
@@ -2106,9 +2428,10 @@ func (a *APIVSphereConfig) ToService() (interface{}, error) {
 }
 
 type APIRepoTrackerConfig struct {
-	NumNewRepoRevisionsToFetch int `json:"revs_to_fetch"`
-	MaxRepoRevisionsToSearch   int `json:"max_revs_to_search"`
-	MaxConcurrentRequests      int `json:"max_con_requests"`
+	NumNewRepoRevisionsToFetch int  `json:"revs_to_fetch"`
+	MaxRepoRevisionsToSearch   int  `json:"max_revs_to_search"`
+	MaxConcurrentRequests      int  `json:"max_con_requests"`
+	AllowLocalGitURLs          bool `json:"allow_local_git_urls"`
 }
 
 func (a *APIRepoTrackerConfig) BuildFromService(h interface{}) error {
@@ -2117,6 +2440,7 @@ func (a *APIRepoTrackerConfig) BuildFromService(h interface{}) error {
 		a.NumNewRepoRevisionsToFetch = v.NumNewRepoRevisionsToFetch
 		a.MaxConcurrentRequests = v.MaxConcurrentRequests
 		a.MaxRepoRevisionsToSearch = v.MaxRepoRevisionsToSearch
+		a.AllowLocalGitURLs = v.AllowLocalGitURLs
 	default:
 		return errors.Errorf("programmatic error: expected repotracker config but got type %T", h)
 	}
@@ -2128,6 +2452,7 @@ func (a *APIRepoTrackerConfig) ToService() (interface{}, error) {
 		NumNewRepoRevisionsToFetch: a.NumNewRepoRevisionsToFetch,
 		MaxConcurrentRequests:      a.MaxConcurrentRequests,
 		MaxRepoRevisionsToSearch:   a.MaxRepoRevisionsToSearch,
+		AllowLocalGitURLs:          a.AllowLocalGitURLs,
 	}, nil
 }
 
@@ -121,6 +121,11 @@ func TestModelConversion(t *testing.T) {
 	assert.Equal(len(testSettings.AuthConfig.Github.Users), len(apiSettings.AuthConfig.Github.Users))
 	assert.Equal(testSettings.Buckets.LogBucket.Name, utility.FromStringPtr(apiSettings.Buckets.LogBucket.Name))
 	assert.Equal(testSettings.Buckets.LogBucket.Type, utility.FromStringPtr(apiSettings.Buckets.LogBucket.Type))
+	assert.Equal(len(testSettings.Buckets.ArtifactBuckets), len(apiSettings.Buckets.ArtifactBuckets))
+	for i, b := range testSettings.Buckets.ArtifactBuckets {
+		assert.Equal(b.Name, utility.FromStringPtr(apiSettings.Buckets.ArtifactBuckets[i].Name))
+		assert.Equal(b.Secret, utility.FromStringPtr(apiSettings.Buckets.ArtifactBuckets[i].Secret))
+	}
 	assert.Equal(testSettings.Cedar.BaseURL, utility.FromStringPtr(apiSettings.Cedar.BaseURL))
 	assert.Equal(testSettings.Cedar.RPCPort, utility.FromStringPtr(apiSettings.Cedar.RPCPort))
 	assert.Equal(testSettings.Cedar.User, utility.FromStringPtr(apiSettings.Cedar.User))
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/utility"
 )
@@ -10,6 +12,11 @@ type APIFile struct {
 	Link           *string `json:"url"`
 	Visibility     *string `json:"visibility"`
 	IgnoreForFetch bool    `json:"ignore_for_fetch"`
+	// Expired indicates that the file was deleted by the project's artifact
+	// retention policy, so its link no longer works.
+	Expired bool `json:"expired"`
+	// SHA256 is the hex-encoded SHA-256 digest of the file, if it's known.
+	SHA256 *string `json:"sha256"`
 }
 
 type APIEntry struct {
@@ -25,6 +32,8 @@ func (f *APIFile) BuildFromService(file artifact.File) {
 	f.Link = utility.ToStringPtr(file.Link)
 	f.Visibility = utility.ToStringPtr(file.Visibility)
 	f.IgnoreForFetch = file.IgnoreForFetch
+	f.Expired = file.Expired
+	f.SHA256 = utility.ToStringPtr(file.SHA256)
 }
 
 func (f *APIFile) ToService() artifact.File {
@@ -33,6 +42,8 @@ func (f *APIFile) ToService() artifact.File {
 		Link:           utility.FromStringPtr(f.Link),
 		Visibility:     utility.FromStringPtr(f.Visibility),
 		IgnoreForFetch: f.IgnoreForFetch,
+		Expired:        f.Expired,
+		SHA256:         utility.FromStringPtr(f.SHA256),
 	}
 }
 
@@ -61,3 +72,33 @@ func (e *APIEntry) ToService() artifact.Entry {
 
 	return entry
 }
+
+// APIArtifactProvenance is the signed provenance statement for a file that a
+// task attached.
+type APIArtifactProvenance struct {
+	TaskID    *string `json:"task_id"`
+	Execution int     `json:"execution"`
+	FileName  *string `json:"file_name"`
+	Link      *string `json:"url"`
+	SHA256    *string `json:"sha256"`
+	// Envelope is the DSSE envelope containing the signed in-toto statement.
+	Envelope   artifact.Envelope `json:"envelope"`
+	CreateTime *time.Time        `json:"create_time"`
+}
+
+func (p *APIArtifactProvenance) BuildFromService(v artifact.Provenance) {
+	p.TaskID = utility.ToStringPtr(v.TaskID)
+	p.Execution = v.Execution
+	p.FileName = utility.ToStringPtr(v.FileName)
+	p.Link = utility.ToStringPtr(v.Link)
+	p.SHA256 = utility.ToStringPtr(v.SHA256)
+	p.Envelope = v.Envelope
+	p.CreateTime = ToTimePtr(v.CreateTime)
+}
+
+// APIProvenancePublicKey is the public key that verifies provenance
+// statements.
+type APIProvenancePublicKey struct {
+	KeyID     *string `json:"key_id"`
+	PublicKey *string `json:"public_key"`
+}
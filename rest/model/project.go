@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/thirdparty"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
@@ -234,16 +235,20 @@ func (bd *APIPeriodicBuildDefinition) BuildFromService(params model.PeriodicBuil
 }
 
 type APICommitQueueParams struct {
-	Enabled     *bool            `json:"enabled"`
-	MergeMethod *string          `json:"merge_method"`
-	MergeQueue  model.MergeQueue `json:"merge_queue"`
-	Message     *string          `json:"message"`
+	Enabled          *bool            `json:"enabled"`
+	MergeMethod      *string          `json:"merge_method"`
+	MergeQueue       model.MergeQueue `json:"merge_queue"`
+	Message          *string          `json:"message"`
+	MergeTrainSize   *int             `json:"merge_train_size"`
+	BatchedMergeSize *int             `json:"batched_merge_size"`
 }
 
 func (cqParams *APICommitQueueParams) BuildFromService(params model.CommitQueueParams) {
 	cqParams.Enabled = utility.BoolPtrCopy(params.Enabled)
 	cqParams.MergeMethod = utility.ToStringPtr(params.MergeMethod)
 	cqParams.Message = utility.ToStringPtr(params.Message)
+	cqParams.MergeTrainSize = utility.ToIntPtr(params.MergeTrainSize)
+	cqParams.BatchedMergeSize = utility.ToIntPtr(params.BatchedMergeSize)
 
 	if params.MergeQueue == "" {
 		params.MergeQueue = model.MergeQueueEvergreen
@@ -256,6 +261,8 @@ func (cqParams *APICommitQueueParams) ToService() model.CommitQueueParams {
 	serviceParams.Enabled = utility.BoolPtrCopy(cqParams.Enabled)
 	serviceParams.MergeMethod = utility.FromStringPtr(cqParams.MergeMethod)
 	serviceParams.Message = utility.FromStringPtr(cqParams.Message)
+	serviceParams.MergeTrainSize = utility.FromIntPtr(cqParams.MergeTrainSize)
+	serviceParams.BatchedMergeSize = utility.FromIntPtr(cqParams.BatchedMergeSize)
 
 	if cqParams.MergeQueue == "" {
 		cqParams.MergeQueue = model.MergeQueueEvergreen
@@ -357,6 +364,23 @@ func (opts *APITaskSyncOptions) ToService() model.TaskSyncOptions {
 	}
 }
 
+type APIArtifactRetentionSettings struct {
+	MainlineDays int `json:"mainline_days"`
+	PatchDays    int `json:"patch_days"`
+}
+
+func (s *APIArtifactRetentionSettings) BuildFromService(in model.ArtifactRetentionSettings) {
+	s.MainlineDays = in.MainlineDays
+	s.PatchDays = in.PatchDays
+}
+
+func (s *APIArtifactRetentionSettings) ToService() model.ArtifactRetentionSettings {
+	return model.ArtifactRetentionSettings{
+		MainlineDays: s.MainlineDays,
+		PatchDays:    s.PatchDays,
+	}
+}
+
 type APIWorkstationConfig struct {
 	SetupCommands []APIWorkstationSetupCommand `bson:"setup_commands" json:"setup_commands"`
 	GitClone      *bool                        `bson:"git_clone" json:"git_clone"`
@@ -506,46 +530,50 @@ func (c *APIParameterInfo) BuildFromService(info model.ParameterInfo) {
 }
 
 type APIProjectRef struct {
-	Id                          *string                   `json:"id"`
-	Owner                       *string                   `json:"owner_name"`
-	Repo                        *string                   `json:"repo_name"`
-	Branch                      *string                   `json:"branch_name"`
-	Enabled                     *bool                     `json:"enabled"`
-	Private                     *bool                     `json:"private"`
-	BatchTime                   int                       `json:"batch_time"`
-	RemotePath                  *string                   `json:"remote_path"`
-	SpawnHostScriptPath         *string                   `json:"spawn_host_script_path"`
-	Identifier                  *string                   `json:"identifier"`
-	DisplayName                 *string                   `json:"display_name"`
-	DeactivatePrevious          *bool                     `json:"deactivate_previous"`
-	TracksPushEvents            *bool                     `json:"tracks_push_events"`
-	PRTestingEnabled            *bool                     `json:"pr_testing_enabled"`
-	ManualPRTestingEnabled      *bool                     `json:"manual_pr_testing_enabled"`
-	GitTagVersionsEnabled       *bool                     `json:"git_tag_versions_enabled"`
-	GithubChecksEnabled         *bool                     `json:"github_checks_enabled"`
-	UseRepoSettings             *bool                     `json:"use_repo_settings"`
-	RepoRefId                   *string                   `json:"repo_ref_id"`
-	CommitQueue                 APICommitQueueParams      `json:"commit_queue"`
-	TaskSync                    APITaskSyncOptions        `json:"task_sync"`
-	TaskAnnotationSettings      APITaskAnnotationSettings `json:"task_annotation_settings"`
-	BuildBaronSettings          APIBuildBaronSettings     `json:"build_baron_settings"`
-	PerfEnabled                 *bool                     `json:"perf_enabled"`
-	Hidden                      *bool                     `json:"hidden"`
-	PatchingDisabled            *bool                     `json:"patching_disabled"`
-	RepotrackerDisabled         *bool                     `json:"repotracker_disabled"`
-	DispatchingDisabled         *bool                     `json:"dispatching_disabled"`
-	StepbackDisabled            *bool                     `json:"stepback_disabled"`
-	VersionControlEnabled       *bool                     `json:"version_control_enabled"`
-	DisabledStatsCache          *bool                     `json:"disabled_stats_cache"`
-	Admins                      []*string                 `json:"admins"`
-	DeleteAdmins                []*string                 `json:"delete_admins,omitempty"`
-	GitTagAuthorizedUsers       []*string                 `json:"git_tag_authorized_users" bson:"git_tag_authorized_users"`
-	DeleteGitTagAuthorizedUsers []*string                 `json:"delete_git_tag_authorized_users,omitempty" bson:"delete_git_tag_authorized_users,omitempty"`
-	GitTagAuthorizedTeams       []*string                 `json:"git_tag_authorized_teams" bson:"git_tag_authorized_teams"`
-	DeleteGitTagAuthorizedTeams []*string                 `json:"delete_git_tag_authorized_teams,omitempty" bson:"delete_git_tag_authorized_teams,omitempty"`
-	NotifyOnBuildFailure        *bool                     `json:"notify_on_failure"`
-	Restricted                  *bool                     `json:"restricted"`
-	Revision                    *string                   `json:"revision"`
+	Id         *string `json:"id"`
+	Owner      *string `json:"owner_name"`
+	Repo       *string `json:"repo_name"`
+	Branch     *string `json:"branch_name"`
+	Enabled    *bool   `json:"enabled"`
+	Private    *bool   `json:"private"`
+	BatchTime  int     `json:"batch_time"`
+	RemotePath *string `json:"remote_path"`
+	GitURL     *string `json:"git_url"`
+	// GitlabWebhookSecret can be set but is never returned.
+	GitlabWebhookSecret         *string                      `json:"gitlab_webhook_secret,omitempty"`
+	SpawnHostScriptPath         *string                      `json:"spawn_host_script_path"`
+	Identifier                  *string                      `json:"identifier"`
+	DisplayName                 *string                      `json:"display_name"`
+	DeactivatePrevious          *bool                        `json:"deactivate_previous"`
+	TracksPushEvents            *bool                        `json:"tracks_push_events"`
+	PRTestingEnabled            *bool                        `json:"pr_testing_enabled"`
+	ManualPRTestingEnabled      *bool                        `json:"manual_pr_testing_enabled"`
+	GitTagVersionsEnabled       *bool                        `json:"git_tag_versions_enabled"`
+	GithubChecksEnabled         *bool                        `json:"github_checks_enabled"`
+	UseRepoSettings             *bool                        `json:"use_repo_settings"`
+	RepoRefId                   *string                      `json:"repo_ref_id"`
+	CommitQueue                 APICommitQueueParams         `json:"commit_queue"`
+	TaskSync                    APITaskSyncOptions           `json:"task_sync"`
+	ArtifactRetention           APIArtifactRetentionSettings `json:"artifact_retention"`
+	TaskAnnotationSettings      APITaskAnnotationSettings    `json:"task_annotation_settings"`
+	BuildBaronSettings          APIBuildBaronSettings        `json:"build_baron_settings"`
+	PerfEnabled                 *bool                        `json:"perf_enabled"`
+	Hidden                      *bool                        `json:"hidden"`
+	PatchingDisabled            *bool                        `json:"patching_disabled"`
+	RepotrackerDisabled         *bool                        `json:"repotracker_disabled"`
+	DispatchingDisabled         *bool                        `json:"dispatching_disabled"`
+	StepbackDisabled            *bool                        `json:"stepback_disabled"`
+	VersionControlEnabled       *bool                        `json:"version_control_enabled"`
+	DisabledStatsCache          *bool                        `json:"disabled_stats_cache"`
+	Admins                      []*string                    `json:"admins"`
+	DeleteAdmins                []*string                    `json:"delete_admins,omitempty"`
+	GitTagAuthorizedUsers       []*string                    `json:"git_tag_authorized_users" bson:"git_tag_authorized_users"`
+	DeleteGitTagAuthorizedUsers []*string                    `json:"delete_git_tag_authorized_users,omitempty" bson:"delete_git_tag_authorized_users,omitempty"`
+	GitTagAuthorizedTeams       []*string                    `json:"git_tag_authorized_teams" bson:"git_tag_authorized_teams"`
+	DeleteGitTagAuthorizedTeams []*string                    `json:"delete_git_tag_authorized_teams,omitempty" bson:"delete_git_tag_authorized_teams,omitempty"`
+	NotifyOnBuildFailure        *bool                        `json:"notify_on_failure"`
+	Restricted                  *bool                        `json:"restricted"`
+	Revision                    *string                      `json:"revision"`
 
 	Triggers                 []APITriggerDefinition       `json:"triggers"`
 	GithubTriggerAliases     []*string                    `json:"github_trigger_aliases"`
@@ -577,6 +605,8 @@ func (p *APIProjectRef) ToService() (*model.ProjectRef, error) {
 		Restricted:             utility.BoolPtrCopy(p.Restricted),
 		BatchTime:              p.BatchTime,
 		RemotePath:             utility.FromStringPtr(p.RemotePath),
+		GitURL:                 utility.FromStringPtr(p.GitURL),
+		GitlabWebhookSecret:    utility.FromStringPtr(p.GitlabWebhookSecret),
 		Id:                     utility.FromStringPtr(p.Id),
 		Identifier:             utility.FromStringPtr(p.Identifier),
 		DisplayName:            utility.FromStringPtr(p.DisplayName),
@@ -589,6 +619,7 @@ func (p *APIProjectRef) ToService() (*model.ProjectRef, error) {
 		RepoRefId:              utility.FromStringPtr(p.RepoRefId),
 		CommitQueue:            p.CommitQueue.ToService(),
 		TaskSync:               p.TaskSync.ToService(),
+		ArtifactRetention:      p.ArtifactRetention.ToService(),
 		WorkstationConfig:      p.WorkstationConfig.ToService(),
 		BuildBaronSettings:     p.BuildBaronSettings.ToService(),
 		TaskAnnotationSettings: p.TaskAnnotationSettings.ToService(),
@@ -692,6 +723,7 @@ func (p *APIProjectRef) BuildPublicFields(projectRef model.ProjectRef) error {
 	p.Restricted = utility.BoolPtrCopy(projectRef.Restricted)
 	p.BatchTime = projectRef.BatchTime
 	p.RemotePath = utility.ToStringPtr(projectRef.RemotePath)
+	p.GitURL = utility.ToStringPtr(thirdparty.RedactGitURL(projectRef.GitURL))
 	p.DeactivatePrevious = projectRef.DeactivatePrevious
 	p.TracksPushEvents = utility.BoolPtrCopy(projectRef.TracksPushEvents)
 	p.PRTestingEnabled = utility.BoolPtrCopy(projectRef.PRTestingEnabled)
@@ -727,6 +759,10 @@ func (p *APIProjectRef) BuildPublicFields(projectRef model.ProjectRef) error {
 	taskSync.BuildFromService(projectRef.TaskSync)
 	p.TaskSync = taskSync
 
+	var artifactRetention APIArtifactRetentionSettings
+	artifactRetention.BuildFromService(projectRef.ArtifactRetention)
+	p.ArtifactRetention = artifactRetention
+
 	buildbaronConfig := APIBuildBaronSettings{}
 	buildbaronConfig.BuildFromService(projectRef.BuildBaronSettings)
 	p.BuildBaronSettings = buildbaronConfig
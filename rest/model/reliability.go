@@ -19,6 +19,10 @@ type APITaskReliability struct {
 	NumTestFailed      int     `json:"num_test_failed"`
 	NumSystemFailed    int     `json:"num_system_failed"`
 	NumSetupFailed     int     `json:"num_setup_failed"`
+	NumOOM             int     `json:"num_oom"`
+	NumDiskFull        int     `json:"num_disk_full"`
+	NumExecTimeout     int     `json:"num_exec_timeout"`
+	NumIdleTimeout     int     `json:"num_idle_timeout"`
 	AvgDurationSuccess float64 `json:"avg_duration_success"`
 	SuccessRate        float64 `json:"success_rate"`
 }
@@ -37,6 +41,10 @@ func (tr *APITaskReliability) BuildFromService(in reliability.TaskReliability) {
 	tr.NumTestFailed = in.NumTestFailed
 	tr.NumSystemFailed = in.NumSystemFailed
 	tr.NumSetupFailed = in.NumSetupFailed
+	tr.NumOOM = in.NumOOM
+	tr.NumDiskFull = in.NumDiskFull
+	tr.NumExecTimeout = in.NumExecTimeout
+	tr.NumIdleTimeout = in.NumIdleTimeout
 	tr.AvgDurationSuccess = in.AvgDurationSuccess
 	tr.SuccessRate = in.SuccessRate
 }
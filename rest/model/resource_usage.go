@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
+	"github.com/evergreen-ci/utility"
+)
+
+// APITaskResourceUsage is the time series of resource usage for a task
+// execution.
+type APITaskResourceUsage struct {
+	TaskID    *string                  `json:"task_id"`
+	Execution int                      `json:"execution"`
+	Samples   []APIResourceUsageSample `json:"samples"`
+}
+
+// APIResourceUsageSample is the resource usage of a task's processes at a
+// point in time. CPU, disk and network usage are the change since the
+// previous sample.
+type APIResourceUsageSample struct {
+	Time                 *time.Time        `json:"time"`
+	CPUPercent           float64           `json:"cpu_percent"`
+	MemoryRSSBytes       int               `json:"memory_rss_bytes"`
+	MemoryAvailableBytes int               `json:"memory_available_bytes"`
+	DiskReadBytes        int               `json:"disk_read_bytes"`
+	DiskWriteBytes       int               `json:"disk_write_bytes"`
+	NetworkRecvBytes     int               `json:"network_recv_bytes"`
+	NetworkSentBytes     int               `json:"network_sent_bytes"`
+	NumProcesses         int               `json:"num_processes"`
+	Processes            []APIProcessUsage `json:"processes"`
+}
+
+// APIProcessUsage is the resource usage of a single process in a sample.
+type APIProcessUsage struct {
+	PID      int     `json:"pid"`
+	Command  *string `json:"command"`
+	RSSBytes int     `json:"rss_bytes"`
+}
+
+func (u *APITaskResourceUsage) BuildFromService(usage resourceusage.TaskResourceUsage) {
+	u.TaskID = utility.ToStringPtr(usage.TaskID)
+	u.Execution = usage.Execution
+	u.Samples = make([]APIResourceUsageSample, 0, len(usage.Samples))
+	for _, s := range usage.Samples {
+		apiSample := APIResourceUsageSample{}
+		apiSample.BuildFromService(s)
+		u.Samples = append(u.Samples, apiSample)
+	}
+}
+
+func (s *APIResourceUsageSample) BuildFromService(sample resourceusage.Sample) {
+	s.Time = utility.ToTimePtr(sample.Time)
+	s.CPUPercent = sample.CPUPercent
+	s.MemoryRSSBytes = int(sample.MemoryRSSBytes)
+	s.MemoryAvailableBytes = int(sample.MemoryAvailableBytes)
+	s.DiskReadBytes = int(sample.DiskReadBytes)
+	s.DiskWriteBytes = int(sample.DiskWriteBytes)
+	s.NetworkRecvBytes = int(sample.NetworkRecvBytes)
+	s.NetworkSentBytes = int(sample.NetworkSentBytes)
+	s.NumProcesses = sample.NumProcesses
+	s.Processes = make([]APIProcessUsage, 0, len(sample.Processes))
+	for _, p := range sample.Processes {
+		s.Processes = append(s.Processes, APIProcessUsage{
+			PID:      int(p.PID),
+			Command:  utility.ToStringPtr(p.Command),
+			RSSBytes: int(p.RSSBytes),
+		})
+	}
+}
@@ -37,6 +37,12 @@ type APIGithubMergeSubscriber struct {
 	Ref   *string `json:"ref" mapstructure:"ref"`
 }
 
+type APIGitlabMRSubscriber struct {
+	ProjectPath *string `json:"project_path" mapstructure:"project_path"`
+	MRNumber    int     `json:"mr_number" mapstructure:"mr_number"`
+	Ref         *string `json:"ref" mapstructure:"ref"`
+}
+
 type APIPRInfo struct {
 	Owner       *string `json:"owner" mapstructure:"owner"`
 	Repo        *string `json:"repo" mapstructure:"repo"`
@@ -89,6 +95,14 @@ func (s *APISubscriber) BuildFromService(in event.Subscriber) error {
 		}
 		target = sub
 
+	case event.GitlabMergeRequestSubscriberType:
+		sub := APIGitlabMRSubscriber{}
+		err := sub.BuildFromService(in.Target)
+		if err != nil {
+			return err
+		}
+		target = sub
+
 	case event.EvergreenWebhookSubscriberType:
 		sub := APIWebhookSubscriber{}
 		err := sub.BuildFromService(in.Target)
@@ -154,6 +168,16 @@ func (s *APISubscriber) ToService() (event.Subscriber, error) {
 		}
 		target = apiModel.ToService()
 
+	case event.GitlabMergeRequestSubscriberType:
+		apiModel := APIGitlabMRSubscriber{}
+		if err = mapstructure.Decode(s.Target, &apiModel); err != nil {
+			return event.Subscriber{}, gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrap(err, "GitLab merge request subscriber target is malformed").Error(),
+			}
+		}
+		target = apiModel.ToService()
+
 	case event.EvergreenWebhookSubscriberType:
 		apiModel := APIWebhookSubscriber{}
 		if s.WebhookSubscriber != nil {
@@ -256,6 +280,28 @@ func (s *APIGithubMergeSubscriber) BuildFromService(h interface{}) error {
 	return nil
 }
 
+func (s *APIGitlabMRSubscriber) ToService() event.GitlabMergeRequestSubscriber {
+	return event.GitlabMergeRequestSubscriber{
+		ProjectPath: utility.FromStringPtr(s.ProjectPath),
+		MRNumber:    s.MRNumber,
+		Ref:         utility.FromStringPtr(s.Ref),
+	}
+}
+
+func (s *APIGitlabMRSubscriber) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case *event.GitlabMergeRequestSubscriber:
+		s.ProjectPath = utility.ToStringPtr(v.ProjectPath)
+		s.MRNumber = v.MRNumber
+		s.Ref = utility.ToStringPtr(v.Ref)
+
+	default:
+		return errors.Errorf("programmatic error: expected GitLab merge request subscriber but got type %T", h)
+	}
+
+	return nil
+}
+
 func (s *APIGithubPRSubscriber) ToService() event.GithubPullRequestSubscriber {
 	return event.GithubPullRequestSubscriber{
 		Owner:    utility.FromStringPtr(s.Owner),
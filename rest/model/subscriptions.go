@@ -2,7 +2,9 @@ package model
 
 import (
 	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/notification"
 	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 )
 
@@ -91,3 +93,45 @@ func (s *APISubscription) ToService() (event.Subscription, error) {
 
 	return out, nil
 }
+
+// APINotificationPreview describes the notification that a draft subscription
+// would create for an existing event.
+type APINotificationPreview struct {
+	EventID *string `json:"event_id"`
+	// Matched is true if the subscription's selectors match the event.
+	Matched bool `json:"matched"`
+	// Triggered is true if the subscription's trigger fired for the event.
+	Triggered      bool           `json:"triggered"`
+	NotificationID *string        `json:"notification_id,omitempty"`
+	Subscriber     *APISubscriber `json:"subscriber,omitempty"`
+	// Message is the rendered text of the notification.
+	Message *string `json:"message,omitempty"`
+	// Payload is the structured content that would be sent to the subscriber.
+	Payload interface{} `json:"payload,omitempty"`
+	// Sent is true if the notification was sent to the requesting user.
+	Sent bool `json:"sent"`
+}
+
+// BuildFromService populates the preview from the notification that would
+// be created and the composer that would be used to send it. Both may be nil
+// if the subscription's trigger did not fire.
+func (p *APINotificationPreview) BuildFromService(eventID string, matched bool, n *notification.Notification, c message.Composer) error {
+	p.EventID = utility.ToStringPtr(eventID)
+	p.Matched = matched
+	if n == nil {
+		return nil
+	}
+
+	p.Triggered = true
+	p.NotificationID = utility.ToStringPtr(n.ID)
+	p.Subscriber = &APISubscriber{}
+	if err := p.Subscriber.BuildFromService(n.Subscriber); err != nil {
+		return errors.Wrap(err, "converting subscriber to API model")
+	}
+	if c != nil {
+		p.Message = utility.ToStringPtr(c.String())
+		p.Payload = c.Raw()
+	}
+
+	return nil
+}
@@ -57,6 +57,7 @@ type APITask struct {
 	Status                      *string             `json:"status"`
 	DisplayStatus               *string             `json:"display_status"`
 	Details                     ApiTaskEndDetail    `json:"status_details"`
+	FailureClassification       *string             `json:"failure_classification"`
 	Logs                        LogLinks            `json:"logs"`
 	ParsleyLogs                 LogLinks            `json:"parsley_logs"`
 	TimeTaken                   APIDuration         `json:"time_taken_ms"`
@@ -115,6 +116,7 @@ type ApiTaskEndDetail struct {
 	TimedOut    bool              `json:"timed_out"`
 	TimeoutType *string           `json:"timeout_type"`
 	OOMTracker  APIOomTrackerInfo `json:"oom_tracker_info"`
+	DiskFull    bool              `json:"disk_full"`
 	TraceID     *string           `json:"trace_id"`
 }
 
@@ -128,6 +130,7 @@ func (at *ApiTaskEndDetail) BuildFromService(t apimodels.TaskEndDetail) error {
 	apiOomTracker := APIOomTrackerInfo{}
 	apiOomTracker.BuildFromService(t.OOMTracker)
 	at.OOMTracker = apiOomTracker
+	at.DiskFull = t.DiskFull
 	at.TraceID = utility.ToStringPtr(t.TraceID)
 
 	return nil
@@ -141,6 +144,7 @@ func (ad *ApiTaskEndDetail) ToService() apimodels.TaskEndDetail {
 		TimedOut:    ad.TimedOut,
 		TimeoutType: utility.FromStringPtr(ad.TimeoutType),
 		OOMTracker:  ad.OOMTracker.ToService(),
+		DiskFull:    ad.DiskFull,
 		TraceID:     utility.FromStringPtr(ad.TraceID),
 	}
 }
@@ -226,6 +230,7 @@ func (at *APITask) buildTask(t *task.Task) error {
 		Order:                       t.RevisionOrderNumber,
 		Status:                      utility.ToStringPtr(t.Status),
 		DisplayStatus:               utility.ToStringPtr(t.GetDisplayStatus()),
+		FailureClassification:       utility.ToStringPtr(t.FailureClassification),
 		ExpectedDuration:            NewAPIDuration(t.ExpectedDuration),
 		GenerateTask:                t.GenerateTask,
 		GeneratedBy:                 t.GeneratedBy,
@@ -422,6 +427,7 @@ func (at *APITask) ToService() (*task.Task, error) {
 		RevisionOrderNumber:         at.Order,
 		Status:                      utility.FromStringPtr(at.Status),
 		DisplayStatus:               utility.FromStringPtr(at.DisplayStatus),
+		FailureClassification:       utility.FromStringPtr(at.FailureClassification),
 		TimeTaken:                   at.TimeTaken.ToDuration(),
 		ExpectedDuration:            at.ExpectedDuration.ToDuration(),
 		GenerateTask:                at.GenerateTask,
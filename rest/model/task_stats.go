@@ -21,6 +21,10 @@ type APITaskStats struct {
 	NumTestFailed      int     `json:"num_test_failed"`
 	NumSystemFailed    int     `json:"num_system_failed"`
 	NumSetupFailed     int     `json:"num_setup_failed"`
+	NumOOM             int     `json:"num_oom"`
+	NumDiskFull        int     `json:"num_disk_full"`
+	NumExecTimeout     int     `json:"num_exec_timeout"`
+	NumIdleTimeout     int     `json:"num_idle_timeout"`
 	AvgDurationSuccess float64 `json:"avg_duration_success"`
 }
 
@@ -38,6 +42,10 @@ func (ts *APITaskStats) BuildFromService(v taskstats.TaskStats) {
 	ts.NumTestFailed = v.NumTestFailed
 	ts.NumSystemFailed = v.NumSystemFailed
 	ts.NumSetupFailed = v.NumSetupFailed
+	ts.NumOOM = v.NumOOM
+	ts.NumDiskFull = v.NumDiskFull
+	ts.NumExecTimeout = v.NumExecTimeout
+	ts.NumIdleTimeout = v.NumIdleTimeout
 	ts.AvgDurationSuccess = v.AvgDurationSuccess
 }
 
@@ -26,6 +26,33 @@ func (pk *APIPubKey) BuildFromService(in user.PubKey) {
 	pk.Key = utility.ToStringPtr(in.Key)
 }
 
+// APIAccessToken is the model for a personal access token. The plaintext token
+// is only ever returned when the token is created.
+type APIAccessToken struct {
+	Id         *string    `json:"id"`
+	Name       *string    `json:"name"`
+	Token      *string    `json:"token,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	Projects   []string   `json:"projects"`
+	CreatedAt  *time.Time `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// BuildFromService converts from service level structs to an APIAccessToken.
+func (t *APIAccessToken) BuildFromService(in user.AccessToken) {
+	t.Id = utility.ToStringPtr(in.Id)
+	t.Name = utility.ToStringPtr(in.Name)
+	t.Scopes = []string{}
+	for _, s := range in.Scopes {
+		t.Scopes = append(t.Scopes, string(s))
+	}
+	t.Projects = in.Projects
+	t.CreatedAt = ToTimePtr(in.CreatedAt)
+	t.ExpiresAt = ToTimePtr(in.ExpiresAt)
+	t.LastUsedAt = ToTimePtr(in.LastUsedAt)
+}
+
 type APIUserSettings struct {
 	Timezone         *string                     `json:"timezone"`
 	Region           *string                     `json:"region"`
@@ -0,0 +1,180 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	dbModel "github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+////////////////////////////////////////////////////////////////////////
+//
+// GET /rest/v2/user/tokens
+
+type accessTokensGetHandler struct{}
+
+func makeFetchAccessTokens() gimlet.RouteHandler {
+	return &accessTokensGetHandler{}
+}
+
+func (h *accessTokensGetHandler) Factory() gimlet.RouteHandler {
+	return &accessTokensGetHandler{}
+}
+
+func (h *accessTokensGetHandler) Parse(ctx context.Context, r *http.Request) error { return nil }
+
+func (h *accessTokensGetHandler) Run(ctx context.Context) gimlet.Responder {
+	u := MustHaveUser(ctx)
+
+	tokens, err := user.FindAccessTokensByUser(u.Id)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding access tokens for user '%s'", u.Id))
+	}
+
+	resp := gimlet.NewResponseBuilder()
+	for _, t := range tokens {
+		apiToken := &model.APIAccessToken{}
+		apiToken.BuildFromService(t)
+		if err := resp.AddData(apiToken); err != nil {
+			return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "adding access token to response"))
+		}
+	}
+
+	return resp
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// POST /rest/v2/user/tokens
+
+type accessTokenPostHandler struct {
+	token model.APIAccessToken
+}
+
+func makeCreateAccessToken() gimlet.RouteHandler {
+	return &accessTokenPostHandler{}
+}
+
+func (h *accessTokenPostHandler) Factory() gimlet.RouteHandler {
+	return &accessTokenPostHandler{}
+}
+
+func (h *accessTokenPostHandler) Parse(ctx context.Context, r *http.Request) error {
+	body := utility.NewRequestReader(r)
+	defer body.Close()
+
+	if err := utility.ReadJSON(body, &h.token); err != nil {
+		return errors.Wrap(err, "reading access token from JSON request body")
+	}
+	if utility.FromStringPtr(h.token.Name) == "" {
+		return errors.New("access token name cannot be empty")
+	}
+	if len(h.token.Scopes) == 0 {
+		return errors.New("access token must have at least one scope")
+	}
+	for _, s := range h.token.Scopes {
+		if err := user.AccessTokenScope(s).Validate(); err != nil {
+			return err
+		}
+	}
+	if h.token.ExpiresAt == nil {
+		return errors.New("access token must have an expiration time")
+	}
+
+	return nil
+}
+
+func (h *accessTokenPostHandler) Run(ctx context.Context) gimlet.Responder {
+	u := MustHaveUser(ctx)
+	// A token must not be able to mint new tokens, otherwise a scoped token
+	// could be used to obtain a token with broader scopes.
+	if getAccessToken(ctx) != nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    "access tokens cannot be used to create access tokens",
+		})
+	}
+
+	projects := []string{}
+	for _, identifier := range h.token.Projects {
+		pRef, err := dbModel.FindBranchProjectRef(identifier)
+		if err != nil {
+			return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding project '%s'", identifier))
+		}
+		if pRef == nil {
+			return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    fmt.Sprintf("project '%s' not found", identifier),
+			})
+		}
+		projects = append(projects, pRef.Id)
+	}
+
+	scopes := []user.AccessTokenScope{}
+	for _, s := range h.token.Scopes {
+		scopes = append(scopes, user.AccessTokenScope(s))
+	}
+
+	t, token, err := user.CreateAccessToken(u.Id, utility.FromStringPtr(h.token.Name), scopes, projects, *h.token.ExpiresAt)
+	if err != nil {
+		return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "creating access token"))
+	}
+
+	apiToken := &model.APIAccessToken{}
+	apiToken.BuildFromService(*t)
+	apiToken.Token = utility.ToStringPtr(token)
+
+	return gimlet.NewJSONResponse(apiToken)
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// DELETE /rest/v2/user/tokens/{token_id}
+
+type accessTokenDeleteHandler struct {
+	tokenID string
+}
+
+func makeRevokeAccessToken() gimlet.RouteHandler {
+	return &accessTokenDeleteHandler{}
+}
+
+func (h *accessTokenDeleteHandler) Factory() gimlet.RouteHandler {
+	return &accessTokenDeleteHandler{}
+}
+
+func (h *accessTokenDeleteHandler) Parse(ctx context.Context, r *http.Request) error {
+	h.tokenID = gimlet.GetVars(r)["token_id"]
+	if h.tokenID == "" {
+		return errors.New("access token ID cannot be empty")
+	}
+
+	return nil
+}
+
+func (h *accessTokenDeleteHandler) Run(ctx context.Context) gimlet.Responder {
+	u := MustHaveUser(ctx)
+
+	t, err := user.FindOneAccessToken(user.AccessTokenByUserAndId(u.Id, h.tokenID))
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding access token '%s'", h.tokenID))
+	}
+	if t == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("access token '%s' not found", h.tokenID),
+		})
+	}
+
+	if err := user.RevokeAccessToken(u.Id, h.tokenID); err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "revoking access token '%s'", h.tokenID))
+	}
+
+	return gimlet.NewJSONResponse(struct{}{})
+}
@@ -0,0 +1,152 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	dbModel "github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTokenRoutes(t *testing.T) {
+	u := &user.DBUser{Id: "me"}
+
+	makeCreateRequest := func(t *testing.T, token model.APIAccessToken) *http.Request {
+		body, err := json.Marshal(token)
+		require.NoError(t, err)
+		r, err := http.NewRequest(http.MethodPost, "/user/tokens", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		return r
+	}
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T){
+		"CreateReturnsTokenOnce": func(ctx context.Context, t *testing.T) {
+			expiresAt := time.Now().Add(time.Hour)
+			h := makeCreateAccessToken()
+			require.NoError(t, h.Parse(ctx, makeCreateRequest(t, model.APIAccessToken{
+				Name:      utility.ToStringPtr("ci"),
+				Scopes:    []string{string(user.AccessTokenScopeReadOnly)},
+				Projects:  []string{"project_identifier"},
+				ExpiresAt: &expiresAt,
+			})))
+			resp := h.Run(ctx)
+			require.Equal(t, http.StatusOK, resp.Status())
+			created, ok := resp.Data().(*model.APIAccessToken)
+			require.True(t, ok)
+			assert.True(t, user.IsAccessToken(utility.FromStringPtr(created.Token)))
+			assert.Equal(t, []string{"project_id"}, created.Projects)
+
+			list := makeFetchAccessTokens()
+			resp = list.Run(ctx)
+			require.Equal(t, http.StatusOK, resp.Status())
+			tokens, ok := resp.Data().([]interface{})
+			require.True(t, ok)
+			require.Len(t, tokens, 1)
+			listed, ok := tokens[0].(*model.APIAccessToken)
+			require.True(t, ok)
+			assert.Equal(t, utility.FromStringPtr(created.Id), utility.FromStringPtr(listed.Id))
+			assert.Nil(t, listed.Token)
+		},
+		"CreateFailsForNonexistentProject": func(ctx context.Context, t *testing.T) {
+			expiresAt := time.Now().Add(time.Hour)
+			h := makeCreateAccessToken()
+			require.NoError(t, h.Parse(ctx, makeCreateRequest(t, model.APIAccessToken{
+				Name:      utility.ToStringPtr("ci"),
+				Scopes:    []string{string(user.AccessTokenScopeAll)},
+				Projects:  []string{"nonexistent"},
+				ExpiresAt: &expiresAt,
+			})))
+			resp := h.Run(ctx)
+			assert.Equal(t, http.StatusNotFound, resp.Status())
+		},
+		"CreateFailsWithAccessToken": func(ctx context.Context, t *testing.T) {
+			expiresAt := time.Now().Add(time.Hour)
+			h := makeCreateAccessToken()
+			require.NoError(t, h.Parse(ctx, makeCreateRequest(t, model.APIAccessToken{
+				Name:      utility.ToStringPtr("ci"),
+				Scopes:    []string{string(user.AccessTokenScopeAll)},
+				ExpiresAt: &expiresAt,
+			})))
+			ctx = context.WithValue(ctx, accessTokenKey, &user.AccessToken{Scopes: []user.AccessTokenScope{user.AccessTokenScopeAll}})
+			resp := h.Run(ctx)
+			assert.Equal(t, http.StatusForbidden, resp.Status())
+		},
+		"ParseFailsWithInvalidInput": func(ctx context.Context, t *testing.T) {
+			expiresAt := time.Now().Add(time.Hour)
+			h := makeCreateAccessToken()
+			assert.Error(t, h.Parse(ctx, makeCreateRequest(t, model.APIAccessToken{
+				Scopes:    []string{string(user.AccessTokenScopeAll)},
+				ExpiresAt: &expiresAt,
+			})), "should require name")
+			assert.Error(t, h.Parse(ctx, makeCreateRequest(t, model.APIAccessToken{
+				Name:      utility.ToStringPtr("ci"),
+				ExpiresAt: &expiresAt,
+			})), "should require scope")
+			assert.Error(t, h.Parse(ctx, makeCreateRequest(t, model.APIAccessToken{
+				Name:      utility.ToStringPtr("ci"),
+				Scopes:    []string{"admin"},
+				ExpiresAt: &expiresAt,
+			})), "should require valid scope")
+			assert.Error(t, h.Parse(ctx, makeCreateRequest(t, model.APIAccessToken{
+				Name:   utility.ToStringPtr("ci"),
+				Scopes: []string{string(user.AccessTokenScopeAll)},
+			})), "should require expiration")
+		},
+		"RevokeDeletesToken": func(ctx context.Context, t *testing.T) {
+			created, token, err := user.CreateAccessToken(u.Id, "ci", []user.AccessTokenScope{user.AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+
+			h := makeRevokeAccessToken()
+			r, err := http.NewRequest(http.MethodDelete, "/user/tokens/"+created.Id, nil)
+			require.NoError(t, err)
+			r = gimlet.SetURLVars(r, map[string]string{"token_id": created.Id})
+			require.NoError(t, h.Parse(ctx, r))
+			resp := h.Run(ctx)
+			require.Equal(t, http.StatusOK, resp.Status())
+
+			found, err := user.FindAccessTokenByToken(token)
+			require.NoError(t, err)
+			assert.Nil(t, found)
+		},
+		"RevokeFailsForOtherUsersToken": func(ctx context.Context, t *testing.T) {
+			created, _, err := user.CreateAccessToken("someone_else", "ci", []user.AccessTokenScope{user.AccessTokenScopeAll}, nil, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+
+			h := makeRevokeAccessToken()
+			r, err := http.NewRequest(http.MethodDelete, "/user/tokens/"+created.Id, nil)
+			require.NoError(t, err)
+			r = gimlet.SetURLVars(r, map[string]string{"token_id": created.Id})
+			require.NoError(t, h.Parse(ctx, r))
+			resp := h.Run(ctx)
+			assert.Equal(t, http.StatusNotFound, resp.Status())
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.ClearCollections(user.AccessTokenCollection, dbModel.ProjectRefCollection))
+			defer func() {
+				assert.NoError(t, db.ClearCollections(user.AccessTokenCollection, dbModel.ProjectRefCollection))
+			}()
+			pRef := dbModel.ProjectRef{
+				Id:         "project_id",
+				Identifier: "project_identifier",
+			}
+			require.NoError(t, pRef.Insert())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctx = gimlet.AttachUser(ctx, u)
+
+			tCase(ctx, t)
+		})
+	}
+}
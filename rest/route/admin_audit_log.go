@@ -0,0 +1,94 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+const auditLogFormatJSON = "json"
+
+////////////////////////////////////////////////////////////////////////
+//
+// GET /rest/v2/admin/audit_log
+
+type auditLogGetHandler struct {
+	query  model.AuditLogQuery
+	format string
+}
+
+func makeFetchAuditLog() gimlet.RouteHandler {
+	return &auditLogGetHandler{}
+}
+
+func (h *auditLogGetHandler) Factory() gimlet.RouteHandler {
+	return &auditLogGetHandler{}
+}
+
+func (h *auditLogGetHandler) Parse(ctx context.Context, r *http.Request) error {
+	vals := r.URL.Query()
+	h.query = model.AuditLogQuery{
+		Actor:        vals.Get("actor"),
+		ResourceType: vals.Get("resource_type"),
+		ResourceId:   vals.Get("resource_id"),
+	}
+
+	var err error
+	if start := vals.Get("start_time"); start != "" {
+		if h.query.StartTime, err = time.Parse(time.RFC3339, start); err != nil {
+			return errors.Wrap(err, "parsing start time as RFC-3339")
+		}
+	}
+	if end := vals.Get("end_time"); end != "" {
+		if h.query.EndTime, err = time.Parse(time.RFC3339, end); err != nil {
+			return errors.Wrap(err, "parsing end time as RFC-3339")
+		}
+	}
+	if limit := vals.Get("limit"); limit != "" {
+		if h.query.Limit, err = strconv.Atoi(limit); err != nil {
+			return errors.Wrap(err, "parsing limit")
+		}
+	}
+	if err = h.query.Validate(); err != nil {
+		return errors.Wrap(err, "invalid audit log query")
+	}
+
+	h.format = vals.Get("format")
+	switch h.format {
+	case "":
+		h.format = auditLogFormatJSON
+	case auditLogFormatJSON, model.AuditLogFormatJSONL, model.AuditLogFormatCSV:
+	default:
+		return errors.Errorf("invalid format '%s', must be one of: %s, %s, %s", h.format, auditLogFormatJSON, model.AuditLogFormatJSONL, model.AuditLogFormatCSV)
+	}
+
+	return nil
+}
+
+func (h *auditLogGetHandler) Run(ctx context.Context) gimlet.Responder {
+	entries, err := model.FindAuditLog(h.query)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "finding audit log"))
+	}
+
+	buf := &bytes.Buffer{}
+	switch h.format {
+	case model.AuditLogFormatJSONL:
+		err = model.WriteAuditLogJSONL(buf, entries)
+	case model.AuditLogFormatCSV:
+		err = model.WriteAuditLogCSV(buf, entries)
+	default:
+		return gimlet.NewJSONResponse(entries)
+	}
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "exporting audit log as %s", h.format))
+	}
+
+	return gimlet.NewTextResponse(buf.String())
+}
@@ -0,0 +1,75 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogGetHandlerParse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parse := func(t *testing.T, query string) (*auditLogGetHandler, error) {
+		h := makeFetchAuditLog().(*auditLogGetHandler)
+		r, err := http.NewRequest(http.MethodGet, "/admin/audit_log?"+query, nil)
+		require.NoError(t, err)
+		return h, h.Parse(ctx, r)
+	}
+
+	t.Run("DefaultsToJSON", func(t *testing.T) {
+		h, err := parse(t, "")
+		require.NoError(t, err)
+		assert.Equal(t, auditLogFormatJSON, h.format)
+		assert.Zero(t, h.query)
+	})
+	t.Run("ParsesFilters", func(t *testing.T) {
+		h, err := parse(t, "actor=me&resource_type=PROJECT&resource_id=p1&start_time=2023-01-01T00:00:00Z&end_time=2023-02-01T00:00:00Z&limit=5&format=csv")
+		require.NoError(t, err)
+		assert.Equal(t, "me", h.query.Actor)
+		assert.Equal(t, event.EventResourceTypeProject, h.query.ResourceType)
+		assert.Equal(t, "p1", h.query.ResourceId)
+		assert.Equal(t, 2023, h.query.StartTime.Year())
+		assert.Equal(t, 5, h.query.Limit)
+		assert.Equal(t, model.AuditLogFormatCSV, h.format)
+	})
+	t.Run("RejectsInvalidInput", func(t *testing.T) {
+		for _, query := range []string{
+			"format=xml",
+			"resource_type=HOST",
+			"start_time=yesterday",
+			"start_time=2023-02-01T00:00:00Z&end_time=2023-01-01T00:00:00Z",
+			"limit=many",
+		} {
+			_, err := parse(t, query)
+			assert.Error(t, err, query)
+		}
+	})
+}
+
+func TestAuditLogGetHandlerRun(t *testing.T) {
+	require.NoError(t, db.ClearCollections(event.EventCollection))
+	defer func() {
+		assert.NoError(t, db.ClearCollections(event.EventCollection))
+	}()
+	require.NoError(t, event.LogUserEventByActor("u1", "admin", event.UserEventTypeRolesUpdate, []string{}, []string{"r1"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := &auditLogGetHandler{format: model.AuditLogFormatJSONL}
+	resp := h.Run(ctx)
+	require.Equal(t, http.StatusOK, resp.Status())
+	out, ok := resp.Data().(string)
+	require.True(t, ok)
+	num, err := model.VerifyAuditLogChain(strings.NewReader(out))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, num)
+}
@@ -4,18 +4,21 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/annotations"
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/manifest"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/thirdparty"
 	"github.com/evergreen-ci/gimlet"
@@ -389,16 +392,23 @@ func (h *getExpansionsAndVarsHandler) Run(ctx context.Context) gimlet.Responder
 		})
 	}
 
-	appToken, err := h.settings.CreateInstallationToken(ctx, pRef.Owner, pRef.Repo, nil)
-	if err != nil {
-		grip.Debug(message.WrapError(err, message.Fields{
-			"ticket":  "EVG-19966",
-			"message": "error creating GitHub app token",
-			"caller":  "getExpansionsAndVarsHandler",
-			"owner":   pRef.Owner,
-			"repo":    pRef.Repo,
-			"task":    t.Id,
-		}))
+	// Projects with a git URL aren't hosted on GitHub, so their tasks don't
+	// get GitHub credentials.
+	var appToken string
+	if pRef.GitURL == "" {
+		appToken, err = h.settings.CreateInstallationToken(ctx, pRef.Owner, pRef.Repo, nil)
+		if err != nil {
+			grip.Debug(message.WrapError(err, message.Fields{
+				"ticket":  "EVG-19966",
+				"message": "error creating GitHub app token",
+				"caller":  "getExpansionsAndVarsHandler",
+				"owner":   pRef.Owner,
+				"repo":    pRef.Repo,
+				"task":    t.Id,
+			}))
+		}
+	} else {
+		oauthToken = ""
 	}
 
 	e, err := model.PopulateExpansions(t, foundHost, oauthToken, appToken)
@@ -423,6 +433,15 @@ func (h *getExpansionsAndVarsHandler) Run(ctx context.Context) gimlet.Responder
 			res.PrivateVars = projectVars.PrivateVars
 		}
 	}
+	// Repo variables are merged into the project's, so references to either
+	// one's secrets are allowed.
+	secretOwners := []string{pRef.Id}
+	if pRef.RepoRefId != "" {
+		secretOwners = append(secretOwners, pRef.RepoRefId)
+	}
+	if err = model.ResolveExternalVars(ctx, h.settings.Secrets, secretOwners, res.Vars); err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "resolving project vars from external secret stores"))
+	}
 
 	v, err := model.VersionFindOneId(t.Version)
 	if err != nil {
@@ -559,6 +578,85 @@ func (h *getParserProjectHandler) Run(ctx context.Context) gimlet.Responder {
 	return gimlet.NewBinaryResponse(projBytes)
 }
 
+// GET /task/{task_id}/shard_tests
+type getShardTestsHandler struct {
+	taskID string
+	env    evergreen.Environment
+}
+
+func makeGetShardTests(env evergreen.Environment) gimlet.RouteHandler {
+	return &getShardTestsHandler{env: env}
+}
+
+func (h *getShardTestsHandler) Factory() gimlet.RouteHandler {
+	return &getShardTestsHandler{env: h.env}
+}
+
+func (h *getShardTestsHandler) Parse(ctx context.Context, r *http.Request) error {
+	if h.taskID = gimlet.GetVars(r)["task_id"]; h.taskID == "" {
+		return errors.New("missing task ID")
+	}
+	return nil
+}
+
+// Run returns the tests assigned to the task's shard, splitting the tests
+// between the shards of the task's build if this is the first shard to ask.
+// The project is only translated when the tests have not been split yet.
+func (h *getShardTestsHandler) Run(ctx context.Context) gimlet.Responder {
+	t, err := task.FindOneId(h.taskID)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding task '%s'", h.taskID))
+	}
+	if t == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("task '%s' not found", h.taskID),
+		})
+	}
+	assignment, err := model.FindTestShardAssignmentForTask(t.BuildId, t.DisplayName)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding test shard assignment for task '%s'", t.Id))
+	}
+	if assignment != nil {
+		return gimlet.NewJSONResponse(newShardTests(assignment, assignment.ShardIndex(t.DisplayName)))
+	}
+
+	v, err := model.VersionFindOne(model.VersionById(t.Version))
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding version '%s'", t.Version))
+	}
+	if v == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("version '%s' not found", t.Version),
+		})
+	}
+	p, _, err := model.FindAndTranslateProjectForVersion(ctx, h.env.Settings(), v)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "getting project for version '%s'", v.Id))
+	}
+	pt := p.FindProjectTask(t.DisplayName)
+	if pt == nil || pt.Shard == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("task '%s' is not sharded", t.DisplayName),
+		})
+	}
+
+	assignment, err = model.GetTestShardAssignment(ctx, h.env, p, t)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "getting test shard assignment for task '%s'", t.Id))
+	}
+
+	return gimlet.NewJSONResponse(newShardTests(assignment, pt.Shard.Index))
+}
+
+func newShardTests(assignment *model.TestShardAssignment, index int) apimodels.ShardTests {
+	res := apimodels.ShardTests{HasHistory: assignment.HasHistory()}
+	res.Tests, res.OtherTests = assignment.TestsForShard(index)
+	return res
+}
+
 // GET /task/{task_id}/distro_view
 type getDistroViewHandler struct {
 	hostID string
@@ -645,6 +743,9 @@ func (h *attachFilesHandler) Run(ctx context.Context) gimlet.Responder {
 		BuildId:         t.BuildId,
 		Execution:       t.Execution,
 		CreateTime:      time.Now(),
+		Project:         t.Project,
+		Requester:       t.Requester,
+		VersionId:       t.Version,
 		Files:           h.files,
 	}
 
@@ -653,9 +754,109 @@ func (h *attachFilesHandler) Run(ctx context.Context) gimlet.Responder {
 		grip.Error(message)
 		return gimlet.MakeJSONInternalErrorResponder(errors.New(message))
 	}
+
+	// The files are already attached, so failing to record their provenance
+	// shouldn't fail the command that attached them.
+	grip.Error(message.WrapError(model.CreateArtifactProvenance(ctx, evergreen.GetEnvironment().Settings(), t, h.files), message.Fields{
+		"message":   "could not create artifact provenance",
+		"task_id":   t.Id,
+		"execution": t.Execution,
+	}))
+
 	return gimlet.NewJSONResponse(fmt.Sprintf("Artifact files for task %s successfully attached", t.Id))
 }
 
+// POST /task/{task_id}/resource_usage
+type attachResourceUsageHandler struct {
+	taskID    string
+	execution int
+	samples   []resourceusage.Sample
+}
+
+func makeAttachResourceUsage() gimlet.RouteHandler {
+	return &attachResourceUsageHandler{}
+}
+
+func (h *attachResourceUsageHandler) Factory() gimlet.RouteHandler {
+	return &attachResourceUsageHandler{}
+}
+
+func (h *attachResourceUsageHandler) Parse(ctx context.Context, r *http.Request) error {
+	if h.taskID = gimlet.GetVars(r)["task_id"]; h.taskID == "" {
+		return errors.New("missing task ID")
+	}
+	execution, err := strconv.Atoi(r.URL.Query().Get("execution"))
+	if err != nil {
+		return errors.Wrap(err, "parsing execution")
+	}
+	h.execution = execution
+	if err = utility.ReadJSON(r.Body, &h.samples); err != nil {
+		return errors.Wrapf(err, "reading resource usage samples for task '%s'", h.taskID)
+	}
+	return nil
+}
+
+// Run appends the samples to the task execution's resource usage.
+func (h *attachResourceUsageHandler) Run(ctx context.Context) gimlet.Responder {
+	t, err := task.FindOneIdAndExecution(h.taskID, h.execution)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding task '%s' execution %d", h.taskID, h.execution))
+	}
+	if t == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("task '%s' execution %d not found", h.taskID, h.execution),
+		})
+	}
+	if err = resourceusage.AppendSamples(h.taskID, h.execution, h.samples); err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "attaching resource usage to task '%s'", h.taskID))
+	}
+	return gimlet.NewJSONResponse(struct{}{})
+}
+
+// POST /task/{task_id}/annotation/metadata_links
+type setAnnotationMetadataLinksHandler struct {
+	taskID string
+	links  []annotations.MetadataLink
+}
+
+func makeSetAnnotationMetadataLinks() gimlet.RouteHandler {
+	return &setAnnotationMetadataLinksHandler{}
+}
+
+func (h *setAnnotationMetadataLinksHandler) Factory() gimlet.RouteHandler {
+	return &setAnnotationMetadataLinksHandler{}
+}
+
+func (h *setAnnotationMetadataLinksHandler) Parse(ctx context.Context, r *http.Request) error {
+	if h.taskID = gimlet.GetVars(r)["task_id"]; h.taskID == "" {
+		return errors.New("missing task ID")
+	}
+	if err := utility.ReadJSON(r.Body, &h.links); err != nil {
+		return errors.Wrapf(err, "reading metadata links for task '%s'", h.taskID)
+	}
+	return errors.Wrap(annotations.ValidateMetadataLinks(h.links...), "invalid metadata links")
+}
+
+// Run sets the metadata links on the annotation for the task's current
+// execution.
+func (h *setAnnotationMetadataLinksHandler) Run(ctx context.Context) gimlet.Responder {
+	t, err := task.FindOneId(h.taskID)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding task '%s'", h.taskID))
+	}
+	if t == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("task '%s' not found", h.taskID),
+		})
+	}
+	if err = annotations.SetAnnotationMetadataLinks(ctx, t.Id, t.Execution, t.Id, annotations.TaskRequester, h.links...); err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "setting metadata links for task '%s'", h.taskID))
+	}
+	return gimlet.NewJSONResponse(struct{}{})
+}
+
 // POST /task/{task_id}/test_logs
 type attachTestLogHandler struct {
 	settings *evergreen.Settings
@@ -13,6 +13,7 @@ import (
 	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
 	"github.com/evergreen-ci/evergreen/mock"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/annotations"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/patch"
@@ -557,3 +558,53 @@ func TestAgentGetProjectRef(t *testing.T) {
 		})
 	}
 }
+
+func TestSetAnnotationMetadataLinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, db.ClearCollections(task.Collection, annotations.Collection))
+	defer func() {
+		assert.NoError(t, db.ClearCollections(task.Collection, annotations.Collection))
+	}()
+
+	tsk := task.Task{
+		Id:        "task1",
+		Execution: 2,
+	}
+	require.NoError(t, tsk.Insert())
+
+	t.Run("ParseRejectsInvalidLinks", func(t *testing.T) {
+		h := makeSetAnnotationMetadataLinks()
+		body := []byte(`[{"url": "not a url", "text": "progress"}]`)
+		r, err := http.NewRequest(http.MethodPost, "/task/task1/annotation/metadata_links", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		r = gimlet.SetURLVars(r, map[string]string{"task_id": tsk.Id})
+		assert.Error(t, h.Parse(ctx, r))
+	})
+	t.Run("SetsLinksForCurrentExecution", func(t *testing.T) {
+		h := makeSetAnnotationMetadataLinks()
+		body := []byte(`[{"url": "https://example.com/progress", "text": "progress"}]`)
+		r, err := http.NewRequest(http.MethodPost, "/task/task1/annotation/metadata_links", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		r = gimlet.SetURLVars(r, map[string]string{"task_id": tsk.Id})
+		require.NoError(t, h.Parse(ctx, r))
+
+		resp := h.Run(ctx)
+		require.Equal(t, http.StatusOK, resp.Status())
+
+		annotation, err := annotations.FindOneByTaskIdAndExecution(tsk.Id, tsk.Execution)
+		require.NoError(t, err)
+		require.NotZero(t, annotation)
+		require.Len(t, annotation.MetadataLinks, 1)
+		assert.Equal(t, "https://example.com/progress", annotation.MetadataLinks[0].URL)
+		assert.Equal(t, "progress", annotation.MetadataLinks[0].Text)
+		require.NotZero(t, annotation.MetadataLinks[0].Source)
+		assert.Equal(t, annotations.TaskRequester, annotation.MetadataLinks[0].Source.Requester)
+	})
+	t.Run("FailsForNonexistentTask", func(t *testing.T) {
+		h := &setAnnotationMetadataLinksHandler{taskID: "nonexistent"}
+		resp := h.Run(ctx)
+		assert.Equal(t, http.StatusNotFound, resp.Status())
+	})
+}
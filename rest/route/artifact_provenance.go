@@ -0,0 +1,118 @@
+package route
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/evergreen-ci/evergreen"
+	dbModel "github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// GET /tasks/{task_id}/artifacts/provenance
+type getTaskArtifactProvenanceHandler struct {
+	taskID    string
+	execution *int
+}
+
+func makeGetTaskArtifactProvenanceHandler() gimlet.RouteHandler {
+	return &getTaskArtifactProvenanceHandler{}
+}
+
+func (h *getTaskArtifactProvenanceHandler) Factory() gimlet.RouteHandler {
+	return &getTaskArtifactProvenanceHandler{}
+}
+
+// Parse fetches the task ID and the optional execution from the request.
+func (h *getTaskArtifactProvenanceHandler) Parse(ctx context.Context, r *http.Request) error {
+	h.taskID = gimlet.GetVars(r)["task_id"]
+	if executionStr := r.URL.Query().Get("execution"); executionStr != "" {
+		execution, err := strconv.Atoi(executionStr)
+		if err != nil {
+			return gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "invalid execution",
+			}
+		}
+		h.execution = &execution
+	}
+	return nil
+}
+
+// Run returns the signed provenance for the files that the task execution
+// attached. It defaults to the task's latest execution.
+func (h *getTaskArtifactProvenanceHandler) Run(ctx context.Context) gimlet.Responder {
+	t, err := task.FindByIdExecution(h.taskID, h.execution)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding task '%s'", h.taskID))
+	}
+	if t == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("task '%s' not found", h.taskID),
+		})
+	}
+
+	provenance, err := artifact.FindProvenanceByTaskIDAndExecution(h.taskID, t.Execution)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding artifact provenance for task '%s'", h.taskID))
+	}
+
+	apiProvenance := []model.APIArtifactProvenance{}
+	for _, p := range provenance {
+		var apiP model.APIArtifactProvenance
+		apiP.BuildFromService(p)
+		apiProvenance = append(apiProvenance, apiP)
+	}
+	return gimlet.NewJSONResponse(apiProvenance)
+}
+
+// GET /artifacts/provenance/public_key
+type getProvenancePublicKeyHandler struct {
+	env evergreen.Environment
+}
+
+func makeGetProvenancePublicKeyHandler(env evergreen.Environment) gimlet.RouteHandler {
+	return &getProvenancePublicKeyHandler{env: env}
+}
+
+func (h *getProvenancePublicKeyHandler) Factory() gimlet.RouteHandler {
+	return &getProvenancePublicKeyHandler{env: h.env}
+}
+
+func (h *getProvenancePublicKeyHandler) Parse(ctx context.Context, r *http.Request) error {
+	return nil
+}
+
+// Run returns the public key that verifies provenance statements.
+func (h *getProvenancePublicKeyHandler) Run(ctx context.Context) gimlet.Responder {
+	key, err := dbModel.LoadProvenanceSigningKey(h.env.Settings())
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "loading provenance signing key"))
+	}
+	if key == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "provenance signing is not configured",
+		})
+	}
+
+	pub := key.Public().(ed25519.PublicKey)
+	pubPEM, err := artifact.MarshalProvenancePublicKey(pub)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "marshalling provenance public key"))
+	}
+
+	return gimlet.NewJSONResponse(model.APIProvenancePublicKey{
+		KeyID:     utility.ToStringPtr(artifact.ProvenanceKeyID(pub)),
+		PublicKey: utility.ToStringPtr(string(pubPEM)),
+	})
+}
@@ -0,0 +1,111 @@
+package route
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/mock"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTaskArtifactProvenance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, db.ClearCollections(task.Collection, task.OldCollection, artifact.ProvenanceCollection))
+	tsk := task.Task{Id: "t1", Execution: 1}
+	require.NoError(t, tsk.Insert())
+	oldTask := task.Task{Id: "t1_0", OldTaskId: "t1", Execution: 0}
+	require.NoError(t, db.Insert(task.OldCollection, oldTask))
+
+	for _, p := range []artifact.Provenance{
+		artifact.NewProvenance("t1", 1, artifact.File{Name: "b", Link: "https://example.com/b", SHA256: "bbb"}, artifact.Envelope{PayloadType: artifact.InTotoPayloadType}),
+		artifact.NewProvenance("t1", 1, artifact.File{Name: "a", Link: "https://example.com/a", SHA256: "aaa"}, artifact.Envelope{PayloadType: artifact.InTotoPayloadType}),
+		artifact.NewProvenance("t1", 0, artifact.File{Name: "c", Link: "https://example.com/c", SHA256: "ccc"}, artifact.Envelope{PayloadType: artifact.InTotoPayloadType}),
+	} {
+		require.NoError(t, p.Upsert())
+	}
+
+	get := func(t *testing.T, taskID, execution string) gimlet.Responder {
+		url := "/tasks/" + taskID + "/artifacts/provenance"
+		if execution != "" {
+			url += "?execution=" + execution
+		}
+		r, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		r = gimlet.SetURLVars(r, map[string]string{"task_id": taskID})
+		rh := makeGetTaskArtifactProvenanceHandler()
+		require.NoError(t, rh.Parse(ctx, r))
+		return rh.Run(ctx)
+	}
+
+	resp := get(t, "t1", "")
+	require.Equal(t, http.StatusOK, resp.Status())
+	provenance, ok := resp.Data().([]model.APIArtifactProvenance)
+	require.True(t, ok)
+	require.Len(t, provenance, 2)
+	assert.Equal(t, "a", utility.FromStringPtr(provenance[0].FileName))
+	assert.Equal(t, "aaa", utility.FromStringPtr(provenance[0].SHA256))
+	assert.Equal(t, "b", utility.FromStringPtr(provenance[1].FileName))
+
+	resp = get(t, "t1", "0")
+	require.Equal(t, http.StatusOK, resp.Status())
+	provenance, ok = resp.Data().([]model.APIArtifactProvenance)
+	require.True(t, ok)
+	require.Len(t, provenance, 1)
+	assert.Equal(t, "c", utility.FromStringPtr(provenance[0].FileName))
+
+	resp = get(t, "nonexistent", "")
+	assert.Equal(t, http.StatusNotFound, resp.Status())
+}
+
+func TestGetProvenancePublicKey(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	env := &mock.Environment{}
+	require.NoError(t, env.Configure(ctx))
+
+	run := func(t *testing.T) gimlet.Responder {
+		rh := makeGetProvenancePublicKeyHandler(env)
+		require.NoError(t, rh.Parse(ctx, nil))
+		return rh.Run(ctx)
+	}
+
+	t.Run("NotFoundWithoutSigningKey", func(t *testing.T) {
+		env.EvergreenSettings.Secrets.ProvenanceKeyFile = ""
+		assert.Equal(t, http.StatusNotFound, run(t).Status())
+	})
+	t.Run("ReturnsPublicKey", func(t *testing.T) {
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		keyFile := filepath.Join(t.TempDir(), "provenance.pem")
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+		env.EvergreenSettings.Secrets.ProvenanceKeyFile = keyFile
+
+		resp := run(t)
+		require.Equal(t, http.StatusOK, resp.Status())
+		apiKey, ok := resp.Data().(model.APIProvenancePublicKey)
+		require.True(t, ok)
+		assert.Equal(t, artifact.ProvenanceKeyID(pub), utility.FromStringPtr(apiKey.KeyID))
+		parsed, err := artifact.ParseProvenancePublicKey([]byte(utility.FromStringPtr(apiKey.PublicKey)))
+		require.NoError(t, err)
+		assert.Equal(t, pub, parsed)
+	})
+}
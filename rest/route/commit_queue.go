@@ -11,6 +11,7 @@ import (
 	dbModel "github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/commitqueue"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	"github.com/evergreen-ci/evergreen/rest/model"
 	"github.com/evergreen-ci/evergreen/units"
@@ -276,6 +277,7 @@ func (p *commitQueueConcludeMerge) Run(ctx context.Context) gimlet.Responder {
 
 type commitQueueAdditionalPatches struct {
 	patchId string
+	taskId  string
 }
 
 func makeCommitQueueAdditionalPatches() gimlet.RouteHandler {
@@ -291,11 +293,28 @@ func (p *commitQueueAdditionalPatches) Parse(ctx context.Context, r *http.Reques
 	if p.patchId == "" {
 		return errors.New("patch ID must be specified")
 	}
+	p.taskId = r.Header.Get(evergreen.TaskHeader)
 	return nil
 }
 
 func (p *commitQueueAdditionalPatches) Run(ctx context.Context) gimlet.Responder {
-	additional, err := data.GetAdditionalPatches(p.patchId)
+	// Earlier items have already merged by the time a merge task runs, so
+	// merge tasks only need the changes batched into their patch.
+	forMergeTask := false
+	if p.taskId != "" {
+		t, err := task.FindOneId(p.taskId)
+		if err != nil {
+			return gimlet.NewJSONInternalErrorResponse(errors.Wrapf(err, "finding task '%s'", p.taskId))
+		}
+		if t == nil {
+			return gimlet.NewJSONErrorResponse(gimlet.ErrorResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    fmt.Sprintf("task '%s' not found", p.taskId),
+			})
+		}
+		forMergeTask = t.CommitQueueMerge
+	}
+	additional, err := data.GetAdditionalPatches(p.patchId, forMergeTask)
 	if err != nil {
 		return gimlet.NewJSONErrorResponse(errors.Wrap(err, "getting additional patches"))
 	}
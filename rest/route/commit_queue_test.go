@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/mgo/bson"
 	mgobson "github.com/evergreen-ci/evergreen/db/mgo/bson"
 	dbModel "github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/commitqueue"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	"github.com/evergreen-ci/evergreen/rest/model"
@@ -251,3 +253,42 @@ func TestAdditionalPatches(t *testing.T) {
 	resp := handler.Run(ctx)
 	assert.Equal(t, []string{"a", "b"}, resp.Data().([]string))
 }
+
+func TestAdditionalPatchesForBatch(t *testing.T) {
+	assert.NoError(t, db.ClearCollections(commitqueue.Collection, patch.Collection, task.Collection))
+	patchId := bson.NewObjectId()
+	p := patch.Patch{
+		Id:      patchId,
+		Project: "proj",
+	}
+	assert.NoError(t, p.Insert())
+	cq := commitqueue.CommitQueue{
+		ProjectID: "proj",
+		Queue: []commitqueue.CommitQueueItem{
+			{Issue: "a", Version: "a"},
+			{Issue: "b", BatchedInto: patchId.Hex()},
+			{Issue: "c", BatchedInto: patchId.Hex()},
+			{Issue: patchId.Hex(), Version: patchId.Hex()},
+		},
+	}
+	assert.NoError(t, commitqueue.InsertQueue(&cq))
+	mergeTask := task.Task{Id: "merge", Version: patchId.Hex(), CommitQueueMerge: true}
+	assert.NoError(t, mergeTask.Insert())
+	testTask := task.Task{Id: "test", Version: patchId.Hex()}
+	assert.NoError(t, testTask.Insert())
+
+	ctx := context.Background()
+	for taskID, expected := range map[string][]string{
+		"merge": {"b", "c"},
+		"test":  {"a", "b", "c"},
+	} {
+		handler := makeCommitQueueAdditionalPatches()
+		request, err := http.NewRequest(http.MethodGet, "", nil)
+		assert.NoError(t, err)
+		request.Header.Set(evergreen.TaskHeader, taskID)
+		request = gimlet.SetURLVars(request, map[string]string{"patch_id": patchId.Hex()})
+		assert.NoError(t, handler.Parse(ctx, request))
+		resp := handler.Run(ctx)
+		assert.Equal(t, expected, resp.Data().([]string), taskID)
+	}
+}
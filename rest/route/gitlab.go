@@ -0,0 +1,280 @@
+package route
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/rest/data"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+// maxGitlabPayloadSize is the largest webhook payload that will be read.
+const maxGitlabPayloadSize = 25 * 1024 * 1024
+
+type gitlabHookApi struct {
+	queue amboy.Queue
+
+	conf      evergreen.GitlabConfig
+	token     string
+	eventType string
+	msgID     string
+	mrEvent   *thirdparty.GitlabMergeRequestEvent
+	noteEvent *thirdparty.GitlabNoteEvent
+}
+
+// makeGitlabHooksRoute returns a route that creates patches for GitLab merge
+// requests in response to GitLab webhooks.
+func makeGitlabHooksRoute(queue amboy.Queue) gimlet.RouteHandler {
+	return &gitlabHookApi{queue: queue}
+}
+
+func (gh *gitlabHookApi) Factory() gimlet.RouteHandler {
+	return &gitlabHookApi{queue: gh.queue}
+}
+
+func (gh *gitlabHookApi) Parse(ctx context.Context, r *http.Request) error {
+	settings, err := evergreen.GetConfig(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting admin settings")
+	}
+	gh.conf = settings.Gitlab
+	if err = gh.parseRequest(r); err != nil {
+		return err
+	}
+	projectPath := gh.projectPath()
+	if projectPath == "" {
+		return nil
+	}
+	projectRefs, err := model.FindMergedProjectRefsByGitlabProject(gh.conf.Host(), projectPath)
+	if err != nil {
+		return errors.Wrapf(err, "finding projects for GitLab project '%s'", projectPath)
+	}
+	return gh.authenticate(projectRefs)
+}
+
+// parseRequest decodes the webhook's event. The webhook can't be
+// authenticated until the event says which GitLab project it's for.
+func (gh *gitlabHookApi) parseRequest(r *http.Request) error {
+	if !gh.conf.IsConfigured() || gh.queue == nil {
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "GitLab webhooks are not configured and therefore disabled",
+		}
+	}
+	gh.token = r.Header.Get(thirdparty.GitlabTokenHeader)
+
+	gh.eventType = r.Header.Get(thirdparty.GitlabEventHeader)
+	gh.msgID = r.Header.Get(thirdparty.GitlabEventUUIDHeader)
+	if gh.msgID == "" {
+		// Older GitLab versions don't send a delivery ID.
+		gh.msgID = utility.RandomString()
+	}
+
+	var out interface{}
+	switch gh.eventType {
+	case thirdparty.GitlabMergeRequestEventType:
+		gh.mrEvent = &thirdparty.GitlabMergeRequestEvent{}
+		out = gh.mrEvent
+	case thirdparty.GitlabNoteEventType:
+		gh.noteEvent = &thirdparty.GitlabNoteEvent{}
+		out = gh.noteEvent
+	default:
+		// Other events are acknowledged but ignored.
+		return nil
+	}
+	body := io.LimitReader(r.Body, maxGitlabPayloadSize)
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "parsing webhook").Error(),
+		}
+	}
+
+	return nil
+}
+
+// projectPath returns the path of the GitLab project that the event is for,
+// or an empty string if the event is ignored.
+func (gh *gitlabHookApi) projectPath() string {
+	switch {
+	case gh.mrEvent != nil:
+		return gh.mrEvent.Project.PathWithNamespace
+	case gh.noteEvent != nil:
+		return gh.noteEvent.Project.PathWithNamespace
+	}
+	return ""
+}
+
+// authenticate checks the webhook's token against the webhook secrets of the
+// Evergreen projects for its GitLab project, so that each GitLab project can
+// only trigger its own Evergreen projects.
+func (gh *gitlabHookApi) authenticate(projectRefs []model.ProjectRef) error {
+	for _, pRef := range projectRefs {
+		if pRef.GitlabWebhookSecret == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(gh.token), []byte(pRef.GitlabWebhookSecret)) == 1 {
+			return nil
+		}
+	}
+	return gimlet.ErrorResponse{
+		StatusCode: http.StatusUnauthorized,
+		Message:    "invalid GitLab webhook token",
+	}
+}
+
+func (gh *gitlabHookApi) Run(ctx context.Context) gimlet.Responder {
+	var err error
+	switch {
+	case gh.mrEvent != nil:
+		err = gh.handleMergeRequestEvent(ctx, gh.mrEvent)
+	case gh.noteEvent != nil:
+		err = gh.handleNoteEvent(ctx, gh.noteEvent)
+	}
+	if err != nil {
+		grip.Error(message.WrapError(err, message.Fields{
+			"source": "GitLab hook",
+			"msg_id": gh.msgID,
+			"event":  gh.eventType,
+		}))
+		return gimlet.MakeJSONInternalErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(struct{}{})
+}
+
+func (gh *gitlabHookApi) handleMergeRequestEvent(ctx context.Context, event *thirdparty.GitlabMergeRequestEvent) error {
+	projectPath := event.Project.PathWithNamespace
+	mr := event.ObjectAttributes
+	switch mr.Action {
+	case thirdparty.GitlabMRActionOpen, thirdparty.GitlabMRActionReopen:
+	case thirdparty.GitlabMRActionUpdate:
+		// Updates that don't push new commits, such as editing the title,
+		// don't need a new patch.
+		if mr.OldRev == "" {
+			return nil
+		}
+	case thirdparty.GitlabMRActionClose, thirdparty.GitlabMRActionMerge:
+		grip.Info(message.Fields{
+			"source":       "GitLab hook",
+			"msg_id":       gh.msgID,
+			"event":        gh.eventType,
+			"action":       mr.Action,
+			"project_path": projectPath,
+			"mr_number":    mr.IID,
+			"message":      "merge request closed; aborting patch",
+		})
+		return errors.Wrap(model.AbortPatchesWithGitlabPatchData(time.Now(), true, "", projectPath, mr.IID), "aborting patches")
+	default:
+		return nil
+	}
+
+	for _, label := range skipCILabels {
+		if strings.Contains(strings.ToLower(mr.Title), label) ||
+			strings.Contains(strings.ToLower(mr.Description), label) {
+			grip.Info(message.Fields{
+				"message":      "skipping CI on merge request",
+				"project_path": projectPath,
+				"mr_number":    mr.IID,
+				"label":        label,
+			})
+			return nil
+		}
+	}
+
+	grip.Info(message.Fields{
+		"source":       "GitLab hook",
+		"msg_id":       gh.msgID,
+		"event":        gh.eventType,
+		"action":       mr.Action,
+		"project_path": projectPath,
+		"mr_number":    mr.IID,
+		"message":      "merge request accepted, attempting to queue",
+	})
+	mergeRequest, err := thirdparty.GetGitlabMergeRequest(ctx, gh.conf, projectPath, mr.IID)
+	if err != nil {
+		return err
+	}
+	authorized, err := gh.isAuthorized(ctx, projectPath, mergeRequest.Author)
+	if err != nil || !authorized {
+		return err
+	}
+	return gh.addIntentForMR(projectPath, mergeRequest, patch.AutomatedCaller)
+}
+
+func (gh *gitlabHookApi) handleNoteEvent(ctx context.Context, event *thirdparty.GitlabNoteEvent) error {
+	if event.ObjectAttributes.NoteableType != thirdparty.GitlabNoteableTypeMergeRequest || event.MergeRequest == nil {
+		return nil
+	}
+	ok, calledBy := triggersPatch(event.ObjectAttributes.Note)
+	if !ok {
+		return nil
+	}
+	authorized, err := gh.isAuthorized(ctx, event.Project.PathWithNamespace, event.User)
+	if err != nil || !authorized {
+		return err
+	}
+	grip.Info(message.Fields{
+		"source":       "GitLab hook",
+		"msg_id":       gh.msgID,
+		"event":        gh.eventType,
+		"project_path": event.Project.PathWithNamespace,
+		"mr_number":    event.MergeRequest.IID,
+		"user":         event.User.Username,
+		"message":      "merge request comment triggered patch",
+	})
+	// Webhooks don't include the commit that the merge request's diff is
+	// based on, so it has to be fetched.
+	mr, err := thirdparty.GetGitlabMergeRequest(ctx, gh.conf, event.Project.PathWithNamespace, event.MergeRequest.IID)
+	if err != nil {
+		return err
+	}
+	return gh.addIntentForMR(event.Project.PathWithNamespace, mr, calledBy)
+}
+
+// isAuthorized returns whether the GitLab user can trigger patches for the
+// GitLab project, which requires at least the Developer role in it. Merge
+// requests from other users can be tested by an authorized user commenting on
+// them.
+func (gh *gitlabHookApi) isAuthorized(ctx context.Context, projectPath string, user thirdparty.GitlabUser) (bool, error) {
+	accessLevel, err := thirdparty.GetGitlabProjectAccessLevel(ctx, gh.conf, projectPath, user.ID)
+	if err != nil {
+		return false, errors.Wrapf(err, "checking access of GitLab user '%s'", user.Username)
+	}
+	if accessLevel >= thirdparty.GitlabDeveloperAccessLevel {
+		return true, nil
+	}
+	grip.Info(message.Fields{
+		"source":       "GitLab hook",
+		"msg_id":       gh.msgID,
+		"event":        gh.eventType,
+		"project_path": projectPath,
+		"user":         user.Username,
+		"access_level": accessLevel,
+		"message":      "GitLab user is not authorized to trigger patches",
+	})
+	return false, nil
+}
+
+// addIntentForMR adds a patch intent for the merge request.
+func (gh *gitlabHookApi) addIntentForMR(projectPath string, mr *thirdparty.GitlabMergeRequest, calledBy string) error {
+	intent, err := patch.NewGitlabIntent(gh.msgID, calledBy, projectPath, mr)
+	if err != nil {
+		return errors.Wrap(err, "creating GitLab patch intent")
+	}
+	return errors.Wrap(data.AddPatchIntent(intent, gh.queue), "saving GitLab patch intent")
+}
@@ -0,0 +1,113 @@
+package route
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/amboy/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitlabHookParse(t *testing.T) {
+	const mrBody = `{
+		"object_kind": "merge_request",
+		"user": {"id": 1, "username": "octocat"},
+		"project": {"id": 2, "path_with_namespace": "group/project"},
+		"object_attributes": {"iid": 5, "action": "update", "target_branch": "main", "title": "title", "oldrev": "abc"}
+	}`
+	const noteBody = `{
+		"object_kind": "note",
+		"user": {"id": 1, "username": "octocat"},
+		"project": {"id": 2, "path_with_namespace": "group/project"},
+		"object_attributes": {"note": "evergreen retry", "noteable_type": "MergeRequest"},
+		"merge_request": {"iid": 5, "target_branch": "main"}
+	}`
+	makeRequest := func(t *testing.T, event, token, body string) *http.Request {
+		r, err := http.NewRequest(http.MethodPost, "/hooks/gitlab", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		r.Header.Set(thirdparty.GitlabEventHeader, event)
+		r.Header.Set(thirdparty.GitlabTokenHeader, token)
+		r.Header.Set(thirdparty.GitlabEventUUIDHeader, "uuid")
+		return r
+	}
+	makeHandler := func() *gitlabHookApi {
+		rh := makeGitlabHooksRoute(queue.NewLocalLimitedSize(1, 1)).Factory()
+		gh, ok := rh.(*gitlabHookApi)
+		require.True(t, ok)
+		gh.conf = evergreen.GitlabConfig{URL: "https://gitlab.example.com", Token: "token"}
+		return gh
+	}
+
+	t.Run("ParsesMergeRequestEvent", func(t *testing.T) {
+		gh := makeHandler()
+		require.NoError(t, gh.parseRequest(makeRequest(t, thirdparty.GitlabMergeRequestEventType, "secret", mrBody)))
+		assert.Equal(t, "uuid", gh.msgID)
+		assert.Equal(t, "secret", gh.token)
+		assert.Equal(t, "group/project", gh.projectPath())
+		require.NotNil(t, gh.mrEvent)
+		assert.Nil(t, gh.noteEvent)
+		assert.Equal(t, "group/project", gh.mrEvent.Project.PathWithNamespace)
+		assert.Equal(t, 5, gh.mrEvent.ObjectAttributes.IID)
+		assert.Equal(t, thirdparty.GitlabMRActionUpdate, gh.mrEvent.ObjectAttributes.Action)
+		assert.Equal(t, "abc", gh.mrEvent.ObjectAttributes.OldRev)
+	})
+	t.Run("ParsesNoteEvent", func(t *testing.T) {
+		gh := makeHandler()
+		require.NoError(t, gh.parseRequest(makeRequest(t, thirdparty.GitlabNoteEventType, "secret", noteBody)))
+		require.NotNil(t, gh.noteEvent)
+		assert.Nil(t, gh.mrEvent)
+		assert.Equal(t, "evergreen retry", gh.noteEvent.ObjectAttributes.Note)
+		require.NotNil(t, gh.noteEvent.MergeRequest)
+		assert.Equal(t, 5, gh.noteEvent.MergeRequest.IID)
+	})
+	t.Run("IgnoresOtherEvents", func(t *testing.T) {
+		gh := makeHandler()
+		require.NoError(t, gh.parseRequest(makeRequest(t, "Push Hook", "secret", "not json")))
+		assert.Nil(t, gh.mrEvent)
+		assert.Nil(t, gh.noteEvent)
+		assert.Empty(t, gh.projectPath())
+	})
+	t.Run("FailsWithoutGitlabConfig", func(t *testing.T) {
+		gh := makeHandler()
+		gh.conf.Token = ""
+		assert.Error(t, gh.parseRequest(makeRequest(t, thirdparty.GitlabMergeRequestEventType, "secret", mrBody)))
+	})
+	t.Run("FailsForMalformedPayload", func(t *testing.T) {
+		gh := makeHandler()
+		assert.Error(t, gh.parseRequest(makeRequest(t, thirdparty.GitlabMergeRequestEventType, "secret", "{")))
+	})
+}
+
+func TestGitlabHookAuthenticate(t *testing.T) {
+	projectRefs := []model.ProjectRef{
+		{Id: "no_secret"},
+		{Id: "other", GitlabWebhookSecret: "other_secret"},
+		{Id: "project", GitlabWebhookSecret: "secret"},
+	}
+	assertUnauthorized := func(t *testing.T, err error) {
+		require.Error(t, err)
+		errResp, ok := err.(gimlet.ErrorResponse)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusUnauthorized, errResp.StatusCode)
+	}
+
+	t.Run("AcceptsAnyProjectSecret", func(t *testing.T) {
+		assert.NoError(t, (&gitlabHookApi{token: "secret"}).authenticate(projectRefs))
+		assert.NoError(t, (&gitlabHookApi{token: "other_secret"}).authenticate(projectRefs))
+	})
+	t.Run("RejectsInvalidToken", func(t *testing.T) {
+		assertUnauthorized(t, (&gitlabHookApi{token: "wrong"}).authenticate(projectRefs))
+	})
+	t.Run("RejectsEmptyToken", func(t *testing.T) {
+		assertUnauthorized(t, (&gitlabHookApi{}).authenticate(projectRefs))
+	})
+	t.Run("RejectsWithoutProjects", func(t *testing.T) {
+		assertUnauthorized(t, (&gitlabHookApi{token: "secret"}).authenticate(nil))
+	})
+}
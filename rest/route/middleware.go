@@ -40,6 +40,7 @@ const (
 	RequestContext   requestContextKey = 0
 	githubPayloadKey requestContextKey = 3
 	snsPayloadKey    requestContextKey = 5
+	accessTokenKey   requestContextKey = 6
 )
 
 type projCtxMiddleware struct{}
@@ -59,6 +60,14 @@ func (m *projCtxMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, n
 		return
 	}
 
+	if t := getAccessToken(ctx); t != nil && opCtx.ProjectRef != nil && !t.AllowsProject(opCtx.ProjectRef.Id) {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    fmt.Sprintf("access token '%s' does not permit access to project '%s'", t.Name, opCtx.ProjectRef.Id),
+		}))
+		return
+	}
+
 	user := gimlet.GetUser(ctx)
 
 	if opCtx.ProjectRef != nil && opCtx.ProjectRef.IsPrivate() && user == nil {
@@ -119,6 +128,196 @@ func MustHaveUser(ctx context.Context) *user.DBUser {
 	return usr
 }
 
+type accessTokenMiddleware struct {
+	conf gimlet.UserMiddlewareConfiguration
+}
+
+// NewAccessTokenMiddleware returns a middleware that authenticates requests
+// made with a personal access token and rejects requests that the token's
+// scopes do not permit. The token may be given either in place of the API key
+// in the API key header or as a bearer token. It must run before the user
+// middleware, which would otherwise reject the token as an invalid API key.
+// Tokens are only accepted by the REST v2 API and the legacy patch routes that
+// the CLI submits patches through; requests to the UI, GraphQL or other legacy
+// API routes that are made with a token are rejected.
+func NewAccessTokenMiddleware(conf gimlet.UserMiddlewareConfiguration) gimlet.Middleware {
+	return &accessTokenMiddleware{conf: conf}
+}
+
+func (m *accessTokenMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	userID := r.Header.Get(m.conf.HeaderUserName)
+	token := r.Header.Get(m.conf.HeaderKeyName)
+	bearer := false
+	if token == "" {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		bearer = true
+	}
+	if !user.IsAccessToken(token) {
+		next(rw, r)
+		return
+	}
+	if !isRESTv2Request(r.URL.Path) && !isLegacyPatchRequest(r.URL.Path) {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusUnauthorized,
+			Message:    "access tokens can only be used with the REST v2 API and the patch API",
+		}))
+		return
+	}
+
+	t, err := user.FindAccessTokenByToken(token)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "finding access token")))
+		return
+	}
+	if t == nil || (userID != "" && userID != t.UserId) {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusUnauthorized,
+			Message:    "invalid or expired access token",
+		}))
+		return
+	}
+	u, err := user.FindOneById(t.UserId)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding user '%s'", t.UserId)))
+		return
+	}
+	if u == nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusUnauthorized,
+			Message:    "invalid or expired access token",
+		}))
+		return
+	}
+	if !t.AllowsRequest(r.Method, isPatchRequest(r.URL.Path)) {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    fmt.Sprintf("access token '%s' does not have a scope that permits this request", t.Name),
+		}))
+		return
+	}
+
+	grip.Warning(message.WrapError(t.MarkUsed(), message.Fields{
+		"message":  "could not record access token usage",
+		"token_id": t.Id,
+		"user":     t.UserId,
+	}))
+
+	// Remove the credentials so that the user middleware does not mistake the
+	// token for the user's API key.
+	r.Header.Del(m.conf.HeaderKeyName)
+	r.Header.Del(m.conf.HeaderUserName)
+	if bearer {
+		r.Header.Del("Authorization")
+	}
+
+	gimlet.AddLoggingAnnotation(r, "access_token", t.Id)
+	ctx := context.WithValue(gimlet.AttachUser(r.Context(), u), accessTokenKey, t)
+
+	next(rw, r.WithContext(ctx))
+}
+
+// getAccessToken returns the personal access token used to authenticate the
+// request, if any.
+func getAccessToken(ctx context.Context) *user.AccessToken {
+	t, _ := ctx.Value(accessTokenKey).(*user.AccessToken)
+	return t
+}
+
+// isRESTv2Request returns whether the request path belongs to the REST v2 API,
+// which is served both with and without the API route prefix.
+func isRESTv2Request(path string) bool {
+	path = strings.TrimPrefix(path, "/"+evergreen.APIRoutePrefix)
+	return strings.HasPrefix(path, evergreen.APIRoutePrefixV2+"/")
+}
+
+// isLegacyPatchRequest returns whether the request path belongs to the legacy
+// patch routes, which the CLI uses to create patches.
+func isLegacyPatchRequest(path string) bool {
+	prefix := "/" + evergreen.APIRoutePrefix + "/patches"
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// isPatchRequest returns whether the request path belongs to the REST or
+// legacy patch routes.
+func isPatchRequest(path string) bool {
+	if isLegacyPatchRequest(path) {
+		return true
+	}
+	path = strings.TrimPrefix(path, "/"+evergreen.APIRoutePrefix)
+	path = strings.TrimPrefix(path, evergreen.APIRoutePrefixV2)
+	return path == "/patches" || strings.HasPrefix(path, "/patches/")
+}
+
+// CheckAccessTokenProject returns an error if the request was made with an
+// access token that does not permit access to the given project. Routes that
+// take the project from the request body rather than the URL must call this
+// themselves.
+func CheckAccessTokenProject(ctx context.Context, projectID string) error {
+	if t := getAccessToken(ctx); t != nil && !t.AllowsProject(projectID) {
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    fmt.Sprintf("access token '%s' does not permit access to project '%s'", t.Name, projectID),
+		}
+	}
+	return nil
+}
+
+type denyProjectRestrictedAccessTokenMiddleware struct{}
+
+// NewDenyProjectRestrictedAccessTokenMiddleware returns a middleware that
+// rejects requests made with a project-restricted access token. It guards
+// routes that only require a user and therefore do not check the token's
+// projects, such as routes for spawn hosts, volumes and user settings.
+func NewDenyProjectRestrictedAccessTokenMiddleware() gimlet.Middleware {
+	return &denyProjectRestrictedAccessTokenMiddleware{}
+}
+
+func (m *denyProjectRestrictedAccessTokenMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if t := getAccessToken(r.Context()); t != nil && t.IsProjectRestricted() {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    fmt.Sprintf("access token '%s' is restricted to specific projects", t.Name),
+		}))
+		return
+	}
+
+	next(rw, r)
+}
+
+// restrictToAccessTokenProjects wraps a resource function so that a request
+// made with a project-restricted access token can only access the token's
+// projects.
+func restrictToAccessTokenProjects(resourceFunc gimlet.FindResourceFunc) gimlet.FindResourceFunc {
+	return func(r *http.Request) ([]string, int, error) {
+		resources, status, err := resourceFunc(r)
+		if err != nil {
+			return resources, status, err
+		}
+		t := getAccessToken(r.Context())
+		if t == nil {
+			return resources, status, nil
+		}
+		for _, resource := range resources {
+			if !t.AllowsProject(resource) {
+				return nil, http.StatusForbidden, errors.Errorf("access token '%s' does not permit access to project '%s'", t.Name, resource)
+			}
+		}
+		return resources, status, nil
+	}
+}
+
+// denyProjectRestrictedAccessTokens wraps a resource function for a
+// non-project resource so that requests made with a project-restricted access
+// token are rejected.
+func denyProjectRestrictedAccessTokens(resourceFunc gimlet.FindResourceFunc) gimlet.FindResourceFunc {
+	return func(r *http.Request) ([]string, int, error) {
+		if t := getAccessToken(r.Context()); t != nil && t.IsProjectRestricted() {
+			return nil, http.StatusForbidden, errors.Errorf("access token '%s' is restricted to specific projects", t.Name)
+		}
+		return resourceFunc(r)
+	}
+}
+
 func validPriority(priority int64, project string, user gimlet.User) bool {
 	if priority > evergreen.MaxTaskPriority {
 		return user.HasPermission(gimlet.PermissionOpts{
@@ -629,7 +828,7 @@ func RequiresProjectPermission(permission string, level evergreen.PermissionLeve
 		PermissionKey: permission,
 		ResourceType:  evergreen.ProjectResourceType,
 		RequiredLevel: level.Value,
-		ResourceFunc:  urlVarsToProjectScopes,
+		ResourceFunc:  restrictToAccessTokenProjects(urlVarsToProjectScopes),
 		DefaultRoles:  defaultRoles,
 	}
 
@@ -649,7 +848,7 @@ func RequiresDistroPermission(permission string, level evergreen.PermissionLevel
 		PermissionKey: permission,
 		ResourceType:  evergreen.DistroResourceType,
 		RequiredLevel: level.Value,
-		ResourceFunc:  urlVarsToDistroScopes,
+		ResourceFunc:  denyProjectRestrictedAccessTokens(urlVarsToDistroScopes),
 		DefaultRoles:  defaultRoles,
 	}
 	return gimlet.RequiresPermission(opts)
@@ -668,7 +867,7 @@ func RequiresSuperUserPermission(permission string, level evergreen.PermissionLe
 		PermissionKey: permission,
 		ResourceType:  evergreen.SuperUserResourceType,
 		RequiredLevel: level.Value,
-		ResourceFunc:  superUserResource,
+		ResourceFunc:  denyProjectRestrictedAccessTokens(superUserResource),
 		DefaultRoles:  defaultRoles,
 	}
 	return gimlet.RequiresPermission(opts)
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
@@ -967,3 +968,222 @@ func TestEventLogPermission(t *testing.T) {
 	assert.Equal(http.StatusOK, rw.Code)
 	assert.Equal(3, counter)
 }
+
+func TestAccessTokenMiddleware(t *testing.T) {
+	require.NoError(t, db.ClearCollections(user.Collection, user.AccessTokenCollection))
+	defer func() {
+		assert.NoError(t, db.ClearCollections(user.Collection, user.AccessTokenCollection))
+	}()
+
+	u := &user.DBUser{Id: "me", APIKey: "legacy_key"}
+	require.NoError(t, u.Insert())
+	_, readOnlyToken, err := user.CreateAccessToken(u.Id, "read", []user.AccessTokenScope{user.AccessTokenScopeReadOnly}, nil, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, patchToken, err := user.CreateAccessToken(u.Id, "patch", []user.AccessTokenScope{user.AccessTokenScopePatchSubmit}, []string{"p1"}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	conf := gimlet.UserMiddlewareConfiguration{
+		HeaderUserName: evergreen.APIUserHeader,
+		HeaderKeyName:  evergreen.APIKeyHeader,
+	}
+	m := NewAccessTokenMiddleware(conf)
+
+	serve := func(method, path string, header http.Header) (*httptest.ResponseRecorder, *http.Request) {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header = header
+		rw := httptest.NewRecorder()
+		var nextReq *http.Request
+		m.ServeHTTP(rw, r, func(rw http.ResponseWriter, r *http.Request) {
+			nextReq = r
+		})
+		return rw, nextReq
+	}
+
+	t.Run("IgnoresLegacyAPIKeys", func(t *testing.T) {
+		rw, next := serve(http.MethodPost, "/rest/v2/hosts", http.Header{
+			evergreen.APIUserHeader: []string{u.Id},
+			evergreen.APIKeyHeader:  []string{u.APIKey},
+		})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		require.NotNil(t, next)
+		assert.Nil(t, gimlet.GetUser(next.Context()))
+		assert.Equal(t, u.APIKey, next.Header.Get(evergreen.APIKeyHeader))
+	})
+	t.Run("AuthenticatesWithAPIKeyHeader", func(t *testing.T) {
+		rw, next := serve(http.MethodGet, "/rest/v2/hosts", http.Header{
+			evergreen.APIUserHeader: []string{u.Id},
+			evergreen.APIKeyHeader:  []string{readOnlyToken},
+		})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		require.NotNil(t, next)
+		usr := gimlet.GetUser(next.Context())
+		require.NotNil(t, usr)
+		assert.Equal(t, u.Id, usr.Username())
+		require.NotNil(t, getAccessToken(next.Context()))
+		assert.Empty(t, next.Header.Get(evergreen.APIKeyHeader), "token should be removed so it is not checked as an API key")
+	})
+	t.Run("AuthenticatesWithBearerToken", func(t *testing.T) {
+		rw, next := serve(http.MethodGet, "/rest/v2/hosts", http.Header{
+			"Authorization": []string{"Bearer " + readOnlyToken},
+		})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		require.NotNil(t, next)
+		require.NotNil(t, gimlet.GetUser(next.Context()))
+	})
+	t.Run("RejectsMismatchedUser", func(t *testing.T) {
+		rw, next := serve(http.MethodGet, "/rest/v2/hosts", http.Header{
+			evergreen.APIUserHeader: []string{"someone_else"},
+			evergreen.APIKeyHeader:  []string{readOnlyToken},
+		})
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		assert.Nil(t, next)
+	})
+	t.Run("RejectsUnknownToken", func(t *testing.T) {
+		rw, next := serve(http.MethodGet, "/rest/v2/hosts", http.Header{
+			evergreen.APIKeyHeader: []string{user.AccessTokenPrefix + "unknown"},
+		})
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		assert.Nil(t, next)
+	})
+	t.Run("ReadOnlyTokenRejectsModification", func(t *testing.T) {
+		rw, next := serve(http.MethodPost, "/rest/v2/hosts", http.Header{
+			evergreen.APIKeyHeader: []string{readOnlyToken},
+		})
+		assert.Equal(t, http.StatusForbidden, rw.Code)
+		assert.Nil(t, next)
+	})
+	t.Run("PatchSubmitTokenAllowsPatchRoutes", func(t *testing.T) {
+		rw, next := serve(http.MethodPatch, "/rest/v2/patches/abc", http.Header{
+			evergreen.APIKeyHeader: []string{patchToken},
+		})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.NotNil(t, next)
+
+		rw, next = serve(http.MethodPost, "/rest/v2/hosts", http.Header{
+			evergreen.APIKeyHeader: []string{patchToken},
+		})
+		assert.Equal(t, http.StatusForbidden, rw.Code)
+		assert.Nil(t, next)
+	})
+	t.Run("PatchSubmitTokenAllowsLegacyPatchRoutes", func(t *testing.T) {
+		rw, next := serve(http.MethodPut, "/api/patches/", http.Header{
+			evergreen.APIUserHeader: []string{u.Id},
+			evergreen.APIKeyHeader:  []string{patchToken},
+		})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		require.NotNil(t, next)
+		require.NotNil(t, gimlet.GetUser(next.Context()))
+		assert.NotNil(t, getAccessToken(next.Context()))
+
+		rw, next = serve(http.MethodPut, "/api/patches/", http.Header{
+			evergreen.APIKeyHeader: []string{readOnlyToken},
+		})
+		assert.Equal(t, http.StatusForbidden, rw.Code)
+		assert.Nil(t, next)
+	})
+	t.Run("RejectsNonRESTv2Routes", func(t *testing.T) {
+		for _, path := range []string{"/graphql/query", "/settings", "/api/patchesother", "/api/2/task/abc"} {
+			rw, next := serve(http.MethodGet, path, http.Header{
+				evergreen.APIKeyHeader: []string{readOnlyToken},
+			})
+			assert.Equal(t, http.StatusUnauthorized, rw.Code, path)
+			assert.Nil(t, next, path)
+		}
+	})
+}
+
+func TestIsRESTv2Request(t *testing.T) {
+	assert.True(t, isRESTv2Request("/rest/v2/hosts"))
+	assert.True(t, isRESTv2Request("/api/rest/v2/patches/abc"))
+	assert.False(t, isRESTv2Request("/api/patches/"))
+	assert.False(t, isRESTv2Request("/graphql/query"))
+	assert.False(t, isRESTv2Request("/rest/v2"))
+	assert.False(t, isRESTv2Request("/settings"))
+}
+
+func TestIsPatchRequest(t *testing.T) {
+	assert.True(t, isPatchRequest("/rest/v2/patches/abc/configure"))
+	assert.True(t, isPatchRequest("/api/rest/v2/patches/abc"))
+	assert.False(t, isPatchRequest("/rest/v2/patches_other"))
+	assert.False(t, isPatchRequest("/rest/v2/projects/p1/patches"))
+	assert.False(t, isPatchRequest("/rest/v2/hosts"))
+	assert.True(t, isPatchRequest("/api/patches/"))
+	assert.True(t, isPatchRequest("/api/patches/abc/modules"))
+}
+
+func TestIsLegacyPatchRequest(t *testing.T) {
+	assert.True(t, isLegacyPatchRequest("/api/patches"))
+	assert.True(t, isLegacyPatchRequest("/api/patches/"))
+	assert.True(t, isLegacyPatchRequest("/api/patches/abc"))
+	assert.False(t, isLegacyPatchRequest("/api/patchesother"))
+	assert.False(t, isLegacyPatchRequest("/api/rest/v2/patches/abc"))
+	assert.False(t, isLegacyPatchRequest("/patches/abc"))
+}
+
+func TestAccessTokenProjectRestriction(t *testing.T) {
+	resourceFunc := func(resources ...string) gimlet.FindResourceFunc {
+		return func(*http.Request) ([]string, int, error) {
+			return resources, http.StatusOK, nil
+		}
+	}
+	requestWithToken := func(token *user.AccessToken) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if token != nil {
+			r = r.WithContext(context.WithValue(r.Context(), accessTokenKey, token))
+		}
+		return r
+	}
+	restricted := &user.AccessToken{Name: "restricted", Projects: []string{"p1"}}
+	unrestricted := &user.AccessToken{Name: "unrestricted"}
+
+	t.Run("AllowsRequestsWithoutToken", func(t *testing.T) {
+		resources, status, err := restrictToAccessTokenProjects(resourceFunc("p2"))(requestWithToken(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"p2"}, resources)
+
+		_, status, err = denyProjectRestrictedAccessTokens(superUserResource)(requestWithToken(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	})
+	t.Run("AllowsTokenProjects", func(t *testing.T) {
+		resources, status, err := restrictToAccessTokenProjects(resourceFunc("p1"))(requestWithToken(restricted))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"p1"}, resources)
+	})
+	t.Run("RejectsOtherProjects", func(t *testing.T) {
+		_, status, err := restrictToAccessTokenProjects(resourceFunc("p1", "p2"))(requestWithToken(restricted))
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+	t.Run("RejectsNonProjectResources", func(t *testing.T) {
+		_, status, err := denyProjectRestrictedAccessTokens(superUserResource)(requestWithToken(restricted))
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		_, status, err = denyProjectRestrictedAccessTokens(superUserResource)(requestWithToken(unrestricted))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	})
+	t.Run("MiddlewareRejectsRestrictedTokens", func(t *testing.T) {
+		m := NewDenyProjectRestrictedAccessTokenMiddleware()
+		for token, expected := range map[*user.AccessToken]int{
+			nil:          http.StatusOK,
+			unrestricted: http.StatusOK,
+			restricted:   http.StatusForbidden,
+		} {
+			rw := httptest.NewRecorder()
+			called := false
+			m.ServeHTTP(rw, requestWithToken(token), func(http.ResponseWriter, *http.Request) { called = true })
+			assert.Equal(t, expected, rw.Code)
+			assert.Equal(t, expected == http.StatusOK, called)
+		}
+	})
+	t.Run("ChecksProjectsFromRequestBody", func(t *testing.T) {
+		assert.NoError(t, CheckAccessTokenProject(requestWithToken(nil).Context(), "p2"))
+		assert.NoError(t, CheckAccessTokenProject(requestWithToken(restricted).Context(), "p1"))
+		assert.NoError(t, CheckAccessTokenProject(requestWithToken(unrestricted).Context(), "p2"))
+		assert.Error(t, CheckAccessTokenProject(requestWithToken(restricted).Context(), "p2"))
+	})
+}
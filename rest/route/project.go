@@ -318,6 +318,11 @@ func (h *projectIDPatchHandler) Parse(ctx context.Context, r *http.Request) erro
 		return errors.Wrap(err, "converting new project to service model")
 	}
 	newProjectRef.RepoRefId = oldProject.RepoRefId // this can't be modified by users
+	newProjectRef.RestoreRedactedGitURL(oldProject.GitURL)
+	if newProjectRef.GitlabWebhookSecret == "" {
+		// The secret is never returned, so leaving it out keeps it.
+		newProjectRef.GitlabWebhookSecret = oldProject.GitlabWebhookSecret
+	}
 
 	h.newProjectRef = newProjectRef
 	h.originalProject = oldProject
@@ -333,6 +338,15 @@ func (h *projectIDPatchHandler) Run(ctx context.Context) gimlet.Responder {
 	if err := h.newProjectRef.ValidateOwnerAndRepo(h.settings.GithubOrgs); err != nil {
 		return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "validating owner and repo"))
 	}
+	if err := h.newProjectRef.ValidateGitURL(); err != nil {
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+	if err := h.newProjectRef.CommitQueue.Validate(); err != nil {
+		return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "validating commit queue settings"))
+	}
+	if err := h.newProjectRef.ArtifactRetention.Validate(); err != nil {
+		return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "validating artifact retention settings"))
+	}
 	if h.newProjectRef.Identifier != h.originalProject.Identifier {
 		if err := h.newProjectRef.ValidateIdentifier(); err != nil {
 			return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "validating project identifier"))
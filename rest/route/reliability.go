@@ -61,6 +61,7 @@ func (trh *taskReliabilityHandler) ParseCommonFilter(vals url.Values) error {
 		trh.filter.Requesters = trh.StatsHandler.filter.Requesters
 		trh.filter.BuildVariants = trh.StatsHandler.filter.BuildVariants
 		trh.filter.Distros = trh.StatsHandler.filter.Distros
+		trh.filter.FailureClassifications = trh.StatsHandler.filter.FailureClassifications
 		trh.filter.GroupNumDays = trh.StatsHandler.filter.GroupNumDays
 		trh.filter.StartAt = trh.StatsHandler.filter.StartAt
 		trh.filter.Sort = trh.StatsHandler.filter.Sort
@@ -130,6 +130,11 @@ func (h *repoIDPatchHandler) Parse(ctx context.Context, r *http.Request) error {
 	if err != nil {
 		return errors.Wrap(err, "converting new repo ref to service model")
 	}
+	pRef.RestoreRedactedGitURL(h.originalRepo.GitURL)
+	if pRef.GitlabWebhookSecret == "" {
+		// The secret is never returned, so leaving it out keeps it.
+		pRef.GitlabWebhookSecret = h.originalRepo.GitlabWebhookSecret
+	}
 	h.newRepoRef = &dbModel.RepoRef{ProjectRef: *pRef}
 	return nil
 }
@@ -142,6 +147,7 @@ func (h *repoIDPatchHandler) Run(ctx context.Context) gimlet.Responder {
 
 	catcher := grip.NewSimpleCatcher()
 	catcher.Add(h.newRepoRef.ValidateOwnerAndRepo(h.settings.GithubOrgs))
+	catcher.Add(h.newRepoRef.ValidateGitURL())
 
 	// validate triggers before updating project
 	for i, trigger := range h.newRepoRef.Triggers {
@@ -34,6 +34,7 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 
 	// Middleware
 	requireUser := gimlet.NewRequireAuthHandler()
+	denyRestrictedTokens := NewDenyProjectRestrictedAccessTokenMiddleware()
 	requireValidGithubPayload := NewGithubAuthMiddleware()
 	requireValidSNSPayload := NewSNSAuthMiddleware()
 	requireTask := NewTaskAuthMiddleware()
@@ -75,8 +76,11 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 	app.AddRoute("/task/{task_id}/expansions_and_vars").Version(2).Get().Wrap(requireTask, requirePodOrHost).RouteHandler(makeGetExpansionsAndVars(settings))
 	app.AddRoute("/task/{task_id}/project_ref").Version(2).Get().Wrap(requireTask).RouteHandler(makeGetProjectRef())
 	app.AddRoute("/task/{task_id}/parser_project").Version(2).Get().Wrap(requireTask).RouteHandler(makeGetParserProject(env))
+	app.AddRoute("/task/{task_id}/shard_tests").Version(2).Get().Wrap(requireTask, requirePodOrHost).RouteHandler(makeGetShardTests(env))
 	app.AddRoute("/task/{task_id}/distro_view").Version(2).Get().Wrap(requireTask, requirePodOrHost).RouteHandler(makeGetDistroView())
 	app.AddRoute("/task/{task_id}/files").Version(2).Post().Wrap(requireTask, requirePodOrHost).RouteHandler(makeAttachFiles())
+	app.AddRoute("/task/{task_id}/resource_usage").Version(2).Post().Wrap(requireTask, requirePodOrHost).RouteHandler(makeAttachResourceUsage())
+	app.AddRoute("/task/{task_id}/annotation/metadata_links").Version(2).Post().Wrap(requireTask, requirePodOrHost).RouteHandler(makeSetAnnotationMetadataLinks())
 	app.AddRoute("/task/{task_id}/test_logs").Version(2).Post().Wrap(requireTask, requirePodOrHost).RouteHandler(makeAttachTestLog(settings))
 	app.AddRoute("/task/{task_id}/heartbeat").Version(2).Post().Wrap(requireTask, requirePodOrHost).RouteHandler(makeHeartbeat())
 	app.AddRoute("/task/{task_id}/pull_request").Version(2).Get().Wrap(requireTask).RouteHandler(makeAgentGetPullRequest(settings))
@@ -93,6 +97,7 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 
 	// Routes
 	app.AddRoute("/").Version(2).Get().Wrap(requireUser).RouteHandler(makePlaceHolder())
+	app.AddRoute("/admin/audit_log").Version(2).Get().Wrap(adminSettings).RouteHandler(makeFetchAuditLog())
 	app.AddRoute("/admin/banner").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchAdminBanner())
 	app.AddRoute("/admin/banner").Version(2).Post().Wrap(adminSettings).RouteHandler(makeSetAdminBanner())
 	app.AddRoute("/admin/uiv2_url").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchAdminUIV2Url())
@@ -109,7 +114,8 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 	app.AddRoute("/admin/service_users").Version(2).Get().Wrap(adminSettings).RouteHandler(makeGetServiceUsers())
 	app.AddRoute("/admin/service_users").Version(2).Post().Wrap(adminSettings).RouteHandler(makeUpdateServiceUser())
 	app.AddRoute("/admin/service_users").Version(2).Delete().Wrap(adminSettings).RouteHandler(makeDeleteServiceUser())
-	app.AddRoute("/alias/{name}").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchAliases())
+	app.AddRoute("/alias/{name}").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchAliases())
+	app.AddRoute("/artifacts/provenance/public_key").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetProvenancePublicKeyHandler(env))
 	app.AddRoute("/auth").Version(2).Get().Wrap(requireUser).RouteHandler(&authPermissionGetHandler{})
 	app.AddRoute("/builds/{build_id}").Version(2).Get().Wrap(viewTasks).RouteHandler(makeGetBuildByID(env))
 	app.AddRoute("/builds/{build_id}").Version(2).Patch().Wrap(requireUser, editTasks).RouteHandler(makeChangeStatusForBuild())
@@ -122,8 +128,8 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 	app.AddRoute("/commit_queue/{patch_id}").Version(2).Put().Wrap(requireUser, addProject, requireCommitQueueItemOwner, editTasks).RouteHandler(makeCommitQueueEnqueueItem())
 	app.AddRoute("/commit_queue/{patch_id}/additional").Version(2).Get().Wrap(requireTask).RouteHandler(makeCommitQueueAdditionalPatches())
 	app.AddRoute("/commit_queue/{patch_id}/conclude_merge").Version(2).Post().Wrap(requireTask).RouteHandler(makeCommitQueueConcludeMerge())
-	app.AddRoute("/commit_queue/{patch_id}/message").Version(2).Get().Wrap(requireUser).RouteHandler(makecqMessageForPatch())
-	app.AddRoute("/distros").Version(2).Get().Wrap(requireUser).RouteHandler(makeDistroRoute())
+	app.AddRoute("/commit_queue/{patch_id}/message").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makecqMessageForPatch())
+	app.AddRoute("/distros").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeDistroRoute())
 	app.AddRoute("/distros/settings").Version(2).Patch().Wrap(createDistro).RouteHandler(makeModifyDistrosSettings())
 	app.AddRoute("/distros/{distro_id}").Version(2).Get().Wrap(editDistroSettings).RouteHandler(makeGetDistroByID())
 	app.AddRoute("/distros/{distro_id}").Version(2).Patch().Wrap(editDistroSettings).RouteHandler(makePatchDistroByID())
@@ -138,41 +144,42 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 	app.AddRoute("/distros/{distro_id}/client_urls").Version(2).Get().RouteHandler(makeGetDistroClientURLs(env))
 
 	app.AddRoute("/hooks/github").Version(2).Post().Wrap(requireValidGithubPayload).RouteHandler(makeGithubHooksRoute(sc, opts.APIQueue, opts.GithubSecret, settings))
+	app.AddRoute("/hooks/gitlab").Version(2).Post().RouteHandler(makeGitlabHooksRoute(opts.APIQueue))
 	app.AddRoute("/hooks/aws").Version(2).Post().Wrap(requireValidSNSPayload).RouteHandler(makeEC2SNS(env, opts.APIQueue))
 	app.AddRoute("/hooks/aws/ecs").Version(2).Post().Wrap(requireValidSNSPayload).RouteHandler(makeECSSNS(env, opts.APIQueue))
 
-	app.AddRoute("/host/filter").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchHostFilter())
-	app.AddRoute("/host/start_processes").Version(2).Post().Wrap(requireUser).RouteHandler(makeHostStartProcesses(env))
-	app.AddRoute("/host/get_processes").Version(2).Get().Wrap(requireUser).RouteHandler(makeHostGetProcesses(env))
-	app.AddRoute("/hosts").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchHosts(opts.URL))
-	app.AddRoute("/hosts").Version(2).Post().Wrap(requireUser).RouteHandler(makeSpawnHostCreateRoute(env))
-	app.AddRoute("/hosts").Version(2).Patch().Wrap(requireUser).RouteHandler(makeChangeHostsStatuses())
-	app.AddRoute("/hosts/{host_id}").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetHostByID())
-	app.AddRoute("/hosts/{host_id}").Version(2).Patch().Wrap(requireUser).RouteHandler(makeHostModifyRouteManager(env))
+	app.AddRoute("/host/filter").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchHostFilter())
+	app.AddRoute("/host/start_processes").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeHostStartProcesses(env))
+	app.AddRoute("/host/get_processes").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeHostGetProcesses(env))
+	app.AddRoute("/hosts").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchHosts(opts.URL))
+	app.AddRoute("/hosts").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeSpawnHostCreateRoute(env))
+	app.AddRoute("/hosts").Version(2).Patch().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeChangeHostsStatuses())
+	app.AddRoute("/hosts/{host_id}").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetHostByID())
+	app.AddRoute("/hosts/{host_id}").Version(2).Patch().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeHostModifyRouteManager(env))
 	app.AddRoute("/hosts/{host_id}/disable").Version(2).Post().Wrap(requireHost).RouteHandler(makeDisableHostHandler(env))
-	app.AddRoute("/hosts/{host_id}/stop").Version(2).Post().Wrap(requireUser).RouteHandler(makeHostStopManager(env))
-	app.AddRoute("/hosts/{host_id}/start").Version(2).Post().Wrap(requireUser).RouteHandler(makeHostStartManager(env))
-	app.AddRoute("/hosts/{host_id}/change_password").Version(2).Post().Wrap(requireUser).RouteHandler(makeHostChangePassword(env))
-	app.AddRoute("/hosts/{host_id}/extend_expiration").Version(2).Post().Wrap(requireUser).RouteHandler(makeExtendHostExpiration())
-	app.AddRoute("/hosts/{host_id}/terminate").Version(2).Post().Wrap(requireUser).RouteHandler(makeTerminateHostRoute())
+	app.AddRoute("/hosts/{host_id}/stop").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeHostStopManager(env))
+	app.AddRoute("/hosts/{host_id}/start").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeHostStartManager(env))
+	app.AddRoute("/hosts/{host_id}/change_password").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeHostChangePassword(env))
+	app.AddRoute("/hosts/{host_id}/extend_expiration").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeExtendHostExpiration())
+	app.AddRoute("/hosts/{host_id}/terminate").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeTerminateHostRoute())
 	app.AddRoute("/hosts/{host_id}/status").Version(2).Get().Wrap(requireTaskHost).RouteHandler(makeContainerStatusManager())
 	app.AddRoute("/hosts/{host_id}/logs/output").Version(2).Get().Wrap(requireTaskHost).RouteHandler(makeContainerLogsRouteManager(false))
 	app.AddRoute("/hosts/{host_id}/logs/error").Version(2).Get().Wrap(requireTaskHost).RouteHandler(makeContainerLogsRouteManager(true))
 	app.AddRoute("/hosts/{task_id}/create").Version(2).Post().Wrap(requireTask).RouteHandler(makeHostCreateRouteManager(env))
 	app.AddRoute("/hosts/{task_id}/list").Version(2).Get().Wrap(requireTask).RouteHandler(makeHostListRouteManager())
-	app.AddRoute("/hosts/{host_id}/attach").Version(2).Post().Wrap(requireUser).RouteHandler(makeAttachVolume(env))
-	app.AddRoute("/hosts/{host_id}/detach").Version(2).Post().Wrap(requireUser).RouteHandler(makeDetachVolume(env))
+	app.AddRoute("/hosts/{host_id}/attach").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeAttachVolume(env))
+	app.AddRoute("/hosts/{host_id}/detach").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeDetachVolume(env))
 	app.AddRoute("/hosts/{host_id}/provisioning_options").Version(2).Get().Wrap(requireHost).RouteHandler(makeHostProvisioningOptionsGetHandler(env))
-	app.AddRoute("/hosts/ip_address/{ip_address}").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetHostByIpAddress())
-	app.AddRoute("/volumes").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetVolumes())
-	app.AddRoute("/volumes").Version(2).Post().Wrap(requireUser).RouteHandler(makeCreateVolume(env))
-	app.AddRoute("/volumes/{volume_id}").Version(2).Wrap(requireUser).Delete().RouteHandler(makeDeleteVolume(env))
-	app.AddRoute("/volumes/{volume_id}").Version(2).Wrap(requireUser).Patch().RouteHandler(makeModifyVolume(env))
-	app.AddRoute("/volumes/{volume_id}").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetVolumeByID())
-	app.AddRoute("/keys").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchKeys())
-	app.AddRoute("/keys").Version(2).Post().Wrap(requireUser).RouteHandler(makeSetKey())
-	app.AddRoute("/keys/{key_name}").Version(2).Delete().Wrap(requireUser).RouteHandler(makeDeleteKeys())
-	app.AddRoute("/notifications/{type}").Version(2).Post().Wrap(requireUser).RouteHandler(makeNotification(env))
+	app.AddRoute("/hosts/ip_address/{ip_address}").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetHostByIpAddress())
+	app.AddRoute("/volumes").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetVolumes())
+	app.AddRoute("/volumes").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeCreateVolume(env))
+	app.AddRoute("/volumes/{volume_id}").Version(2).Wrap(requireUser, denyRestrictedTokens).Delete().RouteHandler(makeDeleteVolume(env))
+	app.AddRoute("/volumes/{volume_id}").Version(2).Wrap(requireUser, denyRestrictedTokens).Patch().RouteHandler(makeModifyVolume(env))
+	app.AddRoute("/volumes/{volume_id}").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetVolumeByID())
+	app.AddRoute("/keys").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchKeys())
+	app.AddRoute("/keys").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeSetKey())
+	app.AddRoute("/keys/{key_name}").Version(2).Delete().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeDeleteKeys())
+	app.AddRoute("/notifications/{type}").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeNotification(env))
 	app.AddRoute("/patches/{patch_id}").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeFetchPatchByID())
 	app.AddRoute("/patches/{patch_id}").Version(2).Patch().Wrap(requireUser, submitPatches).RouteHandler(makeChangePatchStatus(env))
 	app.AddRoute("/patches/{patch_id}/abort").Version(2).Post().Wrap(requireUser, submitPatches).RouteHandler(makeAbortPatch())
@@ -184,22 +191,22 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 	app.AddRoute("/pods").Version(2).Post().Wrap(adminSettings).RouteHandler(makePostPod(env))
 	app.AddRoute("/pods/{pod_id}").Version(2).Get().Wrap(adminSettings).RouteHandler(makeGetPod(env))
 	app.AddRoute("/pods/{pod_id}/provisioning_script").Version(2).Get().Wrap(requirePod).RouteHandler(makePodProvisioningScript(settings))
-	app.AddRoute("/projects").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchProjectsRoute(opts.URL))
-	app.AddRoute("/projects/test_alias").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetProjectAliasResultsHandler())
+	app.AddRoute("/projects").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchProjectsRoute(opts.URL))
+	app.AddRoute("/projects/test_alias").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetProjectAliasResultsHandler())
 	app.AddRoute("/projects/{project_id}").Version(2).Delete().Wrap(requireUser, addProject, requireProjectAdmin, editProjectSettings).RouteHandler(makeDeleteProject())
 	app.AddRoute("/projects/{project_id}").Version(2).Get().Wrap(requireUser, addProject, viewProjectSettings).RouteHandler(makeGetProjectByID())
 	app.AddRoute("/projects/{project_id}").Version(2).Patch().Wrap(requireUser, addProject, requireProjectAdmin, editProjectSettings).RouteHandler(makePatchProjectByID(settings))
 	app.AddRoute("/projects/{project_id}/attach_to_repo").Version(2).Post().Wrap(requireUser, addProject, requireProjectAdmin, editProjectSettings).RouteHandler(makeAttachProjectToRepoHandler())
 	app.AddRoute("/projects/{project_id}/detach_from_repo").Version(2).Post().Wrap(requireUser, addProject, requireProjectAdmin, editProjectSettings).RouteHandler(makeDetachProjectFromRepoHandler())
 	app.AddRoute("/projects/{project_id}/repotracker").Version(2).Post().Wrap(requireUser, addProject).RouteHandler(makeRunRepotrackerForProject())
-	app.AddRoute("/projects/{project_id}").Version(2).Put().Wrap(requireUser, createProject).RouteHandler(makePutProjectByID(env))
+	app.AddRoute("/projects/{project_id}").Version(2).Put().Wrap(requireUser, denyRestrictedTokens, createProject).RouteHandler(makePutProjectByID(env))
 	app.AddRoute("/projects/{project_id}/copy").Version(2).Post().Wrap(requireUser, addProject, requireProjectAdmin, editProjectSettings).RouteHandler(makeCopyProject(env))
 	app.AddRoute("/projects/{project_id}/copy/variables").Version(2).Post().Wrap(requireUser, addProject, requireProjectAdmin, editProjectSettings).RouteHandler(makeCopyVariables())
 	app.AddRoute("/projects/{project_id}/events").Version(2).Get().Wrap(requireUser, addProject, requireProjectAdmin, viewProjectSettings).RouteHandler(makeFetchProjectEvents(opts.URL))
 	app.AddRoute("/projects/{project_id}/patches").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makePatchesByProjectRoute(opts.URL))
 	app.AddRoute("/projects/{project_id}/recent_versions").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeFetchProjectVersionsLegacy())
 	app.AddRoute("/projects/{project_id}/revisions/{commit_hash}/tasks").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeTasksByProjectAndCommitHandler(parsleyURL, opts.URL))
-	app.AddRoute("/projects/{project_id}/task_reliability").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetProjectTaskReliability(opts.URL))
+	app.AddRoute("/projects/{project_id}/task_reliability").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetProjectTaskReliability(opts.URL))
 	app.AddRoute("/projects/{project_id}/task_stats").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeGetProjectTaskStats(opts.URL))
 	app.AddRoute("/projects/{project_id}/versions").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeGetProjectVersionsHandler(opts.URL))
 	app.AddRoute("/projects/{project_id}/versions").Version(2).Patch().Wrap(requireUser, requireProjectAdmin).RouteHandler(makeModifyProjectVersionsHandler(opts.URL))
@@ -211,19 +218,21 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 	app.AddRoute("/permissions").Version(2).Get().Wrap(requireUser).RouteHandler(&permissionsGetHandler{})
 	app.AddRoute("/repos/{repo_id}").Version(2).Get().Wrap(requireUser, viewProjectSettings).RouteHandler(makeGetRepoByID())
 	app.AddRoute("/repos/{repo_id}").Version(2).Patch().Wrap(requireUser, requireRepoAdmin, editProjectSettings).RouteHandler(makePatchRepoByID(settings))
-	app.AddRoute("/roles").Version(2).Get().Wrap(requireUser).RouteHandler(acl.NewGetAllRolesHandler(env.RoleManager()))
-	app.AddRoute("/roles").Version(2).Post().Wrap(requireUser).RouteHandler(acl.NewUpdateRoleHandler(env.RoleManager()))
-	app.AddRoute("/roles/{role_id}/users").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetUsersWithRole())
-	app.AddRoute("/scheduler/compare_tasks").Version(2).Post().Wrap(requireUser).RouteHandler(makeCompareTasksRoute())
+	app.AddRoute("/roles").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(acl.NewGetAllRolesHandler(env.RoleManager()))
+	app.AddRoute("/roles").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(acl.NewUpdateRoleHandler(env.RoleManager()))
+	app.AddRoute("/roles/{role_id}/users").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetUsersWithRole())
+	app.AddRoute("/scheduler/compare_tasks").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeCompareTasksRoute())
 	app.AddRoute("/status/cli_version").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchCLIVersionRoute())
-	app.AddRoute("/status/hosts/distros").Version(2).Get().Wrap(requireUser).RouteHandler(makeHostStatusByDistroRoute())
-	app.AddRoute("/status/notifications").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchNotifcationStatusRoute())
-	app.AddRoute("/status/recent_tasks").Version(2).Get().Wrap(requireUser).RouteHandler(makeRecentTaskStatusHandler())
-	app.AddRoute("/subscriptions").Version(2).Delete().Wrap(requireUser).RouteHandler(makeDeleteSubscription())
-	app.AddRoute("/subscriptions").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchSubscription())
-	app.AddRoute("/subscriptions").Version(2).Post().Wrap(requireUser).RouteHandler(makeSetSubscription())
+	app.AddRoute("/status/hosts/distros").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeHostStatusByDistroRoute())
+	app.AddRoute("/status/notifications").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchNotifcationStatusRoute())
+	app.AddRoute("/status/recent_tasks").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeRecentTaskStatusHandler())
+	app.AddRoute("/subscriptions").Version(2).Delete().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeDeleteSubscription())
+	app.AddRoute("/subscriptions").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchSubscription())
+	app.AddRoute("/subscriptions").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeSetSubscription())
+	app.AddRoute("/subscriptions/preview").Version(2).Post().Wrap(requireUser).RouteHandler(makePreviewSubscription(env))
 	app.AddRoute("/tasks/{task_id}").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeGetTaskRoute(parsleyURL, opts.URL))
 	app.AddRoute("/tasks/{task_id}").Version(2).Patch().Wrap(requireUser, addProject, editTasks).RouteHandler(makeModifyTaskRoute())
+	app.AddRoute("/tasks/{task_id}/artifacts/provenance").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeGetTaskArtifactProvenanceHandler())
 	app.AddRoute("/tasks/{task_id}/annotations").Version(2).Get().Wrap(requireUser, viewAnnotations).RouteHandler(makeFetchAnnotationsByTask())
 	app.AddRoute("/tasks/{task_id}/annotation").Version(2).Put().Wrap(requireUser, editAnnotations).RouteHandler(makePutAnnotationsByTask())
 	app.AddRoute("/tasks/annotations").Version(2).Patch().Wrap(requireUser, editAnnotations).RouteHandler(makeBulkPatchAnnotations())
@@ -235,21 +244,25 @@ func AttachHandler(app *gimlet.APIApp, opts HandlerOpts) {
 	app.AddRoute("/tasks/{task_id}/generate").Version(2).Get().Wrap(requireTask).RouteHandler(makeGenerateTasksPollHandler())
 	app.AddRoute("/tasks/{task_id}/manifest").Version(2).Get().Wrap(viewTasks).RouteHandler(makeGetManifestHandler())
 	app.AddRoute("/tasks/{task_id}/restart").Version(2).Post().Wrap(addProject, requireUser, editTasks).RouteHandler(makeTaskRestartHandler())
+	app.AddRoute("/tasks/{task_id}/resource_usage").Version(2).Get().Wrap(requireUser, viewTasks).RouteHandler(makeGetTaskResourceUsageHandler())
 	app.AddRoute("/tasks/{task_id}/tests").Version(2).Get().Wrap(addProject, viewTasks).RouteHandler(makeFetchTestsForTask(env, sc))
 	app.AddRoute("/tasks/{task_id}/tests/count").Version(2).Get().Wrap(addProject, viewTasks).RouteHandler(makeFetchTestCountForTask())
-	app.AddRoute("/tasks/{task_id}/sync_path").Version(2).Get().Wrap(requireUser).RouteHandler(makeTaskSyncPathGetHandler())
+	app.AddRoute("/tasks/{task_id}/sync_path").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeTaskSyncPathGetHandler())
 	app.AddRoute("/tasks/{task_id}/set_results_info").Version(2).Post().Wrap(requireTask).RouteHandler(makeTaskSetResultsInfoHandler())
-	app.AddRoute("/task/sync_read_credentials").Version(2).Get().Wrap(requireUser).RouteHandler(makeTaskSyncReadCredentialsGetHandler())
-	app.AddRoute("/user/settings").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchUserConfig())
-	app.AddRoute("/user/settings").Version(2).Post().Wrap(requireUser).RouteHandler(makeSetUserConfig())
-	app.AddRoute("/users/{user_id}/hosts").Version(2).Get().Wrap(requireUser).RouteHandler(makeFetchHosts(opts.URL))
-	app.AddRoute("/users/{user_id}/patches").Version(2).Get().Wrap(requireUser).RouteHandler(makeUserPatchHandler(opts.URL))
+	app.AddRoute("/task/sync_read_credentials").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeTaskSyncReadCredentialsGetHandler())
+	app.AddRoute("/user/settings").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchUserConfig())
+	app.AddRoute("/user/settings").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeSetUserConfig())
+	app.AddRoute("/user/tokens").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchAccessTokens())
+	app.AddRoute("/user/tokens").Version(2).Post().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeCreateAccessToken())
+	app.AddRoute("/user/tokens/{token_id}").Version(2).Delete().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeRevokeAccessToken())
+	app.AddRoute("/users/{user_id}/hosts").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeFetchHosts(opts.URL))
+	app.AddRoute("/users/{user_id}/patches").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeUserPatchHandler(opts.URL))
 	app.AddRoute("/users/offboard_user").Version(2).Post().Wrap(requireUser, editRoles).RouteHandler(makeOffboardUser(env))
-	app.AddRoute("/users/{user_id}/permissions").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetUserPermissions(env.RoleManager()))
+	app.AddRoute("/users/{user_id}/permissions").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetUserPermissions(env.RoleManager()))
 	app.AddRoute("/users/{user_id}/permissions").Version(2).Post().Wrap(requireUser, editRoles).RouteHandler(makeModifyUserPermissions(env.RoleManager()))
 	app.AddRoute("/users/{user_id}/permissions").Version(2).Delete().Wrap(requireUser, editRoles).RouteHandler(makeDeleteUserPermissions(env.RoleManager()))
 	app.AddRoute("/users/{user_id}/roles").Version(2).Post().Wrap(requireUser, editRoles).RouteHandler(makeModifyUserRoles(env.RoleManager()))
-	app.AddRoute("/users/permissions").Version(2).Get().Wrap(requireUser).RouteHandler(makeGetAllUsersPermissions(env.RoleManager()))
+	app.AddRoute("/users/permissions").Version(2).Get().Wrap(requireUser, denyRestrictedTokens).RouteHandler(makeGetAllUsersPermissions(env.RoleManager()))
 	app.AddRoute("/versions").Version(2).Put().Wrap(requireUser).RouteHandler(makeVersionCreateHandler())
 	app.AddRoute("/versions/{version_id}").Version(2).Get().Wrap(viewTasks).RouteHandler(makeGetVersionByID())
 	app.AddRoute("/versions/{version_id}").Version(2).Patch().Wrap(requireUser, editTasks).RouteHandler(makePatchVersion())
@@ -2,14 +2,20 @@ package route
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
+	"github.com/evergreen-ci/evergreen"
 	dbModel "github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/notification"
+	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	"github.com/evergreen-ci/evergreen/rest/model"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 )
 
@@ -135,3 +141,183 @@ func (s *subscriptionDeleteHandler) Run(ctx context.Context) gimlet.Responder {
 
 	return gimlet.NewJSONResponse(struct{}{})
 }
+
+////////////////////////////////////////////////////////////////////////
+//
+// POST /rest/v2/subscriptions/preview
+
+type subscriptionPreviewHandler struct {
+	EventID      string                `json:"event_id"`
+	Subscription model.APISubscription `json:"subscription"`
+	// SendToMe sends the rendered notification to the requesting user
+	// instead of the subscription's subscriber.
+	SendToMe bool `json:"send_to_me"`
+
+	env evergreen.Environment
+}
+
+func makePreviewSubscription(env evergreen.Environment) gimlet.RouteHandler {
+	return &subscriptionPreviewHandler{env: env}
+}
+
+func (s *subscriptionPreviewHandler) Factory() gimlet.RouteHandler {
+	return &subscriptionPreviewHandler{env: s.env}
+}
+
+func (s *subscriptionPreviewHandler) Parse(ctx context.Context, r *http.Request) error {
+	if err := utility.ReadJSON(r.Body, s); err != nil {
+		return errors.Wrap(err, "reading subscription preview from JSON request body")
+	}
+	if s.EventID == "" {
+		return errors.New("must specify an event ID to preview")
+	}
+
+	return nil
+}
+
+func (s *subscriptionPreviewHandler) Run(ctx context.Context) gimlet.Responder {
+	sub, err := s.Subscription.ToService()
+	if err != nil {
+		return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "converting subscription to service model"))
+	}
+	if s.SendToMe {
+		sub.Subscriber, err = subscriberForUser(MustHaveUser(ctx), sub.Subscriber.Type)
+		if err != nil {
+			return gimlet.MakeJSONErrorResponder(err)
+		}
+	}
+
+	n, matched, err := data.PreviewSubscription(ctx, s.EventID, sub, canViewEvent(ctx))
+	if err != nil {
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	var c message.Composer
+	if n != nil {
+		c, err = n.Composer(s.env)
+		if err != nil {
+			return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "composing notification"))
+		}
+	}
+
+	preview := model.APINotificationPreview{}
+	if err = preview.BuildFromService(s.EventID, matched, n, c); err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "converting notification preview to API model"))
+	}
+	if !s.SendToMe || c == nil {
+		return gimlet.NewJSONResponse(preview)
+	}
+
+	if err = s.send(ctx, n, c); err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "sending notification preview"))
+	}
+	preview.Sent = true
+
+	return gimlet.NewJSONResponse(preview)
+}
+
+func (s *subscriptionPreviewHandler) send(ctx context.Context, n *notification.Notification, c message.Composer) error {
+	flags, err := evergreen.GetServiceFlags(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting service flags")
+	}
+	if n.Subscriber.Type == event.EmailSubscriberType && flags.EmailNotificationsDisabled {
+		return errors.New("email notifications are disabled")
+	}
+	if n.Subscriber.Type == event.SlackSubscriberType && flags.SlackNotificationsDisabled {
+		return errors.New("Slack notifications are disabled")
+	}
+
+	if err = c.SetPriority(level.Notice); err != nil {
+		return errors.Wrap(err, "setting priority")
+	}
+	if !c.Loggable() {
+		return errors.New("composer is not loggable")
+	}
+	key, err := n.SenderKey()
+	if err != nil {
+		return errors.Wrap(err, "getting sender key for notification")
+	}
+	sender, err := s.env.GetSender(key)
+	if err != nil {
+		return errors.Wrap(err, "getting notification sender")
+	}
+	sender.Send(c)
+
+	return nil
+}
+
+// canViewEvent returns a function that checks whether the requesting user can
+// view the resource that an event is about. Events about project resources
+// require permission to view the project's tasks, and events about other
+// resources, such as hosts, are only visible to their owners and superusers.
+func canViewEvent(ctx context.Context) func(event.Attributes) error {
+	u := MustHaveUser(ctx)
+	token := getAccessToken(ctx)
+	return func(attributes event.Attributes) error {
+		for _, projectID := range attributes.Project {
+			if token != nil && !token.AllowsProject(projectID) {
+				return gimlet.ErrorResponse{
+					StatusCode: http.StatusForbidden,
+					Message:    fmt.Sprintf("access token '%s' does not permit access to project '%s'", token.Name, projectID),
+				}
+			}
+			if !u.HasPermission(gimlet.PermissionOpts{
+				Resource:      projectID,
+				ResourceType:  evergreen.ProjectResourceType,
+				Permission:    evergreen.PermissionTasks,
+				RequiredLevel: evergreen.TasksView.Value,
+			}) {
+				return gimlet.ErrorResponse{
+					StatusCode: http.StatusForbidden,
+					Message:    fmt.Sprintf("user '%s' cannot view project '%s'", u.Username(), projectID),
+				}
+			}
+		}
+		if len(attributes.Project) > 0 || utility.StringSliceContains(attributes.Owner, u.Username()) {
+			return nil
+		}
+		if token == nil || !token.IsProjectRestricted() {
+			if u.HasPermission(gimlet.PermissionOpts{
+				Resource:      evergreen.SuperUserPermissionsID,
+				ResourceType:  evergreen.SuperUserResourceType,
+				Permission:    evergreen.PermissionAdminSettings,
+				RequiredLevel: evergreen.AdminSettingsEdit.Value,
+			}) {
+				return nil
+			}
+		}
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    fmt.Sprintf("user '%s' cannot view this event", u.Username()),
+		}
+	}
+}
+
+// subscriberForUser returns a subscriber of the given type that targets the
+// user. Only email and Slack subscribers can be retargeted.
+func subscriberForUser(u *user.DBUser, subscriberType string) (event.Subscriber, error) {
+	switch subscriberType {
+	case event.EmailSubscriberType:
+		if u.Email() == "" {
+			return event.Subscriber{}, gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("user '%s' has no email address", u.Username()),
+			}
+		}
+		return event.NewEmailSubscriber(u.Email()), nil
+	case event.SlackSubscriberType:
+		if u.Settings.SlackUsername == "" {
+			return event.Subscriber{}, gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("user '%s' has no Slack username", u.Username()),
+			}
+		}
+		return event.NewSlackSubscriber("@" + u.Settings.SlackUsername), nil
+	default:
+		return event.Subscriber{}, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("cannot send a preview for subscriber type '%s', only email and Slack are supported", subscriberType),
+		}
+	}
+}
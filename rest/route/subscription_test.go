@@ -8,8 +8,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
+	dbModel "github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/rest/model"
 	"github.com/evergreen-ci/gimlet"
@@ -558,3 +561,100 @@ func (s *SubscriptionRouteSuite) TestAcceptSubscriptionWithOnlyRegexSelectors()
 	s.NoError(err)
 	s.NoError(s.postHandler.Parse(ctx, request))
 }
+
+func (s *SubscriptionRouteSuite) TestPreview() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s.NoError(db.ClearCollections(task.Collection, dbModel.VersionCollection, event.EventCollection, evergreen.ScopeCollection, evergreen.RoleCollection))
+	rm := evergreen.GetEnvironment().RoleManager()
+	s.NoError(rm.AddScope(gimlet.Scope{
+		ID:        "proj_scope",
+		Resources: []string{"proj"},
+		Type:      evergreen.ProjectResourceType,
+	}))
+	s.NoError(rm.UpdateRole(gimlet.Role{
+		ID:          "proj_viewer",
+		Scope:       "proj_scope",
+		Permissions: gimlet.Permissions{evergreen.PermissionTasks: evergreen.TasksView.Value},
+	}))
+	viewerCtx := gimlet.AttachUser(ctx, &user.DBUser{Id: "me", EmailAddress: "me@example.com", SystemRoles: []string{"proj_viewer"}})
+
+	v := dbModel.Version{Id: "v1", AuthorID: "me"}
+	s.NoError(v.Insert())
+	tsk := task.Task{
+		Id:           "t1",
+		Version:      v.Id,
+		Project:      "proj",
+		BuildVariant: "bv",
+		DisplayName:  "compile",
+		Status:       evergreen.TaskFailed,
+		Requester:    evergreen.RepotrackerVersionRequester,
+	}
+	s.NoError(tsk.Insert())
+	e := event.EventLogEntry{
+		ID:           "e1",
+		ResourceType: event.ResourceTypeTask,
+		EventType:    event.TaskFinished,
+		ResourceId:   tsk.Id,
+		Data:         &event.TaskEventData{Status: evergreen.TaskFailed},
+	}
+	s.NoError(e.Log())
+
+	previewFor := func(ctx context.Context, projectSelector string, sendToMe bool) gimlet.Responder {
+		body := map[string]interface{}{
+			"event_id":   e.ID,
+			"send_to_me": sendToMe,
+			"subscription": map[string]interface{}{
+				"resource_type": event.ResourceTypeTask,
+				"trigger":       event.TriggerOutcome,
+				"selectors": []map[string]string{{
+					"type": event.SelectorProject,
+					"data": projectSelector,
+				}},
+				"subscriber": map[string]interface{}{
+					"type": event.EvergreenWebhookSubscriberType,
+					"target": map[string]string{
+						"url":    "https://example.com",
+						"secret": "shh",
+					},
+				},
+			},
+		}
+		jsonBody, err := json.Marshal(body)
+		s.Require().NoError(err)
+		request, err := http.NewRequest(http.MethodPost, "/subscriptions/preview", bytes.NewBuffer(jsonBody))
+		s.Require().NoError(err)
+		handler := makePreviewSubscription(evergreen.GetEnvironment())
+		s.Require().NoError(handler.Parse(ctx, request))
+		return handler.Run(ctx)
+	}
+
+	s.Run("MatchingSubscription", func() {
+		resp := previewFor(viewerCtx, "proj", false)
+		s.Require().Equal(http.StatusOK, resp.Status())
+		preview, ok := resp.Data().(model.APINotificationPreview)
+		s.Require().True(ok)
+		s.True(preview.Matched)
+		s.True(preview.Triggered)
+		s.False(preview.Sent)
+		s.NotEmpty(utility.FromStringPtr(preview.Message))
+	})
+	s.Run("NonMatchingSubscription", func() {
+		resp := previewFor(viewerCtx, "other-proj", false)
+		s.Require().Equal(http.StatusOK, resp.Status())
+		preview, ok := resp.Data().(model.APINotificationPreview)
+		s.Require().True(ok)
+		s.False(preview.Matched)
+		s.False(preview.Triggered)
+		s.Nil(preview.Message)
+	})
+	s.Run("SendToMeRequiresEmailOrSlack", func() {
+		resp := previewFor(viewerCtx, "proj", true)
+		s.Equal(http.StatusBadRequest, resp.Status())
+	})
+	s.Run("RequiresProjectViewPermission", func() {
+		otherCtx := gimlet.AttachUser(ctx, &user.DBUser{Id: "someone-else"})
+		resp := previewFor(otherCtx, "proj", false)
+		s.Equal(http.StatusForbidden, resp.Status())
+	})
+}
@@ -0,0 +1,71 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+// GET /tasks/{task_id}/resource_usage
+type getTaskResourceUsageHandler struct {
+	taskID    string
+	execution *int
+}
+
+func makeGetTaskResourceUsageHandler() gimlet.RouteHandler {
+	return &getTaskResourceUsageHandler{}
+}
+
+func (h *getTaskResourceUsageHandler) Factory() gimlet.RouteHandler {
+	return &getTaskResourceUsageHandler{}
+}
+
+// Parse fetches the task ID and the optional execution from the request.
+func (h *getTaskResourceUsageHandler) Parse(ctx context.Context, r *http.Request) error {
+	h.taskID = gimlet.GetVars(r)["task_id"]
+	if executionStr := r.URL.Query().Get("execution"); executionStr != "" {
+		execution, err := strconv.Atoi(executionStr)
+		if err != nil {
+			return gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    "invalid execution",
+			}
+		}
+		h.execution = &execution
+	}
+	return nil
+}
+
+// Run returns the resource usage time series for the task execution. It
+// defaults to the task's latest execution.
+func (h *getTaskResourceUsageHandler) Run(ctx context.Context) gimlet.Responder {
+	t, err := task.FindByIdExecution(h.taskID, h.execution)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding task '%s'", h.taskID))
+	}
+	if t == nil {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("task '%s' not found", h.taskID),
+		})
+	}
+
+	usage, err := resourceusage.FindOneByTaskIDAndExecution(h.taskID, t.Execution)
+	if err != nil {
+		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding resource usage for task '%s'", h.taskID))
+	}
+	if usage == nil {
+		usage = &resourceusage.TaskResourceUsage{TaskID: h.taskID, Execution: t.Execution}
+	}
+
+	apiUsage := &model.APITaskResourceUsage{}
+	apiUsage.BuildFromService(*usage)
+	return gimlet.NewJSONResponse(apiUsage)
+}
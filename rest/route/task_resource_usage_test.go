@@ -0,0 +1,91 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/resourceusage"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskResourceUsage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, db.ClearCollections(task.Collection, task.OldCollection, resourceusage.Collection))
+	tsk := task.Task{Id: "t1", Execution: 1}
+	require.NoError(t, tsk.Insert())
+	oldTask := task.Task{Id: "t1_0", OldTaskId: "t1", Execution: 0}
+	require.NoError(t, db.Insert(task.OldCollection, oldTask))
+
+	attach := func(t *testing.T, taskID, execution string, samples []resourceusage.Sample) gimlet.Responder {
+		body, err := json.Marshal(samples)
+		require.NoError(t, err)
+		r, err := http.NewRequest(http.MethodPost, "/task/"+taskID+"/resource_usage?execution="+execution, bytes.NewBuffer(body))
+		require.NoError(t, err)
+		r = gimlet.SetURLVars(r, map[string]string{"task_id": taskID})
+		rh := makeAttachResourceUsage()
+		require.NoError(t, rh.Parse(ctx, r))
+		return rh.Run(ctx)
+	}
+	get := func(t *testing.T, taskID, execution string) gimlet.Responder {
+		url := "/tasks/" + taskID + "/resource_usage"
+		if execution != "" {
+			url += "?execution=" + execution
+		}
+		r, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		r = gimlet.SetURLVars(r, map[string]string{"task_id": taskID})
+		rh := makeGetTaskResourceUsageHandler()
+		require.NoError(t, rh.Parse(ctx, r))
+		return rh.Run(ctx)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	resp := attach(t, "t1", "1", []resourceusage.Sample{
+		{Time: now, CPUPercent: 25, MemoryRSSBytes: 1024, Processes: []resourceusage.ProcessUsage{{PID: 10, Command: "make", RSSBytes: 1024}}},
+	})
+	require.Equal(t, http.StatusOK, resp.Status())
+	resp = attach(t, "t1", "0", []resourceusage.Sample{{Time: now, MemoryRSSBytes: 2048}})
+	require.Equal(t, http.StatusOK, resp.Status())
+	resp = attach(t, "nonexistent", "0", []resourceusage.Sample{{Time: now}})
+	assert.Equal(t, http.StatusNotFound, resp.Status())
+
+	resp = get(t, "t1", "")
+	require.Equal(t, http.StatusOK, resp.Status())
+	usage, ok := resp.Data().(*model.APITaskResourceUsage)
+	require.True(t, ok)
+	assert.Equal(t, 1, usage.Execution)
+	require.Len(t, usage.Samples, 1)
+	assert.EqualValues(t, 25, usage.Samples[0].CPUPercent)
+	assert.Equal(t, 1024, usage.Samples[0].MemoryRSSBytes)
+	require.Len(t, usage.Samples[0].Processes, 1)
+	assert.Equal(t, "make", *usage.Samples[0].Processes[0].Command)
+
+	resp = get(t, "t1", "0")
+	require.Equal(t, http.StatusOK, resp.Status())
+	usage, ok = resp.Data().(*model.APITaskResourceUsage)
+	require.True(t, ok)
+	assert.Equal(t, 0, usage.Execution)
+	require.Len(t, usage.Samples, 1)
+	assert.Equal(t, 2048, usage.Samples[0].MemoryRSSBytes)
+
+	require.NoError(t, db.ClearCollections(resourceusage.Collection))
+	resp = get(t, "t1", "")
+	require.Equal(t, http.StatusOK, resp.Status())
+	usage, ok = resp.Data().(*model.APITaskResourceUsage)
+	require.True(t, ok)
+	assert.Empty(t, usage.Samples)
+
+	resp = get(t, "nonexistent", "")
+	assert.Equal(t, http.StatusNotFound, resp.Status())
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	dbModel "github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/taskstats"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	"github.com/evergreen-ci/gimlet"
@@ -67,6 +68,11 @@ func (sh *StatsHandler) ParseCommonFilter(vals url.Values) error {
 
 	sh.filter.Distros = sh.readStringList(vals["distros"])
 
+	sh.filter.FailureClassifications = sh.readStringList(vals["failure_classifications"])
+	if err = task.ValidateFailureClassifications(sh.filter.FailureClassifications); err != nil {
+		return errors.Wrap(err, "invalid failure classifications")
+	}
+
 	sh.filter.GroupNumDays, err = sh.readInt(vals.Get("group_num_days"), 1, statsAPIMaxGroupNumDays, 1)
 	if err != nil {
 		return errors.Wrap(err, "invalid grouping by number of days")
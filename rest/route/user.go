@@ -143,7 +143,7 @@ func (h *userPermissionsPostHandler) Run(ctx context.Context) gimlet.Responder {
 	if err != nil {
 		return gimlet.NewTextInternalErrorResponse(err.Error())
 	}
-	if err = u.AddRole(newRole.ID); err != nil {
+	if err = u.AddRoleBy(requestUsername(ctx), newRole.ID); err != nil {
 		return gimlet.NewTextInternalErrorResponse(err.Error())
 	}
 
@@ -211,7 +211,7 @@ func (h *userPermissionsDeleteHandler) Run(ctx context.Context) gimlet.Responder
 	}
 
 	if h.resourceType == allResourceType {
-		err = u.DeleteAllRoles()
+		err = u.DeleteAllRolesBy(requestUsername(ctx))
 		if err != nil {
 			return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "deleting all roles for user '%s'", u.Username()))
 		}
@@ -248,7 +248,7 @@ func (h *userPermissionsDeleteHandler) Run(ctx context.Context) gimlet.Responder
 		"resource_type": h.resourceType,
 		"resource_id":   h.resourceId,
 	})
-	err = u.DeleteRoles(rolesToRemove)
+	err = u.DeleteRolesBy(requestUsername(ctx), rolesToRemove)
 	if err != nil {
 		return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "deleting roles for user '%s'", u.Username()))
 	}
@@ -490,7 +490,7 @@ func (h *userRolesPostHandler) Run(ctx context.Context) gimlet.Responder {
 		})
 	}
 	for _, toAdd := range h.roles {
-		if err = u.AddRole(toAdd); err != nil {
+		if err = u.AddRoleBy(requestUsername(ctx), toAdd); err != nil {
 			return gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "adding role '%s' to user '%s'", toAdd, u.Username()))
 		}
 	}
@@ -623,3 +623,12 @@ func (h *serviceUsersGetHandler) Run(ctx context.Context) gimlet.Responder {
 
 	return gimlet.NewJSONResponse(users)
 }
+
+// requestUsername returns the name of the user making the request, or an empty
+// string if there is none.
+func requestUsername(ctx context.Context) string {
+	if u := gimlet.GetUser(ctx); u != nil {
+		return u.Username()
+	}
+	return ""
+}
@@ -56,6 +56,9 @@ func (h *versionCreateHandler) Run(ctx context.Context) gimlet.Responder {
 	if projectInfo.Ref == nil {
 		return gimlet.NewJSONErrorResponse(errors.Errorf("project '%s' not found", h.ProjectID))
 	}
+	if err = CheckAccessTokenProject(ctx, projectInfo.Ref.Id); err != nil {
+		return gimlet.MakeJSONErrorResponder(err)
+	}
 	p := &model.Project{}
 	opts := &model.GetProjectOpts{
 		Ref:          projectInfo.Ref,
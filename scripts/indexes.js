@@ -39,6 +39,10 @@ db.artifact_files.ensureIndex({
 db.artifact_files.ensureIndex({
     "build": 1
 })
+db.artifact_files.createIndex({
+    "files.bucket": 1,
+    "files.filekey": 1
+})
 
 //======builds======//
 db.builds.ensureIndex({
@@ -82,6 +86,14 @@ db.events.ensureIndex({
     "processed_at": 1,
     "ts": 1
 })
+db.events.ensureIndex({
+    "audit_seq": 1
+}, {
+    unique: true,
+    partialFilterExpression: {
+        "audit_seq": { $exists: true }
+    }
+})
 
 //======hosts======//
 db.hosts.ensureIndex({
@@ -306,6 +318,11 @@ db.tasks.createIndex({
     "branch": 1,
     "finish_time": 1
 })
+db.tasks.createIndex({
+    "retry_at": 1
+}, {
+    sparse: true
+})
 
 //======old_tasks======//
 db.old_tasks.ensureIndex({
@@ -537,8 +554,25 @@ db.daily_task_stats.createIndex({
     "_id.date": 1
 })
 
+//======test_shard_assignments======//
+db.test_shard_assignments.createIndex({
+    "build_id": 1,
+    "tasks": 1
+})
+db.test_shard_assignments.createIndex({
+    "create_time": 1
+}, {
+    expireAfterSeconds: 30 * 24 * 3600
+})
+
 //======manifest======//
 db.manifest.createIndex({
     "project": 1,
     "revision": 1
-})
\ No newline at end of file
+})
+//======task_resource_usage======//
+db.task_resource_usage.createIndex({
+    "create_time": 1
+}, {
+    expireAfterSeconds: 30 * 24 * 3600
+})
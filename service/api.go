@@ -257,12 +257,20 @@ func (as *APIServer) validateProjectConfig(w http.ResponseWriter, r *http.Reques
 		}
 		errs = append(errs, validationErr)
 	}
+	if projectRef == nil {
+		// Policies that apply to all projects can still be checked without
+		// the project settings.
+		errs = append(errs, validator.CheckProjectPolicies(evergreen.GetEnvironment().Settings(), project, nil)...)
+	}
 
 	errs = append(errs, validator.CheckProjectErrors(r.Context(), project, input.IncludeLong)...)
 	if projectConfig != nil {
 		errs = append(errs, validator.CheckProjectConfigErrors(projectConfig)...)
 	}
 
+	if !input.Quiet {
+		errs = append(errs, validator.CheckProjectCLIWarnings(project)...)
+	}
 	if input.Quiet {
 		errs = errs.AtLevel(validator.Error)
 	} else if projectRef == nil {
@@ -358,7 +366,7 @@ func (as *APIServer) GetServiceApp() *gimlet.APIApp {
 
 	// Patches
 	app.PrefixRoute("/patches").Route("/").Wrap(requireUser).Handler(as.submitPatch).Put()
-	app.PrefixRoute("/patches").Route("/mine").Wrap(requireUser).Handler(as.listPatches).Get()
+	app.PrefixRoute("/patches").Route("/mine").Wrap(requireUser, route.NewDenyProjectRestrictedAccessTokenMiddleware()).Handler(as.listPatches).Get()
 	app.PrefixRoute("/patches").Route("/{patchId:\\w+}").Wrap(requireUser, viewTasks).Handler(as.summarizePatch).Get()
 	app.PrefixRoute("/patches").Route("/{patchId:\\w+}").Wrap(requireUser, submitPatch).Handler(as.existingPatchRequest).Post()
 	app.PrefixRoute("/patches").Route("/{patchId:\\w+}/{projectId}/modules").Wrap(requireUser, requireProject, viewTasks).Handler(as.listPatchModules).Get()
@@ -14,6 +14,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model/patch"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/evergreen/rest/route"
 	"github.com/evergreen-ci/evergreen/thirdparty"
 	"github.com/evergreen-ci/evergreen/units"
 	"github.com/evergreen-ci/evergreen/util"
@@ -149,6 +150,12 @@ func (as *APIServer) submitPatch(w http.ResponseWriter, r *http.Request) {
 			})
 		return
 	}
+	// The project comes from the request body, so the permission middleware
+	// can't check it against the access token's projects.
+	if err = route.CheckAccessTokenProject(r.Context(), pref.Id); err != nil {
+		gimlet.WriteJSONResponse(w, http.StatusForbidden, err)
+		return
+	}
 
 	patchString := string(data.PatchBytes)
 	if len(patchString) > patch.SizeLimit {
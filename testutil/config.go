@@ -174,6 +174,9 @@ func MockConfig() *evergreen.Settings {
 				Name: "logs",
 				Type: evergreen.BucketTypeS3,
 			},
+			ArtifactBuckets: []evergreen.ArtifactBucket{
+				{Name: "artifacts", Region: "us-east-1", Key: "key", Secret: "secret"},
+			},
 		},
 		Cedar: evergreen.CedarConfig{
 			BaseURL: "url.com",
@@ -2,13 +2,17 @@ package thirdparty
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
@@ -309,3 +313,260 @@ func FormGitUrl(host, owner, repo, token string) string {
 
 	return fmt.Sprintf("git@%s:%s/%s.git", host, owner, repo)
 }
+
+// GitCommit is a commit read from a local git repository.
+type GitCommit struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Message     string
+	CommitTime  time.Time
+}
+
+const (
+	gitFieldSeparator  = "\x1f"
+	gitRecordSeparator = "\x1e"
+)
+
+// GitRemoteBranchRef returns the ref that GitFetchBranch fetches the branch
+// into.
+func GitRemoteBranchRef(branch string) string {
+	return fmt.Sprintf("refs/remotes/origin/%s", branch)
+}
+
+// gitSCPURLRegex matches the scp-like SSH syntax, e.g. git@host:owner/repo.git.
+var gitSCPURLRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9][A-Za-z0-9.-]*:[^/].*$`)
+
+// gitURLInTextRegex matches URLs in free-form text, such as git's output.
+var gitURLInTextRegex = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s'"]+`)
+
+// gitURLRedactedUser replaces the credentials in redacted git URLs.
+const gitURLRedactedUser = "REDACTED"
+
+// ValidateGitURL checks that a git URL is an HTTPS or SSH remote. Local
+// repositories, given as absolute paths or file:// URLs, are only allowed if
+// allowLocal is set, since git reads them from the machine running the fetch.
+// Other transports are always rejected.
+func ValidateGitURL(gitURL string, allowLocal bool) error {
+	if gitURL == "" {
+		return errors.New("git URL cannot be empty")
+	}
+	if strings.HasPrefix(gitURL, "-") {
+		return errors.New("git URL cannot start with '-'")
+	}
+	if strings.ContainsAny(gitURL, "'\"`\\ \t\r\n") {
+		return errors.New("git URL cannot contain quotes, backslashes or whitespace")
+	}
+	if !strings.Contains(gitURL, "://") {
+		if filepath.IsAbs(gitURL) {
+			if !allowLocal {
+				return errors.New("git URL cannot be a local path")
+			}
+			return nil
+		}
+		if !gitSCPURLRegex.MatchString(gitURL) {
+			return errors.Errorf("git URL '%s' must be an HTTPS or SSH URL", RedactGitURL(gitURL))
+		}
+		return nil
+	}
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return errors.New("git URL is not a valid URL")
+	}
+	if u.Scheme == "file" {
+		if !allowLocal {
+			return errors.New("git URL cannot be a local file:// URL")
+		}
+		if u.Host != "" || !filepath.IsAbs(u.Path) {
+			return errors.New("file:// git URL must have an absolute path and no host")
+		}
+		return nil
+	}
+	if u.Scheme != "https" && u.Scheme != "ssh" {
+		return errors.Errorf("git URL scheme '%s' is not supported, must be 'https' or 'ssh'", u.Scheme)
+	}
+	if u.Hostname() == "" || strings.HasPrefix(u.Hostname(), "-") {
+		return errors.New("git URL must have a valid host")
+	}
+	return nil
+}
+
+// RedactGitURL returns the git URL with any credentials it embeds replaced,
+// so that it's safe to display.
+func RedactGitURL(gitURL string) string {
+	if !strings.Contains(gitURL, "://") {
+		return gitURL
+	}
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return gitURLRedactedUser
+	}
+	if u.User == nil {
+		return gitURL
+	}
+	if _, hasPassword := u.User.Password(); u.Scheme == "ssh" && !hasPassword {
+		// A bare SSH user name such as "git" isn't a credential.
+		return gitURL
+	}
+	u.User = url.User(gitURLRedactedUser)
+	return u.String()
+}
+
+// redactGitURLs returns the text with the credentials in any URLs it contains
+// replaced.
+func redactGitURLs(text string) string {
+	return gitURLInTextRegex.ReplaceAllStringFunc(text, RedactGitURL)
+}
+
+// GitURLHostAndPath returns the lowercased host name of a git URL and the path
+// of the repository on that host without a leading slash or ".git" suffix,
+// e.g. "gitlab.example.com" and "group/project".
+func GitURLHostAndPath(gitURL string) (string, string, error) {
+	// Local repositories have no host.
+	if err := ValidateGitURL(gitURL, false); err != nil {
+		return "", "", err
+	}
+	var host, path string
+	if strings.Contains(gitURL, "://") {
+		u, err := url.Parse(gitURL)
+		if err != nil {
+			return "", "", errors.New("git URL is not a valid URL")
+		}
+		host, path = u.Hostname(), u.Path
+	} else {
+		hostAndPath := strings.SplitN(gitURL[strings.Index(gitURL, "@")+1:], ":", 2)
+		host, path = hostAndPath[0], hostAndPath[1]
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if path == "" {
+		return "", "", errors.Errorf("git URL '%s' has no repository path", RedactGitURL(gitURL))
+	}
+	return strings.ToLower(host), path, nil
+}
+
+// GitFetchBranch fetches a branch from a remote repository into a local bare
+// repository, creating the local repository if it doesn't exist yet. Callers
+// must validate remotes that come from user input with ValidateGitURL.
+func GitFetchBranch(ctx context.Context, dir, remote, branch string) error {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); os.IsNotExist(err) {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return errors.Wrapf(err, "creating directory '%s'", dir)
+		}
+		if _, err = runGit(ctx, dir, "init", "--bare", "--quiet"); err != nil {
+			return errors.Wrap(err, "initializing repository")
+		}
+	}
+	refspec := fmt.Sprintf("+refs/heads/%s:%s", branch, GitRemoteBranchRef(branch))
+	_, err := runGit(ctx, dir, "fetch", "--quiet", "--prune", "--", remote, refspec)
+	return errors.Wrapf(err, "fetching branch '%s'", branch)
+}
+
+// GitHasCommit returns whether the local repository contains the commit.
+func GitHasCommit(ctx context.Context, dir, revision string) bool {
+	_, err := runGit(ctx, dir, "cat-file", "-e", revision+"^{commit}")
+	return err == nil
+}
+
+// GitIsAncestor returns whether the ancestor commit is reachable from the
+// revision.
+func GitIsAncestor(ctx context.Context, dir, ancestor, revision string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", ancestor, revision)
+	cmd.Dir = dir
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "checking if '%s' is an ancestor of '%s'", ancestor, revision)
+	}
+	return true, nil
+}
+
+// GitMergeBase returns the best common ancestor of two commits.
+func GitMergeBase(ctx context.Context, dir, revision1, revision2 string) (string, error) {
+	out, err := runGit(ctx, dir, "merge-base", revision1, revision2)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting merge base of '%s' and '%s'", revision1, revision2)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// GitShowFile returns the contents of a file at a revision.
+func GitShowFile(ctx context.Context, dir, revision, path string) ([]byte, error) {
+	out, err := runGit(ctx, dir, "show", fmt.Sprintf("%s:%s", revision, path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading file '%s' at revision '%s'", path, revision)
+	}
+	return []byte(out), nil
+}
+
+// GitChangedFiles returns the paths of the files changed by a commit. Merge
+// commits are compared against their first parent.
+func GitChangedFiles(ctx context.Context, dir, revision string) ([]string, error) {
+	out, err := runGit(ctx, dir, "show", "--format=", "--name-only", "--first-parent", "-m", revision)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting files changed by revision '%s'", revision)
+	}
+	files := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// GitLog returns the commits in the revision range, from most to least
+// recent. If max is positive, at most max commits are returned.
+func GitLog(ctx context.Context, dir, revisionRange string, max int) ([]GitCommit, error) {
+	format := strings.Join([]string{"%H", "%an", "%ae", "%ct", "%B"}, gitFieldSeparator) + gitRecordSeparator
+	args := []string{"log", "--format=" + format}
+	if max > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", max))
+	}
+	out, err := runGit(ctx, dir, append(args, revisionRange, "--")...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting log for '%s'", revisionRange)
+	}
+
+	commits := []GitCommit{}
+	for _, record := range strings.Split(out, gitRecordSeparator) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, gitFieldSeparator, 5)
+		if len(fields) != 5 {
+			return nil, errors.Errorf("malformed git log record '%s'", record)
+		}
+		secs, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing commit time for commit '%s'", fields[0])
+		}
+		commits = append(commits, GitCommit{
+			Hash:        fields[0],
+			AuthorName:  fields[1],
+			AuthorEmail: fields[2],
+			CommitTime:  time.Unix(secs, 0),
+			Message:     strings.TrimSpace(fields[4]),
+		})
+	}
+	return commits, nil
+}
+
+// runGit runs a git command in the directory and returns its output. The
+// returned error redacts credentials in remote URLs from both the command line
+// and git's output, since git often echoes the remote.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Never prompt for credentials, since there's no one to answer.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "running 'git %s': %s", redactGitURLs(strings.Join(args, " ")), redactGitURLs(strings.TrimSpace(stderr.String())))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,346 @@
+package thirdparty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	gitlabAPIPrefix   = "/api/v4"
+	gitlabTokenHeader = "PRIVATE-TOKEN"
+	gitlabAPITimeout  = 30 * time.Second
+
+	// GitlabEventHeader is the header in which GitLab sends the webhook event
+	// type.
+	GitlabEventHeader = "X-Gitlab-Event"
+	// GitlabTokenHeader is the header in which GitLab sends the webhook's
+	// secret token.
+	GitlabTokenHeader = "X-Gitlab-Token"
+	// GitlabEventUUIDHeader is the header in which GitLab sends the unique ID
+	// of the webhook delivery.
+	GitlabEventUUIDHeader = "X-Gitlab-Event-UUID"
+
+	// GitlabMergeRequestEventType is the webhook event type for merge request
+	// events.
+	GitlabMergeRequestEventType = "Merge Request Hook"
+	// GitlabNoteEventType is the webhook event type for comment events.
+	GitlabNoteEventType = "Note Hook"
+
+	// Merge request webhook actions.
+	GitlabMRActionOpen   = "open"
+	GitlabMRActionReopen = "reopen"
+	GitlabMRActionUpdate = "update"
+	GitlabMRActionClose  = "close"
+	GitlabMRActionMerge  = "merge"
+
+	// GitlabNoteableTypeMergeRequest is the noteable type of comments on merge
+	// requests.
+	GitlabNoteableTypeMergeRequest = "MergeRequest"
+
+	// GitlabDeveloperAccessLevel is the access level of project members with
+	// the Developer role, which is the lowest role that can push to the
+	// project.
+	GitlabDeveloperAccessLevel = 30
+)
+
+// GitlabPatch stores patch data for patches created from GitLab merge
+// requests.
+type GitlabPatch struct {
+	// ProjectPath is the full path of the GitLab project that the merge
+	// request targets, e.g. group/subgroup/project.
+	ProjectPath string `bson:"project_path"`
+	// MRNumber is the merge request's project-level ID (its IID).
+	MRNumber   int    `bson:"mr_number"`
+	BaseBranch string `bson:"base_branch"`
+	HeadBranch string `bson:"head_branch"`
+	HeadHash   string `bson:"head_hash"`
+	Author     string `bson:"author"`
+	AuthorID   int    `bson:"author_id"`
+	URL        string `bson:"url"`
+}
+
+var (
+	// BSON fields for GitlabPatch
+	GitlabPatchProjectPathKey = bsonutil.MustHaveTag(GitlabPatch{}, "ProjectPath")
+	GitlabPatchMRNumberKey    = bsonutil.MustHaveTag(GitlabPatch{}, "MRNumber")
+)
+
+// GitlabUser is a GitLab user as returned by the API and in webhooks.
+type GitlabUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// GitlabDiffRefs are the commits that a merge request's diff is computed
+// from.
+type GitlabDiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// GitlabMergeRequest is a merge request as returned by the GitLab API.
+type GitlabMergeRequest struct {
+	IID          int            `json:"iid"`
+	Title        string         `json:"title"`
+	Description  string         `json:"description"`
+	State        string         `json:"state"`
+	SourceBranch string         `json:"source_branch"`
+	TargetBranch string         `json:"target_branch"`
+	SHA          string         `json:"sha"`
+	WebURL       string         `json:"web_url"`
+	Author       GitlabUser     `json:"author"`
+	DiffRefs     GitlabDiffRefs `json:"diff_refs"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// GitlabMergeRequestChange is a file changed by a merge request.
+type GitlabMergeRequestChange struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// GitlabProject is a GitLab project as sent in webhooks.
+type GitlabProject struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+}
+
+// GitlabMergeRequestEvent is the payload of a merge request webhook.
+type GitlabMergeRequestEvent struct {
+	User             GitlabUser                        `json:"user"`
+	Project          GitlabProject                     `json:"project"`
+	ObjectAttributes GitlabMergeRequestEventAttributes `json:"object_attributes"`
+}
+
+// GitlabMergeRequestEventAttributes describes the merge request and the change
+// to it in a merge request webhook.
+type GitlabMergeRequestEventAttributes struct {
+	IID          int    `json:"iid"`
+	Action       string `json:"action"`
+	TargetBranch string `json:"target_branch"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	// OldRev is only set for updates that pushed new commits.
+	OldRev string `json:"oldrev"`
+}
+
+// GitlabNoteEvent is the payload of a comment webhook.
+type GitlabNoteEvent struct {
+	User             GitlabUser                `json:"user"`
+	Project          GitlabProject             `json:"project"`
+	ObjectAttributes GitlabNoteEventAttributes `json:"object_attributes"`
+	// MergeRequest is only set for comments on merge requests.
+	MergeRequest *GitlabMergeRequest `json:"merge_request"`
+}
+
+// GitlabNoteEventAttributes describes the comment in a comment webhook.
+type GitlabNoteEventAttributes struct {
+	Note         string `json:"note"`
+	NoteableType string `json:"noteable_type"`
+}
+
+// SplitGitlabProjectPath splits a GitLab project path into its namespace
+// and project name.
+func SplitGitlabProjectPath(projectPath string) (string, string, error) {
+	i := strings.LastIndex(projectPath, "/")
+	if i <= 0 || i == len(projectPath)-1 {
+		return "", "", errors.Errorf("project path '%s' is invalid (expected [namespace]/[project])", projectPath)
+	}
+	return projectPath[:i], projectPath[i+1:], nil
+}
+
+// GetGitlabMergeRequest returns the merge request with the given IID in the
+// project.
+func GetGitlabMergeRequest(ctx context.Context, conf evergreen.GitlabConfig, projectPath string, iid int) (*GitlabMergeRequest, error) {
+	mr := &GitlabMergeRequest{}
+	path := fmt.Sprintf("%s/merge_requests/%d", gitlabProjectEndpoint(projectPath), iid)
+	if err := gitlabGet(ctx, conf, path, nil, mr); err != nil {
+		return nil, errors.Wrapf(err, "getting merge request '%s!%d'", projectPath, iid)
+	}
+	return mr, nil
+}
+
+// GetGitlabMergeRequestDiff returns the diff of the merge request against its
+// base commit in the format produced by git diff, along with a summary of the
+// changes to each file.
+func GetGitlabMergeRequestDiff(ctx context.Context, conf evergreen.GitlabConfig, projectPath string, iid int) (string, []Summary, error) {
+	out := struct {
+		Changes []GitlabMergeRequestChange `json:"changes"`
+	}{}
+	path := fmt.Sprintf("%s/merge_requests/%d/changes", gitlabProjectEndpoint(projectPath), iid)
+	if err := gitlabGet(ctx, conf, path, url.Values{"access_raw_diffs": []string{"true"}}, &out); err != nil {
+		return "", nil, errors.Wrapf(err, "getting changes for merge request '%s!%d'", projectPath, iid)
+	}
+
+	diff := formatGitlabDiff(out.Changes)
+	summaries, err := GetPatchSummaries(diff)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "getting patch summary")
+	}
+	return diff, summaries, nil
+}
+
+// formatGitlabDiff converts the changes that the GitLab API returns, which
+// only contain each file's hunks, into a diff that git can apply.
+func formatGitlabDiff(changes []GitlabMergeRequestChange) string {
+	var sb strings.Builder
+	for _, change := range changes {
+		if change.Diff == "" && !change.RenamedFile {
+			// Binary files have no diff and can't be applied from the
+			// API's output.
+			continue
+		}
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", change.OldPath, change.NewPath)
+		oldPath, newPath := "a/"+change.OldPath, "b/"+change.NewPath
+		switch {
+		case change.NewFile:
+			fmt.Fprintf(&sb, "new file mode %s\n", change.BMode)
+			oldPath = "/dev/null"
+		case change.DeletedFile:
+			fmt.Fprintf(&sb, "deleted file mode %s\n", change.AMode)
+			newPath = "/dev/null"
+		case change.RenamedFile:
+			fmt.Fprintf(&sb, "rename from %s\nrename to %s\n", change.OldPath, change.NewPath)
+		}
+		if change.Diff == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldPath, newPath)
+		sb.WriteString(change.Diff)
+		if !strings.HasSuffix(change.Diff, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// GetGitlabFile returns the contents of the file at the given path in the
+// project as of the given revision.
+func GetGitlabFile(ctx context.Context, conf evergreen.GitlabConfig, projectPath, path, revision string) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/repository/files/%s/raw", gitlabProjectEndpoint(projectPath), url.PathEscape(path))
+	resp, err := gitlabRequest(ctx, conf, endpoint, url.Values{"ref": []string{revision}})
+	if err != nil {
+		if errors.Is(err, errGitlabNotFound) {
+			return nil, FileNotFoundError{filepath: path}
+		}
+		return nil, errors.Wrapf(err, "getting file '%s' in '%s' at revision '%s'", path, projectPath, revision)
+	}
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading file '%s' in '%s' at revision '%s'", path, projectPath, revision)
+	}
+	return contents, nil
+}
+
+// GetGitlabProjectAccessLevel returns the access level that the user has in
+// the project, including access inherited from its groups, or 0 if the user
+// isn't a member.
+func GetGitlabProjectAccessLevel(ctx context.Context, conf evergreen.GitlabConfig, projectPath string, userID int) (int, error) {
+	member := struct {
+		AccessLevel int `json:"access_level"`
+	}{}
+	path := fmt.Sprintf("%s/members/all/%d", gitlabProjectEndpoint(projectPath), userID)
+	if err := gitlabGet(ctx, conf, path, nil, &member); err != nil {
+		if errors.Is(err, errGitlabNotFound) {
+			return 0, nil
+		}
+		return 0, errors.Wrapf(err, "getting access level of user %d in '%s'", userID, projectPath)
+	}
+	return member.AccessLevel, nil
+}
+
+var errGitlabNotFound = errors.New("not found")
+
+func gitlabProjectEndpoint(projectPath string) string {
+	return fmt.Sprintf("/projects/%s", url.PathEscape(projectPath))
+}
+
+// gitlabGet sends a GET request to the GitLab API and decodes the JSON
+// response into out.
+func gitlabGet(ctx context.Context, conf evergreen.GitlabConfig, path string, query url.Values, out interface{}) error {
+	resp, err := gitlabRequest(ctx, conf, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(out), "decoding response")
+}
+
+// gitlabRequest sends a GET request to the GitLab API. The caller must close
+// the response body if there is no error.
+func gitlabRequest(ctx context.Context, conf evergreen.GitlabConfig, path string, query url.Values) (*http.Response, error) {
+	if !conf.IsConfigured() {
+		return nil, errors.New("GitLab is not configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, gitlabAPITimeout)
+	defer func() {
+		if cancel != nil {
+			cancel()
+		}
+	}()
+
+	u := conf.URL + gitlabAPIPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	req.Header.Set(gitlabTokenHeader, conf.Token)
+
+	client := utility.GetHTTPClient()
+	defer utility.PutHTTPClient(client)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "sending request")
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errGitlabNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.Errorf("GitLab API returned HTTP status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// The context must remain valid until the caller reads the body.
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	cancel = nil
+	return resp, nil
+}
+
+// cancelOnCloseBody cancels a request's context once its body is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
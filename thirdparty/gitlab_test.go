@@ -0,0 +1,104 @@
+package thirdparty
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitGitlabProjectPath(t *testing.T) {
+	namespace, project, err := SplitGitlabProjectPath("group/subgroup/project")
+	require.NoError(t, err)
+	assert.Equal(t, "group/subgroup", namespace)
+	assert.Equal(t, "project", project)
+
+	for _, path := range []string{"", "project", "/project", "group/"} {
+		_, _, err = SplitGitlabProjectPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestGitlabAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// GitLab project and file paths are escaped into a single path segment,
+	// so route on the escaped path.
+	handlers := map[string]http.HandlerFunc{}
+	handlers["/api/v4/projects/group%2Fproject/merge_requests/3"] = func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get(gitlabTokenHeader))
+		assert.NoError(t, json.NewEncoder(w).Encode(GitlabMergeRequest{
+			IID:          3,
+			Title:        "title",
+			SourceBranch: "feature",
+			TargetBranch: "main",
+			DiffRefs:     GitlabDiffRefs{BaseSHA: "base", HeadSHA: "head"},
+		}))
+	}
+	handlers["/api/v4/projects/group%2Fproject/merge_requests/3/changes"] = func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("access_raw_diffs"))
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"changes": []GitlabMergeRequestChange{
+				{OldPath: "a.txt", NewPath: "a.txt", Diff: "@@ -1 +1 @@\n-a\n+b\n"},
+				{OldPath: "new.txt", NewPath: "new.txt", BMode: "100644", NewFile: true, Diff: "@@ -0,0 +1 @@\n+new\n"},
+				{OldPath: "old.txt", NewPath: "old.txt", AMode: "100644", DeletedFile: true, Diff: "@@ -1 +0,0 @@\n-old"},
+			},
+		}))
+	}
+	handlers["/api/v4/projects/group%2Fproject/repository/files/dir%2Fevergreen.yml/raw"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ref") != "base" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("tasks: []"))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}))
+	defer srv.Close()
+	conf := evergreen.GitlabConfig{URL: srv.URL, Token: "token"}
+
+	t.Run("GetMergeRequest", func(t *testing.T) {
+		mr, err := GetGitlabMergeRequest(ctx, conf, "group/project", 3)
+		require.NoError(t, err)
+		assert.Equal(t, "title", mr.Title)
+		assert.Equal(t, "base", mr.DiffRefs.BaseSHA)
+
+		_, err = GetGitlabMergeRequest(ctx, conf, "group/project", 4)
+		assert.Error(t, err)
+	})
+	t.Run("GetMergeRequestDiff", func(t *testing.T) {
+		diff, summaries, err := GetGitlabMergeRequestDiff(ctx, conf, "group/project", 3)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n")
+		assert.Contains(t, diff, "new file mode 100644\n--- /dev/null\n+++ b/new.txt\n")
+		assert.Contains(t, diff, "deleted file mode 100644\n--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-old\n")
+		require.Len(t, summaries, 3)
+		assert.Equal(t, "a.txt", summaries[0].Name)
+		assert.Equal(t, 1, summaries[0].Additions)
+		assert.Equal(t, 1, summaries[0].Deletions)
+	})
+	t.Run("GetFile", func(t *testing.T) {
+		contents, err := GetGitlabFile(ctx, conf, "group/project", "dir/evergreen.yml", "base")
+		require.NoError(t, err)
+		assert.Equal(t, "tasks: []", string(contents))
+
+		_, err = GetGitlabFile(ctx, conf, "group/project", "dir/evergreen.yml", "other")
+		assert.True(t, IsFileNotFound(err))
+	})
+	t.Run("FailsWithoutConfig", func(t *testing.T) {
+		_, err := GetGitlabMergeRequest(ctx, evergreen.GitlabConfig{}, "group/project", 3)
+		assert.Error(t, err)
+	})
+}
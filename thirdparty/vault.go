@@ -0,0 +1,99 @@
+package thirdparty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+const (
+	vaultAPITimeout      = 30 * time.Second
+	vaultTokenHeader     = "X-Vault-Token"
+	vaultNamespaceHeader = "X-Vault-Namespace"
+)
+
+// vaultKVResponse is the response to reading a secret from a KV version 2
+// secrets engine.
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// GetVaultSecret reads the secret at the given path from Vault's KV version 2
+// secrets engine and returns all of its fields.
+func GetVaultSecret(ctx context.Context, conf evergreen.VaultConfig, path string) (map[string]string, error) {
+	if !conf.IsConfigured() {
+		return nil, errors.New("Vault is not configured")
+	}
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, errors.New("secret path cannot be empty")
+	}
+	mountPath := conf.MountPath
+	if mountPath == "" {
+		mountPath = "secret"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, vaultAPITimeout)
+	defer cancel()
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	u := fmt.Sprintf("%s/v1/%s/data/%s", conf.URL, mountPath, strings.Join(segments, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	req.Header.Set(vaultTokenHeader, conf.Token)
+	if conf.Namespace != "" {
+		req.Header.Set(vaultNamespaceHeader, conf.Namespace)
+	}
+
+	client := utility.GetHTTPClient()
+	defer utility.PutHTTPClient(client)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "sending request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.Errorf("secret '%s' not found in Vault", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.Errorf("Vault returned HTTP status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out vaultKVResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, errors.Wrap(err, "decoding response")
+	}
+	fields := make(map[string]string, len(out.Data.Data))
+	for key, val := range out.Data.Data {
+		switch v := val.(type) {
+		case string:
+			fields[key] = v
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "encoding field '%s'", key)
+			}
+			fields[key] = string(b)
+		}
+	}
+
+	return fields, nil
+}
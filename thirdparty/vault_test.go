@@ -0,0 +1,54 @@
+package thirdparty
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetVaultSecret(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/v1/kv/data/team/deploy":
+			assert.Equal(t, "ns", r.Header.Get(vaultNamespaceHeader))
+			_, _ = w.Write([]byte(`{"data": {"data": {"token": "abc", "port": 8080}, "metadata": {"version": 2}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	conf := evergreen.VaultConfig{URL: srv.URL, Token: "token", Namespace: "ns", MountPath: "kv"}
+
+	t.Run("ReturnsFields", func(t *testing.T) {
+		fields, err := GetVaultSecret(ctx, conf, "/team/deploy")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"token": "abc", "port": "8080"}, fields)
+	})
+	t.Run("FailsForMissingSecret", func(t *testing.T) {
+		_, err := GetVaultSecret(ctx, conf, "team/other")
+		assert.Error(t, err)
+	})
+	t.Run("FailsForBadToken", func(t *testing.T) {
+		badConf := conf
+		badConf.Token = "wrong"
+		_, err := GetVaultSecret(ctx, badConf, "team/deploy")
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithoutConfig", func(t *testing.T) {
+		_, err := GetVaultSecret(ctx, evergreen.VaultConfig{}, "team/deploy")
+		assert.Error(t, err)
+	})
+}
@@ -214,7 +214,8 @@ func (t *buildTriggers) makeData(sub *event.Subscription, pastTenseOverride stri
 	if t.data.GithubCheckStatus != "" {
 		data.PastTenseStatus = t.data.GithubCheckStatus
 	}
-	if t.build.Requester == evergreen.GithubPRRequester || t.build.Requester == evergreen.RepotrackerVersionRequester || t.build.Requester == evergreen.GithubMergeRequester {
+	if t.build.Requester == evergreen.GithubPRRequester || t.build.Requester == evergreen.RepotrackerVersionRequester ||
+		t.build.Requester == evergreen.GithubMergeRequester || t.build.Requester == evergreen.GitlabMRRequester {
 		data.githubContext = fmt.Sprintf("evergreen/%s", t.build.BuildVariant)
 		data.githubDescription = t.build.GetPRNotificationDescription(t.tasks)
 	}
@@ -33,6 +33,19 @@ type trigger func(*event.Subscription) (*notification.Notification, error)
 
 type base struct {
 	triggers map[string]trigger
+	// readOnly prevents triggers from writing bookkeeping documents, such as
+	// alert records, while processing subscriptions.
+	readOnly bool
+}
+
+// readOnlyHandler is an event handler that can process subscriptions without
+// writing to the database.
+type readOnlyHandler interface {
+	setReadOnly()
+}
+
+func (b *base) setReadOnly() {
+	b.readOnly = true
 }
 
 func (b *base) Process(sub *event.Subscription) (*notification.Notification, error) {
@@ -85,6 +85,48 @@ func NotificationsFromEvent(ctx context.Context, e *event.EventLogEntry) ([]noti
 	return notifications, catcher.Resolve()
 }
 
+// PreviewNotification runs a single, possibly unsaved, subscription against an
+// event through the same handler used by NotificationsFromEvent, and returns
+// the notification it would create without storing or sending it. The
+// subscription is processed read-only, so no alert records are written. matched
+// reports whether the subscription's selectors match the event; the returned
+// notification is nil if they do not or if the trigger did not fire. authorize
+// is called with the event's attributes before the subscription is processed,
+// and the preview is aborted if it returns an error.
+func PreviewNotification(ctx context.Context, e *event.EventLogEntry, sub *event.Subscription, authorize func(event.Attributes) error) (n *notification.Notification, matched bool, err error) {
+	if e.ResourceType != sub.ResourceType {
+		return nil, false, errors.Errorf("subscription resource type '%s' does not match event resource type '%s'", sub.ResourceType, e.ResourceType)
+	}
+	h := registry.eventHandler(e.ResourceType, e.EventType)
+	if h == nil {
+		return nil, false, errors.Errorf("unknown event resource type '%s' or event type '%s'", e.ResourceType, e.EventType)
+	}
+	if !h.ValidateTrigger(sub.Trigger) {
+		return nil, false, errors.Errorf("trigger '%s' is not valid for event type '%s'", sub.Trigger, e.EventType)
+	}
+	if ro, ok := h.(readOnlyHandler); ok {
+		ro.setReadOnly()
+	}
+
+	if err = h.Fetch(ctx, e); err != nil {
+		return nil, false, errors.Wrapf(err, "fetching data for event '%s' (resource type: '%s', event type: '%s')", e.ID, e.ResourceType, e.EventType)
+	}
+	attributes := h.Attributes()
+	if err = authorize(attributes); err != nil {
+		return nil, false, err
+	}
+	if !sub.MatchesAttributes(attributes) {
+		return nil, false, nil
+	}
+
+	n, err = h.Process(sub)
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "processing subscription for event '%s'", e.ID)
+	}
+
+	return n, true, nil
+}
+
 type projectProcessor func(context.Context, ProcessorArgs) (*model.Version, error)
 
 type ProcessorArgs struct {
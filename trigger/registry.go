@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/message"
 )
@@ -71,3 +72,14 @@ func ValidateTrigger(resourceType, triggerName string) bool {
 
 	return false
 }
+
+// ValidateTriggerData checks the subscription trigger data that the triggers
+// interpret, which the event package can't check because it doesn't know
+// about the values the triggers accept.
+func ValidateTriggerData(data map[string]string) error {
+	classification := data[keyFailureClassification]
+	if classification == "" || classification == "any" {
+		return nil
+	}
+	return task.ValidateFailureClassifications([]string{classification})
+}
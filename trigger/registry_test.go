@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,3 +20,10 @@ func TestRegistryItemsAreSane(t *testing.T) {
 		assert.NotNil(handler, "handler factory for '%s' returned nil (%s)", k, runtime.FuncForPC(reflect.ValueOf(v).Pointer()).Name())
 	}
 }
+
+func TestValidateTriggerData(t *testing.T) {
+	assert.NoError(t, ValidateTriggerData(nil))
+	assert.NoError(t, ValidateTriggerData(map[string]string{keyFailureClassification: "any"}))
+	assert.NoError(t, ValidateTriggerData(map[string]string{keyFailureClassification: task.FailureClassificationDiskFull}))
+	assert.Error(t, ValidateTriggerData(map[string]string{keyFailureClassification: "out-of-disk"}))
+}
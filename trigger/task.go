@@ -40,6 +40,7 @@ const (
 	triggerTaskRegressionByTest              = "regression-by-test"
 	triggerBuildBreak                        = "build-break"
 	keyFailureType                           = "failure-type"
+	keyFailureClassification                 = "failure-classification"
 	triggerTaskFailedOrBlocked               = "task-failed-or-blocked"
 )
 
@@ -383,6 +384,10 @@ func (t *taskTriggers) generateWithAlertRecord(sub *event.Subscription, alertTyp
 		return nil, nil
 	}
 
+	if t.readOnly {
+		return n, nil
+	}
+
 	newRec := newAlertRecord(sub.ID, t.task, alertType)
 	grip.Error(message.WrapError(newRec.Insert(), message.Fields{
 		"source":  "alert-record",
@@ -432,6 +437,9 @@ func (t *taskTriggers) taskFailure(sub *event.Subscription) (*notification.Notif
 	if !matchingFailureType(sub.TriggerData[keyFailureType], t.task.Details.Type) {
 		return nil, nil
 	}
+	if !matchingFailureType(sub.TriggerData[keyFailureClassification], t.task.FailureClassification) {
+		return nil, nil
+	}
 
 	if !isValidFailedTaskStatus(t.data.Status) {
 		return nil, nil
@@ -555,6 +563,9 @@ func (t *taskTriggers) taskRegression(sub *event.Subscription) (*notification.No
 	if err != nil {
 		return nil, err
 	}
+	if t.readOnly {
+		return n, nil
+	}
 	return n, errors.Wrap(alert.Insert(), "processing regression trigger")
 }
 
@@ -567,6 +578,9 @@ func isTaskRegression(sub *event.Subscription, t *task.Task) (bool, *alertrecord
 	if !matchingFailureType(sub.TriggerData[keyFailureType], t.Details.Type) {
 		return false, nil, nil
 	}
+	if !matchingFailureType(sub.TriggerData[keyFailureClassification], t.FailureClassification) {
+		return false, nil, nil
+	}
 
 	query := db.Query(task.ByBeforeRevisionWithStatusesAndRequesters(t.RevisionOrderNumber,
 		evergreen.TaskCompletedStatuses, t.BuildVariant, t.DisplayName, t.Project, evergreen.SystemVersionRequesterTypes)).Sort([]string{"-" + task.RevisionOrderNumberKey})
@@ -818,6 +832,9 @@ func (t *taskTriggers) taskRegressionByTest(sub *event.Subscription) (*notificat
 	if !matchingFailureType(sub.TriggerData[keyFailureType], t.task.Details.Type) {
 		return nil, nil
 	}
+	if !matchingFailureType(sub.TriggerData[keyFailureClassification], t.task.FailureClassification) {
+		return nil, nil
+	}
 	// if no tests, alert only if it's a regression in task status
 	if len(t.task.LocalTestResults) == 0 {
 		return t.taskRegression(sub)
@@ -869,6 +886,10 @@ func (t *taskTriggers) taskRegressionByTest(sub *event.Subscription) (*notificat
 			if previousCompleteTask != nil {
 				orderNumber = previousCompleteTask.RevisionOrderNumber
 			}
+			if t.readOnly {
+				testsToAlert = append(testsToAlert, test)
+				continue
+			}
 			if err = alertrecord.InsertNewTaskRegressionByTestRecord(sub.ID, t.task.Id, test.GetDisplayTestName(), t.task.DisplayName, t.task.BuildVariant, t.task.Project, orderNumber); err != nil {
 				catcher.Add(err)
 				continue
@@ -0,0 +1,269 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/mongodb/grip/sometimes"
+	"github.com/pkg/errors"
+)
+
+const (
+	artifactRetentionJobName = "artifact-retention"
+
+	// artifactRetentionBatchSize is the maximum number of entries that a
+	// single job expires for each kind of requester, so that a project
+	// enabling retention for the first time doesn't produce one very long
+	// job.
+	artifactRetentionBatchSize = 500
+	// artifactRetentionRetryInterval is how long to wait before checking an
+	// entry again if some of its files couldn't be expired.
+	artifactRetentionRetryInterval = 24 * time.Hour
+)
+
+func init() {
+	registry.AddJobType(artifactRetentionJobName, func() amboy.Job { return makeArtifactRetentionJob() })
+}
+
+type artifactRetentionJob struct {
+	job.Base  `bson:"job_base" json:"job_base" yaml:"job_base"`
+	ProjectID string `bson:"project_id" json:"project_id" yaml:"project_id"`
+
+	env evergreen.Environment
+	// makeBucket returns the configured artifact bucket in the given region.
+	makeBucket func(ctx context.Context, conf evergreen.ArtifactBucket, region string) (pail.Bucket, error)
+}
+
+func makeArtifactRetentionJob() *artifactRetentionJob {
+	j := &artifactRetentionJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    artifactRetentionJobName,
+				Version: 0,
+			},
+		},
+	}
+	return j
+}
+
+// NewArtifactRetentionJob deletes the files that the project's tasks uploaded
+// to S3 once they're older than the project's artifact retention settings
+// allow, and marks them expired.
+func NewArtifactRetentionJob(projectID, id string) amboy.Job {
+	j := makeArtifactRetentionJob()
+	j.ProjectID = projectID
+	j.SetID(fmt.Sprintf("%s.%s.%s", artifactRetentionJobName, projectID, id))
+	return j
+}
+
+func (j *artifactRetentionJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+
+	if j.env == nil {
+		j.env = evergreen.GetEnvironment()
+	}
+	if j.makeBucket == nil {
+		httpClient := utility.GetHTTPClient()
+		defer utility.PutHTTPClient(httpClient)
+		j.makeBucket = func(ctx context.Context, conf evergreen.ArtifactBucket, region string) (pail.Bucket, error) {
+			return makeArtifactBucket(ctx, httpClient, conf, region)
+		}
+	}
+
+	flags, err := evergreen.GetServiceFlags(ctx)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "getting service flags"))
+		return
+	}
+	if flags.BackgroundCleanupDisabled {
+		grip.InfoWhen(sometimes.Percent(evergreen.DegradedLoggingPercent), message.Fields{
+			"message": "background cleanup is disabled",
+			"impact":  "skipping artifact retention",
+			"mode":    "degraded",
+			"project": j.ProjectID,
+			"job":     j.ID(),
+		})
+		return
+	}
+
+	pRef, err := model.FindMergedProjectRef(j.ProjectID, "", false)
+	if err != nil {
+		j.AddError(errors.Wrapf(err, "finding project '%s'", j.ProjectID))
+		return
+	}
+	if pRef == nil {
+		j.AddError(errors.Errorf("project '%s' not found", j.ProjectID))
+		return
+	}
+	if !pRef.ArtifactRetention.IsEnabled() {
+		return
+	}
+	bucketConf := j.env.Settings().Buckets
+	if len(bucketConf.ArtifactBuckets) == 0 {
+		return
+	}
+
+	keepVersions, err := model.FindGitTagVersionIDs(pRef.Id)
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+
+	if pRef.ArtifactRetention.MainlineDays > 0 {
+		j.AddError(errors.Wrap(j.expire(ctx, bucketConf, pRef.Id, false, pRef.ArtifactRetention.MainlineDays, keepVersions), "expiring mainline artifacts"))
+	}
+	if pRef.ArtifactRetention.PatchDays > 0 {
+		j.AddError(errors.Wrap(j.expire(ctx, bucketConf, pRef.Id, true, pRef.ArtifactRetention.PatchDays, keepVersions), "expiring patch artifacts"))
+	}
+}
+
+// expire deletes and marks expired the files in the project's entries that
+// are older than the given number of days. Only files in the configured
+// artifact buckets are deleted, using the credentials that are configured for
+// the bucket. Entries whose files can't all be expired aren't checked again
+// until the retry interval has passed, so that they don't fill every batch.
+func (j *artifactRetentionJob) expire(ctx context.Context, bucketConf evergreen.BucketConfig, projectID string, patches bool, days int, keepVersions []string) error {
+	now := time.Now()
+	cutoff := now.Add(-time.Duration(days) * 24 * time.Hour)
+	entries, err := artifact.FindAll(artifact.ByProjectCreatedBefore(projectID, patches, cutoff, now, keepVersions, bucketConf.ArtifactBucketNames()).Limit(artifactRetentionBatchSize))
+	if err != nil {
+		return errors.Wrap(err, "finding entries to expire")
+	}
+
+	isExpiring := func(e artifact.Entry) bool {
+		return e.Project == projectID &&
+			e.CreateTime.Before(cutoff) &&
+			evergreen.IsPatchRequester(e.Requester) == patches &&
+			!utility.StringSliceContains(keepVersions, e.VersionId)
+	}
+
+	buckets := map[string]pail.Bucket{}
+	catcher := grip.NewBasicCatcher()
+	numExpired := 0
+	numSkipped := 0
+	for _, entry := range entries {
+		retry := false
+		for _, file := range entry.Files {
+			if ctx.Err() != nil {
+				catcher.Add(ctx.Err())
+				return catcher.Resolve()
+			}
+			if file.Expired || file.FileKey == "" {
+				continue
+			}
+			conf := bucketConf.GetArtifactBucket(file.Bucket)
+			if conf == nil {
+				continue
+			}
+
+			// Keys like "latest/..." can be shared across versions, so the
+			// file is only deleted once every entry that refers to it is
+			// also expiring.
+			referencing, err := artifact.FindAll(artifact.ByUnexpiredS3Object(file.Bucket, file.FileKey))
+			if err != nil {
+				catcher.Wrapf(err, "finding entries that refer to file '%s' in bucket '%s'", file.FileKey, file.Bucket)
+				retry = true
+				continue
+			}
+			shared := false
+			for _, other := range referencing {
+				if !isExpiring(other) {
+					shared = true
+					break
+				}
+			}
+			if shared {
+				numSkipped++
+				retry = true
+				continue
+			}
+
+			region := file.Region
+			if region == "" {
+				region = conf.Region
+			}
+			cacheKey := fmt.Sprintf("%s/%s", file.Bucket, region)
+			bucket, ok := buckets[cacheKey]
+			if !ok {
+				bucket, err = j.makeBucket(ctx, *conf, region)
+				if err != nil {
+					catcher.Wrapf(err, "setting up bucket '%s'", file.Bucket)
+					retry = true
+					continue
+				}
+				buckets[cacheKey] = bucket
+			}
+
+			if err := bucket.Remove(ctx, file.FileKey); err != nil && !pail.IsKeyNotFoundError(err) {
+				catcher.Wrapf(err, "deleting file '%s' in bucket '%s' for task '%s'", file.FileKey, file.Bucket, entry.TaskId)
+				retry = true
+				continue
+			}
+			if err := artifact.MarkFileExpired(entry.TaskId, entry.Execution, file.Name, file.Link); err != nil {
+				catcher.Wrapf(err, "marking file '%s' for task '%s' expired", file.Name, entry.TaskId)
+				retry = true
+				continue
+			}
+			numExpired++
+		}
+
+		if retry {
+			catcher.Wrapf(artifact.SetRetentionRetryAt(entry.TaskId, entry.Execution, now.Add(artifactRetentionRetryInterval)), "setting retry time for task '%s'", entry.TaskId)
+		}
+	}
+
+	grip.Info(message.Fields{
+		"message":     "expired artifacts",
+		"project":     projectID,
+		"patches":     patches,
+		"cutoff":      cutoff,
+		"num_entries": len(entries),
+		"num_expired": numExpired,
+		"num_shared":  numSkipped,
+		"num_errors":  catcher.Len(),
+		"job":         j.ID(),
+	})
+
+	return catcher.Resolve()
+}
+
+// makeArtifactBucket returns the configured artifact bucket using its
+// configured credentials. If the region isn't known, it's looked up.
+func makeArtifactBucket(ctx context.Context, httpClient *http.Client, conf evergreen.ArtifactBucket, region string) (pail.Bucket, error) {
+	name := conf.Name
+	creds := pail.CreateAWSCredentials(conf.Key, conf.Secret, "")
+	if region == "" {
+		sess, err := session.NewSession(&aws.Config{
+			Credentials: creds,
+			HTTPClient:  httpClient,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating AWS session")
+		}
+		region, err = s3manager.GetBucketRegion(ctx, sess, name, endpoints.UsEast1RegionID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting region of bucket '%s'", name)
+		}
+	}
+	return pail.NewS3BucketWithHTTPClient(httpClient, pail.S3Options{
+		Name:        name,
+		Region:      region,
+		Credentials: creds,
+	})
+}
@@ -0,0 +1,202 @@
+package units
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestArtifactRetentionJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket){
+		"ExpiresOldMainlineAndPatchFiles": func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket) {
+			j.Run(ctx)
+			require.NoError(t, j.Error())
+
+			checkArtifactExpired(t, "old_mainline", true)
+			checkArtifactExpired(t, "old_patch", true)
+			checkArtifactExpired(t, "new_mainline", false)
+			checkArtifactExpired(t, "tagged", false)
+
+			checkArtifactInBucket(ctx, t, bucket, "old_mainline", false)
+			checkArtifactInBucket(ctx, t, bucket, "old_patch", false)
+			checkArtifactInBucket(ctx, t, bucket, "new_mainline", true)
+			checkArtifactInBucket(ctx, t, bucket, "tagged", true)
+		},
+		"KeepsPatchFilesWithoutPatchRetention": func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket) {
+			require.NoError(t, db.Update(model.ProjectRefCollection, bson.M{model.ProjectRefIdKey: "project"}, bson.M{
+				"$set": bson.M{model.ProjectRefArtifactRetentionKey: model.ArtifactRetentionSettings{MainlineDays: 7}},
+			}))
+
+			j.Run(ctx)
+			require.NoError(t, j.Error())
+
+			checkArtifactExpired(t, "old_mainline", true)
+			checkArtifactExpired(t, "old_patch", false)
+
+			checkArtifactInBucket(ctx, t, bucket, "old_patch", true)
+		},
+		"SkipsFilesInUnconfiguredBuckets": func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket) {
+			require.NoError(t, db.Update(artifact.Collection, bson.M{artifact.TaskIdKey: "old_mainline"}, bson.M{
+				"$set": bson.M{bsonutil.GetDottedKeyName(artifact.FilesKey, "$[]", artifact.BucketKey): "other_bucket"},
+			}))
+
+			j.Run(ctx)
+			require.NoError(t, j.Error())
+
+			checkArtifactExpired(t, "old_mainline", false)
+			checkArtifactInBucket(ctx, t, bucket, "old_mainline", true)
+			checkArtifactExpired(t, "old_patch", true)
+		},
+		"KeepsFilesSharedWithUnexpiredEntries": func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket) {
+			shared := artifact.Entry{
+				TaskId:     "new_shared",
+				Project:    "project",
+				Requester:  evergreen.RepotrackerVersionRequester,
+				VersionId:  "v4",
+				CreateTime: time.Now(),
+				Files:      []artifact.File{{Name: "latest", Link: "https://s3.amazonaws.com/bucket/old_mainline", Bucket: "bucket", FileKey: "old_mainline"}},
+			}
+			require.NoError(t, shared.Upsert())
+
+			j.Run(ctx)
+			require.NoError(t, j.Error())
+
+			checkArtifactExpired(t, "old_mainline", false)
+			checkArtifactInBucket(ctx, t, bucket, "old_mainline", true)
+			entry, err := artifact.FindOne(artifact.ByTaskId("old_mainline"))
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.True(t, entry.RetentionRetryAt.After(time.Now()), "entry should be retried later")
+		},
+		"RetriesFailedEntriesLater": func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket) {
+			j.makeBucket = func(context.Context, evergreen.ArtifactBucket, string) (pail.Bucket, error) {
+				return nil, errors.New("access denied")
+			}
+
+			j.Run(ctx)
+			assert.Error(t, j.Error())
+			checkArtifactExpired(t, "old_mainline", false)
+
+			entries, err := artifact.FindAll(artifact.ByProjectCreatedBefore("project", false, time.Now().Add(-7*24*time.Hour), time.Now(), []string{"tagged_version"}, []string{"bucket"}))
+			require.NoError(t, err)
+			assert.Empty(t, entries, "failed entries should not be found again until they're retried")
+		},
+		"NoopsWithoutArtifactBuckets": func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket) {
+			j.env.Settings().Buckets.ArtifactBuckets = nil
+
+			j.Run(ctx)
+			require.NoError(t, j.Error())
+
+			checkArtifactExpired(t, "old_mainline", false)
+			checkArtifactInBucket(ctx, t, bucket, "old_mainline", true)
+		},
+		"NoopsWithoutRetention": func(ctx context.Context, t *testing.T, j *artifactRetentionJob, bucket pail.Bucket) {
+			require.NoError(t, db.Update(model.ProjectRefCollection, bson.M{model.ProjectRefIdKey: "project"}, bson.M{
+				"$unset": bson.M{model.ProjectRefArtifactRetentionKey: 1},
+			}))
+
+			j.Run(ctx)
+			require.NoError(t, j.Error())
+
+			checkArtifactExpired(t, "old_mainline", false)
+			checkArtifactExpired(t, "old_patch", false)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			require.NoError(t, db.ClearCollections(model.ProjectRefCollection, model.VersionCollection, artifact.Collection))
+			defer func() {
+				assert.NoError(t, db.ClearCollections(model.ProjectRefCollection, model.VersionCollection, artifact.Collection))
+			}()
+
+			env := evergreen.GetEnvironment()
+			oldArtifactBuckets := env.Settings().Buckets.ArtifactBuckets
+			env.Settings().Buckets.ArtifactBuckets = []evergreen.ArtifactBucket{{Name: "bucket", Key: "aws_key", Secret: "aws_secret"}}
+			defer func() {
+				env.Settings().Buckets.ArtifactBuckets = oldArtifactBuckets
+			}()
+
+			pRef := model.ProjectRef{
+				Id: "project",
+				ArtifactRetention: model.ArtifactRetentionSettings{
+					MainlineDays: 7,
+					PatchDays:    1,
+				},
+			}
+			require.NoError(t, pRef.Insert())
+			tagged := model.Version{
+				Id:         "tagged_version",
+				Identifier: "project",
+				Requester:  evergreen.RepotrackerVersionRequester,
+				GitTags:    []model.GitTag{{Tag: "v1.0.0"}},
+			}
+			require.NoError(t, tagged.Insert())
+
+			bucket, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+			require.NoError(t, err)
+
+			now := time.Now()
+			for _, e := range []artifact.Entry{
+				{TaskId: "old_mainline", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v1", CreateTime: now.Add(-30 * 24 * time.Hour)},
+				{TaskId: "old_patch", Requester: evergreen.PatchVersionRequester, VersionId: "v2", CreateTime: now.Add(-2 * 24 * time.Hour)},
+				{TaskId: "new_mainline", Requester: evergreen.RepotrackerVersionRequester, VersionId: "v3", CreateTime: now},
+				{TaskId: "tagged", Requester: evergreen.RepotrackerVersionRequester, VersionId: tagged.Id, CreateTime: now.Add(-30 * 24 * time.Hour)},
+			} {
+				e.Project = "project"
+				e.Files = []artifact.File{{
+					Name:    e.TaskId,
+					Link:    "https://s3.amazonaws.com/bucket/" + e.TaskId,
+					Bucket:  "bucket",
+					FileKey: e.TaskId,
+					Region:  "us-east-1",
+				}}
+				require.NoError(t, e.Upsert())
+				require.NoError(t, bucket.Put(ctx, e.TaskId, strings.NewReader("artifact")))
+			}
+
+			j := makeArtifactRetentionJob()
+			j.ProjectID = "project"
+			j.env = env
+			j.makeBucket = func(_ context.Context, conf evergreen.ArtifactBucket, region string) (pail.Bucket, error) {
+				assert.Equal(t, "bucket", conf.Name)
+				assert.Equal(t, "aws_key", conf.Key)
+				assert.Equal(t, "us-east-1", region)
+				return bucket, nil
+			}
+
+			tCase(ctx, t, j, bucket)
+		})
+	}
+}
+
+func checkArtifactExpired(t *testing.T, taskID string, expired bool) {
+	entry, err := artifact.FindOne(artifact.ByTaskId(taskID))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	require.Len(t, entry.Files, 1)
+	assert.Equal(t, expired, entry.Files[0].Expired)
+}
+
+func checkArtifactInBucket(ctx context.Context, t *testing.T, bucket pail.Bucket, key string, exists bool) {
+	r, err := bucket.Get(ctx, key)
+	if !exists {
+		assert.Error(t, err)
+		return
+	}
+	require.NoError(t, err)
+	assert.NoError(t, r.Close())
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+package units
+
+import (
+	"encoding/json"
+	"log/syslog"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/pkg/errors"
+)
+
+// auditLogSyslogSink sends each audit log entry to syslog as a single JSON
+// message.
+type auditLogSyslogSink struct {
+	writer *syslog.Writer
+}
+
+func newAuditLogSyslogSink(conf evergreen.AuditLogConfig) (auditLogSink, error) {
+	w, err := syslog.Dial(conf.SyslogNetwork, conf.SyslogAddress, syslog.LOG_INFO|syslog.LOG_AUTH, conf.SyslogTag)
+	if err != nil {
+		return nil, errors.Wrap(err, "connecting to syslog")
+	}
+	return &auditLogSyslogSink{writer: w}, nil
+}
+
+func (s *auditLogSyslogSink) send(entries []model.AuditLogEntry) error {
+	for _, entry := range entries {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return errors.Wrapf(err, "marshalling audit log entry '%s'", entry.ID)
+		}
+		if err := s.writer.Info(string(b)); err != nil {
+			return errors.Wrapf(err, "writing audit log entry '%s' to syslog", entry.ID)
+		}
+	}
+	return nil
+}
+
+func (s *auditLogSyslogSink) close() error { return s.writer.Close() }
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package units
+
+import (
+	"github.com/evergreen-ci/evergreen"
+	"github.com/pkg/errors"
+)
+
+func newAuditLogSyslogSink(conf evergreen.AuditLogConfig) (auditLogSink, error) {
+	return nil, errors.New("the syslog audit log sink is only supported on Linux")
+}
@@ -0,0 +1,148 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	auditLogStreamJobName = "audit-log-stream"
+
+	// auditLogStreamDelay is how long the job waits before streaming an
+	// event, so that events logged concurrently with the same timestamp are
+	// all streamed together.
+	auditLogStreamDelay = time.Minute
+	// auditLogStreamBatchSize is the maximum number of audit log entries
+	// streamed in one job.
+	auditLogStreamBatchSize = 1000
+)
+
+func init() {
+	registry.AddJobType(auditLogStreamJobName, func() amboy.Job { return makeAuditLogStreamJob() })
+}
+
+type auditLogStreamJob struct {
+	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
+
+	env evergreen.Environment
+}
+
+func makeAuditLogStreamJob() *auditLogStreamJob {
+	j := &auditLogStreamJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    auditLogStreamJobName,
+				Version: 0,
+			},
+		},
+	}
+	return j
+}
+
+// NewAuditLogStreamJob streams audit log entries that have not yet been
+// streamed to the configured audit log sink.
+func NewAuditLogStreamJob(id string) amboy.Job {
+	j := makeAuditLogStreamJob()
+	j.SetID(fmt.Sprintf("%s.%s", auditLogStreamJobName, id))
+	// Only one job can stream at a time so that entries are sent to the sink
+	// exactly once and in order.
+	j.SetScopes([]string{auditLogStreamJobName})
+	j.SetEnqueueAllScopes(true)
+	return j
+}
+
+func (j *auditLogStreamJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = evergreen.GetEnvironment()
+	}
+
+	conf := j.env.Settings().AuditLog
+	if conf.SinkType == "" {
+		return
+	}
+
+	state, err := model.GetAuditLogStreamState()
+	if err != nil {
+		j.AddError(errors.Wrap(err, "getting audit log stream state"))
+		return
+	}
+	entries, err := state.FindUnstreamedAuditLog(time.Now().Add(-auditLogStreamDelay), auditLogStreamBatchSize)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "finding unstreamed audit log entries"))
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	sink, err := newAuditLogSink(conf)
+	if err != nil {
+		j.AddError(errors.Wrapf(err, "creating audit log sink '%s'", conf.SinkType))
+		return
+	}
+	sendErr := sink.send(entries)
+	j.AddError(errors.Wrap(sink.close(), "closing audit log sink"))
+	if sendErr != nil {
+		j.AddError(errors.Wrapf(sendErr, "sending audit log entries to sink '%s'", conf.SinkType))
+		return
+	}
+
+	if err := state.Advance(entries[len(entries)-1]); err != nil {
+		j.AddError(errors.Wrap(err, "advancing audit log stream state"))
+		return
+	}
+
+	grip.Info(message.Fields{
+		"message": "streamed audit log entries",
+		"job":     j.ID(),
+		"sink":    conf.SinkType,
+		"num":     len(entries),
+	})
+}
+
+// auditLogSink is a destination that audit log entries are streamed to.
+type auditLogSink interface {
+	send(entries []model.AuditLogEntry) error
+	close() error
+}
+
+func newAuditLogSink(conf evergreen.AuditLogConfig) (auditLogSink, error) {
+	switch conf.SinkType {
+	case evergreen.AuditLogSinkFile:
+		f, err := os.OpenFile(conf.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, errors.Wrapf(err, "opening audit log file '%s'", conf.FilePath)
+		}
+		return &auditLogFileSink{file: f}, nil
+	case evergreen.AuditLogSinkSyslog:
+		return newAuditLogSyslogSink(conf)
+	default:
+		return nil, errors.Errorf("unrecognized audit log sink type '%s'", conf.SinkType)
+	}
+}
+
+// auditLogFileSink appends audit log entries to a local file as JSON lines.
+type auditLogFileSink struct {
+	file *os.File
+}
+
+func (s *auditLogFileSink) send(entries []model.AuditLogEntry) error {
+	if err := model.WriteAuditLogJSONL(s.file, entries); err != nil {
+		return err
+	}
+	return errors.Wrap(s.file.Sync(), "syncing audit log file")
+}
+
+func (s *auditLogFileSink) close() error { return s.file.Close() }
@@ -0,0 +1,57 @@
+package units
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogFileSink(t *testing.T) {
+	conf := evergreen.AuditLogConfig{
+		SinkType: evergreen.AuditLogSinkFile,
+		FilePath: filepath.Join(t.TempDir(), "audit.log"),
+	}
+
+	first := []model.AuditLogEntry{{ID: "e1"}, {ID: "e2"}}
+	require.NoError(t, model.ChainAuditLogEntries(first, ""))
+	second := []model.AuditLogEntry{{ID: "e3"}}
+	require.NoError(t, model.ChainAuditLogEntries(second, first[1].Hash))
+
+	for _, entries := range [][]model.AuditLogEntry{first, second} {
+		sink, err := newAuditLogSink(conf)
+		require.NoError(t, err)
+		require.NoError(t, sink.send(entries))
+		require.NoError(t, sink.close())
+	}
+
+	f, err := os.Open(conf.FilePath)
+	require.NoError(t, err)
+	defer f.Close()
+	num, err := model.VerifyAuditLogChain(f)
+	assert.NoError(t, err, "entries streamed in separate batches should form one chain")
+	assert.Equal(t, 3, num)
+
+	_, err = newAuditLogSink(evergreen.AuditLogConfig{SinkType: "nonexistent"})
+	assert.Error(t, err)
+}
+
+func TestAuditLogFileSinkAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0600))
+
+	sink, err := newAuditLogSink(evergreen.AuditLogConfig{SinkType: evergreen.AuditLogSinkFile, FilePath: path})
+	require.NoError(t, err)
+	require.NoError(t, sink.send([]model.AuditLogEntry{{ID: "e1"}}))
+	require.NoError(t, sink.close())
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(contents), "existing\n"))
+	assert.Contains(t, string(contents), `"id":"e1"`)
+}
@@ -137,19 +137,28 @@ func (j *commitQueueJob) Run(ctx context.Context) {
 		return
 	}
 	j.TryUnstick(ctx, cq, projectRef, githubToken)
-
-	if cq.Processing() {
-		return
+	if projectRef.CommitQueue.IsBatchedMerge() {
+		// Release items whose batch was dequeued so they're tested again.
+		if err = cq.ReleaseBatch(""); err != nil {
+			j.AddError(errors.Wrapf(err, "releasing orphaned batched items in commit queue '%s'", j.QueueID))
+			return
+		}
 	}
 
-	batchSize := conf.CommitQueue.BatchSize
-	if batchSize < 1 {
-		batchSize = 1
-	}
-	nextItems := cq.NextUnprocessed(batchSize)
+	nextItems, batchSize := nextCommitQueueItems(cq, projectRef.CommitQueue, conf.CommitQueue.BatchSize)
 	if len(nextItems) == 0 {
 		return
 	}
+	if projectRef.CommitQueue.IsBatchedMerge() && len(nextItems) > 1 {
+		// The last item's merge patch tests and merges the whole batch, so
+		// it's the only one that gets a version.
+		tail := nextItems[len(nextItems)-1]
+		if err = cq.SetBatch(nextItems[:len(nextItems)-1], tail.Issue); err != nil {
+			j.AddError(errors.Wrapf(err, "batching commit queue items into item '%s'", tail.Issue))
+			return
+		}
+		nextItems = nextItems[len(nextItems)-1:]
+	}
 	beginBatchProcessingTime := time.Now()
 	grip.Info(message.Fields{
 		"source":       "commit queue",
@@ -158,6 +167,9 @@ func (j *commitQueueJob) Run(ctx context.Context) {
 		"project_id":   cq.ProjectID,
 		"queue_length": len(cq.Queue),
 		"batch_size":   batchSize,
+		"merge_train":  projectRef.CommitQueue.IsMergeTrain(),
+		"batched":      projectRef.CommitQueue.IsBatchedMerge(),
+		"in_progress":  cq.NumProcessing(),
 		"message":      "starting processing batch of commit queue items",
 	})
 	for _, nextItem := range nextItems {
@@ -212,6 +224,33 @@ func (j *commitQueueJob) Run(ctx context.Context) {
 	j.AddError(j.addMergeTaskDependencies(*cq))
 }
 
+// nextCommitQueueItems returns the items that should start being tested and
+// the batch size that they were chosen with. Without a merge train, a batch of
+// items is tested only once the previous batch is done. In a merge train,
+// items are added to the train as soon as there's room rather than waiting
+// for the items already being tested to finish. Each item is tested on top of
+// the items ahead of it and its merge task depends on theirs, so an item
+// merges once everything ahead of it has passed. When an item fails, it's
+// dequeued and the items behind it are restarted without it. With batched
+// merges, the items are combined into a single merge patch, which is bisected
+// if it fails.
+func nextCommitQueueItems(cq *commitqueue.CommitQueue, params model.CommitQueueParams, globalBatchSize int) ([]commitqueue.CommitQueueItem, int) {
+	if params.IsBatchedMerge() {
+		return cq.NextBatch(params.BatchedMergeSize), params.BatchedMergeSize
+	}
+
+	batchSize := globalBatchSize
+	if params.IsMergeTrain() {
+		batchSize = params.MergeTrainSize
+	} else if cq.Processing() {
+		return nil, batchSize
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return cq.NextUnprocessed(batchSize), batchSize
+}
+
 func (j *commitQueueJob) addMergeTaskDependencies(cq commitqueue.CommitQueue) error {
 	var prevMergeTask string
 	for i, currentItem := range cq.Queue {
@@ -255,6 +294,15 @@ func (j *commitQueueJob) TryUnstick(ctx context.Context, cq *commitqueue.CommitQ
 	if !valid {
 		return
 	}
+	if nextItem.BatchedInto != "" {
+		// The item at the front is tested by the merge patch of the item
+		// that it's batched into.
+		idx := cq.FindItem(nextItem.BatchedInto)
+		if idx < 0 {
+			return
+		}
+		nextItem = cq.Queue[idx]
+	}
 
 	if nextItem.Version == "" {
 		return
@@ -446,3 +446,88 @@ func TestAddMergeTaskDependencies(t *testing.T) {
 	assert.Len(t, dbTask3.DependsOn, 1)
 	assert.Equal(t, dbTask2.Id, dbTask3.DependsOn[0].TaskId)
 }
+
+func TestNextCommitQueueItems(t *testing.T) {
+	processing := &commitqueue.CommitQueue{
+		ProjectID: "mci",
+		Queue: []commitqueue.CommitQueueItem{
+			{Issue: "1", Version: "v1"},
+			{Issue: "2", Version: "v2"},
+			{Issue: "3"},
+			{Issue: "4"},
+			{Issue: "5"},
+		},
+	}
+	idle := &commitqueue.CommitQueue{
+		ProjectID: "mci",
+		Queue: []commitqueue.CommitQueueItem{
+			{Issue: "1"},
+			{Issue: "2"},
+			{Issue: "3"},
+		},
+	}
+	issues := func(items []commitqueue.CommitQueueItem) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Issue)
+		}
+		return out
+	}
+
+	t.Run("WaitsForBatchWithoutMergeTrain", func(t *testing.T) {
+		items, _ := nextCommitQueueItems(processing, model.CommitQueueParams{}, 4)
+		assert.Empty(t, items)
+	})
+	t.Run("UsesGlobalBatchSizeWithoutMergeTrain", func(t *testing.T) {
+		items, batchSize := nextCommitQueueItems(idle, model.CommitQueueParams{}, 2)
+		assert.Equal(t, 2, batchSize)
+		assert.Equal(t, []string{"1", "2"}, issues(items))
+	})
+	t.Run("BatchesCLIItems", func(t *testing.T) {
+		cq := &commitqueue.CommitQueue{
+			ProjectID: "mci",
+			Queue: []commitqueue.CommitQueueItem{
+				{Issue: "1", Source: commitqueue.SourceDiff},
+				{Issue: "2", Source: commitqueue.SourceDiff},
+				{Issue: "3", Source: commitqueue.SourceDiff},
+				{Issue: "4", Source: commitqueue.SourcePullRequest},
+			},
+		}
+		items, batchSize := nextCommitQueueItems(cq, model.CommitQueueParams{BatchedMergeSize: 5}, 1)
+		assert.Equal(t, 5, batchSize)
+		assert.Equal(t, []string{"1", "2", "3"}, issues(items))
+
+		items, _ = nextCommitQueueItems(cq, model.CommitQueueParams{BatchedMergeSize: 2}, 1)
+		assert.Equal(t, []string{"1", "2"}, issues(items))
+	})
+	t.Run("WaitsForBatchedMerge", func(t *testing.T) {
+		cq := &commitqueue.CommitQueue{
+			ProjectID: "mci",
+			Queue: []commitqueue.CommitQueueItem{
+				{Issue: "1", Source: commitqueue.SourceDiff, BatchedInto: "2"},
+				{Issue: "2", Source: commitqueue.SourceDiff, Version: "2"},
+				{Issue: "3", Source: commitqueue.SourceDiff},
+			},
+		}
+		items, _ := nextCommitQueueItems(cq, model.CommitQueueParams{BatchedMergeSize: 5}, 1)
+		assert.Empty(t, items)
+	})
+	t.Run("DefaultsToSerial", func(t *testing.T) {
+		items, batchSize := nextCommitQueueItems(idle, model.CommitQueueParams{}, 0)
+		assert.Equal(t, 1, batchSize)
+		assert.Equal(t, []string{"1"}, issues(items))
+	})
+	t.Run("MergeTrainFillsRemainingSpots", func(t *testing.T) {
+		items, batchSize := nextCommitQueueItems(processing, model.CommitQueueParams{MergeTrainSize: 4}, 1)
+		assert.Equal(t, 4, batchSize)
+		assert.Equal(t, []string{"3", "4"}, issues(items))
+	})
+	t.Run("MergeTrainIsFull", func(t *testing.T) {
+		items, _ := nextCommitQueueItems(processing, model.CommitQueueParams{MergeTrainSize: 2}, 10)
+		assert.Empty(t, items)
+	})
+	t.Run("MergeTrainStartsFromIdle", func(t *testing.T) {
+		items, _ := nextCommitQueueItems(idle, model.CommitQueueParams{MergeTrainSize: 5}, 1)
+		assert.Equal(t, []string{"1", "2", "3"}, issues(items))
+	})
+}
@@ -539,6 +539,68 @@ func PopulateDuplicateTaskCheckJobs() amboy.QueueOperation {
 	}
 }
 
+// PopulateProjectVarsEncryptionJob enqueues a job to bring the stored project
+// variables up to date with the encryption settings.
+func PopulateProjectVarsEncryptionJob() amboy.QueueOperation {
+	return func(ctx context.Context, queue amboy.Queue) error {
+		ts := utility.RoundPartOfHour(0).Format(TSFormat)
+		return queue.Put(ctx, NewProjectVarsEncryptionJob(ts))
+	}
+}
+
+// PopulateAuditLogStreamJob enqueues a job to stream new audit log entries to
+// the configured audit log sink.
+func PopulateAuditLogStreamJob() amboy.QueueOperation {
+	return func(ctx context.Context, queue amboy.Queue) error {
+		ts := utility.RoundPartOfMinute(0).Format(TSFormat)
+		return amboy.EnqueueUniqueJob(ctx, queue, NewAuditLogStreamJob(ts))
+	}
+}
+
+// PopulateArtifactRetentionJobs enqueues a job for each project with artifact
+// retention enabled to delete its expired artifacts.
+func PopulateArtifactRetentionJobs() amboy.QueueOperation {
+	return func(ctx context.Context, queue amboy.Queue) error {
+		pRefs, err := model.FindAllMergedTrackedProjectRefs()
+		if err != nil {
+			return errors.Wrap(err, "finding projects")
+		}
+
+		catcher := grip.NewBasicCatcher()
+		ts := utility.RoundPartOfHour(0).Format(TSFormat)
+		for _, pRef := range pRefs {
+			if !pRef.ArtifactRetention.IsEnabled() {
+				continue
+			}
+			catcher.Wrapf(amboy.EnqueueUniqueJob(ctx, queue, NewArtifactRetentionJob(pRef.Id, ts)), "enqueueing artifact retention job for project '%s'", pRef.Id)
+		}
+
+		return errors.Wrap(catcher.Resolve(), "populating artifact retention jobs")
+	}
+}
+
+// PopulateTaskRetryJobs enqueues a job to restart tasks whose retry policy
+// backoff has elapsed.
+func PopulateTaskRetryJobs() amboy.QueueOperation {
+	return func(ctx context.Context, queue amboy.Queue) error {
+		flags, err := evergreen.GetServiceFlags(ctx)
+		if err != nil {
+			return errors.Wrap(err, "getting service flags")
+		}
+		if flags.SchedulerDisabled {
+			grip.InfoWhen(sometimes.Percent(evergreen.DegradedLoggingPercent), message.Fields{
+				"message": "scheduler is disabled",
+				"impact":  "skipping task retries",
+				"mode":    "degraded",
+			})
+			return nil
+		}
+
+		ts := utility.RoundPartOfMinute(0).Format(TSFormat)
+		return queue.Put(ctx, NewTaskRetryJob(ts))
+	}
+}
+
 // PopulateHostStatJobs adds host stats jobs.
 func PopulateHostStatJobs(parts int) amboy.QueueOperation {
 	return func(ctx context.Context, queue amboy.Queue) error {
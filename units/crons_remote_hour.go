@@ -57,6 +57,8 @@ func (j *cronsRemoteHourJob) Run(ctx context.Context) {
 		PopulateSSHKeyUpdates(j.env),
 		PopulateDuplicateTaskCheckJobs(),
 		PopulatePodResourceCleanupJobs(),
+		PopulateProjectVarsEncryptionJob(),
+		PopulateArtifactRetentionJobs(),
 	}
 
 	queue := j.env.RemoteQueue()
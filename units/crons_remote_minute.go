@@ -59,6 +59,8 @@ func (j *cronsRemoteMinuteJob) Run(ctx context.Context) {
 		PopulatePeriodicNotificationJobs(1),
 		PopulateUserDataDoneJobs(j.env),
 		PopulatePodTerminationJobs(j.env),
+		PopulateTaskRetryJobs(),
+		PopulateAuditLogStreamJob(),
 	}
 
 	catcher := grip.NewBasicCatcher()
@@ -23,6 +23,7 @@ import (
 	"github.com/mongodb/amboy/job"
 	"github.com/mongodb/amboy/registry"
 	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
 	"github.com/mongodb/grip/message"
 	"github.com/mongodb/grip/sometimes"
 	"github.com/pkg/errors"
@@ -146,6 +147,12 @@ func (j *patchIntentProcessor) Run(ctx context.Context) {
 			}
 			grip.Error(message.WrapError(err, msg))
 		}
+		if j.IntentType == patch.GitlabIntentType {
+			if j.gitHubError == "" {
+				j.gitHubError = OtherErrors
+			}
+			j.sendGitlabStatus(ctx, patchDoc, message.GithubStateFailure, j.gitHubError, "")
+		}
 		j.AddError(err)
 		return
 	}
@@ -175,9 +182,27 @@ func (j *patchIntentProcessor) Run(ctx context.Context) {
 			false, patchDoc.Id.Hex(), patchDoc.GithubPatchData.BaseOwner,
 			patchDoc.GithubPatchData.BaseRepo, patchDoc.GithubPatchData.PRNumber))
 	}
+
+	if j.IntentType == patch.GitlabIntentType && patchDoc.Version != "" {
+		url := fmt.Sprintf("%s/version/%s?redirect_spruce_users=true", j.env.Settings().Ui.Url, patchDoc.Id.Hex())
+		j.sendGitlabStatus(ctx, patchDoc, message.GithubStatePending, "preparing to run tasks", url)
+
+		j.AddError(model.AbortPatchesWithGitlabPatchData(patchDoc.CreateTime, false, patchDoc.Id.Hex(),
+			patchDoc.GitlabPatchData.ProjectPath, patchDoc.GitlabPatchData.MRNumber))
+	}
 }
 
 func (j *patchIntentProcessor) finishPatch(ctx context.Context, patchDoc *patch.Patch) error {
+	// GitLab merge requests aren't in a GitHub repository, so there's no app
+	// installation to create a token for.
+	if j.IntentType == patch.GitlabIntentType {
+		githubOauthToken, err := j.env.Settings().GetGithubOauthToken()
+		if err != nil {
+			return errors.Wrap(err, "getting GitHub OAuth token")
+		}
+		return j.finishPatchWithToken(ctx, patchDoc, githubOauthToken)
+	}
+
 	// TODO EVG-19966 Delete fallback
 	appToken, err := j.env.Settings().CreateInstallationToken(ctx, patchDoc.GithubPatchData.BaseOwner, patchDoc.GithubPatchData.BaseRepo, nil)
 	if err != nil {
@@ -222,6 +247,8 @@ func (j *patchIntentProcessor) finishPatchWithToken(ctx context.Context, patchDo
 		if err := j.buildGithubMergeDoc(ctx, patchDoc, token); err != nil {
 			catcher.Wrap(err, "building GitHub merge queue patch document")
 		}
+	case patch.GitlabIntentType:
+		catcher.Wrap(j.buildGitlabPatchDoc(ctx, patchDoc), "building GitLab patch document")
 	case patch.TriggerIntentType:
 		patchedProject, patchedParserProject, err = j.buildTriggerPatchDoc(patchDoc)
 		catcher.Wrap(err, "building trigger patch document")
@@ -416,6 +443,9 @@ func (j *patchIntentProcessor) finishPatchWithToken(ctx context.Context, patchDo
 	if patchDoc.IsGithubMergePatch() {
 		catcher.Wrap(j.createGitHubMergeSubscription(ctx, patchDoc), "creating GitHub merge queue subscriptions")
 	}
+	if patchDoc.IsGitlabMRPatch() {
+		catcher.Wrap(j.createGitlabSubscriptions(patchDoc), "creating GitLab merge request patch subscriptions")
+	}
 	if patchDoc.IsBackport() {
 		backportSubscription := event.NewExpiringPatchSuccessSubscription(j.PatchID.Hex(), event.NewEnqueuePatchSubscriber())
 		if err = backportSubscription.Upsert(); err != nil {
@@ -518,6 +548,32 @@ func (j *patchIntentProcessor) createGitHubSubscriptions(p *patch.Patch) error {
 	return catcher.Resolve()
 }
 
+// createGitlabSubscriptions creates subscriptions for notifications related to
+// GitLab merge request patches.
+func (j *patchIntentProcessor) createGitlabSubscriptions(p *patch.Patch) error {
+	catcher := grip.NewBasicCatcher()
+	gitlabSub := event.NewGitlabStatusAPISubscriber(event.GitlabMergeRequestSubscriber{
+		ProjectPath: p.GitlabPatchData.ProjectPath,
+		MRNumber:    p.GitlabPatchData.MRNumber,
+		Ref:         p.GitlabPatchData.HeadHash,
+	})
+	patchSub := event.NewExpiringPatchOutcomeSubscription(j.PatchID.Hex(), gitlabSub)
+	catcher.Wrap(patchSub.Upsert(), "inserting patch subscription for GitLab merge request")
+	buildSub := event.NewExpiringBuildOutcomeSubscriptionByVersion(j.PatchID.Hex(), gitlabSub)
+	catcher.Wrap(buildSub.Upsert(), "inserting build subscription for GitLab merge request")
+	for _, childPatch := range p.Triggers.ChildPatches {
+		childSub := event.NewGitlabStatusAPISubscriber(event.GitlabMergeRequestSubscriber{
+			ProjectPath: p.GitlabPatchData.ProjectPath,
+			MRNumber:    p.GitlabPatchData.MRNumber,
+			Ref:         p.GitlabPatchData.HeadHash,
+			ChildId:     childPatch,
+		})
+		patchSub := event.NewExpiringPatchChildOutcomeSubscription(childPatch, childSub)
+		catcher.Wrap(patchSub.Upsert(), "inserting child patch subscription for GitLab merge request")
+	}
+	return catcher.Resolve()
+}
+
 // createGithubMergeSubscription creates a subscription on a commit for the GitHub merge queue.
 func (j *patchIntentProcessor) createGitHubMergeSubscription(ctx context.Context, p *patch.Patch) error {
 	catcher := grip.NewBasicCatcher()
@@ -1007,6 +1063,60 @@ func (j *patchIntentProcessor) buildGithubMergeDoc(ctx context.Context, patchDoc
 	return nil
 }
 
+func (j *patchIntentProcessor) buildGitlabPatchDoc(ctx context.Context, patchDoc *patch.Patch) error {
+	defer func() {
+		grip.Error(message.WrapError(j.intent.SetProcessed(), message.Fields{
+			"message":     "could not mark patch intent as processed",
+			"intent_id":   j.IntentID,
+			"intent_type": j.IntentType,
+			"patch_id":    j.PatchID,
+			"source":      "patch intents",
+			"job":         j.ID(),
+		}))
+	}()
+
+	settings := j.env.Settings()
+	if !settings.Gitlab.IsConfigured() {
+		return errors.New("GitLab merge request testing is not configured")
+	}
+	data := patchDoc.GitlabPatchData
+	projectRef, err := model.FindOneProjectRefByGitlabProjectWithMRTesting(settings.Gitlab.Host(), data.ProjectPath, data.BaseBranch, j.intent.GetCalledBy())
+	if err != nil {
+		return errors.Wrapf(err, "fetching project ref for GitLab project '%s' with branch '%s'", data.ProjectPath, data.BaseBranch)
+	}
+	if projectRef == nil {
+		return errors.Errorf("project ref for GitLab project '%s' with branch '%s' not found", data.ProjectPath, data.BaseBranch)
+	}
+
+	patchContent, summaries, err := thirdparty.GetGitlabMergeRequestDiff(ctx, settings.Gitlab, data.ProjectPath, data.MRNumber)
+	if err != nil {
+		return err
+	}
+
+	patchFileID := fmt.Sprintf("%s_%s", patchDoc.Id.Hex(), patchDoc.Githash)
+	patchDoc.Patches = append(patchDoc.Patches, patch.ModulePatch{
+		ModuleName: "",
+		Githash:    patchDoc.Githash,
+		PatchSet: patch.PatchSet{
+			PatchFileId: patchFileID,
+			Summary:     summaries,
+		},
+	})
+	patchDoc.Project = projectRef.Id
+
+	if err = db.WriteGridFile(patch.GridFSPrefix, patchFileID, strings.NewReader(patchContent)); err != nil {
+		return errors.Wrap(err, "writing patch file to DB")
+	}
+
+	j.user, err = findEvergreenUserForGitlabMR()
+	if err != nil {
+		return errors.Wrap(err, "finding GitLab patch user")
+	}
+	patchDoc.Author = j.user.Id
+
+	return nil
+}
+
 func (j *patchIntentProcessor) buildTriggerPatchDoc(patchDoc *patch.Patch) (*model.Project, *model.ParserProject, error) {
 	defer func() {
 		grip.Error(message.WrapError(j.intent.SetProcessed(), message.Fields{
@@ -1137,6 +1247,26 @@ func findEvergreenUserForGithubMergeGroup(githubUID int) (*user.DBUser, error) {
 	return u, err
 }
 
+func findEvergreenUserForGitlabMR() (*user.DBUser, error) {
+	u, err := user.FindOne(user.ById(evergreen.GitlabPatchUser))
+	if err != nil {
+		return u, errors.Wrap(err, "finding GitLab patch user")
+	}
+	// and if that user doesn't exist, make it
+	if u == nil {
+		u = &user.DBUser{
+			Id:       evergreen.GitlabPatchUser,
+			DispName: "GitLab Merge Requests",
+			APIKey:   utility.RandomString(),
+		}
+		if err = u.Insert(); err != nil {
+			return nil, errors.Wrap(err, "inserting GitLab patch user")
+		}
+	}
+
+	return u, err
+}
+
 func (j *patchIntentProcessor) isUserAuthorized(ctx context.Context, patchDoc *patch.Patch, requiredOrganization, githubUser, githubOauthToken string) (bool, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -1227,3 +1357,42 @@ func (j *patchIntentProcessor) sendGitHubSuccessMessage(ctx context.Context, pat
 
 	j.AddError(update.Error())
 }
+
+// sendGitlabStatus sets the status of the merge request's head commit in
+// GitLab.
+func (j *patchIntentProcessor) sendGitlabStatus(ctx context.Context, patchDoc *patch.Patch, state message.GithubState, description, url string) {
+	flags, err := evergreen.GetServiceFlags(ctx)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "getting service flags"))
+		return
+	}
+	// GitLab statuses are disabled along with GitHub statuses.
+	if flags.GithubStatusAPIDisabled {
+		return
+	}
+	sender, err := j.env.GetSender(evergreen.SenderGitlabStatus)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "getting GitLab status sender"))
+		return
+	}
+	owner, repo, err := thirdparty.SplitGitlabProjectPath(patchDoc.GitlabPatchData.ProjectPath)
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+	c := message.NewGithubStatusMessageWithRepo(level.Notice, message.GithubStatus{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         patchDoc.GitlabPatchData.HeadHash,
+		Context:     evergreenContext,
+		State:       state,
+		URL:         url,
+		Description: description,
+	})
+	if !c.Loggable() {
+		j.AddError(errors.Errorf("GitLab status message is invalid for merge request '%s!%d'",
+			patchDoc.GitlabPatchData.ProjectPath, patchDoc.GitlabPatchData.MRNumber))
+		return
+	}
+	sender.Send(c)
+}
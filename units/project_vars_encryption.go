@@ -0,0 +1,51 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/pkg/errors"
+)
+
+const (
+	projectVarsEncryptionJobName = "project-vars-encryption"
+)
+
+func init() {
+	registry.AddJobType(projectVarsEncryptionJobName, func() amboy.Job { return makeProjectVarsEncryptionJob() })
+}
+
+type projectVarsEncryptionJob struct {
+	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
+}
+
+func makeProjectVarsEncryptionJob() *projectVarsEncryptionJob {
+	j := &projectVarsEncryptionJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    projectVarsEncryptionJobName,
+				Version: 0,
+			},
+		},
+	}
+	return j
+}
+
+// NewProjectVarsEncryptionJob encrypts project variables that are stored in
+// plaintext and re-encrypts project data keys with the active key-encryption
+// key, if project variable encryption is enabled.
+func NewProjectVarsEncryptionJob(id string) amboy.Job {
+	j := makeProjectVarsEncryptionJob()
+	j.SetID(fmt.Sprintf("%s.%s", projectVarsEncryptionJobName, id))
+	return j
+}
+
+func (j *projectVarsEncryptionJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+
+	j.AddError(errors.Wrap(model.EncryptProjectVars(ctx), "encrypting project variables"))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/job"
@@ -120,10 +121,12 @@ func (j *reauthorizeUserJob) Run(ctx context.Context) {
 
 	err = um.ReauthorizeUser(j.user)
 
-	// This handles a special Okta case in which the user's refresh token from
-	// Okta has expired, in which case they should be logged out, so that they
-	// are forced to log in to get a new refresh token.
-	if err != nil && strings.Contains(err.Error(), "invalid_grant") && strings.Contains(err.Error(), "The refresh token is invalid or expired.") {
+	// This handles the case in which the user's refresh token has expired or
+	// the user is no longer allowed to log in, in which case they should be
+	// logged out, so that they are forced to log in again. The Okta user
+	// manager only reports this in the error message.
+	if err != nil && (errors.Cause(err) == gimlet.ErrNeedsReauthentication ||
+		strings.Contains(err.Error(), "invalid_grant") && strings.Contains(err.Error(), "The refresh token is invalid or expired.")) {
 		grip.Info(message.WrapError(err, message.Fields{
 			"message": "user's refresh token is invalid, logging them out",
 			"user":    j.UserID,
@@ -0,0 +1,77 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	taskRetryJobName = "task-retry"
+)
+
+func init() {
+	registry.AddJobType(taskRetryJobName, func() amboy.Job { return makeTaskRetryJob() })
+}
+
+type taskRetryJob struct {
+	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
+
+	env evergreen.Environment
+}
+
+func makeTaskRetryJob() *taskRetryJob {
+	j := &taskRetryJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    taskRetryJobName,
+				Version: 0,
+			},
+		},
+	}
+	return j
+}
+
+// NewTaskRetryJob restarts failed tasks whose retry policy backoff has
+// elapsed.
+func NewTaskRetryJob(id string) amboy.Job {
+	j := makeTaskRetryJob()
+	j.SetID(fmt.Sprintf("%s.%s", taskRetryJobName, id))
+	return j
+}
+
+func (j *taskRetryJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	if j.env == nil {
+		j.env = evergreen.GetEnvironment()
+	}
+
+	tasks, err := task.FindRetryable(time.Now())
+	if err != nil {
+		j.AddError(errors.Wrap(err, "finding tasks to retry"))
+		return
+	}
+
+	for i := range tasks {
+		if err := model.RetryTask(ctx, j.env.Settings(), &tasks[i]); err != nil {
+			j.AddError(errors.Wrapf(err, "retrying task '%s'", tasks[i].Id))
+			continue
+		}
+		grip.Info(message.Fields{
+			"message":   "retried task after retry policy backoff",
+			"task_id":   tasks[i].Id,
+			"execution": tasks[i].Execution,
+			"job":       j.ID(),
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package units
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/build"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskRetryJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, db.ClearCollections(task.Collection, task.OldCollection, build.Collection, model.VersionCollection))
+	defer func() {
+		assert.NoError(t, db.ClearCollections(task.Collection, task.OldCollection, build.Collection, model.VersionCollection))
+	}()
+
+	b := build.Build{
+		Id:      "build",
+		Status:  evergreen.BuildFailed,
+		Version: "version",
+	}
+	require.NoError(t, b.Insert())
+	v := model.Version{
+		Id:     b.Version,
+		Status: evergreen.VersionFailed,
+	}
+	require.NoError(t, v.Insert())
+
+	policy := &task.RetryPolicy{MaxAttempts: 3, BackoffSecs: 600}
+	for _, tsk := range []task.Task{
+		{Id: "due", RetryAt: time.Now().Add(-time.Minute)},
+		{Id: "not_due", RetryAt: time.Now().Add(time.Hour)},
+		{Id: "no_retry"},
+	} {
+		tsk.DisplayName = tsk.Id
+		tsk.BuildId = b.Id
+		tsk.Version = v.Id
+		tsk.Project = "project"
+		tsk.Activated = true
+		tsk.Status = evergreen.TaskFailed
+		tsk.RetryPolicy = policy
+		require.NoError(t, tsk.Insert())
+	}
+
+	j, ok := NewTaskRetryJob("id").(*taskRetryJob)
+	require.True(t, ok)
+	j.env = evergreen.GetEnvironment()
+	j.Run(ctx)
+	require.NoError(t, j.Error())
+
+	dbTask, err := task.FindOneId("due")
+	require.NoError(t, err)
+	require.NotZero(t, dbTask)
+	assert.Equal(t, evergreen.TaskUndispatched, dbTask.Status, "task should be retried once its retry time has passed")
+	assert.Equal(t, 1, dbTask.Execution)
+	assert.True(t, dbTask.Activated)
+	assert.Zero(t, dbTask.RetryAt, "retry time should be cleared so the task isn't retried again")
+
+	for _, taskID := range []string{"not_due", "no_retry"} {
+		dbTask, err = task.FindOneId(taskID)
+		require.NoError(t, err)
+		require.NotZero(t, dbTask)
+		assert.Equal(t, evergreen.TaskFailed, dbTask.Status, taskID)
+		assert.Equal(t, 0, dbTask.Execution, taskID)
+	}
+
+	j, ok = NewTaskRetryJob("id2").(*taskRetryJob)
+	require.True(t, ok)
+	j.env = evergreen.GetEnvironment()
+	j.Run(ctx)
+	require.NoError(t, j.Error())
+
+	dbTask, err = task.FindOneId("due")
+	require.NoError(t, err)
+	require.NotZero(t, dbTask)
+	assert.Equal(t, 1, dbTask.Execution, "task should only be retried once for each retry time")
+}
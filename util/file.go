@@ -1,9 +1,13 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/pkg/errors"
 )
 
 // WriteToTempFile writes the given string to a temporary file and returns the
@@ -24,3 +28,18 @@ func WriteToTempFile(data string) (string, error) {
 	}
 	return file.Name(), nil
 }
+
+// FileSHA256 returns the hex-encoded SHA-256 digest of the file's contents.
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "opening file '%s'", path)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err = io.Copy(hasher, f); err != nil {
+		return "", errors.Wrapf(err, "reading file '%s'", path)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
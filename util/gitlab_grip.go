@@ -0,0 +1,146 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/message"
+	"github.com/mongodb/grip/send"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultGitlabStatusTimeout = 10 * time.Second
+	gitlabStatusRetries        = 3
+	gitlabPrivateTokenHeader   = "PRIVATE-TOKEN"
+
+	gitlabStatePending = "pending"
+	gitlabStateSuccess = "success"
+	gitlabStateFailed  = "failed"
+)
+
+// gitlabStatus is the body of a request to GitLab's commit status API.
+type gitlabStatus struct {
+	State       string `json:"state"`
+	Name        string `json:"name,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type gitlabStatusLogger struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	*send.Base
+}
+
+// NewGitlabStatusLogger returns a sender that sets commit statuses in GitLab.
+// It accepts the same GitHub status messages as the GitHub status sender,
+// where the message's owner is the GitLab project's namespace and its repo is
+// the project's name.
+func NewGitlabStatusLogger(baseURL, token string) (send.Sender, error) {
+	if baseURL == "" {
+		return nil, errors.New("GitLab URL must not be empty")
+	}
+	if token == "" {
+		return nil, errors.New("GitLab token must not be empty")
+	}
+	s := &gitlabStatusLogger{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		Base:    send.NewBase("gitlab-status"),
+	}
+
+	return s, nil
+}
+
+func (s *gitlabStatusLogger) Send(m message.Composer) {
+	if s.Level().ShouldLog(m) {
+		if err := s.send(m); err != nil {
+			s.ErrorHandler()(err, m)
+		}
+	}
+}
+
+// gitlabState converts a GitHub status state into the equivalent GitLab
+// commit status state.
+func gitlabState(state message.GithubState) (string, error) {
+	switch state {
+	case message.GithubStatePending:
+		return gitlabStatePending, nil
+	case message.GithubStateSuccess:
+		return gitlabStateSuccess, nil
+	case message.GithubStateFailure, message.GithubStateError:
+		return gitlabStateFailed, nil
+	default:
+		return "", errors.Errorf("unknown status state '%s'", state)
+	}
+}
+
+func (s *gitlabStatusLogger) send(m message.Composer) error {
+	raw, ok := m.Raw().(*message.GithubStatus)
+	if !ok {
+		return errors.Errorf("received unexpected composer %T", m.Raw())
+	}
+	state, err := gitlabState(raw.State)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(gitlabStatus{
+		State:       state,
+		Name:        raw.Context,
+		TargetURL:   raw.URL,
+		Description: raw.Description,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshalling GitLab status")
+	}
+	statusURL := fmt.Sprintf("%s/api/v4/projects/%s/statuses/%s", s.baseURL,
+		url.PathEscape(raw.Owner+"/"+raw.Repo), url.PathEscape(raw.Ref))
+
+	client := s.client
+	return utility.Retry(context.Background(), func() (bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultGitlabStatusTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, statusURL, bytes.NewReader(body))
+		if err != nil {
+			return false, errors.Wrap(err, "creating GitLab status request")
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(gitlabPrivateTokenHeader, s.token)
+
+		if client == nil {
+			client = utility.GetHTTPClient()
+			defer utility.PutHTTPClient(client)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return true, errors.Wrap(err, "sending GitLab status")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			err = errors.Errorf("response was %d (%s): %s", resp.StatusCode, http.StatusText(resp.StatusCode), strings.TrimSpace(string(respBody)))
+			// Client errors, such as setting a status that the commit
+			// already has, won't succeed on retry.
+			return resp.StatusCode >= 500, err
+		}
+
+		return false, nil
+	}, utility.RetryOptions{
+		MaxAttempts: gitlabStatusRetries,
+		MinDelay:    defaultMinDelay,
+	})
+}
+
+func (s *gitlabStatusLogger) Flush(_ context.Context) error { return nil }
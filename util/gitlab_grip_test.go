@@ -0,0 +1,94 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitlabStatusSender(t *testing.T) {
+	_, err := NewGitlabStatusLogger("", "token")
+	assert.Error(t, err)
+	_, err = NewGitlabStatusLogger("https://gitlab.example.com", "")
+	assert.Error(t, err)
+
+	var (
+		path   string
+		token  string
+		status gitlabStatus
+		code   int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		token = r.Header.Get(gitlabPrivateTokenHeader)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		w.WriteHeader(code)
+	}))
+	defer srv.Close()
+
+	sender, err := NewGitlabStatusLogger(srv.URL+"/", "token")
+	require.NoError(t, err)
+	s, ok := sender.(*gitlabStatusLogger)
+	require.True(t, ok)
+
+	for name, test := range map[string]func(*testing.T){
+		"SendsStatus": func(t *testing.T) {
+			code = http.StatusCreated
+			m := message.NewGithubStatusMessageWithRepo(level.Notice, message.GithubStatus{
+				Owner:       "group/subgroup",
+				Repo:        "project",
+				Ref:         "abcdef",
+				Context:     "evergreen",
+				State:       message.GithubStatePending,
+				URL:         "https://evergreen.example.com/version/v",
+				Description: "tasks are running",
+			})
+			require.True(t, m.Loggable())
+			assert.NoError(t, s.send(m))
+			assert.Equal(t, "/api/v4/projects/group%2Fsubgroup%2Fproject/statuses/abcdef", path)
+			assert.Equal(t, "token", token)
+			assert.Equal(t, gitlabStatus{
+				State:       gitlabStatePending,
+				Name:        "evergreen",
+				TargetURL:   "https://evergreen.example.com/version/v",
+				Description: "tasks are running",
+			}, status)
+		},
+		"MapsFailureStates": func(t *testing.T) {
+			code = http.StatusCreated
+			for _, state := range []message.GithubState{message.GithubStateFailure, message.GithubStateError} {
+				m := message.NewGithubStatusMessageWithRepo(level.Notice, message.GithubStatus{
+					Owner:   "group",
+					Repo:    "project",
+					Ref:     "abcdef",
+					Context: "evergreen",
+					State:   state,
+				})
+				assert.NoError(t, s.send(m))
+				assert.Equal(t, gitlabStateFailed, status.State)
+			}
+		},
+		"FailsForErrorResponse": func(t *testing.T) {
+			code = http.StatusBadRequest
+			m := message.NewGithubStatusMessageWithRepo(level.Notice, message.GithubStatus{
+				Owner:   "group",
+				Repo:    "project",
+				Ref:     "abcdef",
+				Context: "evergreen",
+				State:   message.GithubStateSuccess,
+			})
+			assert.Error(t, s.send(m))
+		},
+		"FailsForUnexpectedMessage": func(t *testing.T) {
+			assert.Error(t, s.send(message.NewString("hello")))
+		},
+	} {
+		t.Run(name, test)
+	}
+}
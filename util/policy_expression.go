@@ -0,0 +1,660 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// PolicyExpression is a compiled boolean expression used to write declarative
+// policies. The syntax is a small subset of CEL:
+//
+//   - Literals: numbers, single- or double-quoted strings, true, false, null,
+//     and lists such as ["a", "b"].
+//   - Variables: dotted paths into the evaluated object, such as
+//     params.permissions. Map keys that are not identifiers can be indexed,
+//     such as params["local-file"]. Missing fields evaluate to null.
+//   - Comparison: ==, !=, <, <=, >, >=.
+//   - Membership: x in list, list contains x, and s matches "regexp". For
+//     strings, in and contains check for substrings.
+//   - Logic: &&, ||, !, and parentheses.
+//   - Functions: size(x) for the length of a string, list, or map, and
+//     has(x) for whether a field is set.
+type PolicyExpression struct {
+	src  string
+	root exprNode
+}
+
+// CompilePolicyExpression parses the expression.
+func CompilePolicyExpression(src string) (*PolicyExpression, error) {
+	toks, err := tokenizeExpression(src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing expression '%s'", src)
+	}
+	p := &exprParser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing expression '%s'", src)
+	}
+	if p.peek().kind != tokEOF {
+		return nil, errors.Errorf("parsing expression '%s': unexpected '%s'", src, p.peek().val)
+	}
+	return &PolicyExpression{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *PolicyExpression) String() string { return e.src }
+
+// EvalBool evaluates the expression against the given variables. It returns
+// an error if the expression does not evaluate to a boolean. A null result is
+// false.
+func (e *PolicyExpression) EvalBool(vars map[string]interface{}) (bool, error) {
+	val, err := e.root.eval(vars)
+	if err != nil {
+		return false, errors.Wrapf(err, "evaluating expression '%s'", e.src)
+	}
+	b, err := truthy(val)
+	if err != nil {
+		return false, errors.Wrapf(err, "evaluating expression '%s'", e.src)
+	}
+	return b, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+}
+
+func tokenizeExpression(src string) ([]token, error) {
+	toks := []token{}
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			toks = append(toks, token{kind: tokIdent, val: string(runes[start:i])})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			toks = append(toks, token{kind: tokNumber, val: string(runes[start:i])})
+		case r == '"' || r == '\'':
+			quote := r
+			i++
+			var sb strings.Builder
+			for ; i < len(runes) && runes[i] != quote; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated string")
+			}
+			i++
+			toks = append(toks, token{kind: tokString, val: sb.String()})
+		default:
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					toks = append(toks, token{kind: tokOp, val: two})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("<>!()[],.", r) {
+				return nil, errors.Errorf("unexpected character '%c'", r)
+			}
+			toks = append(toks, token{kind: tokOp, val: string(r)})
+			i++
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+type exprParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *exprParser) peek() token { return p.toks[p.pos] }
+
+func (p *exprParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) isOp(val string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.val == val
+}
+
+func (p *exprParser) expectOp(val string) error {
+	if !p.isOp(val) {
+		return errors.Errorf("expected '%s' but found '%s'", val, p.peek().val)
+	}
+	p.next()
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	var op string
+	switch {
+	case t.kind == tokOp && utility.StringSliceContains([]string{"==", "!=", "<", "<=", ">", ">="}, t.val):
+		op = t.val
+	case t.kind == tokIdent && utility.StringSliceContains([]string{"in", "contains", "matches"}, t.val):
+		op = t.val
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	node := &compareNode{op: op, left: left, right: right}
+	if op == "matches" {
+		if lit, ok := right.(*literalNode); ok {
+			pattern, ok := lit.val.(string)
+			if !ok {
+				return nil, errors.New("matches requires a string pattern")
+			}
+			if node.re, err = regexp.Compile(pattern); err != nil {
+				return nil, errors.Wrapf(err, "compiling pattern '%s'", pattern)
+			}
+		}
+	}
+	return node, nil
+}
+
+func (p *exprParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isOp("."):
+			p.next()
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, errors.Errorf("expected field name after '.' but found '%s'", t.val)
+			}
+			node = &indexNode{target: node, key: &literalNode{val: t.val}}
+		case p.isOp("["):
+			p.next()
+			key, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+			node = &indexNode{target: node, key: key}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing number '%s'", t.val)
+		}
+		return &literalNode{val: f}, nil
+	case tokString:
+		return &literalNode{val: t.val}, nil
+	case tokIdent:
+		switch t.val {
+		case "true":
+			return &literalNode{val: true}, nil
+		case "false":
+			return &literalNode{val: false}, nil
+		case "null":
+			return &literalNode{val: nil}, nil
+		}
+		if p.isOp("(") {
+			p.next()
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			switch t.val {
+			case "size", "has":
+				return &funcNode{name: t.val, arg: arg}, nil
+			default:
+				return nil, errors.Errorf("unknown function '%s'", t.val)
+			}
+		}
+		return &varNode{name: t.val}, nil
+	case tokOp:
+		switch t.val {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expectOp(")")
+		case "[":
+			list := &listNode{}
+			for !p.isOp("]") {
+				elem, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				list.elems = append(list.elems, elem)
+				if !p.isOp(",") {
+					break
+				}
+				p.next()
+			}
+			return list, p.expectOp("]")
+		}
+	case tokEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, errors.Errorf("unexpected '%s'", t.val)
+}
+
+type exprNode interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct{ val interface{} }
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) { return n.val, nil }
+
+type varNode struct{ name string }
+
+func (n *varNode) eval(vars map[string]interface{}) (interface{}, error) {
+	return normalizeExprValue(vars[n.name]), nil
+}
+
+type listNode struct{ elems []exprNode }
+
+func (n *listNode) eval(vars map[string]interface{}) (interface{}, error) {
+	out := make([]interface{}, 0, len(n.elems))
+	for _, elem := range n.elems {
+		val, err := elem.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, val)
+	}
+	return out, nil
+}
+
+type indexNode struct {
+	target exprNode
+	key    exprNode
+}
+
+func (n *indexNode) eval(vars map[string]interface{}) (interface{}, error) {
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	key, err := n.key.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch t := target.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return nil, errors.Errorf("cannot index map with %T", key)
+		}
+		return normalizeExprValue(t[k]), nil
+	case []interface{}:
+		idx, ok := key.(float64)
+		if !ok {
+			return nil, errors.Errorf("cannot index list with %T", key)
+		}
+		if idx < 0 || int(idx) >= len(t) {
+			return nil, nil
+		}
+		return normalizeExprValue(t[int(idx)]), nil
+	default:
+		return nil, errors.Errorf("cannot index %T", target)
+	}
+}
+
+type funcNode struct {
+	name string
+	arg  exprNode
+}
+
+func (n *funcNode) eval(vars map[string]interface{}) (interface{}, error) {
+	val, err := n.arg.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.name {
+	case "has":
+		return val != nil, nil
+	case "size":
+		switch v := val.(type) {
+		case nil:
+			return float64(0), nil
+		case string:
+			return float64(len(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		default:
+			return nil, errors.Errorf("size is not defined for %T", val)
+		}
+	}
+	return nil, errors.Errorf("unknown function '%s'", n.name)
+}
+
+type notNode struct{ operand exprNode }
+
+func (n *notNode) eval(vars map[string]interface{}) (interface{}, error) {
+	val, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truthy(val)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+type logicNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *logicNode) eval(vars map[string]interface{}) (interface{}, error) {
+	leftVal, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	left, err := truthy(leftVal)
+	if err != nil {
+		return nil, err
+	}
+	if (n.op == "&&" && !left) || (n.op == "||" && left) {
+		return left, nil
+	}
+	rightVal, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(rightVal)
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+	re          *regexp.Regexp
+}
+
+func (n *compareNode) eval(vars map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	case "in":
+		return exprContains(right, left)
+	case "contains":
+		return exprContains(left, right)
+	case "matches":
+		s, ok := left.(string)
+		if !ok {
+			return false, nil
+		}
+		re := n.re
+		if re == nil {
+			pattern, ok := right.(string)
+			if !ok {
+				return nil, errors.Errorf("matches requires a string pattern but got %T", right)
+			}
+			if re, err = regexp.Compile(pattern); err != nil {
+				return nil, errors.Wrapf(err, "compiling pattern '%s'", pattern)
+			}
+		}
+		return re.MatchString(s), nil
+	}
+
+	if left == nil || right == nil {
+		return false, nil
+	}
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, errors.Errorf("cannot compare number to %T", right)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, errors.Errorf("cannot compare string to %T", right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, errors.Errorf("cannot order values of type %T", left)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func exprContains(container, elem interface{}) (bool, error) {
+	switch c := container.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, item := range c {
+			if reflect.DeepEqual(item, elem) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := elem.(string)
+		if !ok {
+			return false, nil
+		}
+		return strings.Contains(c, s), nil
+	case map[string]interface{}:
+		k, ok := elem.(string)
+		if !ok {
+			return false, nil
+		}
+		_, found := c[k]
+		return found, nil
+	default:
+		return false, errors.Errorf("cannot check membership in %T", container)
+	}
+}
+
+func truthy(val interface{}) (bool, error) {
+	switch v := val.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, errors.Errorf("expected a boolean but got %T", val)
+	}
+}
+
+// normalizeExprValue converts the value into the types that expressions
+// operate on: float64 for numbers, []interface{} for lists, and
+// map[string]interface{} for maps.
+func normalizeExprValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil, bool, string, float64:
+		return v
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			out = append(out, normalizeExprValue(item))
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = normalizeExprValue(item)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, 0, len(v))
+		for _, s := range v {
+			out = append(out, s)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			out[k] = s
+		}
+		return out
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String:
+		return rv.String()
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out = append(out, normalizeExprValue(rv.Index(i).Interface()))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = normalizeExprValue(iter.Value().Interface())
+		}
+		return out
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalizeExprValue(rv.Elem().Interface())
+	}
+	return val
+}
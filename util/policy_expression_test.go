@@ -0,0 +1,83 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyExpression(t *testing.T) {
+	vars := map[string]interface{}{
+		"name":              "compile",
+		"exec_timeout_secs": 7200,
+		"patchable":         true,
+		"run_on":            []string{"ubuntu2204", "release"},
+		"tags":              []string{},
+		"params": map[string]interface{}{
+			"permissions": "public-read",
+			"local-file":  "dist.tgz",
+			"nested":      map[interface{}]interface{}{"count": 3},
+		},
+	}
+
+	for expr, expected := range map[string]bool{
+		"exec_timeout_secs <= 21600":                           true,
+		"exec_timeout_secs > 7200":                             false,
+		"exec_timeout_secs == 7200":                            true,
+		"name == 'compile' && patchable":                       true,
+		"name != \"compile\" || !patchable":                    false,
+		"\"release\" in run_on":                                true,
+		"run_on contains 'windows'":                            false,
+		"!(\"release\" in run_on)":                             false,
+		"params.permissions != 'public-read'":                  false,
+		"params[\"local-file\"] matches '\\\\.tgz$'":           true,
+		"params.nested.count >= 3":                             true,
+		"has(params.missing)":                                  false,
+		"has(params.permissions)":                              true,
+		"size(run_on) == 2 && size(tags) == 0":                 true,
+		"name in ['compile', 'lint']":                          true,
+		"'pile' in name":                                       true,
+		"missing.field == null":                                true,
+		"missing":                                              false,
+		"missing < 3":                                          false,
+		"patchable && (exec_timeout_secs < 100 || name == '')": false,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			e, err := CompilePolicyExpression(expr)
+			require.NoError(t, err)
+			actual, err := e.EvalBool(vars)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+
+	t.Run("InvalidSyntax", func(t *testing.T) {
+		for _, expr := range []string{
+			"",
+			"name ==",
+			"name = 'compile'",
+			"(name == 'compile'",
+			"'unterminated",
+			"unknown(name)",
+			"name matches '('",
+			"name == 'a' extra",
+		} {
+			_, err := CompilePolicyExpression(expr)
+			assert.Error(t, err, expr)
+		}
+	})
+	t.Run("TypeErrors", func(t *testing.T) {
+		for _, expr := range []string{
+			"name",
+			"name < 3",
+			"size(patchable) == 0",
+			"patchable && name",
+		} {
+			e, err := CompilePolicyExpression(expr)
+			require.NoError(t, err, expr)
+			_, err = e.EvalBool(vars)
+			assert.Error(t, err, expr)
+		}
+	})
+}
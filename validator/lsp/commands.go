@@ -0,0 +1,149 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/evergreen-ci/evergreen/agent/command"
+)
+
+const commandDocsURL = "https://docs.devprod.prod.corp.mongodb.com/evergreen/Project-Configuration/Project-Commands"
+
+// commandParam is a parameter that a command accepts.
+type commandParam struct {
+	name string
+	typ  string
+	// expandable is whether the parameter's value is expanded.
+	expandable bool
+}
+
+// commandInfo describes a registered command and its parameters.
+type commandInfo struct {
+	name       string
+	deprecated bool
+	params     []commandParam
+}
+
+var (
+	commandInfoOnce sync.Once
+	commandInfos    map[string]commandInfo
+)
+
+// getCommandInfos returns the information about every registered command,
+// which is derived from the command's parameter struct.
+func getCommandInfos() map[string]commandInfo {
+	commandInfoOnce.Do(func() {
+		commandInfos = map[string]commandInfo{}
+		for _, name := range command.RegisteredCommandNames() {
+			factory, ok := command.GetCommandFactory(name)
+			if !ok {
+				continue
+			}
+			_, deprecated := command.DeprecatedCommands[name]
+			info := commandInfo{name: name, deprecated: deprecated}
+			if cmd := factory(); cmd != nil {
+				info.params = paramsFromType(reflect.TypeOf(cmd))
+			}
+			commandInfos[name] = info
+		}
+	})
+	return commandInfos
+}
+
+func sortedCommandNames() []string {
+	names := []string{}
+	for name := range getCommandInfos() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// paramsFromType returns the parameters decoded into the struct type by its
+// mapstructure tags.
+func paramsFromType(t reflect.Type) []commandParam {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	params := []commandParam{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("mapstructure")
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && (name == "" || strings.Contains(opts, "squash")) {
+			if f.IsExported() || strings.Contains(opts, "squash") {
+				params = append(params, paramsFromType(f.Type)...)
+			}
+			continue
+		}
+		if !hasTag || name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		params = append(params, commandParam{
+			name:       name,
+			typ:        describeType(f.Type),
+			expandable: strings.Contains(f.Tag.Get("plugin"), "expand"),
+		})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].name < params[j].name })
+	return params
+}
+
+// describeType returns a YAML-oriented description of a parameter type.
+func describeType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("list of %s", describeType(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map of %s", describeType(t.Elem()))
+	default:
+		return "object"
+	}
+}
+
+// commandDocsLink returns a link to the documentation for the command.
+func commandDocsLink(name string) string {
+	return fmt.Sprintf("%s#%s", commandDocsURL, strings.ReplaceAll(name, ".", ""))
+}
+
+// commandMarkdown documents the command and its parameters.
+func commandMarkdown(info commandInfo) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s**", info.name)
+	if info.deprecated {
+		sb.WriteString(" (deprecated)")
+	}
+	sb.WriteString("\n\n")
+	if len(info.params) > 0 {
+		sb.WriteString("Parameters:\n")
+		for _, p := range info.params {
+			fmt.Fprintf(&sb, "- `%s`: %s", p.name, p.typ)
+			if p.expandable {
+				sb.WriteString(" (supports expansions)")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "[Documentation](%s)", commandDocsLink(info.name))
+	return sb.String()
+}
@@ -0,0 +1,159 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+)
+
+const markdownKind = "markdown"
+
+// completion suggests command names after "command:", function names after
+// "func:" and a command's parameter names inside its "params" block.
+func (s *Server) completion(params TextDocumentPositionParams) CompletionList {
+	list := CompletionList{Items: []CompletionItem{}}
+	doc := s.getDocument(params.TextDocument.URI)
+	if doc == nil {
+		return list
+	}
+
+	line := doc.line(params.Position.Line)
+	prefix := line
+	if params.Position.Character < len(line) {
+		prefix = line[:params.Position.Character]
+	}
+	key, _, hasValue := strings.Cut(strings.TrimLeft(prefix, " \t-"), ":")
+	key = strings.TrimSpace(key)
+
+	switch {
+	case hasValue && key == "command":
+		for _, name := range sortedCommandNames() {
+			info := getCommandInfos()[name]
+			item := CompletionItem{
+				Label:         name,
+				Kind:          CompletionKindModule,
+				Documentation: &MarkupContent{Kind: markdownKind, Value: commandMarkdown(info)},
+			}
+			if info.deprecated {
+				item.Detail = "deprecated"
+			}
+			list.Items = append(list.Items, item)
+		}
+	case hasValue && key == "func":
+		seen := map[string]bool{}
+		for _, d := range s.relatedDocuments(doc) {
+			for name := range d.defs[definitionFunction] {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				list.Items = append(list.Items, CompletionItem{Label: name, Kind: CompletionKindFunction, Detail: "function"})
+			}
+		}
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Label < list.Items[j].Label })
+	case !hasValue:
+		info, ok := getCommandInfos()[enclosingParamsCommand(doc, params.Position.Line)]
+		if !ok {
+			return list
+		}
+		for _, p := range info.params {
+			list.Items = append(list.Items, CompletionItem{
+				Label:      p.name,
+				Kind:       CompletionKindField,
+				Detail:     p.typ,
+				InsertText: p.name + ": ",
+			})
+		}
+	}
+	return list
+}
+
+// lineKey is the mapping key that a line starts, if any.
+type lineKey struct {
+	// indent is the column that the key starts at.
+	indent int
+	key    string
+	// itemStart is whether the key is the first key of a list item.
+	itemStart bool
+	blank     bool
+}
+
+func parseLineKey(line string) lineKey {
+	rest := strings.TrimLeft(line, " ")
+	k := lineKey{indent: len(line) - len(rest)}
+	if strings.HasPrefix(rest, "- ") || rest == "-" {
+		trimmed := strings.TrimLeft(rest[1:], " ")
+		k.indent += len(rest) - len(trimmed)
+		k.itemStart = true
+		rest = trimmed
+	}
+	if rest == "" || strings.HasPrefix(rest, "#") {
+		k.blank = !k.itemStart
+		return k
+	}
+	k.key, _, _ = strings.Cut(rest, ":")
+	k.key = strings.TrimSpace(k.key)
+	return k
+}
+
+// enclosingParamsCommand returns the name of the command whose "params"
+// mapping directly contains the line, or an empty string if the line is not
+// a top-level key of a command's parameters.
+func enclosingParamsCommand(doc *document, lineNum int) string {
+	current := parseLineKey(doc.line(lineNum))
+
+	paramsLine := -1
+	var params lineKey
+	for i := lineNum - 1; i >= 0; i-- {
+		k := parseLineKey(doc.line(i))
+		if k.blank || k.indent >= current.indent {
+			continue
+		}
+		if k.key != "params" {
+			return ""
+		}
+		paramsLine, params = i, k
+		break
+	}
+	if paramsLine < 0 {
+		return ""
+	}
+
+	// The command is a sibling key of "params" in the same mapping, so search
+	// both before and after the params line until the mapping ends.
+	if !params.itemStart {
+		for i := paramsLine - 1; i >= 0; i-- {
+			k := parseLineKey(doc.line(i))
+			if k.blank || k.indent > params.indent {
+				continue
+			}
+			if k.indent < params.indent {
+				break
+			}
+			if k.key == "command" {
+				return commandValue(doc.line(i))
+			}
+			if k.itemStart {
+				break
+			}
+		}
+	}
+	for i := paramsLine + 1; i < len(doc.lines); i++ {
+		k := parseLineKey(doc.line(i))
+		if k.blank || k.indent > params.indent || i == lineNum {
+			continue
+		}
+		if k.indent < params.indent || k.itemStart {
+			break
+		}
+		if k.key == "command" {
+			return commandValue(doc.line(i))
+		}
+	}
+	return ""
+}
+
+func commandValue(line string) string {
+	_, value, _ := strings.Cut(line, ":")
+	value, _, _ = strings.Cut(value, " #")
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
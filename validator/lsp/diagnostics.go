@@ -0,0 +1,136 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/validator"
+	"github.com/pkg/errors"
+)
+
+const diagnosticSource = "evergreen"
+
+var (
+	quotedNamePattern = regexp.MustCompile(`'([^']+)'`)
+	lineNumberPattern = regexp.MustCompile(`line (\d+)`)
+)
+
+// publishDiagnostics validates the document as part of its main
+// configuration file and publishes the diagnostics for every file involved.
+func (s *Server) publishDiagnostics(ctx context.Context, doc *document) error {
+	if doc.parseErr != nil {
+		return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         doc.uri,
+			Diagnostics: []Diagnostic{newDiagnostic(doc, errorRange(doc, doc.parseErr.Error()), SeverityError, doc.parseErr.Error())},
+		})
+	}
+
+	main := s.mainDocument(doc)
+	related := s.relatedDocuments(doc)
+	diagnostics := map[string][]Diagnostic{}
+	for _, d := range related {
+		diagnostics[d.uri] = []Diagnostic{}
+	}
+
+	s.mu.Lock()
+	localModules := s.localModules
+	s.mu.Unlock()
+	for _, verr := range validateProject(ctx, main, localModules) {
+		target, rng := locateValidationError(verr.Message, main, related)
+		severity := SeverityWarning
+		if verr.Level == validator.Error {
+			severity = SeverityError
+		}
+		diagnostics[target.uri] = append(diagnostics[target.uri], newDiagnostic(target, rng, severity, verr.Message))
+	}
+
+	// Clear the diagnostics for files that are no longer part of the main
+	// configuration file.
+	s.mu.Lock()
+	for _, uri := range s.published[main.uri] {
+		if _, ok := diagnostics[uri]; !ok {
+			diagnostics[uri] = []Diagnostic{}
+		}
+	}
+	s.published[main.uri] = nil
+	for uri := range diagnostics {
+		s.published[main.uri] = append(s.published[main.uri], uri)
+	}
+	s.mu.Unlock()
+
+	for uri, diags := range diagnostics {
+		if err := s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diags}); err != nil {
+			return errors.Wrapf(err, "publishing diagnostics for '%s'", uri)
+		}
+	}
+	return nil
+}
+
+// validateProject loads the project from the main configuration file and its
+// includes and runs the validation checks that don't need the Evergreen
+// server.
+func validateProject(ctx context.Context, main *document, localModules map[string]string) (errs validator.ValidationErrors) {
+	defer func() {
+		if r := recover(); r != nil {
+			errs = append(errs, validator.ValidationError{
+				Level:   validator.Warning,
+				Message: fmt.Sprintf("validation could not be completed: %v", r),
+			})
+		}
+	}()
+
+	project := &model.Project{}
+	opts := &model.GetProjectOpts{
+		ReadFileFrom:    model.ReadFromLocal,
+		LocalModules:    localModules,
+		UnmarshalStrict: true,
+	}
+	_, _, errs = validator.LoadProjectIntoWithValidation(ctx, []byte(main.text), opts, project)
+	if errs.HasError() {
+		return errs
+	}
+	errs = append(errs, validator.CheckLocalProjectErrors(project)...)
+	errs = append(errs, validator.CheckProjectWarnings(project)...)
+	errs = append(errs, validator.CheckProjectCLIWarnings(project)...)
+	return errs
+}
+
+// locateValidationError returns the document and range that a validation
+// error refers to. Errors that name a task, task group, build variant or
+// function are placed on its definition, and errors that mention a line
+// number are placed on that line of the main configuration file. Other errors
+// are placed at the start of the main configuration file.
+func locateValidationError(msg string, main *document, docs []*document) (*document, Range) {
+	for _, match := range quotedNamePattern.FindAllStringSubmatch(msg, -1) {
+		for _, kind := range definitionKinds {
+			for _, d := range docs {
+				if def, ok := d.defs[kind][match[1]]; ok {
+					return d, def.rng
+				}
+			}
+		}
+	}
+	return main, errorRange(main, msg)
+}
+
+// errorRange returns the line that the error message mentions, or the first
+// line if it doesn't mention one.
+func errorRange(doc *document, msg string) Range {
+	line := 0
+	if match := lineNumberPattern.FindStringSubmatch(msg); match != nil {
+		if n, err := strconv.Atoi(match[1]); err == nil && n > 0 {
+			line = n - 1
+		}
+	}
+	return Range{
+		Start: Position{Line: line},
+		End:   Position{Line: line, Character: len(doc.line(line))},
+	}
+}
+
+func newDiagnostic(doc *document, rng Range, severity DiagnosticSeverity, msg string) Diagnostic {
+	return Diagnostic{Range: rng, Severity: severity, Source: diagnosticSource, Message: msg}
+}
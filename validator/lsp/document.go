@@ -0,0 +1,279 @@
+package lsp
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// definitionKind is a kind of named definition in a project configuration.
+type definitionKind string
+
+const (
+	definitionFunction  definitionKind = "function"
+	definitionTask      definitionKind = "task"
+	definitionTaskGroup definitionKind = "task group"
+	definitionVariant   definitionKind = "build variant"
+)
+
+// definitionKinds are the kinds of definitions in the order that they're
+// searched when a name could refer to more than one kind.
+var definitionKinds = []definitionKind{definitionTask, definitionTaskGroup, definitionVariant, definitionFunction}
+
+// definition is a named function, task, task group or build variant.
+type definition struct {
+	kind definitionKind
+	name string
+	// rng is the range of the definition's name.
+	rng Range
+	// node is the definition's body.
+	node *yaml.Node
+}
+
+// referenceKind is what a scalar in a project configuration refers to.
+type referenceKind int
+
+const (
+	referenceNone referenceKind = iota
+	referenceFunction
+	// referenceTask refers to either a task or a task group.
+	referenceTask
+	referenceVariant
+	referenceCommand
+	referenceParam
+)
+
+// scalar is a scalar key or value in a project configuration.
+type scalar struct {
+	node  *yaml.Node
+	rng   Range
+	path  []string
+	isKey bool
+	ref   referenceKind
+	// command is the name of the command that a param belongs to.
+	command string
+}
+
+type include struct {
+	fileName string
+	module   string
+}
+
+// document is a parsed and indexed project configuration file.
+type document struct {
+	uri      string
+	path     string
+	text     string
+	lines    []string
+	parseErr error
+	defs     map[definitionKind]map[string]definition
+	scalars  []scalar
+	includes []include
+}
+
+func newDocument(uri, path, text string) *document {
+	d := &document{
+		uri:   uri,
+		path:  path,
+		text:  text,
+		lines: strings.Split(text, "\n"),
+		defs:  map[definitionKind]map[string]definition{},
+	}
+	for _, kind := range definitionKinds {
+		d.defs[kind] = map[string]definition{}
+	}
+
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(text), root); err != nil {
+		d.parseErr = err
+		return d
+	}
+	d.walk(root, nil, nil, "")
+	if len(root.Content) > 0 {
+		d.indexIncludes(root.Content[0])
+	}
+	return d
+}
+
+func (d *document) walk(n, parent *yaml.Node, path []string, command string) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, child := range n.Content {
+			d.walk(child, n, path, "")
+		}
+	case yaml.MappingNode:
+		mappingCommand := ""
+		for i := 0; i < len(n.Content)-1; i += 2 {
+			if n.Content[i].Value == "command" && n.Content[i+1].Kind == yaml.ScalarNode {
+				mappingCommand = n.Content[i+1].Value
+			}
+		}
+		for i := 0; i < len(n.Content)-1; i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			d.addKey(key, value, n, path, command)
+			childCommand := ""
+			if key.Value == "params" {
+				childCommand = mappingCommand
+			}
+			d.walk(value, n, appendPath(path, key.Value), childCommand)
+		}
+	case yaml.SequenceNode:
+		for _, child := range n.Content {
+			d.walk(child, n, appendPath(path, "[]"), "")
+		}
+	case yaml.ScalarNode:
+		d.scalars = append(d.scalars, scalar{
+			node: n,
+			rng:  scalarRange(n),
+			path: path,
+			ref:  classifyValue(path),
+		})
+	}
+}
+
+// addKey indexes the key of a mapping and any definition that it names.
+func (d *document) addKey(key, value, mapping *yaml.Node, path []string, command string) {
+	s := scalar{node: key, rng: scalarRange(key), path: path, isKey: true}
+	if command != "" {
+		s.ref = referenceParam
+		s.command = command
+	}
+	d.scalars = append(d.scalars, s)
+
+	if matchPath(path, "functions") {
+		d.addDefinition(definitionFunction, key, value)
+		return
+	}
+	if key.Value != "name" || value.Kind != yaml.ScalarNode {
+		return
+	}
+	switch {
+	case matchPath(path, "tasks", "[]"):
+		d.addDefinition(definitionTask, value, mapping)
+	case matchPath(path, "task_groups", "[]"):
+		d.addDefinition(definitionTaskGroup, value, mapping)
+	case matchPath(path, "buildvariants", "[]"):
+		d.addDefinition(definitionVariant, value, mapping)
+	}
+}
+
+func (d *document) addDefinition(kind definitionKind, name, body *yaml.Node) {
+	if _, ok := d.defs[kind][name.Value]; ok {
+		return
+	}
+	d.defs[kind][name.Value] = definition{kind: kind, name: name.Value, rng: scalarRange(name), node: body}
+}
+
+func (d *document) indexIncludes(root *yaml.Node) {
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		if root.Content[i].Value != "include" || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range root.Content[i+1].Content {
+			inc := include{}
+			for j := 0; j+1 < len(item.Content); j += 2 {
+				switch item.Content[j].Value {
+				case "filename":
+					inc.fileName = item.Content[j+1].Value
+				case "module":
+					inc.module = item.Content[j+1].Value
+				}
+			}
+			if inc.fileName != "" {
+				d.includes = append(d.includes, inc)
+			}
+		}
+	}
+}
+
+// scalarAt returns the innermost scalar at the position, if any.
+func (d *document) scalarAt(pos Position) *scalar {
+	var found *scalar
+	for i := range d.scalars {
+		s := &d.scalars[i]
+		if s.rng.contains(pos) && (found == nil || s.rng.Start.Character >= found.rng.Start.Character) {
+			found = s
+		}
+	}
+	return found
+}
+
+// line returns the text of the line, or an empty string if it doesn't exist.
+func (d *document) line(i int) string {
+	if i < 0 || i >= len(d.lines) {
+		return ""
+	}
+	return strings.TrimRight(d.lines[i], "\r")
+}
+
+// classifyValue returns what a scalar value at the given path refers to.
+func classifyValue(path []string) referenceKind {
+	if len(path) == 0 {
+		return referenceNone
+	}
+	last := path[len(path)-1]
+	switch {
+	case last == "func":
+		return referenceFunction
+	case last == "command":
+		return referenceCommand
+	case matchPath(path, "buildvariants", "[]", "tasks", "[]"),
+		matchPath(path, "buildvariants", "[]", "tasks", "[]", "name"),
+		matchPath(path, "buildvariants", "[]", "display_tasks", "[]", "execution_tasks", "[]"),
+		matchPath(path, "task_groups", "[]", "tasks", "[]"),
+		matchPath(path, "buildvariants", "[]", "tasks", "[]", "task_group", "tasks", "[]"):
+		return referenceTask
+	}
+	for i, elem := range path {
+		if elem != "depends_on" {
+			continue
+		}
+		rest := path[i+1:]
+		switch {
+		case len(rest) == 1 && rest[0] == "[]", len(rest) == 2 && rest[1] == "name", len(rest) == 1 && rest[0] == "name":
+			return referenceTask
+		case len(rest) == 2 && rest[1] == "variant", len(rest) == 1 && rest[0] == "variant":
+			return referenceVariant
+		}
+	}
+	return referenceNone
+}
+
+// matchPath returns whether the path is exactly the given elements.
+func matchPath(path []string, elems ...string) bool {
+	if len(path) != len(elems) {
+		return false
+	}
+	for i := range path {
+		if path[i] != elems[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func appendPath(path []string, elem string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
+
+// scalarRange returns the range that the scalar node covers. Multi-line
+// scalars only cover their first character.
+func scalarRange(n *yaml.Node) Range {
+	start := Position{Line: n.Line - 1, Character: n.Column - 1}
+	length := len(n.Value)
+	switch n.Style {
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		length += 2
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		length = 1
+	}
+	if strings.Contains(n.Value, "\n") {
+		length = 1
+	}
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + length}}
+}
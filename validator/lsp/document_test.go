@@ -0,0 +1,65 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDocument(t *testing.T) {
+	doc := newDocument("file:///evergreen.yml", "/evergreen.yml", testProject)
+	require.NoError(t, doc.parseErr)
+
+	t.Run("IndexesDefinitions", func(t *testing.T) {
+		assert.Contains(t, doc.defs[definitionFunction], "fetch")
+		assert.Contains(t, doc.defs[definitionTask], "compile")
+		assert.Contains(t, doc.defs[definitionTask], "test")
+		assert.Contains(t, doc.defs[definitionVariant], "ubuntu")
+		assert.Equal(t, Range{Start: Position{Line: 6, Character: 10}, End: Position{Line: 6, Character: 17}}, doc.defs[definitionTask]["compile"].rng)
+	})
+	t.Run("ClassifiesReferences", func(t *testing.T) {
+		for _, tc := range []struct {
+			pos  Position
+			ref  referenceKind
+			name string
+		}{
+			{pos: Position{Line: 2, Character: 18}, ref: referenceCommand, name: "git.get_project"},
+			{pos: Position{Line: 4, Character: 10}, ref: referenceParam, name: "directory"},
+			{pos: Position{Line: 8, Character: 16}, ref: referenceFunction, name: "fetch"},
+			{pos: Position{Line: 14, Character: 16}, ref: referenceTask, name: "compile"},
+			{pos: Position{Line: 24, Character: 16}, ref: referenceTask, name: "test"},
+			{pos: Position{Line: 19, Character: 20}, ref: referenceNone, name: "Ubuntu"},
+		} {
+			sc := doc.scalarAt(tc.pos)
+			require.NotNil(t, sc, tc.name)
+			assert.Equal(t, tc.name, sc.node.Value)
+			assert.Equal(t, tc.ref, sc.ref, tc.name)
+		}
+		assert.Equal(t, "git.get_project", doc.scalarAt(Position{Line: 4, Character: 10}).command)
+	})
+	t.Run("ParseError", func(t *testing.T) {
+		invalid := newDocument("file:///evergreen.yml", "/evergreen.yml", "tasks: [\n")
+		assert.Error(t, invalid.parseErr)
+	})
+}
+
+func TestEnclosingParamsCommand(t *testing.T) {
+	doc := newDocument("", "", `tasks:
+  - name: t
+    commands:
+      - params:
+          script: make
+          env:
+            FOO: bar
+        command: shell.exec
+      - command: s3.put
+        params:
+          bucket: b
+`)
+	assert.Equal(t, "shell.exec", enclosingParamsCommand(doc, 4))
+	assert.Equal(t, "shell.exec", enclosingParamsCommand(doc, 5))
+	assert.Empty(t, enclosingParamsCommand(doc, 6))
+	assert.Equal(t, "s3.put", enclosingParamsCommand(doc, 10))
+	assert.Empty(t, enclosingParamsCommand(doc, 1))
+}
@@ -0,0 +1,196 @@
+package lsp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// referencedKinds returns the kinds of definitions that a reference can
+// refer to.
+func referencedKinds(ref referenceKind) []definitionKind {
+	switch ref {
+	case referenceFunction:
+		return []definitionKind{definitionFunction}
+	case referenceTask:
+		return []definitionKind{definitionTask, definitionTaskGroup}
+	case referenceVariant:
+		return []definitionKind{definitionVariant}
+	default:
+		return nil
+	}
+}
+
+// findDefinitions returns the definitions that the scalar refers to across
+// the document's main configuration file and its includes. Tag selectors and
+// wildcards don't refer to a single definition, so they have none.
+func (s *Server) findDefinitions(doc *document, sc *scalar) ([]*document, []definition) {
+	kinds := referencedKinds(sc.ref)
+	name := sc.node.Value
+	if len(kinds) == 0 || name == "" || strings.ContainsAny(name[:1], ".!*") || strings.Contains(name, " ") {
+		return nil, nil
+	}
+
+	var docs []*document
+	var defs []definition
+	for _, kind := range kinds {
+		for _, d := range s.relatedDocuments(doc) {
+			if def, ok := d.defs[kind][name]; ok {
+				docs = append(docs, d)
+				defs = append(defs, def)
+			}
+		}
+	}
+	return docs, defs
+}
+
+// definition returns the locations of the function, task, task group or build
+// variant that the scalar at the position refers to.
+func (s *Server) definition(params TextDocumentPositionParams) []Location {
+	locations := []Location{}
+	doc := s.getDocument(params.TextDocument.URI)
+	if doc == nil {
+		return locations
+	}
+	sc := doc.scalarAt(params.Position)
+	if sc == nil || sc.isKey {
+		return locations
+	}
+	docs, defs := s.findDefinitions(doc, sc)
+	for i := range defs {
+		locations = append(locations, Location{URI: docs[i].uri, Range: defs[i].rng})
+	}
+	return locations
+}
+
+// hover documents the command, parameter or definition at the position.
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	doc := s.getDocument(params.TextDocument.URI)
+	if doc == nil {
+		return nil
+	}
+	sc := doc.scalarAt(params.Position)
+	if sc == nil {
+		return nil
+	}
+
+	var contents string
+	switch {
+	case sc.ref == referenceCommand && !sc.isKey:
+		if info, ok := getCommandInfos()[sc.node.Value]; ok {
+			contents = commandMarkdown(info)
+		}
+	case sc.ref == referenceParam:
+		if info, ok := getCommandInfos()[sc.command]; ok {
+			for _, p := range info.params {
+				if p.name != sc.node.Value {
+					continue
+				}
+				contents = fmt.Sprintf("`%s`: %s", p.name, p.typ)
+				if p.expandable {
+					contents += " (supports expansions)"
+				}
+				contents += fmt.Sprintf("\n\nParameter of [%s](%s)", info.name, commandDocsLink(info.name))
+			}
+		}
+	case !sc.isKey:
+		docs, defs := s.findDefinitions(doc, sc)
+		summaries := make([]string, 0, len(defs))
+		for i := range defs {
+			summaries = append(summaries, describeDefinition(docs[i], defs[i]))
+		}
+		contents = strings.Join(summaries, "\n\n---\n\n")
+	}
+	if contents == "" {
+		return nil
+	}
+
+	rng := sc.rng
+	return &Hover{Contents: MarkupContent{Kind: markdownKind, Value: contents}, Range: &rng}
+}
+
+// describeDefinition summarizes the definition and where it's defined.
+func describeDefinition(doc *document, def definition) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** `%s`\n\nDefined in %s:%d", def.kind, def.name, filepath.Base(doc.path), def.rng.Start.Line+1)
+
+	switch def.kind {
+	case definitionFunction:
+		writeCommandList(&sb, def.node)
+	case definitionTask:
+		if tags := stringList(mappingValue(def.node, "tags")); len(tags) > 0 {
+			fmt.Fprintf(&sb, "\n\nTags: %s", strings.Join(tags, ", "))
+		}
+		writeCommandList(&sb, mappingValue(def.node, "commands"))
+	case definitionTaskGroup:
+		if tasks := stringList(mappingValue(def.node, "tasks")); len(tasks) > 0 {
+			fmt.Fprintf(&sb, "\n\nTasks: %s", strings.Join(tasks, ", "))
+		}
+	case definitionVariant:
+		if displayName := mappingValue(def.node, "display_name"); displayName != nil && displayName.Kind == yaml.ScalarNode {
+			fmt.Fprintf(&sb, "\n\nDisplay name: %s", displayName.Value)
+		}
+		if runOn := stringList(mappingValue(def.node, "run_on")); len(runOn) > 0 {
+			fmt.Fprintf(&sb, "\n\nRuns on: %s", strings.Join(runOn, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// writeCommandList lists the commands and functions that a command block
+// runs.
+func writeCommandList(sb *strings.Builder, block *yaml.Node) {
+	if block == nil {
+		return
+	}
+	cmds := []*yaml.Node{block}
+	if block.Kind == yaml.SequenceNode {
+		cmds = block.Content
+	}
+
+	lines := []string{}
+	for _, cmd := range cmds {
+		if name := mappingValue(cmd, "command"); name != nil {
+			lines = append(lines, fmt.Sprintf("- `%s`", name.Value))
+		} else if name := mappingValue(cmd, "func"); name != nil {
+			lines = append(lines, fmt.Sprintf("- func `%s`", name.Value))
+		}
+	}
+	if len(lines) > 0 {
+		sb.WriteString("\n\nCommands:\n")
+		sb.WriteString(strings.Join(lines, "\n"))
+	}
+}
+
+// mappingValue returns the value for the key if the node is a mapping that
+// contains it.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// stringList returns the values of a scalar or a sequence of scalars.
+func stringList(n *yaml.Node) []string {
+	if n == nil {
+		return nil
+	}
+	if n.Kind == yaml.ScalarNode {
+		return []string{n.Value}
+	}
+	values := []string{}
+	for _, child := range n.Content {
+		if child.Kind == yaml.ScalarNode {
+			values = append(values, child.Value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,332 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+// defaultProjectFiles are the paths, relative to the workspace root, that are
+// checked for the project's main configuration file if the client doesn't
+// specify one.
+var defaultProjectFiles = []string{".evergreen.yml", "evergreen.yml", "etc/evergreen.yml"}
+
+// Server is a language server for Evergreen project configuration files. It
+// speaks the Language Server Protocol over a reader and writer, typically
+// standard input and output.
+type Server struct {
+	conn *conn
+
+	mu           sync.Mutex
+	docs         map[string]*document
+	root         string
+	projectFile  string
+	localModules map[string]string
+	// published tracks the documents that were last published diagnostics
+	// for each validated document, so that stale diagnostics can be
+	// cleared.
+	published    map[string][]string
+	shuttingDown bool
+}
+
+// NewServer returns a language server that reads requests from in and writes
+// responses to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		conn:      newConn(in, out),
+		docs:      map[string]*document{},
+		published: map[string][]string{},
+	}
+}
+
+// Run handles requests until the client sends the exit notification, the
+// input is closed or the context is done.
+func (s *Server) Run(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		body, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "reading message")
+		}
+
+		req := &request{}
+		if err = json.Unmarshal(body, req); err != nil {
+			grip.Error(errors.Wrap(s.conn.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}), "replying to invalid message"))
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, err := s.handle(ctx, req)
+		if req.isNotification() {
+			grip.Error(message.WrapError(err, message.Fields{
+				"message": "handling notification",
+				"method":  req.Method,
+			}))
+			continue
+		}
+		if err = s.conn.reply(req.ID, result, err); err != nil {
+			return errors.Wrapf(err, "replying to '%s'", req.Method)
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, req *request) (interface{}, error) {
+	s.mu.Lock()
+	shuttingDown := s.shuttingDown
+	s.mu.Unlock()
+	if shuttingDown {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		params := InitializeParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(params), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.mu.Lock()
+		s.shuttingDown = true
+		s.mu.Unlock()
+		return nil, nil
+	case "textDocument/didOpen":
+		params := DidOpenTextDocumentParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.updateDocument(ctx, params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		params := DidChangeTextDocumentParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.updateDocument(ctx, params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didSave":
+		params := DidSaveTextDocumentParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		doc := s.docs[params.TextDocument.URI]
+		s.mu.Unlock()
+		if doc == nil {
+			return nil, nil
+		}
+		return nil, s.publishDiagnostics(ctx, doc)
+	case "textDocument/didClose":
+		params := DidCloseTextDocumentParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		delete(s.docs, params.TextDocument.URI)
+		s.mu.Unlock()
+		return nil, nil
+	case "textDocument/completion":
+		params := TextDocumentPositionParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	case "textDocument/hover":
+		params := TextDocumentPositionParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params), nil
+	case "textDocument/definition":
+		params := TextDocumentPositionParams{}
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params), nil
+	default:
+		if req.isNotification() {
+			// Unknown notifications, such as "$/cancelRequest", can be
+			// ignored.
+			return nil, nil
+		}
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) initialize(params InitializeParams) InitializeResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.root = params.RootPath
+	if params.RootURI != "" {
+		s.root = uriToPath(params.RootURI)
+	}
+	if params.InitializationOptions != nil {
+		s.projectFile = params.InitializationOptions.ProjectFile
+		s.localModules = params.InitializationOptions.LocalModules
+	}
+	if s.root != "" {
+		// Included files are read relative to the working directory.
+		grip.Warning(message.WrapError(os.Chdir(s.root), message.Fields{
+			"message": "could not change to the workspace root, so included files may not be found",
+			"root":    s.root,
+		}))
+	}
+	if s.projectFile == "" {
+		for _, candidate := range defaultProjectFiles {
+			if _, err := os.Stat(s.absPath(candidate)); err == nil {
+				s.projectFile = candidate
+				break
+			}
+		}
+	}
+
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync: TextDocumentSyncOptions{
+				OpenClose: true,
+				Change:    TextDocumentSyncFull,
+				Save:      true,
+			},
+			CompletionProvider: CompletionOptions{TriggerCharacters: []string{" ", ":"}},
+			HoverProvider:      true,
+			DefinitionProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "evergreen", Version: evergreen.ClientVersion},
+	}
+}
+
+func (s *Server) updateDocument(ctx context.Context, uri, text string) error {
+	doc := newDocument(uri, uriToPath(uri), text)
+	s.mu.Lock()
+	s.docs[uri] = doc
+	s.mu.Unlock()
+	return s.publishDiagnostics(ctx, doc)
+}
+
+// getDocument returns the open document for the URI.
+func (s *Server) getDocument(uri string) *document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.docs[uri]
+}
+
+// loadDocument returns the document for the file, preferring the open
+// document's contents over the file on disk.
+func (s *Server) loadDocument(path string) (*document, error) {
+	uri := pathToURI(path)
+	if doc := s.getDocument(uri); doc != nil {
+		return doc, nil
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading file '%s'", path)
+	}
+	return newDocument(uri, path, string(contents)), nil
+}
+
+// mainDocument returns the configuration file that the document should be
+// validated as part of. This is the project's main configuration file if it
+// includes the document, or the document itself otherwise.
+func (s *Server) mainDocument(doc *document) *document {
+	s.mu.Lock()
+	projectFile := s.projectFile
+	s.mu.Unlock()
+	if projectFile == "" {
+		return doc
+	}
+
+	mainPath := s.absPath(projectFile)
+	if filepath.Clean(doc.path) == mainPath {
+		return doc
+	}
+	main, err := s.loadDocument(mainPath)
+	if err != nil {
+		return doc
+	}
+	for _, inc := range main.includes {
+		if inc.module == "" && s.absPath(inc.fileName) == filepath.Clean(doc.path) {
+			return main
+		}
+	}
+	return doc
+}
+
+// relatedDocuments returns the document's main configuration file and every
+// file that it includes, including the document itself.
+func (s *Server) relatedDocuments(doc *document) []*document {
+	main := s.mainDocument(doc)
+	docs := []*document{main}
+	for _, inc := range main.includes {
+		path := inc.fileName
+		if inc.module != "" {
+			s.mu.Lock()
+			modulePath, ok := s.localModules[inc.module]
+			s.mu.Unlock()
+			if !ok {
+				continue
+			}
+			path = filepath.Join(modulePath, inc.fileName)
+		}
+		included, err := s.loadDocument(s.absPath(path))
+		if err != nil {
+			continue
+		}
+		docs = append(docs, included)
+	}
+	if main != doc {
+		found := false
+		for _, d := range docs {
+			found = found || d.uri == doc.uri
+		}
+		if !found {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+func (s *Server) absPath(path string) string {
+	if filepath.IsAbs(path) || s.root == "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(s.root, path)
+}
+
+func unmarshalParams(req *request, out interface{}) error {
+	if err := json.Unmarshal(req.Params, out); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
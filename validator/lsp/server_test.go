@@ -0,0 +1,274 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProject = `functions:
+  fetch:
+    - command: git.get_project
+      params:
+        directory: src
+tasks:
+  - name: compile
+    commands:
+      - func: fetch
+      - command: shell.exec
+        params:
+          script: make
+  - name: test
+    depends_on:
+      - name: compile
+    commands:
+      - func: fetch
+buildvariants:
+  - name: ubuntu
+    display_name: Ubuntu
+    run_on:
+      - ubuntu2204-small
+    tasks:
+      - name: compile
+      - name: test
+      - name: missing
+`
+
+// session runs the server over the messages and returns the messages that it
+// wrote.
+func session(t *testing.T, messages ...interface{}) []map[string]interface{} {
+	in := &bytes.Buffer{}
+	for _, msg := range messages {
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		fmt.Fprintf(in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	out := &bytes.Buffer{}
+	require.NoError(t, NewServer(in, out).Run(context.Background()))
+
+	c := newConn(out, nil)
+	var written []map[string]interface{}
+	for {
+		body, err := c.read()
+		if err != nil {
+			break
+		}
+		msg := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(body, &msg))
+		written = append(written, msg)
+	}
+	return written
+}
+
+func call(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func responseTo(t *testing.T, written []map[string]interface{}, id int) map[string]interface{} {
+	for _, msg := range written {
+		if msgID, ok := msg["id"].(float64); ok && int(msgID) == id {
+			return msg
+		}
+	}
+	require.FailNow(t, "missing response", "id %d", id)
+	return nil
+}
+
+func setupWorkspace(t *testing.T) (string, string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, os.Chdir(wd)) })
+
+	root := t.TempDir()
+	path := filepath.Join(root, "evergreen.yml")
+	require.NoError(t, os.WriteFile(path, []byte(testProject), 0644))
+	return root, pathToURI(path)
+}
+
+func position(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func TestServer(t *testing.T) {
+	root, uri := setupWorkspace(t)
+	open := DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, LanguageID: "yaml", Text: testProject}}
+
+	t.Run("Initialize", func(t *testing.T) {
+		written := session(t, call(1, "initialize", InitializeParams{RootURI: pathToURI(root)}), notify("exit", nil))
+		resp := responseTo(t, written, 1)
+		result := resp["result"].(map[string]interface{})
+		capabilities := result["capabilities"].(map[string]interface{})
+		assert.Equal(t, true, capabilities["hoverProvider"])
+		assert.Equal(t, true, capabilities["definitionProvider"])
+	})
+	t.Run("PublishesDiagnosticsOnDefinitions", func(t *testing.T) {
+		written := session(t,
+			call(1, "initialize", InitializeParams{RootURI: pathToURI(root)}),
+			notify("textDocument/didOpen", open),
+			notify("exit", nil),
+		)
+		var diagnostics []interface{}
+		for _, msg := range written {
+			if msg["method"] == "textDocument/publishDiagnostics" {
+				params := msg["params"].(map[string]interface{})
+				assert.Equal(t, uri, params["uri"])
+				diagnostics = params["diagnostics"].([]interface{})
+			}
+		}
+		require.NotEmpty(t, diagnostics)
+		found := false
+		for _, d := range diagnostics {
+			diag := d.(map[string]interface{})
+			if strings.Contains(diag["message"].(string), "'missing'") {
+				found = true
+				assert.EqualValues(t, SeverityError, diag["severity"])
+				assert.Equal(t, diagnosticSource, diag["source"])
+			}
+		}
+		assert.True(t, found, "should report the undefined task")
+	})
+	t.Run("PublishesParseErrors", func(t *testing.T) {
+		invalid := DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Text: "tasks:\n  - name: [\n"}}
+		written := session(t,
+			call(1, "initialize", InitializeParams{RootURI: pathToURI(root)}),
+			notify("textDocument/didOpen", invalid),
+			notify("exit", nil),
+		)
+		require.Len(t, written, 2)
+		params := written[1]["params"].(map[string]interface{})
+		diagnostics := params["diagnostics"].([]interface{})
+		require.Len(t, diagnostics, 1)
+		assert.EqualValues(t, SeverityError, diagnostics[0].(map[string]interface{})["severity"])
+	})
+	t.Run("Definition", func(t *testing.T) {
+		written := session(t,
+			call(1, "initialize", InitializeParams{RootURI: pathToURI(root)}),
+			notify("textDocument/didOpen", open),
+			// The "fetch" in "- func: fetch" of the compile task.
+			call(2, "textDocument/definition", position(uri, 8, 16)),
+			// The "compile" in the test task's dependency.
+			call(3, "textDocument/definition", position(uri, 14, 16)),
+			notify("exit", nil),
+		)
+		locations := responseTo(t, written, 2)["result"].([]interface{})
+		require.Len(t, locations, 1)
+		loc := locations[0].(map[string]interface{})
+		assert.Equal(t, uri, loc["uri"])
+		assert.EqualValues(t, 1, loc["range"].(map[string]interface{})["start"].(map[string]interface{})["line"])
+
+		locations = responseTo(t, written, 3)["result"].([]interface{})
+		require.Len(t, locations, 1)
+		loc = locations[0].(map[string]interface{})
+		assert.EqualValues(t, 6, loc["range"].(map[string]interface{})["start"].(map[string]interface{})["line"])
+	})
+	t.Run("Hover", func(t *testing.T) {
+		written := session(t,
+			call(1, "initialize", InitializeParams{RootURI: pathToURI(root)}),
+			notify("textDocument/didOpen", open),
+			call(2, "textDocument/hover", position(uri, 9, 20)),
+			call(3, "textDocument/hover", position(uri, 11, 12)),
+			call(4, "textDocument/hover", position(uri, 8, 16)),
+			call(5, "textDocument/hover", position(uri, 0, 0)),
+			notify("exit", nil),
+		)
+		contents := func(id int) string {
+			result := responseTo(t, written, id)["result"].(map[string]interface{})
+			return result["contents"].(map[string]interface{})["value"].(string)
+		}
+		assert.Contains(t, contents(2), "**shell.exec**")
+		assert.Contains(t, contents(3), "`script`: string")
+		assert.Contains(t, contents(4), "git.get_project")
+		assert.Nil(t, responseTo(t, written, 5)["result"])
+	})
+	t.Run("Completion", func(t *testing.T) {
+		text := testProject + "  - name: lint\n    commands:\n      - command: \n      - func: \n      - command: shell.exec\n        params:\n          \n"
+		edit := DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Text: text}}
+		lines := strings.Count(testProject, "\n")
+		written := session(t,
+			call(1, "initialize", InitializeParams{RootURI: pathToURI(root)}),
+			notify("textDocument/didOpen", edit),
+			call(2, "textDocument/completion", position(uri, lines+2, 17)),
+			call(3, "textDocument/completion", position(uri, lines+3, 14)),
+			call(4, "textDocument/completion", position(uri, lines+6, 10)),
+			notify("exit", nil),
+		)
+		labels := func(id int) []string {
+			result := responseTo(t, written, id)["result"].(map[string]interface{})
+			var labels []string
+			for _, item := range result["items"].([]interface{}) {
+				labels = append(labels, item.(map[string]interface{})["label"].(string))
+			}
+			return labels
+		}
+		assert.Contains(t, labels(2), "shell.exec")
+		assert.Contains(t, labels(2), "s3.put")
+		assert.Equal(t, []string{"fetch"}, labels(3))
+		assert.Contains(t, labels(4), "script")
+		assert.Contains(t, labels(4), "working_dir")
+	})
+	t.Run("RejectsRequestsAfterShutdown", func(t *testing.T) {
+		written := session(t,
+			call(1, "initialize", InitializeParams{RootURI: pathToURI(root)}),
+			call(2, "shutdown", nil),
+			call(3, "textDocument/hover", position(uri, 0, 0)),
+			notify("exit", nil),
+		)
+		assert.Nil(t, responseTo(t, written, 2)["error"])
+		respErr := responseTo(t, written, 3)["error"].(map[string]interface{})
+		assert.EqualValues(t, codeInvalidRequest, respErr["code"])
+	})
+	t.Run("UnknownMethod", func(t *testing.T) {
+		written := session(t, call(1, "workspace/symbol", nil), notify("exit", nil))
+		respErr := responseTo(t, written, 1)["error"].(map[string]interface{})
+		assert.EqualValues(t, codeMethodNotFound, respErr["code"])
+	})
+}
+
+func TestIncludedFiles(t *testing.T) {
+	root, _ := setupWorkspace(t)
+	mainPath := filepath.Join(root, "main.yml")
+	includedPath := filepath.Join(root, "included.yml")
+	require.NoError(t, os.WriteFile(mainPath, []byte("include:\n  - filename: included.yml\nbuildvariants:\n  - name: bv\n    run_on: [d]\n    tasks:\n      - name: t\n"), 0644))
+	included := "functions:\n  f:\n    command: shell.exec\ntasks:\n  - name: t\n    commands:\n      - func: f\n"
+	require.NoError(t, os.WriteFile(includedPath, []byte(included), 0644))
+
+	written := session(t,
+		call(1, "initialize", InitializeParams{RootURI: pathToURI(root), InitializationOptions: &InitializationOptions{ProjectFile: "main.yml"}}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: pathToURI(mainPath), Text: mustReadFile(t, mainPath)}}),
+		call(2, "textDocument/definition", position(pathToURI(mainPath), 6, 14)),
+		notify("exit", nil),
+	)
+	locations := responseTo(t, written, 2)["result"].([]interface{})
+	require.Len(t, locations, 1)
+	assert.Equal(t, pathToURI(includedPath), locations[0].(map[string]interface{})["uri"])
+
+	published := map[string]bool{}
+	for _, msg := range written {
+		if msg["method"] == "textDocument/publishDiagnostics" {
+			published[msg["params"].(map[string]interface{})["uri"].(string)] = true
+		}
+	}
+	assert.True(t, published[pathToURI(mainPath)])
+	assert.True(t, published[pathToURI(includedPath)])
+}
+
+func mustReadFile(t *testing.T, path string) string {
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(contents)
+}
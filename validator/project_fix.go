@@ -0,0 +1,548 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/evergreen-ci/evergreen/agent/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// The canonical order of the keys in each part of the project configuration.
+// Keys that are not listed keep their relative order and are placed before
+// the listed keys, which keeps YAML anchors defined in unrecognized top-level
+// sections (e.g. "variables") before the aliases that refer to them.
+var (
+	projectKeyOrder = []string{
+		"include",
+		"identifier",
+		"display_name",
+		"enabled",
+		"owner",
+		"repo",
+		"remote_path",
+		"branch",
+		"command_type",
+		"stepback",
+		"pre_error_fails_task",
+		"post_error_fails_task",
+		"oom_tracker",
+		"batchtime",
+		"exec_timeout_secs",
+		"callback_timeout_secs",
+		"ignore",
+		"parameters",
+		"loggers",
+		"modules",
+		"containers",
+		"pre",
+		"post",
+		"timeout",
+		"early_termination",
+		"functions",
+		"tasks",
+		"task_groups",
+		"axes",
+		"buildvariants",
+	}
+	taskKeyOrder = []string{
+		"name",
+		"tags",
+		"run_on",
+		"depends_on",
+		"priority",
+		"exec_timeout_secs",
+		"patchable",
+		"patch_only",
+		"disable",
+		"allow_for_git_tag",
+		"git_tag_only",
+		"stepback",
+		"must_have_test_results",
+		"retry",
+		"matrix",
+		"shard",
+		"commands",
+	}
+	taskGroupKeyOrder = []string{
+		"name",
+		"tags",
+		"max_hosts",
+		"share_processes",
+		"priority",
+		"depends_on",
+		"patchable",
+		"patch_only",
+		"allow_for_git_tag",
+		"git_tag_only",
+		"exec_timeout_secs",
+		"stepback",
+		"setup_group_can_fail_task",
+		"setup_group_timeout_secs",
+		"teardown_task_can_fail_task",
+		"setup_group",
+		"setup_task",
+		"tasks",
+		"teardown_task",
+		"teardown_group",
+		"timeout",
+	}
+	variantKeyOrder = []string{
+		"name",
+		"display_name",
+		"tags",
+		"run_on",
+		"modules",
+		"expansions",
+		"batchtime",
+		"cron",
+		"activate",
+		"disable",
+		"patchable",
+		"patch_only",
+		"allow_for_git_tag",
+		"git_tag_only",
+		"stepback",
+		"depends_on",
+		"matrix_name",
+		"matrix_spec",
+		"exclude_spec",
+		"rules",
+		"tasks",
+		"display_tasks",
+	}
+	variantTaskKeyOrder = []string{
+		"name",
+		"run_on",
+		"distros",
+		"depends_on",
+		"priority",
+		"exec_timeout_secs",
+		"batchtime",
+		"cron",
+		"activate",
+		"patchable",
+		"patch_only",
+		"disable",
+		"allow_for_git_tag",
+		"git_tag_only",
+		"stepback",
+		"retry",
+		"commit_queue_merge",
+		"task_group",
+	}
+	commandKeyOrder = []string{
+		"func",
+		"command",
+		"type",
+		"display_name",
+		"timeout_secs",
+		"variants",
+		"vars",
+		"params",
+		"loggers",
+	}
+)
+
+// projectYAMLCommandBlocks are the keys of the command blocks at the top level
+// of the project configuration.
+var projectYAMLCommandBlocks = []string{"pre", "post", "timeout", "early_termination"}
+
+// taskGroupYAMLCommandBlocks are the keys of the command blocks in a task
+// group.
+var taskGroupYAMLCommandBlocks = []string{"setup_group", "setup_task", "teardown_task", "teardown_group", "timeout"}
+
+// FormatProjectYAML rewrites the project configuration YAML with its keys in
+// canonical order and consistent indentation. Comments, anchors and aliases are
+// preserved, and the order of list items such as tasks and build variants is
+// not changed.
+func FormatProjectYAML(data []byte) ([]byte, error) {
+	doc, err := parseProjectYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	formatProjectNode(doc)
+	return encodeProjectYAML(doc)
+}
+
+// FixProjectYAML fixes issues in the project configuration YAML that can be
+// corrected without changing how the project runs and formats the result. It
+// replaces deprecated commands and fields with their replacements and removes
+// the functions and tasks that the given project, which should be the project
+// loaded from the same YAML along with its includes, never uses. It returns
+// the fixed YAML and a description of each fix.
+func FixProjectYAML(data []byte, project *model.Project) ([]byte, []string, error) {
+	doc, err := parseProjectYAML(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := doc.Content[0]
+
+	fixes := []string{}
+	forEachCommandList(root, func(location string, cmds *yaml.Node) {
+		fixes = append(fixes, fixDeprecatedCommands(location, cmds)...)
+	})
+	fixes = append(fixes, fixDeprecatedVariantFields(root)...)
+	if project != nil {
+		fixes = append(fixes, removeUnusedFunctions(root, findUnusedFunctions(project))...)
+		fixes = append(fixes, removeUnusedTasks(root, findUnusedTasks(project))...)
+	}
+
+	formatProjectNode(doc)
+	out, err := encodeProjectYAML(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, fixes, nil
+}
+
+func parseProjectYAML(data []byte) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, errors.Wrap(err, "parsing project YAML")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("project YAML must be a mapping")
+	}
+	return doc, nil
+}
+
+func encodeProjectYAML(doc *yaml.Node) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, errors.Wrap(err, "encoding project YAML")
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errors.Wrap(err, "encoding project YAML")
+	}
+	return buf.Bytes(), nil
+}
+
+func formatProjectNode(doc *yaml.Node) {
+	root := doc.Content[0]
+	sortMappingKeys(root, projectKeyOrder)
+
+	if functions := mappingValue(root, "functions"); functions != nil && functions.Kind == yaml.MappingNode {
+		names := []string{}
+		for i := 0; i < len(functions.Content)-1; i += 2 {
+			names = append(names, functions.Content[i].Value)
+		}
+		sort.Strings(names)
+		sortMappingKeys(functions, names)
+	}
+	forEachCommandList(root, func(_ string, cmds *yaml.Node) {
+		forEachMapping(cmds, func(cmd *yaml.Node) {
+			sortMappingKeys(cmd, commandKeyOrder)
+		})
+	})
+	forEachMapping(mappingValue(root, "tasks"), func(t *yaml.Node) {
+		sortMappingKeys(t, taskKeyOrder)
+	})
+	forEachMapping(mappingValue(root, "task_groups"), func(tg *yaml.Node) {
+		sortMappingKeys(tg, taskGroupKeyOrder)
+	})
+	forEachMapping(mappingValue(root, "buildvariants"), func(bv *yaml.Node) {
+		sortMappingKeys(bv, variantKeyOrder)
+		forEachMapping(mappingValue(bv, "tasks"), func(bvt *yaml.Node) {
+			sortMappingKeys(bvt, variantTaskKeyOrder)
+			if tg := mappingValue(bvt, "task_group"); tg != nil && tg.Kind == yaml.MappingNode {
+				sortMappingKeys(tg, taskGroupKeyOrder)
+			}
+		})
+	})
+}
+
+// forEachCommandList calls fn on each list of commands in the project
+// configuration along with a description of where it is defined.
+func forEachCommandList(root *yaml.Node, fn func(location string, cmds *yaml.Node)) {
+	call := func(location string, cmds *yaml.Node) {
+		if cmds != nil && (cmds.Kind == yaml.SequenceNode || cmds.Kind == yaml.MappingNode) {
+			fn(location, cmds)
+		}
+	}
+	forEachTaskGroupBlock := func(name string, tg *yaml.Node) {
+		for _, block := range taskGroupYAMLCommandBlocks {
+			call(fmt.Sprintf("%s of task group '%s'", block, name), mappingValue(tg, block))
+		}
+	}
+
+	for _, block := range projectYAMLCommandBlocks {
+		call(block, mappingValue(root, block))
+	}
+	if functions := mappingValue(root, "functions"); functions != nil && functions.Kind == yaml.MappingNode {
+		for i := 0; i < len(functions.Content)-1; i += 2 {
+			call(fmt.Sprintf("function '%s'", functions.Content[i].Value), functions.Content[i+1])
+		}
+	}
+	forEachMapping(mappingValue(root, "tasks"), func(t *yaml.Node) {
+		call(fmt.Sprintf("task '%s'", scalarValue(t, "name")), mappingValue(t, "commands"))
+	})
+	forEachMapping(mappingValue(root, "task_groups"), func(tg *yaml.Node) {
+		forEachTaskGroupBlock(scalarValue(tg, "name"), tg)
+	})
+	forEachMapping(mappingValue(root, "buildvariants"), func(bv *yaml.Node) {
+		forEachMapping(mappingValue(bv, "tasks"), func(bvt *yaml.Node) {
+			if tg := mappingValue(bvt, "task_group"); tg != nil && tg.Kind == yaml.MappingNode {
+				forEachTaskGroupBlock(scalarValue(bvt, "name"), tg)
+			}
+		})
+	})
+}
+
+// fixDeprecatedCommands replaces deprecated commands in the list of commands
+// and removes the ones that have no effect.
+func fixDeprecatedCommands(location string, cmds *yaml.Node) []string {
+	fixes := []string{}
+	if cmds.Kind == yaml.MappingNode {
+		// A command block with a single command can't be left empty, so the
+		// command can only be renamed.
+		if fix := renameDeprecatedCommand(location, cmds); fix != "" {
+			fixes = append(fixes, fix)
+		}
+		return fixes
+	}
+
+	kept := make([]*yaml.Node, 0, len(cmds.Content))
+	for _, cmd := range cmds.Content {
+		name := scalarValue(cmd, "command")
+		replacement, ok := command.DeprecatedCommands[name]
+		if ok && replacement == "" && !definesAnchor(cmd) {
+			fixes = append(fixes, fmt.Sprintf("removed deprecated command '%s' from %s", name, location))
+			continue
+		}
+		if fix := renameDeprecatedCommand(location, cmd); fix != "" {
+			fixes = append(fixes, fix)
+		}
+		kept = append(kept, cmd)
+	}
+	if len(kept) == 0 {
+		// Removing every command would leave an empty block, which is
+		// invalid, so leave the block as it is.
+		return nil
+	}
+	cmds.Content = kept
+	return fixes
+}
+
+func renameDeprecatedCommand(location string, cmd *yaml.Node) string {
+	name := scalarValue(cmd, "command")
+	replacement := command.DeprecatedCommands[name]
+	if replacement == "" {
+		return ""
+	}
+	mappingValue(cmd, "command").Value = replacement
+	return fmt.Sprintf("replaced deprecated command '%s' with '%s' in %s", name, replacement, location)
+}
+
+// fixDeprecatedVariantFields renames the deprecated "distros" field of build
+// variant tasks to "run_on".
+func fixDeprecatedVariantFields(root *yaml.Node) []string {
+	fixes := []string{}
+	forEachMapping(mappingValue(root, "buildvariants"), func(bv *yaml.Node) {
+		forEachMapping(mappingValue(bv, "tasks"), func(bvt *yaml.Node) {
+			if mappingValue(bvt, "run_on") != nil {
+				return
+			}
+			for i := 0; i < len(bvt.Content)-1; i += 2 {
+				if bvt.Content[i].Value == "distros" {
+					bvt.Content[i].Value = "run_on"
+					fixes = append(fixes, fmt.Sprintf("renamed deprecated field 'distros' to 'run_on' for task '%s' in build variant '%s'",
+						scalarValue(bvt, "name"), scalarValue(bv, "name")))
+				}
+			}
+		})
+	})
+	return fixes
+}
+
+// removeUnusedFunctions removes the definitions of the given functions.
+// Definitions that define anchors are kept, since other parts of the
+// configuration may refer to them.
+func removeUnusedFunctions(root *yaml.Node, unused []string) []string {
+	functions := mappingValue(root, "functions")
+	if functions == nil || functions.Kind != yaml.MappingNode || len(unused) == 0 {
+		return nil
+	}
+	unusedSet := map[string]bool{}
+	for _, name := range unused {
+		unusedSet[name] = true
+	}
+
+	fixes := []string{}
+	kept := make([]*yaml.Node, 0, len(functions.Content))
+	for i := 0; i < len(functions.Content)-1; i += 2 {
+		key, value := functions.Content[i], functions.Content[i+1]
+		if unusedSet[key.Value] && !definesAnchor(key) && !definesAnchor(value) {
+			fixes = append(fixes, fmt.Sprintf("removed unused function '%s'", key.Value))
+			continue
+		}
+		kept = append(kept, key, value)
+	}
+	functions.Content = kept
+	return fixes
+}
+
+// removeUnusedTasks removes the definitions of the given tasks. Definitions
+// that define anchors are kept, since other parts of the configuration may
+// refer to them.
+func removeUnusedTasks(root *yaml.Node, unused []string) []string {
+	tasks := mappingValue(root, "tasks")
+	if tasks == nil || tasks.Kind != yaml.SequenceNode || len(unused) == 0 {
+		return nil
+	}
+	unusedSet := map[string]bool{}
+	for _, name := range unused {
+		unusedSet[name] = true
+	}
+
+	fixes := []string{}
+	kept := make([]*yaml.Node, 0, len(tasks.Content))
+	for _, t := range tasks.Content {
+		name := scalarValue(t, "name")
+		if t.Kind == yaml.MappingNode && unusedSet[name] && !definesAnchor(t) {
+			fixes = append(fixes, fmt.Sprintf("removed unused task '%s'", name))
+			continue
+		}
+		kept = append(kept, t)
+	}
+	tasks.Content = kept
+	return fixes
+}
+
+// sortMappingKeys orders the keys of the mapping so that the given keys come
+// last in the given order. The mapping is left unchanged if the new order would
+// put an alias before the anchor that it refers to.
+func sortMappingKeys(n *yaml.Node, order []string) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return
+	}
+	rank := map[string]int{}
+	for i, key := range order {
+		rank[key] = i + 1
+	}
+	type entry struct {
+		key, value *yaml.Node
+		rank       int
+	}
+	entries := make([]entry, 0, len(n.Content)/2)
+	for i := 0; i < len(n.Content)-1; i += 2 {
+		entries = append(entries, entry{key: n.Content[i], value: n.Content[i+1], rank: rank[n.Content[i].Value]})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].rank < entries[j].rank })
+
+	defined := map[string]bool{}
+	content := make([]*yaml.Node, 0, len(n.Content))
+	for _, e := range entries {
+		for _, anchor := range collectAnchors(e.key, e.value) {
+			defined[anchor] = true
+		}
+		for _, alias := range collectAliases(e.key, e.value) {
+			if !defined[alias] && mappingDefinesAnchor(n, alias) {
+				return
+			}
+		}
+		content = append(content, e.key, e.value)
+	}
+	n.Content = content
+}
+
+// mappingDefinesAnchor returns whether any of the mapping's entries define the
+// anchor.
+func mappingDefinesAnchor(n *yaml.Node, anchor string) bool {
+	for _, a := range collectAnchors(n.Content...) {
+		if a == anchor {
+			return true
+		}
+	}
+	return false
+}
+
+func collectAnchors(nodes ...*yaml.Node) []string {
+	anchors := []string{}
+	for _, n := range nodes {
+		walkYAML(n, func(n *yaml.Node) {
+			if n.Anchor != "" {
+				anchors = append(anchors, n.Anchor)
+			}
+		})
+	}
+	return anchors
+}
+
+func collectAliases(nodes ...*yaml.Node) []string {
+	aliases := []string{}
+	for _, n := range nodes {
+		walkYAML(n, func(n *yaml.Node) {
+			if n.Kind == yaml.AliasNode {
+				aliases = append(aliases, n.Value)
+			}
+		})
+	}
+	return aliases
+}
+
+func definesAnchor(n *yaml.Node) bool {
+	return len(collectAnchors(n)) > 0
+}
+
+// walkYAML calls fn on the node and all of its descendants without following
+// aliases.
+func walkYAML(n *yaml.Node, fn func(*yaml.Node)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	if n.Kind == yaml.AliasNode {
+		return
+	}
+	for _, child := range n.Content {
+		walkYAML(child, fn)
+	}
+}
+
+// mappingValue returns the value of the key in the mapping, or nil if the node
+// is not a mapping or does not contain the key.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(n.Content)-1; i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarValue returns the value of the key in the mapping if it is a scalar.
+func scalarValue(n *yaml.Node, key string) string {
+	v := mappingValue(n, key)
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return v.Value
+}
+
+// forEachMapping calls fn on each mapping in the sequence.
+func forEachMapping(n *yaml.Node, fn func(*yaml.Node)) {
+	if n == nil {
+		return
+	}
+	if n.Kind == yaml.MappingNode {
+		fn(n)
+		return
+	}
+	if n.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range n.Content {
+		if item.Kind == yaml.MappingNode {
+			fn(item)
+		}
+	}
+}
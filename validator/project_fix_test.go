@@ -0,0 +1,229 @@
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatProjectYAML(t *testing.T) {
+	t.Run("OrdersKeys", func(t *testing.T) {
+		in := `
+buildvariants:
+- tasks:
+  - name: compile
+    distros: [ubuntu]
+  display_name: Ubuntu
+  name: ubuntu
+tasks:
+- commands:
+  - params:
+      script: echo hi
+    command: shell.exec
+  name: compile
+# the functions
+functions:
+  z-func:
+    command: shell.exec
+  a-func:
+    command: shell.exec
+exec_timeout_secs: 100
+`
+		out, err := FormatProjectYAML([]byte(in))
+		require.NoError(t, err)
+		expected := `exec_timeout_secs: 100
+# the functions
+functions:
+  a-func:
+    command: shell.exec
+  z-func:
+    command: shell.exec
+tasks:
+  - name: compile
+    commands:
+      - command: shell.exec
+        params:
+          script: echo hi
+buildvariants:
+  - name: ubuntu
+    display_name: Ubuntu
+    tasks:
+      - name: compile
+        distros: [ubuntu]
+`
+		assert.Equal(t, expected, string(out))
+
+		again, err := FormatProjectYAML(out)
+		require.NoError(t, err)
+		assert.Equal(t, string(out), string(again), "formatting should be idempotent")
+	})
+	t.Run("KeepsAnchorsBeforeAliases", func(t *testing.T) {
+		in := `
+buildvariants:
+- name: ubuntu
+  run_on: &distros [ubuntu]
+  tasks: [compile]
+tasks:
+- name: compile
+  run_on: *distros
+  commands:
+  - command: shell.exec
+`
+		out, err := FormatProjectYAML([]byte(in))
+		require.NoError(t, err)
+		assert.Less(t, strings.Index(string(out), "&distros"), strings.Index(string(out), "*distros"))
+
+		p := &model.Project{}
+		_, err = model.LoadProjectInto(context.Background(), out, nil, "", p)
+		require.NoError(t, err)
+		require.Len(t, p.Tasks, 1)
+		assert.Equal(t, []string{"ubuntu"}, p.Tasks[0].RunOn)
+	})
+	t.Run("RejectsNonMapping", func(t *testing.T) {
+		_, err := FormatProjectYAML([]byte("- a\n- b\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestFixProjectYAML(t *testing.T) {
+	in := `
+functions:
+  used:
+    command: shell.exec
+  unused:
+    command: shell.exec
+  anchored: &anchored
+    command: shell.exec
+tasks:
+- name: compile
+  commands:
+  - command: git.get_project
+  - command: git.apply_patch
+  - command: manifest.load
+  - func: used
+- name: only-deprecated
+  commands:
+  - command: manifest.load
+- name: dead
+  commands:
+  - func: used
+- name: grouped
+  commands:
+  - func: used
+task_groups:
+- name: group
+  tasks: [grouped]
+buildvariants:
+- name: ubuntu
+  tasks:
+  - name: compile
+    distros: [ubuntu]
+  - name: only-deprecated
+`
+	p := &model.Project{}
+	_, err := model.LoadProjectInto(context.Background(), []byte(in), nil, "", p)
+	require.NoError(t, err)
+
+	out, fixes, err := FixProjectYAML([]byte(in), p)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"removed deprecated command 'git.apply_patch' from task 'compile'",
+		"removed deprecated command 'manifest.load' from task 'compile'",
+		"renamed deprecated field 'distros' to 'run_on' for task 'compile' in build variant 'ubuntu'",
+		"removed unused function 'unused'",
+		"removed unused task 'dead'",
+	}, fixes)
+
+	fixed := &model.Project{}
+	_, err = model.LoadProjectInto(context.Background(), out, nil, "", fixed)
+	require.NoError(t, err)
+	assert.Len(t, fixed.Functions, 2)
+	assert.NotNil(t, fixed.Functions["anchored"], "functions with anchors should be kept")
+	require.NotNil(t, fixed.FindProjectTask("compile"))
+	assert.Len(t, fixed.FindProjectTask("compile").Commands, 2)
+	require.NotNil(t, fixed.FindProjectTask("only-deprecated"))
+	assert.Len(t, fixed.FindProjectTask("only-deprecated").Commands, 1, "blocks should not be left empty")
+	assert.Nil(t, fixed.FindProjectTask("dead"))
+	assert.NotNil(t, fixed.FindProjectTask("grouped"))
+	assert.Equal(t, []string{"ubuntu"}, fixed.BuildVariants[0].Tasks[0].RunOn)
+
+	t.Run("GenerateTasksKeepsDefinitions", func(t *testing.T) {
+		in := `
+functions:
+  unused:
+    command: shell.exec
+tasks:
+- name: generator
+  commands:
+  - command: generate.tasks
+    params:
+      files: [tasks.json]
+- name: generated-later
+  commands:
+  - func: unused
+buildvariants:
+- name: ubuntu
+  tasks: [generator]
+`
+		p := &model.Project{}
+		_, err := model.LoadProjectInto(context.Background(), []byte(in), nil, "", p)
+		require.NoError(t, err)
+		_, fixes, err := FixProjectYAML([]byte(in), p)
+		require.NoError(t, err)
+		assert.Empty(t, fixes)
+	})
+}
+
+func TestCheckUnusedDefinitions(t *testing.T) {
+	p := &model.Project{
+		Functions: map[string]*model.YAMLCommandSet{
+			"used":   {SingleCommand: &model.PluginCommandConf{Command: "shell.exec"}},
+			"unused": {SingleCommand: &model.PluginCommandConf{Command: "shell.exec"}},
+		},
+		Tasks: []model.ProjectTask{
+			{Name: "t1", Commands: []model.PluginCommandConf{{Function: "used"}}},
+			{Name: "t2"},
+		},
+		BuildVariants: []model.BuildVariant{
+			{Name: "bv", Tasks: []model.BuildVariantTaskUnit{{Name: "t1", Variant: "bv"}}},
+		},
+	}
+	errs := checkUnusedDefinitions(p)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "function 'unused' is never called", errs[0].Message)
+	assert.Equal(t, "task 't2' is not listed in any buildvariant or task group", errs[1].Message)
+}
+
+func TestCheckDeadBuildVariants(t *testing.T) {
+	disabled := true
+	p := &model.Project{
+		Tasks: []model.ProjectTask{{Name: "t1"}, {Name: "t2", Disable: &disabled}},
+		BuildVariants: []model.BuildVariant{
+			{Name: "live", Tasks: []model.BuildVariantTaskUnit{{Name: "t1", Variant: "live"}, {Name: "t2", Variant: "live"}}},
+			{Name: "disabled-tasks", Tasks: []model.BuildVariantTaskUnit{{Name: "t2", Variant: "disabled-tasks"}}},
+			{Name: "disabled", Disable: &disabled, Tasks: []model.BuildVariantTaskUnit{{Name: "t1", Variant: "disabled"}}},
+			{Name: "empty"},
+		},
+	}
+	errs := checkDeadBuildVariants(p)
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Message, "'disabled-tasks'")
+	assert.Contains(t, errs[1].Message, "'disabled' is disabled")
+}
+
+func TestCheckDeprecatedCommands(t *testing.T) {
+	p := &model.Project{
+		Pre: &model.YAMLCommandSet{SingleCommand: &model.PluginCommandConf{Command: "git.apply_patch"}},
+		Tasks: []model.ProjectTask{
+			{Name: "t1", Commands: []model.PluginCommandConf{{Command: "git.apply_patch"}, {Command: "shell.exec"}}},
+		},
+	}
+	errs := checkDeprecatedCommands(p)
+	require.Len(t, errs, 1)
+	assert.Equal(t, Warning, errs[0].Level)
+	assert.Contains(t, errs[0].Message, "git.apply_patch")
+}
@@ -0,0 +1,342 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/agent"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/evergreen-ci/utility"
+)
+
+// policyTarget is a single part of the project configuration that a policy is
+// evaluated against, along with a description of where it is defined.
+type policyTarget struct {
+	location string
+	vars     map[string]interface{}
+}
+
+// validateProjectPolicies checks the project configuration against the
+// admin-defined project policies.
+func validateProjectPolicies(_ context.Context, settings *evergreen.Settings, project *model.Project, ref *model.ProjectRef, _ bool) ValidationErrors {
+	return CheckProjectPolicies(settings, project, ref)
+}
+
+// CheckProjectPolicies checks the project configuration against the
+// admin-defined project policies. Policies with block severity are reported
+// as errors and policies with warn severity are reported as warnings. If the
+// project ref is nil, only the policies that apply to all projects are
+// checked.
+func CheckProjectPolicies(settings *evergreen.Settings, project *model.Project, ref *model.ProjectRef) ValidationErrors {
+	if settings == nil || project == nil || len(settings.ProjectPolicies.Policies) == 0 {
+		return nil
+	}
+
+	var id, identifier string
+	if ref != nil {
+		id, identifier = ref.Id, ref.Identifier
+	}
+
+	errs := ValidationErrors{}
+	targets := map[evergreen.ProjectPolicyTarget][]policyTarget{}
+	for _, policy := range settings.ProjectPolicies.Policies {
+		if ref == nil && len(policy.Projects) > 0 {
+			continue
+		}
+		if !policy.AppliesToProject(id, identifier) {
+			continue
+		}
+		if _, ok := targets[policy.Target]; !ok {
+			targets[policy.Target] = getPolicyTargets(project, identifier, policy.Target)
+		}
+		errs = append(errs, checkProjectPolicy(policy, targets[policy.Target])...)
+	}
+
+	return errs
+}
+
+// checkProjectPolicy evaluates the policy against each target. A policy that
+// can't be evaluated is reported at the policy's severity, so that a broken
+// blocking policy doesn't silently let violations through.
+func checkProjectPolicy(policy evergreen.ProjectPolicy, targets []policyTarget) ValidationErrors {
+	level := Warning
+	if policy.Severity == evergreen.ProjectPolicySeverityBlock {
+		level = Error
+	}
+
+	var when *util.PolicyExpression
+	var err error
+	if policy.When != "" {
+		when, err = util.CompilePolicyExpression(policy.When)
+		if err != nil {
+			return ValidationErrors{{
+				Level:   level,
+				Message: fmt.Sprintf("policy '%s' could not be checked: %s", policy.Name, err.Error()),
+			}}
+		}
+	}
+	condition, err := util.CompilePolicyExpression(policy.Condition)
+	if err != nil {
+		return ValidationErrors{{
+			Level:   level,
+			Message: fmt.Sprintf("policy '%s' could not be checked: %s", policy.Name, err.Error()),
+		}}
+	}
+
+	errs := ValidationErrors{}
+	for _, target := range targets {
+		if when != nil {
+			applies, err := when.EvalBool(target.vars)
+			if err != nil {
+				errs = append(errs, ValidationError{
+					Level:   level,
+					Message: fmt.Sprintf("policy '%s' could not be checked for %s: %s", policy.Name, target.location, err.Error()),
+				})
+				continue
+			}
+			if !applies {
+				continue
+			}
+		}
+
+		ok, err := condition.EvalBool(target.vars)
+		if err != nil {
+			errs = append(errs, ValidationError{
+				Level:   level,
+				Message: fmt.Sprintf("policy '%s' could not be checked for %s: %s", policy.Name, target.location, err.Error()),
+			})
+			continue
+		}
+		if ok {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s violates policy '%s'", target.location, policy.Name)
+		if policy.Description != "" {
+			msg = fmt.Sprintf("%s: %s", msg, policy.Description)
+		}
+		errs = append(errs, ValidationError{Level: level, Message: msg})
+	}
+
+	return errs
+}
+
+// getPolicyTargets returns the parts of the project configuration that a
+// policy with the given target is evaluated against.
+func getPolicyTargets(project *model.Project, identifier string, target evergreen.ProjectPolicyTarget) []policyTarget {
+	switch target {
+	case evergreen.ProjectPolicyTargetProject:
+		return []policyTarget{getProjectPolicyTarget(project, identifier)}
+	case evergreen.ProjectPolicyTargetVariant:
+		return getVariantPolicyTargets(project)
+	case evergreen.ProjectPolicyTargetTask:
+		return getTaskPolicyTargets(project)
+	case evergreen.ProjectPolicyTargetCommand:
+		return getCommandPolicyTargets(project)
+	default:
+		return nil
+	}
+}
+
+func getProjectPolicyTarget(project *model.Project, identifier string) policyTarget {
+	if identifier == "" {
+		identifier = project.Identifier
+	}
+	modules := []string{}
+	for _, m := range project.Modules {
+		modules = append(modules, m.Name)
+	}
+	return policyTarget{
+		location: "project",
+		vars: map[string]interface{}{
+			"identifier":            identifier,
+			"display_name":          project.DisplayName,
+			"exec_timeout_secs":     project.ExecTimeoutSecs,
+			"callback_timeout_secs": project.CallbackTimeout,
+			"stepback":              project.Stepback,
+			"batchtime":             project.BatchTime,
+			"command_type":          project.CommandType,
+			"oom_tracker":           project.OomTracker,
+			"pre_error_fails_task":  project.PreErrorFailsTask,
+			"post_error_fails_task": project.PostErrorFailsTask,
+			"modules":               modules,
+			"num_tasks":             len(project.Tasks),
+			"num_variants":          len(project.BuildVariants),
+		},
+	}
+}
+
+func getVariantPolicyTargets(project *model.Project) []policyTarget {
+	targets := []policyTarget{}
+	for _, bv := range project.BuildVariants {
+		tasks := []string{}
+		for _, t := range bv.Tasks {
+			tasks = append(tasks, t.Name)
+		}
+		vars := map[string]interface{}{
+			"name":              bv.Name,
+			"display_name":      bv.DisplayName,
+			"run_on":            bv.RunOn,
+			"tags":              bv.Tags,
+			"modules":           bv.Modules,
+			"expansions":        bv.Expansions,
+			"tasks":             tasks,
+			"cron":              bv.CronBatchTime,
+			"disable":           utility.FromBoolPtr(bv.Disable),
+			"patchable":         utility.FromBoolTPtr(bv.Patchable),
+			"patch_only":        utility.FromBoolPtr(bv.PatchOnly),
+			"allow_for_git_tag": utility.FromBoolTPtr(bv.AllowForGitTag),
+			"git_tag_only":      utility.FromBoolPtr(bv.GitTagOnly),
+			"stepback":          utility.FromBoolTPtr(bv.Stepback),
+		}
+		if bv.BatchTime != nil {
+			vars["batchtime"] = *bv.BatchTime
+		}
+		if bv.Activate != nil {
+			vars["activate"] = *bv.Activate
+		}
+		targets = append(targets, policyTarget{
+			location: fmt.Sprintf("build variant '%s'", bv.Name),
+			vars:     vars,
+		})
+	}
+	return targets
+}
+
+func getTaskPolicyTargets(project *model.Project) []policyTarget {
+	tasksByName := project.FindAllTasksMap()
+	targets := []policyTarget{}
+	for _, bvt := range project.FindAllBuildVariantTasks() {
+		bv := project.FindBuildVariant(bvt.Variant)
+		if bv == nil {
+			continue
+		}
+		targets = append(targets, getTaskPolicyTarget(project, *bv, bvt, tasksByName[bvt.Name]))
+	}
+	return targets
+}
+
+func getTaskPolicyTarget(project *model.Project, bv model.BuildVariant, bvt model.BuildVariantTaskUnit, pt model.ProjectTask) policyTarget {
+	runOn := bvt.RunOn
+	if len(runOn) == 0 {
+		runOn = bv.RunOn
+	}
+	execTimeoutSecs := pt.ExecTimeoutSecs
+	if execTimeoutSecs == 0 {
+		execTimeoutSecs = project.ExecTimeoutSecs
+	}
+	if execTimeoutSecs == 0 {
+		execTimeoutSecs = int(agent.DefaultExecTimeout.Seconds())
+	}
+	stepback := project.Stepback
+	if bvt.Stepback != nil {
+		stepback = *bvt.Stepback
+	} else if bv.Stepback != nil {
+		stepback = *bv.Stepback
+	}
+	dependsOn := []string{}
+	for _, d := range bvt.DependsOn {
+		dependsOn = append(dependsOn, d.Name)
+	}
+
+	return policyTarget{
+		location: fmt.Sprintf("task '%s' in build variant '%s'", bvt.Name, bv.Name),
+		vars: map[string]interface{}{
+			"name":               bvt.Name,
+			"variant":            bv.Name,
+			"task_group":         bvt.GroupName,
+			"run_on":             runOn,
+			"tags":               pt.Tags,
+			"depends_on":         dependsOn,
+			"priority":           bvt.Priority,
+			"exec_timeout_secs":  execTimeoutSecs,
+			"stepback":           stepback,
+			"disable":            bvt.IsDisabled(),
+			"patchable":          !bvt.SkipOnPatchBuild(),
+			"patch_only":         bvt.SkipOnNonPatchBuild(),
+			"allow_for_git_tag":  !bvt.SkipOnGitTagBuild(),
+			"git_tag_only":       bvt.SkipOnNonGitTagBuild(),
+			"commit_queue_merge": bvt.CommitQueueMerge,
+			"num_commands":       len(pt.Commands),
+		},
+	}
+}
+
+func getCommandPolicyTargets(project *model.Project) []policyTarget {
+	targets := []policyTarget{}
+	addCommands := func(location string, vars map[string]interface{}, cmds []model.PluginCommandConf) {
+		for i, cmd := range cmds {
+			if cmd.Function != "" {
+				continue
+			}
+			cmdVars := map[string]interface{}{
+				"command":      cmd.Command,
+				"type":         cmd.Type,
+				"display_name": cmd.DisplayName,
+				"timeout_secs": cmd.TimeoutSecs,
+				"variants":     cmd.Variants,
+				"params":       cmd.Params,
+			}
+			for k, v := range vars {
+				cmdVars[k] = v
+			}
+			targets = append(targets, policyTarget{
+				location: fmt.Sprintf("command %d ('%s') in %s", i+1, cmd.Command, location),
+				vars:     cmdVars,
+			})
+		}
+	}
+	addBlock := func(block string, cmds *model.YAMLCommandSet) {
+		if cmds == nil {
+			return
+		}
+		addCommands(block, map[string]interface{}{"block": block}, cmds.List())
+	}
+
+	addBlock("pre", project.Pre)
+	addBlock("post", project.Post)
+	addBlock("timeout", project.Timeout)
+	addBlock("early_termination", project.EarlyTermination)
+
+	funcNames := make([]string, 0, len(project.Functions))
+	for name := range project.Functions {
+		funcNames = append(funcNames, name)
+	}
+	sort.Strings(funcNames)
+	for _, name := range funcNames {
+		if project.Functions[name] == nil {
+			continue
+		}
+		addCommands(fmt.Sprintf("function '%s'", name), map[string]interface{}{"block": "function", "func": name}, project.Functions[name].List())
+	}
+
+	for _, t := range project.Tasks {
+		addCommands(fmt.Sprintf("task '%s'", t.Name), map[string]interface{}{"block": "task", "task": t.Name}, t.Commands)
+	}
+
+	for _, tg := range project.TaskGroups {
+		blocks := map[string]*model.YAMLCommandSet{
+			"setup_group":    tg.SetupGroup,
+			"setup_task":     tg.SetupTask,
+			"teardown_task":  tg.TeardownTask,
+			"teardown_group": tg.TeardownGroup,
+			"timeout":        tg.Timeout,
+		}
+		blockNames := make([]string, 0, len(blocks))
+		for block := range blocks {
+			blockNames = append(blockNames, block)
+		}
+		sort.Strings(blockNames)
+		for _, block := range blockNames {
+			if blocks[block] == nil {
+				continue
+			}
+			addCommands(fmt.Sprintf("%s of task group '%s'", block, tg.Name), map[string]interface{}{"block": block, "task_group": tg.Name}, blocks[block].List())
+		}
+	}
+
+	return targets
+}
@@ -0,0 +1,179 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProjectPolicies(t *testing.T) {
+	makeProject := func() *model.Project {
+		return &model.Project{
+			Identifier: "mci",
+			Tasks: []model.ProjectTask{
+				{
+					Name: "compile",
+					Commands: []model.PluginCommandConf{
+						{Command: "s3.put", Params: map[string]interface{}{"permissions": "public-read"}},
+					},
+				},
+				{
+					Name:            "long",
+					ExecTimeoutSecs: 3600,
+					Commands: []model.PluginCommandConf{
+						{Command: "s3.put", Params: map[string]interface{}{"permissions": "private"}},
+					},
+				},
+			},
+			BuildVariants: []model.BuildVariant{
+				{
+					Name:  "release",
+					RunOn: []string{"release-distro"},
+					Tasks: []model.BuildVariantTaskUnit{
+						{Name: "compile", Variant: "release"},
+						{Name: "long", Variant: "release", Patchable: utility.FalsePtr()},
+					},
+				},
+				{
+					Name:  "ubuntu",
+					RunOn: []string{"ubuntu-distro"},
+					Tasks: []model.BuildVariantTaskUnit{
+						{Name: "compile", Variant: "ubuntu"},
+					},
+				},
+			},
+		}
+	}
+	makeSettings := func(policies ...evergreen.ProjectPolicy) *evergreen.Settings {
+		return &evergreen.Settings{ProjectPolicies: evergreen.ProjectPoliciesConfig{Policies: policies}}
+	}
+	ref := &model.ProjectRef{Id: "mci_id", Identifier: "mci"}
+
+	t.Run("NoPolicies", func(t *testing.T) {
+		assert.Empty(t, CheckProjectPolicies(makeSettings(), makeProject(), ref))
+		assert.Empty(t, CheckProjectPolicies(nil, makeProject(), ref))
+	})
+	t.Run("TaskExecTimeoutUsesDefault", func(t *testing.T) {
+		settings := makeSettings(evergreen.ProjectPolicy{
+			Name:        "max-exec-timeout",
+			Description: "tasks must not run longer than 2 hours",
+			Severity:    evergreen.ProjectPolicySeverityBlock,
+			Target:      evergreen.ProjectPolicyTargetTask,
+			Condition:   "exec_timeout_secs <= 7200",
+		})
+		errs := CheckProjectPolicies(settings, makeProject(), ref)
+		require.Len(t, errs, 2)
+		for _, err := range errs {
+			assert.Equal(t, Error, err.Level)
+			assert.Contains(t, err.Message, "task 'compile'")
+			assert.Contains(t, err.Message, "max-exec-timeout")
+			assert.Contains(t, err.Message, "tasks must not run longer than 2 hours")
+		}
+
+		project := makeProject()
+		project.ExecTimeoutSecs = 7200
+		assert.Empty(t, CheckProjectPolicies(settings, project, ref))
+	})
+	t.Run("TaskWhenSelectsTargets", func(t *testing.T) {
+		settings := makeSettings(evergreen.ProjectPolicy{
+			Name:      "release-not-patchable",
+			Severity:  evergreen.ProjectPolicySeverityWarn,
+			Target:    evergreen.ProjectPolicyTargetTask,
+			When:      "'release-distro' in run_on",
+			Condition: "!patchable",
+		})
+		errs := CheckProjectPolicies(settings, makeProject(), ref)
+		require.Len(t, errs, 1)
+		assert.Equal(t, Warning, errs[0].Level)
+		assert.Contains(t, errs[0].Message, "task 'compile' in build variant 'release'")
+	})
+	t.Run("CommandParams", func(t *testing.T) {
+		settings := makeSettings(evergreen.ProjectPolicy{
+			Name:      "no-public-s3",
+			Severity:  evergreen.ProjectPolicySeverityBlock,
+			Target:    evergreen.ProjectPolicyTargetCommand,
+			When:      "command == 's3.put'",
+			Condition: "params.permissions != 'public-read'",
+		})
+		errs := CheckProjectPolicies(settings, makeProject(), ref)
+		require.Len(t, errs, 1)
+		assert.Equal(t, Error, errs[0].Level)
+		assert.Contains(t, errs[0].Message, "task 'compile'")
+	})
+	t.Run("VariantTarget", func(t *testing.T) {
+		settings := makeSettings(evergreen.ProjectPolicy{
+			Name:      "approved-distros",
+			Severity:  evergreen.ProjectPolicySeverityWarn,
+			Target:    evergreen.ProjectPolicyTargetVariant,
+			Condition: "size(run_on) > 0 && run_on[0] matches '^(release|ubuntu)-'",
+		})
+		assert.Empty(t, CheckProjectPolicies(settings, makeProject(), ref))
+
+		project := makeProject()
+		project.BuildVariants[1].RunOn = []string{"windows"}
+		errs := CheckProjectPolicies(settings, project, ref)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "build variant 'ubuntu'")
+	})
+	t.Run("ScopedToProjects", func(t *testing.T) {
+		policy := evergreen.ProjectPolicy{
+			Name:      "no-stepback",
+			Severity:  evergreen.ProjectPolicySeverityBlock,
+			Target:    evergreen.ProjectPolicyTargetProject,
+			Projects:  []string{"other"},
+			Condition: "stepback",
+		}
+		assert.Empty(t, CheckProjectPolicies(makeSettings(policy), makeProject(), ref))
+
+		policy.Projects = []string{"mci_id"}
+		assert.Len(t, CheckProjectPolicies(makeSettings(policy), makeProject(), ref), 1)
+		assert.Empty(t, CheckProjectPolicies(makeSettings(policy), makeProject(), nil), "scoped policies should not apply without a project ref")
+
+		policy.Projects = nil
+		assert.Len(t, CheckProjectPolicies(makeSettings(policy), makeProject(), nil), 1)
+	})
+	t.Run("EvaluationErrorsUsePolicySeverity", func(t *testing.T) {
+		policy := evergreen.ProjectPolicy{
+			Name:      "bad",
+			Severity:  evergreen.ProjectPolicySeverityBlock,
+			Target:    evergreen.ProjectPolicyTargetProject,
+			Condition: "identifier > 1",
+		}
+		errs := CheckProjectPolicies(makeSettings(policy), makeProject(), ref)
+		require.Len(t, errs, 1)
+		assert.Equal(t, Error, errs[0].Level)
+		assert.Contains(t, errs[0].Message, "could not be checked")
+
+		policy.Severity = evergreen.ProjectPolicySeverityWarn
+		errs = CheckProjectPolicies(makeSettings(policy), makeProject(), ref)
+		require.Len(t, errs, 1)
+		assert.Equal(t, Warning, errs[0].Level)
+	})
+	t.Run("TaskGroupCommandsAreInStableOrder", func(t *testing.T) {
+		project := makeProject()
+		cmds := &model.YAMLCommandSet{MultiCommand: []model.PluginCommandConf{{Command: "shell.exec"}}}
+		project.TaskGroups = []model.TaskGroup{{
+			Name:          "tg",
+			SetupGroup:    cmds,
+			SetupTask:     cmds,
+			TeardownTask:  cmds,
+			TeardownGroup: cmds,
+			Timeout:       cmds,
+		}}
+		settings := makeSettings(evergreen.ProjectPolicy{
+			Name:      "no-shell-exec",
+			Severity:  evergreen.ProjectPolicySeverityBlock,
+			Target:    evergreen.ProjectPolicyTargetCommand,
+			Condition: "command != 'shell.exec'",
+		})
+		errs := CheckProjectPolicies(settings, project, ref)
+		require.Len(t, errs, 5)
+		for i, block := range []string{"setup_group", "setup_task", "teardown_group", "teardown_task", "timeout"} {
+			assert.Contains(t, errs[i].Message, block+" of task group 'tg'")
+		}
+	})
+}
@@ -139,6 +139,7 @@ var projectErrorValidators = []projectValidator{
 	validateHostCreates,
 	validateDuplicateBVTasks,
 	validateGenerateTasks,
+	validateTaskRetryPolicies,
 }
 
 // Functions used to validate the syntax of project configs representing properties found on the project page.
@@ -158,10 +159,20 @@ var projectWarningValidators = []projectValidator{
 	checkBuildVariants,
 }
 
+// Functions used to validate the semantics of a project configuration file
+// that only suggest cleanups, so they're only run when a user validates their
+// configuration rather than every time a version is created.
+var projectCLIWarningValidators = []projectValidator{
+	checkDeadBuildVariants,
+	checkUnusedDefinitions,
+	checkDeprecatedCommands,
+}
+
 var projectSettingsValidators = []projectSettingsValidator{
 	validateTaskSyncSettings,
 	validateVersionControl,
 	validateContainers,
+	validateProjectPolicies,
 }
 
 // These validators have the potential to be very long, and may not be fully run unless specified.
@@ -231,10 +242,58 @@ func CheckProjectWarnings(project *model.Project) ValidationErrors {
 	return validationErrs
 }
 
+// CheckProjectCLIWarnings checks the project configuration for cleanups that
+// are suggested when a user validates their configuration, such as unused
+// definitions and deprecated commands.
+func CheckProjectCLIWarnings(project *model.Project) ValidationErrors {
+	validationErrs := ValidationErrors{}
+	for _, projectWarningValidator := range projectCLIWarningValidators {
+		validationErrs = append(validationErrs,
+			projectWarningValidator(project)...)
+	}
+	return validationErrs
+}
+
 func CheckAliasWarnings(project *model.Project, aliases model.ProjectAliases) ValidationErrors {
 	return validateAliasCoverage(project, aliases)
 }
 
+// CheckLocalProjectErrors is like CheckProjectErrors, but it skips the checks
+// that require the database. Since the distros can't be looked up, every name
+// in run_on that isn't a container is assumed to be a valid distro. It's meant
+// for tools that validate a project on the user's machine.
+func CheckLocalProjectErrors(project *model.Project) ValidationErrors {
+	validationErrs := ValidationErrors{}
+	for _, projectErrorValidator := range projectErrorValidators {
+		validationErrs = append(validationErrs,
+			projectErrorValidator(project)...)
+	}
+
+	containerNameMap := map[string]bool{}
+	for _, container := range project.Containers {
+		if containerNameMap[container.Name] {
+			validationErrs = append(validationErrs, ValidationError{Message: fmt.Sprintf("container '%s' is defined multiple times", container.Name)})
+		}
+		containerNameMap[container.Name] = true
+	}
+	distroIDs := []string{}
+	addDistros := func(runOn []string) {
+		for _, name := range runOn {
+			if !containerNameMap[name] {
+				distroIDs = append(distroIDs, name)
+			}
+		}
+	}
+	for _, bv := range project.BuildVariants {
+		addDistros(bv.RunOn)
+		for _, bvtu := range bv.Tasks {
+			addDistros(bvtu.RunOn)
+		}
+	}
+	validationErrs = append(validationErrs, ensureReferentialIntegrity(project, containerNameMap, distroIDs, nil)...)
+	return validationErrs
+}
+
 // verify that the project configuration syntax is valid
 func CheckProjectErrors(ctx context.Context, project *model.Project, includeLong bool) ValidationErrors {
 	validationErrs := ValidationErrors{}
@@ -1554,6 +1613,68 @@ func validateParameters(p *model.Project) ValidationErrors {
 	return errs
 }
 
+// validateTaskRetryPolicies checks that all task retry policies defined on
+// tasks and build variant tasks are valid. Execution tasks and tasks in
+// single-host task groups can only be restarted along with their display task
+// or task group, so they can't have retry policies.
+func validateTaskRetryPolicies(p *model.Project) ValidationErrors {
+	errs := ValidationErrors{}
+	taskPolicies := map[string]*task.RetryPolicy{}
+	for _, t := range p.Tasks {
+		if t.RetryPolicy == nil {
+			continue
+		}
+		taskPolicies[t.Name] = t.RetryPolicy
+		if err := t.RetryPolicy.Validate(); err != nil {
+			errs = append(errs, ValidationError{
+				Level:   Error,
+				Message: errors.Wrapf(err, "invalid retry policy for task '%s'", t.Name).Error(),
+			})
+		}
+	}
+	for _, bv := range p.BuildVariants {
+		execTasks := map[string]string{}
+		for _, dt := range bv.DisplayTasks {
+			for _, et := range dt.ExecTasks {
+				execTasks[et] = dt.Name
+			}
+		}
+		for _, bvtu := range bv.Tasks {
+			if tg := p.FindTaskGroup(bvtu.Name); tg != nil && tg.MaxHosts <= 1 {
+				for _, tgTask := range tg.Tasks {
+					if bvtu.RetryPolicy != nil || taskPolicies[tgTask] != nil {
+						errs = append(errs, ValidationError{
+							Level:   Error,
+							Message: fmt.Sprintf("task '%s' in build variant '%s' cannot have a retry policy because it is part of single-host task group '%s'", tgTask, bv.Name, tg.Name),
+						})
+					}
+				}
+			}
+			if bvtu.RetryPolicy == nil {
+				continue
+			}
+			if displayTask, ok := execTasks[bvtu.Name]; ok {
+				errs = append(errs, ValidationError{
+					Level:   Error,
+					Message: fmt.Sprintf("task '%s' in build variant '%s' cannot have a retry policy because it is part of display task '%s'", bvtu.Name, bv.Name, displayTask),
+				})
+			}
+			// Build variant tasks inherit the task's policy if they don't
+			// override it, which has already been checked.
+			if bvtu.RetryPolicy == taskPolicies[bvtu.Name] {
+				continue
+			}
+			if err := bvtu.RetryPolicy.Validate(); err != nil {
+				errs = append(errs, ValidationError{
+					Level:   Error,
+					Message: errors.Wrapf(err, "invalid retry policy for task '%s' in build variant '%s'", bvtu.Name, bv.Name).Error(),
+				})
+			}
+		}
+	}
+	return errs
+}
+
 func validateTaskGroups(p *model.Project) ValidationErrors {
 	errs := ValidationErrors{}
 	taskGroups := p.TaskGroups
@@ -2213,3 +2334,229 @@ func checkBuildVariants(project *model.Project) ValidationErrors {
 	}
 	return errs
 }
+
+// checkDeadBuildVariants checks for build variants that list tasks but will
+// never run any of them.
+func checkDeadBuildVariants(project *model.Project) ValidationErrors {
+	errs := ValidationErrors{}
+	tasksByVariant := map[string][]model.BuildVariantTaskUnit{}
+	for _, bvtu := range project.FindAllBuildVariantTasks() {
+		tasksByVariant[bvtu.Variant] = append(tasksByVariant[bvtu.Variant], bvtu)
+	}
+	for _, bv := range project.BuildVariants {
+		if len(bv.Tasks) == 0 {
+			continue
+		}
+		if utility.FromBoolPtr(bv.Disable) {
+			errs = append(errs, ValidationError{
+				Level:   Warning,
+				Message: fmt.Sprintf("buildvariant '%s' is disabled and will never run any tasks", bv.Name),
+			})
+			continue
+		}
+		dead := true
+		for _, bvtu := range tasksByVariant[bv.Name] {
+			if !isDeadTask(bvtu) {
+				dead = false
+				break
+			}
+		}
+		if dead {
+			errs = append(errs, ValidationError{
+				Level:   Warning,
+				Message: fmt.Sprintf("buildvariant '%s' will never run any tasks because all of its tasks are disabled or can never run", bv.Name),
+			})
+		}
+	}
+	return errs
+}
+
+// isDeadTask returns whether the build variant task can never run.
+func isDeadTask(bvtu model.BuildVariantTaskUnit) bool {
+	return bvtu.IsDisabled() ||
+		(bvtu.SkipOnPatchBuild() && bvtu.SkipOnNonPatchBuild()) ||
+		(bvtu.SkipOnGitTagBuild() && bvtu.SkipOnNonGitTagBuild()) ||
+		(bvtu.SkipOnNonGitTagBuild() && bvtu.SkipOnNonPatchBuild())
+}
+
+// checkUnusedDefinitions checks for functions that are never called and tasks
+// that are never run by any build variant.
+func checkUnusedDefinitions(project *model.Project) ValidationErrors {
+	errs := ValidationErrors{}
+	for _, name := range findUnusedFunctions(project) {
+		errs = append(errs, ValidationError{
+			Level:   Warning,
+			Message: fmt.Sprintf("function '%s' is never called", name),
+		})
+	}
+	for _, name := range findUnusedTasks(project) {
+		errs = append(errs, ValidationError{
+			Level:   Warning,
+			Message: fmt.Sprintf("task '%s' is not listed in any buildvariant or task group", name),
+		})
+	}
+	return errs
+}
+
+// checkDeprecatedCommands checks for commands that are deprecated.
+func checkDeprecatedCommands(project *model.Project) ValidationErrors {
+	errs := ValidationErrors{}
+	seen := map[string]bool{}
+	for _, cmds := range getProjectCommandBlocks(project, true) {
+		for _, cmd := range cmds {
+			replacement, ok := command.DeprecatedCommands[cmd.Command]
+			if !ok || seen[cmd.Command] {
+				continue
+			}
+			seen[cmd.Command] = true
+			msg := fmt.Sprintf("command '%s' is deprecated and has no effect; it can be removed", cmd.Command)
+			if replacement != "" {
+				msg = fmt.Sprintf("command '%s' is deprecated; use '%s' instead", cmd.Command, replacement)
+			}
+			errs = append(errs, ValidationError{Level: Warning, Message: msg})
+		}
+	}
+	return errs
+}
+
+// findUnusedFunctions returns the sorted names of the functions that are not
+// called anywhere in the project. Since generated tasks can call any
+// function, no functions are considered unused if the project generates
+// tasks. A project without tasks is assumed to be a partial configuration
+// that's included elsewhere, so its functions are not considered unused either.
+func findUnusedFunctions(project *model.Project) []string {
+	if len(project.Functions) == 0 || len(project.Tasks) == 0 || len(project.TasksThatCallCommand(evergreen.GenerateTasksCommandName)) > 0 {
+		return nil
+	}
+	called := map[string]bool{}
+	for _, cmds := range getProjectCommandBlocks(project, false) {
+		for _, cmd := range cmds {
+			if cmd.Function != "" {
+				called[cmd.Function] = true
+			}
+		}
+	}
+	unused := []string{}
+	for name := range project.Functions {
+		if !called[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// findUnusedTasks returns the names of the tasks that are not listed in any
+// build variant or task group. Since generated tasks can add any existing
+// task to a build variant, no tasks are considered unused if the project
+// generates tasks. A project without build variants is assumed to be a partial
+// configuration that's included elsewhere, so its tasks are not considered
+// unused either.
+func findUnusedTasks(project *model.Project) []string {
+	if len(project.BuildVariants) == 0 || len(project.TasksThatCallCommand(evergreen.GenerateTasksCommandName)) > 0 {
+		return nil
+	}
+	used := map[string]bool{}
+	for _, tg := range project.TaskGroups {
+		for _, t := range tg.Tasks {
+			used[t] = true
+		}
+	}
+	for _, bv := range project.BuildVariants {
+		for _, bvtu := range bv.Tasks {
+			used[bvtu.Name] = true
+			if bvtu.TaskGroup != nil {
+				for _, t := range bvtu.TaskGroup.Tasks {
+					used[t] = true
+				}
+			}
+		}
+	}
+	unused := []string{}
+	for _, t := range project.Tasks {
+		if !used[t.Name] {
+			unused = append(unused, t.Name)
+		}
+	}
+	return unused
+}
+
+// getProjectCommandBlocks returns all the lists of commands in the project,
+// optionally including the function definitions.
+func getProjectCommandBlocks(project *model.Project, includeFunctions bool) [][]model.PluginCommandConf {
+	blocks := [][]model.PluginCommandConf{}
+	addBlock := func(cmds *model.YAMLCommandSet) {
+		if cmds != nil {
+			blocks = append(blocks, cmds.List())
+		}
+	}
+	addTaskGroup := func(tg *model.TaskGroup) {
+		addBlock(tg.SetupGroup)
+		addBlock(tg.SetupTask)
+		addBlock(tg.TeardownTask)
+		addBlock(tg.TeardownGroup)
+		addBlock(tg.Timeout)
+	}
+
+	addBlock(project.Pre)
+	addBlock(project.Post)
+	addBlock(project.Timeout)
+	addBlock(project.EarlyTermination)
+	for _, t := range project.Tasks {
+		blocks = append(blocks, t.Commands)
+	}
+	for i := range project.TaskGroups {
+		addTaskGroup(&project.TaskGroups[i])
+	}
+	for _, bv := range project.BuildVariants {
+		for _, bvtu := range bv.Tasks {
+			if bvtu.TaskGroup != nil {
+				addTaskGroup(bvtu.TaskGroup)
+			}
+		}
+	}
+	if includeFunctions {
+		for _, cmds := range project.Functions {
+			addBlock(cmds)
+		}
+	}
+	return blocks
+}
+
+// LoadProjectIntoWithValidation loads the project configuration and returns
+// a warning (instead of an error) if there's an error with unmarshalling
+// strictly.
+func LoadProjectIntoWithValidation(ctx context.Context, data []byte, opts *model.GetProjectOpts,
+	project *model.Project) (*model.ParserProject, *model.ProjectConfig, ValidationErrors) {
+	errs := ValidationErrors{}
+	// We validate the project config regardless if version control is disabled for the project
+	// to ensure that the config will remain valid if it is turned on.
+	pc, err := model.CreateProjectConfig(data, "")
+	if err != nil {
+		errs = append(errs, ValidationError{
+			Level:   Error,
+			Message: err.Error(),
+		})
+	}
+	pp, err := model.LoadProjectInto(ctx, data, opts, "", project)
+	if err != nil {
+		// If the error came from unmarshalling strict, try it again without strict to verify if
+		// it's a legitimate unmarshal error or just an error from strict (which should be a warning)
+		if strings.Contains(err.Error(), util.UnmarshalStrictError) {
+			opts.UnmarshalStrict = false
+			pp, err2 := model.LoadProjectInto(ctx, data, opts, "", project)
+			if err2 == nil {
+				errs = append(errs, ValidationError{
+					Level:   Warning,
+					Message: errors.Wrap(err, "strict unmarshalling YAML").Error(),
+				})
+				return pp, pc, errs
+			}
+		}
+		errs = append(errs, ValidationError{
+			Level:   Error,
+			Message: err.Error(),
+		})
+	}
+	return pp, pc, errs
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/evergreen-ci/evergreen"
@@ -12,6 +13,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/task"
 	_ "github.com/evergreen-ci/evergreen/plugin"
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/utility"
@@ -4925,6 +4927,102 @@ func TestParseS3PullParameters(t *testing.T) {
 	}
 }
 
+func TestValidateTaskRetryPolicies(t *testing.T) {
+	t.Run("ValidPolicies", func(t *testing.T) {
+		policy := &task.RetryPolicy{MaxAttempts: 3, FailureTypes: []string{evergreen.CommandTypeSystem}}
+		p := &model.Project{
+			Tasks: []model.ProjectTask{{Name: "t1", RetryPolicy: policy}},
+			BuildVariants: []model.BuildVariant{
+				{Name: "bv1", Tasks: []model.BuildVariantTaskUnit{{Name: "t1", RetryPolicy: policy}}},
+			},
+		}
+		assert.Empty(t, validateTaskRetryPolicies(p))
+	})
+	t.Run("InvalidTaskPolicy", func(t *testing.T) {
+		p := &model.Project{
+			Tasks: []model.ProjectTask{{Name: "t1", RetryPolicy: &task.RetryPolicy{MaxAttempts: 1}}},
+		}
+		errs := validateTaskRetryPolicies(p)
+		require.Len(t, errs, 1)
+		assert.Equal(t, Error, errs[0].Level)
+		assert.Contains(t, errs[0].Message, "task 't1'")
+	})
+	t.Run("InvalidBuildVariantOverride", func(t *testing.T) {
+		p := &model.Project{
+			Tasks: []model.ProjectTask{{Name: "t1"}},
+			BuildVariants: []model.BuildVariant{
+				{Name: "bv1", Tasks: []model.BuildVariantTaskUnit{{Name: "t1", RetryPolicy: &task.RetryPolicy{
+					MaxAttempts:        2,
+					FailureTypes:       []string{"bogus"},
+					TestFailureRegexes: []string{"("},
+				}}}},
+			},
+		}
+		errs := validateTaskRetryPolicies(p)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "build variant 'bv1'")
+		assert.Contains(t, errs[0].Message, "invalid failure type 'bogus'")
+		assert.Contains(t, errs[0].Message, "invalid test failure regex '('")
+	})
+	t.Run("PolicyOnExecutionTask", func(t *testing.T) {
+		policy := &task.RetryPolicy{MaxAttempts: 2}
+		p := &model.Project{
+			Tasks: []model.ProjectTask{{Name: "t1", RetryPolicy: policy}},
+			BuildVariants: []model.BuildVariant{
+				{
+					Name:         "bv1",
+					Tasks:        []model.BuildVariantTaskUnit{{Name: "t1", RetryPolicy: policy}},
+					DisplayTasks: []patch.DisplayTask{{Name: "dt", ExecTasks: []string{"t1"}}},
+				},
+			},
+		}
+		errs := validateTaskRetryPolicies(p)
+		require.Len(t, errs, 1)
+		assert.Equal(t, Error, errs[0].Level)
+		assert.Contains(t, errs[0].Message, "display task 'dt'")
+	})
+	t.Run("PolicyOnSingleHostTaskGroup", func(t *testing.T) {
+		policy := &task.RetryPolicy{MaxAttempts: 2}
+		p := &model.Project{
+			Tasks:      []model.ProjectTask{{Name: "t1"}, {Name: "t2"}},
+			TaskGroups: []model.TaskGroup{{Name: "tg", MaxHosts: 1, Tasks: []string{"t1", "t2"}}},
+			BuildVariants: []model.BuildVariant{
+				{Name: "bv1", Tasks: []model.BuildVariantTaskUnit{{Name: "tg", RetryPolicy: policy}}},
+			},
+		}
+		errs := validateTaskRetryPolicies(p)
+		require.Len(t, errs, 2)
+		for _, err := range errs {
+			assert.Equal(t, Error, err.Level)
+			assert.Contains(t, err.Message, "single-host task group 'tg'")
+		}
+	})
+	t.Run("PolicyOnTaskInSingleHostTaskGroup", func(t *testing.T) {
+		policy := &task.RetryPolicy{MaxAttempts: 2}
+		p := &model.Project{
+			Tasks:      []model.ProjectTask{{Name: "t1", RetryPolicy: policy}, {Name: "t2"}},
+			TaskGroups: []model.TaskGroup{{Name: "tg", MaxHosts: 1, Tasks: []string{"t1", "t2"}}},
+			BuildVariants: []model.BuildVariant{
+				{Name: "bv1", Tasks: []model.BuildVariantTaskUnit{{Name: "tg"}}},
+			},
+		}
+		errs := validateTaskRetryPolicies(p)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "task 't1'")
+	})
+	t.Run("PolicyOnMultiHostTaskGroup", func(t *testing.T) {
+		policy := &task.RetryPolicy{MaxAttempts: 2}
+		p := &model.Project{
+			Tasks:      []model.ProjectTask{{Name: "t1", RetryPolicy: policy}, {Name: "t2"}},
+			TaskGroups: []model.TaskGroup{{Name: "tg", MaxHosts: 2, Tasks: []string{"t1", "t2"}}},
+			BuildVariants: []model.BuildVariant{
+				{Name: "bv1", Tasks: []model.BuildVariantTaskUnit{{Name: "tg", RetryPolicy: policy}}},
+			},
+		}
+		assert.Empty(t, validateTaskRetryPolicies(p))
+	})
+}
+
 func TestValidateTaskGroupsInBV(t *testing.T) {
 	tests := map[string]struct {
 		project        model.Project
@@ -5954,3 +6052,34 @@ func TestValidationErrorsAtLevel(t *testing.T) {
 		assert.Empty(t, errs.AtLevel(Error))
 	})
 }
+
+func TestCheckLocalProjectErrors(t *testing.T) {
+	t.Run("AcceptsAnyDistro", func(t *testing.T) {
+		project := &model.Project{
+			Tasks: []model.ProjectTask{{Name: "t", Commands: []model.PluginCommandConf{{Command: "shell.exec", Params: map[string]interface{}{"script": "echo"}}}}},
+			BuildVariants: []model.BuildVariant{{
+				Name:        "bv",
+				DisplayName: "BV",
+				RunOn:       []string{"nonexistent"},
+				Tasks:       []model.BuildVariantTaskUnit{{Name: "t", Variant: "bv"}},
+			}},
+		}
+		assert.Empty(t, CheckLocalProjectErrors(project).AtLevel(Error))
+	})
+	t.Run("FindsUndefinedTasks", func(t *testing.T) {
+		project := &model.Project{
+			BuildVariants: []model.BuildVariant{{
+				Name:  "bv",
+				RunOn: []string{"d"},
+				Tasks: []model.BuildVariantTaskUnit{{Name: "missing", Variant: "bv"}},
+			}},
+		}
+		errs := CheckLocalProjectErrors(project).AtLevel(Error)
+		require.NotEmpty(t, errs)
+		found := false
+		for _, err := range errs {
+			found = found || strings.Contains(err.Message, "'missing'")
+		}
+		assert.True(t, found)
+	})
+}